@@ -41,6 +41,12 @@ func GetCellIdx(col int, row int) (string, error) {
 	return excelize.CoordinatesToCellName(col+colStartIdx, row+rowStartIdx)
 }
 
+// GetCellSqref get the sqref of a single cell
+// Example: GetCellSqref(0, 1) // return A2:A2
+func GetCellSqref(col, row int) (string, error) {
+	return GetSingleColSqref(col, row+rowStartIdx, row+rowStartIdx)
+}
+
 // GetSingleColSqref get single column sqref
 // Example: GetSingleColSqref(0, 1, 2) // return A1:A2
 func GetSingleColSqref(col, startRow, endRow int) (string, error) {
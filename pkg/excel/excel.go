@@ -116,6 +116,14 @@ func KeepDefaultSheet() OperatorFunc {
 	}
 }
 
+// GetFilePath get the file path of the excel
+func (excel *Excel) GetFilePath() string {
+	excel.RLock()
+	defer excel.RUnlock()
+
+	return excel.filePath
+}
+
 // CreateSheet create a new sheet
 func (excel *Excel) CreateSheet(sheet string) error {
 	excel.Lock()
@@ -396,6 +404,40 @@ func (excel *Excel) NewStyle(style *Style) (int, error) {
 	return excel.file.NewStyle(excelStyle)
 }
 
+// SetCellValue set the value of a cell, it can not be used on the sheet that is being written by the StreamWriter
+func (excel *Excel) SetCellValue(sheet string, col, row int, value interface{}) error {
+	cell, err := GetCellIdx(col, row)
+	if err != nil {
+		return err
+	}
+
+	excel.Lock()
+	defer excel.Unlock()
+
+	if excel.file == nil {
+		return fmt.Errorf("excel file has not been created yet")
+	}
+
+	return excel.file.SetCellValue(sheet, cell, value)
+}
+
+// SetCellStyle set the style of a cell, it can not be used on the sheet that is being written by the StreamWriter
+func (excel *Excel) SetCellStyle(sheet string, col, row, styleID int) error {
+	cell, err := GetCellIdx(col, row)
+	if err != nil {
+		return err
+	}
+
+	excel.Lock()
+	defer excel.Unlock()
+
+	if excel.file == nil {
+		return fmt.Errorf("excel file has not been created yet")
+	}
+
+	return excel.file.SetCellStyle(sheet, cell, cell, styleID)
+}
+
 // oneCellLen 1个单元格的长度
 const oneCellLen = 1
 
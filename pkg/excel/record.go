@@ -0,0 +1,382 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package excel
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Format the file format of bulk import and export
+type Format string
+
+const (
+	// FormatXlsx excel file
+	FormatXlsx Format = "xlsx"
+	// FormatCSV csv file, the first line is the header of the record keys
+	FormatCSV Format = "csv"
+	// FormatJSONL json lines file, each line is a json object
+	FormatJSONL Format = "jsonl"
+)
+
+// IsRecordFormat returns if the format is a record format, which is a stream of records instead of a workbook
+func IsRecordFormat(format Format) bool {
+	return format == FormatCSV || format == FormatJSONL
+}
+
+// RecordWriter writes records in stream, a record is a map of key and value
+type RecordWriter interface {
+	// WriteHeader write the keys of the records, it must be called before Write
+	WriteHeader(keys []string) error
+	// Write write a record, the keys that are not in the header are ignored by the csv writer
+	Write(record map[string]interface{}) error
+	// Flush flush the buffered records to the underlying writer
+	Flush() error
+	// WriteError write a trailing error record and flush it, which marks the written records as incomplete, the
+	// reader fails when it reads the error record
+	WriteError(err error) error
+}
+
+// recordErrorKey is the key of the trailing error record, it is the first cell of the error line of csv file
+const recordErrorKey = "#bk_cmdb_export_error"
+
+// NewRecordWriter create a record writer of the format
+func NewRecordWriter(format Format, w io.Writer) (RecordWriter, error) {
+	switch format {
+	case FormatCSV:
+		return &csvWriter{w: w, writer: csv.NewWriter(w)}, nil
+	case FormatJSONL:
+		buf := bufio.NewWriter(w)
+		return &jsonlWriter{buf: buf, encoder: json.NewEncoder(buf)}, nil
+	default:
+		return nil, fmt.Errorf("record format %s is not supported", format)
+	}
+}
+
+// utf8BOM is written at the beginning of the csv file, so that excel can recognize it as utf-8 encoded
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+type csvWriter struct {
+	w      io.Writer
+	writer *csv.Writer
+	keys   []string
+}
+
+// WriteHeader write the keys of the records as the first line, csvEscapedMarker is appended to the keys to mark the
+// cells of the file as escaped
+func (c *csvWriter) WriteHeader(keys []string) error {
+	if _, err := c.w.Write(utf8BOM); err != nil {
+		return err
+	}
+
+	c.keys = keys
+	header := make([]string, 0, len(keys)+1)
+	header = append(header, keys...)
+	return c.writer.Write(append(header, csvEscapedMarker))
+}
+
+// csvEscapePrefix is prefixed to the csv cells that start with one of csvFormulaChars, so that they are not evaluated
+// as formulas when the csv file is opened by spreadsheet applications. the cells that start with the prefix itself are
+// also prefixed, so that the reader can always strip the first prefix and get the original value. the reader only
+// strips the prefix of the files with csvEscapedMarker as the last header key, which are exported by cmdb, the cells
+// of the other csv files are read as they are
+const (
+	csvEscapePrefix  = "'"
+	csvFormulaChars  = "=+-@\t\r"
+	csvEscapedMarker = "#bk_cmdb_escaped"
+)
+
+// escapeCSVCell prefix the cell that may be evaluated as a formula with csvEscapePrefix
+func escapeCSVCell(val string) string {
+	if val == "" {
+		return val
+	}
+
+	if strings.ContainsRune(csvFormulaChars, rune(val[0])) || strings.HasPrefix(val, csvEscapePrefix) {
+		return csvEscapePrefix + val
+	}
+	return val
+}
+
+// Write write a record as a csv line, the values that are not strings are converted to strings or json
+func (c *csvWriter) Write(record map[string]interface{}) error {
+	line := make([]string, len(c.keys))
+	for idx, key := range c.keys {
+		val, err := RecordValueToString(record[key])
+		if err != nil {
+			return fmt.Errorf("convert value of %s failed, err: %v", key, err)
+		}
+		line[idx] = escapeCSVCell(val)
+	}
+
+	return c.writer.Write(line)
+}
+
+// Flush flush the buffered lines
+func (c *csvWriter) Flush() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+// WriteError write the error line whose first cell is recordErrorKey and the second cell is the error message
+func (c *csvWriter) WriteError(err error) error {
+	if wErr := c.writer.Write([]string{recordErrorKey, escapeCSVCell(err.Error())}); wErr != nil {
+		return wErr
+	}
+	return c.Flush()
+}
+
+type jsonlWriter struct {
+	buf     *bufio.Writer
+	encoder *json.Encoder
+}
+
+// WriteHeader json lines file has no header
+func (j *jsonlWriter) WriteHeader(_ []string) error {
+	return nil
+}
+
+// Write write a record as a json line, the nil values are omitted
+func (j *jsonlWriter) Write(record map[string]interface{}) error {
+	data := make(map[string]interface{}, len(record))
+	for key, val := range record {
+		if val != nil {
+			data[key] = val
+		}
+	}
+
+	return j.encoder.Encode(data)
+}
+
+// Flush flush the buffered lines
+func (j *jsonlWriter) Flush() error {
+	return j.buf.Flush()
+}
+
+// WriteError write the error line whose only key is recordErrorKey and the value is the error message
+func (j *jsonlWriter) WriteError(err error) error {
+	if wErr := j.encoder.Encode(map[string]string{recordErrorKey: err.Error()}); wErr != nil {
+		return wErr
+	}
+	return j.Flush()
+}
+
+// RecordValueToString convert a record value to string, the value that is not a scalar is converted to json
+func RecordValueToString(val interface{}) (string, error) {
+	switch v := val.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	default:
+		js, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(js), nil
+	}
+}
+
+// RecordReader reads records in stream
+type RecordReader interface {
+	// Next read the next record, returns false if there is no more record or an error occurs
+	Next() bool
+	// Record returns the current record, the empty values are omitted, the values of csv records are strings,
+	// the numbers of json lines records are json.Number
+	Record() map[string]interface{}
+	// Row returns the row number of the current record, which starts from 1 and includes the csv header
+	Row() int
+	// Err returns the error that occurs when reading
+	Err() error
+}
+
+// NewRecordReader create a record reader of the format
+func NewRecordReader(format Format, r io.Reader) (RecordReader, error) {
+	switch format {
+	case FormatCSV:
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		return &csvReader{reader: reader}, nil
+	case FormatJSONL:
+		return &jsonlReader{reader: bufio.NewReader(r)}, nil
+	default:
+		return nil, fmt.Errorf("record format %s is not supported", format)
+	}
+}
+
+type csvReader struct {
+	reader *csv.Reader
+	keys   []string
+	// escaped the file is exported by cmdb, whose cells are escaped
+	escaped bool
+	record  map[string]interface{}
+	row     int
+	err     error
+}
+
+// Next read the next csv line, the first line is read as the header, the escape prefix of the cells is stripped if
+// the file is exported by cmdb
+func (c *csvReader) Next() bool {
+	if c.err != nil {
+		return false
+	}
+
+	for {
+		line, err := c.reader.Read()
+		if err != nil {
+			if err != io.EOF {
+				c.err = err
+			}
+			return false
+		}
+		c.row++
+
+		if c.keys == nil {
+			if len(line) > 0 {
+				line[0] = string(bytes.TrimPrefix([]byte(line[0]), utf8BOM))
+			}
+			if len(line) > 0 && line[len(line)-1] == csvEscapedMarker {
+				c.escaped = true
+				line = line[:len(line)-1]
+			}
+			c.keys = line
+			continue
+		}
+
+		if c.escaped && len(line) > 0 && line[0] == recordErrorKey {
+			msg := ""
+			if len(line) > 1 {
+				msg = strings.TrimPrefix(line[1], csvEscapePrefix)
+			}
+			c.err = fmt.Errorf("line %d: the file is incomplete as the export failed, err: %s", c.row, msg)
+			return false
+		}
+
+		c.record = make(map[string]interface{})
+		for idx, val := range line {
+			if idx >= len(c.keys) || val == "" {
+				continue
+			}
+			if c.escaped {
+				val = strings.TrimPrefix(val, csvEscapePrefix)
+			}
+			c.record[c.keys[idx]] = val
+		}
+		return true
+	}
+}
+
+// Record returns the current record
+func (c *csvReader) Record() map[string]interface{} {
+	return c.record
+}
+
+// Row returns the row number of the current record
+func (c *csvReader) Row() int {
+	return c.row
+}
+
+// Err returns the error that occurs when reading
+func (c *csvReader) Err() error {
+	return c.err
+}
+
+type jsonlReader struct {
+	reader *bufio.Reader
+	record map[string]interface{}
+	row    int
+	err    error
+}
+
+// Next read the next json line, the blank lines are skipped
+func (j *jsonlReader) Next() bool {
+	if j.err != nil {
+		return false
+	}
+
+	for {
+		line, err := j.reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			j.err = err
+			return false
+		}
+		if len(line) == 0 && err == io.EOF {
+			return false
+		}
+		j.row++
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			if err == io.EOF {
+				return false
+			}
+			continue
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.UseNumber()
+		record := make(map[string]interface{})
+		if err := decoder.Decode(&record); err != nil {
+			j.err = fmt.Errorf("line %d is not a valid json object, err: %v", j.row, err)
+			return false
+		}
+
+		if msg, exists := record[recordErrorKey]; exists {
+			j.err = fmt.Errorf("line %d: the file is incomplete as the export failed, err: %v", j.row, msg)
+			return false
+		}
+
+		j.record = make(map[string]interface{}, len(record))
+		for key, val := range record {
+			if val != nil {
+				j.record[key] = val
+			}
+		}
+		return true
+	}
+}
+
+// Record returns the current record
+func (j *jsonlReader) Record() map[string]interface{} {
+	return j.record
+}
+
+// Row returns the line number of the current record
+func (j *jsonlReader) Row() int {
+	return j.row
+}
+
+// Err returns the error that occurs when reading
+func (j *jsonlReader) Err() error {
+	return j.err
+}
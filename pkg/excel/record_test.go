@@ -0,0 +1,151 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package excel
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSVRecord(t *testing.T) {
+	buf := new(bytes.Buffer)
+	writer, err := NewRecordWriter(FormatCSV, buf)
+	require.NoError(t, err)
+
+	require.NoError(t, writer.WriteHeader([]string{"name", "count", "tags"}))
+	require.NoError(t, writer.Write(map[string]interface{}{"name": "a,b", "count": int64(3),
+		"tags": []map[string]interface{}{{"k": "v"}}}))
+	require.NoError(t, writer.Write(map[string]interface{}{"name": "c"}))
+	require.NoError(t, writer.Flush())
+	require.True(t, bytes.HasPrefix(buf.Bytes(), utf8BOM))
+
+	reader, err := NewRecordReader(FormatCSV, buf)
+	require.NoError(t, err)
+
+	require.True(t, reader.Next())
+	require.Equal(t, 2, reader.Row())
+	require.Equal(t, map[string]interface{}{"name": "a,b", "count": "3", "tags": `[{"k":"v"}]`}, reader.Record())
+
+	require.True(t, reader.Next())
+	require.Equal(t, 3, reader.Row())
+	require.Equal(t, map[string]interface{}{"name": "c"}, reader.Record())
+
+	require.False(t, reader.Next())
+	require.NoError(t, reader.Err())
+}
+
+func TestCSVRecordEscape(t *testing.T) {
+	buf := new(bytes.Buffer)
+	writer, err := NewRecordWriter(FormatCSV, buf)
+	require.NoError(t, err)
+
+	values := []string{"=1+1", "+86", "-5", "@SUM(A1)", "'quoted", "a=b"}
+	keys := make([]string, len(values))
+	record := make(map[string]interface{})
+	for idx, val := range values {
+		keys[idx] = string(rune('a' + idx))
+		record[keys[idx]] = val
+	}
+	require.NoError(t, writer.WriteHeader(keys))
+	require.NoError(t, writer.Write(record))
+	require.NoError(t, writer.Flush())
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+	require.Equal(t, `'=1+1,'+86,'-5,'@SUM(A1),''quoted,a=b`, string(lines[1]))
+
+	reader, err := NewRecordReader(FormatCSV, buf)
+	require.NoError(t, err)
+	require.True(t, reader.Next())
+	require.Equal(t, record, reader.Record())
+
+	// the cells that start with tab or carriage return are escaped too
+	buf.Reset()
+	writer, err = NewRecordWriter(FormatCSV, buf)
+	require.NoError(t, err)
+	record = map[string]interface{}{"a": "\tcmd", "b": "\r=1"}
+	require.NoError(t, writer.WriteHeader([]string{"a", "b"}))
+	require.NoError(t, writer.Write(record))
+	require.NoError(t, writer.Flush())
+	require.Contains(t, buf.String(), "'\tcmd")
+
+	reader, err = NewRecordReader(FormatCSV, buf)
+	require.NoError(t, err)
+	require.True(t, reader.Next())
+	require.Equal(t, record, reader.Record())
+
+	// the csv file that is not exported by cmdb is read as it is
+	reader, err = NewRecordReader(FormatCSV, bytes.NewBufferString("name,remark\n'a,'b\n"))
+	require.NoError(t, err)
+	require.True(t, reader.Next())
+	require.Equal(t, map[string]interface{}{"name": "'a", "remark": "'b"}, reader.Record())
+}
+
+func TestRecordWriteError(t *testing.T) {
+	for _, format := range []Format{FormatCSV, FormatJSONL} {
+		buf := new(bytes.Buffer)
+		writer, err := NewRecordWriter(format, buf)
+		require.NoError(t, err)
+
+		require.NoError(t, writer.WriteHeader([]string{"name"}))
+		require.NoError(t, writer.Write(map[string]interface{}{"name": "a"}))
+		require.NoError(t, writer.WriteError(errors.New("search instances failed")))
+
+		reader, err := NewRecordReader(format, buf)
+		require.NoError(t, err)
+		require.True(t, reader.Next())
+		require.Equal(t, map[string]interface{}{"name": "a"}, reader.Record())
+
+		require.False(t, reader.Next())
+		require.ErrorContains(t, reader.Err(), "search instances failed")
+	}
+}
+
+func TestJSONLRecord(t *testing.T) {
+	buf := new(bytes.Buffer)
+	writer, err := NewRecordWriter(FormatJSONL, buf)
+	require.NoError(t, err)
+
+	require.NoError(t, writer.WriteHeader([]string{"name", "count"}))
+	require.NoError(t, writer.Write(map[string]interface{}{"name": "a", "count": int64(3), "empty": nil}))
+	require.NoError(t, writer.Flush())
+	buf.WriteString("\n{\"name\": \"b\"}")
+
+	reader, err := NewRecordReader(FormatJSONL, buf)
+	require.NoError(t, err)
+
+	require.True(t, reader.Next())
+	require.Equal(t, 1, reader.Row())
+	require.Equal(t, map[string]interface{}{"name": "a", "count": json.Number("3")}, reader.Record())
+
+	require.True(t, reader.Next())
+	require.Equal(t, 3, reader.Row())
+	require.Equal(t, map[string]interface{}{"name": "b"}, reader.Record())
+
+	require.False(t, reader.Next())
+	require.NoError(t, reader.Err())
+
+	reader, err = NewRecordReader(FormatJSONL, bytes.NewBufferString("{\"name\": \"a\"}\n[1]\n"))
+	require.NoError(t, err)
+	require.True(t, reader.Next())
+	require.False(t, reader.Next())
+	require.Error(t, reader.Err())
+}
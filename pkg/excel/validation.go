@@ -34,8 +34,16 @@ const (
 	// 如果下拉列表总大小需要超过255字符，可以使用Ref类型引用另一个sheet的一列值作为下拉列表
 	Enum FieldType = "enum"
 	Ref  FieldType = "ref"
+	// Prompt 不限制单元格的值，只在选中单元格时弹出提示信息，Option为*PromptOption
+	Prompt FieldType = "prompt"
 )
 
+// PromptOption prompt validation option
+type PromptOption struct {
+	Title   string
+	Message string
+}
+
 // ValidationParam validation parameter
 type ValidationParam struct {
 	Type   FieldType
@@ -54,6 +62,10 @@ const (
 	errTitle = "警告"
 	// errMessage 当填入excel数据不满足校验时，弹出的错误框内容
 	errMessage = "此值与此单元格定义的数据验证限制不匹配。"
+	// maxPromptTitleLen excel限制提示框标题最多32个字符
+	maxPromptTitleLen = 32
+	// maxPromptMessageLen excel限制提示框内容最多255个字符
+	maxPromptMessageLen = 255
 )
 
 func newValidation(param *ValidationParam) (*excelize.DataValidation, error) {
@@ -85,6 +97,14 @@ func newValidation(param *ValidationParam) (*excelize.DataValidation, error) {
 			return nil, err
 		}
 		validation.SetSqrefDropList(ref)
+	case Prompt:
+		option, ok := param.Option.(*PromptOption)
+		if !ok {
+			return nil, fmt.Errorf("prompt validation option is invalid, val: %v", param.Option)
+		}
+		validation.SetInput(truncate(option.Title, maxPromptTitleLen),
+			truncate(option.Message, maxPromptMessageLen))
+		return validation, nil
 	}
 
 	validation.SetError(excelize.DataValidationErrorStyleStop, errTitle, errMessage)
@@ -95,3 +115,13 @@ func newValidation(param *ValidationParam) (*excelize.DataValidation, error) {
 func getRefDropList(sheet string) (string, error) {
 	return fmt.Sprintf("'%s'%s", sheet, enumRefSuffix), nil
 }
+
+// truncate truncate the string to the max length of characters
+func truncate(val string, maxLen int) string {
+	runes := []rune(val)
+	if len(runes) <= maxLen {
+		return val
+	}
+
+	return string(runes[:maxLen])
+}
@@ -585,6 +585,29 @@ func TestNotEndsWithInsensitiveMatch(t *testing.T) {
 	assert.Equal(t, false, matched)
 }
 
+func TestIPInCIDRMatch(t *testing.T) {
+	op := IPInCIDR.Factory().Operator()
+
+	matched, err := op.Match("192.168.1.100", "192.168.1.0/24")
+	assert.NoError(t, err)
+	assert.Equal(t, true, matched)
+
+	matched, err = op.Match("192.168.2.1", "192.168.1.0/24")
+	assert.NoError(t, err)
+	assert.Equal(t, false, matched)
+
+	matched, err = op.Match("fe80::1", "fe80::/64")
+	assert.NoError(t, err)
+	assert.Equal(t, true, matched)
+
+	matched, err = op.Match("not an ip", "fe80::/64")
+	assert.NoError(t, err)
+	assert.Equal(t, false, matched)
+
+	_, err = op.Match("192.168.1.1", "192.168.1.1")
+	assert.Error(t, err)
+}
+
 func TestIsEmptyMatch(t *testing.T) {
 	op := IsEmpty.Factory().Operator()
 
@@ -2122,6 +2122,60 @@ func TestNotEndsWithInsensitiveMongoCond(t *testing.T) {
 	}
 }
 
+func TestIPInCIDRValidate(t *testing.T) {
+	op := IPInCIDR.Factory().Operator()
+
+	// test ipv4 and ipv6 cidr
+	for _, cidr := range []string{"192.168.1.0/24", "10.0.0.1/8", "fe80::/64"} {
+		if err := op.ValidateValue(cidr, nil); err != nil {
+			t.Errorf("validate %s failed, err: %v", cidr, err)
+			return
+		}
+	}
+
+	// test invalid cidr
+	for _, value := range []interface{}{"", "192.168.1.1", "192.168.1.0/33", 1, nil, []string{"10.0.0.0/8"}} {
+		if err := op.ValidateValue(value, nil); err == nil {
+			t.Errorf("validate %v should return error", value)
+			return
+		}
+	}
+}
+
+func TestIPInCIDRMongoCond(t *testing.T) {
+	op := IPInCIDR.Factory().Operator()
+
+	cond, err := op.ToMgo("test", "192.168.1.0/24")
+	if err != nil {
+		t.Errorf("to mongo failed, err: %v", err)
+		return
+	}
+
+	if !reflect.DeepEqual(cond, map[string]interface{}{"test": map[string]interface{}{
+		common.BKDBLIKE: `^192\.168\.1\.\d{1,3}$`}}) {
+		t.Errorf("cond %+v is invalid", cond)
+		return
+	}
+
+	cond, err = op.ToMgo("test", "10.1.2.3/14")
+	if err != nil {
+		t.Errorf("to mongo failed, err: %v", err)
+		return
+	}
+
+	if !reflect.DeepEqual(cond, map[string]interface{}{"test": map[string]interface{}{
+		common.BKDBLIKE: `^10\.(0|1|2|3)\.\d{1,3}\.\d{1,3}$`}}) {
+		t.Errorf("cond %+v is invalid", cond)
+		return
+	}
+
+	// ipv6 cidr is not supported in db query
+	if _, err = op.ToMgo("test", "fe80::/64"); err == nil {
+		t.Errorf("ipv6 cidr to mongo should return error")
+		return
+	}
+}
+
 func TestIsEmptyValidate(t *testing.T) {
 	op := IsEmpty.Factory().Operator()
 
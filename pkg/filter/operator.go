@@ -20,7 +20,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"reflect"
+	"strconv"
 	"strings"
 
 	"configcenter/src/common"
@@ -83,6 +85,8 @@ func init() {
 	opFactory[OpFactory(notEndsWith.Name())] = &notEndsWith
 	notEndsWithInsensitive := NotEndsWithInsensitiveOp(NotEndsWithInsensitive)
 	opFactory[OpFactory(notEndsWithInsensitive.Name())] = &notEndsWithInsensitive
+	ipInCIDR := IPInCIDROp(IPInCIDR)
+	opFactory[OpFactory(ipInCIDR.Name())] = &ipInCIDR
 	isEmpty := IsEmptyOp(IsEmpty)
 	opFactory[OpFactory(isEmpty.Name())] = &isEmpty
 	isNotEmpty := IsNotEmptyOp(IsNotEmpty)
@@ -212,6 +216,11 @@ const (
 	// NotEndsWithInsensitive operator with case-insensitive
 	NotEndsWithInsensitive OpType = "not_ends_with_i"
 
+	// network operator
+
+	// IPInCIDR operator checks if the ip address is in the cidr network
+	IPInCIDR OpType = "ip_in_cidr"
+
 	// array operator
 
 	// IsEmpty operator
@@ -252,7 +261,7 @@ func (op OpType) Validate() error {
 	case Equal, NotEqual, In, NotIn, Less, LessOrEqual, Greater, GreaterOrEqual, DatetimeLess, DatetimeLessOrEqual,
 		DatetimeGreater, DatetimeGreaterOrEqual, BeginsWith, BeginsWithInsensitive, NotBeginsWith,
 		NotBeginsWithInsensitive, Contains, ContainsSensitive, NotContains, NotContainsInsensitive, EndsWith,
-		EndsWithInsensitive, NotEndsWith, NotEndsWithInsensitive, IsEmpty, IsNotEmpty, Size, IsNull,
+		EndsWithInsensitive, NotEndsWith, NotEndsWithInsensitive, IPInCIDR, IsEmpty, IsNotEmpty, Size, IsNull,
 		IsNotNull, Exist, NotExist, Object, Array:
 	default:
 		return fmt.Errorf("unsupported operator: %s", op)
@@ -1360,6 +1369,97 @@ func (o NotEndsWithInsensitiveOp) ToMgo(field string, value interface{}) (map[st
 	}, nil
 }
 
+// IPInCIDROp is ip in cidr operator
+type IPInCIDROp OpType
+
+// Name is ip in cidr operator name
+func (o IPInCIDROp) Name() OpType {
+	return IPInCIDR
+}
+
+// ValidateValue validate ip in cidr operator's value
+func (o IPInCIDROp) ValidateValue(v interface{}, opt *ExprOption) error {
+	cidr, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("ip in cidr operator's value(%+v) is not string type", v)
+	}
+
+	if _, _, err := net.ParseCIDR(cidr); err != nil {
+		return fmt.Errorf("ip in cidr operator's value is invalid, err: %v", err)
+	}
+
+	return nil
+}
+
+// ToMgo convert the ip in cidr operator's field and value to a mongo query condition.
+// ip addresses are stored as strings, so the condition is a regular expression that matches all ipv4 addresses in
+// the cidr. ipv6 addresses can be written in various compressed forms, so ipv6 cidr is not supported here.
+func (o IPInCIDROp) ToMgo(field string, value interface{}) (map[string]interface{}, error) {
+	if len(field) == 0 {
+		return nil, errors.New("field is empty")
+	}
+
+	cidr, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("ip in cidr operator's value(%+v) is not string type", value)
+	}
+
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, fmt.Errorf("ip in cidr operator's value is invalid, err: %v", err)
+	}
+
+	ip := network.IP.To4()
+	if ip == nil {
+		return nil, fmt.Errorf("ip in cidr operator only supports ipv4 cidr, value: %s", cidr)
+	}
+
+	ones, _ := network.Mask.Size()
+	octets := make([]string, net.IPv4len)
+	for idx := range octets {
+		switch {
+		case ones >= (idx+1)*8:
+			// the octet is fixed by the mask
+			octets[idx] = strconv.Itoa(int(ip[idx]))
+		case ones > idx*8:
+			// the octet is partially fixed by the mask, lists all the possible values
+			count := 1 << ((idx+1)*8 - ones)
+			values := make([]string, count)
+			for i := 0; i < count; i++ {
+				values[i] = strconv.Itoa(int(ip[idx]) + i)
+			}
+			octets[idx] = "(" + strings.Join(values, "|") + ")"
+		default:
+			octets[idx] = `\d{1,3}`
+		}
+	}
+
+	return mapstr.MapStr{
+		field: map[string]interface{}{
+			common.BKDBLIKE: "^" + strings.Join(octets, `\.`) + "$",
+		},
+	}, nil
+}
+
+// Match checks if the first data matches the second data by this operator
+func (o IPInCIDROp) Match(value1, value2 interface{}) (bool, error) {
+	val1, val2, err := parseStringValues(value1, value2)
+	if err != nil {
+		return false, err
+	}
+
+	_, network, err := net.ParseCIDR(val2)
+	if err != nil {
+		return false, fmt.Errorf("rule value(%s) is not a valid cidr, err: %v", val2, err)
+	}
+
+	ip := net.ParseIP(val1)
+	if ip == nil {
+		return false, nil
+	}
+	return network.Contains(ip), nil
+}
+
 // IsEmptyOp is empty operator
 type IsEmptyOp OpType
 
@@ -0,0 +1,117 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package types
+
+import (
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+)
+
+// IncrSyncPushTimeKey is the redis hash key that stores the push time of the last applied incremental sync data
+// of each resource type in destination cmdb
+const IncrSyncPushTimeKey = "cmdb_syncer:incr_sync_push_time"
+
+// ListSyncAuditSubResOpt is the option to list sub resources of the resource type for sync audit
+type ListSyncAuditSubResOpt struct {
+	ResType ResType `json:"resource_type"`
+}
+
+// Validate ListSyncAuditSubResOpt
+func (o *ListSyncAuditSubResOpt) Validate() errors.RawErrorInfo {
+	if _, withSubRes := ResTypeWithSubResMap[o.ResType]; !withSubRes {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsIsInvalid,
+			Args:    []interface{}{ResTypeField},
+		}
+	}
+
+	return errors.RawErrorInfo{}
+}
+
+// ListSyncAuditDataOpt is the option to list one interval of source sync data for sync audit
+type ListSyncAuditDataOpt struct {
+	ResType ResType          `json:"resource_type"`
+	SubRes  string           `json:"sub_resource"`
+	Start   map[string]int64 `json:"start"`
+}
+
+// Validate ListSyncAuditDataOpt
+func (o *ListSyncAuditDataOpt) Validate() errors.RawErrorInfo {
+	return o.ResType.Validate(o.SubRes)
+}
+
+// SyncAuditData is one interval of source sync data for sync audit, it is the same as the full sync data pushed
+// to the transfer medium, destination cmdb compares it with its own data of the same interval
+type SyncAuditData struct {
+	ResType   ResType            `json:"resource_type"`
+	SubRes    string             `json:"sub_resource"`
+	Data      *FullSyncTransData `json:"data"`
+	IsAll     bool               `json:"is_all"`
+	NextStart map[string]int64   `json:"next_start"`
+	// IncrCursor is the last incremental sync cursor of the resource type in source cmdb
+	IncrCursor *SyncAuditIncrCursor `json:"incr_cursor,omitempty"`
+}
+
+// Validate SyncAuditData
+func (o *SyncAuditData) Validate() errors.RawErrorInfo {
+	if rawErr := o.ResType.Validate(o.SubRes); rawErr.ErrCode != 0 {
+		return rawErr
+	}
+
+	if o.Data == nil || o.Data.Name == "" {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedSet,
+			Args:    []interface{}{"data.name"},
+		}
+	}
+
+	return errors.RawErrorInfo{}
+}
+
+// SyncAuditIncrCursor is the incremental sync cursor info of one resource type
+type SyncAuditIncrCursor struct {
+	// Cursor is the event watch cursor of each event type, only set for resource types watched by api
+	Cursor map[string]string `json:"cursor,omitempty"`
+	// StartAtTime is the time from which the events are watched
+	StartAtTime *time.Time `json:"start_at_time,omitempty"`
+	// LastPushTime is the unix time when the last incremental sync data was pushed to the transfer medium
+	LastPushTime int64 `json:"last_push_time"`
+}
+
+// SyncAuditCompareRes is the compare result of one interval of sync data for sync audit
+type SyncAuditCompareRes struct {
+	ResType ResType `json:"resource_type"`
+	SubRes  string  `json:"sub_resource"`
+	// SrcCount is the count of source data in the interval
+	SrcCount int `json:"src_count"`
+	// DestCount is the count of destination data in the interval
+	DestCount int `json:"dest_count"`
+	// SrcChecksum is the checksum of the sorted source data ids
+	SrcChecksum string `json:"src_checksum"`
+	// DestChecksum is the checksum of the sorted destination data ids
+	DestChecksum string `json:"dest_checksum"`
+	// MissingIDs are the ids of the source data that do not exist in destination
+	MissingIDs []string `json:"missing_ids"`
+	// DifferentIDs are the ids of the data whose source and destination contents are different
+	DifferentIDs []string `json:"different_ids"`
+	// RedundantIDs are the ids of the destination data that do not exist in source
+	RedundantIDs []string `json:"redundant_ids"`
+	// LastApplyPushTime is the push time of the last incremental sync data applied in destination, unix time
+	LastApplyPushTime int64 `json:"last_apply_push_time"`
+}
@@ -0,0 +1,60 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package types
+
+import (
+	"time"
+
+	"configcenter/src/common"
+	ccErr "configcenter/src/common/errors"
+)
+
+// SyncConflict is the sync data that can not be synchronized because the destination data has diverged
+type SyncConflict struct {
+	ResType ResType `json:"resource_type"`
+	// Key is the unique key of the sync data in all environments
+	Key string `json:"key"`
+	// Reason is the reason why the sync data conflicts with the destination data
+	Reason string `json:"reason"`
+	// Data is the source sync data
+	Data any `json:"data"`
+	// DetectTime is the last time when the conflict is detected
+	DetectTime time.Time `json:"detect_time"`
+}
+
+// ListSyncConflictOpt is the list sync conflict option
+type ListSyncConflictOpt struct {
+	ResType ResType `json:"resource_type"`
+}
+
+// Validate list sync conflict option
+func (o *ListSyncConflictOpt) Validate() ccErr.RawErrorInfo {
+	if o.ResType != "" && !IsModelResType(o.ResType) {
+		return ccErr.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsIsInvalid,
+			Args:    []interface{}{ResTypeField},
+		}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// ListSyncConflictRes is the list sync conflict result
+type ListSyncConflictRes struct {
+	Count int            `json:"count"`
+	Info  []SyncConflict `json:"info"`
+}
@@ -63,4 +63,6 @@ type IncrSyncTransData struct {
 	Name       string                       `json:"name"`
 	UpsertInfo map[string][]json.RawMessage `json:"upsert_info"`
 	DeleteInfo map[string][]json.RawMessage `json:"delete_info"`
+	// PushTime is the unix time when the data is pushed to the transfer medium, used to calculate the sync lag
+	PushTime int64 `json:"push_time,omitempty"`
 }
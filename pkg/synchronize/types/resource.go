@@ -26,6 +26,21 @@ import (
 type ResType string
 
 const (
+	// ModelClassification is the model classification synchronize resource type
+	ModelClassification ResType = "model_classification"
+	// AssociationKind is the association kind synchronize resource type
+	AssociationKind ResType = "association_kind"
+	// Model is the model synchronize resource type
+	Model ResType = "model"
+	// ModelAttrGroup is the model attribute group synchronize resource type
+	ModelAttrGroup ResType = "model_attribute_group"
+	// ModelAttribute is the model attribute synchronize resource type
+	ModelAttribute ResType = "model_attribute"
+	// ModelUnique is the model unique rule synchronize resource type
+	ModelUnique ResType = "model_unique"
+	// ModelAssociation is the model association synchronize resource type
+	ModelAssociation ResType = "model_association"
+
 	// Biz is the business synchronize resource type
 	Biz ResType = "biz"
 	// Set is the set synchronize resource type
@@ -51,16 +66,31 @@ const (
 )
 
 var (
+	// modelResType is all model level synchronize resource type in the order of dependency, model definitions are
+	// synchronized ahead of the instance data
+	modelResType = []ResType{ModelClassification, AssociationKind, Model, ModelAttrGroup, ModelAttribute, ModelUnique,
+		ModelAssociation}
 	// allResType is all synchronize resource type in the order of dependency
-	allResType = []ResType{Biz, ObjectInstance, Set, Module, Host, HostRelation, InstAsst, ServiceInstance, Process,
-		ProcessRelation, QuotedInstance}
-	allResTypeMap = make(map[ResType]struct{})
+	allResType = append(modelResType, Biz, ObjectInstance, Set, Module, Host, HostRelation, InstAsst,
+		ServiceInstance, Process, ProcessRelation, QuotedInstance)
+	allResTypeMap   = make(map[ResType]struct{})
+	modelResTypeMap = make(map[ResType]struct{})
 )
 
 func init() {
 	for _, resType := range allResType {
 		allResTypeMap[resType] = struct{}{}
 	}
+
+	for _, resType := range modelResType {
+		modelResTypeMap[resType] = struct{}{}
+	}
+}
+
+// IsModelResType checks if the resource type is model level synchronize resource type
+func IsModelResType(resType ResType) bool {
+	_, exists := modelResTypeMap[resType]
+	return exists
 }
 
 // ListAllResType list all synchronize resource type
@@ -382,6 +382,14 @@ authServer:
   # list_biz_hosts、list_biz_hosts_topo、find_host_by_topo、list_host_total_mainline_topo这几个上esb接口,
   # 可以配置不进行业务访问鉴权
   skipViewBizAuth: false
+  # 鉴权模式，可选值为iam和local，默认为iam
+  # iam: 使用蓝鲸权限中心鉴权
+  # local: 使用cmdb内置的基于角色的鉴权，不依赖蓝鲸权限中心，角色和角色绑定存储在cmdb的db中，通过local_auth相关接口管理
+  mode: iam
+  # 内置鉴权配置，仅在mode为local时生效
+  local:
+    # 拥有所有权限的超级管理员，多个用,(逗号)分割，用于初始化角色和角色绑定
+    superAdmins: admin
 
 #cloudServer专属配置
 cloudServer:
@@ -397,6 +405,8 @@ cloudServer:
   syncTask:
     # 同步周期,最小为5分钟
     syncPeriodMinutes: 5
+    # vpc没有对应的管控区域时是否自动为其创建管控区域，默认为false，此时同步会报错
+    autoCreateCloudArea: false
 
 #datacollection专属配置
 datacollection:
@@ -665,6 +675,62 @@ webServer:
   login:
     #登录模式
     version: $loginVersion
+    # 单点登录(oidc)和ldap登录方式的用户登录有效期，单位为秒，默认为86400
+    loginTimeout: 86400
+    # 用户组到开发商账号和默认业务的映射，按顺序使用第一个与用户所属组匹配的映射，group为"*"时匹配所有用户，未匹配时使用默认开发商账号
+    groupMapping:
+    #  - group: ops
+    #    supplierAccount: "0"
+    #    defaultBizID: 2
+    # openid connect单点登录配置，登录模式为oidc时生效
+    oidc:
+      # 认证服务的issuer地址，会从issuer/.well-known/openid-configuration获取认证服务的地址信息
+      issuer:
+      # 在认证服务中注册的客户端ID和密钥
+      clientId:
+      clientSecret:
+      # 登录成功后的回调地址，需要在认证服务中注册，如: http://cmdb.example.com/login/callback
+      redirectUrl:
+      # 申请的scope，默认为openid、profile和email
+      scopes:
+      # 用户名、中文名、邮箱、手机号和用户组对应的claim，默认为preferred_username、name、email、phone_number和groups
+      userNameClaim:
+      displayNameClaim:
+      emailClaim:
+      phoneClaim:
+      groupsClaim:
+      # 请求认证服务的超时时间，单位为秒，默认为10
+      timeoutSeconds:
+      tls:
+        # 客户端是否验证服务端证书，包含证书链和主机名，bool值, true为不校验, false为校验
+        insecureSkipVerify:
+        # CA证书的路径，用于验证对方证书,如:/data/cmdb/cert/ca.crt
+        caFile:
+    # ldap登录配置，登录模式为ldap时使用ldap校验登录页面的用户名和密码，登录模式为oidc时作为认证服务不可用时的备用登录方式
+    ldap:
+      # 是否启用ldap登录
+      enabled: false
+      # ldap服务地址，如: ldap://127.0.0.1:389 或 ldaps://127.0.0.1:636
+      url:
+      # 用于查询用户的账号和密码，为空时使用匿名查询
+      bindDN:
+      bindPassword:
+      # 查询用户的根节点，如: dc=example,dc=com
+      baseDN:
+      # 查询用户的过滤条件，%s会被替换为登录的用户名，默认为(uid=%s)
+      userFilter:
+      # 中文名、邮箱、手机号和用户组对应的属性，默认为displayName、mail、mobile和memberOf，用户组的名称取组dn的第一个rdn的值
+      displayNameAttr:
+      emailAttr:
+      phoneAttr:
+      groupAttr:
+      # ldap登录的超时时间，单位为秒，默认为10
+      timeoutSeconds:
+      tls:
+        # 客户端是否验证服务端证书，包含证书链和主机名，bool值, true为不校验, false为校验
+        insecureSkipVerify:
+        # CA证书的路径，用于验证对方证书,如:/data/cmdb/cert/ca.crt
+        caFile:
   #cmdb版本日志存放路径配置
   changelogPath:
     #中文版版本日志存放路径
@@ -23,7 +23,10 @@ import (
 	"net/http"
 
 	"configcenter/src/ac/iam"
+	"configcenter/src/ac/local"
 	"configcenter/src/ac/meta"
+	"configcenter/src/apimachinery"
+	"configcenter/src/common/auth"
 	"configcenter/src/common/metadata"
 	"configcenter/src/scene_server/auth_server/sdk/types"
 	"configcenter/src/storage/dal/redis"
@@ -73,3 +76,12 @@ type AuthorizeInterface interface {
 	BatchRegisterResourceCreatorAction(ctx context.Context, h http.Header, input metadata.IamInstancesWithCreator) (
 		[]metadata.IamCreatorActionPolicy, error)
 }
+
+// NewAuthorizer new authorizer by the auth mode, the built-in local authorizer is used in local mode,
+// otherwise the BlueKing IAM authorizer is used
+func NewAuthorizer(clientSet apimachinery.ClientSetInterface) AuthorizeInterface {
+	if auth.IsLocalMode() {
+		return local.NewAuthorizer(clientSet)
+	}
+	return iam.NewAuthorizer(clientSet)
+}
@@ -43,7 +43,7 @@ type AuthManager struct {
 func NewAuthManager(clientSet apimachinery.ClientSetInterface, iamCli *iam.IAM) *AuthManager {
 	return &AuthManager{
 		clientSet:                    clientSet,
-		Authorizer:                   iam.NewAuthorizer(clientSet),
+		Authorizer:                   ac.NewAuthorizer(clientSet),
 		Viewer:                       iam.NewViewer(clientSet, iamCli),
 		RegisterModuleEnabled:        false,
 		RegisterSetEnabled:           false,
@@ -53,7 +53,7 @@ type IAM struct {
 // NewIAM new iam client
 func NewIAM(cfg AuthConfig, reg prometheus.Registerer) (*IAM, error) {
 	blog.V(5).Infof("new iam with parameters cfg: %+v", cfg)
-	if !auth.EnableAuthorize() {
+	if !auth.EnableIAM() {
 		return new(IAM), nil
 	}
 
@@ -150,7 +150,7 @@ type RegisterIamOptions struct {
 
 // Register cc auth resources to iam
 func (i IAM) Register(ctx context.Context, redisCli redis.Client, opt *RegisterIamOptions, rid string) error {
-	if !auth.EnableAuthorize() {
+	if !auth.EnableIAM() {
 		return nil
 	}
 
@@ -64,7 +64,7 @@ type AuthConfig struct {
 // ParseConfigFromKV TODO
 func ParseConfigFromKV(prefix string, configMap map[string]string) (AuthConfig, error) {
 	var cfg AuthConfig
-	if !auth.EnableAuthorize() {
+	if !auth.EnableIAM() {
 		return AuthConfig{}, nil
 	}
 	address, err := cc.String(prefix + ".address")
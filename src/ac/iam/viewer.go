@@ -47,7 +47,7 @@ func NewViewer(client apimachinery.ClientSetInterface, iam *IAM) *viewer {
 func (v *viewer) CreateView(ctx context.Context, header http.Header, objects []metadata.Object, redisCli redis.Client,
 	rid string) error {
 
-	if !auth.EnableAuthorize() {
+	if !auth.EnableIAM() {
 		return nil
 	}
 
@@ -87,7 +87,7 @@ func (v *viewer) CreateView(ctx context.Context, header http.Header, objects []m
 func (v *viewer) DeleteView(ctx context.Context, header http.Header, objects []metadata.Object, redisCli redis.Client,
 	rid string) error {
 
-	if !auth.EnableAuthorize() {
+	if !auth.EnableIAM() {
 		return nil
 	}
 
@@ -127,7 +127,7 @@ func (v *viewer) DeleteView(ctx context.Context, header http.Header, objects []m
 func (v *viewer) UpdateView(ctx context.Context, header http.Header, objects []metadata.Object, redisCli redis.Client,
 	rid string) error {
 
-	if !auth.EnableAuthorize() {
+	if !auth.EnableIAM() {
 		return nil
 	}
 
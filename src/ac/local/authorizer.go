@@ -0,0 +1,238 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+// Package local is the built-in rbac authorizer which works without BlueKing IAM, the roles and role bindings are
+// stored in cmdb db, and the resources are converted to the iam actions and resources in the same way as iam does.
+package local
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"configcenter/src/ac/iam"
+	"configcenter/src/ac/meta"
+	"configcenter/src/apimachinery"
+	"configcenter/src/apimachinery/coreservice"
+	"configcenter/src/common/auth"
+	cc "configcenter/src/common/backbone/configcenter"
+	"configcenter/src/common/blog"
+	httpheader "configcenter/src/common/http/header"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/auth_server/sdk/types"
+)
+
+// superAdminsConfig the users that have all the permissions, they are used to initialize the roles and role bindings
+const superAdminsConfig = "authServer.local.superAdmins"
+
+type authorizer struct {
+	coreCli coreservice.CoreServiceClientInterface
+}
+
+// NewAuthorizer new local authorizer
+func NewAuthorizer(clientSet apimachinery.ClientSetInterface) *authorizer {
+	return &authorizer{coreCli: clientSet.CoreService()}
+}
+
+// AuthorizeBatch batch authorization will not pass if one of them does not have permission
+func (a *authorizer) AuthorizeBatch(ctx context.Context, h http.Header, user meta.UserInfo,
+	resources ...meta.ResourceAttribute) ([]types.Decision, error) {
+	return a.authorizeBatch(ctx, h, true, user, resources...)
+}
+
+// AuthorizeAnyBatch batch authorization will pass if one of them has permission
+func (a *authorizer) AuthorizeAnyBatch(ctx context.Context, h http.Header, user meta.UserInfo,
+	resources ...meta.ResourceAttribute) ([]types.Decision, error) {
+	return a.authorizeBatch(ctx, h, false, user, resources...)
+}
+
+func (a *authorizer) authorizeBatch(ctx context.Context, h http.Header, exact bool, user meta.UserInfo,
+	resources ...meta.ResourceAttribute) ([]types.Decision, error) {
+
+	rid := httpheader.GetRid(h)
+	decisions := make([]types.Decision, len(resources))
+	if !auth.EnableAuthorize() {
+		for i := range decisions {
+			decisions[i].Authorized = true
+		}
+		return decisions, nil
+	}
+
+	var p *policy
+	for index := range resources {
+		resource := resources[index]
+
+		// this resource should be skipped, do not need to verify.
+		if resource.Action == meta.SkipAction {
+			decisions[index].Authorized = true
+			continue
+		}
+
+		action, iamResources, err := iam.AdaptAuthOptions(&resource)
+		if err != nil {
+			blog.Errorf("adaptor cmdb resource to iam failed, err: %v, resource: %+v, rid: %s", err, resource, rid)
+			return nil, err
+		}
+
+		if action == iam.Skip {
+			decisions[index].Authorized = true
+			continue
+		}
+
+		// get the policy lazily, so that no policy is fetched when all the resources are skipped
+		if p == nil {
+			p, err = a.getPolicy(ctx, h, user.UserName)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if exact {
+			decisions[index].Authorized = p.authorize(action, resource.BusinessID, iamResources)
+		} else {
+			decisions[index].Authorized = p.hasAction(action)
+		}
+	}
+
+	return decisions, nil
+}
+
+// getPolicy get the merged policy of the user
+func (a *authorizer) getPolicy(ctx context.Context, h http.Header, user string) (*policy, error) {
+	if isSuperAdmin(user) {
+		return &policy{superAdmin: true}, nil
+	}
+
+	userPolicy, err := a.coreCli.LocalAuth().GetUserPolicy(ctx, h, user)
+	if err != nil {
+		blog.Errorf("get local auth policy of user %s failed, err: %v, rid: %s", user, err, httpheader.GetRid(h))
+		return nil, err
+	}
+
+	return newPolicy(userPolicy), nil
+}
+
+// isSuperAdmin returns if the user is one of the configured super admins, the config is read every time so that
+// it can be changed without restarting the processes
+func isSuperAdmin(user string) bool {
+	admins, err := cc.String(superAdminsConfig)
+	if err != nil || admins == "" {
+		return false
+	}
+
+	for _, admin := range strings.Split(admins, ",") {
+		if strings.TrimSpace(admin) == user {
+			return true
+		}
+	}
+	return false
+}
+
+// ListAuthorizedResources list the ids of the resources that the user has the permission of
+func (a *authorizer) ListAuthorizedResources(ctx context.Context, h http.Header,
+	input meta.ListAuthorizedResourcesParam) (*types.AuthorizeList, error) {
+
+	rid := httpheader.GetRid(h)
+	rscType, err := iam.ConvertResourceType(input.ResourceType, 0)
+	if err != nil {
+		blog.Errorf("convert resource type failed, err: %v, input: %+v, rid: %s", err, input, rid)
+		return nil, err
+	}
+
+	action, err := iam.ConvertResourceAction(input.ResourceType, input.Action, input.BizID)
+	if err != nil {
+		blog.Errorf("convert resource action failed, err: %v, input: %+v, rid: %s", err, input, rid)
+		return nil, err
+	}
+
+	p, err := a.getPolicy(ctx, h, input.UserName)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.listAuthorized(action, *rscType, input.BizID), nil
+}
+
+// GetNoAuthSkipUrl returns empty url, because there is no permission center to apply for the permissions
+func (a *authorizer) GetNoAuthSkipUrl(ctx context.Context, h http.Header,
+	input *metadata.IamPermission) (string, error) {
+	return "", nil
+}
+
+// GetPermissionToApply get the permissions that the user lacks, they can be granted by the admin
+func (a *authorizer) GetPermissionToApply(ctx context.Context, h http.Header,
+	input []meta.ResourceAttribute) (*metadata.IamPermission, error) {
+
+	rid := httpheader.GetRid(h)
+	permission := &metadata.IamPermission{
+		SystemID:   iam.SystemIDCMDB,
+		SystemName: iam.SystemNameCMDB,
+		Actions:    make([]metadata.IamAction, 0),
+	}
+
+	actionIndex := make(map[iam.ActionID]int)
+	for index := range input {
+		action, resources, err := iam.AdaptAuthOptions(&input[index])
+		if err != nil {
+			blog.Errorf("adaptor cmdb resource to iam failed, err: %v, resource: %+v, rid: %s", err, input[index],
+				rid)
+			return nil, err
+		}
+
+		if action == iam.Skip {
+			continue
+		}
+
+		idx, exists := actionIndex[action]
+		if !exists {
+			idx = len(permission.Actions)
+			actionIndex[action] = idx
+			permission.Actions = append(permission.Actions, metadata.IamAction{
+				ID:                   string(action),
+				Name:                 iam.ActionIDNameMap[action],
+				RelatedResourceTypes: make([]metadata.IamResourceType, 0),
+			})
+		}
+
+		for _, res := range resources {
+			rscType := metadata.IamResourceType{
+				SystemID:   iam.SystemIDCMDB,
+				SystemName: iam.SystemNameCMDB,
+				Type:       string(res.Type),
+			}
+			if res.ID != "" {
+				rscType.Instances = [][]metadata.IamResourceInstance{{{Type: string(res.Type), ID: res.ID}}}
+			}
+			permission.Actions[idx].RelatedResourceTypes = append(permission.Actions[idx].RelatedResourceTypes,
+				rscType)
+		}
+	}
+
+	return permission, nil
+}
+
+// RegisterResourceCreatorAction does nothing, the local authorizer does not grant permissions to resource creators
+func (a *authorizer) RegisterResourceCreatorAction(ctx context.Context, h http.Header,
+	input metadata.IamInstanceWithCreator) ([]metadata.IamCreatorActionPolicy, error) {
+	return make([]metadata.IamCreatorActionPolicy, 0), nil
+}
+
+// BatchRegisterResourceCreatorAction does nothing, the local authorizer does not grant permissions to resource
+// creators
+func (a *authorizer) BatchRegisterResourceCreatorAction(ctx context.Context, h http.Header,
+	input metadata.IamInstancesWithCreator) ([]metadata.IamCreatorActionPolicy, error) {
+	return make([]metadata.IamCreatorActionPolicy, 0), nil
+}
@@ -0,0 +1,245 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package local
+
+import (
+	"strconv"
+	"strings"
+
+	"configcenter/src/ac/iam"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/auth_server/sdk/types"
+)
+
+// grant is a permission of an action that the user gets from a role binding
+type grant struct {
+	scope metadata.LocalAuthScope
+	// instances the resource ids that the grant is restricted to, nil means all resources in the scope
+	instances map[string]struct{}
+}
+
+// policy is the merged permissions of a user
+type policy struct {
+	superAdmin bool
+	grants     map[iam.ActionID][]grant
+}
+
+// newPolicy merge the role bindings of the user into a policy, bindings that refer to deleted roles are ignored
+func newPolicy(userPolicy *metadata.LocalAuthUserPolicy) *policy {
+	p := &policy{grants: make(map[iam.ActionID][]grant)}
+	if userPolicy == nil {
+		return p
+	}
+
+	roleMap := make(map[int64]metadata.LocalAuthRole)
+	for _, role := range userPolicy.Roles {
+		roleMap[role.ID] = role
+	}
+
+	for _, binding := range userPolicy.Bindings {
+		role, exists := roleMap[binding.RoleID]
+		if !exists {
+			continue
+		}
+
+		for _, perm := range role.Permissions {
+			g := grant{scope: binding.Scope}
+			if len(perm.Instances) > 0 {
+				g.instances = make(map[string]struct{})
+				for _, inst := range perm.Instances {
+					g.instances[inst] = struct{}{}
+				}
+			}
+			action := iam.ActionID(perm.Action)
+			p.grants[action] = append(p.grants[action], g)
+		}
+	}
+
+	return p
+}
+
+// hasAction returns if the user has the action on any resource
+func (p *policy) hasAction(action iam.ActionID) bool {
+	if p.superAdmin {
+		return true
+	}
+	return len(p.grants[action]) > 0
+}
+
+// authorize returns if the user has the action on all the resources of the business, the business id is used to
+// match the business scope because some of the iam resources like set templates do not carry the business path.
+// resources with no instance like creating a business can only be authorized by the grant without instance limit
+func (p *policy) authorize(action iam.ActionID, bizID int64, resources []types.Resource) bool {
+	if p.superAdmin {
+		return true
+	}
+
+	grants := p.grants[action]
+	if len(resources) == 0 {
+		for _, g := range grants {
+			if g.instances == nil && g.matchScope(types.Resource{}, bizID) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, res := range resources {
+		matched := false
+		for _, g := range grants {
+			if g.match(res, bizID) {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// listAuthorized returns the ids of the resources with the type that the user has the action on in the business,
+// the business id is 0 means the resources are not business related
+func (p *policy) listAuthorized(action iam.ActionID, rscType iam.TypeID, bizID int64) *types.AuthorizeList {
+	if p.superAdmin {
+		return &types.AuthorizeList{IsAny: true}
+	}
+
+	idMap := make(map[string]struct{})
+	for _, g := range p.grants[action] {
+		scopeID := strconv.FormatInt(g.scope.ID, 10)
+		switch {
+		case g.scope.Type == metadata.LocalAuthSystemScope,
+			g.scope.Type == metadata.LocalAuthBizScope && bizID > 0 && g.scope.ID == bizID:
+			if g.instances == nil {
+				return &types.AuthorizeList{IsAny: true}
+			}
+			for id := range g.instances {
+				idMap[id] = struct{}{}
+			}
+		case g.scope.Type == metadata.LocalAuthBizScope && rscType == iam.Business,
+			g.scope.Type == metadata.LocalAuthBizSetScope && rscType == iam.BizSet:
+			// the business or biz set of the scope is the resource itself
+			if g.instances == nil {
+				idMap[scopeID] = struct{}{}
+				continue
+			}
+			if _, exists := g.instances[scopeID]; exists {
+				idMap[scopeID] = struct{}{}
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(idMap))
+	for id := range idMap {
+		ids = append(ids, id)
+	}
+	return &types.AuthorizeList{Ids: ids}
+}
+
+// match returns if the resource is in the scope of the grant and is one of the granted instances
+func (g grant) match(res types.Resource, bizID int64) bool {
+	if !g.matchScope(res, bizID) {
+		return false
+	}
+
+	if g.instances == nil {
+		return true
+	}
+
+	if res.ID == "" {
+		return false
+	}
+	_, exists := g.instances[res.ID]
+	return exists
+}
+
+func (g grant) matchScope(res types.Resource, bizID int64) bool {
+	scopeID := strconv.FormatInt(g.scope.ID, 10)
+	switch g.scope.Type {
+	case metadata.LocalAuthSystemScope:
+		return true
+	case metadata.LocalAuthBizScope:
+		if bizID > 0 {
+			return bizID == g.scope.ID
+		}
+
+		if res.Type == types.ResourceType(iam.Business) {
+			return res.ID == scopeID
+		}
+
+		bizPath := "/" + string(iam.Business) + "," + scopeID + "/"
+		for _, path := range getIamPaths(res) {
+			if strings.HasPrefix(path, bizPath) {
+				return true
+			}
+		}
+		return false
+	case metadata.LocalAuthBizSetScope:
+		return res.Type == types.ResourceType(iam.BizSet) && res.ID == scopeID
+	default:
+		return false
+	}
+}
+
+// getIamPaths get the auth topology paths of the resource, like "/biz,1/set,2/"
+func getIamPaths(res types.Resource) []string {
+	if res.Attribute == nil {
+		return nil
+	}
+
+	switch paths := res.Attribute[types.IamPathKey].(type) {
+	case []string:
+		return paths
+	case []interface{}:
+		result := make([]string, 0, len(paths))
+		for _, path := range paths {
+			if p, ok := path.(string); ok {
+				result = append(result, p)
+			}
+		}
+		return result
+	case string:
+		return []string{paths}
+	default:
+		return nil
+	}
+}
+
+// IsValidAction returns if the action can be granted by the local auth role, it should be one of the actions
+// defined in ac/iam, including the dynamic actions of the custom models like "edit_sys_instance_1"
+func IsValidAction(action string) bool {
+	if _, exists := iam.ActionIDNameMap[iam.ActionID(action)]; exists {
+		return true
+	}
+
+	idx := strings.Index(action, "_"+iam.IAMSysInstTypePrefix)
+	if idx <= 0 {
+		return false
+	}
+
+	switch iam.ActionType(action[:idx]) {
+	case iam.Create, iam.Edit, iam.Delete, iam.View:
+	default:
+		return false
+	}
+
+	modelID, err := strconv.ParseInt(action[idx+len(iam.IAMSysInstTypePrefix)+1:], 10, 64)
+	return err == nil && modelID > 0
+}
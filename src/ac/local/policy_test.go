@@ -0,0 +1,146 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package local
+
+import (
+	"sort"
+	"testing"
+
+	"configcenter/src/ac/iam"
+	"configcenter/src/ac/meta"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/auth_server/sdk/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+func adapt(t *testing.T, attr meta.ResourceAttribute) (iam.ActionID, []types.Resource) {
+	action, resources, err := iam.AdaptAuthOptions(&attr)
+	require.NoError(t, err)
+	return action, resources
+}
+
+func testPolicy() *policy {
+	return newPolicy(&metadata.LocalAuthUserPolicy{
+		Roles: []metadata.LocalAuthRole{
+			{ID: 1, Permissions: []metadata.LocalAuthPermission{
+				{Action: string(iam.EditBusinessSetTemplate)},
+				{Action: string(iam.ViewBusinessResource)},
+			}},
+			{ID: 2, Permissions: []metadata.LocalAuthPermission{
+				{Action: string(iam.EditBusinessSetTemplate), Instances: []string{"8"}},
+			}},
+			{ID: 3, Permissions: []metadata.LocalAuthPermission{{Action: string(iam.CreateBusiness)}}},
+			{ID: 4, Permissions: []metadata.LocalAuthPermission{{Action: string(iam.ViewBizSet)}}},
+		},
+		Bindings: []metadata.LocalAuthRoleBinding{
+			{RoleID: 1, Scope: metadata.LocalAuthScope{Type: metadata.LocalAuthBizScope, ID: 2}},
+			{RoleID: 2, Scope: metadata.LocalAuthScope{Type: metadata.LocalAuthBizScope, ID: 3}},
+			{RoleID: 3, Scope: metadata.LocalAuthScope{Type: metadata.LocalAuthSystemScope}},
+			{RoleID: 4, Scope: metadata.LocalAuthScope{Type: metadata.LocalAuthBizSetScope, ID: 5}},
+			// the role is deleted, the binding should be ignored
+			{RoleID: 100, Scope: metadata.LocalAuthScope{Type: metadata.LocalAuthSystemScope}},
+		},
+	})
+}
+
+func TestPolicyAuthorizeBizScope(t *testing.T) {
+	p := testPolicy()
+
+	action, resources := adapt(t, meta.ResourceAttribute{Basic: meta.Basic{Type: meta.SetTemplate,
+		Action: meta.Update, InstanceID: 7}, BusinessID: 2})
+	require.Equal(t, iam.EditBusinessSetTemplate, action)
+	require.True(t, p.authorize(action, 2, resources))
+
+	// instance level grant in business 3
+	_, resources = adapt(t, meta.ResourceAttribute{Basic: meta.Basic{Type: meta.SetTemplate,
+		Action: meta.Update, InstanceID: 8}, BusinessID: 3})
+	require.True(t, p.authorize(action, 3, resources))
+
+	_, resources = adapt(t, meta.ResourceAttribute{Basic: meta.Basic{Type: meta.SetTemplate,
+		Action: meta.Update, InstanceID: 9}, BusinessID: 3})
+	require.False(t, p.authorize(action, 3, resources))
+
+	// business that is not bound
+	_, resources = adapt(t, meta.ResourceAttribute{Basic: meta.Basic{Type: meta.SetTemplate,
+		Action: meta.Update, InstanceID: 7}, BusinessID: 4})
+	require.False(t, p.authorize(action, 4, resources))
+
+	// the business is matched by the auth path of the resource
+	require.True(t, p.authorize(iam.ViewBusinessResource, 0, []types.Resource{{
+		Type:      types.ResourceType(iam.BizSet),
+		Attribute: types.ResourceAttributes{types.IamPathKey: []interface{}{"/biz,2/set,3/"}},
+	}}))
+	require.True(t, p.authorize(iam.ViewBusinessResource, 0, []types.Resource{{
+		Type: types.ResourceType(iam.Business), ID: "2"}}))
+	require.False(t, p.authorize(iam.ViewBusinessResource, 0, []types.Resource{{
+		Type: types.ResourceType(iam.Business), ID: "3"}}))
+}
+
+func TestPolicyAuthorizeSystemAndBizSetScope(t *testing.T) {
+	p := testPolicy()
+
+	action, resources := adapt(t, meta.ResourceAttribute{Basic: meta.Basic{Type: meta.Business,
+		Action: meta.Create}})
+	require.Equal(t, iam.CreateBusiness, action)
+	require.True(t, p.authorize(action, 0, resources))
+
+	// biz scope can not authorize the actions that are not related to the business
+	require.False(t, p.authorize(iam.EditBusinessSetTemplate, 0, nil))
+	require.True(t, p.authorize(iam.EditBusinessSetTemplate, 2, nil))
+
+	bizSet := func(id string) []types.Resource {
+		return []types.Resource{{Type: types.ResourceType(iam.BizSet), ID: id}}
+	}
+	require.True(t, p.authorize(iam.ViewBizSet, 0, bizSet("5")))
+	require.False(t, p.authorize(iam.ViewBizSet, 0, bizSet("6")))
+
+	require.True(t, p.hasAction(iam.ViewBizSet))
+	require.False(t, p.hasAction(iam.DeleteBusinessSetTemplate))
+
+	admin := &policy{superAdmin: true}
+	require.True(t, admin.authorize(iam.DeleteBusinessSetTemplate, 0, nil))
+}
+
+func TestPolicyListAuthorized(t *testing.T) {
+	p := testPolicy()
+
+	list := p.listAuthorized(iam.ViewBusinessResource, iam.Business, 0)
+	require.False(t, list.IsAny)
+	require.Equal(t, []string{"2"}, list.Ids)
+
+	list = p.listAuthorized(iam.EditBusinessSetTemplate, iam.BizSetTemplate, 2)
+	require.True(t, list.IsAny)
+
+	list = p.listAuthorized(iam.EditBusinessSetTemplate, iam.BizSetTemplate, 3)
+	require.False(t, list.IsAny)
+	sort.Strings(list.Ids)
+	require.Equal(t, []string{"8"}, list.Ids)
+
+	list = p.listAuthorized(iam.ViewBizSet, iam.BizSet, 0)
+	require.Equal(t, []string{"5"}, list.Ids)
+
+	require.True(t, p.listAuthorized(iam.CreateBusiness, iam.Business, 0).IsAny)
+}
+
+func TestIsValidAction(t *testing.T) {
+	require.True(t, IsValidAction(string(iam.EditBusinessHost)))
+	require.True(t, IsValidAction(string(iam.GenDynamicActionID(iam.Edit, 12))))
+	require.False(t, IsValidAction("edit_"+iam.IAMSysInstTypePrefix))
+	require.False(t, IsValidAction("list_"+iam.IAMSysInstTypePrefix+"1"))
+	require.False(t, IsValidAction("not_exist_action"))
+}
@@ -52,6 +52,13 @@ var ConfigAdminConfigs = []AuthConfig{
 		HTTPMethod:     http.MethodPut,
 		ResourceType:   meta.ConfigAdmin,
 		ResourceAction: meta.Update,
+	}, {
+		Name:           "findAPILimiterUsage",
+		Description:    "查询api限流策略使用情况",
+		Pattern:        "/api/v3/find/api_limiter/usage",
+		HTTPMethod:     http.MethodGet,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Find,
 	},
 }
 
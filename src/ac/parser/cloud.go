@@ -176,6 +176,22 @@ var cloudResourceTaskConfigs = []AuthConfig{
 			}
 			return nil, errors.New("unexpected error: this code shouldn't be reached")
 		},
+	}, {
+		Name:             "previewCloudResourceTaskRegex",
+		Description:      "预览云资源同步任务的同步结果",
+		Regex:            regexp.MustCompile(`^/api/v3/find/cloud/sync/task/([0-9]+)/preview$`),
+		HTTPMethod:       http.MethodPost,
+		ResourceType:     meta.CloudResourceTask,
+		ResourceAction:   meta.Find,
+		InstanceIDGetter: cloudSyncTaskIDGetter,
+	}, {
+		Name:             "confirmCloudResourceTaskRegex",
+		Description:      "确认并执行云资源同步任务",
+		Regex:            regexp.MustCompile(`^/api/v3/update/cloud/sync/task/([0-9]+)/confirm$`),
+		HTTPMethod:       http.MethodPost,
+		ResourceType:     meta.CloudResourceTask,
+		ResourceAction:   meta.Update,
+		InstanceIDGetter: cloudSyncTaskIDGetter,
 	}, {
 		Name:           "listCloudResourceTaskHistoryPattern",
 		Description:    "查询云资源同步历史记录",
@@ -194,6 +210,14 @@ var cloudResourceTaskConfigs = []AuthConfig{
 			}
 			return []int64{taskID}, nil
 		},
+	}, {
+		Name:             "rollbackCloudResourceTaskHistoryRegex",
+		Description:      "回滚云资源同步任务的一次同步",
+		Regex:            regexp.MustCompile(`^/api/v3/update/cloud/sync/task/([0-9]+)/history/([0-9]+)/rollback$`),
+		HTTPMethod:       http.MethodPost,
+		ResourceType:     meta.CloudResourceTask,
+		ResourceAction:   meta.Update,
+		InstanceIDGetter: cloudSyncTaskIDGetter,
 	},
 	{
 		Name:           "listCloudResourceRegionPattern",
@@ -205,6 +229,20 @@ var cloudResourceTaskConfigs = []AuthConfig{
 	},
 }
 
+// cloudSyncTaskIDGetter get the cloud sync task id, which is the first id in the request uri
+func cloudSyncTaskIDGetter(request *RequestContext, re *regexp.Regexp) ([]int64, error) {
+	subMatch := re.FindStringSubmatch(request.URI)
+	if len(subMatch) < 2 {
+		return nil, errors.New("unexpected error: this code shouldn't be reached")
+	}
+
+	id, err := strconv.ParseInt(subMatch[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("parse task id to int64 failed, err: %s", err)
+	}
+	return []int64{id}, nil
+}
+
 func (ps *parseStream) cloudAccount() *parseStream {
 	return ParseStreamWithFramework(ps, cloudAccountConfigs)
 }
@@ -15,7 +15,9 @@ func (ps *parseStream) netCollectorRelated() *parseStream {
 	ps.netCollector().
 		netDevice().
 		netProperty().
-		netReport()
+		netReport().
+		hostSnapFieldMapping().
+		netTarget()
 
 	return ps
 }
@@ -329,3 +331,86 @@ func (ps *parseStream) netReport() *parseStream {
 
 	return ps
 }
+
+// HostSnapFieldMappingAuthConfigs host snapshot field mapping is a global config of host snapshot, so it is
+// authorized as config admin.
+var HostSnapFieldMappingAuthConfigs = []AuthConfig{
+	{
+		Name:           "createHostSnapFieldMapping",
+		Description:    "创建主机快照字段映射",
+		Pattern:        "/api/v3/collector/hostsnap/field_mapping/action/create",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "updateHostSnapFieldMappingRegex",
+		Description:    "更新主机快照字段映射",
+		Regex:          regexp.MustCompile(`^/api/v3/collector/hostsnap/field_mapping/[0-9]+/action/update/?$`),
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "searchHostSnapFieldMapping",
+		Description:    "查询主机快照字段映射",
+		Pattern:        "/api/v3/collector/hostsnap/field_mapping/action/search",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Find,
+	}, {
+		Name:           "deleteHostSnapFieldMapping",
+		Description:    "删除主机快照字段映射",
+		Pattern:        "/api/v3/collector/hostsnap/field_mapping/action/delete",
+		HTTPMethod:     http.MethodDelete,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	},
+}
+
+func (ps *parseStream) hostSnapFieldMapping() *parseStream {
+	return ParseStreamWithFramework(ps, HostSnapFieldMappingAuthConfigs)
+}
+
+// NetTargetAuthConfigs net device targets contain the credentials of the devices, so they are authorized as
+// config admin.
+var NetTargetAuthConfigs = []AuthConfig{
+	{
+		Name:           "createNetTarget",
+		Description:    "创建网络设备采集目标",
+		Pattern:        "/api/v3/collector/netcollect/target/action/create",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "updateNetTargetRegex",
+		Description:    "更新网络设备采集目标",
+		Regex:          regexp.MustCompile(`^/api/v3/collector/netcollect/target/[0-9]+/action/update/?$`),
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "searchNetTarget",
+		Description:    "查询网络设备采集目标",
+		Pattern:        "/api/v3/collector/netcollect/target/action/search",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Find,
+	}, {
+		Name:           "deleteNetTarget",
+		Description:    "删除网络设备采集目标",
+		Pattern:        "/api/v3/collector/netcollect/target/action/delete",
+		HTTPMethod:     http.MethodDelete,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "collectNetTargetRegex",
+		Description:    "立即采集网络设备采集目标",
+		Regex:          regexp.MustCompile(`^/api/v3/collector/netcollect/target/[0-9]+/action/collect/?$`),
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	},
+}
+
+func (ps *parseStream) netTarget() *parseStream {
+	return ParseStreamWithFramework(ps, NetTargetAuthConfigs)
+}
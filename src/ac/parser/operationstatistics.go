@@ -30,6 +30,7 @@ import (
  http.MethodPost,  "/update/operation/chart"
  http.MethodGet,  "/search/operation/chart"
  http.MethodPost,  "/search/operation/chart/data"
+ http.MethodPost,  "/find/operation/statistic"
 */
 var OperationStatisticAuthConfigs = []AuthConfig{
 	{
@@ -86,6 +87,15 @@ var OperationStatisticAuthConfigs = []AuthConfig{
 		ResourceType:   meta.OperationStatistic,
 		ResourceAction: meta.Update,
 	},
+	{
+		Name:           "StatisticQueryOperationStatisticRegex",
+		Description:    "运营统计自定义统计查询",
+		Regex:          regexp.MustCompile(`^/api/v3/find/operation/statistic/?$`),
+		HTTPMethod:     http.MethodPost,
+		BizIDGetter:    nil,
+		ResourceType:   meta.OperationStatistic,
+		ResourceAction: meta.Find,
+	},
 }
 
 // OperationStatistic TODO
@@ -27,6 +27,7 @@ import (
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
 
 	"github.com/tidwall/gjson"
 )
@@ -53,7 +54,8 @@ func (ps *parseStream) topologyLatest() *parseStream {
 		mainlineLatest().
 		setTemplate().
 		modelQuote().
-		fieldTemplate()
+		fieldTemplate().
+		excelImportLatest()
 
 	return ps
 }
@@ -2531,3 +2533,124 @@ func (ps *parseStream) mainlineLatest() *parseStream {
 
 	return ps
 }
+
+const (
+	createExcelImportTaskPattern = "/api/v3/create/excel_import/task"
+	findExcelImportTaskPattern   = "/api/v3/find/excel_import/task"
+)
+
+func (ps *parseStream) excelImportLatest() *parseStream {
+	if ps.shouldReturn() {
+		return ps
+	}
+
+	// create excel import task, authorized the same way as the synchronous import of the same kind
+	if ps.hitPattern(createExcelImportTaskPattern, http.MethodPost) {
+		kind, err := ps.RequestCtx.getValueFromBody("kind")
+		if err != nil {
+			ps.err = err
+			return ps
+		}
+
+		switch kind.String() {
+		case string(metadata.ExcelImportKindInst):
+			ps.excelImportInstAuth()
+		case string(metadata.ExcelImportKindAddHost):
+			ps.excelImportAddHostAuth()
+		default:
+			// update host import task is authorized by host server when the task is executed
+			ps.Attribute.Resources = []meta.ResourceAttribute{
+				{
+					Basic: meta.Basic{
+						Type:   meta.HostInstance,
+						Action: meta.SkipAction,
+					},
+				},
+			}
+		}
+		return ps
+	}
+
+	// find excel import task, only the creator of the task can see it, which is checked by topo server
+	if ps.hitPattern(findExcelImportTaskPattern, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:   meta.MainlineInstance,
+					Action: meta.SkipAction,
+				},
+			},
+		}
+		return ps
+	}
+
+	return ps
+}
+
+func (ps *parseStream) excelImportInstAuth() {
+	objID, err := ps.RequestCtx.getValueFromBody(common.BKObjIDField)
+	if err != nil {
+		ps.err = err
+		return
+	}
+
+	model, err := ps.getOneModel(mapstr.MapStr{common.BKObjIDField: objID.String()})
+	if err != nil {
+		ps.err = err
+		return
+	}
+
+	instanceType, err := ps.getInstanceTypeByObject(model.ObjectID, model.ID)
+	if err != nil {
+		ps.err = err
+		return
+	}
+
+	bizID, err := ps.RequestCtx.getBizIDFromBody()
+	if err != nil {
+		ps.err = err
+		return
+	}
+
+	ps.Attribute.Resources = []meta.ResourceAttribute{
+		{
+			BusinessID: bizID,
+			Basic: meta.Basic{
+				Type:   instanceType,
+				Action: meta.Create,
+			},
+		},
+	}
+}
+
+func (ps *parseStream) excelImportAddHostAuth() {
+	val, err := ps.RequestCtx.getValueFromBody(common.BKModuleIDField)
+	if err != nil {
+		ps.err = err
+		return
+	}
+
+	dirID := val.Int()
+	if dirID == 0 {
+		dirID, err = ps.getResourcePoolDefaultDirID()
+		if err != nil {
+			ps.err = fmt.Errorf("invalid directory id value, %s", err.Error())
+			return
+		}
+	}
+
+	ps.Attribute.Resources = []meta.ResourceAttribute{
+		{
+			Basic: meta.Basic{
+				Type:   meta.HostInstance,
+				Action: meta.AddHostToResourcePool,
+			},
+			Layers: []meta.Item{
+				{
+					Type:       meta.ResourcePoolDirectory,
+					InstanceID: dirID,
+				},
+			},
+		},
+	}
+}
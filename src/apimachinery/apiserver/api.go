@@ -418,6 +418,56 @@ func (a *apiServer) ImportAssociation(ctx context.Context, h http.Header, objID
 	return
 }
 
+// CreateExcelImportTask create an asynchronous excel import task
+func (a *apiServer) CreateExcelImportTask(ctx context.Context, h http.Header,
+	opt *metadata.CreateExcelImportTaskOption) (*metadata.CreateExcelImportTaskResult, error) {
+
+	resp := new(metadata.CreateExcelImportTaskResp)
+	subPath := "/create/excel_import/task"
+
+	err := a.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		IntoCmdbResp(resp)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if ccErr := resp.CCError(); ccErr != nil {
+		return nil, ccErr
+	}
+	return &resp.Data, nil
+}
+
+// FindExcelImportTask find the progress and the result of the excel import task
+func (a *apiServer) FindExcelImportTask(ctx context.Context, h http.Header, opt *metadata.ExcelImportTaskOption) (
+	*metadata.ExcelImportTaskResult, error) {
+
+	resp := new(metadata.ExcelImportTaskResp)
+	subPath := "/find/excel_import/task"
+
+	err := a.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		IntoCmdbResp(resp)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if ccErr := resp.CCError(); ccErr != nil {
+		return nil, ccErr
+	}
+	return &resp.Data, nil
+}
+
 // SearchNetCollectDevice TODO
 func (a *apiServer) SearchNetCollectDevice(ctx context.Context, h http.Header,
 	cond condition.Condition) (resp *metadata.ResponseInstData, err error) {
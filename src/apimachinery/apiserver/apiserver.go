@@ -74,6 +74,10 @@ type ApiServerClientInterface interface {
 		request *metadata.SearchAssociationInstRequest) (resp *metadata.SearchAssociationInstResult, err error)
 	ImportAssociation(ctx context.Context, h http.Header, objID string,
 		input *metadata.RequestImportAssociation) (resp *metadata.ResponeImportAssociation, err error)
+	CreateExcelImportTask(ctx context.Context, h http.Header, opt *metadata.CreateExcelImportTaskOption) (
+		*metadata.CreateExcelImportTaskResult, error)
+	FindExcelImportTask(ctx context.Context, h http.Header, opt *metadata.ExcelImportTaskOption) (
+		*metadata.ExcelImportTaskResult, error)
 
 	SearchNetCollectDevice(ctx context.Context, h http.Header,
 		cond condition.Condition) (resp *metadata.ResponseInstData, err error)
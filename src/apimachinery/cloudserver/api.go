@@ -135,6 +135,54 @@ func (c *cloudserver) DeleteSyncTask(ctx context.Context, h http.Header, taskID
 	return
 }
 
+// PreviewSyncTask 预览同步任务的同步结果
+func (c *cloudserver) PreviewSyncTask(ctx context.Context, h http.Header,
+	taskID int64) (resp *metadata.CloudSyncPreviewResult, err error) {
+	resp = new(metadata.CloudSyncPreviewResult)
+	subPath := "/find/cloud/sync/task/%d/preview"
+
+	err = c.client.Post().
+		WithContext(ctx).
+		Body(nil).
+		SubResourcef(subPath, taskID).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+// ConfirmSyncTask 确认预览结果并执行同步任务
+func (c *cloudserver) ConfirmSyncTask(ctx context.Context, h http.Header, taskID int64,
+	option *metadata.ConfirmSyncTaskOption) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := "/update/cloud/sync/task/%d/confirm"
+
+	err = c.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath, taskID).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+// RollbackSyncHistory 回滚一次同步对主机的修改
+func (c *cloudserver) RollbackSyncHistory(ctx context.Context, h http.Header, taskID,
+	historyID int64) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := "/update/cloud/sync/task/%d/history/%d/rollback"
+
+	err = c.client.Post().
+		WithContext(ctx).
+		Body(nil).
+		SubResourcef(subPath, taskID, historyID).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
 // SearchSyncHistory TODO
 func (c *cloudserver) SearchSyncHistory(ctx context.Context, h http.Header,
 	data map[string]interface{}) (resp *metadata.SearchResp, err error) {
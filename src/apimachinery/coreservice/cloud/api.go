@@ -284,10 +284,10 @@ func (c *cloud) SearchSyncHistory(ctx context.Context, h http.Header,
 	return &ret.Data, nil
 }
 
-// DeleteDestroyedHostRelated TODO
+// DeleteDestroyedHostRelated update the destroyed hosts and delete their related data, returns the deleted data
 func (c *cloud) DeleteDestroyedHostRelated(ctx context.Context, h http.Header,
-	option *metadata.DeleteDestroyedHostRelatedOption) errors.CCErrorCoder {
-	ret := new(metadata.DeletedOptionResult)
+	option *metadata.DeleteDestroyedHostRelatedOption) (*metadata.DestroyedHostRelated, errors.CCErrorCoder) {
+	ret := new(metadata.DestroyedHostRelatedResult)
 	subPath := "/delete/cloud/sync/destroyed_host_related"
 
 	err := c.client.Delete().
@@ -299,11 +299,35 @@ func (c *cloud) DeleteDestroyedHostRelated(ctx context.Context, h http.Header,
 		Into(ret)
 
 	if err != nil {
-		return errors.CCHttpError
+		return nil, errors.CCHttpError
 	}
 	if ret.CCError() != nil {
-		return ret.CCError()
+		return nil, ret.CCError()
 	}
 
-	return nil
+	return &ret.Data, nil
+}
+
+// RestoreDestroyedHostRelated restore the related data that is deleted when the hosts are destroyed
+func (c *cloud) RestoreDestroyedHostRelated(ctx context.Context, h http.Header,
+	related *metadata.DestroyedHostRelated) (*metadata.RestoreDestroyedHostRelatedData, errors.CCErrorCoder) {
+	ret := new(metadata.RestoreDestroyedHostRelatedResult)
+	subPath := "/create/cloud/sync/destroyed_host_related"
+
+	err := c.client.Post().
+		WithContext(ctx).
+		Body(related).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+	if ret.CCError() != nil {
+		return nil, ret.CCError()
+	}
+
+	return &ret.Data, nil
 }
@@ -51,7 +51,9 @@ type CloudInterface interface {
 	SearchSyncHistory(ctx context.Context, h http.Header,
 		option *metadata.SearchSyncHistoryOption) (*metadata.MultipleSyncHistory, errors.CCErrorCoder)
 	DeleteDestroyedHostRelated(ctx context.Context, h http.Header,
-		option *metadata.DeleteDestroyedHostRelatedOption) errors.CCErrorCoder
+		option *metadata.DeleteDestroyedHostRelatedOption) (*metadata.DestroyedHostRelated, errors.CCErrorCoder)
+	RestoreDestroyedHostRelated(ctx context.Context, h http.Header,
+		related *metadata.DestroyedHostRelated) (*metadata.RestoreDestroyedHostRelatedData, errors.CCErrorCoder)
 }
 
 // NewCloudInterfaceClient TODO
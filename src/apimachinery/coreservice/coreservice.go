@@ -33,6 +33,7 @@ import (
 	"configcenter/src/apimachinery/coreservice/instance"
 	"configcenter/src/apimachinery/coreservice/kube"
 	"configcenter/src/apimachinery/coreservice/label"
+	localauth "configcenter/src/apimachinery/coreservice/local_auth"
 	"configcenter/src/apimachinery/coreservice/mainline"
 	"configcenter/src/apimachinery/coreservice/model"
 	modelquote "configcenter/src/apimachinery/coreservice/model_quote"
@@ -74,6 +75,7 @@ type CoreServiceClientInterface interface {
 	ModelQuote() modelquote.Interface
 	FieldTemplate() fieldtmpl.Interface
 	IDRule() idrule.Interface
+	LocalAuth() localauth.Interface
 }
 
 // NewCoreServiceClient TODO
@@ -208,3 +210,8 @@ func (c *coreService) FieldTemplate() fieldtmpl.Interface {
 func (c *coreService) IDRule() idrule.Interface {
 	return idrule.New(c.restCli)
 }
+
+// LocalAuth return the local auth policy client
+func (c *coreService) LocalAuth() localauth.Interface {
+	return localauth.New(c.restCli)
+}
@@ -28,6 +28,8 @@ import (
 // Interface defines id rule apis.
 type Interface interface {
 	UpdateInstIDRule(ctx context.Context, h http.Header, opt *metadata.UpdateInstIDRuleOption) errors.CCErrorCoder
+	PreviewIDRule(ctx context.Context, h http.Header, opt *metadata.PreviewIDRuleOption) (
+		*metadata.PreviewIDRuleResult, errors.CCErrorCoder)
 }
 
 // New idRule api client.
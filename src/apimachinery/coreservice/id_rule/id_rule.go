@@ -49,3 +49,28 @@ func (a *idRule) UpdateInstIDRule(ctx context.Context, h http.Header,
 
 	return nil
 }
+
+// PreviewIDRule preview the next ids of the id rule
+func (a *idRule) PreviewIDRule(ctx context.Context, h http.Header, opt *metadata.PreviewIDRuleOption) (
+	*metadata.PreviewIDRuleResult, errors.CCErrorCoder) {
+
+	resp := new(metadata.PreviewIDRuleResp)
+
+	err := a.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef("/find/id_rule/preview").
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+
+	if err := resp.CCError(); err != nil {
+		return nil, err
+	}
+
+	return resp.Data, nil
+}
@@ -0,0 +1,57 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+// Package localauth defines the local auth policy apis of core service
+package localauth
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/apimachinery/rest"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+)
+
+// Interface defines local auth policy apis.
+type Interface interface {
+	CreateRole(ctx context.Context, h http.Header, opt *metadata.LocalAuthRoleOption) (*metadata.LocalAuthRole,
+		errors.CCErrorCoder)
+	UpdateRole(ctx context.Context, h http.Header, id int64, opt *metadata.LocalAuthRoleOption) errors.CCErrorCoder
+	DeleteRole(ctx context.Context, h http.Header, id int64) errors.CCErrorCoder
+	ListRole(ctx context.Context, h http.Header, opt *metadata.ListLocalAuthRoleOption) (
+		*metadata.ListLocalAuthRoleResult, errors.CCErrorCoder)
+
+	CreateRoleBinding(ctx context.Context, h http.Header, opt *metadata.CreateLocalAuthRoleBindingOption) (
+		*metadata.LocalAuthRoleBinding, errors.CCErrorCoder)
+	UpdateRoleBinding(ctx context.Context, h http.Header, id int64,
+		opt *metadata.UpdateLocalAuthRoleBindingOption) errors.CCErrorCoder
+	DeleteRoleBinding(ctx context.Context, h http.Header, id int64) errors.CCErrorCoder
+	ListRoleBinding(ctx context.Context, h http.Header, opt *metadata.ListLocalAuthRoleBindingOption) (
+		*metadata.ListLocalAuthRoleBindingResult, errors.CCErrorCoder)
+
+	GetUserPolicy(ctx context.Context, h http.Header, user string) (*metadata.LocalAuthUserPolicy,
+		errors.CCErrorCoder)
+}
+
+// New local auth api client.
+func New(client rest.ClientInterface) Interface {
+	return &localAuth{client: client}
+}
+
+type localAuth struct {
+	client rest.ClientInterface
+}
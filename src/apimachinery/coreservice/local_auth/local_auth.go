@@ -0,0 +1,228 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package localauth
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+)
+
+// CreateRole create local auth role
+func (l *localAuth) CreateRole(ctx context.Context, h http.Header, opt *metadata.LocalAuthRoleOption) (
+	*metadata.LocalAuthRole, errors.CCErrorCoder) {
+
+	resp := new(metadata.LocalAuthRoleResp)
+
+	err := l.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef("/create/local_auth/role").
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+
+	if err := resp.CCError(); err != nil {
+		return nil, err
+	}
+
+	return resp.Data, nil
+}
+
+// UpdateRole update local auth role
+func (l *localAuth) UpdateRole(ctx context.Context, h http.Header, id int64,
+	opt *metadata.LocalAuthRoleOption) errors.CCErrorCoder {
+
+	resp := new(metadata.BaseResp)
+
+	err := l.client.Put().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef("/update/local_auth/role/%d", id).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return errors.CCHttpError
+	}
+
+	return resp.CCError()
+}
+
+// DeleteRole delete local auth role
+func (l *localAuth) DeleteRole(ctx context.Context, h http.Header, id int64) errors.CCErrorCoder {
+	resp := new(metadata.BaseResp)
+
+	err := l.client.Delete().
+		WithContext(ctx).
+		SubResourcef("/delete/local_auth/role/%d", id).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return errors.CCHttpError
+	}
+
+	return resp.CCError()
+}
+
+// ListRole list local auth roles
+func (l *localAuth) ListRole(ctx context.Context, h http.Header, opt *metadata.ListLocalAuthRoleOption) (
+	*metadata.ListLocalAuthRoleResult, errors.CCErrorCoder) {
+
+	resp := new(metadata.ListLocalAuthRoleResp)
+
+	err := l.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef("/findmany/local_auth/role").
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+
+	if err := resp.CCError(); err != nil {
+		return nil, err
+	}
+
+	return resp.Data, nil
+}
+
+// CreateRoleBinding create local auth role binding
+func (l *localAuth) CreateRoleBinding(ctx context.Context, h http.Header,
+	opt *metadata.CreateLocalAuthRoleBindingOption) (*metadata.LocalAuthRoleBinding, errors.CCErrorCoder) {
+
+	resp := new(metadata.LocalAuthRoleBindingResp)
+
+	err := l.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef("/create/local_auth/role_binding").
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+
+	if err := resp.CCError(); err != nil {
+		return nil, err
+	}
+
+	return resp.Data, nil
+}
+
+// UpdateRoleBinding update the users of local auth role binding
+func (l *localAuth) UpdateRoleBinding(ctx context.Context, h http.Header, id int64,
+	opt *metadata.UpdateLocalAuthRoleBindingOption) errors.CCErrorCoder {
+
+	resp := new(metadata.BaseResp)
+
+	err := l.client.Put().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef("/update/local_auth/role_binding/%d", id).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return errors.CCHttpError
+	}
+
+	return resp.CCError()
+}
+
+// DeleteRoleBinding delete local auth role binding
+func (l *localAuth) DeleteRoleBinding(ctx context.Context, h http.Header, id int64) errors.CCErrorCoder {
+	resp := new(metadata.BaseResp)
+
+	err := l.client.Delete().
+		WithContext(ctx).
+		SubResourcef("/delete/local_auth/role_binding/%d", id).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return errors.CCHttpError
+	}
+
+	return resp.CCError()
+}
+
+// ListRoleBinding list local auth role bindings
+func (l *localAuth) ListRoleBinding(ctx context.Context, h http.Header,
+	opt *metadata.ListLocalAuthRoleBindingOption) (*metadata.ListLocalAuthRoleBindingResult, errors.CCErrorCoder) {
+
+	resp := new(metadata.ListLocalAuthRoleBindingResp)
+
+	err := l.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef("/findmany/local_auth/role_binding").
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+
+	if err := resp.CCError(); err != nil {
+		return nil, err
+	}
+
+	return resp.Data, nil
+}
+
+// GetUserPolicy get all the role bindings of the user and the roles they refer to
+func (l *localAuth) GetUserPolicy(ctx context.Context, h http.Header, user string) (*metadata.LocalAuthUserPolicy,
+	errors.CCErrorCoder) {
+
+	resp := new(metadata.LocalAuthUserPolicyResp)
+
+	err := l.client.Post().
+		WithContext(ctx).
+		Body(&metadata.GetLocalAuthUserPolicyOption{User: user}).
+		SubResourcef("/find/local_auth/user_policy").
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+
+	if err := resp.CCError(); err != nil {
+		return nil, err
+	}
+
+	return resp.Data, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 
+	"configcenter/src/common/errors"
 	"configcenter/src/common/metadata"
 )
 
@@ -176,3 +177,29 @@ func (s *operation) TimerFreshData(ctx context.Context, h http.Header, data inte
 		Into(resp)
 	return
 }
+
+// StatisticQuery count instances or audit logs of an object by the statistic query
+func (s *operation) StatisticQuery(ctx context.Context, h http.Header, opt *metadata.StatisticQueryOption) (
+	*metadata.StatisticResult, error) {
+
+	resp := new(metadata.StatisticQueryResponse)
+	subPath := "/find/operation/statistic"
+
+	err := s.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+
+	if err = resp.CCError(); err != nil {
+		return nil, err
+	}
+
+	return resp.Data, nil
+}
@@ -24,6 +24,8 @@ type OperationClientInterface interface {
 	SearchChartCommon(ctx context.Context, h http.Header, data interface{}) (resp *metadata.SearchChartCommon,
 		err error)
 	TimerFreshData(ctx context.Context, h http.Header, data interface{}) (resp *metadata.BoolResponse, err error)
+	StatisticQuery(ctx context.Context, h http.Header, opt *metadata.StatisticQueryOption) (
+		*metadata.StatisticResult, error)
 }
 
 // NewOperationClientInterface TODO
@@ -21,6 +21,7 @@ import (
 	"net/http"
 
 	"configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 	"configcenter/src/kube/types"
 )
@@ -89,6 +90,54 @@ func (hs *hostServer) AddHostToResourcePool(ctx context.Context, h http.Header,
 	return
 }
 
+// AddHostByExcel add the hosts imported from excel to resource pool
+func (hs *hostServer) AddHostByExcel(ctx context.Context, h http.Header, dat mapstr.MapStr) (
+	*metadata.ImportInstRes, error) {
+
+	resp := new(metadata.ImportInstResp)
+	subPath := "/hosts/excel/add"
+
+	err := hs.client.Post().
+		WithContext(ctx).
+		Body(dat).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+
+	if ccErr := resp.CCError(); ccErr != nil {
+		return nil, ccErr
+	}
+	return &resp.Data, nil
+}
+
+// UpdateImportHosts update the hosts imported from excel
+func (hs *hostServer) UpdateImportHosts(ctx context.Context, h http.Header, dat mapstr.MapStr) (
+	*metadata.ImportInstRes, error) {
+
+	resp := new(metadata.ImportInstResp)
+	subPath := "/hosts/update"
+
+	err := hs.client.Put().
+		WithContext(ctx).
+		Body(dat).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+
+	if ccErr := resp.CCError(); ccErr != nil {
+		return nil, ccErr
+	}
+	return &resp.Data, nil
+}
+
 // AddHostFromAgent TODO
 func (hs *hostServer) AddHostFromAgent(ctx context.Context, h http.Header, dat interface{}) (resp *metadata.Response,
 	err error) {
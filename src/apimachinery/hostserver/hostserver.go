@@ -25,6 +25,7 @@ import (
 	"configcenter/src/apimachinery/rest"
 	"configcenter/src/apimachinery/util"
 	"configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 	"configcenter/src/kube/types"
 )
@@ -38,6 +39,8 @@ type HostServerClientInterface interface {
 	AddHostToResourcePool(ctx context.Context, h http.Header,
 		dat metadata.AddHostToResourcePoolHostList) (resp *metadata.Response, err error)
 	AddHostFromAgent(ctx context.Context, h http.Header, dat interface{}) (resp *metadata.Response, err error)
+	AddHostByExcel(ctx context.Context, h http.Header, dat mapstr.MapStr) (*metadata.ImportInstRes, error)
+	UpdateImportHosts(ctx context.Context, h http.Header, dat mapstr.MapStr) (*metadata.ImportInstRes, error)
 	SyncHost(ctx context.Context, h http.Header, data interface{}) (resp *metadata.Response, err error)
 
 	GetHostFavourites(ctx context.Context, h http.Header, dat interface{}) (resp *metadata.GetHostFavoriteResult,
@@ -30,6 +30,9 @@ type TaskClientInterface interface {
 	// Create  新加任务， name 任务名，flag:任务标识，留给业务方做识别任务, instID:任务的执行源实例id, data 每一项任务需要的参数
 	Create(ctx context.Context, header http.Header, flag string, instID int64, data []interface{}) (
 		metadata.APITaskDetail, errors.CCErrorCoder)
+	// CreateWithExtra 新加任务，extra与instID一起唯一标识任务，同一个instID下extra不同的任务可以同时执行
+	CreateWithExtra(ctx context.Context, header http.Header, flag string, instID int64, extra interface{},
+		data []interface{}) (metadata.APITaskDetail, errors.CCErrorCoder)
 
 	CreateBatch(c context.Context, h http.Header, tasks []metadata.CreateTaskRequest) ([]metadata.APITaskDetail, error)
 	CreateFieldTemplateBatch(c context.Context, h http.Header, tasks []metadata.CreateTaskRequest) (
@@ -53,6 +56,15 @@ type TaskClientInterface interface {
 
 	ListFieldTemplateTaskSyncResult(ctx context.Context, header http.Header,
 		data *metadata.ListFieldTmplSyncTaskStatusOption) ([]metadata.ListFieldTmplTaskSyncResult, errors.CCErrorCoder)
+
+	// CreateSchedule 新加定时任务，按照cron表达式定时生成任务
+	CreateSchedule(ctx context.Context, header http.Header, option *metadata.CreateTaskScheduleRequest) (
+		*metadata.APITaskSchedule, errors.CCErrorCoder)
+	ListSchedule(ctx context.Context, header http.Header, option *metadata.ListAPITaskScheduleRequest) (
+		*metadata.ListAPITaskScheduleData, errors.CCErrorCoder)
+	PauseSchedule(ctx context.Context, header http.Header, option *metadata.APITaskScheduleIDsOption) errors.CCErrorCoder
+	ResumeSchedule(ctx context.Context, header http.Header, option *metadata.APITaskScheduleIDsOption) errors.CCErrorCoder
+	DeleteSchedule(ctx context.Context, header http.Header, option *metadata.APITaskScheduleIDsOption) errors.CCErrorCoder
 }
 
 // NewTaskClientInterface TODO
@@ -0,0 +1,109 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package task
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+)
+
+// CreateSchedule create task schedule, returns the created task schedule
+func (t *task) CreateSchedule(ctx context.Context, header http.Header, option *metadata.CreateTaskScheduleRequest) (
+	*metadata.APITaskSchedule, errors.CCErrorCoder) {
+
+	resp := new(metadata.CreateTaskScheduleResponse)
+	subPath := "/task/schedule/create"
+
+	err := t.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath).
+		WithHeaders(header).
+		Do().
+		Into(resp)
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+	if err := resp.CCError(); err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+// ListSchedule list task schedules by condition
+func (t *task) ListSchedule(ctx context.Context, header http.Header, option *metadata.ListAPITaskScheduleRequest) (
+	*metadata.ListAPITaskScheduleData, errors.CCErrorCoder) {
+
+	resp := new(metadata.ListAPITaskScheduleResponse)
+	subPath := "/task/schedule/findmany"
+
+	err := t.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath).
+		WithHeaders(header).
+		Do().
+		Into(resp)
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+	if err := resp.CCError(); err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+// PauseSchedule pause task schedules
+func (t *task) PauseSchedule(ctx context.Context, header http.Header,
+	option *metadata.APITaskScheduleIDsOption) errors.CCErrorCoder {
+
+	return t.operateSchedule(ctx, header, "/task/schedule/pause", option)
+}
+
+// ResumeSchedule resume paused task schedules
+func (t *task) ResumeSchedule(ctx context.Context, header http.Header,
+	option *metadata.APITaskScheduleIDsOption) errors.CCErrorCoder {
+
+	return t.operateSchedule(ctx, header, "/task/schedule/resume", option)
+}
+
+// DeleteSchedule delete task schedules
+func (t *task) DeleteSchedule(ctx context.Context, header http.Header,
+	option *metadata.APITaskScheduleIDsOption) errors.CCErrorCoder {
+
+	return t.operateSchedule(ctx, header, "/task/schedule/deletemany", option)
+}
+
+func (t *task) operateSchedule(ctx context.Context, header http.Header, subPath string,
+	option *metadata.APITaskScheduleIDsOption) errors.CCErrorCoder {
+
+	resp := new(metadata.BaseResp)
+	err := t.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath).
+		WithHeaders(header).
+		Do().
+		Into(resp)
+	if err != nil {
+		return errors.CCHttpError
+	}
+	return resp.CCError()
+}
@@ -30,11 +30,19 @@ import (
 func (t *task) Create(ctx context.Context, header http.Header, taskType string, instID int64, data []interface{}) (
 	metadata.APITaskDetail, errors.CCErrorCoder) {
 
+	return t.CreateWithExtra(ctx, header, taskType, instID, nil, data)
+}
+
+// CreateWithExtra 新加任务，extra与instID一起唯一标识任务，同一个instID下extra不同的任务可以同时执行
+func (t *task) CreateWithExtra(ctx context.Context, header http.Header, taskType string, instID int64,
+	extra interface{}, data []interface{}) (metadata.APITaskDetail, errors.CCErrorCoder) {
+
 	resp := new(metadata.CreateTaskResponse)
 	subPath := "/task/create"
 	body := metadata.CreateTaskRequest{
 		TaskType: taskType,
 		InstID:   instID,
+		Extra:    extra,
 		Data:     data,
 	}
 
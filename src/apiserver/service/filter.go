@@ -17,7 +17,6 @@
 package service
 
 import (
-	"context"
 	"fmt"
 	"net/http"
 	"strings"
@@ -331,33 +330,6 @@ func (s *service) verifyAuthorizeStatus(req *restful.Request, errFunc func() err
 	return nil, true
 }
 
-// KEYS[1] is the redis key to incr and expire
-// ARGV[1] is the ttl
-const setRequestCntTTLScript = `
-local cnt = redis.pcall('INCR', KEYS[1]);
-if type(cnt) ~= "number"
-then
-	return cnt
-end
-
-local rs = redis.pcall('TTL', KEYS[1]);
-if type(rs) ~= "number"
-then
-	return rs
-end
-
-if rs == -1
-then
-	rs = redis.pcall('EXPIRE', KEYS[1], ARGV[1]);
-	if type(rs) ~= "number"
-	then
-		return rs
-	end
-end
-
-return cnt
-`
-
 // LimiterFilter limit on a api request according to limiter rules
 func (s *service) LimiterFilter() func(req *restful.Request, resp *restful.Response, fchain *restful.FilterChain) {
 	return func(req *restful.Request, resp *restful.Response, fchain *restful.FilterChain) {
@@ -375,40 +347,42 @@ func (s *service) LimiterFilter() func(req *restful.Request, resp *restful.Respo
 
 		if rule.DenyAll {
 			blog.Errorf("too many requests, matched rule is %#v, rid: %s", *rule, rid)
+			s.respTooManyRequests(req, resp)
+			return
+		}
 
-			s.errorLimiterTotal.With(prometheus.Labels{
-				metrics.LabelAppCode: httpheader.GetAppCode(req.Request.Header),
-				metrics.LabelHandler: req.Request.RequestURI,
-			}).Inc()
-
-			rsp := metadata.BaseResp{
-				Code:   common.CCErrTooManyRequestErr,
-				ErrMsg: "too many requests",
-				Result: false,
+		if rule.Concurrency > 0 {
+			concurrency, err := s.acquireConcurrency(rule, rid)
+			if err != nil {
+				// the request is not limited when the concurrency can not be acquired, so that the api server is
+				// still available when redis fails, the failure is counted for alarm
+				blog.Errorf("acquire concurrency of limiter rule %s failed, skip the concurrency limit, err: %v, "+
+					"rid: %s", rule.RuleName, err, rid)
+				s.collectLimiterFailure(req)
+			} else {
+				concurrency.setHeaders(resp.Header())
+				if !concurrency.acquired {
+					blog.Errorf("too many concurrent requests, matched rule is %#v, rid: %s", *rule, rid)
+					s.respTooManyRequests(req, resp)
+					return
+				}
+				defer concurrency.release()
 			}
-			resp.WriteAsJson(rsp)
-			return
 		}
 
-		exceedLimit, err := s.checkIfExceedLimit(rule, rid)
+		quota, err := s.checkLimiterQuota(rule, rid)
 		if err != nil {
+			blog.Errorf("check quota of limiter rule %s failed, skip the request limit, err: %v, rid: %s",
+				rule.RuleName, err, rid)
+			s.collectLimiterFailure(req)
 			fchain.ProcessFilter(req, resp)
 			return
 		}
 
-		if exceedLimit {
+		quota.setHeaders(resp.Header())
+		if quota.exceeded {
 			blog.Errorf("too many requests, matched rule is %#v, rid: %s", *rule, rid)
-			s.errorLimiterTotal.With(prometheus.Labels{
-				metrics.LabelAppCode: httpheader.GetAppCode(req.Request.Header),
-				metrics.LabelHandler: req.Request.RequestURI,
-			}).Inc()
-
-			rsp := metadata.BaseResp{
-				Code:   common.CCErrTooManyRequestErr,
-				ErrMsg: "too many requests",
-				Result: false,
-			}
-			resp.WriteAsJson(rsp)
+			s.respTooManyRequests(req, resp)
 			return
 		}
 
@@ -417,27 +391,27 @@ func (s *service) LimiterFilter() func(req *restful.Request, resp *restful.Respo
 	}
 }
 
-// checkIfExceedLimit checks if the request exceeds the rate limit, return true if request should be rejected
-func (s *service) checkIfExceedLimit(rule *metadata.LimiterRule, rid string) (bool, error) {
-
-	key := common.ApiCacheLimiterRulePrefix + rule.RuleName
-	result, err := s.cache.Eval(context.Background(), setRequestCntTTLScript, []string{key}, rule.TTL).Result()
-	if err != nil {
-		blog.Errorf("redis Eval failed, key:%s, rule:%#v, err: %v, rid: %s", key, *rule, err, rid)
-		return false, err
-	}
-
-	cnt, ok := result.(int64)
-	if !ok {
-		blog.Errorf("execute setRequestCntTTLScript failed, key:%s, rule:%#v, result: %v, rid: %s",
-			key, *rule, result, rid)
-		return false, fmt.Errorf("execute setRequestCntTTLScript failed, result: %v", result)
-	}
+// collectLimiterFailure counts the request that is not limited because the limiter rule can not be checked
+func (s *service) collectLimiterFailure(req *restful.Request) {
+	s.limiterFailureTotal.With(prometheus.Labels{
+		metrics.LabelAppCode: httpheader.GetAppCode(req.Request.Header),
+		metrics.LabelHandler: req.Request.RequestURI,
+	}).Inc()
+}
 
-	if cnt > rule.Limit {
-		return true, nil
+// respTooManyRequests response the request that is rejected by the limiter rule
+func (s *service) respTooManyRequests(req *restful.Request, resp *restful.Response) {
+	s.errorLimiterTotal.With(prometheus.Labels{
+		metrics.LabelAppCode: httpheader.GetAppCode(req.Request.Header),
+		metrics.LabelHandler: req.Request.RequestURI,
+	}).Inc()
+
+	rsp := metadata.BaseResp{
+		Code:   common.CCErrTooManyRequestErr,
+		ErrMsg: "too many requests",
+		Result: false,
 	}
-	return false, nil
+	resp.WriteAsJson(rsp)
 }
 
 // JwtFilter the filter that handles the source of the jwt request
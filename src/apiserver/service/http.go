@@ -30,6 +30,7 @@ import (
 	httpheader "configcenter/src/common/http/header"
 	ccjson "configcenter/src/common/json"
 	"configcenter/src/common/metadata"
+	"configcenter/src/common/watch"
 	"configcenter/src/thirdparty/monitor"
 	"configcenter/src/thirdparty/monitor/meta"
 
@@ -132,7 +133,11 @@ func (s *service) Do(req *restful.Request, resp *restful.Response) {
 		}
 	}
 
-	parseResponse(req, resp, response.Body, rid)
+	if strings.HasPrefix(response.Header.Get("Content-Type"), watch.EventStreamContentType) {
+		streamResponse(req, resp, response.Body, rid)
+	} else {
+		parseResponse(req, resp, response.Body, rid)
+	}
 
 	blog.V(4).Infof("cost: %dms, action: %s, status code: %d, user: %s, app code: %s, url: %s, rid: %s",
 		time.Since(start).Nanoseconds()/int64(time.Millisecond), req.Request.Method, response.StatusCode,
@@ -142,6 +147,32 @@ func (s *service) Do(req *restful.Request, resp *restful.Response) {
 	return
 }
 
+// streamResponse writes the streaming response to the client as soon as it is received, the response body is not
+// converted since it is not a json response.
+func streamResponse(req *restful.Request, resp *restful.Response, body io.ReadCloser, rid string) {
+	defer body.Close()
+
+	buf := make([]byte, 4096)
+	for {
+		n, err := body.Read(buf)
+		if n > 0 {
+			if _, writeErr := resp.Write(buf[:n]); writeErr != nil {
+				blog.Errorf("write stream response[url: %s] failed, err: %v, rid: %s", req.Request.RequestURI,
+					writeErr, rid)
+				return
+			}
+			resp.Flush()
+		}
+
+		if err != nil {
+			if err != io.EOF && req.Request.Context().Err() == nil {
+				blog.Errorf("read stream response[url: %s] failed, err: %v, rid: %s", req.Request.RequestURI, err, rid)
+			}
+			return
+		}
+	}
+}
+
 func parseResponse(req *restful.Request, resp *restful.Response, body io.ReadCloser, rid string) {
 	// compatible for esb and old ui response
 	// TODO remove this logics and change cc response format when esb is not supported
@@ -0,0 +1,65 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package service
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/require"
+)
+
+// flushRecorder records the written frames when the response is flushed
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+	flushed []string
+}
+
+func (f *flushRecorder) Flush() {
+	f.flushed = append(f.flushed, f.Body.String())
+	f.ResponseRecorder.Flush()
+}
+
+func TestStreamResponse(t *testing.T) {
+	frames := []string{
+		"retry: 3000\n\n",
+		"id: cursor1\nevent: create\ndata: {}\n\n",
+		"id: cursor1\nevent: heartbeat\ndata: {\"bk_cursor\":\"cursor1\"}\n\n",
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		for _, frame := range frames {
+			_, _ = writer.Write([]byte(frame))
+		}
+		_ = writer.Close()
+	}()
+
+	recorder := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	req := restful.NewRequest(httptest.NewRequest("POST", "/api/v3/event/watch/resource/host/stream", nil))
+	streamResponse(req, restful.NewResponse(recorder), reader, "rid")
+
+	// the body is passed through without converting, and each frame is flushed as soon as it is received
+	require.Equal(t, strings.Join(frames, ""), recorder.Body.String())
+	require.Len(t, recorder.flushed, len(frames))
+	for i := range frames {
+		require.Equal(t, strings.Join(frames[:i+1], ""), recorder.flushed[i])
+	}
+}
@@ -0,0 +1,488 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal/redis"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+const (
+	// rateLimitLimitHeader is the response header of the request quota of the matched limiter rule
+	rateLimitLimitHeader = "X-RateLimit-Limit"
+	// rateLimitRemainingHeader is the response header of the remaining request quota of the matched limiter rule
+	rateLimitRemainingHeader = "X-RateLimit-Remaining"
+	// rateLimitResetHeader is the response header of the seconds until the request quota is fully restored
+	rateLimitResetHeader = "X-RateLimit-Reset"
+	// rateLimitConcurrencyHeader is the response header of the concurrency limit of the matched limiter rule
+	rateLimitConcurrencyHeader = "X-RateLimit-Concurrency-Limit"
+	// rateLimitConcurrencyRemainingHeader is the response header of the remaining concurrency of the matched rule
+	rateLimitConcurrencyRemainingHeader = "X-RateLimit-Concurrency-Remaining"
+	// retryAfterHeader is the response header of the seconds to wait before retrying the rejected request
+	retryAfterHeader = "Retry-After"
+
+	// tokenBucketKeyPrefix is the redis key prefix of the token bucket of the limiter rule
+	tokenBucketKeyPrefix = common.ApiCacheLimiterRulePrefix + "token_bucket:"
+	// concurrencyKeyPrefix is the redis key prefix of the in-flight requests of the limiter rule
+	concurrencyKeyPrefix = common.ApiCacheLimiterRulePrefix + "concurrency:"
+	// concurrencyStaleTime is the time after which an in-flight request is regarded as finished, it prevents the
+	// requests that are not released because of the api server crash from occupying the concurrency forever
+	concurrencyStaleTime = 10 * time.Minute
+	// concurrencyRefreshInterval is the interval to refresh the in-flight request, so that the long-running requests
+	// like the watch event streams are not regarded as stale while they are still open
+	concurrencyRefreshInterval = concurrencyStaleTime / 5
+	// concurrencyRetryAfter is the seconds to wait before retrying the request rejected by the concurrency limit,
+	// it is not known when the in-flight requests are finished, so a short time is used
+	concurrencyRetryAfter = 1
+)
+
+// KEYS[1] is the redis key to incr and expire
+// ARGV[1] is the ttl
+// returns the request count and the ttl of the current window
+const setRequestCntTTLScript = `
+local cnt = redis.pcall('INCR', KEYS[1]);
+if type(cnt) ~= "number"
+then
+	return cnt
+end
+
+local rs = redis.pcall('TTL', KEYS[1]);
+if type(rs) ~= "number"
+then
+	return rs
+end
+
+if rs == -1
+then
+	rs = redis.pcall('EXPIRE', KEYS[1], ARGV[1]);
+	if type(rs) ~= "number"
+	then
+		return rs
+	end
+	rs = tonumber(ARGV[1])
+end
+
+return {cnt, rs}
+`
+
+// KEYS[1] is the redis key of the token bucket
+// ARGV[1] is the bucket capacity, ARGV[2] is the refill rate in tokens per millisecond,
+// ARGV[3] is the current time in milliseconds, ARGV[4] is the ttl of the bucket in seconds
+// returns whether the token is taken and the remaining tokens in thousandths
+const takeTokenScript = `
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil or ts == nil
+then
+	tokens = capacity
+	ts = now
+end
+
+if now > ts
+then
+	tokens = math.min(capacity, tokens + (now - ts) * rate)
+	ts = now
+end
+
+local taken = 0
+if tokens >= 1
+then
+	tokens = tokens - 1
+	taken = 1
+end
+
+redis.call('HMSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(ts))
+redis.call('EXPIRE', KEYS[1], ARGV[4])
+return {taken, math.floor(tokens * 1000)}
+`
+
+// KEYS[1] is the redis key of the in-flight requests
+// ARGV[1] is the unique member of the request, ARGV[2] is the current time in milliseconds,
+// ARGV[3] is the stale time in milliseconds, ARGV[4] is the concurrency limit
+// returns whether the request is accepted and the number of in-flight requests
+const acquireConcurrencyScript = `
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', tonumber(ARGV[2]) - tonumber(ARGV[3]))
+local cnt = redis.call('ZCARD', KEYS[1])
+if cnt >= tonumber(ARGV[4])
+then
+	return {0, cnt}
+end
+
+redis.call('ZADD', KEYS[1], ARGV[2], ARGV[1])
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+return {1, cnt + 1}
+`
+
+// KEYS[1] is the redis key of the in-flight requests
+// ARGV[1] is the unique member of the request, ARGV[2] is the current time in milliseconds,
+// ARGV[3] is the stale time in milliseconds
+// returns whether the in-flight request is refreshed, the request that is released or removed is not added back
+const refreshConcurrencyScript = `
+if redis.call('ZSCORE', KEYS[1], ARGV[1]) == false
+then
+	return 0
+end
+
+redis.call('ZADD', KEYS[1], ARGV[2], ARGV[1])
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+return 1
+`
+
+// KEYS[1] is the redis key of the in-flight requests
+// ARGV[1] is the minimum time in milliseconds of the requests that are not stale
+const countConcurrencyScript = `
+return redis.call('ZCOUNT', KEYS[1], ARGV[1], '+inf')
+`
+
+// limiterQuota is the request quota of the matched limiter rule
+type limiterQuota struct {
+	limit     int64
+	remaining int64
+	// reset is the seconds until the quota is fully restored
+	reset int64
+	// retryAfter is the seconds to wait before retrying when the request exceeds the quota
+	retryAfter int64
+	exceeded   bool
+}
+
+// setHeaders set the rate limit response headers of the quota
+func (q *limiterQuota) setHeaders(header http.Header) {
+	header.Set(rateLimitLimitHeader, strconv.FormatInt(q.limit, 10))
+	header.Set(rateLimitRemainingHeader, strconv.FormatInt(q.remaining, 10))
+	header.Set(rateLimitResetHeader, strconv.FormatInt(q.reset, 10))
+	if q.exceeded {
+		header.Set(retryAfterHeader, strconv.FormatInt(q.retryAfter, 10))
+	}
+}
+
+// checkLimiterQuota takes a request quota of the limiter rule, returns the quota after it is taken
+func (s *service) checkLimiterQuota(rule *metadata.LimiterRule, rid string) (*limiterQuota, error) {
+	if rule.GetMode() == metadata.LimiterModeTokenBucket {
+		return s.takeToken(rule, rid)
+	}
+	return s.countRequest(rule, rid)
+}
+
+// countRequest counts the request in the fixed window of the limiter rule
+func (s *service) countRequest(rule *metadata.LimiterRule, rid string) (*limiterQuota, error) {
+	key := common.ApiCacheLimiterRulePrefix + rule.RuleName
+	result, err := s.cache.Eval(context.Background(), setRequestCntTTLScript, []string{key}, rule.TTL).Result()
+	if err != nil {
+		blog.Errorf("redis Eval failed, key:%s, rule:%#v, err: %v, rid: %s", key, *rule, err, rid)
+		return nil, err
+	}
+
+	values, err := parseScriptInts(result, 2)
+	if err != nil {
+		blog.Errorf("execute setRequestCntTTLScript failed, key:%s, rule:%#v, result: %v, rid: %s",
+			key, *rule, result, rid)
+		return nil, fmt.Errorf("execute setRequestCntTTLScript failed, result: %v", result)
+	}
+
+	cnt, ttl := values[0], values[1]
+	quota := &limiterQuota{
+		limit:     rule.Limit,
+		remaining: rule.Limit - cnt,
+		reset:     ttl,
+		exceeded:  cnt > rule.Limit,
+	}
+	if quota.remaining < 0 {
+		quota.remaining = 0
+	}
+	quota.retryAfter = quota.reset
+	return quota, nil
+}
+
+// takeToken takes a token from the token bucket of the limiter rule
+func (s *service) takeToken(rule *metadata.LimiterRule, rid string) (*limiterQuota, error) {
+	key := tokenBucketKeyPrefix + rule.RuleName
+	burst := rule.GetBurst()
+	rate := getTokenRefillRate(rule)
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	// the bucket is full again after it is idle for the time to refill all the tokens, it can be removed then
+	ttl := int64(math.Ceil(float64(burst)/rate/1000)) + 1
+
+	result, err := s.cache.Eval(context.Background(), takeTokenScript, []string{key}, burst,
+		strconv.FormatFloat(rate, 'f', -1, 64), now, ttl).Result()
+	if err != nil {
+		blog.Errorf("redis Eval failed, key:%s, rule:%#v, err: %v, rid: %s", key, *rule, err, rid)
+		return nil, err
+	}
+
+	values, err := parseScriptInts(result, 2)
+	if err != nil {
+		blog.Errorf("execute takeTokenScript failed, key:%s, rule:%#v, result: %v, rid: %s", key, *rule, result, rid)
+		return nil, fmt.Errorf("execute takeTokenScript failed, result: %v", result)
+	}
+
+	return newTokenBucketQuota(burst, rate, float64(values[1])/1000, values[0] == 0), nil
+}
+
+// getTokenRefillRate get the refill rate in tokens per millisecond of the token bucket of the limiter rule
+func getTokenRefillRate(rule *metadata.LimiterRule) float64 {
+	return float64(rule.Limit) / float64(rule.TTL*1000)
+}
+
+// newTokenBucketQuota generate the quota of the token bucket by the remaining tokens
+func newTokenBucketQuota(burst int64, rate, tokens float64, exceeded bool) *limiterQuota {
+	return &limiterQuota{
+		limit:      burst,
+		remaining:  int64(math.Floor(tokens)),
+		reset:      int64(math.Ceil((float64(burst) - tokens) / rate / 1000)),
+		retryAfter: int64(math.Ceil((1 - tokens) / rate / 1000)),
+		exceeded:   exceeded,
+	}
+}
+
+// concurrencyQuota is the concurrency of the matched limiter rule that is occupied by the request
+type concurrencyQuota struct {
+	limit     int64
+	remaining int64
+	acquired  bool
+	// release frees the concurrency occupied by the request, it is nil if the concurrency is not acquired
+	release func()
+}
+
+// setHeaders set the concurrency limit response headers of the quota
+func (q *concurrencyQuota) setHeaders(header http.Header) {
+	header.Set(rateLimitConcurrencyHeader, strconv.FormatInt(q.limit, 10))
+	header.Set(rateLimitConcurrencyRemainingHeader, strconv.FormatInt(q.remaining, 10))
+	if !q.acquired {
+		header.Set(retryAfterHeader, strconv.Itoa(concurrencyRetryAfter))
+	}
+}
+
+// acquireConcurrency occupies a concurrency of the limiter rule, the occupied concurrency is refreshed until it is
+// released, so that the long-running request is not regarded as stale
+func (s *service) acquireConcurrency(rule *metadata.LimiterRule, rid string) (*concurrencyQuota, error) {
+	key := concurrencyKeyPrefix + rule.RuleName
+	// the rid may be set by the caller and shared by concurrent requests, so a random suffix is added to make sure
+	// that the member is unique, otherwise the concurrent requests would occupy only one concurrency
+	member := fmt.Sprintf("%s:%d", rid, rand.Int63())
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+
+	result, err := s.cache.Eval(context.Background(), acquireConcurrencyScript, []string{key}, member, now,
+		concurrencyStaleTime.Milliseconds(), rule.Concurrency).Result()
+	if err != nil {
+		blog.Errorf("redis Eval failed, key:%s, rule:%#v, err: %v, rid: %s", key, *rule, err, rid)
+		return nil, err
+	}
+
+	values, err := parseScriptInts(result, 2)
+	if err != nil {
+		blog.Errorf("execute acquireConcurrencyScript failed, key:%s, rule:%#v, result: %v, rid: %s", key, *rule,
+			result, rid)
+		return nil, fmt.Errorf("execute acquireConcurrencyScript failed, result: %v", result)
+	}
+
+	quota := &concurrencyQuota{limit: rule.Concurrency, remaining: rule.Concurrency - values[1],
+		acquired: values[0] == 1}
+	if quota.remaining < 0 {
+		quota.remaining = 0
+	}
+	if !quota.acquired {
+		return quota, nil
+	}
+
+	stop := make(chan struct{})
+	go s.keepConcurrency(key, member, rid, stop)
+
+	quota.release = func() {
+		close(stop)
+		if err := s.cache.ZRem(context.Background(), key, member).Err(); err != nil {
+			blog.Errorf("release concurrency of rule %s failed, err: %v, rid: %s", rule.RuleName, err, rid)
+		}
+	}
+	return quota, nil
+}
+
+// keepConcurrency refreshes the occupied concurrency periodically until it is released
+func (s *service) keepConcurrency(key, member, rid string, stop <-chan struct{}) {
+	ticker := time.NewTicker(concurrencyRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			refreshed, err := s.refreshConcurrency(key, member)
+			if err != nil {
+				blog.Errorf("refresh concurrency %s of %s failed, err: %v, rid: %s", member, key, err, rid)
+				continue
+			}
+			if !refreshed {
+				return
+			}
+		}
+	}
+}
+
+// refreshConcurrency refreshes the time of the in-flight request, returns false if the request is not in flight
+func (s *service) refreshConcurrency(key, member string) (bool, error) {
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	result, err := s.cache.Eval(context.Background(), refreshConcurrencyScript, []string{key}, member, now,
+		concurrencyStaleTime.Milliseconds()).Result()
+	if err != nil {
+		return false, err
+	}
+
+	refreshed, ok := result.(int64)
+	if !ok {
+		return false, fmt.Errorf("execute refreshConcurrencyScript failed, result: %v", result)
+	}
+	return refreshed == 1, nil
+}
+
+// parseScriptInts parse the integer array result of the lua script
+func parseScriptInts(result interface{}, length int) ([]int64, error) {
+	items, ok := result.([]interface{})
+	if !ok || len(items) != length {
+		return nil, fmt.Errorf("result %v is not an array of length %d", result, length)
+	}
+
+	values := make([]int64, length)
+	for idx, item := range items {
+		value, ok := item.(int64)
+		if !ok {
+			return nil, fmt.Errorf("result item %v is not an integer", item)
+		}
+		values[idx] = value
+	}
+	return values, nil
+}
+
+// GetLimiterUsage get the current usage of all the api limiter rules, it does not take any quota
+func (s *service) GetLimiterUsage(req *restful.Request, resp *restful.Response) {
+	ctx := req.Request.Context()
+	rules := s.limiter.GetRules()
+
+	usages := make([]metadata.LimiterRuleUsage, 0)
+	for _, rule := range rules {
+		usage, err := s.getLimiterRuleUsage(ctx, rule)
+		if err != nil {
+			blog.Errorf("get usage of limiter rule %s failed, err: %v", rule.RuleName, err)
+			resp.WriteError(http.StatusOK, &metadata.RespError{Msg: err})
+			return
+		}
+		usages = append(usages, *usage)
+	}
+
+	sort.Slice(usages, func(i, j int) bool {
+		return usages[i].RuleName < usages[j].RuleName
+	})
+
+	resp.WriteEntity(metadata.NewSuccessResp(usages))
+}
+
+func (s *service) getLimiterRuleUsage(ctx context.Context, rule *metadata.LimiterRule) (
+	*metadata.LimiterRuleUsage, error) {
+
+	usage := &metadata.LimiterRuleUsage{LimiterRule: *rule}
+
+	if rule.Concurrency > 0 {
+		minTime := time.Now().Add(-concurrencyStaleTime).UnixNano() / int64(time.Millisecond)
+		result, err := s.cache.Eval(ctx, countConcurrencyScript, []string{concurrencyKeyPrefix + rule.RuleName},
+			minTime).Result()
+		if err != nil {
+			return nil, err
+		}
+
+		inFlight, ok := result.(int64)
+		if !ok {
+			return nil, fmt.Errorf("execute countConcurrencyScript failed, result: %v", result)
+		}
+		usage.InFlight = inFlight
+	}
+
+	if rule.DenyAll {
+		return usage, nil
+	}
+
+	if rule.GetMode() == metadata.LimiterModeTokenBucket {
+		quota, err := s.getTokenBucketQuota(ctx, rule)
+		if err != nil {
+			return nil, err
+		}
+		usage.Remaining, usage.Reset = quota.remaining, quota.reset
+		return usage, nil
+	}
+
+	key := common.ApiCacheLimiterRulePrefix + rule.RuleName
+	var cnt int64
+	if err := s.cache.Get(ctx, key).Scan(&cnt); err != nil && !redis.IsNilErr(err) {
+		return nil, err
+	}
+
+	usage.Remaining = rule.Limit - cnt
+	if usage.Remaining < 0 {
+		usage.Remaining = 0
+	}
+
+	if cnt > 0 {
+		ttl, err := s.cache.TTL(ctx, key).Result()
+		if err != nil {
+			return nil, err
+		}
+		if ttl > 0 {
+			usage.Reset = int64(math.Ceil(ttl.Seconds()))
+		}
+	}
+
+	return usage, nil
+}
+
+// getTokenBucketQuota get the quota of the token bucket of the limiter rule without taking a token
+func (s *service) getTokenBucketQuota(ctx context.Context, rule *metadata.LimiterRule) (*limiterQuota, error) {
+	burst := rule.GetBurst()
+	rate := getTokenRefillRate(rule)
+
+	bucket, err := s.cache.HMGet(ctx, tokenBucketKeyPrefix+rule.RuleName, "tokens", "ts").Result()
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := float64(burst)
+	if len(bucket) == 2 && bucket[0] != nil && bucket[1] != nil {
+		lastTokens, tokenErr := strconv.ParseFloat(fmt.Sprint(bucket[0]), 64)
+		lastTime, timeErr := strconv.ParseInt(fmt.Sprint(bucket[1]), 10, 64)
+		if tokenErr == nil && timeErr == nil {
+			now := time.Now().UnixNano() / int64(time.Millisecond)
+			tokens = math.Min(float64(burst), lastTokens+float64(now-lastTime)*rate)
+		}
+	}
+
+	return newTokenBucketQuota(burst, rate, tokens, false), nil
+}
@@ -0,0 +1,226 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal/redis"
+
+	"github.com/alicebob/miniredis"
+	goredis "github.com/go-redis/redis/v7"
+	"github.com/stretchr/testify/require"
+)
+
+func newLimiterTestService(t *testing.T) (*service, *miniredis.Miniredis) {
+	redisMock, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(redisMock.Close)
+
+	return &service{cache: redis.NewClient(&goredis.Options{Addr: redisMock.Addr()})}, redisMock
+}
+
+func TestCountRequest(t *testing.T) {
+	s, _ := newLimiterTestService(t)
+	rule := &metadata.LimiterRule{RuleName: "fixed", Limit: 2, TTL: 60}
+
+	expects := []struct {
+		remaining int64
+		exceeded  bool
+	}{
+		{remaining: 1, exceeded: false},
+		{remaining: 0, exceeded: false},
+		{remaining: 0, exceeded: true},
+	}
+
+	for idx, expect := range expects {
+		quota, err := s.checkLimiterQuota(rule, "rid")
+		require.NoError(t, err)
+		require.Equal(t, int64(2), quota.limit, "request %d", idx)
+		require.Equal(t, expect.remaining, quota.remaining, "request %d", idx)
+		require.Equal(t, expect.exceeded, quota.exceeded, "request %d", idx)
+		require.Equal(t, int64(60), quota.reset, "request %d", idx)
+		require.Equal(t, quota.reset, quota.retryAfter, "request %d", idx)
+	}
+
+	usage, err := s.getLimiterRuleUsage(context.Background(), rule)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), usage.Remaining)
+	require.Equal(t, int64(60), usage.Reset)
+}
+
+func TestTakeToken(t *testing.T) {
+	s, redisMock := newLimiterTestService(t)
+	// 1 token is refilled every 10 seconds, at most 2 tokens can be taken at once
+	rule := &metadata.LimiterRule{RuleName: "bucket", Limit: 6, TTL: 60, Burst: 2,
+		Mode: metadata.LimiterModeTokenBucket}
+
+	usage, err := s.getLimiterRuleUsage(context.Background(), rule)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), usage.Remaining)
+	require.Equal(t, int64(0), usage.Reset)
+
+	quota, err := s.checkLimiterQuota(rule, "rid")
+	require.NoError(t, err)
+	require.False(t, quota.exceeded)
+	require.Equal(t, int64(2), quota.limit)
+	require.Equal(t, int64(1), quota.remaining)
+
+	quota, err = s.checkLimiterQuota(rule, "rid")
+	require.NoError(t, err)
+	require.False(t, quota.exceeded)
+	require.Equal(t, int64(0), quota.remaining)
+
+	quota, err = s.checkLimiterQuota(rule, "rid")
+	require.NoError(t, err)
+	require.True(t, quota.exceeded)
+	require.Equal(t, int64(0), quota.remaining)
+	require.InDelta(t, 10, quota.retryAfter, 1)
+	require.InDelta(t, 20, quota.reset, 1)
+
+	// the bucket expires after it is idle for the time to refill all the tokens
+	require.Equal(t, 21*time.Second, redisMock.TTL(tokenBucketKeyPrefix+rule.RuleName))
+
+	usage, err = s.getLimiterRuleUsage(context.Background(), rule)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), usage.Remaining)
+}
+
+func TestAcquireConcurrency(t *testing.T) {
+	s, redisMock := newLimiterTestService(t)
+	rule := &metadata.LimiterRule{RuleName: "concurrency", Concurrency: 2, DenyAll: true}
+	key := concurrencyKeyPrefix + rule.RuleName
+
+	// the requests with the same rid occupy different concurrencies
+	quota1, err := s.acquireConcurrency(rule, "rid")
+	require.NoError(t, err)
+	require.True(t, quota1.acquired)
+	require.Equal(t, int64(1), quota1.remaining)
+	quota2, err := s.acquireConcurrency(rule, "rid")
+	require.NoError(t, err)
+	require.True(t, quota2.acquired)
+	require.Equal(t, int64(0), quota2.remaining)
+	defer quota2.release()
+
+	quota, err := s.acquireConcurrency(rule, "rid")
+	require.NoError(t, err)
+	require.False(t, quota.acquired)
+	require.Nil(t, quota.release)
+
+	// the rejected request tells the client when to retry
+	header := make(http.Header)
+	quota.setHeaders(header)
+	require.Equal(t, "2", header.Get(rateLimitConcurrencyHeader))
+	require.Equal(t, "0", header.Get(rateLimitConcurrencyRemainingHeader))
+	require.Equal(t, strconv.Itoa(concurrencyRetryAfter), header.Get(retryAfterHeader))
+
+	usage, err := s.getLimiterRuleUsage(context.Background(), rule)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), usage.InFlight)
+
+	quota1.release()
+	quota, err = s.acquireConcurrency(rule, "rid")
+	require.NoError(t, err)
+	require.True(t, quota.acquired)
+	defer quota.release()
+
+	// the stale requests are removed and do not occupy the concurrency
+	members, err := redisMock.ZMembers(key)
+	require.NoError(t, err)
+	staleTime := float64(time.Now().Add(-2*concurrencyStaleTime).UnixNano() / int64(time.Millisecond))
+	for _, member := range members {
+		_, err = redisMock.ZAdd(key, staleTime, member)
+		require.NoError(t, err)
+	}
+
+	usage, err = s.getLimiterRuleUsage(context.Background(), rule)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), usage.InFlight)
+
+	quota, err = s.acquireConcurrency(rule, "rid")
+	require.NoError(t, err)
+	require.True(t, quota.acquired)
+	defer quota.release()
+	members, err = redisMock.ZMembers(key)
+	require.NoError(t, err)
+	require.Len(t, members, 1)
+}
+
+func TestRefreshConcurrency(t *testing.T) {
+	s, redisMock := newLimiterTestService(t)
+	key := concurrencyKeyPrefix + "refresh"
+
+	// the long-running request is refreshed so that it is not regarded as stale
+	staleTime := float64(time.Now().Add(-2*concurrencyStaleTime).UnixNano() / int64(time.Millisecond))
+	_, err := redisMock.ZAdd(key, staleTime, "rid:1")
+	require.NoError(t, err)
+
+	refreshed, err := s.refreshConcurrency(key, "rid:1")
+	require.NoError(t, err)
+	require.True(t, refreshed)
+
+	score, err := redisMock.ZScore(key, "rid:1")
+	require.NoError(t, err)
+	require.Greater(t, score, staleTime)
+	require.Equal(t, concurrencyStaleTime, redisMock.TTL(key))
+
+	// the released request is not added back
+	refreshed, err = s.refreshConcurrency(key, "rid:2")
+	require.NoError(t, err)
+	require.False(t, refreshed)
+	members, err := redisMock.ZMembers(key)
+	require.NoError(t, err)
+	require.Equal(t, []string{"rid:1"}, members)
+}
+
+func TestNewTokenBucketQuota(t *testing.T) {
+	// 1 token per 10 seconds
+	rate := getTokenRefillRate(&metadata.LimiterRule{Limit: 6, TTL: 60})
+	require.InDelta(t, 0.0001, rate, 1e-12)
+
+	tests := []struct {
+		name       string
+		tokens     float64
+		exceeded   bool
+		remaining  int64
+		reset      int64
+		retryAfter int64
+	}{
+		{name: "full bucket", tokens: 5, remaining: 5, reset: 0, retryAfter: 0},
+		{name: "partial bucket", tokens: 2.5, remaining: 2, reset: 25, retryAfter: 0},
+		{name: "empty bucket", tokens: 0, exceeded: true, remaining: 0, reset: 50, retryAfter: 10},
+		{name: "almost a token", tokens: 0.75, exceeded: true, remaining: 0, reset: 43, retryAfter: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quota := newTokenBucketQuota(5, rate, tt.tokens, tt.exceeded)
+			require.Equal(t, int64(5), quota.limit)
+			require.Equal(t, tt.remaining, quota.remaining)
+			require.Equal(t, tt.reset, quota.reset)
+			require.Equal(t, tt.exceeded, quota.exceeded)
+			if tt.exceeded {
+				require.Equal(t, tt.retryAfter, quota.retryAfter)
+			}
+		})
+	}
+}
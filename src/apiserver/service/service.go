@@ -19,7 +19,6 @@ package service
 
 import (
 	"configcenter/src/ac"
-	"configcenter/src/ac/iam"
 	"configcenter/src/apimachinery"
 	"configcenter/src/apimachinery/discovery"
 	"configcenter/src/common/auth"
@@ -30,6 +29,7 @@ import (
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/metrics"
 	"configcenter/src/common/rdapi"
+	"configcenter/src/common/watch"
 	"configcenter/src/common/webservice/restfulservice"
 	"configcenter/src/storage/dal/redis"
 
@@ -62,6 +62,8 @@ type service struct {
 	// errorRequestTotal is the total number of request with error response
 	errorRequestTotal *prometheus.CounterVec
 	errorLimiterTotal *prometheus.CounterVec
+	// limiterFailureTotal is the total number of request that is not limited because of limiter failures
+	limiterFailureTotal *prometheus.CounterVec
 }
 
 // SetConfig set config
@@ -73,7 +75,7 @@ func (s *service) SetConfig(engine *backbone.Engine, httpClient HTTPClient, disc
 	s.clientSet = clientSet
 	s.cache = cache
 	s.limiter = limiter
-	s.authorizer = iam.NewAuthorizer(clientSet)
+	s.authorizer = ac.NewAuthorizer(clientSet)
 }
 
 // WebServices TODO
@@ -137,6 +139,17 @@ func (s *service) routeSkipAuthAPI(ws *restful.WebService) {
 	ws.Route(ws.POST("/sync/inst/id_rule").Filter(s.TopoFilterChan).To(s.Post))
 	ws.Route(ws.POST("/sync/id_rule/inst/task").Filter(s.TopoFilterChan).To(s.Post))
 	ws.Route(ws.POST("/find/inst/id_rule/task_status").Filter(s.TopoFilterChan).To(s.Post))
+	ws.Route(ws.POST("/find/id_rule/preview").Filter(s.TopoFilterChan).To(s.Post))
+	ws.Route(ws.POST("/create/excel_import/task").Filter(s.TopoFilterChan).To(s.Post))
+	ws.Route(ws.POST("/find/excel_import/task").Filter(s.TopoFilterChan).To(s.Post))
+	ws.Route(ws.POST("/create/local_auth/role").Filter(s.TopoFilterChan).To(s.Post))
+	ws.Route(ws.PUT("/update/local_auth/role/{.*}").Filter(s.TopoFilterChan).To(s.Put))
+	ws.Route(ws.DELETE("/delete/local_auth/role/{.*}").Filter(s.TopoFilterChan).To(s.Delete))
+	ws.Route(ws.POST("/findmany/local_auth/role").Filter(s.TopoFilterChan).To(s.Post))
+	ws.Route(ws.POST("/create/local_auth/role_binding").Filter(s.TopoFilterChan).To(s.Post))
+	ws.Route(ws.PUT("/update/local_auth/role_binding/{.*}").Filter(s.TopoFilterChan).To(s.Put))
+	ws.Route(ws.DELETE("/delete/local_auth/role_binding/{.*}").Filter(s.TopoFilterChan).To(s.Delete))
+	ws.Route(ws.POST("/findmany/local_auth/role_binding").Filter(s.TopoFilterChan).To(s.Post))
 	ws.Route(ws.POST("/findmany/biz_set/{.*}/kube/containers").Filter(s.TopoFilterChan).To(s.Post))
 
 	ws.Route(ws.POST("/cache/create/full/sync/cond").Filter(s.CacheFilterChan).To(s.Post))
@@ -164,6 +177,12 @@ func (s *service) routeNeedAuthAPI(ws *restful.WebService, errFunc func() errors
 
 	}
 
+	ws.Route(ws.GET("/find/api_limiter/usage").Filter(s.authFilter(errFunc)).To(s.GetLimiterUsage))
+
+	// the watch stream api responses server-sent events, which is not acceptable by the json routes
+	ws.Route(ws.POST("/event/watch/resource/{resource}/stream").Filter(s.authFilter(errFunc)).
+		Filter(s.URLFilterChan).Produces(watch.EventStreamContentType, restful.MIME_JSON).To(s.Post))
+
 	ws.Route(ws.GET("{.*}").Filter(s.authFilter(errFunc)).Filter(s.URLFilterChan).To(s.Get))
 	ws.Route(ws.POST("{.*}").Filter(s.authFilter(errFunc)).Filter(s.URLFilterChan).To(s.Post))
 	ws.Route(ws.PUT("{.*}").Filter(s.authFilter(errFunc)).Filter(s.URLFilterChan).To(s.Put))
@@ -208,4 +227,13 @@ func (s *service) metricsRegister() {
 		[]string{metrics.LabelHandler, metrics.LabelAppCode},
 	)
 	s.engine.Metric().Registry().MustRegister(s.errorLimiterTotal)
+
+	s.limiterFailureTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cmdb_api_total_limiter_failure_count",
+			Help: "total number of requests that are not limited because of limiter failures for apiServer.",
+		},
+		[]string{metrics.LabelHandler, metrics.LabelAppCode},
+	)
+	s.engine.Metric().Registry().MustRegister(s.limiterFailureTotal)
 }
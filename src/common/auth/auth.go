@@ -18,6 +18,7 @@
 package auth
 
 import (
+	"fmt"
 	"strconv"
 	"sync"
 
@@ -73,3 +74,36 @@ func setEnableAuth(enable bool) {
 func EnableAuthorize() bool {
 	return enableAuth
 }
+
+const (
+	// IAMMode authorize with BlueKing IAM through the auth server, it is the default mode
+	IAMMode = "iam"
+	// LocalMode authorize with the built-in rbac policies stored in cmdb db, BlueKing IAM is not needed
+	LocalMode = "local"
+)
+
+var authMode = IAMMode
+
+// SetAuthMode set the authorize mode, it is called when the common config is loaded
+func SetAuthMode(mode string) error {
+	switch mode {
+	case "":
+		authMode = IAMMode
+	case IAMMode, LocalMode:
+		authMode = mode
+	default:
+		return fmt.Errorf("auth mode %s is invalid", mode)
+	}
+	return nil
+}
+
+// IsLocalMode returns if the built-in local authorizer is used
+func IsLocalMode() bool {
+	return authMode == LocalMode
+}
+
+// EnableIAM returns if the authorize is enabled and BlueKing IAM is used, IAM related operations like registering
+// cmdb system and syncing iam views should be skipped in local mode
+func EnableIAM() bool {
+	return enableAuth && authMode == IAMMode
+}
@@ -29,6 +29,7 @@ import (
 	"time"
 
 	"configcenter/src/common"
+	"configcenter/src/common/auth"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/cryptor"
 	ccerr "configcenter/src/common/errors"
@@ -186,14 +187,14 @@ func SetCommonFromByte(data []byte) error {
 			blog.Errorf("fail to read configure from common")
 			return err
 		}
-		return nil
+		return setAuthMode(commonParser)
 	}
 	commonParser, err = newViperParser(data)
 	if err != nil {
 		blog.Errorf("fail to read configure from common")
 		return err
 	}
-	return nil
+	return setAuthMode(commonParser)
 }
 
 // SetCommonFromFile TODO
@@ -206,6 +207,16 @@ func SetCommonFromFile(target string) error {
 		blog.Errorf("fail to read configure from common")
 		return err
 	}
+	return setAuthMode(commonParser)
+}
+
+// setAuthMode set the authorize mode by the authServer.mode common configuration
+func setAuthMode(parser *viperParser) error {
+	mode := parser.getString("authServer.mode")
+	if err := auth.SetAuthMode(mode); err != nil {
+		blog.Errorf("set auth mode failed, err: %v", err)
+		return err
+	}
 	return nil
 }
 
@@ -339,6 +350,20 @@ func Mongo(prefix string) (mongo.Config, error) {
 		c.Mechanism = "SCRAM-SHA-1"
 	}
 
+	if parser.isSet(prefix + ".readPreference") {
+		readPrefConf := mongo.ReadPrefConfig{}
+		if err = parser.unmarshalKey(prefix+".readPreference", &readPrefConf); err != nil {
+			blog.Errorf("parse %s.readPreference config failed, err: %v", prefix, err)
+			return mongo.Config{}, err
+		}
+
+		c.ReadPref, c.CollReadPref, err = readPrefConf.Parse()
+		if err != nil {
+			blog.Errorf("%s.readPreference config is invalid, err: %v", prefix, err)
+			return mongo.Config{}, err
+		}
+	}
+
 	maxOpenConns := prefix + ".maxOpenConns"
 	if !parser.isSet(maxOpenConns) {
 		blog.Errorf("can not find config %s, set default value: %d", maxOpenConns, mongo.DefaultMaxOpenConns)
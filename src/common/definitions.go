@@ -994,7 +994,8 @@ const (
 
 var FieldTypes = []string{FieldTypeSingleChar, FieldTypeLongChar, FieldTypeInt, FieldTypeFloat, FieldTypeEnum,
 	FieldTypeEnumMulti, FieldTypeDate, FieldTypeTime, FieldTypeUser, FieldTypeOrganization, FieldTypeTimeZone,
-	FieldTypeBool, FieldTypeList, FieldTypeTable, FieldTypeInnerTable, FieldTypeEnumQuote}
+	FieldTypeBool, FieldTypeList, FieldTypeTable, FieldTypeInnerTable, FieldTypeEnumQuote, FieldTypeIPAddr,
+	FieldTypeCIDR, FieldTypeURL, FieldTypeJSON, FieldTypeComputed}
 
 const (
 	// FieldTypeSingleChar the single char filed type
@@ -1054,6 +1055,22 @@ const (
 	// FieldTypeIDRule the id rule field type
 	FieldTypeIDRule string = "id_rule"
 
+	// FieldTypeIPAddr the ipv4 or ipv6 address field type
+	FieldTypeIPAddr string = "ipaddr"
+
+	// FieldTypeCIDR the ipv4 or ipv6 network field type in CIDR notation, e.g. 192.168.1.0/24
+	FieldTypeCIDR string = "cidr"
+
+	// FieldTypeURL the url field type
+	FieldTypeURL string = "url"
+
+	// FieldTypeJSON the json field type, its value is validated by the json schema in option if it is set
+	FieldTypeJSON string = "json"
+
+	// FieldTypeComputed the computed field type, its value is calculated by the expression in option from other
+	// fields of the instance, it can not be set by users
+	FieldTypeComputed string = "computed"
+
 	// FieldTypeSingleLenChar the single char length limit
 	FieldTypeSingleLenChar int = 256
 
@@ -1063,6 +1080,9 @@ const (
 	// FieldTypeUserLenChar the user char length limit
 	FieldTypeUserLenChar int = 2000
 
+	// FieldTypeJSONLenLimit the json field value length limit after it is marshaled
+	FieldTypeJSONLenLimit int = 65536
+
 	// FieldTypeStrictCharRegexp the single char regex expression
 	FieldTypeStrictCharRegexp string = `^[a-zA-Z]\w*$`
 
@@ -1404,6 +1424,10 @@ const (
 	BKOpenSourceLoginPluginVersion = "opensource"
 	// BKSkipLoginPluginVersion TODO
 	BKSkipLoginPluginVersion = "skip-login"
+	// BKOIDCLoginPluginVersion is the login type that uses openid connect single sign-on
+	BKOIDCLoginPluginVersion = "oidc"
+	// BKLDAPLoginPluginVersion is the login type that verifies the user name and password by ldap bind
+	BKLDAPLoginPluginVersion = "ldap"
 
 	// BKNoopMonitorPlugin TODO
 	// monitor plugin type
@@ -1438,6 +1462,8 @@ const (
 	WEBSessionAvatarUrlKey = "avatar_url"
 	// WEBSessionMultiSupplierKey TODO
 	WEBSessionMultiSupplierKey = "multisupplier"
+	// WEBSessionDefaultBizKey is the session key of the default business of the login user
+	WEBSessionDefaultBizKey = "default_biz_id"
 
 	// LoginSystemMultiSupplierTrue TODO
 	LoginSystemMultiSupplierTrue = "1"
@@ -1615,6 +1641,8 @@ const (
 	ModelInstChart = "model_inst_chart"
 	// ModelInstChangeChart TODO
 	ModelInstChangeChart = "model_inst_change_chart"
+	// StatisticQueryChart custom chart whose data is calculated by a statistic query
+	StatisticQueryChart = "statistic_query_chart"
 	// CreateObject TODO
 	CreateObject = "create object"
 	// DeleteObject TODO
@@ -1641,6 +1669,8 @@ const (
 	SyncServiceTemplateHostApplyTaskFlag = "service_template_host_apply_sync"
 	// SyncInstIDRuleTaskFlag  instance id rule async task flag.
 	SyncInstIDRuleTaskFlag = "inst_id_rule_sync"
+	// ImportExcelTaskFlag  excel import async task flag.
+	ImportExcelTaskFlag = "excel_import"
 
 	// BKHostState TODO
 	BKHostState = "bk_state"
@@ -1664,6 +1694,7 @@ const (
 	BKCloudVendor                = "bk_cloud_vendor"
 	BKCloudSyncTaskName          = "bk_task_name"
 	BKCloudSyncTaskID            = "bk_task_id"
+	BKCloudSyncHistoryID         = "bk_history_id"
 	BKCloudSyncStatus            = "bk_sync_status"
 	BKCloudSyncStatusDescription = "bk_status_description"
 	BKCloudLastSyncTime          = "bk_last_sync_time"
@@ -1864,6 +1895,24 @@ const (
 
 	// RandomIDVar random id variable
 	RandomIDVar = "random_id"
+
+	// PrefixIncrIDVar self-increasing id variable that is counted separately for each generated prefix
+	PrefixIncrIDVar = "prefix.incr_id"
+
+	// YearIncrIDVar self-increasing id variable that is reset every year
+	YearIncrIDVar = "year.incr_id"
+
+	// MonthIncrIDVar self-increasing id variable that is reset every month
+	MonthIncrIDVar = "month.incr_id"
+
+	// DayIncrIDVar self-increasing id variable that is reset every day
+	DayIncrIDVar = "day.incr_id"
+
+	// DateVar formatted create date variable
+	DateVar = "date"
+
+	// CheckDigitVar check digit variable
+	CheckDigitVar = "check_digit"
 )
 
 const (
@@ -243,6 +243,21 @@ const (
 	// 该状态码只提供给支持可多选字段校验报错时使用，目前用户类型，枚举多选，枚举引用，组织类型校验可多选报错时可以使用
 	CCErrCommParamsNeedSingleChoice = 1199092
 
+	// CCErrCommWebhookRejected the request is rejected by the admission webhook
+	CCErrCommWebhookRejected = 1199093
+
+	// CCErrCommWebhookCallFailed failed to call the admission webhook
+	CCErrCommWebhookCallFailed = 1199094
+
+	// CCErrCommAttrValidatorNotExist the attribute validator plugin is not registered
+	CCErrCommAttrValidatorNotExist = 1199095
+
+	// CCErrCommAttrValidatorFailed the attribute value is rejected by the validator plugin
+	CCErrCommAttrValidatorFailed = 1199096
+
+	// CCErrCommLocalAuthRoleInUse the local auth role can not be deleted because it is still bound to users
+	CCErrCommLocalAuthRoleInUse = 1199097
+
 	// too many requests
 	CCErrTooManyRequestErr = 1199997
 
@@ -778,6 +793,8 @@ const (
 	CCErrWebDisableNotification         = 1111025
 	CCErrWebGetAnnFail                  = 1111026
 	CCErrInvalidFileTypeFail            = 1111027
+	CCErrWebSSOLoginFailed              = 1111028
+	CCErrWebPasswordLoginNotSupported   = 1111029
 
 	// datacollection 1112xxx
 	CCErrCollectNetDeviceCreateFail            = 1112000
@@ -799,6 +816,7 @@ const (
 	CCErrCollectNetHistorySearchFail           = 1112016
 	CCErrCollectNetDeviceUpdateFail            = 1112017
 	CCErrCollectNetPropertyUpdateFail          = 1112018
+	CCErrCollectNetTargetCollectFail           = 1112019
 
 	// coreservice 1113xxx
 	// CCErrorModelAttributeGroupHasSomeAttributes the group has some attributes
@@ -887,6 +905,8 @@ const (
 	CCErrCoreServiceFieldTemplateHasRelation = 1113042
 	// CCErrCoreServiceHostRelateToKube some hosts has related container resources
 	CCErrCoreServiceHostRelateToKube = 1113043
+	// CCErrCoreServiceAttrReferencedByComputed attribute %s is referenced by computed attribute %s
+	CCErrCoreServiceAttrReferencedByComputed = 1113044
 
 	// synchronize data core service  11139xx
 	CCErrCoreServiceSyncError = 1113900
@@ -948,6 +968,11 @@ const (
 	CCErrGetCloudAccountConfBatchFailed       = 1118021
 	CCErrDeleteDestroyedHostRelatedFailed     = 1118022
 	CCErrCloudAccountDeletedFailedForSyncTask = 1118023
+	// CCErrCloudSyncPreviewChanged the cloud sync diff has changed since it was previewed
+	CCErrCloudSyncPreviewChanged = 1118024
+
+	// CCErrCloudSyncTaskIsSyncing the cloud sync task is being synchronized
+	CCErrCloudSyncTaskIsSyncing = 1118025
 
 	/** TODO: 以下错误码需要改造 **/
 
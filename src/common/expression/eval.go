@@ -0,0 +1,419 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package expression
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type node interface {
+	eval(data map[string]interface{}) (interface{}, error)
+}
+
+type literalNode struct {
+	val interface{}
+}
+
+func (n *literalNode) eval(map[string]interface{}) (interface{}, error) {
+	return n.val, nil
+}
+
+type fieldNode struct {
+	field string
+}
+
+func (n *fieldNode) eval(data map[string]interface{}) (interface{}, error) {
+	return normalize(data[n.field])
+}
+
+type unaryNode struct {
+	op      string
+	operand node
+}
+
+func (n *unaryNode) eval(data map[string]interface{}) (interface{}, error) {
+	val, err := n.operand.eval(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.op == "!" {
+		return !truthy(val), nil
+	}
+
+	switch v := val.(type) {
+	case nil:
+		return nil, nil
+	case int64:
+		return -v, nil
+	case float64:
+		return -v, nil
+	default:
+		return nil, fmt.Errorf("operator - can not be applied to %T value", val)
+	}
+}
+
+type binaryNode struct {
+	op    string
+	left  node
+	right node
+}
+
+func (n *binaryNode) eval(data map[string]interface{}) (interface{}, error) {
+	left, err := n.left.eval(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// logical operators are short-circuited
+	switch n.op {
+	case "&&":
+		if !truthy(left) {
+			return false, nil
+		}
+		right, err := n.right.eval(data)
+		if err != nil {
+			return nil, err
+		}
+		return truthy(right), nil
+	case "||":
+		if truthy(left) {
+			return true, nil
+		}
+		right, err := n.right.eval(data)
+		if err != nil {
+			return nil, err
+		}
+		return truthy(right), nil
+	}
+
+	right, err := n.right.eval(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	case "<", "<=", ">", ">=":
+		return compare(n.op, left, right)
+	case "+":
+		_, leftIsStr := left.(string)
+		_, rightIsStr := right.(string)
+		if leftIsStr || rightIsStr {
+			return toString(left) + toString(right), nil
+		}
+		return arithmetic(n.op, left, right)
+	default:
+		return arithmetic(n.op, left, right)
+	}
+}
+
+func arithmetic(op string, left, right interface{}) (interface{}, error) {
+	if left == nil || right == nil {
+		return nil, nil
+	}
+
+	li, leftIsInt := left.(int64)
+	ri, rightIsInt := right.(int64)
+	if leftIsInt && rightIsInt && op != "/" {
+		switch op {
+		case "+":
+			return li + ri, nil
+		case "-":
+			return li - ri, nil
+		case "*":
+			return li * ri, nil
+		case "%":
+			if ri == 0 {
+				return nil, fmt.Errorf("modulo by zero")
+			}
+			return li % ri, nil
+		}
+	}
+
+	lf, ok := toFloat(left)
+	if !ok {
+		return nil, fmt.Errorf("operator %s can not be applied to %T value", op, left)
+	}
+	rf, ok := toFloat(right)
+	if !ok {
+		return nil, fmt.Errorf("operator %s can not be applied to %T value", op, right)
+	}
+
+	switch op {
+	case "+":
+		return lf + rf, nil
+	case "-":
+		return lf - rf, nil
+	case "*":
+		return lf * rf, nil
+	case "/":
+		if rf == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return lf / rf, nil
+	case "%":
+		if rf == 0 {
+			return nil, fmt.Errorf("modulo by zero")
+		}
+		return math.Mod(lf, rf), nil
+	default:
+		return nil, fmt.Errorf("unsupported operator %s", op)
+	}
+}
+
+func equal(left, right interface{}) bool {
+	lf, leftIsNum := toFloat(left)
+	rf, rightIsNum := toFloat(right)
+	if leftIsNum && rightIsNum {
+		return lf == rf
+	}
+	return left == right
+}
+
+func compare(op string, left, right interface{}) (interface{}, error) {
+	if left == nil || right == nil {
+		return false, nil
+	}
+
+	var cmp int
+	lf, leftIsNum := toFloat(left)
+	rf, rightIsNum := toFloat(right)
+	ls, leftIsStr := left.(string)
+	rs, rightIsStr := right.(string)
+	switch {
+	case leftIsNum && rightIsNum:
+		switch {
+		case lf < rf:
+			cmp = -1
+		case lf > rf:
+			cmp = 1
+		}
+	case leftIsStr && rightIsStr:
+		cmp = strings.Compare(ls, rs)
+	default:
+		return nil, fmt.Errorf("operator %s can not be applied to %T and %T values", op, left, right)
+	}
+
+	switch op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+// normalize converts the attribute value to one of the types that the expression supports
+func normalize(val interface{}) (interface{}, error) {
+	switch v := val.(type) {
+	case nil, string, bool, int64, float64:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case int8:
+		return int64(v), nil
+	case int16:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case uint:
+		return int64(v), nil
+	case uint8:
+		return int64(v), nil
+	case uint16:
+		return int64(v), nil
+	case uint32:
+		return int64(v), nil
+	case uint64:
+		return int64(v), nil
+	case float32:
+		return float64(v), nil
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, nil
+		}
+		return v.Float64()
+	default:
+		return nil, fmt.Errorf("value type %T is not supported in expression", val)
+	}
+}
+
+func toFloat(val interface{}) (float64, bool) {
+	switch v := val.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+func toString(val interface{}) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func truthy(val interface{}) bool {
+	switch v := val.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case int64:
+		return v != 0
+	case float64:
+		return v != 0
+	default:
+		return true
+	}
+}
+
+type callNode struct {
+	name string
+	fn   function
+	args []node
+}
+
+func (n *callNode) eval(data map[string]interface{}) (interface{}, error) {
+	// if function evaluates its arguments lazily
+	if n.name == "if" {
+		cond, err := n.args[0].eval(data)
+		if err != nil {
+			return nil, err
+		}
+		if truthy(cond) {
+			return n.args[1].eval(data)
+		}
+		return n.args[2].eval(data)
+	}
+
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		val, err := arg.eval(data)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = val
+	}
+
+	val, err := n.fn.call(args)
+	if err != nil {
+		return nil, fmt.Errorf("call function %s failed, err: %v", n.name, err)
+	}
+	return val, nil
+}
+
+type function struct {
+	minArgs int
+	// maxArgs is the max argument count, -1 means no limit
+	maxArgs int
+	call    func(args []interface{}) (interface{}, error)
+}
+
+// functions are the builtin functions that can be used in expression
+var functions = map[string]function{
+	"if": {minArgs: 3, maxArgs: 3},
+	"concat": {minArgs: 1, maxArgs: -1, call: func(args []interface{}) (interface{}, error) {
+		var sb strings.Builder
+		for _, arg := range args {
+			sb.WriteString(toString(arg))
+		}
+		return sb.String(), nil
+	}},
+	"upper": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+		return strings.ToUpper(toString(args[0])), nil
+	}},
+	"lower": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+		return strings.ToLower(toString(args[0])), nil
+	}},
+	"len": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+		return int64(utf8.RuneCountInString(toString(args[0]))), nil
+	}},
+	"coalesce": {minArgs: 1, maxArgs: -1, call: func(args []interface{}) (interface{}, error) {
+		for _, arg := range args {
+			if arg != nil && arg != "" {
+				return arg, nil
+			}
+		}
+		return nil, nil
+	}},
+	"round": {minArgs: 1, maxArgs: 2, call: func(args []interface{}) (interface{}, error) {
+		if args[0] == nil {
+			return nil, nil
+		}
+		val, ok := toFloat(args[0])
+		if !ok {
+			return nil, fmt.Errorf("argument %v is not a number", args[0])
+		}
+
+		var precision int64
+		if len(args) == 2 {
+			if precision, ok = args[1].(int64); !ok || precision < 0 || precision > 15 {
+				return nil, fmt.Errorf("precision %v is invalid", args[1])
+			}
+		}
+		pow := math.Pow10(int(precision))
+		return math.Round(val*pow) / pow, nil
+	}},
+	"min": {minArgs: 1, maxArgs: -1, call: func(args []interface{}) (interface{}, error) {
+		return extremum(args, func(a, b float64) bool { return a < b })
+	}},
+	"max": {minArgs: 1, maxArgs: -1, call: func(args []interface{}) (interface{}, error) {
+		return extremum(args, func(a, b float64) bool { return a > b })
+	}},
+}
+
+func extremum(args []interface{}, better func(a, b float64) bool) (interface{}, error) {
+	var result interface{}
+	var resultVal float64
+	for _, arg := range args {
+		if arg == nil {
+			continue
+		}
+		val, ok := toFloat(arg)
+		if !ok {
+			return nil, fmt.Errorf("argument %v is not a number", arg)
+		}
+		if result == nil || better(val, resultVal) {
+			result, resultVal = arg, val
+		}
+	}
+	return result, nil
+}
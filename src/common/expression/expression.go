@@ -0,0 +1,336 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+// Package expression parses and evaluates the expression of the computed model attributes. The expression is
+// composed of literals, attribute ids, arithmetic/comparison/logical operators and a few builtin functions, e.g.
+// concat(bk_host_innerip, ":", port) or cpu * 2
+package expression
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	// maxExpressionLength is the max length of an expression
+	maxExpressionLength = 1024
+	// maxDepth is the max nesting depth of an expression
+	maxDepth = 32
+)
+
+// Expression is a parsed expression that can be evaluated with the attribute values of an instance
+type Expression struct {
+	raw    string
+	root   node
+	fields []string
+}
+
+// Parse parses the expression
+func Parse(expr string) (*Expression, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, fmt.Errorf("expression is empty")
+	}
+
+	if len(expr) > maxExpressionLength {
+		return nil, fmt.Errorf("expression length exceeds max length %d", maxExpressionLength)
+	}
+
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens, fields: make(map[string]struct{})}
+	root, err := p.parseExpr(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.peek().kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected token %s at position %d", p.peek().val, p.peek().pos)
+	}
+
+	fields := make([]string, 0, len(p.fields))
+	for field := range p.fields {
+		fields = append(fields, field)
+	}
+
+	return &Expression{raw: expr, root: root, fields: fields}, nil
+}
+
+// String returns the raw expression
+func (e *Expression) String() string {
+	return e.raw
+}
+
+// Fields returns the attribute ids that the expression references
+func (e *Expression) Fields() []string {
+	return e.fields
+}
+
+// Eval evaluates the expression with the attribute values, the result is one of nil, int64, float64, string and bool.
+// arithmetic with a nil operand results in nil, so that a missing attribute value does not generate a wrong result
+func (e *Expression) Eval(data map[string]interface{}) (interface{}, error) {
+	return e.root.eval(data)
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type token struct {
+	kind tokenKind
+	val  string
+	pos  int
+}
+
+var operators = []string{"==", "!=", "<=", ">=", "&&", "||", "+", "-", "*", "/", "%", "<", ">", "!"}
+
+func tokenize(expr string) ([]token, error) {
+	tokens := make([]token, 0)
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, val: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, val: ")", pos: i})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{kind: tokenComma, val: ",", pos: i})
+			i++
+		case r == '"' || r == '\'':
+			start := i
+			var sb strings.Builder
+			i++
+			for ; i < len(runes) && runes[i] != r; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				sb.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d", start)
+			}
+			i++
+			tokens = append(tokens, token{kind: tokenString, val: sb.String(), pos: start})
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, val: string(runes[start:i]), pos: start})
+		case r == '_' || unicode.IsLetter(r):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, val: string(runes[start:i]), pos: start})
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(string(runes[i:]), op) {
+					tokens = append(tokens, token{kind: tokenOperator, val: op, pos: i})
+					i += len([]rune(op))
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("invalid character %q at position %d", r, i)
+			}
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, val: "EOF", pos: len(runes)}), nil
+}
+
+// binaryPrecedence defines the precedence of the binary operators, the bigger the tighter
+var binaryPrecedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"==": 3, "!=": 3, "<": 3, "<=": 3, ">": 3, ">=": 3,
+	"+": 4, "-": 4,
+	"*": 5, "/": 5, "%": 5,
+}
+
+type parser struct {
+	tokens []token
+	idx    int
+	depth  int
+	fields map[string]struct{}
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.idx]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.idx]
+	if t.kind != tokenEOF {
+		p.idx++
+	}
+	return t
+}
+
+func (p *parser) expect(kind tokenKind, val string) error {
+	t := p.next()
+	if t.kind != kind {
+		return fmt.Errorf("expect %s at position %d, but got %s", val, t.pos, t.val)
+	}
+	return nil
+}
+
+// parseExpr parses binary expressions whose operator precedence is bigger than minPrec by precedence climbing
+func (p *parser) parseExpr(minPrec int) (node, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxDepth {
+		return nil, fmt.Errorf("expression nesting depth exceeds max depth %d", maxDepth)
+	}
+
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t := p.peek()
+		prec, ok := binaryPrecedence[t.val]
+		if t.kind != tokenOperator || !ok || prec <= minPrec {
+			return left, nil
+		}
+		p.next()
+
+		right, err := p.parseExpr(prec)
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: t.val, left: left, right: right}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	t := p.peek()
+	if t.kind == tokenOperator && (t.val == "-" || t.val == "!") {
+		p.next()
+		p.depth++
+		defer func() { p.depth-- }()
+		if p.depth > maxDepth {
+			return nil, fmt.Errorf("expression nesting depth exceeds max depth %d", maxDepth)
+		}
+
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: t.val, operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenNumber:
+		if i, err := strconv.ParseInt(t.val, 10, 64); err == nil {
+			return &literalNode{val: i}, nil
+		}
+		f, err := strconv.ParseFloat(t.val, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s at position %d", t.val, t.pos)
+		}
+		return &literalNode{val: f}, nil
+	case tokenString:
+		return &literalNode{val: t.val}, nil
+	case tokenLParen:
+		n, err := p.parseExpr(0)
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expect(tokenRParen, ")"); err != nil {
+			return nil, err
+		}
+		return n, nil
+	case tokenIdent:
+		switch t.val {
+		case "true":
+			return &literalNode{val: true}, nil
+		case "false":
+			return &literalNode{val: false}, nil
+		case "null":
+			return &literalNode{val: nil}, nil
+		}
+
+		if p.peek().kind != tokenLParen {
+			p.fields[t.val] = struct{}{}
+			return &fieldNode{field: t.val}, nil
+		}
+		return p.parseCall(t)
+	default:
+		return nil, fmt.Errorf("unexpected token %s at position %d", t.val, t.pos)
+	}
+}
+
+func (p *parser) parseCall(name token) (node, error) {
+	fn, exists := functions[name.val]
+	if !exists {
+		return nil, fmt.Errorf("unknown function %s at position %d", name.val, name.pos)
+	}
+	p.next()
+
+	args := make([]node, 0)
+	if p.peek().kind == tokenRParen {
+		p.next()
+	} else {
+		for {
+			arg, err := p.parseExpr(0)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+
+			t := p.next()
+			if t.kind == tokenRParen {
+				break
+			}
+			if t.kind != tokenComma {
+				return nil, fmt.Errorf("expect , or ) at position %d, but got %s", t.pos, t.val)
+			}
+		}
+	}
+
+	if len(args) < fn.minArgs || (fn.maxArgs >= 0 && len(args) > fn.maxArgs) {
+		return nil, fmt.Errorf("invalid argument count %d of function %s", len(args), name.val)
+	}
+	return &callNode{name: name.val, fn: fn, args: args}, nil
+}
@@ -0,0 +1,91 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package expression
+
+import (
+	"encoding/json"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	expr, err := Parse(`concat(bk_inst_name, "-", upper(region)) + suffix`)
+	require.NoError(t, err)
+	fields := expr.Fields()
+	sort.Strings(fields)
+	require.Equal(t, []string{"bk_inst_name", "region", "suffix"}, fields)
+
+	invalid := []string{"", "a +", "(a", "unknown(a)", "upper(a, b)", "a $ b", `"abc`, "a b", "1..2"}
+	for _, e := range invalid {
+		_, err := Parse(e)
+		require.Error(t, err, e)
+	}
+}
+
+func TestEval(t *testing.T) {
+	data := map[string]interface{}{
+		"cpu":    4,
+		"mem":    json.Number("8192"),
+		"price":  1.5,
+		"name":   "host",
+		"region": "gz",
+		"empty":  nil,
+	}
+
+	cases := []struct {
+		expr   string
+		result interface{}
+	}{
+		{"cpu * 2 + 1", int64(9)},
+		{"cpu * (2 + 1)", int64(12)},
+		{"-cpu + 10", int64(6)},
+		{"mem / 1024", float64(8)},
+		{"mem % 1000", int64(192)},
+		{"cpu * price", float64(6)},
+		{"cpu + empty", nil},
+		{"missing * 2", nil},
+		{`name + "-" + cpu`, "host-4"},
+		{`concat(upper(region), ":", name, empty)`, "GZ:host"},
+		{"len(name)", int64(4)},
+		{"cpu > 2 && region == 'gz'", true},
+		{"cpu > 8 || !name", false},
+		{`if(cpu >= 4, "large", "small")`, "large"},
+		{`coalesce(empty, "", region)`, "gz"},
+		{"round(price / 4, 2)", 0.38},
+		{"max(cpu, price, empty)", int64(4)},
+		{"min(cpu, price)", 1.5},
+		{"cpu == 4.0", true},
+	}
+
+	for _, c := range cases {
+		expr, err := Parse(c.expr)
+		require.NoError(t, err, c.expr)
+		result, err := expr.Eval(data)
+		require.NoError(t, err, c.expr)
+		require.Equal(t, c.result, result, c.expr)
+	}
+
+	errCases := []string{"cpu / 0", "name * 2", "name > cpu", "-name"}
+	for _, e := range errCases {
+		expr, err := Parse(e)
+		require.NoError(t, err, e)
+		_, err = expr.Eval(data)
+		require.Error(t, err, e)
+	}
+}
@@ -0,0 +1,581 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+// Package jsonschema implements a subset of the JSON Schema specification that is used to validate the value of
+// the json type model attributes. the supported keywords are: type, enum, const, properties, required,
+// additionalProperties, minProperties, maxProperties, items, minItems, maxItems, uniqueItems, minimum, maximum,
+// exclusiveMinimum, exclusiveMaximum, multipleOf, minLength, maxLength, pattern, allOf, anyOf, oneOf and not.
+// annotation keywords like title and description are ignored, other keywords like $ref are not supported.
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"unicode/utf8"
+)
+
+// annotationKeywords are the keywords that do not affect validation
+var annotationKeywords = map[string]struct{}{
+	"$schema":     {},
+	"$id":         {},
+	"$comment":    {},
+	"title":       {},
+	"description": {},
+	"default":     {},
+	"examples":    {},
+	"format":      {},
+}
+
+var validTypes = map[string]struct{}{
+	"object":  {},
+	"array":   {},
+	"string":  {},
+	"number":  {},
+	"integer": {},
+	"boolean": {},
+	"null":    {},
+}
+
+// Schema is a compiled json schema
+type Schema struct {
+	types            []string
+	enum             []interface{}
+	constVal         interface{}
+	hasConst         bool
+	properties       map[string]*Schema
+	required         []string
+	additionalBool   *bool
+	additional       *Schema
+	minProperties    *int
+	maxProperties    *int
+	items            *Schema
+	minItems         *int
+	maxItems         *int
+	uniqueItems      bool
+	minimum          *float64
+	maximum          *float64
+	exclusiveMinimum *float64
+	exclusiveMaximum *float64
+	multipleOf       *float64
+	minLength        *int
+	maxLength        *int
+	pattern          *regexp.Regexp
+	allOf            []*Schema
+	anyOf            []*Schema
+	oneOf            []*Schema
+	not              *Schema
+	// alwaysFalse is set when the schema is boolean false
+	alwaysFalse bool
+}
+
+// Compile compiles the json schema, the schema can be a json object(map) or a boolean
+func Compile(schema interface{}) (*Schema, error) {
+	return compile(schema, "#")
+}
+
+func compile(raw interface{}, path string) (*Schema, error) {
+	raw = normalize(raw)
+	if b, ok := raw.(bool); ok {
+		return &Schema{alwaysFalse: !b}, nil
+	}
+
+	obj, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: schema must be an object or a boolean", path)
+	}
+
+	s := new(Schema)
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var err error
+	for _, key := range keys {
+		val := obj[key]
+		keyPath := path + "/" + key
+		if _, exists := annotationKeywords[key]; exists {
+			continue
+		}
+
+		switch key {
+		case "type":
+			s.types, err = compileTypes(val, keyPath)
+		case "enum":
+			arr, ok := val.([]interface{})
+			if !ok || len(arr) == 0 {
+				return nil, fmt.Errorf("%s: must be a non-empty array", keyPath)
+			}
+			s.enum = arr
+		case "const":
+			s.constVal, s.hasConst = val, true
+		case "properties":
+			s.properties, err = compileProperties(val, keyPath)
+		case "required":
+			s.required, err = compileStrings(val, keyPath)
+		case "additionalProperties":
+			if b, ok := val.(bool); ok {
+				s.additionalBool = &b
+				continue
+			}
+			s.additional, err = compile(val, keyPath)
+		case "items":
+			s.items, err = compile(val, keyPath)
+		case "allOf", "anyOf", "oneOf":
+			var schemas []*Schema
+			if schemas, err = compileSchemas(val, keyPath); err != nil {
+				return nil, err
+			}
+			switch key {
+			case "allOf":
+				s.allOf = schemas
+			case "anyOf":
+				s.anyOf = schemas
+			default:
+				s.oneOf = schemas
+			}
+		case "not":
+			s.not, err = compile(val, keyPath)
+		case "uniqueItems":
+			if s.uniqueItems, ok = val.(bool); !ok {
+				return nil, fmt.Errorf("%s: must be a boolean", keyPath)
+			}
+		case "pattern":
+			str, ok := val.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: must be a string", keyPath)
+			}
+			if s.pattern, err = regexp.Compile(str); err != nil {
+				return nil, fmt.Errorf("%s: invalid regular expression, err: %v", keyPath, err)
+			}
+		case "minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "multipleOf":
+			f, ok := toFloat(val)
+			if !ok {
+				return nil, fmt.Errorf("%s: must be a number", keyPath)
+			}
+			if key == "multipleOf" && f <= 0 {
+				return nil, fmt.Errorf("%s: must be greater than 0", keyPath)
+			}
+			*s.numberKeyword(key) = &f
+		case "minProperties", "maxProperties", "minItems", "maxItems", "minLength", "maxLength":
+			f, ok := toFloat(val)
+			if !ok || f < 0 || f != math.Trunc(f) {
+				return nil, fmt.Errorf("%s: must be a non-negative integer", keyPath)
+			}
+			i := int(f)
+			*s.countKeyword(key) = &i
+		default:
+			return nil, fmt.Errorf("%s: keyword is not supported", keyPath)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+func (s *Schema) numberKeyword(key string) **float64 {
+	switch key {
+	case "minimum":
+		return &s.minimum
+	case "maximum":
+		return &s.maximum
+	case "exclusiveMinimum":
+		return &s.exclusiveMinimum
+	case "exclusiveMaximum":
+		return &s.exclusiveMaximum
+	default:
+		return &s.multipleOf
+	}
+}
+
+func (s *Schema) countKeyword(key string) **int {
+	switch key {
+	case "minProperties":
+		return &s.minProperties
+	case "maxProperties":
+		return &s.maxProperties
+	case "minItems":
+		return &s.minItems
+	case "maxItems":
+		return &s.maxItems
+	case "minLength":
+		return &s.minLength
+	default:
+		return &s.maxLength
+	}
+}
+
+func compileTypes(val interface{}, path string) ([]string, error) {
+	var types []string
+	if str, ok := val.(string); ok {
+		types = []string{str}
+	} else {
+		var err error
+		if types, err = compileStrings(val, path); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, typ := range types {
+		if _, exists := validTypes[typ]; !exists {
+			return nil, fmt.Errorf("%s: invalid type %s", path, typ)
+		}
+	}
+	return types, nil
+}
+
+func compileStrings(val interface{}, path string) ([]string, error) {
+	arr, ok := val.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: must be an array of strings", path)
+	}
+
+	result := make([]string, len(arr))
+	for i, item := range arr {
+		if result[i], ok = item.(string); !ok {
+			return nil, fmt.Errorf("%s: must be an array of strings", path)
+		}
+	}
+	return result, nil
+}
+
+func compileProperties(val interface{}, path string) (map[string]*Schema, error) {
+	obj, ok := val.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: must be an object", path)
+	}
+
+	props := make(map[string]*Schema, len(obj))
+	for name, prop := range obj {
+		schema, err := compile(prop, path+"/"+name)
+		if err != nil {
+			return nil, err
+		}
+		props[name] = schema
+	}
+	return props, nil
+}
+
+func compileSchemas(val interface{}, path string) ([]*Schema, error) {
+	arr, ok := val.([]interface{})
+	if !ok || len(arr) == 0 {
+		return nil, fmt.Errorf("%s: must be a non-empty array", path)
+	}
+
+	schemas := make([]*Schema, len(arr))
+	for i, item := range arr {
+		schema, err := compile(item, fmt.Sprintf("%s/%d", path, i))
+		if err != nil {
+			return nil, err
+		}
+		schemas[i] = schema
+	}
+	return schemas, nil
+}
+
+// Validate validates the value by the schema, the value can be the decoded result of json or bson
+func (s *Schema) Validate(value interface{}) error {
+	return s.validate(normalize(value), "#")
+}
+
+func (s *Schema) validate(val interface{}, path string) error {
+	if s.alwaysFalse {
+		return fmt.Errorf("%s: value is not allowed", path)
+	}
+
+	if len(s.types) > 0 && !matchTypes(s.types, val) {
+		return fmt.Errorf("%s: expect type %v, but got %s", path, s.types, typeOf(val))
+	}
+
+	if len(s.enum) > 0 {
+		matched := false
+		for _, item := range s.enum {
+			if equal(item, val) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s: value must be one of %v", path, s.enum)
+		}
+	}
+
+	if s.hasConst && !equal(s.constVal, val) {
+		return fmt.Errorf("%s: value must be %v", path, s.constVal)
+	}
+
+	var err error
+	switch v := val.(type) {
+	case map[string]interface{}:
+		err = s.validateObject(v, path)
+	case []interface{}:
+		err = s.validateArray(v, path)
+	case string:
+		err = s.validateString(v, path)
+	case float64:
+		err = s.validateNumber(v, path)
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.validateCombination(val, path)
+}
+
+func (s *Schema) validateObject(obj map[string]interface{}, path string) error {
+	if s.minProperties != nil && len(obj) < *s.minProperties {
+		return fmt.Errorf("%s: property count must be at least %d", path, *s.minProperties)
+	}
+	if s.maxProperties != nil && len(obj) > *s.maxProperties {
+		return fmt.Errorf("%s: property count must be at most %d", path, *s.maxProperties)
+	}
+
+	for _, name := range s.required {
+		if _, exists := obj[name]; !exists {
+			return fmt.Errorf("%s: property %s is required", path, name)
+		}
+	}
+
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		propPath := path + "/" + name
+		if prop, exists := s.properties[name]; exists {
+			if err := prop.validate(obj[name], propPath); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if s.additionalBool != nil && !*s.additionalBool {
+			return fmt.Errorf("%s: additional property is not allowed", propPath)
+		}
+		if s.additional != nil {
+			if err := s.additional.validate(obj[name], propPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Schema) validateArray(arr []interface{}, path string) error {
+	if s.minItems != nil && len(arr) < *s.minItems {
+		return fmt.Errorf("%s: item count must be at least %d", path, *s.minItems)
+	}
+	if s.maxItems != nil && len(arr) > *s.maxItems {
+		return fmt.Errorf("%s: item count must be at most %d", path, *s.maxItems)
+	}
+
+	for i, item := range arr {
+		if s.items != nil {
+			if err := s.items.validate(item, fmt.Sprintf("%s/%d", path, i)); err != nil {
+				return err
+			}
+		}
+
+		if s.uniqueItems {
+			for j := 0; j < i; j++ {
+				if equal(arr[j], item) {
+					return fmt.Errorf("%s: items must be unique", path)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Schema) validateString(str string, path string) error {
+	length := utf8.RuneCountInString(str)
+	if s.minLength != nil && length < *s.minLength {
+		return fmt.Errorf("%s: length must be at least %d", path, *s.minLength)
+	}
+	if s.maxLength != nil && length > *s.maxLength {
+		return fmt.Errorf("%s: length must be at most %d", path, *s.maxLength)
+	}
+	if s.pattern != nil && !s.pattern.MatchString(str) {
+		return fmt.Errorf("%s: value does not match pattern %s", path, s.pattern.String())
+	}
+	return nil
+}
+
+func (s *Schema) validateNumber(num float64, path string) error {
+	if s.minimum != nil && num < *s.minimum {
+		return fmt.Errorf("%s: value must be >= %v", path, *s.minimum)
+	}
+	if s.maximum != nil && num > *s.maximum {
+		return fmt.Errorf("%s: value must be <= %v", path, *s.maximum)
+	}
+	if s.exclusiveMinimum != nil && num <= *s.exclusiveMinimum {
+		return fmt.Errorf("%s: value must be > %v", path, *s.exclusiveMinimum)
+	}
+	if s.exclusiveMaximum != nil && num >= *s.exclusiveMaximum {
+		return fmt.Errorf("%s: value must be < %v", path, *s.exclusiveMaximum)
+	}
+	if s.multipleOf != nil {
+		quotient := num / *s.multipleOf
+		if quotient != math.Trunc(quotient) {
+			return fmt.Errorf("%s: value must be a multiple of %v", path, *s.multipleOf)
+		}
+	}
+	return nil
+}
+
+func (s *Schema) validateCombination(val interface{}, path string) error {
+	for _, sub := range s.allOf {
+		if err := sub.validate(val, path); err != nil {
+			return err
+		}
+	}
+
+	if len(s.anyOf) > 0 {
+		matched := false
+		for _, sub := range s.anyOf {
+			if sub.validate(val, path) == nil {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s: value does not match any schema of anyOf", path)
+		}
+	}
+
+	if len(s.oneOf) > 0 {
+		count := 0
+		for _, sub := range s.oneOf {
+			if sub.validate(val, path) == nil {
+				count++
+			}
+		}
+		if count != 1 {
+			return fmt.Errorf("%s: value must match exactly one schema of oneOf, but matched %d", path, count)
+		}
+	}
+
+	if s.not != nil && s.not.validate(val, path) == nil {
+		return fmt.Errorf("%s: value must not match the schema of not", path)
+	}
+	return nil
+}
+
+func matchTypes(types []string, val interface{}) bool {
+	actual := typeOf(val)
+	for _, typ := range types {
+		if typ == actual {
+			return true
+		}
+		if typ == "number" && actual == "integer" {
+			return true
+		}
+	}
+	return false
+}
+
+func typeOf(val interface{}) string {
+	switch v := val.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if v == math.Trunc(v) && !math.IsInf(v, 0) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", val)
+	}
+}
+
+func equal(a, b interface{}) bool {
+	return reflect.DeepEqual(normalize(a), normalize(b))
+}
+
+func toFloat(val interface{}) (float64, bool) {
+	f, ok := normalize(val).(float64)
+	return f, ok
+}
+
+// normalize converts the value to the types of the standard json decoding result, so that the values decoded by json
+// with UseNumber, or by bson, or the named map/slice types like mapstr.MapStr can be validated in the same way
+func normalize(val interface{}) interface{} {
+	switch v := val.(type) {
+	case nil, bool, string, float64:
+		return v
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return v.String()
+		}
+		return f
+	}
+
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	case reflect.String:
+		return rv.String()
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return val
+		}
+		result := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			result[iter.Key().String()] = normalize(iter.Value().Interface())
+		}
+		return result
+	case reflect.Slice, reflect.Array:
+		result := make([]interface{}, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			result[i] = normalize(rv.Index(i).Interface())
+		}
+		return result
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return nil
+		}
+		return normalize(rv.Elem().Interface())
+	default:
+		return val
+	}
+}
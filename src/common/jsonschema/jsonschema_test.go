@@ -0,0 +1,119 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package jsonschema
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompile(t *testing.T) {
+	invalid := []interface{}{
+		"object",
+		map[string]interface{}{"type": "unknown"},
+		map[string]interface{}{"$ref": "#/definitions/a"},
+		map[string]interface{}{"pattern": "("},
+		map[string]interface{}{"minLength": -1},
+		map[string]interface{}{"required": []interface{}{1}},
+		map[string]interface{}{"anyOf": []interface{}{}},
+		map[string]interface{}{"properties": map[string]interface{}{"a": 1}},
+	}
+	for _, schema := range invalid {
+		_, err := Compile(schema)
+		require.Error(t, err, schema)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	raw := `{
+		"title": "port config",
+		"type": "object",
+		"required": ["port"],
+		"additionalProperties": false,
+		"properties": {
+			"port": {"type": "integer", "minimum": 1, "maximum": 65535},
+			"protocol": {"enum": ["tcp", "udp"]},
+			"name": {"type": "string", "pattern": "^[a-z]+$", "maxLength": 8},
+			"tags": {"type": "array", "items": {"type": "string"}, "uniqueItems": true, "maxItems": 2},
+			"weight": {"type": ["number", "null"], "exclusiveMinimum": 0}
+		}
+	}`
+	var schemaVal interface{}
+	require.NoError(t, json.Unmarshal([]byte(raw), &schemaVal))
+	schema, err := Compile(schemaVal)
+	require.NoError(t, err)
+
+	valid := []string{
+		`{"port": 80}`,
+		`{"port": 8080, "protocol": "tcp", "name": "web", "tags": ["a", "b"], "weight": 0.5}`,
+		`{"port": 53, "weight": null}`,
+	}
+	for _, val := range valid {
+		decoder := json.NewDecoder(strings.NewReader(val))
+		decoder.UseNumber()
+		var v interface{}
+		require.NoError(t, decoder.Decode(&v))
+		require.NoError(t, schema.Validate(v), val)
+	}
+
+	invalid := []string{
+		`[]`,
+		`{}`,
+		`{"port": 0}`,
+		`{"port": 80.5}`,
+		`{"port": 80, "protocol": "http"}`,
+		`{"port": 80, "name": "Web"}`,
+		`{"port": 80, "tags": ["a", "a"]}`,
+		`{"port": 80, "tags": [1]}`,
+		`{"port": 80, "weight": 0}`,
+		`{"port": 80, "other": 1}`,
+	}
+	for _, val := range invalid {
+		var v interface{}
+		require.NoError(t, json.Unmarshal([]byte(val), &v))
+		require.Error(t, schema.Validate(v), val)
+	}
+
+	// named map types and native go values should be validated like json values
+	type mapStr map[string]interface{}
+	require.NoError(t, schema.Validate(mapStr{"port": int64(22), "tags": []string{"ssh"}}))
+	require.Error(t, schema.Validate(mapStr{"port": uint8(0)}))
+}
+
+func TestCombination(t *testing.T) {
+	schema, err := Compile(map[string]interface{}{
+		"oneOf": []interface{}{
+			map[string]interface{}{"type": "string"},
+			map[string]interface{}{"type": "integer", "multipleOf": 2},
+		},
+		"not": map[string]interface{}{"const": "forbidden"},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, schema.Validate("abc"))
+	require.NoError(t, schema.Validate(4))
+	require.Error(t, schema.Validate(3))
+	require.Error(t, schema.Validate("forbidden"))
+	require.Error(t, schema.Validate(true))
+
+	falseSchema, err := Compile(false)
+	require.NoError(t, err)
+	require.Error(t, falseSchema.Validate(nil))
+}
@@ -23,6 +23,17 @@ import (
 	"configcenter/src/common/util"
 )
 
+// LimiterMode is the rate limiting algorithm of the api limiter rule
+type LimiterMode string
+
+const (
+	// LimiterModeFixedWindow counts the requests in a fixed window of ttl seconds, it is the default mode
+	LimiterModeFixedWindow LimiterMode = "fixed_window"
+	// LimiterModeTokenBucket refills limit tokens every ttl seconds into a bucket whose capacity is burst, each request
+	// takes a token, so the requests are spread smoothly instead of being rejected at the window edges
+	LimiterModeTokenBucket LimiterMode = "token_bucket"
+)
+
 // LimiterRule is a rule for api limiter
 type LimiterRule struct {
 	RuleName string `json:"rulename"`
@@ -34,6 +45,28 @@ type LimiterRule struct {
 	Limit    int64  `json:"limit"`
 	TTL      int64  `json:"ttl"`
 	DenyAll  bool   `json:"denyall"`
+	// Mode is the rate limiting algorithm, default is fixed window
+	Mode LimiterMode `json:"mode,omitempty"`
+	// Burst is the bucket capacity of the token bucket mode, default is the limit
+	Burst int64 `json:"burst,omitempty"`
+	// Concurrency is the maximum number of in-flight requests matching the rule, 0 means no concurrency limit
+	Concurrency int64 `json:"concurrency,omitempty"`
+}
+
+// GetMode get the rate limiting algorithm of the rule
+func (r LimiterRule) GetMode() LimiterMode {
+	if r.Mode == "" {
+		return LimiterModeFixedWindow
+	}
+	return r.Mode
+}
+
+// GetBurst get the bucket capacity of the token bucket mode
+func (r LimiterRule) GetBurst() int64 {
+	if r.Burst <= 0 {
+		return r.Limit
+	}
+	return r.Burst
 }
 
 // Verify to check the fields of LimiterRule
@@ -59,5 +92,31 @@ func (r LimiterRule) Verify() error {
 			return fmt.Errorf("both limit and ttl must be set and bigger than 0 when denyall is false")
 		}
 	}
+	switch r.Mode {
+	case "", LimiterModeFixedWindow:
+		if r.Burst != 0 {
+			return fmt.Errorf("burst can only be set in %s mode", LimiterModeTokenBucket)
+		}
+	case LimiterModeTokenBucket:
+		if r.Burst < 0 {
+			return fmt.Errorf("burst can not be less than 0")
+		}
+	default:
+		return fmt.Errorf("mode must be one of %s,%s", LimiterModeFixedWindow, LimiterModeTokenBucket)
+	}
+	if r.Concurrency < 0 {
+		return fmt.Errorf("concurrency can not be less than 0")
+	}
 	return nil
 }
+
+// LimiterRuleUsage is the current usage of an api limiter rule
+type LimiterRuleUsage struct {
+	LimiterRule
+	// Remaining is the number of requests that can be accepted now
+	Remaining int64 `json:"remaining"`
+	// Reset is the seconds until the quota is fully restored
+	Reset int64 `json:"reset"`
+	// InFlight is the number of in-flight requests matching the rule, it is only counted when concurrency is set
+	InFlight int64 `json:"in_flight"`
+}
@@ -20,6 +20,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
+	"net/url"
 	"reflect"
 	"regexp"
 	"strconv"
@@ -30,6 +32,7 @@ import (
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/errors"
+	"configcenter/src/common/jsonschema"
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/util"
 	"configcenter/src/common/valid/attribute/manager"
@@ -90,6 +93,8 @@ const (
 	AttributeFieldDefault = "default"
 	// AttributeFieldIsMultiple the is multiple name field
 	AttributeFieldIsMultiple = "ismultiple"
+	// AttributeFieldValidators the validator plugins attached to the attribute field
+	AttributeFieldValidators = "bk_validators"
 )
 
 const (
@@ -104,33 +109,41 @@ const (
 
 // Attribute attribute metadata definition
 type Attribute struct {
-	BizID             int64       `field:"bk_biz_id" json:"bk_biz_id" bson:"bk_biz_id" mapstructure:"bk_biz_id"`
-	ID                int64       `field:"id" json:"id" bson:"id" mapstructure:"id"`
-	OwnerID           string      `field:"bk_supplier_account" json:"bk_supplier_account" bson:"bk_supplier_account" mapstructure:"bk_supplier_account"`
-	ObjectID          string      `field:"bk_obj_id" json:"bk_obj_id" bson:"bk_obj_id" mapstructure:"bk_obj_id"`
-	PropertyID        string      `field:"bk_property_id" json:"bk_property_id" bson:"bk_property_id" mapstructure:"bk_property_id"`
-	PropertyName      string      `field:"bk_property_name" json:"bk_property_name" bson:"bk_property_name" mapstructure:"bk_property_name"`
-	PropertyGroup     string      `field:"bk_property_group" json:"bk_property_group" bson:"bk_property_group" mapstructure:"bk_property_group"`
-	PropertyGroupName string      `field:"bk_property_group_name,ignoretomap" json:"bk_property_group_name" bson:"-" mapstructure:"bk_property_group_name"`
-	PropertyIndex     int64       `field:"bk_property_index" json:"bk_property_index" bson:"bk_property_index" mapstructure:"bk_property_index"`
-	Unit              string      `field:"unit" json:"unit" bson:"unit" mapstructure:"unit"`
-	Placeholder       string      `field:"placeholder" json:"placeholder" bson:"placeholder" mapstructure:"placeholder"`
-	IsEditable        bool        `field:"editable" json:"editable" bson:"editable" mapstructure:"editable"`
-	IsPre             bool        `field:"ispre" json:"ispre" bson:"ispre" mapstructure:"ispre"`
-	IsRequired        bool        `field:"isrequired" json:"isrequired" bson:"isrequired" mapstructure:"isrequired"`
-	IsReadOnly        bool        `field:"isreadonly" json:"isreadonly" bson:"isreadonly" mapstructure:"isreadonly"`
-	IsOnly            bool        `field:"isonly" json:"isonly" bson:"isonly" mapstructure:"isonly"`
-	IsSystem          bool        `field:"bk_issystem" json:"bk_issystem" bson:"bk_issystem" mapstructure:"bk_issystem"`
-	IsAPI             bool        `field:"bk_isapi" json:"bk_isapi" bson:"bk_isapi" mapstructure:"bk_isapi"`
-	PropertyType      string      `field:"bk_property_type" json:"bk_property_type" bson:"bk_property_type" mapstructure:"bk_property_type"`
-	Option            interface{} `field:"option" json:"option" bson:"option" mapstructure:"option"`
-	Default           interface{} `field:"default" json:"default,omitempty" bson:"default" mapstructure:"default"`
-	IsMultiple        *bool       `field:"ismultiple" json:"ismultiple,omitempty" bson:"ismultiple" mapstructure:"ismultiple"`
-	Description       string      `field:"description" json:"description" bson:"description" mapstructure:"description"`
-	TemplateID        int64       `field:"bk_template_id" json:"bk_template_id" bson:"bk_template_id" mapstructure:"bk_template_id"`
-	Creator           string      `field:"creator" json:"creator" bson:"creator" mapstructure:"creator"`
-	CreateTime        *Time       `json:"create_time" bson:"create_time" mapstructure:"create_time"`
-	LastTime          *Time       `json:"last_time" bson:"last_time" mapstructure:"last_time"`
+	BizID             int64           `field:"bk_biz_id" json:"bk_biz_id" bson:"bk_biz_id" mapstructure:"bk_biz_id"`
+	ID                int64           `field:"id" json:"id" bson:"id" mapstructure:"id"`
+	OwnerID           string          `field:"bk_supplier_account" json:"bk_supplier_account" bson:"bk_supplier_account" mapstructure:"bk_supplier_account"`
+	ObjectID          string          `field:"bk_obj_id" json:"bk_obj_id" bson:"bk_obj_id" mapstructure:"bk_obj_id"`
+	PropertyID        string          `field:"bk_property_id" json:"bk_property_id" bson:"bk_property_id" mapstructure:"bk_property_id"`
+	PropertyName      string          `field:"bk_property_name" json:"bk_property_name" bson:"bk_property_name" mapstructure:"bk_property_name"`
+	PropertyGroup     string          `field:"bk_property_group" json:"bk_property_group" bson:"bk_property_group" mapstructure:"bk_property_group"`
+	PropertyGroupName string          `field:"bk_property_group_name,ignoretomap" json:"bk_property_group_name" bson:"-" mapstructure:"bk_property_group_name"`
+	PropertyIndex     int64           `field:"bk_property_index" json:"bk_property_index" bson:"bk_property_index" mapstructure:"bk_property_index"`
+	Unit              string          `field:"unit" json:"unit" bson:"unit" mapstructure:"unit"`
+	Placeholder       string          `field:"placeholder" json:"placeholder" bson:"placeholder" mapstructure:"placeholder"`
+	IsEditable        bool            `field:"editable" json:"editable" bson:"editable" mapstructure:"editable"`
+	IsPre             bool            `field:"ispre" json:"ispre" bson:"ispre" mapstructure:"ispre"`
+	IsRequired        bool            `field:"isrequired" json:"isrequired" bson:"isrequired" mapstructure:"isrequired"`
+	IsReadOnly        bool            `field:"isreadonly" json:"isreadonly" bson:"isreadonly" mapstructure:"isreadonly"`
+	IsOnly            bool            `field:"isonly" json:"isonly" bson:"isonly" mapstructure:"isonly"`
+	IsSystem          bool            `field:"bk_issystem" json:"bk_issystem" bson:"bk_issystem" mapstructure:"bk_issystem"`
+	IsAPI             bool            `field:"bk_isapi" json:"bk_isapi" bson:"bk_isapi" mapstructure:"bk_isapi"`
+	PropertyType      string          `field:"bk_property_type" json:"bk_property_type" bson:"bk_property_type" mapstructure:"bk_property_type"`
+	Option            interface{}     `field:"option" json:"option" bson:"option" mapstructure:"option"`
+	Default           interface{}     `field:"default" json:"default,omitempty" bson:"default" mapstructure:"default"`
+	IsMultiple        *bool           `field:"ismultiple" json:"ismultiple,omitempty" bson:"ismultiple" mapstructure:"ismultiple"`
+	Validators        []AttrValidator `field:"bk_validators" json:"bk_validators,omitempty" bson:"bk_validators,omitempty" mapstructure:"bk_validators"`
+	Description       string          `field:"description" json:"description" bson:"description" mapstructure:"description"`
+	TemplateID        int64           `field:"bk_template_id" json:"bk_template_id" bson:"bk_template_id" mapstructure:"bk_template_id"`
+	Creator           string          `field:"creator" json:"creator" bson:"creator" mapstructure:"creator"`
+	CreateTime        *Time           `json:"create_time" bson:"create_time" mapstructure:"create_time"`
+	LastTime          *Time           `json:"last_time" bson:"last_time" mapstructure:"last_time"`
+}
+
+// AttrValidator the validator plugin attached to the attribute, the validator is registered in
+// valid/attribute/plugins and referenced by name
+type AttrValidator struct {
+	Name   string                 `json:"name" bson:"name" mapstructure:"name"`
+	Params map[string]interface{} `json:"params,omitempty" bson:"params,omitempty" mapstructure:"params"`
 }
 
 // AttributeGroup attribute metadata definition
@@ -194,6 +207,11 @@ func (attribute *Attribute) Validate(ctx context.Context, data interface{}, key
 		common.FieldTypeOrganization: attribute.validOrganization,
 		common.FieldTypeInnerTable:   attribute.validInnerTable,
 		common.FieldTypeIDRule:       attribute.validIDRule,
+		common.FieldTypeIPAddr:       attribute.validIPAddr,
+		common.FieldTypeCIDR:         attribute.validCIDR,
+		common.FieldTypeURL:          attribute.validURL,
+		common.FieldTypeJSON:         attribute.validJSON,
+		common.FieldTypeComputed:     attribute.validComputed,
 	}
 
 	rawError := errors.RawErrorInfo{}
@@ -1132,6 +1150,203 @@ func (attribute *Attribute) validIDRule(ctx context.Context, val interface{}, ke
 	return errors.RawErrorInfo{}
 }
 
+// validIPAddr valid object attribute that is ip address type
+func (attribute *Attribute) validIPAddr(ctx context.Context, val interface{}, key string) errors.RawErrorInfo {
+	return attribute.validNetworkAddr(ctx, val, key, func(value string) (net.IP, bool) {
+		ip := net.ParseIP(value)
+		return ip, ip != nil
+	})
+}
+
+// validCIDR valid object attribute that is cidr type
+func (attribute *Attribute) validCIDR(ctx context.Context, val interface{}, key string) errors.RawErrorInfo {
+	return attribute.validNetworkAddr(ctx, val, key, func(value string) (net.IP, bool) {
+		ip, _, err := net.ParseCIDR(value)
+		return ip, err == nil
+	})
+}
+
+// NormalizeNetworkAddr returns the canonical form of the ipaddr or cidr attribute value, e.g. " 10.0.0.1 " is
+// normalized to "10.0.0.1", so that the stored value can be matched by the anchored regex of the ip_in_cidr
+// operator. the value is returned as it is if it is not a valid ipaddr or cidr value
+func NormalizeNetworkAddr(propertyType string, val interface{}) interface{} {
+	value, ok := val.(string)
+	if !ok {
+		return val
+	}
+	value = strings.TrimSpace(value)
+
+	switch propertyType {
+	case common.FieldTypeIPAddr:
+		if ip := net.ParseIP(value); ip != nil {
+			return ip.String()
+		}
+	case common.FieldTypeCIDR:
+		if ip, network, err := net.ParseCIDR(value); err == nil {
+			ones, _ := network.Mask.Size()
+			return ip.String() + "/" + strconv.Itoa(ones)
+		}
+	default:
+		return val
+	}
+	return value
+}
+
+func (attribute *Attribute) validNetworkAddr(ctx context.Context, val interface{}, key string,
+	parse func(string) (net.IP, bool)) errors.RawErrorInfo {
+
+	rid := util.ExtractRequestIDFromContext(ctx)
+	value, rawErr := attribute.getStringValue(ctx, val, key, common.FieldTypeSingleLenChar)
+	if rawErr.ErrCode != 0 || value == "" {
+		return rawErr
+	}
+
+	ip, ok := parse(value)
+	if !ok {
+		blog.Errorf("params %s is not a valid %s value, rid: %s", value, attribute.PropertyType, rid)
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{key}}
+	}
+
+	version, err := ParseIPVersionOption(attribute.Option)
+	if err != nil {
+		blog.Errorf("parse %s option %+v failed, err: %v, rid: %s", attribute.PropertyType, attribute.Option, err, rid)
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{key}}
+	}
+
+	if (version == IPVersion4 && ip.To4() == nil) || (version == IPVersion6 && ip.To4() != nil) {
+		blog.Errorf("params %s is not a valid %s %s value, rid: %s", value, version, attribute.PropertyType, rid)
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{key}}
+	}
+
+	return errors.RawErrorInfo{}
+}
+
+// validURL valid object attribute that is url type
+func (attribute *Attribute) validURL(ctx context.Context, val interface{}, key string) errors.RawErrorInfo {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	value, rawErr := attribute.getStringValue(ctx, val, key, common.FieldTypeLongLenChar)
+	if rawErr.ErrCode != 0 || value == "" {
+		return rawErr
+	}
+
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		blog.Errorf("params %s is not a valid absolute url, err: %v, rid: %s", value, err, rid)
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{key}}
+	}
+
+	schemes, err := ParseURLOption(attribute.Option)
+	if err != nil {
+		blog.Errorf("parse url option %+v failed, err: %v, rid: %s", attribute.Option, err, rid)
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{key}}
+	}
+
+	if len(schemes) > 0 && !util.InStrArr(schemes, strings.ToLower(parsed.Scheme)) {
+		blog.Errorf("params %s scheme is not in %v, rid: %s", value, schemes, rid)
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{key}}
+	}
+
+	return errors.RawErrorInfo{}
+}
+
+// getStringValue get the string value of the string like attribute and check if it is required or exceeds the limit
+func (attribute *Attribute) getStringValue(ctx context.Context, val interface{}, key string,
+	limit int) (string, errors.RawErrorInfo) {
+
+	rid := util.ExtractRequestIDFromContext(ctx)
+	if val == nil {
+		if attribute.IsRequired {
+			blog.Errorf("params %s in need, rid: %s", key, rid)
+			return "", errors.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{key}}
+		}
+		return "", errors.RawErrorInfo{}
+	}
+
+	value, ok := val.(string)
+	if !ok {
+		blog.Errorf("params %s should be string, but its type is %T, rid: %s", key, val, rid)
+		return "", errors.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedString, Args: []interface{}{key}}
+	}
+
+	value = strings.TrimSpace(value)
+	if value == "" {
+		if attribute.IsRequired {
+			blog.Errorf("params %s can not be empty, rid: %s", key, rid)
+			return "", errors.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{key}}
+		}
+		return "", errors.RawErrorInfo{}
+	}
+
+	if len(value) > limit {
+		blog.Errorf("params %s over length %d, rid: %s", key, limit, rid)
+		return "", errors.RawErrorInfo{ErrCode: common.CCErrCommOverLimit, Args: []interface{}{key}}
+	}
+
+	return value, errors.RawErrorInfo{}
+}
+
+// validJSON valid object attribute that is json type, the value is validated by the json schema option if it is set
+func (attribute *Attribute) validJSON(ctx context.Context, val interface{}, key string) errors.RawErrorInfo {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	if val == nil {
+		if attribute.IsRequired {
+			blog.Errorf("params %s in need, rid: %s", key, rid)
+			return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{key}}
+		}
+		return errors.RawErrorInfo{}
+	}
+
+	marshaled, err := json.Marshal(val)
+	if err != nil {
+		blog.Errorf("params %s value %+v is not a valid json value, err: %v, rid: %s", key, val, err, rid)
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{key}}
+	}
+
+	if len(marshaled) > common.FieldTypeJSONLenLimit {
+		blog.Errorf("params %s over length %d, rid: %s", key, common.FieldTypeJSONLenLimit, rid)
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommOverLimit, Args: []interface{}{key}}
+	}
+
+	schema, err := ParseJSONOption(attribute.Option)
+	if err != nil {
+		blog.Errorf("parse json schema option %+v failed, err: %v, rid: %s", attribute.Option, err, rid)
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{key}}
+	}
+
+	if schema == nil {
+		return errors.RawErrorInfo{}
+	}
+
+	if err = schema.Validate(val); err != nil {
+		blog.Errorf("params %s value does not match json schema, err: %v, rid: %s", key, err, rid)
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{key}}
+	}
+
+	return errors.RawErrorInfo{}
+}
+
+// validComputed valid object attribute that is computed type, its value is calculated by server, so only the value
+// type is checked here
+func (attribute *Attribute) validComputed(ctx context.Context, val interface{}, key string) errors.RawErrorInfo {
+	rid := util.ExtractRequestIDFromContext(ctx)
+
+	switch value := val.(type) {
+	case nil, bool:
+	case string:
+		if len(value) > common.FieldTypeLongLenChar {
+			blog.Errorf("params %s over length %d, rid: %s", key, common.FieldTypeLongLenChar, rid)
+			return errors.RawErrorInfo{ErrCode: common.CCErrCommOverLimit, Args: []interface{}{key}}
+		}
+	default:
+		if !util.IsNumeric(val) {
+			blog.Errorf("computed params %s value type %T is invalid, rid: %s", key, val, rid)
+			return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{key}}
+		}
+	}
+
+	return errors.RawErrorInfo{}
+}
+
 // ValidIDRuleVal validate id rule value
 func ValidIDRuleVal(ctx context.Context, inst mapstr.MapStr, field Attribute, attrMap map[string]Attribute) error {
 	rid := util.ExtractRequestIDFromContext(ctx)
@@ -1141,6 +1356,7 @@ func ValidIDRuleVal(ctx context.Context, inst mapstr.MapStr, field Attribute, at
 		blog.Errorf("get property: %s failed, inst: %+v, err: %v, rid: %s", field.PropertyID, inst, err, rid)
 		return err
 	}
+	fullVal := val
 
 	rules, err := ParseSubIDRules(field.Option)
 	if err != nil {
@@ -1189,7 +1405,7 @@ func ValidIDRuleVal(ctx context.Context, inst mapstr.MapStr, field Attribute, at
 				return fmt.Errorf("val is invalid, val: %s, rule val: %s", prefix, refVal)
 			}
 
-		case GlobalID, LocalID, RandomID:
+		case GlobalID, LocalID, RandomID, PrefixID, PeriodID, Date:
 			prefix := val[:rule.Len]
 			for _, c := range prefix {
 				if !unicode.IsDigit(c) {
@@ -1198,6 +1414,18 @@ func ValidIDRuleVal(ctx context.Context, inst mapstr.MapStr, field Attribute, at
 				}
 			}
 
+		case CheckDigit:
+			checked := fullVal[:len(fullVal)-len(val)]
+			checkDigit, err := GetIDRuleCheckDigit(rule.Val, checked)
+			if err != nil {
+				blog.Errorf("get check digit failed, val: %s, rule: %+v, err: %v, rid: %s", checked, rule, err, rid)
+				return err
+			}
+			if val[:rule.Len] != checkDigit {
+				blog.Errorf("check digit is invalid, val: %s, check digit: %s, rid: %s", val, checkDigit, rid)
+				return fmt.Errorf("check digit of val %s is invalid", fullVal)
+			}
+
 		default:
 			blog.Errorf("option is invalid, val: %+v, rid: %s", field.Option, rid)
 			return fmt.Errorf("option is invalid, val: %+v", field.Option)
@@ -1573,6 +1801,85 @@ func ParseListOption(option interface{}) (ListOption, error) {
 	return valueList, nil
 }
 
+// IPVersion the ip version limit of ip address and cidr type attribute
+type IPVersion string
+
+const (
+	// IPVersionAll both ipv4 and ipv6 are allowed
+	IPVersionAll IPVersion = ""
+	// IPVersion4 only ipv4 is allowed
+	IPVersion4 IPVersion = "ipv4"
+	// IPVersion6 only ipv6 is allowed
+	IPVersion6 IPVersion = "ipv6"
+)
+
+// ParseIPVersionOption parse ip address and cidr type attribute option, the option is the allowed ip version
+func ParseIPVersionOption(option interface{}) (IPVersion, error) {
+	if option == nil {
+		return IPVersionAll, nil
+	}
+
+	version, ok := option.(string)
+	if !ok {
+		return "", fmt.Errorf("ip version option type %T is invalid", option)
+	}
+
+	switch IPVersion(version) {
+	case IPVersionAll, IPVersion4, IPVersion6:
+		return IPVersion(version), nil
+	default:
+		return "", fmt.Errorf("ip version option %s is invalid", version)
+	}
+}
+
+// ParseURLOption parse url type attribute option, the option is the allowed url schemes, empty means all schemes are
+// allowed
+func ParseURLOption(option interface{}) ([]string, error) {
+	if option == nil || option == "" {
+		return make([]string, 0), nil
+	}
+
+	var arrOption []interface{}
+	switch val := option.(type) {
+	case []interface{}:
+		arrOption = val
+	case primitive.A:
+		arrOption = val
+	case []string:
+		return val, nil
+	default:
+		return nil, fmt.Errorf("url option type %T is invalid", option)
+	}
+
+	schemes := make([]string, len(arrOption))
+	for idx, val := range arrOption {
+		scheme, ok := val.(string)
+		if !ok || scheme == "" {
+			return nil, fmt.Errorf("url option scheme %+v is invalid", val)
+		}
+		schemes[idx] = strings.ToLower(scheme)
+	}
+
+	return schemes, nil
+}
+
+// ParseJSONOption parse json type attribute option, the option is the json schema, returns nil if it is not set
+func ParseJSONOption(option interface{}) (*jsonschema.Schema, error) {
+	if option == nil || option == "" {
+		return nil, nil
+	}
+
+	if str, ok := option.(string); ok {
+		var schema interface{}
+		if err := json.Unmarshal([]byte(str), &schema); err != nil {
+			return nil, fmt.Errorf("json schema option is not a valid json, err: %v", err)
+		}
+		option = schema
+	}
+
+	return jsonschema.Compile(option)
+}
+
 // PrettyValue TODO
 func (attribute Attribute) PrettyValue(ctx context.Context, val interface{}) (string, error) {
 	if val == nil {
@@ -1673,6 +1980,20 @@ func (attribute Attribute) PrettyValue(ctx context.Context, val interface{}) (st
 			}
 		}
 		return "", fmt.Errorf("invalid value for list, value: %s, options: %+v", strVal, listOption)
+	case common.FieldTypeIPAddr, common.FieldTypeCIDR, common.FieldTypeURL:
+		value, ok := val.(string)
+		if !ok {
+			return "", fmt.Errorf("invalid value type for %s, value: %+v", fieldType, val)
+		}
+		return value, nil
+	case common.FieldTypeJSON:
+		value, err := json.Marshal(val)
+		if err != nil {
+			return "", fmt.Errorf("invalid value for %s, value: %+v, err: %v", fieldType, val, err)
+		}
+		return string(value), nil
+	case common.FieldTypeComputed:
+		return util.GetStrByInterface(val), nil
 	default:
 		blog.V(3).Infof("unexpected property type: %s", fieldType)
 		return fmt.Sprintf("%#v", val), nil
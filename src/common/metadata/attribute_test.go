@@ -0,0 +1,46 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package metadata
+
+import (
+	"testing"
+
+	"configcenter/src/common"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeNetworkAddr(t *testing.T) {
+	cases := []struct {
+		propertyType string
+		val          interface{}
+		expected     interface{}
+	}{
+		{common.FieldTypeIPAddr, " 10.0.0.1 ", "10.0.0.1"},
+		{common.FieldTypeIPAddr, "2001:DB8::0:1", "2001:db8::1"},
+		{common.FieldTypeIPAddr, " invalid ", "invalid"},
+		{common.FieldTypeCIDR, " 10.0.0.5/24 ", "10.0.0.5/24"},
+		{common.FieldTypeCIDR, "2001:DB8::/32", "2001:db8::/32"},
+		{common.FieldTypeCIDR, "10.0.0.1", "10.0.0.1"},
+		{common.FieldTypeIPAddr, int64(1), int64(1)},
+		{common.FieldTypeSingleChar, " 10.0.0.1 ", " 10.0.0.1 "},
+	}
+
+	for _, c := range cases {
+		require.Equal(t, c.expected, NormalizeNetworkAddr(c.propertyType, c.val), "%s: %v", c.propertyType, c.val)
+	}
+}
@@ -27,17 +27,19 @@ import (
 
 // CloudAccount 云账户
 type CloudAccount struct {
-	AccountName string    `json:"bk_account_name" bson:"bk_account_name"`
-	CloudVendor string    `json:"bk_cloud_vendor" bson:"bk_cloud_vendor"`
-	AccountID   int64     `json:"bk_account_id" bson:"bk_account_id"`
-	SecretID    string    `json:"bk_secret_id" bson:"bk_secret_id"`
-	SecretKey   string    `json:"bk_secret_key" bson:"bk_secret_key"`
-	Description string    `json:"bk_description" bson:"bk_description"`
-	OwnerID     string    `json:"bk_supplier_account" bson:"bk_supplier_account"`
-	Creator     string    `json:"bk_creator" bson:"bk_creator"`
-	LastEditor  string    `json:"bk_last_editor" bson:"bk_last_editor"`
-	CreateTime  time.Time `json:"create_time" bson:"create_time"`
-	LastTime    time.Time `json:"last_time" bson:"last_time"`
+	AccountName string `json:"bk_account_name" bson:"bk_account_name"`
+	CloudVendor string `json:"bk_cloud_vendor" bson:"bk_cloud_vendor"`
+	AccountID   int64  `json:"bk_account_id" bson:"bk_account_id"`
+	SecretID    string `json:"bk_secret_id" bson:"bk_secret_id"`
+	SecretKey   string `json:"bk_secret_key" bson:"bk_secret_key"`
+	// VendorConf 通用云厂商的接入配置，仅在云厂商为GenericCloud时需要
+	VendorConf  *CloudVendorConf `json:"bk_vendor_conf,omitempty" bson:"bk_vendor_conf,omitempty"`
+	Description string           `json:"bk_description" bson:"bk_description"`
+	OwnerID     string           `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Creator     string           `json:"bk_creator" bson:"bk_creator"`
+	LastEditor  string           `json:"bk_last_editor" bson:"bk_last_editor"`
+	CreateTime  time.Time        `json:"create_time" bson:"create_time"`
+	LastTime    time.Time        `json:"last_time" bson:"last_time"`
 }
 
 // Validate account validate
@@ -76,6 +78,10 @@ func (c *CloudAccount) Validate() (rawError errors.RawErrorInfo) {
 		}
 	}
 
+	if c.CloudVendor == GenericCloud {
+		return c.VendorConf.Validate()
+	}
+
 	return errors.RawErrorInfo{}
 }
 
@@ -91,11 +97,59 @@ type CloudAccountWithExtraInfo struct {
 const (
 	AWS          string = "1"
 	TencentCloud string = "2"
+	// GenericCloud 通用云厂商，通过账户上配置的HTTP/JSON接口对接其他公有云或私有云
+	GenericCloud string = "19"
 )
 
 // SupportedCloudVendors 支持的云厂商
 // 实现了相应的云厂商插件
-var SupportedCloudVendors = []string{AWS, TencentCloud}
+var SupportedCloudVendors = []string{AWS, TencentCloud, GenericCloud}
+
+// CloudVendorConf 通用云厂商的接入配置
+// 云厂商需要提供地域、vpc、实例三类资源的HTTP/JSON查询接口，通过配置的gjson路径将返回数据映射为CMDB的云资源
+type CloudVendorConf struct {
+	// Endpoint 云厂商接口的访问地址，如http://127.0.0.1:8080
+	Endpoint string `json:"endpoint" bson:"endpoint"`
+	// Timeout 单次请求的超时时间，单位为秒，为0时使用默认值
+	Timeout int64 `json:"timeout,omitempty" bson:"timeout,omitempty"`
+	// Regions 地域列表查询接口，为空时使用默认配置
+	Regions *CloudResourceAPI `json:"regions,omitempty" bson:"regions,omitempty"`
+	// Vpcs vpc列表查询接口，为空时使用默认配置
+	Vpcs *CloudResourceAPI `json:"vpcs,omitempty" bson:"vpcs,omitempty"`
+	// Instances 实例列表查询接口，为空时使用默认配置
+	Instances *CloudResourceAPI `json:"instances,omitempty" bson:"instances,omitempty"`
+}
+
+// Validate validate generic cloud vendor config
+func (c *CloudVendorConf) Validate() errors.RawErrorInfo {
+	if c == nil || c.Endpoint == "" {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedSet,
+			Args:    []interface{}{"bk_vendor_conf.endpoint"},
+		}
+	}
+
+	if c.Timeout < 0 {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsInvalid,
+			Args:    []interface{}{"bk_vendor_conf.timeout"},
+		}
+	}
+
+	return errors.RawErrorInfo{}
+}
+
+// CloudResourceAPI 通用云厂商某类资源的查询接口配置
+type CloudResourceAPI struct {
+	// Path 请求路径，可以使用{region}作为地域的占位符，如/v1/regions/{region}/vpcs
+	Path string `json:"path" bson:"path"`
+	// ListPath 返回数据中资源列表的gjson路径，如data.items
+	ListPath string `json:"list_path" bson:"list_path"`
+	// TotalPath 返回数据中资源总数的gjson路径，为空时分页获取资源直到返回的资源不满一页，以实际返回的资源个数为准
+	TotalPath string `json:"total_path,omitempty" bson:"total_path,omitempty"`
+	// Fields 资源字段映射，key为CMDB中的字段名，如bk_vpc_id，value为单个资源数据中的gjson路径
+	Fields map[string]string `json:"fields,omitempty" bson:"fields,omitempty"`
+}
 
 // 云同步任务同步状态
 const (
@@ -106,10 +160,11 @@ const (
 
 // CloudAccountConf 云厂商账户配置
 type CloudAccountConf struct {
-	AccountID  int64  `json:"bk_account_id" bson:"bk_account_id"`
-	VendorName string `json:"bk_cloud_vendor" bson:"bk_cloud_vendor"`
-	SecretID   string `json:"bk_secret_id" bson:"bk_secret_id"`
-	SecretKey  string `json:"bk_secret_key" bson:"bk_secret_key"`
+	AccountID  int64            `json:"bk_account_id" bson:"bk_account_id"`
+	VendorName string           `json:"bk_cloud_vendor" bson:"bk_cloud_vendor"`
+	SecretID   string           `json:"bk_secret_id" bson:"bk_secret_id"`
+	SecretKey  string           `json:"bk_secret_key" bson:"bk_secret_key"`
+	VendorConf *CloudVendorConf `json:"bk_vendor_conf,omitempty" bson:"bk_vendor_conf,omitempty"`
 }
 
 // SearchCloudOption TODO
@@ -164,6 +219,73 @@ type DeleteDestroyedHostRelatedOption struct {
 	HostIDs []int64 `json:"host_ids" bson:"host_ids"`
 }
 
+// DestroyedHostRelated 被销毁主机删除的服务实例、进程和实例关联，回滚同步时用于恢复这些数据
+type DestroyedHostRelated struct {
+	ServiceInstances []ServiceInstance         `json:"service_instances,omitempty" bson:"service_instances,omitempty"`
+	Processes        []Process                 `json:"processes,omitempty" bson:"processes,omitempty"`
+	ProcessRelations []ProcessInstanceRelation `json:"process_relations,omitempty" bson:"process_relations,omitempty"`
+	InstAssts        []InstAsst                `json:"inst_assts,omitempty" bson:"inst_assts,omitempty"`
+}
+
+// IsEmpty 是否没有被删除的关联数据
+func (d *DestroyedHostRelated) IsEmpty() bool {
+	return len(d.ServiceInstances) == 0 && len(d.Processes) == 0 && len(d.ProcessRelations) == 0 &&
+		len(d.InstAssts) == 0
+}
+
+// SplitByHost 将被删除的关联数据按主机拆分，以便每个主机回滚时只恢复自己的数据
+func (d *DestroyedHostRelated) SplitByHost() map[int64]*DestroyedHostRelated {
+	hostRelated := make(map[int64]*DestroyedHostRelated)
+	getRelated := func(hostID int64) *DestroyedHostRelated {
+		if _, exists := hostRelated[hostID]; !exists {
+			hostRelated[hostID] = new(DestroyedHostRelated)
+		}
+		return hostRelated[hostID]
+	}
+
+	for _, instance := range d.ServiceInstances {
+		related := getRelated(instance.HostID)
+		related.ServiceInstances = append(related.ServiceInstances, instance)
+	}
+
+	procHostMap := make(map[int64]int64)
+	for _, relation := range d.ProcessRelations {
+		procHostMap[relation.ProcessID] = relation.HostID
+		related := getRelated(relation.HostID)
+		related.ProcessRelations = append(related.ProcessRelations, relation)
+	}
+
+	for _, process := range d.Processes {
+		hostID, exists := procHostMap[process.ProcessID]
+		if !exists {
+			continue
+		}
+		related := getRelated(hostID)
+		related.Processes = append(related.Processes, process)
+	}
+
+	// 主机之间的关联会同时拆分到两个主机下，恢复时会按关联id去重
+	for _, asst := range d.InstAssts {
+		if asst.ObjectID == common.BKInnerObjIDHost {
+			related := getRelated(asst.InstID)
+			related.InstAssts = append(related.InstAssts, asst)
+		}
+		if asst.AsstObjectID == common.BKInnerObjIDHost && !(asst.ObjectID == common.BKInnerObjIDHost &&
+			asst.AsstInstID == asst.InstID) {
+			related := getRelated(asst.AsstInstID)
+			related.InstAssts = append(related.InstAssts, asst)
+		}
+	}
+
+	return hostRelated
+}
+
+// RestoreDestroyedHostRelatedData 恢复被销毁主机关联数据的结果
+type RestoreDestroyedHostRelatedData struct {
+	// NotRestored 关联数据未能全部恢复的主机，如主机已不在服务实例所属的模块下
+	NotRestored []int64 `json:"not_restored"`
+}
+
 // MultipleSyncHistory TODO
 type MultipleSyncHistory struct {
 	Count int64         `json:"count"`
@@ -184,9 +306,10 @@ type MultipleCloudAccountConf struct {
 
 // CloudAccountVerify TODO
 type CloudAccountVerify struct {
-	SecretID    string `json:"bk_secret_id"`
-	SecretKey   string `json:"bk_secret_key"`
-	CloudVendor string `json:"bk_cloud_vendor"`
+	SecretID    string           `json:"bk_secret_id"`
+	SecretKey   string           `json:"bk_secret_key"`
+	CloudVendor string           `json:"bk_cloud_vendor"`
+	VendorConf  *CloudVendorConf `json:"bk_vendor_conf,omitempty"`
 }
 
 // SearchAccountValidityOption TODO
@@ -383,6 +506,8 @@ type SyncHistory struct {
 	StatusDescription SyncStatusDesc `json:"bk_status_description" bson:"bk_status_description"`
 	Detail            SyncDetail     `json:"bk_detail" bson:"bk_detail"`
 	CreateTime        time.Time      `json:"create_time" bson:"create_time"`
+	// HostChanges 本次同步新增、更新或销毁的主机在同步前后的字段值，用于回滚本次同步
+	HostChanges []SyncHostChange `json:"bk_host_changes,omitempty" bson:"bk_host_changes,omitempty"`
 }
 
 // SyncStatusDesc TODO
@@ -411,11 +536,91 @@ type SyncFailInfo struct {
 
 // SyncResult TODO
 type SyncResult struct {
-	SuccessInfo       SyncSuccessInfo `json:"success_info" bson:"success_info"`
-	FailInfo          SyncFailInfo    `json:"fail_info" bson:"fail_info"`
-	Detail            SyncDetail      `json:"detail" bson:"detail"`
-	SyncStatus        string          `json:"bk_sync_status" bson:"bk_sync_status"`
-	StatusDescription SyncStatusDesc  `json:"bk_status_description" bson:"bk_status_description"`
+	SuccessInfo       SyncSuccessInfo  `json:"success_info" bson:"success_info"`
+	FailInfo          SyncFailInfo     `json:"fail_info" bson:"fail_info"`
+	Detail            SyncDetail       `json:"detail" bson:"detail"`
+	SyncStatus        string           `json:"bk_sync_status" bson:"bk_sync_status"`
+	StatusDescription SyncStatusDesc   `json:"bk_status_description" bson:"bk_status_description"`
+	HostChanges       []SyncHostChange `json:"host_changes" bson:"host_changes"`
+}
+
+const (
+	// SyncHostChangeUpdate 同步时更新了主机的云主机信息
+	SyncHostChangeUpdate = "update"
+	// SyncHostChangeDestroy 同步时将云端已销毁的主机置为已销毁状态
+	SyncHostChangeDestroy = "destroy"
+	// SyncHostChangeAdd 同步时新增了云主机
+	SyncHostChangeAdd = "add"
+)
+
+// SyncHostChange 一次同步中单个主机被同步修改的字段在修改前后的值
+type SyncHostChange struct {
+	HostID     int64         `json:"bk_host_id" bson:"bk_host_id"`
+	InstanceID string        `json:"bk_cloud_inst_id" bson:"bk_cloud_inst_id"`
+	Operation  string        `json:"operation" bson:"operation"`
+	Before     mapstr.MapStr `json:"before" bson:"before"`
+	After      mapstr.MapStr `json:"after" bson:"after"`
+	// Related 主机被销毁时删除的服务实例、进程和实例关联，回滚时恢复
+	Related *DestroyedHostRelated `json:"related,omitempty" bson:"related,omitempty"`
+}
+
+// SyncRollbackResult 回滚一次同步的结果
+type SyncRollbackResult struct {
+	HistoryID int64 `json:"bk_history_id"`
+	// Restored 已恢复为同步前数据的主机
+	Restored []int64 `json:"restored"`
+	// Skipped 同步后又被修改过的主机，为避免覆盖新的数据不会回滚
+	Skipped []int64 `json:"skipped"`
+	// RelationsNotRestored 已恢复的被销毁主机中，被销毁时删除的服务实例、进程和实例关联未能全部恢复的主机，需要手动重建，
+	// 如主机已不在服务实例所属的模块下，或同步历史是在记录这些数据之前生成的
+	RelationsNotRestored []int64 `json:"relations_not_restored"`
+}
+
+// CloudSyncPreview 云同步任务的预览结果，即同步任务实际执行时会对CMDB做的变更
+type CloudSyncPreview struct {
+	TaskID int64 `json:"bk_task_id"`
+	// Add 需要新增的主机
+	Add []CloudHost `json:"add"`
+	// Update 需要更新的主机
+	Update []CloudHostUpdatePreview `json:"update"`
+	// Destroy 云端已销毁，需要在CMDB中置为已销毁状态的主机
+	Destroy []CloudHost `json:"destroy"`
+	// DestroyedVpcs 云端已销毁的vpc，其下的主机都会被置为已销毁状态
+	DestroyedVpcs []VpcSyncInfo `json:"destroyed_vpcs"`
+	// CloudAreas 需要为vpc新建的管控区域
+	CloudAreas []CloudArea `json:"cloud_areas"`
+	// Digest 预览结果的摘要，确认同步时需要带上，用于校验同步时的差异与预览时一致
+	Digest string `json:"digest"`
+}
+
+// CloudSyncPreviewResult 云同步任务预览结果
+type CloudSyncPreviewResult struct {
+	BaseResp `json:",inline"`
+	Data     CloudSyncPreview `json:"data"`
+}
+
+// CloudHostUpdatePreview 需要更新的云主机在更新前后的数据
+type CloudHostUpdatePreview struct {
+	Before CloudHost `json:"before"`
+	After  CloudHost `json:"after"`
+}
+
+// ConfirmSyncTaskOption 确认执行云同步任务的参数
+type ConfirmSyncTaskOption struct {
+	// Digest 预览同步任务时返回的摘要
+	Digest string `json:"digest"`
+}
+
+// Validate validate confirm sync task option
+func (c *ConfirmSyncTaskOption) Validate() errors.RawErrorInfo {
+	if c.Digest == "" {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedSet,
+			Args:    []interface{}{"digest"},
+		}
+	}
+
+	return errors.RawErrorInfo{}
 }
 
 // SecretKeyResult TODO
@@ -0,0 +1,59 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package metadata
+
+import (
+	"testing"
+
+	"configcenter/src/common"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDestroyedHostRelatedSplitByHost(t *testing.T) {
+	related := &DestroyedHostRelated{
+		ServiceInstances: []ServiceInstance{{ID: 1, HostID: 10}, {ID: 2, HostID: 20}},
+		Processes:        []Process{{ProcessID: 100}, {ProcessID: 200}, {ProcessID: 300}},
+		ProcessRelations: []ProcessInstanceRelation{
+			{ProcessID: 100, ServiceInstanceID: 1, HostID: 10},
+			{ProcessID: 200, ServiceInstanceID: 2, HostID: 20},
+		},
+		InstAssts: []InstAsst{
+			{ID: 1000, ObjectID: common.BKInnerObjIDHost, InstID: 10, AsstObjectID: "switch", AsstInstID: 1},
+			{ID: 2000, ObjectID: common.BKInnerObjIDHost, InstID: 10, AsstObjectID: common.BKInnerObjIDHost,
+				AsstInstID: 20},
+		},
+	}
+	require.False(t, related.IsEmpty())
+
+	hostRelated := related.SplitByHost()
+	require.Len(t, hostRelated, 2)
+
+	host10 := hostRelated[10]
+	require.Equal(t, []ServiceInstance{{ID: 1, HostID: 10}}, host10.ServiceInstances)
+	require.Equal(t, []Process{{ProcessID: 100}}, host10.Processes)
+	require.Len(t, host10.ProcessRelations, 1)
+	require.Len(t, host10.InstAssts, 2)
+
+	// the association between two hosts belongs to both of them, the process without relation is dropped
+	host20 := hostRelated[20]
+	require.Equal(t, []Process{{ProcessID: 200}}, host20.Processes)
+	require.Len(t, host20.InstAssts, 1)
+	require.Equal(t, int64(2000), host20.InstAssts[0].ID)
+
+	require.True(t, new(DestroyedHostRelated).IsEmpty())
+}
@@ -0,0 +1,123 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/expression"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/util"
+)
+
+// ComputedOption computed type attribute option
+type ComputedOption struct {
+	// Expression the expression that calculates the attribute value from other attributes, e.g. cpu * 2
+	Expression string `json:"expression" bson:"expression"`
+}
+
+// ParseComputedOption parse computed type attribute option and compile its expression
+func ParseComputedOption(val interface{}) (*expression.Expression, error) {
+	if val == nil || val == "" {
+		return nil, fmt.Errorf("computed option is not set")
+	}
+
+	option := new(ComputedOption)
+	switch opt := val.(type) {
+	case ComputedOption:
+		option = &opt
+	case *ComputedOption:
+		option = opt
+	case string:
+		if err := json.Unmarshal([]byte(opt), option); err != nil {
+			return nil, fmt.Errorf("computed option is invalid, err: %v", err)
+		}
+	default:
+		marshaled, err := json.Marshal(val)
+		if err != nil {
+			return nil, fmt.Errorf("computed option is invalid, err: %v", err)
+		}
+		if err = json.Unmarshal(marshaled, option); err != nil {
+			return nil, fmt.Errorf("computed option is invalid, err: %v", err)
+		}
+	}
+
+	return expression.Parse(option.Expression)
+}
+
+// IsValidComputedRefType check if the attribute of the type can be referenced by the computed attribute expression
+func IsValidComputedRefType(typ string) bool {
+	switch typ {
+	case common.FieldTypeSingleChar, common.FieldTypeLongChar, common.FieldTypeInt, common.FieldTypeFloat,
+		common.FieldTypeEnum, common.FieldTypeList, common.FieldTypeBool, common.FieldTypeDate, common.FieldTypeTime,
+		common.FieldTypeTimeZone, common.FieldTypeUser, common.FieldTypeIPAddr, common.FieldTypeCIDR,
+		common.FieldTypeURL:
+		return true
+	}
+	return false
+}
+
+// ValidComputedOption validate the computed attribute option, the attributes that the expression references must
+// exist in the attrTypeMap(property id -> property type) and have valid types
+func ValidComputedOption(option interface{}, attrTypeMap map[string]string) error {
+	expr, err := ParseComputedOption(option)
+	if err != nil {
+		return err
+	}
+
+	for _, field := range expr.Fields() {
+		typ, exists := attrTypeMap[field]
+		if !exists {
+			return fmt.Errorf("computed expression referenced attribute %s not exists", field)
+		}
+		if !IsValidComputedRefType(typ) {
+			return fmt.Errorf("computed expression referenced attribute %s type %s is invalid", field, typ)
+		}
+	}
+	return nil
+}
+
+// FillComputedFields calculate the computed attribute values of the instance by their expressions and set them into
+// the instance, the expression evaluation failure results in an empty value so that it would not block the writing
+func FillComputedFields(ctx context.Context, inst mapstr.MapStr, attrs []Attribute) error {
+	rid := util.ExtractRequestIDFromContext(ctx)
+
+	for _, attr := range attrs {
+		if attr.PropertyType != common.FieldTypeComputed {
+			continue
+		}
+
+		expr, err := ParseComputedOption(attr.Option)
+		if err != nil {
+			blog.Errorf("parse computed attribute %s option failed, err: %v, rid: %s", attr.PropertyID, err, rid)
+			return err
+		}
+
+		val, err := expr.Eval(inst)
+		if err != nil {
+			blog.Warnf("eval computed attribute %s expression %s failed, err: %v, rid: %s", attr.PropertyID,
+				expr.String(), err, rid)
+			val = nil
+		}
+		inst[attr.PropertyID] = val
+	}
+	return nil
+}
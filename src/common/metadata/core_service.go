@@ -717,6 +717,18 @@ type MultipleSyncHistoryResult struct {
 	Data     MultipleSyncHistory `json:"data"`
 }
 
+// DestroyedHostRelatedResult 删除被销毁主机关联数据的结果，返回被删除的数据
+type DestroyedHostRelatedResult struct {
+	BaseResp `json:",inline"`
+	Data     DestroyedHostRelated `json:"data"`
+}
+
+// RestoreDestroyedHostRelatedResult 恢复被销毁主机关联数据的结果
+type RestoreDestroyedHostRelatedResult struct {
+	BaseResp `json:",inline"`
+	Data     RestoreDestroyedHostRelatedData `json:"data"`
+}
+
 // MultipleSyncRegionResult TODO
 type MultipleSyncRegionResult struct {
 	BaseResp `json:",inline"`
@@ -16,6 +16,14 @@
 
 package metadata
 
+import (
+	"regexp"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+)
+
 const (
 	// HostSnapDataSourcesDelayQueue host snap data comes from the delay queue.
 	HostSnapDataSourcesDelayQueue = "delay_queue"
@@ -97,3 +105,167 @@ type SearchNetPropertyResult struct {
 type DeleteNetPropertyBatchOpt struct {
 	NetcollectPropertyIDs []uint64 `json:"netcollect_property_id"`
 }
+
+// AddNetTargetResult add net device target result
+type AddNetTargetResult struct {
+	TargetID uint64 `json:"target_id"`
+}
+
+// SearchNetTargetOption search net device targets option
+type SearchNetTargetOption struct {
+	DeviceIDs []uint64 `json:"device_id"`
+	CloudID   *int64   `json:"bk_cloud_id"`
+	Address   string   `json:"address"`
+	Protocol  string   `json:"protocol"`
+	Page      BasePage `json:"page"`
+}
+
+// SearchNetTarget search net device targets result
+type SearchNetTarget struct {
+	Count uint64             `json:"count"`
+	Info  []NetcollectTarget `json:"info"`
+}
+
+// DeleteNetTargetBatchOpt delete net device targets option
+type DeleteNetTargetBatchOpt struct {
+	TargetIDs []uint64 `json:"target_id"`
+}
+
+// HostSnapFieldMapping is the mapping from a json path of the host snapshot to a host attribute, it is used to
+// fill the host attributes that are not supported by hostsnap with the snapshot data reported by the collectors.
+type HostSnapFieldMapping struct {
+	ID int64 `json:"id" bson:"id"`
+	// Path is the gjson path of the value in the snapshot message, such as "data.mem.meminfo.total".
+	Path string `json:"path" bson:"path"`
+	// PropertyID is the host attribute that the value is saved to.
+	PropertyID string `json:"bk_property_id" bson:"bk_property_id"`
+	// Transform is the optional transform that is applied to the value before saving.
+	Transform  *HostSnapFieldTransform `json:"transform,omitempty" bson:"transform,omitempty"`
+	OwnerID    string                  `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Creator    string                  `json:"creator" bson:"creator"`
+	Modifier   string                  `json:"modifier" bson:"modifier"`
+	CreateTime *time.Time              `json:"create_time" bson:"create_time"`
+	LastTime   *time.Time              `json:"last_time" bson:"last_time"`
+}
+
+// Validate validate the host snapshot field mapping.
+func (m *HostSnapFieldMapping) Validate() errors.RawErrorInfo {
+	if m.Path == "" {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedSet,
+			Args:    []interface{}{"path"},
+		}
+	}
+
+	if m.PropertyID == "" {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedSet,
+			Args:    []interface{}{common.BKPropertyIDField},
+		}
+	}
+
+	if m.Transform != nil {
+		return m.Transform.Validate()
+	}
+
+	return errors.RawErrorInfo{}
+}
+
+// HostSnapTransformType is the type of the host snapshot field transform.
+type HostSnapTransformType string
+
+const (
+	// HostSnapTransformUnit converts the numeric value from one unit to another, the values of an array are summed
+	// before converting.
+	HostSnapTransformUnit HostSnapTransformType = "unit"
+	// HostSnapTransformRegex extracts the first sub match of the pattern from the value, or the whole match if the
+	// pattern has no sub expression.
+	HostSnapTransformRegex HostSnapTransformType = "regex"
+	// HostSnapTransformJoin joins the values of an array with the separator.
+	HostSnapTransformJoin HostSnapTransformType = "join"
+)
+
+// HostSnapByteUnits is the byte units that the unit transform supports and their sizes in byte.
+var HostSnapByteUnits = map[string]float64{
+	"B":  1,
+	"KB": 1 << 10,
+	"MB": 1 << 20,
+	"GB": 1 << 30,
+	"TB": 1 << 40,
+}
+
+// HostSnapFieldTransform is the transform of the host snapshot field mapping.
+type HostSnapFieldTransform struct {
+	Type HostSnapTransformType `json:"type" bson:"type"`
+	// FromUnit and ToUnit are the units of the unit transform.
+	FromUnit string `json:"from_unit,omitempty" bson:"from_unit,omitempty"`
+	ToUnit   string `json:"to_unit,omitempty" bson:"to_unit,omitempty"`
+	// Precision is the number of decimal places kept by the unit transform.
+	Precision int `json:"precision,omitempty" bson:"precision,omitempty"`
+	// Pattern is the regular expression of the regex transform.
+	Pattern string `json:"pattern,omitempty" bson:"pattern,omitempty"`
+	// Separator is the separator of the join transform, default is ",".
+	Separator string `json:"separator,omitempty" bson:"separator,omitempty"`
+}
+
+// Validate validate the host snapshot field transform.
+func (t *HostSnapFieldTransform) Validate() errors.RawErrorInfo {
+	switch t.Type {
+	case HostSnapTransformUnit:
+		if _, exists := HostSnapByteUnits[t.FromUnit]; !exists {
+			return errors.RawErrorInfo{
+				ErrCode: common.CCErrCommParamsInvalid,
+				Args:    []interface{}{"transform.from_unit"},
+			}
+		}
+		if _, exists := HostSnapByteUnits[t.ToUnit]; !exists {
+			return errors.RawErrorInfo{
+				ErrCode: common.CCErrCommParamsInvalid,
+				Args:    []interface{}{"transform.to_unit"},
+			}
+		}
+		if t.Precision < 0 {
+			return errors.RawErrorInfo{
+				ErrCode: common.CCErrCommParamsInvalid,
+				Args:    []interface{}{"transform.precision"},
+			}
+		}
+	case HostSnapTransformRegex:
+		if t.Pattern == "" {
+			return errors.RawErrorInfo{
+				ErrCode: common.CCErrCommParamsNeedSet,
+				Args:    []interface{}{"transform.pattern"},
+			}
+		}
+		if _, err := regexp.Compile(t.Pattern); err != nil {
+			return errors.RawErrorInfo{
+				ErrCode: common.CCErrCommParamsInvalid,
+				Args:    []interface{}{"transform.pattern"},
+			}
+		}
+	case HostSnapTransformJoin:
+	default:
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsInvalid,
+			Args:    []interface{}{"transform.type"},
+		}
+	}
+
+	return errors.RawErrorInfo{}
+}
+
+// SearchHostSnapFieldMappingOption is the option to search host snapshot field mappings.
+type SearchHostSnapFieldMappingOption struct {
+	Page BasePage `json:"page"`
+}
+
+// SearchHostSnapFieldMapping is the result of searching host snapshot field mappings.
+type SearchHostSnapFieldMapping struct {
+	Count uint64                 `json:"count"`
+	Info  []HostSnapFieldMapping `json:"info"`
+}
+
+// DeleteHostSnapFieldMappingOption is the option to delete host snapshot field mappings.
+type DeleteHostSnapFieldMappingOption struct {
+	IDs []int64 `json:"ids"`
+}
@@ -232,7 +232,8 @@ const (
 func getAttributeType(attributeType string) (string, error) {
 	switch attributeType {
 	case common.FieldTypeSingleChar, common.FieldTypeLongChar, common.FieldTypeEnum, common.FieldTypeEnumMulti,
-		common.FieldTypeTimeZone, common.FieldTypeUser, common.FieldTypeList:
+		common.FieldTypeTimeZone, common.FieldTypeUser, common.FieldTypeList, common.FieldTypeIPAddr,
+		common.FieldTypeCIDR, common.FieldTypeURL:
 		return stringType, nil
 	case common.FieldTypeInt, common.FieldTypeFloat, common.FieldTypeOrganization, common.FieldTypeEnumQuote:
 		return numericType, nil
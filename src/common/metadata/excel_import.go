@@ -0,0 +1,202 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package metadata
+
+import (
+	"regexp"
+	"strconv"
+
+	"configcenter/src/common"
+	ccErr "configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
+)
+
+// ExcelImportKind the kind of the excel import task and its sub tasks
+type ExcelImportKind string
+
+const (
+	// ExcelImportKindInst import instances of a common object
+	ExcelImportKindInst ExcelImportKind = "inst"
+	// ExcelImportKindAddHost import hosts into the resource pool
+	ExcelImportKindAddHost ExcelImportKind = "add_host"
+	// ExcelImportKindUpdateHost update the imported hosts
+	ExcelImportKindUpdateHost ExcelImportKind = "update_host"
+	// ExcelImportKindAssociation import instance associations, only used as a sub task kind
+	ExcelImportKindAssociation ExcelImportKind = "association"
+)
+
+// ExcelAssociationSheet the sheet name of the instance associations in the import excel
+const ExcelAssociationSheet = "association"
+
+// ExcelImportTaskData one sub task of the excel import task, each sub task imports a batch of excel rows
+type ExcelImportTaskData struct {
+	Kind  ExcelImportKind `json:"kind"`
+	ObjID string          `json:"bk_obj_id"`
+	// Rows excel row numbers of the batch, used to report the errors of the rows that are not imported
+	Rows []int64 `json:"rows,omitempty"`
+	// Params the request body of the batch import api, which is the same as the synchronous excel import
+	Params mapstr.MapStr `json:"params,omitempty"`
+	// Association the import association request, only set when kind is association
+	Association *RequestImportAssociation `json:"association,omitempty"`
+	// Errors the errors of the excel rows that are rejected by the pre-check before the task is created,
+	// they are reported together with the errors of the task
+	Errors []ExcelImportRowError `json:"errors,omitempty"`
+}
+
+// CreateExcelImportTaskOption create excel import task option
+type CreateExcelImportTaskOption struct {
+	Kind     ExcelImportKind       `json:"kind"`
+	ObjID    string                `json:"bk_obj_id"`
+	BizID    int64                 `json:"bk_biz_id"`
+	ModuleID int64                 `json:"bk_module_id"`
+	Tasks    []ExcelImportTaskData `json:"tasks"`
+}
+
+// maxExcelImportSubTasks the maximum sub tasks of an excel import task, every sub task imports at most 100 rows
+const maxExcelImportSubTasks = common.ExcelImportMaxRow/100 + 1
+
+// Validate validate CreateExcelImportTaskOption
+func (c *CreateExcelImportTaskOption) Validate() ccErr.RawErrorInfo {
+	switch c.Kind {
+	case ExcelImportKindInst, ExcelImportKindAddHost, ExcelImportKindUpdateHost:
+	default:
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{"kind"}}
+	}
+
+	if c.ObjID == "" {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{common.BKObjIDField}}
+	}
+
+	if c.Kind != ExcelImportKindInst && c.ObjID != common.BKInnerObjIDHost {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{common.BKObjIDField}}
+	}
+
+	if len(c.Tasks) == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"tasks"}}
+	}
+
+	if len(c.Tasks) > maxExcelImportSubTasks {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommXXExceedLimit,
+			Args: []interface{}{"tasks", maxExcelImportSubTasks}}
+	}
+
+	for _, task := range c.Tasks {
+		if task.ObjID != c.ObjID {
+			return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{common.BKObjIDField}}
+		}
+
+		switch task.Kind {
+		case c.Kind:
+			if len(task.Params) == 0 {
+				return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"params"}}
+			}
+		case ExcelImportKindAssociation:
+			if task.Association == nil {
+				return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"association"}}
+			}
+		default:
+			return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{"kind"}}
+		}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// TaskScope returns the scope of the excel import task in the object, which is the business of the instances or the
+// module of the added hosts, so that the import tasks of an object in different scopes can be executed at the same time
+func (c *CreateExcelImportTaskOption) TaskScope() string {
+	if c.Kind == ExcelImportKindAddHost {
+		return common.BKModuleIDField + ":" + strconv.FormatInt(c.ModuleID, 10)
+	}
+	return common.BKAppIDField + ":" + strconv.FormatInt(c.BizID, 10)
+}
+
+// CreateExcelImportTaskResult create excel import task result
+type CreateExcelImportTaskResult struct {
+	TaskID string `json:"task_id"`
+}
+
+// ExcelImportTaskOption find excel import task option
+type ExcelImportTaskOption struct {
+	TaskID string `json:"task_id"`
+}
+
+// Validate validate ExcelImportTaskOption
+func (e *ExcelImportTaskOption) Validate() ccErr.RawErrorInfo {
+	if e.TaskID == "" {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{common.BKTaskIDField}}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// ExcelImportRowError the error of an excel row, property id is set when the error belongs to a cell
+type ExcelImportRowError struct {
+	// Sheet the sheet of the row, empty means the object sheet
+	Sheet      string `json:"sheet,omitempty"`
+	Row        int64  `json:"row"`
+	PropertyID string `json:"bk_property_id,omitempty"`
+	Message    string `json:"message"`
+}
+
+// ExcelImportSubTaskResult the result of an excel import sub task
+type ExcelImportSubTaskResult struct {
+	Success []int64               `json:"success"`
+	Errors  []ExcelImportRowError `json:"errors"`
+}
+
+// ExcelImportTaskResult the progress and the result of an excel import task
+type ExcelImportTaskResult struct {
+	TaskID string        `json:"task_id"`
+	Status APITaskStatus `json:"status"`
+	// Total the number of the sub tasks, Finished the number of the executed sub tasks
+	Total    int                   `json:"total"`
+	Finished int                   `json:"finished"`
+	Success  []int64               `json:"success"`
+	Errors   []ExcelImportRowError `json:"errors"`
+}
+
+// excelRowRegexp matches the row number of the excel import error message, all the row error messages of the
+// excel import start with the row number, like "3行xxx", "第3行xxx" or "3 line xxx"
+var excelRowRegexp = regexp.MustCompile(`^\D{0,8}?(\d+)`)
+
+// NewExcelImportRowErrors converts the excel import error messages of a sheet to row errors, the row of the
+// message that has no row number is 0
+func NewExcelImportRowErrors(sheet string, messages []string) []ExcelImportRowError {
+	rowErrs := make([]ExcelImportRowError, 0, len(messages))
+	for _, msg := range messages {
+		rowErr := ExcelImportRowError{Sheet: sheet, Message: msg}
+		if match := excelRowRegexp.FindStringSubmatch(msg); len(match) == 2 {
+			rowErr.Row, _ = strconv.ParseInt(match[1], 10, 64)
+		}
+		rowErrs = append(rowErrs, rowErr)
+	}
+
+	return rowErrs
+}
+
+// CreateExcelImportTaskResp create excel import task response
+type CreateExcelImportTaskResp struct {
+	BaseResp `json:",inline"`
+	Data     CreateExcelImportTaskResult `json:"data"`
+}
+
+// ExcelImportTaskResp find excel import task response
+type ExcelImportTaskResp struct {
+	BaseResp `json:",inline"`
+	Data     ExcelImportTaskResult `json:"data"`
+}
@@ -0,0 +1,84 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package metadata
+
+import (
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewExcelImportRowErrors(t *testing.T) {
+	rowErrs := NewExcelImportRowErrors("", []string{
+		"7行数据导入失败",
+		"第12行必填字段[名称]未填写",
+		"35 line required field [name] is empty",
+		"too many rows",
+	})
+	require.Equal(t, []ExcelImportRowError{
+		{Row: 7, Message: "7行数据导入失败"},
+		{Row: 12, Message: "第12行必填字段[名称]未填写"},
+		{Row: 35, Message: "35 line required field [name] is empty"},
+		{Row: 0, Message: "too many rows"},
+	}, rowErrs)
+}
+
+func TestCreateExcelImportTaskOptionValidate(t *testing.T) {
+	instTask := ExcelImportTaskData{Kind: ExcelImportKindInst, ObjID: "switch", Rows: []int64{7},
+		Params: mapstr.MapStr{"input_type": common.InputTypeExcel}}
+	asstTask := ExcelImportTaskData{Kind: ExcelImportKindAssociation, ObjID: "switch",
+		Association: new(RequestImportAssociation)}
+
+	opt := &CreateExcelImportTaskOption{Kind: ExcelImportKindInst, ObjID: "switch",
+		Tasks: []ExcelImportTaskData{instTask, asstTask}}
+	require.Equal(t, 0, opt.Validate().ErrCode)
+
+	// host import tasks can only import hosts
+	opt = &CreateExcelImportTaskOption{Kind: ExcelImportKindAddHost, ObjID: "switch",
+		Tasks: []ExcelImportTaskData{instTask}}
+	require.Equal(t, common.CCErrCommParamsInvalid, opt.Validate().ErrCode)
+
+	// sub task kind must be the same as the task
+	opt = &CreateExcelImportTaskOption{Kind: ExcelImportKindUpdateHost, ObjID: "switch",
+		Tasks: []ExcelImportTaskData{instTask}}
+	require.Equal(t, common.CCErrCommParamsInvalid, opt.Validate().ErrCode)
+
+	opt = &CreateExcelImportTaskOption{Kind: ExcelImportKindInst, ObjID: "switch"}
+	require.Equal(t, common.CCErrCommParamsNeedSet, opt.Validate().ErrCode)
+
+	tasks := make([]ExcelImportTaskData, maxExcelImportSubTasks+1)
+	for idx := range tasks {
+		tasks[idx] = instTask
+	}
+	opt = &CreateExcelImportTaskOption{Kind: ExcelImportKindInst, ObjID: "switch", Tasks: tasks}
+	require.Equal(t, common.CCErrCommXXExceedLimit, opt.Validate().ErrCode)
+}
+
+func TestCreateExcelImportTaskOptionTaskScope(t *testing.T) {
+	opt := &CreateExcelImportTaskOption{Kind: ExcelImportKindInst, ObjID: "switch", BizID: 3}
+	require.Equal(t, "bk_biz_id:3", opt.TaskScope())
+
+	opt = &CreateExcelImportTaskOption{Kind: ExcelImportKindAddHost, ObjID: common.BKInnerObjIDHost, BizID: 1,
+		ModuleID: 5}
+	require.Equal(t, "bk_module_id:5", opt.TaskScope())
+
+	opt = &CreateExcelImportTaskOption{Kind: ExcelImportKindUpdateHost, ObjID: common.BKInnerObjIDHost, BizID: 2}
+	require.Equal(t, "bk_biz_id:2", opt.TaskScope())
+}
@@ -27,6 +27,7 @@ import (
 
 	"configcenter/src/common"
 	ccErr "configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
 	"configcenter/src/common/util"
 )
 
@@ -76,6 +77,14 @@ const (
 	LocalID RuleKind = "localID"
 	// RandomID rules for random id types
 	RandomID RuleKind = "randomID"
+	// PrefixID rules for self-increasing id types that are counted separately for each generated prefix
+	PrefixID RuleKind = "prefixID"
+	// PeriodID rules for self-increasing id types that are reset every period
+	PeriodID RuleKind = "periodID"
+	// Date rules for formatted create date types
+	Date RuleKind = "date"
+	// CheckDigit rules for check digit types, the check digit is calculated by the digits before it
+	CheckDigit RuleKind = "checkDigit"
 )
 
 // SubAssetRule sub asset rule
@@ -86,7 +95,7 @@ type SubAssetRule struct {
 }
 
 const (
-	idRuleVarLimit = 4
+	idRuleVarLimit = 8
 	idLenLimit     = 32
 	varTypeLimit   = 1
 
@@ -94,6 +103,55 @@ const (
 	IDRuleFieldLimit = 1
 )
 
+// IDRulePeriod the reset period of PeriodID rule
+type IDRulePeriod string
+
+const (
+	// IDRulePeriodYear reset the id every year
+	IDRulePeriodYear IDRulePeriod = "year"
+	// IDRulePeriodMonth reset the id every month
+	IDRulePeriodMonth IDRulePeriod = "month"
+	// IDRulePeriodDay reset the id every day
+	IDRulePeriodDay IDRulePeriod = "day"
+)
+
+// Layout returns the time layout that identifies the period
+func (p IDRulePeriod) Layout() string {
+	switch p {
+	case IDRulePeriodYear:
+		return "2006"
+	case IDRulePeriodMonth:
+		return "200601"
+	default:
+		return "20060102"
+	}
+}
+
+var idRulePeriodVars = map[string]IDRulePeriod{
+	common.YearIncrIDVar:  IDRulePeriodYear,
+	common.MonthIncrIDVar: IDRulePeriodMonth,
+	common.DayIncrIDVar:   IDRulePeriodDay,
+}
+
+const (
+	// IDRuleCheckDigitLuhn luhn check digit algorithm
+	IDRuleCheckDigitLuhn = "luhn"
+	// IDRuleCheckDigitMod11 ISO 7064 MOD 11-2 check digit algorithm, the check digit may be 'X'
+	IDRuleCheckDigitMod11 = "mod11"
+)
+
+// idRuleDateParts the supported date parts of date rule and the corresponding time layout,
+// longer parts must be matched first
+var idRuleDateParts = []struct {
+	part   string
+	layout string
+}{
+	{part: "YYYY", layout: "2006"},
+	{part: "YY", layout: "06"},
+	{part: "MM", layout: "01"},
+	{part: "DD", layout: "02"},
+}
+
 // ParseSubIDRules parse sub asset rule
 func ParseSubIDRules(val interface{}) ([]SubAssetRule, error) {
 	option, err := ParseAsstIDOption(val)
@@ -108,17 +166,19 @@ func ParseSubIDRules(val interface{}) ([]SubAssetRule, error) {
 
 	// id规则变量个数限制
 	if len(indexes) > idRuleVarLimit {
-		return nil, fmt.Errorf("option.rule var count:%d exceed max count:%d", indexes, idRuleVarLimit)
+		return nil, fmt.Errorf("option.rule var count:%d exceed max count:%d", len(indexes), idRuleVarLimit)
 	}
 
 	lastIdx := 0
 	result := make([]SubAssetRule, 0)
-	globalVarCount, localVarCount, randVarCount := 0, 0, 0
+	kindCount := make(map[RuleKind]int)
 
 	for _, idx := range indexes {
 		// 处理普通的字符串
 		varConst := rule[lastIdx:idx[0]]
-		result = append(result, SubAssetRule{Val: varConst, Kind: Const, Len: int64(len(varConst))})
+		if varConst != "" {
+			result = append(result, SubAssetRule{Val: varConst, Kind: Const, Len: int64(len(varConst))})
+		}
 		lastIdx = idx[1]
 
 		// 处理变量, 去掉左右括号
@@ -131,49 +191,159 @@ func ParseSubIDRules(val interface{}) ([]SubAssetRule, error) {
 		}
 
 		varVal := strings.Join(split[0:len(split)-1], ".")
-		var kind RuleKind
-		switch varVal {
-		case common.GlobalIncrIDVar:
-			kind = GlobalID
-			globalVarCount++
-		case common.LocalIncrIDVar:
-			kind = LocalID
-			localVarCount++
-		case common.RandomIDVar:
-			kind = RandomID
-			randVarCount++
-		default:
-			return nil, fmt.Errorf("option var %s is invalid", varVal)
-		}
-
-		// 随机ID、自增id等长度限制
-		length, err := strconv.ParseInt(split[len(split)-1], 10, 64)
+		subRule, err := parseSubIDVarRule(varVal, split[len(split)-1])
 		if err != nil {
 			return nil, err
 		}
-		if length > idLenLimit {
-			return nil, fmt.Errorf("option.rule var %s length exceed max length %d", varVal, idLenLimit)
-		}
-
-		result = append(result, SubAssetRule{Val: varVal, Kind: kind, Len: length})
+		kindCount[subRule.Kind]++
+		result = append(result, *subRule)
 	}
 
-	if globalVarCount == 0 && localVarCount == 0 && randVarCount == 0 {
-		return nil, fmt.Errorf("option.rule has no %s, %s and %s", GlobalID, LocalID, RandomID)
+	if kindCount[GlobalID] == 0 && kindCount[LocalID] == 0 && kindCount[RandomID] == 0 &&
+		kindCount[PrefixID] == 0 && kindCount[PeriodID] == 0 {
+		return nil, fmt.Errorf("option.rule has no %s, %s, %s, %s and %s", GlobalID, LocalID, RandomID, PrefixID,
+			PeriodID)
 	}
 
-	if globalVarCount > varTypeLimit || localVarCount > varTypeLimit || randVarCount > varTypeLimit {
-		return nil, fmt.Errorf("option.rule var type exceed max count %d", idLenLimit)
+	for _, kind := range []RuleKind{GlobalID, LocalID, RandomID, PrefixID, PeriodID, CheckDigit} {
+		if kindCount[kind] > varTypeLimit {
+			return nil, fmt.Errorf("option.rule var type %s exceed max count %d", kind, varTypeLimit)
+		}
 	}
 
 	lastVal := rule[lastIdx:]
 	if lastVal != "" {
-		result = append(result, SubAssetRule{Val: lastVal})
+		result = append(result, SubAssetRule{Val: lastVal, Kind: Const, Len: int64(len(lastVal))})
 	}
 
 	return result, nil
 }
 
+// parseSubIDVarRule parse the variable rule like {{varVal.param}}, param is the length of the id for id variables
+func parseSubIDVarRule(varVal string, param string) (*SubAssetRule, error) {
+	switch varVal {
+	case common.DateVar:
+		if _, err := FormatIDRuleDate(param, time.Now()); err != nil {
+			return nil, err
+		}
+		return &SubAssetRule{Val: param, Kind: Date, Len: int64(len(param))}, nil
+
+	case common.CheckDigitVar:
+		if param != IDRuleCheckDigitLuhn && param != IDRuleCheckDigitMod11 {
+			return nil, fmt.Errorf("option.rule check digit algorithm %s is invalid", param)
+		}
+		return &SubAssetRule{Val: param, Kind: CheckDigit, Len: 1}, nil
+	}
+
+	var kind RuleKind
+	val := varVal
+	switch varVal {
+	case common.GlobalIncrIDVar:
+		kind = GlobalID
+	case common.LocalIncrIDVar:
+		kind = LocalID
+	case common.RandomIDVar:
+		kind = RandomID
+	case common.PrefixIncrIDVar:
+		kind = PrefixID
+	case common.YearIncrIDVar, common.MonthIncrIDVar, common.DayIncrIDVar:
+		kind = PeriodID
+		val = string(idRulePeriodVars[varVal])
+	default:
+		return nil, fmt.Errorf("option var %s is invalid", varVal)
+	}
+
+	// 随机ID、自增id等长度限制
+	length, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	if length <= 0 || length > idLenLimit {
+		return nil, fmt.Errorf("option.rule var %s length must be in range [1, %d]", varVal, idLenLimit)
+	}
+
+	return &SubAssetRule{Val: val, Kind: kind, Len: length}, nil
+}
+
+// FormatIDRuleDate format the time by the date rule layout which consists of YYYY, YY, MM and DD, e.g. YYYYMM
+func FormatIDRuleDate(layout string, t time.Time) (string, error) {
+	if layout == "" {
+		return "", fmt.Errorf("option.rule date layout is empty")
+	}
+
+	var sb strings.Builder
+	for rest := layout; rest != ""; {
+		matched := false
+		for _, dp := range idRuleDateParts {
+			if strings.HasPrefix(rest, dp.part) {
+				sb.WriteString(t.Format(dp.layout))
+				rest = rest[len(dp.part):]
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			return "", fmt.Errorf("option.rule date layout %s is invalid, only YYYY, YY, MM and DD are supported",
+				layout)
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// GetIDRuleCheckDigit calculate the check digit of the digits in the value by the algorithm, non-digit
+// characters are ignored
+func GetIDRuleCheckDigit(algorithm string, val string) (string, error) {
+	digits := make([]int, 0, len(val))
+	for _, c := range val {
+		if c >= '0' && c <= '9' {
+			digits = append(digits, int(c-'0'))
+		}
+	}
+
+	switch algorithm {
+	case IDRuleCheckDigitLuhn:
+		sum := 0
+		for i := len(digits) - 1; i >= 0; i-- {
+			d := digits[i]
+			// the rightmost digit of the payload is doubled since the check digit will be appended after it
+			if (len(digits)-1-i)%2 == 0 {
+				d *= 2
+				if d > 9 {
+					d -= 9
+				}
+			}
+			sum += d
+		}
+		return strconv.Itoa((10 - sum%10) % 10), nil
+
+	case IDRuleCheckDigitMod11:
+		p := 0
+		for _, d := range digits {
+			p = ((p + d) * 2) % 11
+		}
+		check := (12 - p) % 11
+		if check == 10 {
+			return "X", nil
+		}
+		return strconv.Itoa(check), nil
+
+	default:
+		return "", fmt.Errorf("check digit algorithm %s is invalid", algorithm)
+	}
+}
+
+// GetIDRulePrefixSeqName get the sequence name of the PrefixID rule, the id is counted separately for each prefix
+func GetIDRulePrefixSeqName(objID string, prefix string) string {
+	return GetIDRule(fmt.Sprintf("%s:prefix:%s", objID, prefix))
+}
+
+// GetIDRulePeriodSeqName get the sequence name of the PeriodID rule, the id is counted separately for each period
+func GetIDRulePeriodSeqName(objID string, period IDRulePeriod, t time.Time) string {
+	return GetIDRule(fmt.Sprintf("%s:%s:%s", objID, period, t.Format(period.Layout())))
+}
+
 // GetIDRuleRandomID get id rule random id
 func GetIDRuleRandomID(length int64) string {
 	rand.Seed(time.Now().UnixNano())
@@ -296,3 +466,50 @@ func IsValidAttrRuleType(typ string) bool {
 	}
 	return false
 }
+
+// IDRulePreviewMaxCount the max count of ids that can be previewed at once
+const IDRulePreviewMaxCount = 50
+
+// PreviewIDRuleOption preview id rule option, previews the id rule of the attribute, or the specified rule that is
+// not saved yet if rule is set
+type PreviewIDRuleOption struct {
+	ObjID      string        `json:"bk_obj_id"`
+	PropertyID string        `json:"bk_property_id"`
+	Rule       string        `json:"rule"`
+	Count      int           `json:"count"`
+	Data       mapstr.MapStr `json:"data"`
+}
+
+// Validate validate PreviewIDRuleOption
+func (p *PreviewIDRuleOption) Validate() ccErr.RawErrorInfo {
+	if p.ObjID == "" {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{common.BKObjIDField}}
+	}
+
+	if p.PropertyID == "" && p.Rule == "" {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommAtLeastSetOneVal,
+			Args: []interface{}{common.BKPropertyIDField, "rule"}}
+	}
+
+	if p.Count <= 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{"count"}}
+	}
+
+	if p.Count > IDRulePreviewMaxCount {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommXXExceedLimit,
+			Args: []interface{}{"count", IDRulePreviewMaxCount}}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// PreviewIDRuleResult preview id rule result
+type PreviewIDRuleResult struct {
+	IDs []string `json:"ids"`
+}
+
+// PreviewIDRuleResp preview id rule response
+type PreviewIDRuleResp struct {
+	BaseResp `json:",inline"`
+	Data     *PreviewIDRuleResult `json:"data"`
+}
@@ -0,0 +1,128 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package metadata
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"configcenter/src/common/mapstr"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSubIDRules(t *testing.T) {
+	rules, err := ParseSubIDRules(map[string]interface{}{
+		"rule": "SRV-{{date.YYYY}}-{{date.MM}}-{{prefix.incr_id.6}}-{{check_digit.luhn}}",
+	})
+	require.NoError(t, err)
+	require.Equal(t, []SubAssetRule{
+		{Val: "SRV-", Kind: Const, Len: 4},
+		{Val: "YYYY", Kind: Date, Len: 4},
+		{Val: "-", Kind: Const, Len: 1},
+		{Val: "MM", Kind: Date, Len: 2},
+		{Val: "-", Kind: Const, Len: 1},
+		{Val: "prefix.incr_id", Kind: PrefixID, Len: 6},
+		{Val: "-", Kind: Const, Len: 1},
+		{Val: "luhn", Kind: CheckDigit, Len: 1},
+	}, rules)
+
+	rules, err = ParseSubIDRules(AsstIDOption{Rule: "{{month.incr_id.4}}-END"})
+	require.NoError(t, err)
+	require.Equal(t, []SubAssetRule{
+		{Val: string(IDRulePeriodMonth), Kind: PeriodID, Len: 4},
+		{Val: "-END", Kind: Const, Len: 4},
+	}, rules)
+
+	invalidRules := []string{
+		"{{date.YYYY}}",
+		"{{date.YYYYQ}}-{{local.incr_id.3}}",
+		"{{check_digit.mod10}}-{{local.incr_id.3}}",
+		"{{week.incr_id.3}}",
+		"{{prefix.incr_id.0}}",
+		"{{prefix.incr_id.3}}{{prefix.incr_id.3}}",
+		"{{local.incr_id.3}}{{check_digit.luhn}}{{check_digit.mod11}}",
+	}
+	for _, rule := range invalidRules {
+		_, err = ParseSubIDRules(AsstIDOption{Rule: rule})
+		require.Error(t, err, rule)
+	}
+}
+
+func TestFormatIDRuleDate(t *testing.T) {
+	now := time.Date(2026, 10, 8, 0, 0, 0, 0, time.Local)
+	cases := map[string]string{
+		"YYYY":     "2026",
+		"YY":       "26",
+		"YYYYMM":   "202610",
+		"YYYYMMDD": "20261008",
+		"YYMMDD":   "261008",
+	}
+	for layout, expected := range cases {
+		val, err := FormatIDRuleDate(layout, now)
+		require.NoError(t, err)
+		require.Equal(t, expected, val)
+	}
+
+	_, err := FormatIDRuleDate("YYYY-MM", now)
+	require.Error(t, err)
+}
+
+func TestGetIDRuleCheckDigit(t *testing.T) {
+	digit, err := GetIDRuleCheckDigit(IDRuleCheckDigitLuhn, "7992739871")
+	require.NoError(t, err)
+	require.Equal(t, "3", digit)
+
+	digit, err = GetIDRuleCheckDigit(IDRuleCheckDigitLuhn, "SRV-2026-10-000123-")
+	require.NoError(t, err)
+	require.Equal(t, "2", digit)
+
+	digit, err = GetIDRuleCheckDigit(IDRuleCheckDigitMod11, "079")
+	require.NoError(t, err)
+	require.Equal(t, "X", digit)
+
+	digit, err = GetIDRuleCheckDigit(IDRuleCheckDigitMod11, "0794")
+	require.NoError(t, err)
+	require.Equal(t, "0", digit)
+
+	_, err = GetIDRuleCheckDigit("mod10", "123")
+	require.Error(t, err)
+}
+
+func TestValidIDRuleValWithCheckDigit(t *testing.T) {
+	attr := Attribute{
+		PropertyID: "asset_id",
+		Option:     AsstIDOption{Rule: "SRV-{{date.YYYY}}-{{prefix.incr_id.6}}-{{check_digit.luhn}}"},
+	}
+
+	checkDigit, err := GetIDRuleCheckDigit(IDRuleCheckDigitLuhn, "SRV-2026-000123-")
+	require.NoError(t, err)
+
+	inst := mapstr.MapStr{"asset_id": "SRV-2026-000123-" + checkDigit}
+	require.NoError(t, ValidIDRuleVal(context.Background(), inst, attr, nil))
+
+	wrongDigit := "0"
+	if checkDigit == wrongDigit {
+		wrongDigit = "1"
+	}
+	inst = mapstr.MapStr{"asset_id": "SRV-2026-000123-" + wrongDigit}
+	require.Error(t, ValidIDRuleVal(context.Background(), inst, attr, nil))
+
+	inst = mapstr.MapStr{"asset_id": "SRV-20A6-000123-" + checkDigit}
+	require.Error(t, ValidIDRuleVal(context.Background(), inst, attr, nil))
+}
@@ -0,0 +1,303 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package metadata
+
+import (
+	"unicode/utf8"
+
+	"configcenter/src/common"
+	ccErr "configcenter/src/common/errors"
+)
+
+// LocalAuthScopeType is the scope type of the local auth role binding
+type LocalAuthScopeType string
+
+const (
+	// LocalAuthSystemScope the role binding takes effect on all resources
+	LocalAuthSystemScope LocalAuthScopeType = "system"
+	// LocalAuthBizScope the role binding takes effect on the resources of the business
+	LocalAuthBizScope LocalAuthScopeType = "biz"
+	// LocalAuthBizSetScope the role binding takes effect on the business set
+	LocalAuthBizSetScope LocalAuthScopeType = "biz_set"
+)
+
+// LocalAuthAnyUser matches all the users when used in the users of the role binding
+const LocalAuthAnyUser = "*"
+
+const (
+	localAuthRoleNameMaxLen   = 128
+	localAuthRoleDescMaxLen   = 2000
+	localAuthPermissionLimit  = 500
+	localAuthInstanceLimit    = 500
+	localAuthBindingUserLimit = 500
+)
+
+// LocalAuthPermission is a resource level grant of the local auth role
+type LocalAuthPermission struct {
+	// Action is the action id defined in ac/iam, like "view_business_resource"
+	Action string `json:"action" bson:"action"`
+	// Instances are the resource instance ids that the permission is restricted to,
+	// empty means all the instances in the scope of the role binding
+	Instances []string `json:"instances,omitempty" bson:"instances,omitempty"`
+}
+
+// LocalAuthRole is a named set of permissions of the local authorizer
+type LocalAuthRole struct {
+	ID          int64                 `json:"id" bson:"id"`
+	Name        string                `json:"name" bson:"name"`
+	Description string                `json:"description" bson:"description"`
+	Permissions []LocalAuthPermission `json:"permissions" bson:"permissions"`
+	OwnerID     string                `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Creator     string                `json:"creator" bson:"creator"`
+	Modifier    string                `json:"modifier" bson:"modifier"`
+	CreateTime  *Time                 `json:"create_time" bson:"create_time"`
+	LastTime    *Time                 `json:"last_time" bson:"last_time"`
+}
+
+// LocalAuthRoleOption is the option to create or update a local auth role
+type LocalAuthRoleOption struct {
+	Name        string                `json:"name"`
+	Description string                `json:"description"`
+	Permissions []LocalAuthPermission `json:"permissions"`
+}
+
+// Validate validate LocalAuthRoleOption, the existence of the actions is validated by the caller, because
+// metadata can not import the action definitions in ac/iam
+func (o *LocalAuthRoleOption) Validate() ccErr.RawErrorInfo {
+	if len(o.Name) == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{common.BKFieldName}}
+	}
+
+	if utf8.RuneCountInString(o.Name) > localAuthRoleNameMaxLen {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommValExceedMaxFailed,
+			Args: []interface{}{common.BKFieldName, localAuthRoleNameMaxLen}}
+	}
+
+	if utf8.RuneCountInString(o.Description) > localAuthRoleDescMaxLen {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommValExceedMaxFailed,
+			Args: []interface{}{common.BKDescriptionField, localAuthRoleDescMaxLen}}
+	}
+
+	if len(o.Permissions) > localAuthPermissionLimit {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommXXExceedLimit,
+			Args: []interface{}{"permissions", localAuthPermissionLimit}}
+	}
+
+	actions := make(map[string]struct{})
+	for _, perm := range o.Permissions {
+		if len(perm.Action) == 0 {
+			return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"permissions.action"}}
+		}
+
+		if _, exists := actions[perm.Action]; exists {
+			return ccErr.RawErrorInfo{ErrCode: common.CCErrCommDuplicateItem, Args: []interface{}{perm.Action}}
+		}
+		actions[perm.Action] = struct{}{}
+
+		if len(perm.Instances) > localAuthInstanceLimit {
+			return ccErr.RawErrorInfo{ErrCode: common.CCErrCommXXExceedLimit,
+				Args: []interface{}{"permissions.instances", localAuthInstanceLimit}}
+		}
+
+		for _, inst := range perm.Instances {
+			if len(inst) == 0 {
+				return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid,
+					Args: []interface{}{"permissions.instances"}}
+			}
+		}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// LocalAuthScope is the scope that the role binding takes effect on
+type LocalAuthScope struct {
+	Type LocalAuthScopeType `json:"type" bson:"type"`
+	// ID is the business id or biz set id, it is not needed for system scope
+	ID int64 `json:"id" bson:"id"`
+}
+
+// Validate validate LocalAuthScope
+func (s LocalAuthScope) Validate() ccErr.RawErrorInfo {
+	switch s.Type {
+	case LocalAuthSystemScope:
+		if s.ID != 0 {
+			return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{"scope.id"}}
+		}
+	case LocalAuthBizScope, LocalAuthBizSetScope:
+		if s.ID <= 0 {
+			return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{"scope.id"}}
+		}
+	default:
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{"scope.type"}}
+	}
+	return ccErr.RawErrorInfo{}
+}
+
+// LocalAuthRoleBinding grants the role to the users in the scope
+type LocalAuthRoleBinding struct {
+	ID         int64          `json:"id" bson:"id"`
+	RoleID     int64          `json:"role_id" bson:"role_id"`
+	Users      []string       `json:"users" bson:"users"`
+	Scope      LocalAuthScope `json:"scope" bson:"scope"`
+	OwnerID    string         `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Creator    string         `json:"creator" bson:"creator"`
+	Modifier   string         `json:"modifier" bson:"modifier"`
+	CreateTime *Time          `json:"create_time" bson:"create_time"`
+	LastTime   *Time          `json:"last_time" bson:"last_time"`
+}
+
+// CreateLocalAuthRoleBindingOption is the option to create a local auth role binding
+type CreateLocalAuthRoleBindingOption struct {
+	RoleID int64          `json:"role_id"`
+	Users  []string       `json:"users"`
+	Scope  LocalAuthScope `json:"scope"`
+}
+
+// Validate validate CreateLocalAuthRoleBindingOption
+func (o *CreateLocalAuthRoleBindingOption) Validate() ccErr.RawErrorInfo {
+	if o.RoleID <= 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"role_id"}}
+	}
+
+	if err := validateLocalAuthUsers(o.Users); err.ErrCode != 0 {
+		return err
+	}
+
+	return o.Scope.Validate()
+}
+
+// UpdateLocalAuthRoleBindingOption is the option to update the users of a local auth role binding
+type UpdateLocalAuthRoleBindingOption struct {
+	Users []string `json:"users"`
+}
+
+// Validate validate UpdateLocalAuthRoleBindingOption
+func (o *UpdateLocalAuthRoleBindingOption) Validate() ccErr.RawErrorInfo {
+	return validateLocalAuthUsers(o.Users)
+}
+
+func validateLocalAuthUsers(users []string) ccErr.RawErrorInfo {
+	if len(users) == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"users"}}
+	}
+
+	if len(users) > localAuthBindingUserLimit {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommXXExceedLimit,
+			Args: []interface{}{"users", localAuthBindingUserLimit}}
+	}
+
+	for _, user := range users {
+		if len(user) == 0 {
+			return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{"users"}}
+		}
+	}
+	return ccErr.RawErrorInfo{}
+}
+
+// ListLocalAuthRoleOption is the option to list local auth roles
+type ListLocalAuthRoleOption struct {
+	IDs  []int64  `json:"ids"`
+	Name string   `json:"name"`
+	Page BasePage `json:"page"`
+}
+
+// Validate validate ListLocalAuthRoleOption
+func (o *ListLocalAuthRoleOption) Validate() ccErr.RawErrorInfo {
+	if len(o.IDs) > common.BKMaxPageSize {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommXXExceedLimit,
+			Args: []interface{}{"ids", common.BKMaxPageSize}}
+	}
+	return o.Page.ValidateWithEnableCount(false)
+}
+
+// ListLocalAuthRoleResult is the result of listing local auth roles
+type ListLocalAuthRoleResult struct {
+	Count uint64          `json:"count"`
+	Info  []LocalAuthRole `json:"info"`
+}
+
+// ListLocalAuthRoleResp is the response of listing local auth roles
+type ListLocalAuthRoleResp struct {
+	BaseResp `json:",inline"`
+	Data     *ListLocalAuthRoleResult `json:"data"`
+}
+
+// ListLocalAuthRoleBindingOption is the option to list local auth role bindings
+type ListLocalAuthRoleBindingOption struct {
+	IDs    []int64         `json:"ids"`
+	RoleID int64           `json:"role_id"`
+	User   string          `json:"user"`
+	Scope  *LocalAuthScope `json:"scope"`
+	Page   BasePage        `json:"page"`
+}
+
+// Validate validate ListLocalAuthRoleBindingOption
+func (o *ListLocalAuthRoleBindingOption) Validate() ccErr.RawErrorInfo {
+	if len(o.IDs) > common.BKMaxPageSize {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommXXExceedLimit,
+			Args: []interface{}{"ids", common.BKMaxPageSize}}
+	}
+
+	if o.Scope != nil {
+		if err := o.Scope.Validate(); err.ErrCode != 0 {
+			return err
+		}
+	}
+	return o.Page.ValidateWithEnableCount(false)
+}
+
+// ListLocalAuthRoleBindingResult is the result of listing local auth role bindings
+type ListLocalAuthRoleBindingResult struct {
+	Count uint64                 `json:"count"`
+	Info  []LocalAuthRoleBinding `json:"info"`
+}
+
+// ListLocalAuthRoleBindingResp is the response of listing local auth role bindings
+type ListLocalAuthRoleBindingResp struct {
+	BaseResp `json:",inline"`
+	Data     *ListLocalAuthRoleBindingResult `json:"data"`
+}
+
+// GetLocalAuthUserPolicyOption is the option to get all the policies of a user
+type GetLocalAuthUserPolicyOption struct {
+	User string `json:"user"`
+}
+
+// LocalAuthUserPolicy is all the role bindings of a user and the roles that they refer to
+type LocalAuthUserPolicy struct {
+	Bindings []LocalAuthRoleBinding `json:"bindings"`
+	Roles    []LocalAuthRole        `json:"roles"`
+}
+
+// LocalAuthUserPolicyResp is the response of getting the policies of a user
+type LocalAuthUserPolicyResp struct {
+	BaseResp `json:",inline"`
+	Data     *LocalAuthUserPolicy `json:"data"`
+}
+
+// LocalAuthRoleResp is the response of creating or updating a local auth role
+type LocalAuthRoleResp struct {
+	BaseResp `json:",inline"`
+	Data     *LocalAuthRole `json:"data"`
+}
+
+// LocalAuthRoleBindingResp is the response of creating or updating a local auth role binding
+type LocalAuthRoleBindingResp struct {
+	BaseResp `json:",inline"`
+	Data     *LocalAuthRoleBinding `json:"data"`
+}
@@ -52,6 +52,37 @@ type NetcollectProperty struct {
 	DeviceModel          string     `json:"device_model,omitempty" bson:"-"`
 }
 
+// NetcollectTarget is a network device whose properties are pulled by the protocol driver of datacollection
+// directly, such as NETCONF, RESTCONF or vendor REST APIs, rather than reported by the netdevicebeat plugin.
+// The properties to pull are the net properties of the net device, their oid are the locators of the driver.
+type NetcollectTarget struct {
+	TargetID   uint64 `json:"target_id,omitempty" bson:"target_id,omitempty"`
+	DeviceID   uint64 `json:"device_id" bson:"device_id"`
+	DeviceName string `json:"device_name,omitempty" bson:"-"`
+	CloudID    int64  `json:"bk_cloud_id" bson:"bk_cloud_id"`
+	// Address is the management ip address or domain of the target.
+	Address string `json:"address" bson:"address"`
+	// InstKey is the instance name of the target in cmdb, default is the address.
+	InstKey  string `json:"bk_inst_key" bson:"bk_inst_key"`
+	Protocol string `json:"protocol" bson:"protocol"`
+	Port     int    `json:"port,omitempty" bson:"port,omitempty"`
+	Username string `json:"username,omitempty" bson:"username,omitempty"`
+	Password string `json:"password,omitempty" bson:"password,omitempty"`
+	// Options are the driver specific options, such as "scheme" and "insecure_skip_verify".
+	Options    map[string]string      `json:"options,omitempty" bson:"options,omitempty"`
+	Period     string                 `json:"period,omitempty" bson:"period,omitempty"`
+	Status     NetcollectTargetStatus `json:"status" bson:"status"`
+	OwnerID    string                 `json:"-" bson:"bk_supplier_account,omitempty"`
+	CreateTime *time.Time             `json:"create_time,omitempty" bson:"create_time,omitempty"`
+	LastTime   *time.Time             `json:"last_time,omitempty" bson:"last_time,omitempty"`
+}
+
+// NetcollectTargetStatus is the latest collecting status of the target.
+type NetcollectTargetStatus struct {
+	LastCollectTime *time.Time `json:"last_collect_time,omitempty" bson:"last_collect_time,omitempty"`
+	Error           string     `json:"error" bson:"error"`
+}
+
 // ParamNetcollectorSearch TODO
 type ParamNetcollectorSearch struct {
 	Query string   `json:"query"`
@@ -34,6 +34,8 @@ type ChartConfig struct {
 	ChartType  string `json:"chart_type" bson:"chart_type"`
 	Field      string `json:"field" bson:"field"`
 	XAxisCount int64  `json:"x_axis_count" bson:"x_axis_count"`
+	// StatisticQuery is the query of the statistic query chart
+	StatisticQuery *StatisticQueryOption `json:"statistic_query,omitempty" bson:"statistic_query,omitempty"`
 }
 
 // ChartPosition TODO
@@ -122,6 +124,7 @@ type HostChangeChartData struct {
 // ChartData TODO
 type ChartData struct {
 	ReportType string      `json:"report_type" bson:"report_type"`
+	ConfigID   uint64      `json:"config_id,omitempty" bson:"config_id,omitempty"`
 	Data       interface{} `json:"data" data:"data"`
 	OwnerID    string      `json:"bk_supplier_account" bson:"bk_supplier_account"`
 	LastTime   time.Time   `json:"last_time" bson:"last_time"`
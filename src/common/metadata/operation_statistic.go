@@ -0,0 +1,373 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package metadata
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"configcenter/pkg/filter"
+	"configcenter/src/common"
+	ccErr "configcenter/src/common/errors"
+)
+
+const (
+	// StatisticMaxDimensions the max number of group by dimensions of a statistic query
+	StatisticMaxDimensions = 5
+	// StatisticMaxGroups the max number of result groups returned by a statistic query
+	StatisticMaxGroups = 1000
+)
+
+// StatisticAuditLogFields are the audit log fields that can be used as the dimensions and the metric field of
+// the audit log source, the value is whether the field is numeric so that it can be summed or averaged
+var StatisticAuditLogFields = map[string]bool{
+	"audit_type":                 false,
+	"user":                       false,
+	common.BKResourceTypeField:   false,
+	"action":                     false,
+	"operate_from":               false,
+	common.BKOperationTimeField:  false,
+	common.BKAppIDField:          true,
+	"resource_id":                false,
+	"resource_name":              false,
+	"code":                       false,
+	"operation_detail.bk_obj_id": false,
+}
+
+// StatisticSource is the data source of a statistic query
+type StatisticSource string
+
+const (
+	// StatisticSourceInstance statistic on the instances of the object
+	StatisticSourceInstance StatisticSource = "instance"
+	// StatisticSourceAuditLog statistic on the audit logs of the object's instances
+	StatisticSourceAuditLog StatisticSource = "audit_log"
+)
+
+// StatisticMetricType is the aggregate function of a statistic query
+type StatisticMetricType string
+
+const (
+	// StatisticMetricCount count the matched documents
+	StatisticMetricCount StatisticMetricType = "count"
+	// StatisticMetricSum sum the metric field
+	StatisticMetricSum StatisticMetricType = "sum"
+	// StatisticMetricAvg average the metric field
+	StatisticMetricAvg StatisticMetricType = "avg"
+	// StatisticMetricMax max value of the metric field
+	StatisticMetricMax StatisticMetricType = "max"
+	// StatisticMetricMin min value of the metric field
+	StatisticMetricMin StatisticMetricType = "min"
+)
+
+// StatisticTimeUnit is the bucket unit of a statistic query time bucket
+type StatisticTimeUnit string
+
+const (
+	// StatisticTimeUnitDay bucket by day, time is formatted as 2006-01-02
+	StatisticTimeUnitDay StatisticTimeUnit = "day"
+	// StatisticTimeUnitWeek bucket by iso week, time is formatted as 2006-W01
+	StatisticTimeUnitWeek StatisticTimeUnit = "week"
+	// StatisticTimeUnitMonth bucket by month, time is formatted as 2006-01
+	StatisticTimeUnitMonth StatisticTimeUnit = "month"
+)
+
+// DateFormat returns the mongodb $dateToString format of the time unit
+func (u StatisticTimeUnit) DateFormat() string {
+	switch u {
+	case StatisticTimeUnitWeek:
+		return "%G-W%V"
+	case StatisticTimeUnitMonth:
+		return "%Y-%m"
+	default:
+		return "%Y-%m-%d"
+	}
+}
+
+// StatisticQueryOption is a generic statistic query option, it counts the instances or audit logs of an
+// object matching the filter, grouped by the dimensions and bucketed by time.
+type StatisticQueryOption struct {
+	ObjID  string             `json:"bk_obj_id" bson:"bk_obj_id"`
+	Source StatisticSource    `json:"source" bson:"source"`
+	Filter *filter.Expression `json:"filter,omitempty" bson:"filter,omitempty"`
+	// Dimensions are the fields to group by, for audit log source they are the audit log's fields
+	Dimensions []string             `json:"dimensions" bson:"dimensions"`
+	Metric     StatisticMetric      `json:"metric" bson:"metric"`
+	TimeBucket *StatisticTimeBucket `json:"time_bucket,omitempty" bson:"time_bucket,omitempty"`
+}
+
+// StatisticMetric is the metric of a statistic query
+type StatisticMetric struct {
+	Type StatisticMetricType `json:"type" bson:"type"`
+	// Field is the numeric field to aggregate, not needed for count metric
+	Field string `json:"field,omitempty" bson:"field,omitempty"`
+}
+
+// StatisticTimeBucket buckets the statistic data over time. the instance source uses the instance's
+// create time, the audit log source uses the operation time.
+type StatisticTimeBucket struct {
+	Unit StatisticTimeUnit `json:"unit" bson:"unit"`
+	// Start and End are the time range in the format of 2006-01-02 15:04:05, both are optional
+	Start string `json:"start,omitempty" bson:"start,omitempty"`
+	End   string `json:"end,omitempty" bson:"end,omitempty"`
+	// Recent limits the time range to the recent n units including the current one, it is used by saved charts
+	// so that the range moves forward when they are refreshed. it can not be used with start.
+	Recent int `json:"recent,omitempty" bson:"recent,omitempty"`
+	// Timezone is the iana time zone name used to bucket and parse times, default is UTC
+	Timezone string `json:"timezone,omitempty" bson:"timezone,omitempty"`
+}
+
+// Validate statistic query option, fills in the default source and metric type
+func (s *StatisticQueryOption) Validate() ccErr.RawErrorInfo {
+	if len(s.ObjID) == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{common.BKObjIDField}}
+	}
+
+	switch s.Source {
+	case "":
+		s.Source = StatisticSourceInstance
+	case StatisticSourceInstance, StatisticSourceAuditLog:
+	default:
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{"source"}}
+	}
+
+	if s.Filter != nil {
+		opt := filter.NewDefaultExprOpt(nil)
+		opt.IgnoreRuleFields = true
+		if err := s.Filter.Validate(opt); err != nil {
+			return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{err.Error()}}
+		}
+	}
+
+	if len(s.Dimensions) > StatisticMaxDimensions {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommXXExceedLimit,
+			Args: []interface{}{"dimensions", StatisticMaxDimensions}}
+	}
+
+	dimensions := make(map[string]struct{}, len(s.Dimensions))
+	for _, dimension := range s.Dimensions {
+		if !isValidStatisticField(dimension) {
+			return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{"dimensions"}}
+		}
+		if _, exists := dimensions[dimension]; exists {
+			return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid,
+				Args: []interface{}{fmt.Sprintf("duplicate dimension %s", dimension)}}
+		}
+		dimensions[dimension] = struct{}{}
+	}
+
+	if err := s.Metric.validate(); err.ErrCode != 0 {
+		return err
+	}
+
+	if s.TimeBucket != nil {
+		if err := s.TimeBucket.validate(); err.ErrCode != 0 {
+			return err
+		}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// ValidateFields checks that the dimensions and the metric field are the fields of the statistic source, the
+// instance source uses the attributes of the model whose types are given by attrTypes, and the audit log source
+// uses StatisticAuditLogFields. it should be called after Validate.
+func (s *StatisticQueryOption) ValidateFields(attrTypes map[string]string) ccErr.RawErrorInfo {
+	for _, dimension := range s.Dimensions {
+		if _, exists := s.getFieldNumeric(dimension, attrTypes); !exists {
+			return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid,
+				Args: []interface{}{fmt.Sprintf("dimension %s is not a field of %s", dimension, s.Source)}}
+		}
+	}
+
+	if s.Metric.Type == StatisticMetricCount {
+		return ccErr.RawErrorInfo{}
+	}
+
+	numeric, exists := s.getFieldNumeric(s.Metric.Field, attrTypes)
+	if !exists {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid,
+			Args: []interface{}{fmt.Sprintf("metric.field %s is not a field of %s", s.Metric.Field, s.Source)}}
+	}
+
+	// max and min can be applied on the non-numeric fields like time, sum and avg can not
+	if !numeric && (s.Metric.Type == StatisticMetricSum || s.Metric.Type == StatisticMetricAvg) {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid,
+			Args: []interface{}{fmt.Sprintf("metric.field %s is not numeric", s.Metric.Field)}}
+	}
+	return ccErr.RawErrorInfo{}
+}
+
+// getFieldNumeric returns whether the field of the statistic source is numeric and whether it exists, the sub
+// field of the instance's attribute belongs to the attribute
+func (s *StatisticQueryOption) getFieldNumeric(field string, attrTypes map[string]string) (bool, bool) {
+	if s.Source == StatisticSourceAuditLog {
+		numeric, exists := StatisticAuditLogFields[field]
+		return numeric, exists
+	}
+
+	attrID := strings.Split(field, ".")[0]
+	if attrID == common.CreateTimeField || attrID == common.LastTimeField {
+		return false, true
+	}
+
+	attrType, exists := attrTypes[attrID]
+	if !exists {
+		return false, false
+	}
+	return attrType == common.FieldTypeInt || attrType == common.FieldTypeFloat, true
+}
+
+func (m *StatisticMetric) validate() ccErr.RawErrorInfo {
+	switch m.Type {
+	case "":
+		m.Type = StatisticMetricCount
+		return ccErr.RawErrorInfo{}
+	case StatisticMetricCount:
+		return ccErr.RawErrorInfo{}
+	case StatisticMetricSum, StatisticMetricAvg, StatisticMetricMax, StatisticMetricMin:
+	default:
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{"metric.type"}}
+	}
+
+	if len(m.Field) == 0 {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"metric.field"}}
+	}
+
+	if !isValidStatisticField(m.Field) {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{"metric.field"}}
+	}
+	return ccErr.RawErrorInfo{}
+}
+
+func (t *StatisticTimeBucket) validate() ccErr.RawErrorInfo {
+	switch t.Unit {
+	case StatisticTimeUnitDay, StatisticTimeUnitWeek, StatisticTimeUnitMonth:
+	default:
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{"time_bucket.unit"}}
+	}
+
+	if t.Recent < 0 || (t.Recent > 0 && len(t.Start) != 0) {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{"time_bucket.recent"}}
+	}
+
+	if _, _, err := t.TimeRange(time.Now()); err != nil {
+		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{err.Error()}}
+	}
+	return ccErr.RawErrorInfo{}
+}
+
+// Location returns the time zone of the time bucket
+func (t *StatisticTimeBucket) Location() (*time.Location, error) {
+	if len(t.Timezone) == 0 {
+		return time.UTC, nil
+	}
+
+	loc, err := time.LoadLocation(t.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time_bucket.timezone %s", t.Timezone)
+	}
+	return loc, nil
+}
+
+// TimeRange returns the [start, end) time range of the time bucket relative to now, nil means unlimited
+func (t *StatisticTimeBucket) TimeRange(now time.Time) (*time.Time, *time.Time, error) {
+	loc, err := t.Location()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var start, end *time.Time
+	if len(t.Start) != 0 {
+		st, err := time.ParseInLocation(common.TimeTransferModel, t.Start, loc)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid time_bucket.start %s", t.Start)
+		}
+		start = &st
+	}
+
+	if len(t.End) != 0 {
+		et, err := time.ParseInLocation(common.TimeTransferModel, t.End, loc)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid time_bucket.end %s", t.End)
+		}
+		end = &et
+	}
+
+	if start != nil && end != nil && !start.Before(*end) {
+		return nil, nil, fmt.Errorf("time_bucket.start must be earlier than time_bucket.end")
+	}
+
+	if t.Recent > 0 {
+		st := t.bucketStart(now.In(loc), t.Recent-1)
+		start = &st
+	}
+
+	return start, end, nil
+}
+
+// bucketStart returns the start time of the bucket which is n units before the bucket of the given time
+func (t *StatisticTimeBucket) bucketStart(tm time.Time, n int) time.Time {
+	day := time.Date(tm.Year(), tm.Month(), tm.Day(), 0, 0, 0, 0, tm.Location())
+	switch t.Unit {
+	case StatisticTimeUnitWeek:
+		// iso week starts on monday
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset-7*n)
+	case StatisticTimeUnitMonth:
+		return time.Date(tm.Year(), tm.Month()-time.Month(n), 1, 0, 0, 0, 0, tm.Location())
+	default:
+		return day.AddDate(0, 0, -n)
+	}
+}
+
+// isValidStatisticField checks that the field can be referenced in an aggregate pipeline
+func isValidStatisticField(field string) bool {
+	if len(field) == 0 || strings.HasPrefix(field, "$") {
+		return false
+	}
+
+	for _, part := range strings.Split(field, ".") {
+		if len(part) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// StatisticResult is the result of a statistic query
+type StatisticResult struct {
+	Info []StatisticResultItem `json:"info" bson:"info"`
+	// Truncated defines if the groups exceeding StatisticMaxGroups are cut off from the result
+	Truncated bool `json:"truncated" bson:"truncated"`
+}
+
+// StatisticResultItem is one group of the statistic query result
+type StatisticResultItem struct {
+	// Dimensions are the group by dimension field values of this group
+	Dimensions map[string]interface{} `json:"dimensions" bson:"dimensions"`
+	// Time is the time bucket of this group, only set when time bucket is used
+	Time  string  `json:"time,omitempty" bson:"time,omitempty"`
+	Value float64 `json:"value" bson:"value"`
+}
+
+// StatisticQueryResponse is the response of a statistic query
+type StatisticQueryResponse struct {
+	BaseResp `json:",inline"`
+	Data     *StatisticResult `json:"data"`
+}
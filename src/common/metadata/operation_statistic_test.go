@@ -0,0 +1,128 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package metadata
+
+import (
+	"testing"
+	"time"
+
+	"configcenter/pkg/filter"
+	"configcenter/src/common"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatisticQueryOptionValidate(t *testing.T) {
+	opt := &StatisticQueryOption{
+		ObjID: "switch",
+		Filter: &filter.Expression{RuleFactory: &filter.AtomRule{
+			Field:    "bk_status",
+			Operator: filter.Equal.Factory(),
+			Value:    "online",
+		}},
+		Dimensions: []string{"bk_vendor", "bk_region"},
+		TimeBucket: &StatisticTimeBucket{Unit: StatisticTimeUnitMonth, Recent: 6, Timezone: "Asia/Shanghai"},
+	}
+	require.Equal(t, 0, opt.Validate().ErrCode)
+	require.Equal(t, StatisticSourceInstance, opt.Source)
+	require.Equal(t, StatisticMetricCount, opt.Metric.Type)
+
+	invalidOpts := []StatisticQueryOption{
+		{},
+		{ObjID: "switch", Source: "event"},
+		{ObjID: "switch", Dimensions: []string{"a", "b", "c", "d", "e", "f"}},
+		{ObjID: "switch", Dimensions: []string{"bk_vendor", "bk_vendor"}},
+		{ObjID: "switch", Dimensions: []string{"$bk_vendor"}},
+		{ObjID: "switch", Dimensions: []string{"detail..name"}},
+		{ObjID: "switch", Metric: StatisticMetric{Type: "median", Field: "port_num"}},
+		{ObjID: "switch", Metric: StatisticMetric{Type: StatisticMetricSum}},
+		{ObjID: "switch", TimeBucket: &StatisticTimeBucket{Unit: "year"}},
+		{ObjID: "switch", TimeBucket: &StatisticTimeBucket{Unit: StatisticTimeUnitDay, Timezone: "Mars/Base"}},
+		{ObjID: "switch", TimeBucket: &StatisticTimeBucket{Unit: StatisticTimeUnitDay, Start: "2023-01-01"}},
+		{ObjID: "switch", TimeBucket: &StatisticTimeBucket{Unit: StatisticTimeUnitDay, Recent: 3,
+			Start: "2023-01-01 00:00:00"}},
+		{ObjID: "switch", TimeBucket: &StatisticTimeBucket{Unit: StatisticTimeUnitDay,
+			Start: "2023-02-01 00:00:00", End: "2023-01-01 00:00:00"}},
+	}
+	for idx := range invalidOpts {
+		require.NotEqual(t, 0, invalidOpts[idx].Validate().ErrCode, "option %d", idx)
+	}
+}
+
+func TestStatisticQueryOptionValidateFields(t *testing.T) {
+	attrTypes := map[string]string{
+		"bk_vendor": common.FieldTypeEnum,
+		"port_num":  common.FieldTypeInt,
+		"detail":    common.FieldTypeJSON,
+	}
+
+	validOpts := []StatisticQueryOption{
+		{ObjID: "switch", Source: StatisticSourceInstance, Dimensions: []string{"bk_vendor", "detail.name"},
+			Metric: StatisticMetric{Type: StatisticMetricSum, Field: "port_num"}},
+		{ObjID: "switch", Source: StatisticSourceInstance, Dimensions: []string{"bk_vendor"},
+			Metric: StatisticMetric{Type: StatisticMetricMax, Field: common.CreateTimeField}},
+		{ObjID: "switch", Source: StatisticSourceAuditLog, Dimensions: []string{"action", "operation_detail.bk_obj_id"},
+			Metric: StatisticMetric{Type: StatisticMetricCount}},
+	}
+	for idx := range validOpts {
+		require.Equal(t, 0, validOpts[idx].ValidateFields(attrTypes).ErrCode, "option %d", idx)
+	}
+
+	invalidOpts := []StatisticQueryOption{
+		{ObjID: "switch", Source: StatisticSourceInstance, Dimensions: []string{"bk_region"},
+			Metric: StatisticMetric{Type: StatisticMetricCount}},
+		{ObjID: "switch", Source: StatisticSourceInstance, Metric: StatisticMetric{Type: StatisticMetricAvg,
+			Field: "port_count"}},
+		{ObjID: "switch", Source: StatisticSourceInstance, Metric: StatisticMetric{Type: StatisticMetricSum,
+			Field: "bk_vendor"}},
+		{ObjID: "switch", Source: StatisticSourceAuditLog, Dimensions: []string{"bk_vendor"},
+			Metric: StatisticMetric{Type: StatisticMetricCount}},
+		{ObjID: "switch", Source: StatisticSourceAuditLog, Metric: StatisticMetric{Type: StatisticMetricSum,
+			Field: common.BKOperationTimeField}},
+	}
+	for idx := range invalidOpts {
+		require.NotEqual(t, 0, invalidOpts[idx].ValidateFields(attrTypes).ErrCode, "option %d", idx)
+	}
+}
+
+func TestStatisticTimeBucketTimeRange(t *testing.T) {
+	now := time.Date(2023, 3, 15, 10, 30, 0, 0, time.UTC)
+
+	bucket := &StatisticTimeBucket{Unit: StatisticTimeUnitDay, Recent: 7}
+	start, end, err := bucket.TimeRange(now)
+	require.NoError(t, err)
+	require.Nil(t, end)
+	require.Equal(t, time.Date(2023, 3, 9, 0, 0, 0, 0, time.UTC), *start)
+
+	// 2023-03-15 is wednesday, the iso week starts on monday
+	bucket = &StatisticTimeBucket{Unit: StatisticTimeUnitWeek, Recent: 2}
+	start, _, err = bucket.TimeRange(now)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2023, 3, 6, 0, 0, 0, 0, time.UTC), *start)
+
+	bucket = &StatisticTimeBucket{Unit: StatisticTimeUnitMonth, Recent: 6}
+	start, _, err = bucket.TimeRange(now)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC), *start)
+
+	bucket = &StatisticTimeBucket{Unit: StatisticTimeUnitDay, Start: "2023-01-01 00:00:00",
+		End: "2023-02-01 00:00:00", Timezone: "Asia/Shanghai"}
+	start, end, err = bucket.TimeRange(now)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2022, 12, 31, 16, 0, 0, 0, time.UTC), start.UTC())
+	require.Equal(t, time.Date(2023, 1, 31, 16, 0, 0, 0, time.UTC), end.UTC())
+}
@@ -0,0 +1,183 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package metadata
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"configcenter/src/common"
+	ccErr "configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
+)
+
+const (
+	// APITaskScheduleIDField the schedule id field of the task schedule
+	APITaskScheduleIDField = "schedule_id"
+	// APITaskScheduleNextTimeField the next trigger time field of the task schedule
+	APITaskScheduleNextTimeField = "next_time"
+	// APITaskScheduleLastTaskIDField the id field of the last task created by the task schedule
+	APITaskScheduleLastTaskIDField = "last_task_id"
+	// APITaskScheduleLastTriggerTimeField the last trigger time field of the task schedule
+	APITaskScheduleLastTriggerTimeField = "last_trigger_time"
+)
+
+// APITaskScheduleStatus task schedule status type
+type APITaskScheduleStatus string
+
+const (
+	// APITaskScheduleStatusEnabled the task schedule is enabled, tasks are created periodically
+	APITaskScheduleStatusEnabled APITaskScheduleStatus = "enabled"
+	// APITaskScheduleStatusPaused the task schedule is paused, no task is created until it is resumed
+	APITaskScheduleStatusPaused APITaskScheduleStatus = "paused"
+)
+
+// CreateTaskScheduleRequest create task schedule request parameters
+type CreateTaskScheduleRequest struct {
+	// TaskType 任务标识，同时表示所在的任务队列
+	TaskType string `json:"task_type"`
+	// InstID 实例id，定时生成的任务关联的实例id
+	InstID int64 `json:"bk_inst_id"`
+	// Extra used in conjunction with InstID to uniquely identify the created task
+	Extra interface{} `json:"extra,omitempty"`
+	// Data 定时生成的任务的子任务参数
+	Data []interface{} `json:"data"`
+	// Cron 标准cron表达式，如 "*/10 * * * *"
+	Cron string `json:"cron"`
+	// NotBefore 定时任务最早触发时间，为空时从创建时间开始计算
+	NotBefore *time.Time `json:"not_before,omitempty"`
+}
+
+// Validate validate create task schedule request, the task type must be one of the task queues that the task server
+// executes, which is judged by isValidTaskType. the cron expression is validated when it is parsed
+func (c *CreateTaskScheduleRequest) Validate(isValidTaskType func(taskType string) bool) ccErr.RawErrorInfo {
+	c.TaskType = strings.TrimSpace(c.TaskType)
+	if c.TaskType == "" {
+		return ccErr.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedSet,
+			Args:    []interface{}{common.BKTaskTypeField},
+		}
+	}
+
+	if !isValidTaskType(c.TaskType) {
+		return ccErr.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsInvalid,
+			Args:    []interface{}{common.BKTaskTypeField},
+		}
+	}
+
+	if len(c.Data) == 0 {
+		return ccErr.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedSet,
+			Args:    []interface{}{"data"},
+		}
+	}
+
+	c.Cron = strings.TrimSpace(c.Cron)
+	if c.Cron == "" {
+		return ccErr.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedSet,
+			Args:    []interface{}{"cron"},
+		}
+	}
+
+	return ccErr.RawErrorInfo{}
+}
+
+// APITaskSchedule task schedule which creates tasks periodically by cron expression
+type APITaskSchedule struct {
+	// ScheduleID 定时任务ID，由taskserver生成的唯一ID
+	ScheduleID string `json:"schedule_id" bson:"schedule_id"`
+	// TaskType 任务标识，同时表示所在的任务队列
+	TaskType string `json:"task_type" bson:"task_type"`
+	// InstID 实例id，定时生成的任务关联的实例id
+	InstID int64 `json:"bk_inst_id" bson:"bk_inst_id"`
+	// Extra used in conjunction with InstID to uniquely identify the created task
+	Extra interface{} `json:"extra,omitempty" bson:"extra"`
+	// Data 定时生成的任务的子任务参数
+	Data []interface{} `json:"data" bson:"data"`
+	// Cron 标准cron表达式
+	Cron string `json:"cron" bson:"cron"`
+	// NotBefore 定时任务最早触发时间
+	NotBefore *time.Time `json:"not_before,omitempty" bson:"not_before,omitempty"`
+	// Status 定时任务状态
+	Status APITaskScheduleStatus `json:"status" bson:"status"`
+	// NextTime 下次触发时间
+	NextTime time.Time `json:"next_time" bson:"next_time"`
+	// LastTriggerTime 上次触发时间
+	LastTriggerTime *time.Time `json:"last_trigger_time,omitempty" bson:"last_trigger_time,omitempty"`
+	// LastTaskID 上次触发生成的任务ID
+	LastTaskID string `json:"last_task_id,omitempty" bson:"last_task_id,omitempty"`
+	// User 定时任务创建者
+	User string `json:"user" bson:"user"`
+	// Header 创建定时任务请求的 http header，触发生成任务时使用
+	Header http.Header `json:"-" bson:"header"`
+	// SupplierAccount 开发商ID
+	SupplierAccount string `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	// CreateTime 定时任务创建时间
+	CreateTime time.Time `json:"create_time" bson:"create_time"`
+	// LastTime 定时任务最后更新时间
+	LastTime time.Time `json:"last_time" bson:"last_time"`
+}
+
+// ListAPITaskScheduleRequest list task schedule request
+type ListAPITaskScheduleRequest struct {
+	Condition mapstr.MapStr `json:"condition"`
+	Page      BasePage      `json:"page"`
+}
+
+// ListAPITaskScheduleData list task schedule result
+type ListAPITaskScheduleData struct {
+	Info  []APITaskSchedule `json:"info"`
+	Count int64             `json:"count"`
+}
+
+// ListAPITaskScheduleResponse list task schedule response
+type ListAPITaskScheduleResponse struct {
+	BaseResp
+	Data ListAPITaskScheduleData `json:"data"`
+}
+
+// CreateTaskScheduleResponse create task schedule response
+type CreateTaskScheduleResponse struct {
+	BaseResp
+	Data APITaskSchedule `json:"data"`
+}
+
+// APITaskScheduleIDsOption the option to operate task schedules by ids
+type APITaskScheduleIDsOption struct {
+	ScheduleIDs []string `json:"schedule_ids"`
+}
+
+// Validate judging the legality of parameters
+func (o *APITaskScheduleIDsOption) Validate() ccErr.RawErrorInfo {
+	if len(o.ScheduleIDs) == 0 {
+		return ccErr.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedSet,
+			Args:    []interface{}{"schedule_ids"},
+		}
+	}
+
+	if len(o.ScheduleIDs) > common.BKMaxLimitSize {
+		return ccErr.RawErrorInfo{
+			ErrCode: common.CCErrCommXXExceedLimit,
+			Args:    []interface{}{"schedule_ids", common.BKMaxLimitSize},
+		}
+	}
+	return ccErr.RawErrorInfo{}
+}
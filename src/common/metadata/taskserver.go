@@ -34,10 +34,13 @@ type CreateTaskRequest struct {
 	InstID int64 `json:"bk_inst_id"`
 
 	// Extra used in conjunction with InstID to uniquely identify
-	// a task. currently used in scenarios where taskType is SyncFieldTemplateTaskFlag
+	// a task. currently used in scenarios where taskType is SyncFieldTemplateTaskFlag or ImportExcelTaskFlag
 	Extra interface{} `json:"extra,omitempty" bson:"extra"`
 
 	Data []interface{} `json:"data"`
+
+	// NotBefore 任务最早执行时间，为空时任务创建后即可执行
+	NotBefore *time.Time `json:"not_before,omitempty"`
 }
 
 // APITaskDetail task info detail
@@ -67,6 +70,8 @@ type APITaskDetail struct {
 	CreateTime time.Time `json:"create_time,omitempty" bson:"create_time"`
 	// LastTime 任务最后更新时间
 	LastTime time.Time `json:"last_time,omitempty" bson:"last_time"`
+	// NotBefore 任务最早执行时间，为空时任务创建后即可执行
+	NotBefore *time.Time `json:"not_before,omitempty" bson:"not_before,omitempty"`
 }
 
 // APISubTaskDetail task data and execute detail
@@ -157,6 +162,8 @@ const (
 	// APITaskFieldTemplateMaxNum the possible task status scenarios are: one is executing,
 	// one is waiting or new, but there will be no more than two tasks.
 	APITaskFieldTemplateMaxNum = 2
+	// APITaskNotBeforeField the earliest execution time field of the task
+	APITaskNotBeforeField = "not_before"
 )
 
 // ListAPITaskRequest TODO
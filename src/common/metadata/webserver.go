@@ -51,6 +51,7 @@ type LoginUserInfo struct {
 	Language      string                      `json:"-"`
 	AvatarUrl     string                      `json:"avatar_url"`
 	MultiSupplier bool                        `json:"multi_supplier"`
+	DefaultBizID  int64                       `json:"default_biz_id,omitempty"`
 }
 
 // LoginPluginInfo TODO
@@ -75,6 +76,18 @@ type LoginUserPluginInerface interface {
 	GetUserList(c *gin.Context, config map[string]string) ([]*LoginSystemUserInfo, *errors.RawErrorInfo)
 }
 
+// LoginCallbackPluginInterface is implemented by the login plugins that redirect the user to a single sign-on server,
+// it handles the redirection back from the sign-on server, saves the login user and returns the original url
+type LoginCallbackPluginInterface interface {
+	HandleCallback(c *gin.Context, config map[string]string) (redirectURL string, err *errors.RawErrorInfo)
+}
+
+// LoginPasswordPluginInterface is implemented by the login plugins that verify the user name and password submitted
+// from the login page by themselves instead of the user info in the configuration
+type LoginPasswordPluginInterface interface {
+	VerifyPassword(c *gin.Context, config map[string]string, userName, password string) *errors.RawErrorInfo
+}
+
 // LoginSystemUserInfo TODO
 type LoginSystemUserInfo struct {
 	CnName string `json:"chinese_name"`
@@ -107,6 +120,7 @@ type LoginUserInfoDetail struct {
 	OwnerUinArr   []LoginUserInfoOwnerUinList `json:"supplier_list"` // user all owner uin
 	AvatarUrl     string                      `json:"avatar_url"`
 	MultiSupplier bool                        `json:"multi_supplier"`
+	DefaultBizID  int64                       `json:"default_biz_id,omitempty"`
 }
 
 // LoginUserInfoResult TODO
@@ -81,6 +81,12 @@ const (
 	BKTableNameNetcollectReport  = "cc_NetcollectReport"
 	BKTableNameNetcollectHistory = "cc_NetcollectHistory"
 
+	// BKTableNameNetcollectTarget net devices whose properties are pulled by protocol drivers
+	BKTableNameNetcollectTarget = "cc_NetcollectTarget"
+
+	// BKTableNameHostSnapFieldMapping mapping from host snapshot json path to host attribute
+	BKTableNameHostSnapFieldMapping = "cc_HostSnapFieldMapping"
+
 	BKTableNameHostLock = "cc_HostLock"
 
 	// Operation tables
@@ -101,6 +107,8 @@ const (
 	BKTableNameSetServiceTemplateRelation = "cc_SetServiceTemplateRelation"
 	BKTableNameAPITask                    = "cc_APITask"
 	BKTableNameAPITaskSyncHistory         = "cc_APITaskSyncHistory"
+	// BKTableNameAPITaskSchedule task schedule table, the tasks are created periodically by the cron expression
+	BKTableNameAPITaskSchedule = "cc_APITaskSchedule"
 
 	// BKTableNameHostApplyRule rule for host property auto apply
 	BKTableNameHostApplyRule = "cc_HostApplyRule"
@@ -127,6 +135,15 @@ const (
 
 	// BKTableNameObjFieldTemplateRelation  object and field template relationship table
 	BKTableNameObjFieldTemplateRelation = "cc_ObjFieldTemplateRelation"
+
+	// BKTableNameLocalAuthRole the role table of the built-in local authorizer
+	BKTableNameLocalAuthRole = "cc_LocalAuthRole"
+
+	// BKTableNameLocalAuthRoleBinding the role binding table of the built-in local authorizer
+	BKTableNameLocalAuthRoleBinding = "cc_LocalAuthRoleBinding"
+
+	// BKTableNameSyncDataConflict the table of the transfer service's sync data that conflicts with the destination
+	BKTableNameSyncDataConflict = "cc_SyncDataConflict"
 )
 
 // AllTables is all table names, not include the sharding tables which is created dynamically,
@@ -159,6 +176,8 @@ var AllTables = []string{
 	BKTableNameNetcollectProperty,
 	BKTableNameNetcollectReport,
 	BKTableNameNetcollectHistory,
+	BKTableNameNetcollectTarget,
+	BKTableNameHostSnapFieldMapping,
 	BKTableNameTransaction,
 	BKTableNameIDgenerator,
 	BKTableNameHostLock,
@@ -177,9 +196,13 @@ var AllTables = []string{
 	BKTableNameHostApplyRule,
 	BKTableNameAPITask,
 	BKTableNameAPITaskSyncHistory,
+	BKTableNameAPITaskSchedule,
 	BKTableNameCloudSyncTask,
 	BKTableNameCloudAccount,
 	BKTableNameCloudSyncHistory,
+	BKTableNameLocalAuthRole,
+	BKTableNameLocalAuthRoleBinding,
+	BKTableNameSyncDataConflict,
 }
 
 // TableSpecifier is table specifier type which describes the metadata
@@ -244,6 +267,18 @@ func IsObjectInstAsstShardingTable(tableName string) bool {
 	return strings.HasPrefix(tableName, BKObjectInstAsstShardingTablePrefix)
 }
 
+// RedirectShardingTable returns the base table of the object instance (association) sharding table, the sharding
+// tables share the id generator sequence and read preference of their base table.
+func RedirectShardingTable(tableName string) string {
+	if IsObjectInstShardingTable(tableName) {
+		return BKTableNameBaseInst
+	}
+	if IsObjectInstAsstShardingTable(tableName) {
+		return BKTableNameInstAsst
+	}
+	return tableName
+}
+
 // GetInstTableName returns inst data table name
 func GetInstTableName(objID, supplierAccount string) string {
 	switch objID {
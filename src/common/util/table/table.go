@@ -43,6 +43,14 @@ var delArchiveCollMap = map[string]string{
 
 	common.BKTableNameServiceInstance: common.BKTableNameDelArchive,
 
+	common.BKTableNameObjClassification: common.BKTableNameDelArchive,
+	common.BKTableNameAsstDes:           common.BKTableNameDelArchive,
+	common.BKTableNameObjDes:            common.BKTableNameDelArchive,
+	common.BKTableNamePropertyGroup:     common.BKTableNameDelArchive,
+	common.BKTableNameObjAttDes:         common.BKTableNameDelArchive,
+	common.BKTableNameObjUnique:         common.BKTableNameDelArchive,
+	common.BKTableNameObjAsst:           common.BKTableNameDelArchive,
+
 	kubetypes.BKTableNameBaseCluster:        common.BKTableNameKubeDelArchive,
 	kubetypes.BKTableNameBaseNode:           common.BKTableNameKubeDelArchive,
 	kubetypes.BKTableNameBaseNamespace:      common.BKTableNameKubeDelArchive,
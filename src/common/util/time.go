@@ -202,6 +202,33 @@ func FormatPeriod(period string) (string, error) {
 	return strconv.Itoa(num) + period[len(period)-1:], nil
 }
 
+// PeriodToDuration convert the period formatted by FormatPeriod to duration, returns 0 if the period is infinite
+func PeriodToDuration(period string) (time.Duration, error) {
+	period, err := FormatPeriod(period)
+	if err != nil {
+		return 0, err
+	}
+	if common.Infinite == period {
+		return 0, nil
+	}
+
+	num, err := strconv.Atoi(period[:len(period)-1])
+	if err != nil {
+		return 0, err
+	}
+
+	switch period[len(period)-1:] {
+	case "D":
+		return time.Duration(num) * 24 * time.Hour, nil
+	case "H":
+		return time.Duration(num) * time.Hour, nil
+	case "M":
+		return time.Duration(num) * time.Minute, nil
+	default:
+		return time.Duration(num) * time.Second, nil
+	}
+}
+
 // ConvToTime convert value to time type
 func ConvToTime(value interface{}) (time.Time, error) {
 	timeVal, ok := value.(time.Time)
@@ -59,3 +59,29 @@ func TestFormatPeriod(t *testing.T) {
 	}
 	fmt.Println(periodFormated)
 }
+
+func TestPeriodToDuration(t *testing.T) {
+	cases := map[string]time.Duration{
+		"290S": 290 * time.Second,
+		"05M":  5 * time.Minute,
+		"1H":   time.Hour,
+		"2D":   48 * time.Hour,
+		"0S":   0,
+		"":     0,
+		"∞":    0,
+	}
+	for period, expected := range cases {
+		duration, err := PeriodToDuration(period)
+		if err != nil {
+			t.Errorf("convert period %s failed, err: %v", period, err)
+			continue
+		}
+		if duration != expected {
+			t.Errorf("period %s should be %s, but got %s", period, expected, duration)
+		}
+	}
+
+	if _, err := PeriodToDuration("1W"); err == nil {
+		t.Errorf("invalid period 1W should return error")
+	}
+}
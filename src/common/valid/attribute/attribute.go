@@ -57,6 +57,19 @@ func ValidPropertyOption(kit *rest.Kit, propertyType string, option interface{},
 			return kit.CCError.Errorf(common.CCErrCommParamsIsInvalid, "option")
 		}
 		return ValidIDRuleOption(kit, option, attrTypeMap)
+	case common.FieldTypeIPAddr, common.FieldTypeCIDR:
+		return ValidFieldTypeIPOption(kit, propertyType, option, extraOpt)
+	case common.FieldTypeURL:
+		return ValidFieldTypeURLOption(kit, option, extraOpt)
+	case common.FieldTypeJSON:
+		return ValidFieldTypeJSONOption(kit, option, extraOpt)
+	case common.FieldTypeComputed:
+		attrTypeMap, ok := extraOpt.(map[string]string)
+		if !ok {
+			blog.Errorf("extra opt(%+v) type %T is invalid, rid: %s", extraOpt, extraOpt, kit.Rid)
+			return kit.CCError.Errorf(common.CCErrCommParamsIsInvalid, "option")
+		}
+		return ValidComputedOption(kit, option, attrTypeMap)
 	}
 
 	if handle, ok := manager.Get(propertyType); ok {
@@ -335,7 +348,8 @@ func ValidPropertyTypeIsMultiple(kit *rest.Kit, propertyType string, isMultiple
 	switch propertyType {
 	case common.FieldTypeSingleChar, common.FieldTypeInt, common.FieldTypeFloat, common.FieldTypeEnum,
 		common.FieldTypeDate, common.FieldTypeTime, common.FieldTypeLongChar, common.FieldTypeTimeZone,
-		common.FieldTypeBool, common.FieldTypeList:
+		common.FieldTypeBool, common.FieldTypeList, common.FieldTypeIPAddr, common.FieldTypeCIDR, common.FieldTypeURL,
+		common.FieldTypeJSON, common.FieldTypeComputed:
 		if isMultiple != nil && *isMultiple {
 			return kit.CCError.Errorf(common.CCErrCommFieldTypeNotSupportMultiple, propertyType)
 		}
@@ -376,3 +390,58 @@ func ValidIDRuleOption(kit *rest.Kit, val interface{}, attrTypeMap map[string]st
 
 	return nil
 }
+
+// ValidFieldTypeIPOption validate ip address or cidr field type's ip version option and default value
+func ValidFieldTypeIPOption(kit *rest.Kit, propertyType string, option, defaultVal interface{}) error {
+	if _, err := metadata.ParseIPVersionOption(option); err != nil {
+		blog.Errorf("%s type option %+v is invalid, err: %v, rid: %s", propertyType, option, err, kit.Rid)
+		return kit.CCError.Errorf(common.CCErrCommParamsIsInvalid, "option")
+	}
+
+	return validDefaultValue(kit, propertyType, option, defaultVal)
+}
+
+// ValidFieldTypeURLOption validate url field type's scheme option and default value
+func ValidFieldTypeURLOption(kit *rest.Kit, option, defaultVal interface{}) error {
+	if _, err := metadata.ParseURLOption(option); err != nil {
+		blog.Errorf("url type option %+v is invalid, err: %v, rid: %s", option, err, kit.Rid)
+		return kit.CCError.Errorf(common.CCErrCommParamsIsInvalid, "option")
+	}
+
+	return validDefaultValue(kit, common.FieldTypeURL, option, defaultVal)
+}
+
+// ValidFieldTypeJSONOption validate json field type's json schema option and default value
+func ValidFieldTypeJSONOption(kit *rest.Kit, option, defaultVal interface{}) error {
+	if _, err := metadata.ParseJSONOption(option); err != nil {
+		blog.Errorf("json type option %+v is invalid, err: %v, rid: %s", option, err, kit.Rid)
+		return kit.CCError.Errorf(common.CCErrCommParamsIsInvalid, err.Error())
+	}
+
+	return validDefaultValue(kit, common.FieldTypeJSON, option, defaultVal)
+}
+
+// validDefaultValue validate the default value of the field type by its option
+func validDefaultValue(kit *rest.Kit, propertyType string, option, defaultVal interface{}) error {
+	if defaultVal == nil {
+		return nil
+	}
+
+	attr := metadata.Attribute{PropertyType: propertyType, Option: option}
+	if rawErr := attr.Validate(kit.Ctx, defaultVal, "default"); rawErr.ErrCode != 0 {
+		blog.Errorf("%s type default value %+v is invalid, err: %v, rid: %s", propertyType, defaultVal, rawErr,
+			kit.Rid)
+		return kit.CCError.Errorf(common.CCErrCommParamsIsInvalid, propertyType+" default value")
+	}
+	return nil
+}
+
+// ValidComputedOption validate computed field type's expression option, attrTypeMap is the property id to property
+// type map of the model attributes that can be referenced
+func ValidComputedOption(kit *rest.Kit, option interface{}, attrTypeMap map[string]string) error {
+	if err := metadata.ValidComputedOption(option, attrTypeMap); err != nil {
+		blog.Errorf("computed type option %+v is invalid, err: %v, rid: %s", option, err, kit.Rid)
+		return kit.CCError.Errorf(common.CCErrCommParamsIsInvalid, err.Error())
+	}
+	return nil
+}
@@ -24,6 +24,7 @@ import (
 	"configcenter/src/common"
 	"configcenter/src/common/errors"
 	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 )
 
@@ -106,7 +107,7 @@ func TestValidPropertyOption(t *testing.T) {
 			Type:      "text",
 			IsDefault: true,
 		}}, true, []string{"c", "b"}}, false},
-		{"int", args{common.FieldTypeInt, metadata.PrevIntOption{
+		{"int", args{common.FieldTypeInt, metadata.IntOption{
 			Min: 1,
 			Max: 100,
 		}, false, 1}, false},
@@ -129,7 +130,7 @@ func TestValidPropertyOption(t *testing.T) {
 			Type:      "aaa",
 			IsDefault: false,
 		}}, false, nil}, true},
-		{"int", args{common.FieldTypeInt, metadata.PrevIntOption{
+		{"int", args{common.FieldTypeInt, metadata.IntOption{
 			Min: 101,
 			Max: 100,
 		}, false, 100}, true},
@@ -146,8 +147,11 @@ func TestValidPropertyOption(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := ValidPropertyOption(kit, tt.args.propertyType, tt.args.option, tt.args.isMultiple,
-				tt.args.defaultVal)
+			var extraOpt interface{} = tt.args.defaultVal
+			if tt.args.propertyType == common.FieldTypeEnum || tt.args.propertyType == common.FieldTypeEnumMulti {
+				extraOpt = &tt.args.isMultiple
+			}
+			err := ValidPropertyOption(kit, tt.args.propertyType, tt.args.option, extraOpt)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ValidPropertyOption() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -175,3 +179,37 @@ func TestIsStrProperty(t *testing.T) {
 		})
 	}
 }
+
+func TestRunUpdatedPropertyValidators(t *testing.T) {
+	kit := rest.NewKitFromHeader(http.Header{}, errif{})
+	attr := metadata.Attribute{
+		ObjectID:     "obj",
+		PropertyID:   "end",
+		PropertyType: common.FieldTypeInt,
+		Validators: []metadata.AttrValidator{
+			{Name: "field_compare", Params: map[string]interface{}{"field": "start", "op": "gt"}},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		updateData mapstr.MapStr
+		instance   mapstr.MapStr
+		wantErr    bool
+	}{
+		{"update attribute", mapstr.MapStr{"end": 5}, mapstr.MapStr{"start": 1, "end": 5}, false},
+		{"update attribute invalid", mapstr.MapStr{"end": 1}, mapstr.MapStr{"start": 5, "end": 1}, true},
+		{"update referenced field", mapstr.MapStr{"start": 3}, mapstr.MapStr{"start": 3, "end": 5}, false},
+		{"update referenced field invalid", mapstr.MapStr{"start": 10}, mapstr.MapStr{"start": 10, "end": 5}, true},
+		{"update unrelated field", mapstr.MapStr{"name": "a"}, mapstr.MapStr{"name": "a", "start": 10, "end": 5},
+			false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := RunUpdatedPropertyValidators(kit, attr, tt.updateData, tt.instance)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RunUpdatedPropertyValidators() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,2 +1,7 @@
 // Package init  for plugin initialization
 package init
+
+import (
+	// import plugins to register all attribute validators
+	_ "configcenter/src/common/valid/attribute/plugins"
+)
@@ -9,3 +9,9 @@ import (
 
 // Get returns the Attribute by name
 var Get = register.Get
+
+// GetValidator returns the attribute validator plugin by name
+var GetValidator = register.GetValidator
+
+// ListValidators returns all registered attribute validator plugins
+var ListValidators = register.ListValidators
@@ -0,0 +1,87 @@
+package register
+
+import (
+	"context"
+	"fmt"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+)
+
+var (
+	validatorMap = map[string]ValidatorI{}
+)
+
+// ValidateParam is the parameter passed to the validator plugin
+type ValidateParam struct {
+	// ObjID the model of the instance
+	ObjID string
+	// PropertyID the attribute that the validator is attached to
+	PropertyID string
+	// PropertyType the type of the attribute
+	PropertyType string
+	// Params the params of the validator configured in the attribute
+	Params map[string]interface{}
+	// Value the value of the attribute
+	Value interface{}
+	// Instance the whole instance data, for update it is the merged data of the origin instance and update data,
+	// so that the validator can do cross field validation
+	Instance mapstr.MapStr
+}
+
+// ValidatorI interface defines the methods for attribute validator plugins
+type ValidatorI interface {
+	// Name 校验器的唯一名字，模型属性通过该名字引用校验器
+	Name() string
+	// Info 描述信息
+	Info() string
+	// ValidateParams 校验模型属性中配置的校验器参数
+	ValidateParams(ctx context.Context, params map[string]interface{}) error
+	// Validate 实际校验方法，返回的错误信息会直接展示给用户
+	Validate(ctx context.Context, param *ValidateParam) error
+}
+
+// FieldReferencer is optionally implemented by the validator plugins whose params reference other fields of the
+// instance, when any of the referenced fields is updated the validator is run again on the merged instance
+type FieldReferencer interface {
+	// ReferencedFields returns the instance fields referenced by the validator params
+	ReferencedFields(params map[string]interface{}) []string
+}
+
+// RegisterValidator register attribute validator plugin
+// it will panic if the validator name is empty or already exists
+// it is called in init() function of each validator plugin file
+// so that all validators are registered when the program starts, not at runtime
+func RegisterValidator(validator ValidatorI) {
+	if validator == nil {
+		blog.Errorf("register validator is nil")
+		panic("register validator is nil")
+	}
+
+	name := validator.Name()
+	if name == "" {
+		blog.Errorf("register validator name is empty")
+		panic("register validator name is empty")
+	}
+
+	if _, exists := validatorMap[name]; exists {
+		blog.Errorf("validator %s already exists", name)
+		panic(fmt.Sprintf("validator %s already exists", name))
+	}
+	validatorMap[name] = validator
+}
+
+// GetValidator returns the validator plugin by name
+func GetValidator(name string) (ValidatorI, bool) {
+	v, ok := validatorMap[name]
+	return v, ok
+}
+
+// ListValidators returns all registered validator plugins
+func ListValidators() []ValidatorI {
+	validators := make([]ValidatorI, 0, len(validatorMap))
+	for _, v := range validatorMap {
+		validators = append(validators, v)
+	}
+	return validators
+}
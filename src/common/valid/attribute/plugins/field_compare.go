@@ -0,0 +1,150 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"configcenter/src/common/util"
+	"configcenter/src/common/valid/attribute/manager/register"
+)
+
+func init() {
+	register.RegisterValidator(new(fieldCompare))
+}
+
+const (
+	// fieldCompareFieldParam the param name of the compared field
+	fieldCompareFieldParam = "field"
+	// fieldCompareOpParam the param name of the compare operator
+	fieldCompareOpParam = "op"
+)
+
+var fieldCompareOps = map[string]func(cmp int) bool{
+	"eq":  func(cmp int) bool { return cmp == 0 },
+	"ne":  func(cmp int) bool { return cmp != 0 },
+	"gt":  func(cmp int) bool { return cmp > 0 },
+	"gte": func(cmp int) bool { return cmp >= 0 },
+	"lt":  func(cmp int) bool { return cmp < 0 },
+	"lte": func(cmp int) bool { return cmp <= 0 },
+}
+
+// fieldCompare validates the value by comparing it with another field of the same instance,
+// e.g. the expire date must be greater than the start date.
+// numbers and times are compared by value, other values are compared by their string form.
+type fieldCompare struct{}
+
+// Name returns the validator name
+func (f *fieldCompare) Name() string {
+	return "field_compare"
+}
+
+// Info returns the validator description
+func (f *fieldCompare) Info() string {
+	return "compare the value with another field of the instance, params: field, op(eq/ne/gt/gte/lt/lte)"
+}
+
+// ValidateParams validates the params of field compare validator
+func (f *fieldCompare) ValidateParams(_ context.Context, params map[string]interface{}) error {
+	field, ok := params[fieldCompareFieldParam].(string)
+	if !ok || field == "" {
+		return fmt.Errorf("param %s must be a non-empty string", fieldCompareFieldParam)
+	}
+
+	op, ok := params[fieldCompareOpParam].(string)
+	if !ok {
+		return fmt.Errorf("param %s must be a string", fieldCompareOpParam)
+	}
+
+	if _, exists := fieldCompareOps[op]; !exists {
+		return fmt.Errorf("param %s value %s is not supported", fieldCompareOpParam, op)
+	}
+
+	if len(params) != 2 {
+		return fmt.Errorf("only params %s and %s are supported", fieldCompareFieldParam, fieldCompareOpParam)
+	}
+	return nil
+}
+
+// Validate compares the value with the specified field
+func (f *fieldCompare) Validate(_ context.Context, param *register.ValidateParam) error {
+	field := util.GetStrByInterface(param.Params[fieldCompareFieldParam])
+	op := util.GetStrByInterface(param.Params[fieldCompareOpParam])
+	match, exists := fieldCompareOps[op]
+	if !exists {
+		return fmt.Errorf("compare operator %s is not supported", op)
+	}
+
+	other := param.Instance[field]
+	if param.Value == nil || other == nil {
+		return nil
+	}
+
+	if !match(compareValue(param.Value, other)) {
+		return fmt.Errorf("value must be %s the value of field %s", op, field)
+	}
+	return nil
+}
+
+// ReferencedFields returns the compared field, so that the validator is run again when the compared field is updated
+func (f *fieldCompare) ReferencedFields(params map[string]interface{}) []string {
+	field := util.GetStrByInterface(params[fieldCompareFieldParam])
+	if field == "" {
+		return nil
+	}
+	return []string{field}
+}
+
+// compareValue returns an integer comparing two values, the result will be 0 if a==b, -1 if a < b, and +1 if a > b.
+func compareValue(a, b interface{}) int {
+	_, aIsTime := a.(time.Time)
+	_, bIsTime := b.(time.Time)
+	if aIsTime || bIsTime {
+		aTime, aErr := util.ConvToTime(a)
+		bTime, bErr := util.ConvToTime(b)
+		if aErr == nil && bErr == nil {
+			return aTime.Compare(bTime)
+		}
+	}
+
+	if util.IsNumeric(a) && util.IsNumeric(b) {
+		aVal, aErr := util.GetFloat64ByInterface(a)
+		bVal, bErr := util.GetFloat64ByInterface(b)
+		if aErr == nil && bErr == nil {
+			switch {
+			case aVal < bVal:
+				return -1
+			case aVal > bVal:
+				return 1
+			default:
+				return 0
+			}
+		}
+	}
+
+	aStr, bStr := util.GetStrByInterface(a), util.GetStrByInterface(b)
+	switch {
+	case aStr < bStr:
+		return -1
+	case aStr > bStr:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,115 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package plugins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"configcenter/src/common/util"
+	"configcenter/src/common/valid/attribute/manager/register"
+)
+
+func init() {
+	register.RegisterValidator(new(hostname))
+}
+
+const (
+	// hostnameMaxLength the max length of a hostname defined by RFC 1123
+	hostnameMaxLength = 253
+	// hostnamePatternParam the param name of the naming convention regular expression
+	hostnamePatternParam = "pattern"
+)
+
+var hostnameLabelRegexp = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`)
+
+// hostname validates that the value is a valid RFC 1123 hostname, and optionally matches the naming convention
+// specified by the "pattern" param
+type hostname struct{}
+
+// Name returns the validator name
+func (h *hostname) Name() string {
+	return "hostname"
+}
+
+// Info returns the validator description
+func (h *hostname) Info() string {
+	return "validate that the value is a valid hostname, the optional param pattern is the naming convention regexp"
+}
+
+// ValidateParams validates the params of hostname validator
+func (h *hostname) ValidateParams(_ context.Context, params map[string]interface{}) error {
+	for key, val := range params {
+		if key != hostnamePatternParam {
+			return fmt.Errorf("unsupported param %s", key)
+		}
+
+		pattern, ok := val.(string)
+		if !ok {
+			return fmt.Errorf("param %s must be a string", key)
+		}
+
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("param %s is not a valid regular expression, err: %v", key, err)
+		}
+	}
+	return nil
+}
+
+// Validate validates the hostname value
+func (h *hostname) Validate(_ context.Context, param *register.ValidateParam) error {
+	if param.Value == nil {
+		return nil
+	}
+
+	value, ok := param.Value.(string)
+	if !ok {
+		return errors.New("value must be a string")
+	}
+
+	if value == "" {
+		return nil
+	}
+
+	if len(value) > hostnameMaxLength {
+		return fmt.Errorf("hostname exceeds the max length %d", hostnameMaxLength)
+	}
+
+	for _, label := range strings.Split(value, ".") {
+		if !hostnameLabelRegexp.MatchString(label) {
+			return fmt.Errorf("hostname label %s is invalid", label)
+		}
+	}
+
+	pattern := util.GetStrByInterface(param.Params[hostnamePatternParam])
+	if pattern == "" {
+		return nil
+	}
+
+	matched, err := regexp.MatchString(pattern, value)
+	if err != nil {
+		return fmt.Errorf("pattern %s is invalid, err: %v", pattern, err)
+	}
+
+	if !matched {
+		return fmt.Errorf("hostname does not match the naming convention %s", pattern)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package plugins
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/valid/attribute/manager/register"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostnameValidator(t *testing.T) {
+	h, ok := register.GetValidator("hostname")
+	assert.True(t, ok)
+
+	ctx := context.Background()
+	assert.NoError(t, h.ValidateParams(ctx, nil))
+	assert.NoError(t, h.ValidateParams(ctx, map[string]interface{}{"pattern": "^web-[0-9]+$"}))
+	assert.Error(t, h.ValidateParams(ctx, map[string]interface{}{"pattern": "("}))
+	assert.Error(t, h.ValidateParams(ctx, map[string]interface{}{"pattern": 1}))
+	assert.Error(t, h.ValidateParams(ctx, map[string]interface{}{"prefix": "web"}))
+
+	cases := []struct {
+		value   interface{}
+		params  map[string]interface{}
+		isValid bool
+	}{
+		{value: nil, isValid: true},
+		{value: "", isValid: true},
+		{value: "web-01", isValid: true},
+		{value: "web-01.example.com", isValid: true},
+		{value: 1, isValid: false},
+		{value: "-web", isValid: false},
+		{value: "web_01", isValid: false},
+		{value: "web..com", isValid: false},
+		{value: "web-01", params: map[string]interface{}{"pattern": "^web-[0-9]+$"}, isValid: true},
+		{value: "db-01", params: map[string]interface{}{"pattern": "^web-[0-9]+$"}, isValid: false},
+	}
+
+	for idx, c := range cases {
+		err := h.Validate(ctx, &register.ValidateParam{Value: c.value, Params: c.params})
+		assert.Equal(t, c.isValid, err == nil, "case %d, value: %v, err: %v", idx, c.value, err)
+	}
+}
+
+func TestFieldCompareValidator(t *testing.T) {
+	f, ok := register.GetValidator("field_compare")
+	assert.True(t, ok)
+
+	ctx := context.Background()
+	assert.NoError(t, f.ValidateParams(ctx, map[string]interface{}{"field": "start", "op": "gt"}))
+	assert.Error(t, f.ValidateParams(ctx, map[string]interface{}{"op": "gt"}))
+	assert.Error(t, f.ValidateParams(ctx, map[string]interface{}{"field": "start", "op": "in"}))
+	assert.Error(t, f.ValidateParams(ctx, map[string]interface{}{"field": "start", "op": "gt", "a": 1}))
+
+	referencer, ok := f.(register.FieldReferencer)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"start"}, referencer.ReferencedFields(map[string]interface{}{"field": "start"}))
+
+	now := time.Now()
+	cases := []struct {
+		value   interface{}
+		other   interface{}
+		op      string
+		isValid bool
+	}{
+		{value: 2, other: 1, op: "gt", isValid: true},
+		{value: 1, other: 1, op: "gt", isValid: false},
+		{value: 1, other: 1.0, op: "gte", isValid: true},
+		{value: 10, other: 9, op: "lt", isValid: false},
+		{value: "2024-01-02", other: "2024-01-01", op: "gt", isValid: true},
+		{value: "a", other: "a", op: "ne", isValid: false},
+		{value: now.Add(time.Hour), other: now, op: "gt", isValid: true},
+		{value: "2000-01-01 00:00:00", other: now, op: "gte", isValid: false},
+		{value: nil, other: 1, op: "eq", isValid: true},
+		{value: 1, other: nil, op: "eq", isValid: true},
+	}
+
+	for idx, c := range cases {
+		param := &register.ValidateParam{
+			Params:   map[string]interface{}{"field": "start", "op": c.op},
+			Value:    c.value,
+			Instance: mapstr.MapStr{"start": c.other},
+		}
+		err := f.Validate(ctx, param)
+		assert.Equal(t, c.isValid, err == nil, "case %d, value: %v, err: %v", idx, c.value, err)
+	}
+}
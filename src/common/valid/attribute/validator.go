@@ -0,0 +1,133 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package attrvalid
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/valid/attribute/manager"
+	"configcenter/src/common/valid/attribute/manager/register"
+)
+
+// attrValidatorsMaxNum the max number of validator plugins that can be attached to one attribute
+const attrValidatorsMaxNum = 10
+
+// ValidPropertyValidators validate the validator plugins attached to the attribute
+func ValidPropertyValidators(kit *rest.Kit, validators []metadata.AttrValidator) error {
+	if len(validators) > attrValidatorsMaxNum {
+		return kit.CCError.Errorf(common.CCErrCommValExceedMaxFailed, metadata.AttributeFieldValidators,
+			attrValidatorsMaxNum)
+	}
+
+	names := make(map[string]struct{})
+	for _, v := range validators {
+		if _, exists := names[v.Name]; exists {
+			blog.Errorf("validator %s is duplicated, rid: %s", v.Name, kit.Rid)
+			return kit.CCError.Errorf(common.CCErrCommParamsIsInvalid, metadata.AttributeFieldValidators)
+		}
+		names[v.Name] = struct{}{}
+
+		handle, ok := manager.GetValidator(v.Name)
+		if !ok {
+			blog.Errorf("validator %s is not registered, rid: %s", v.Name, kit.Rid)
+			return kit.CCError.Errorf(common.CCErrCommAttrValidatorNotExist, v.Name)
+		}
+
+		if err := handle.ValidateParams(kit.Ctx, v.Params); err != nil {
+			blog.Errorf("validator %s params %+v is invalid, err: %v, rid: %s", v.Name, v.Params, err, kit.Rid)
+			return kit.CCError.Errorf(common.CCErrCommParamsIsInvalid, metadata.AttributeFieldValidators+"."+
+				v.Name+": "+err.Error())
+		}
+	}
+	return nil
+}
+
+// RunPropertyValidators run the validator plugins attached to the attribute on the value,
+// instance is the whole instance data used for cross field validation
+func RunPropertyValidators(kit *rest.Kit, attr metadata.Attribute, value interface{}, instance mapstr.MapStr) error {
+	return runValidators(kit, attr, attr.Validators, value, instance)
+}
+
+// RunUpdatedPropertyValidators run the validator plugins attached to the attribute for the update data,
+// if the attribute is updated, all its validators are run on the updated value, otherwise only the validators
+// whose params reference an updated field are run on the attribute value of the merged instance.
+// for creation, the update data and the instance are both the created data.
+func RunUpdatedPropertyValidators(kit *rest.Kit, attr metadata.Attribute, updateData, instance mapstr.MapStr) error {
+	if len(attr.Validators) == 0 {
+		return nil
+	}
+
+	if value, exists := updateData[attr.PropertyID]; exists {
+		return RunPropertyValidators(kit, attr, value, instance)
+	}
+
+	validators := make([]metadata.AttrValidator, 0)
+	for _, v := range attr.Validators {
+		handle, ok := manager.GetValidator(v.Name)
+		if !ok {
+			blog.Errorf("validator %s of attribute %s is not registered, rid: %s", v.Name, attr.PropertyID, kit.Rid)
+			return kit.CCError.Errorf(common.CCErrCommAttrValidatorNotExist, v.Name)
+		}
+
+		referencer, ok := handle.(register.FieldReferencer)
+		if !ok {
+			continue
+		}
+
+		for _, field := range referencer.ReferencedFields(v.Params) {
+			if _, exists := updateData[field]; exists {
+				validators = append(validators, v)
+				break
+			}
+		}
+	}
+
+	if len(validators) == 0 {
+		return nil
+	}
+	return runValidators(kit, attr, validators, instance[attr.PropertyID], instance)
+}
+
+func runValidators(kit *rest.Kit, attr metadata.Attribute, validators []metadata.AttrValidator, value interface{},
+	instance mapstr.MapStr) error {
+
+	for _, v := range validators {
+		handle, ok := manager.GetValidator(v.Name)
+		if !ok {
+			blog.Errorf("validator %s of attribute %s is not registered, rid: %s", v.Name, attr.PropertyID, kit.Rid)
+			return kit.CCError.Errorf(common.CCErrCommAttrValidatorNotExist, v.Name)
+		}
+
+		param := &register.ValidateParam{
+			ObjID:        attr.ObjectID,
+			PropertyID:   attr.PropertyID,
+			PropertyType: attr.PropertyType,
+			Params:       v.Params,
+			Value:        value,
+			Instance:     instance,
+		}
+		if err := handle.Validate(kit.Ctx, param); err != nil {
+			blog.Errorf("attribute %s value %v is rejected by validator %s, err: %v, rid: %s", attr.PropertyID, value,
+				v.Name, err, kit.Rid)
+			return kit.CCError.Errorf(common.CCErrCommAttrValidatorFailed, attr.PropertyID, v.Name, err.Error())
+		}
+	}
+	return nil
+}
@@ -22,9 +22,17 @@ import (
 	"errors"
 	"fmt"
 
+	"configcenter/pkg/filter"
 	"configcenter/src/common/metadata"
 )
 
+const (
+	// EventStreamContentType is the content type of the watch event stream, which is the server-sent events
+	EventStreamContentType = "text/event-stream"
+	// LastEventIDHeader is the header of the last event cursor received by the client, used to resume the stream
+	LastEventIDHeader = "Last-Event-ID"
+)
+
 // WatchEventOptions TODO
 type WatchEventOptions struct {
 	// event types you want to care, empty means all.
@@ -47,6 +55,10 @@ type WatchEventFilter struct {
 	SubResource string `json:"bk_sub_resource,omitempty"`
 	// SubResources is the sub resources you want to watch, NOTE: this is a special parameter for internal use only
 	SubResources []string `json:"-"`
+	// Rules the filter rules of the watched resource's detail, only the events whose detail matches the rules are
+	// returned. If a resource is updated from matching the rules to not matching them, its update event is returned
+	// with Unmatched set so that the watcher can remove it.
+	Rules *filter.Expression `json:"rules,omitempty"`
 }
 
 // Validate watch event options
@@ -81,6 +93,14 @@ func (w *WatchEventOptions) Validate(isInner bool) error {
 		}
 	}
 
+	if w.Filter.Rules != nil {
+		opt := filter.NewDefaultExprOpt(nil)
+		opt.IgnoreRuleFields = true
+		if err := w.Filter.Rules.Validate(opt); err != nil {
+			return fmt.Errorf("bk_filter.rules is invalid, err: %v", err)
+		}
+	}
+
 	return nil
 }
 
@@ -104,6 +124,8 @@ type WatchEventDetail struct {
 	EventType EventType  `json:"bk_event_type"`
 	// Default instance is JsonString type
 	Detail DetailInterface `json:"bk_detail"`
+	// Unmatched means the resource is updated to not matching the filter rules, only set when the filter rules are used
+	Unmatched bool `json:"bk_unmatched,omitempty"`
 
 	// ChainNode is the chain node of this watch event
 	// NOTE: this is a special return value for internal use only
@@ -115,6 +137,7 @@ type jsonWatchEventDetail struct {
 	Resource  CursorType      `json:"bk_resource"`
 	EventType EventType       `json:"bk_event_type"`
 	Detail    json.RawMessage `json:"bk_detail"`
+	Unmatched bool            `json:"bk_unmatched,omitempty"`
 }
 
 // UnmarshalJSON TODO
@@ -127,6 +150,7 @@ func (w *WatchEventDetail) UnmarshalJSON(data []byte) error {
 	w.Cursor = watchEventDetail.Cursor
 	w.EventType = watchEventDetail.EventType
 	w.Resource = watchEventDetail.Resource
+	w.Unmatched = watchEventDetail.Unmatched
 
 	if watchEventDetail.Detail == nil {
 		return nil
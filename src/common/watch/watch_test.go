@@ -0,0 +1,64 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package watch
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestWatchEventOptionsFilterRules(t *testing.T) {
+	raw := `{"bk_resource":"host","bk_fields":["bk_host_id"],"bk_filter":{"rules":{"condition":"AND",
+"rules":[{"field":"bk_os_type","operator":"equal","value":"1"},
+{"field":"bk_cloud_id","operator":"in","value":[0,1]}]}}}`
+
+	opts := new(WatchEventOptions)
+	if err := json.Unmarshal([]byte(raw), opts); err != nil {
+		t.Fatalf("unmarshal watch options failed, err: %v", err)
+	}
+
+	if opts.Filter.Rules == nil {
+		t.Fatalf("filter rules should be parsed")
+	}
+
+	if err := opts.Validate(false); err != nil {
+		t.Errorf("validate watch options failed, err: %v", err)
+	}
+
+	invalid := `{"bk_resource":"host","bk_fields":["bk_host_id"],"bk_filter":{"rules":{"condition":"AND","rules":[]}}}`
+	opts = new(WatchEventOptions)
+	if err := json.Unmarshal([]byte(invalid), opts); err != nil {
+		t.Fatalf("unmarshal watch options failed, err: %v", err)
+	}
+
+	if err := opts.Validate(false); err == nil {
+		t.Errorf("empty filter rules should be invalid")
+	}
+}
+
+func TestWatchEventDetailUnmatched(t *testing.T) {
+	detail := new(WatchEventDetail)
+	raw := `{"bk_cursor":"xxx","bk_resource":"host","bk_event_type":"update","bk_detail":{"bk_host_id":1},` +
+		`"bk_unmatched":true}`
+	if err := json.Unmarshal([]byte(raw), detail); err != nil {
+		t.Fatalf("unmarshal watch event detail failed, err: %v", err)
+	}
+
+	if !detail.Unmatched {
+		t.Errorf("watch event detail should be unmatched")
+	}
+}
@@ -358,6 +358,23 @@ func (z *ZkClient) CreateEphAndSeq(path string, data []byte) error {
 	return err
 }
 
+// CreateEphemeral create ephemeral node, returns ErrNodeExists if the node already exists
+func (z *ZkClient) CreateEphemeral(path string, data []byte) error {
+	tmpPath := strings.Split(path, "/")
+	if len(tmpPath) > 2 {
+		rootPath := strings.Join(tmpPath[0:len(tmpPath)-1], "/")
+		b, _ := z.Exist(rootPath)
+		if !b {
+			if err := z.CreateDeepNode(rootPath, []byte("")); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err := z.ZkConn.Create(path, data, zk.FlagEphemeral, z.zkAcl)
+	return err
+}
+
 // CreateEphAndSeqEx TODO
 func (z *ZkClient) CreateEphAndSeqEx(path string, data []byte) (string, error) {
 	tmpPath := strings.Split(path, "/")
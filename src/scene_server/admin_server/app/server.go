@@ -80,7 +80,7 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 	process.Service.SetCache(cache)
 
 	var iamCli *iamcli.IAM
-	if auth.EnableAuthorize() {
+	if auth.EnableIAM() {
 		blog.Info("enable auth center access.")
 
 		iamCli, err = iamcli.NewIAM(process.Config.IAM, process.Core.Metric().Registry())
@@ -200,7 +200,7 @@ func parseSeverConfig(ctx context.Context, op *options.ServerOption) (*MigrateSe
 	process.Config.SnapRedis = snapRedisConf
 
 	process.Config.IAM, err = iamcli.ParseConfigFromKV("authServer", nil)
-	if err != nil && auth.EnableAuthorize() {
+	if err != nil && auth.EnableIAM() {
 		blog.Errorf("parse iam error: %v", err)
 		return nil, err
 	}
@@ -100,7 +100,7 @@ func newKit() *rest.Kit {
 
 // SyncIAM sync the system instances resource between CMDB and IAM
 func (s *syncor) SyncIAM(iamCli *iamcli.IAM, redisCli redis.Client, lgc *logics.Logics) {
-	if !auth.EnableAuthorize() {
+	if !auth.EnableIAM() {
 		return
 	}
 	time.Sleep(time.Minute)
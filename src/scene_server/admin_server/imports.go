@@ -122,4 +122,10 @@ import (
 	_ "configcenter/src/scene_server/admin_server/upgrader/y3.14.202502101200"
 	_ "configcenter/src/scene_server/admin_server/upgrader/y3.14.202603231000"
 	_ "configcenter/src/scene_server/admin_server/upgrader/y3.14.202605111642"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.14.202610181030"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.14.202610181130"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.14.202610181230"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.14.202610181330"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.14.202610181430"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.14.202610181530"
 )
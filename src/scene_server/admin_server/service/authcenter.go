@@ -33,7 +33,7 @@ import (
 
 // InitAuthCenter init auth resources on IAM
 func (s *Service) InitAuthCenter(req *restful.Request, resp *restful.Response) {
-	if !auth.EnableAuthorize() {
+	if !auth.EnableIAM() {
 		_ = resp.WriteEntity(metadata.NewSuccessResp(nil))
 		return
 	}
@@ -41,7 +41,7 @@ func (s *Service) InitAuthCenter(req *restful.Request, resp *restful.Response) {
 	rHeader := req.Request.Header
 	rid := httpheader.GetRid(rHeader)
 	defErr := s.CCErr.CreateDefaultCCErrorIf(httpheader.GetLanguage(rHeader))
-	if !auth.EnableAuthorize() {
+	if !auth.EnableIAM() {
 		blog.Warnf("received iam initialization request, but auth not enabled, rid: %s", rid)
 		_ = resp.WriteEntity(metadata.NewSuccessResp(nil))
 		return
@@ -99,7 +99,7 @@ func (s *Service) InitAuthCenter(req *restful.Request, resp *restful.Response) {
 */
 // RegisterAuthAccount register auth account to iam
 func (s *Service) RegisterAuthAccount(req *restful.Request, resp *restful.Response) {
-	if !auth.EnableAuthorize() {
+	if !auth.EnableIAM() {
 		_ = resp.WriteEntity(metadata.NewSuccessResp(nil))
 		return
 	}
@@ -107,7 +107,7 @@ func (s *Service) RegisterAuthAccount(req *restful.Request, resp *restful.Respon
 	rHeader := req.Request.Header
 	rid := httpheader.GetRid(rHeader)
 	defErr := s.CCErr.CreateDefaultCCErrorIf(httpheader.GetLanguage(rHeader))
-	if !auth.EnableAuthorize() {
+	if !auth.EnableIAM() {
 		blog.Warnf("received iam register request, but auth not enabled, rid: %s", rid)
 		_ = resp.WriteEntity(metadata.NewSuccessResp(nil))
 		return
@@ -148,6 +148,8 @@ func (s *Service) createWatchIndexes(cursorType watch.CursorType, key event.Key,
 		{Name: "index_cursor", Keys: bson.D{{common.BKCursorField, -1}}, Background: true, Unique: true},
 		{Name: "index_cluster_time", Keys: bson.D{{common.BKClusterTimeField, -1}}, Background: true,
 			ExpireAfterSeconds: dbChainTTLTime},
+		// used to find the previous event of the same resource when watching with filter rules
+		{Name: "index_oid_id", Keys: bson.D{{common.BKOIDField, 1}, {common.BKFieldID, -1}}, Background: true},
 	}
 
 	if cursorType == watch.ObjectBase || cursorType == watch.MainlineInstance || cursorType == watch.InstAsst {
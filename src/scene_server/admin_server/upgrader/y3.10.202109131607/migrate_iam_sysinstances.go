@@ -39,7 +39,7 @@ import (
 // migrateIAMSysInstances migrate iam system instances
 func migrateIAMSysInstances(ctx context.Context, db dal.RDB, cache redis.Client, iam *iamtype.IAM,
 	conf *upgrader.Config) error {
-	if !auth.EnableAuthorize() {
+	if !auth.EnableIAM() {
 		return nil
 	}
 
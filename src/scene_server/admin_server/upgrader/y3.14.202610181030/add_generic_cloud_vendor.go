@@ -0,0 +1,90 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package y3_14_202610181030
+
+import (
+	"context"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/admin_server/upgrader"
+	"configcenter/src/storage/dal"
+)
+
+// attribute object attribute
+type attribute struct {
+	ID       int64        `json:"id" bson:"id"`
+	ObjectID string       `json:"bk_obj_id" bson:"bk_obj_id"`
+	Option   []enumOption `json:"option" bson:"option"`
+}
+
+// enumOption enum option
+type enumOption struct {
+	ID        string `json:"id" bson:"id"`
+	Name      string `json:"name" bson:"name"`
+	Type      string `json:"type" bson:"type"`
+	IsDefault bool   `json:"is_default" bson:"is_default"`
+}
+
+// addGenericCloudVendor add the generic cloud vendor option to the cloud vendor attribute of host and cloud area
+func addGenericCloudVendor(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	cond := map[string]interface{}{
+		common.BKObjIDField: map[string]interface{}{
+			common.BKDBIN: []string{common.BKInnerObjIDHost, common.BKInnerObjIDPlat},
+		},
+		common.BKPropertyIDField:   common.BKCloudVendor,
+		common.BKPropertyTypeField: common.FieldTypeEnum,
+	}
+
+	objAttrs := make([]attribute, 0)
+	if err := db.Table(common.BKTableNameObjAttDes).Find(cond).All(ctx, &objAttrs); err != nil {
+		blog.Errorf("get cloud vendor attribute failed, err: %v", err)
+		return err
+	}
+
+	genericEnum := enumOption{
+		ID:        metadata.GenericCloud,
+		Name:      "通用云厂商",
+		Type:      "text",
+		IsDefault: false,
+	}
+
+	for _, attr := range objAttrs {
+		exists := false
+		for _, option := range attr.Option {
+			if option.ID == genericEnum.ID {
+				exists = true
+				break
+			}
+		}
+		if exists {
+			continue
+		}
+
+		attr.Option = append(attr.Option, genericEnum)
+		updateCond := map[string]interface{}{common.BKFieldID: attr.ID}
+		updateData := map[string]interface{}{common.BKOptionField: attr.Option}
+		if err := db.Table(common.BKTableNameObjAttDes).Update(ctx, updateCond, updateData); err != nil {
+			blog.Errorf("update cloud vendor attribute failed, cond: %v, data: %v, err: %v", updateCond, updateData,
+				err)
+			return err
+		}
+	}
+
+	return nil
+}
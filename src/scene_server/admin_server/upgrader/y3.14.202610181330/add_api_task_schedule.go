@@ -0,0 +1,94 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package y3_14_202610181330
+
+import (
+	"context"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/types"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func addAPITaskScheduleTable(ctx context.Context, db dal.RDB) error {
+	exists, err := db.HasTable(ctx, common.BKTableNameAPITaskSchedule)
+	if err != nil {
+		blog.Errorf("check if %s table exists failed, err: %v", common.BKTableNameAPITaskSchedule, err)
+		return err
+	}
+
+	if !exists {
+		if err = db.CreateTable(ctx, common.BKTableNameAPITaskSchedule); err != nil {
+			blog.Errorf("create %s table failed, err: %v", common.BKTableNameAPITaskSchedule, err)
+			return err
+		}
+	}
+
+	indexes := []types.Index{
+		{
+			Name: common.CCLogicUniqueIdxNamePrefix + "scheduleID",
+			Keys: bson.D{
+				{
+					"schedule_id", 1,
+				},
+			},
+			Background: true,
+			Unique:     true,
+		},
+		{
+			Name: common.CCLogicIndexNamePrefix + "status_nextTime",
+			Keys: bson.D{
+				{
+					common.BKStatusField, 1,
+				},
+				{
+					"next_time", 1,
+				},
+			},
+			Background: true,
+		},
+	}
+
+	existIndexArr, err := db.Table(common.BKTableNameAPITaskSchedule).Indexes(ctx)
+	if err != nil {
+		blog.Errorf("get exist index for %s table failed, err: %v", common.BKTableNameAPITaskSchedule, err)
+		return err
+	}
+
+	existIdxMap := make(map[string]struct{})
+	for _, index := range existIndexArr {
+		existIdxMap[index.Name] = struct{}{}
+	}
+
+	for _, index := range indexes {
+		if _, exist := existIdxMap[index.Name]; exist {
+			continue
+		}
+
+		err = db.Table(common.BKTableNameAPITaskSchedule).CreateIndex(ctx, index)
+		if err != nil && !db.IsDuplicatedError(err) {
+			blog.Errorf("create index for %s table failed, index: %+v, err: %v", common.BKTableNameAPITaskSchedule,
+				index, err)
+			return err
+		}
+	}
+
+	return nil
+}
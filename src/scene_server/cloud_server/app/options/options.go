@@ -55,4 +55,6 @@ type Config struct {
 	SecretsEnv     string
 	// sync period of cloud sync task, unit is second
 	SyncPeriodMinutes int
+	// AutoCreateCloudArea whether to create cloud area for the vpc that has no cloud area when syncing
+	AutoCreateCloudArea bool
 }
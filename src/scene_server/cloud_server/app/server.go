@@ -22,7 +22,7 @@ import (
 	"fmt"
 	"time"
 
-	"configcenter/src/ac/iam"
+	"configcenter/src/ac"
 	"configcenter/src/common"
 	"configcenter/src/common/auth"
 	"configcenter/src/common/backbone"
@@ -91,7 +91,7 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 	}
 	process.Service.SetEncryptor(accountCryptor)
 
-	authorizer := iam.NewAuthorizer(engine.CoreAPI)
+	authorizer := ac.NewAuthorizer(engine.CoreAPI)
 	service.SetAuthorizer(authorizer)
 
 	mongoConf := mongoConfig.GetMongoConf()
@@ -172,6 +172,7 @@ func (c *CloudServer) onCloudConfigUpdate(previous, current cc.ProcessConfig) {
 	c.Config.SecretsProject, _ = cc.String("cloudServer.cryptor.secretsProject")
 	c.Config.SecretsEnv, _ = cc.String("cloudServer.cryptor.secretsEnv")
 	c.Config.SyncPeriodMinutes, _ = cc.Int("cloudServer.syncTask.syncPeriodMinutes")
+	c.Config.AutoCreateCloudArea, _ = cc.Bool("cloudServer.syncTask.autoCreateCloudArea")
 }
 
 // getSecretKey get the secret key from bk-secrets service
@@ -214,11 +215,14 @@ func (c *CloudServer) getSecretKey() (string, error) {
 	return secretsClient.GetCloudAccountSecretKey(context.Background(), header)
 }
 
-// setSyncPeriod set the sync period
+// setSyncPeriod set the sync period and whether to create cloud area automatically
 func (c *CloudServer) setSyncPeriod() {
 	cloudsync.SyncPeriodMinutes = c.Config.SyncPeriodMinutes
 	if cloudsync.SyncPeriodMinutes < cloudsync.SyncPeriodMinutesMin {
 		cloudsync.SyncPeriodMinutes = cloudsync.SyncPeriodMinutesMin
 	}
 	blog.Infof("sync period is %d minutes", cloudsync.SyncPeriodMinutes)
+
+	cloudsync.AutoCreateCloudArea = c.Config.AutoCreateCloudArea
+	blog.Infof("auto create cloud area: %t", cloudsync.AutoCreateCloudArea)
 }
@@ -14,10 +14,21 @@ import (
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/util"
+	"configcenter/src/common/zkclient"
 	ccom "configcenter/src/scene_server/cloud_server/common"
 	"configcenter/src/scene_server/cloud_server/logics"
 )
 
+const (
+	// taskLockBasePath 同步任务锁的zk路径
+	taskLockBasePath = "/cc/cloudsync/tasklock"
+	// pendingCloudAreaID 预览时需要新建的云区域的占位id
+	pendingCloudAreaID int64 = -1
+)
+
+// AutoCreateCloudArea vpc没有对应的云区域时是否自动为其创建云区域，默认不创建，同步报错
+var AutoCreateCloudArea bool
+
 // HostSyncor 云主机同步器
 type HostSyncor struct {
 	logics *logics.Logics
@@ -49,14 +60,14 @@ func (h *HostSyncor) syncCloudHost(syncResult *metadata.SyncResult, hostResource
 	}
 
 	// 查询vpc对应的云区域并更新云主机资源信息里的云区域id
-	err := h.addCLoudId(accountConf, hostResource)
+	err := h.addCLoudId(accountConf, hostResource, nil)
 	if err != nil {
 		blog.Errorf("addCLoudId fail, taskID: %d, err: %v, rid: %s", taskID, err, h.readKit.Rid)
 		return err
 	}
 
 	// 根据主机实例id获取mongo中的主机信息,并获取有差异的主机
-	diffHosts, err := h.getDiffHosts(hostResource)
+	diffHosts, _, err := h.getDiffHosts(hostResource)
 	if err != nil {
 		blog.Errorf("getDiffHosts fail, taskID: %d, err: %v, rid: %s", taskID, err, h.readKit.Rid)
 		return err
@@ -111,7 +122,7 @@ func (h *HostSyncor) syncCloudHost(syncResult *metadata.SyncResult, hostResource
 	return nil
 }
 
-// Sync 同步云主机
+// Sync 同步云主机，同步事务失败时会将任务状态置为失败，并返回事务的错误
 func (h *HostSyncor) Sync(task *metadata.CloudSyncTask) error {
 	defer func() {
 		if err := recover(); err != nil {
@@ -119,13 +130,19 @@ func (h *HostSyncor) Sync(task *metadata.CloudSyncTask) error {
 		}
 	}()
 
-	// 每次同步生成新的kit
-	h.readKit = ccom.NewKit()
-	// 将云同步任务的开发商ID作为写kit的开发商ID
-	h.writeKit = ccom.NewWriteKit(task.OwnerID)
-	// 让读写kit的requestID保持一致，以追踪同一个task的日志
-	httpheader.SetRid(h.writeKit.Header, httpheader.GetRid(h.readKit.Header))
+	h.initKit(task)
 
+	unlock, err := h.lockTask(task.TaskID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return h.sync(task)
+}
+
+// sync 同步云主机，调用方需要先获取任务的锁
+func (h *HostSyncor) sync(task *metadata.CloudSyncTask) error {
 	startTime := time.Now()
 	blog.Infof("start sync taskid:%d, rid:%s", task.TaskID, h.readKit.Rid)
 
@@ -202,7 +219,37 @@ func (h *HostSyncor) Sync(task *metadata.CloudSyncTask) error {
 	blog.Infof("sync loop for taskID: %d is over, costTime: %ds, rid: %s", task.TaskID,
 		time.Since(startTime)/time.Second, h.readKit.Rid)
 
-	return nil
+	return txnErr
+}
+
+// initKit 每次同步生成新的kit
+func (h *HostSyncor) initKit(task *metadata.CloudSyncTask) {
+	h.readKit = ccom.NewKit()
+	// 将云同步任务的开发商ID作为写kit的开发商ID
+	h.writeKit = ccom.NewWriteKit(task.OwnerID)
+	// 让读写kit的requestID保持一致，以追踪同一个task的日志
+	httpheader.SetRid(h.writeKit.Header, httpheader.GetRid(h.readKit.Header))
+}
+
+// lockTask 获取同步任务的锁，保证同一个任务在所有进程中同一时间只有一个同步在进行，如定时同步与确认同步，
+// 锁为zk的临时节点，进程退出时自动释放
+func (h *HostSyncor) lockTask(taskID int64) (func(), error) {
+	path := fmt.Sprintf("%s/%d", taskLockBasePath, taskID)
+	zkClient := h.logics.ServiceManageClient().Client()
+	if err := zkClient.CreateEphemeral(path, []byte(h.readKit.Rid)); err != nil {
+		if err == zkclient.ErrNodeExists {
+			blog.Warnf("task is being synchronized, skip it, taskID: %d, rid: %s", taskID, h.readKit.Rid)
+			return nil, h.readKit.CCError.CCError(common.CCErrCloudSyncTaskIsSyncing)
+		}
+		blog.Errorf("lock sync task failed, taskID: %d, err: %v, rid: %s", taskID, err, h.readKit.Rid)
+		return nil, err
+	}
+
+	return func() {
+		if err := zkClient.Del(path, -1); err != nil {
+			blog.Errorf("unlock sync task failed, taskID: %d, err: %v, rid: %s", taskID, err, h.readKit.Rid)
+		}
+	}, nil
 }
 
 // getCloudHostResource 根据任务详情和账号信息获取要同步的云主机资源
@@ -234,18 +281,14 @@ func (h *HostSyncor) syncDestroyedVpcs(hostResource *metadata.CloudHostResource,
 		common.BKDBIN: cloudIDs,
 	}}
 
-	query := &metadata.QueryCondition{
-		Condition: condition,
-	}
-	res, err := h.logics.CoreAPI.CoreService().Instance().ReadInstance(h.readKit.Ctx, h.readKit.Header,
-		common.BKInnerObjIDHost, query)
+	hosts, err := h.readHosts(condition)
 	if nil != err {
-		blog.Errorf("syncDestroyedVpcs ReadInstance failed, error: %v query:%#v, rid:%s", err, query, h.readKit.Rid)
+		blog.Errorf("syncDestroyedVpcs readHosts failed, error: %v cond:%#v, rid:%s", err, condition, h.readKit.Rid)
 		return err
 	}
 
 	hostIDs := make([]int64, 0)
-	for _, host := range res.Info {
+	for _, host := range hosts {
 		hostID, _ := host.Int64(common.BKHostIDField)
 		hostIDs = append(hostIDs, hostID)
 	}
@@ -259,6 +302,7 @@ func (h *HostSyncor) syncDestroyedVpcs(hostResource *metadata.CloudHostResource,
 	syncResult.Detail.Update.IPs = append(syncResult.Detail.Update.IPs, sResult.SuccessInfo.IPs...)
 	syncResult.SuccessInfo.Count += sResult.SuccessInfo.Count
 	syncResult.SuccessInfo.IPs = append(syncResult.SuccessInfo.IPs, sResult.SuccessInfo.IPs...)
+	syncResult.HostChanges = append(syncResult.HostChanges, sResult.HostChanges...)
 
 	// 更新被销毁vpc对应的云区域状态为异常
 	if err := h.updateDestroyedCloudArea(cloudIDs); err != nil {
@@ -281,9 +325,10 @@ func (h *HostSyncor) syncDestroyedVpcs(hostResource *metadata.CloudHostResource,
 	return nil
 }
 
-// addCLoudId 查询vpc对应的云区域
-func (h *HostSyncor) addCLoudId(accountConf *metadata.CloudAccountConf,
-	hostResource *metadata.CloudHostResource) error {
+// addCLoudId 查询vpc对应的云区域，vpc没有对应的云区域时，开启了自动创建云区域则为其创建云区域，否则报错
+// preview不为空时为预览模式，只记录需要创建的云区域，不会实际创建，其下主机的云区域id为pendingCloudAreaID
+func (h *HostSyncor) addCLoudId(accountConf *metadata.CloudAccountConf, hostResource *metadata.CloudHostResource,
+	preview *metadata.CloudSyncPreview) error {
 	for _, hostRes := range hostResource.HostResource {
 		cloudID, err := h.getCloudId(hostRes.Vpc.VpcID)
 		if err != nil {
@@ -291,18 +336,45 @@ func (h *HostSyncor) addCLoudId(accountConf *metadata.CloudAccountConf,
 			return err
 		}
 		if cloudID == 0 {
-			blog.Errorf("addCLoudId getCloudId err:%s, vpcID:%s, rid:%s",
-				"the correspond cloudID for the vpc can't be found", hostRes.Vpc.VpcID, h.readKit.Rid)
-			return fmt.Errorf("the correspond cloudID for the vpc %s can't be found,vpc name: %s", hostRes.Vpc.VpcID,
-				hostRes.Vpc.VpcName)
+			if !AutoCreateCloudArea {
+				blog.Errorf("addCLoudId getCloudId err:%s, vpcID:%s, rid:%s",
+					"the correspond cloudID for the vpc can't be found", hostRes.Vpc.VpcID, h.readKit.Rid)
+				return fmt.Errorf("the correspond cloudID for the vpc %s can't be found,vpc name: %s",
+					hostRes.Vpc.VpcID, hostRes.Vpc.VpcName)
+			}
+
+			blog.Infof("the correspond cloudID for the vpc %s can't be found, create it, rid: %s", hostRes.Vpc.VpcID,
+				h.readKit.Rid)
+			if preview != nil {
+				preview.CloudAreas = append(preview.CloudAreas, metadata.CloudArea{
+					CloudName:   getCloudAreaName(accountConf.AccountID, hostRes.Vpc.VpcID),
+					Status:      "1",
+					CloudVendor: accountConf.VendorName,
+					VpcID:       hostRes.Vpc.VpcID,
+					VpcName:     hostRes.Vpc.VpcName,
+					Region:      hostRes.Vpc.Region,
+					AccountID:   accountConf.AccountID,
+				})
+				// 云区域还未创建，其下的主机都是新增的主机，不需要与本地主机做对比
+				hostRes.CloudID = pendingCloudAreaID
+				continue
+			}
+
+			cloudID, err = h.createCloudArea(hostRes.Vpc, accountConf)
+			if err != nil {
+				blog.Errorf("addCLoudId createCloudArea err: %v, vpcID: %s, rid: %s", err, hostRes.Vpc.VpcID,
+					h.readKit.Rid)
+				return err
+			}
 		}
 		hostRes.CloudID = cloudID
 	}
 	return nil
 }
 
-// getDiffHosts 根据主机实例id获取mongo中的主机信息,并获取有差异的主机
-func (h *HostSyncor) getDiffHosts(hostResource *metadata.CloudHostResource) (map[string][]*metadata.CloudHost, error) {
+// getDiffHosts 根据主机实例id获取mongo中的主机信息,并获取有差异的主机，同时返回以实例id为key的本地主机信息
+func (h *HostSyncor) getDiffHosts(hostResource *metadata.CloudHostResource) (map[string][]*metadata.CloudHost,
+	map[string]*metadata.CloudHost, error) {
 	// 云端的主机
 	remoteHostsMap := make(map[string]*metadata.CloudHost)
 	for _, hostRes := range hostResource.HostResource {
@@ -318,6 +390,10 @@ func (h *HostSyncor) getDiffHosts(hostResource *metadata.CloudHostResource) (map
 
 	cloudIDs := make([]int64, 0)
 	for _, vpcInfo := range hostResource.HostResource {
+		// 预览时还未创建的云区域下没有本地主机
+		if vpcInfo.CloudID == pendingCloudAreaID {
+			continue
+		}
 		cloudIDs = append(cloudIDs, vpcInfo.CloudID)
 	}
 	blog.V(4).Infof("taskid:%d, host cloudIDs:%#v, rid:%s", hostResource.TaskID, cloudIDs, h.readKit.Rid)
@@ -325,7 +401,7 @@ func (h *HostSyncor) getDiffHosts(hostResource *metadata.CloudHostResource) (map
 	// 本地已有的云主机
 	localHosts, err := h.getLocalHosts(cloudIDs)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	blog.V(4).Infof("taskid:%d, len(localHosts):%d, rid:%s", hostResource.TaskID, len(localHosts), h.readKit.Rid)
 	localIdHostsMap := make(map[string]*metadata.CloudHost)
@@ -346,6 +422,7 @@ func (h *HostSyncor) getDiffHosts(hostResource *metadata.CloudHostResource) (map
 			}
 			if h.InstanceState != lh.InstanceState || h.PublicIp != lh.PublicIp ||
 				h.PrivateIp != lh.PrivateIp || h.CloudID != lh.CloudID {
+				h.HostID = lh.HostID
 				diffHosts["update"] = append(diffHosts["update"], h)
 			}
 		} else {
@@ -364,7 +441,7 @@ func (h *HostSyncor) getDiffHosts(hostResource *metadata.CloudHostResource) (map
 		}
 	}
 
-	return diffHosts, nil
+	return diffHosts, localIdHostsMap, nil
 }
 
 // syncDiffHosts 同步有差异的主机数据
@@ -407,6 +484,7 @@ func (h *HostSyncor) syncDiffHosts(diffhosts map[string][]*metadata.CloudHost, s
 		}
 		syncResult.SuccessInfo.Count += result.SuccessInfo.Count
 		syncResult.SuccessInfo.IPs = append(syncResult.SuccessInfo.IPs, result.SuccessInfo.IPs...)
+		syncResult.HostChanges = append(syncResult.HostChanges, result.HostChanges...)
 		syncResult.FailInfo.Count += result.FailInfo.Count
 		for ip, errinfo := range result.FailInfo.IPError {
 			syncResult.FailInfo.IPError[ip] = errinfo
@@ -423,6 +501,7 @@ func (h *HostSyncor) addSyncHistory(syncResult *metadata.SyncResult, taskid int6
 		SyncStatus:        syncResult.SyncStatus,
 		StatusDescription: syncResult.StatusDescription,
 		Detail:            syncResult.Detail,
+		HostChanges:       syncResult.HostChanges,
 	}
 	result, err := h.logics.CreateSyncHistory(h.writeKit, &syncHistory)
 	if err != nil {
@@ -476,7 +555,7 @@ func (h *HostSyncor) getCloudId(vpcID string) (int64, error) {
 // createCloudArea 创建vpc对应的云区域
 func (h *HostSyncor) createCloudArea(vpc *metadata.VpcSyncInfo, accountConf *metadata.CloudAccountConf) (int64, error) {
 	cloudArea := map[string]interface{}{
-		common.BKCloudNameField:  fmt.Sprintf("%d_%s", accountConf.AccountID, vpc.VpcID),
+		common.BKCloudNameField:  getCloudAreaName(accountConf.AccountID, vpc.VpcID),
 		common.BKCloudVendor:     accountConf.VendorName,
 		common.BKVpcID:           vpc.VpcID,
 		common.BKVpcName:         vpc.VpcName,
@@ -519,6 +598,11 @@ func (h *HostSyncor) createCloudArea(vpc *metadata.VpcSyncInfo, accountConf *met
 	return cloudID, nil
 }
 
+// getCloudAreaName 获取为vpc创建的云区域的名称
+func getCloudAreaName(accountID int64, vpcID string) string {
+	return fmt.Sprintf("%d_%s", accountID, vpcID)
+}
+
 // getHostIDAndIP get hostID and innerIP by hostInfo.
 func getHostIDAndIP(hostInfo map[string]interface{}) (int64, string, error) {
 	var hostID int64
@@ -549,42 +633,72 @@ func (h *HostSyncor) getLocalHosts(cloudIDs []int64) ([]*metadata.CloudHost, err
 		// 必须带有实例id，说明是云主机
 		common.BKCloudInstIDField: mapstr.MapStr{common.BKDBNIN: []interface{}{nil, ""}},
 	}
-	query := &metadata.QueryCondition{
-		Condition: cond,
-	}
-	res, err := h.logics.CoreAPI.CoreService().Instance().ReadInstance(h.readKit.Ctx, h.readKit.Header,
-		common.BKInnerObjIDHost, query)
+	hosts, err := h.readHosts(cond)
 	if nil != err {
-		blog.Errorf("getLocalHosts failed, error: %v query:%#v, rid:%s", err, query, h.readKit.Rid)
+		blog.Errorf("getLocalHosts failed, error: %v cond:%#v, rid:%s", err, cond, h.readKit.Rid)
 		return nil, err
 	}
 
-	if len(res.Info) == 0 {
+	if len(hosts) == 0 {
 		return nil, nil
 	}
 
-	for _, host := range res.Info {
-		instID, _ := host.String(common.BKCloudInstIDField)
-		hostStatus, _ := host.String(common.BKCloudHostStatusField)
-		privateIp, _ := host.String(common.BKHostInnerIPField)
-		publicIp, _ := host.String(common.BKHostOuterIPField)
-		cloudID, _ := host.Int64(common.BKCloudIDField)
-		hostID, _ := host.Int64(common.BKHostIDField)
-		result = append(result, &metadata.CloudHost{
-			Instance: metadata.Instance{
-				InstanceId:    instID,
-				InstanceState: hostStatus,
-				PrivateIp:     privateIp,
-				PublicIp:      publicIp,
-			},
-			CloudID: cloudID,
-			HostID:  hostID,
-		})
+	for _, host := range hosts {
+		result = append(result, convertToCloudHost(host))
 	}
 
 	return result, nil
 }
 
+// readHosts 按主机id分页查询满足条件的所有主机，避免一次读取过多主机
+func (h *HostSyncor) readHosts(cond mapstr.MapStr) ([]mapstr.MapStr, error) {
+	hosts := make([]mapstr.MapStr, 0)
+	query := &metadata.QueryCondition{
+		Condition: cond,
+		Page: metadata.BasePage{
+			Limit: common.BKMaxPageSize,
+			Sort:  common.BKHostIDField,
+		},
+	}
+
+	for {
+		res, err := h.logics.CoreAPI.CoreService().Instance().ReadInstance(h.readKit.Ctx, h.readKit.Header,
+			common.BKInnerObjIDHost, query)
+		if err != nil {
+			blog.Errorf("read hosts failed, err: %v, query: %#v, rid: %s", err, query, h.readKit.Rid)
+			return nil, err
+		}
+
+		hosts = append(hosts, res.Info...)
+		if len(res.Info) < common.BKMaxPageSize {
+			break
+		}
+		query.Page.Start += common.BKMaxPageSize
+	}
+
+	return hosts, nil
+}
+
+// convertToCloudHost 将主机详情转换为云主机信息
+func convertToCloudHost(host mapstr.MapStr) *metadata.CloudHost {
+	instID, _ := host.String(common.BKCloudInstIDField)
+	hostStatus, _ := host.String(common.BKCloudHostStatusField)
+	privateIp, _ := host.String(common.BKHostInnerIPField)
+	publicIp, _ := host.String(common.BKHostOuterIPField)
+	cloudID, _ := host.Int64(common.BKCloudIDField)
+	hostID, _ := host.Int64(common.BKHostIDField)
+	return &metadata.CloudHost{
+		Instance: metadata.Instance{
+			InstanceId:    instID,
+			InstanceState: hostStatus,
+			PrivateIp:     privateIp,
+			PublicIp:      publicIp,
+		},
+		CloudID: cloudID,
+		HostID:  hostID,
+	}
+}
+
 // addHosts 添加云主机到本地数据库和主机资源池目录对应关系
 func (h *HostSyncor) addHosts(hosts []*metadata.CloudHost) (*metadata.SyncResult, error) {
 	syncResult := new(metadata.SyncResult)
@@ -613,6 +727,7 @@ func (h *HostSyncor) addHosts(hosts []*metadata.CloudHost) (*metadata.SyncResult
 	if err != nil {
 		blog.Errorf("addHosts getHostDetailByInstIDs err:%s, instIDs:%#v, rid:%s", err.Error(), instIDs,
 			h.readKit.Rid)
+		return nil, err
 	}
 
 	// generate audit log.
@@ -630,9 +745,25 @@ func (h *HostSyncor) addHosts(hosts []*metadata.CloudHost) (*metadata.SyncResult
 		}
 	}
 
+	for _, data := range curData {
+		syncResult.HostChanges = append(syncResult.HostChanges, newSyncHostAddChange(data))
+	}
 	return syncResult, nil
 }
 
+// newSyncHostAddChange 根据新增主机的数据生成主机的变更记录，新增的主机在同步前没有数据
+func newSyncHostAddChange(data mapstr.MapStr) metadata.SyncHostChange {
+	after := make(mapstr.MapStr)
+	for _, field := range []string{common.BKCloudIDField, common.BKCloudInstIDField, common.BKHostInnerIPField,
+		common.BKHostOuterIPField, common.BKCloudHostStatusField, common.BKCloudVendor} {
+		after[field] = data[field]
+	}
+
+	change := newSyncHostChange(metadata.SyncHostChangeAdd, data, after)
+	change.Before = make(mapstr.MapStr)
+	return change
+}
+
 // addHost 添加云主机
 func (h *HostSyncor) addHost(cHost *metadata.CloudHost) (string, error) {
 	host := mapstr.MapStr{
@@ -656,29 +787,8 @@ func (h *HostSyncor) addHost(cHost *metadata.CloudHost) (string, error) {
 
 	hostID := int64(result.Created.ID)
 
-	// 获取资源池业务id
-	condition := mapstr.MapStr{
-		common.BKDefaultField: common.DefaultAppFlag,
-	}
-	cond := &metadata.QueryCondition{
-		Fields:    []string{common.BKAppIDField},
-		Condition: condition,
-	}
-	res, err := h.logics.CoreAPI.CoreService().Instance().ReadInstance(h.readKit.Ctx, h.readKit.Header,
-		common.BKInnerObjIDApp, cond)
-	if err != nil {
-		blog.Errorf("addHost fail,err:%s, cond:%+v, rid:%s", err.Error(), *cond, h.readKit.Rid)
-		return "", err
-	}
-
-	if len(res.Info) == 0 {
-		blog.Errorf("addHost fail,err:%s, cond:%+v, rid:%s", "no default biz is found", *cond, h.readKit.Rid)
-		return "", fmt.Errorf("%s", "no default biz is found")
-	}
-
-	appID, err := res.Info[0].Int64(common.BKAppIDField)
+	appID, err := h.getDefaultBizID()
 	if err != nil {
-		blog.Errorf("addHost fail,err:%s, cond:%+v, rid:%s", err.Error(), *cond, h.readKit.Rid)
 		return "", err
 	}
 
@@ -698,6 +808,36 @@ func (h *HostSyncor) addHost(cHost *metadata.CloudHost) (string, error) {
 	return cHost.PrivateIp, nil
 }
 
+// getDefaultBizID 获取资源池业务id
+func (h *HostSyncor) getDefaultBizID() (int64, error) {
+	condition := mapstr.MapStr{
+		common.BKDefaultField: common.DefaultAppFlag,
+	}
+	cond := &metadata.QueryCondition{
+		Fields:    []string{common.BKAppIDField},
+		Condition: condition,
+	}
+	res, err := h.logics.CoreAPI.CoreService().Instance().ReadInstance(h.readKit.Ctx, h.readKit.Header,
+		common.BKInnerObjIDApp, cond)
+	if err != nil {
+		blog.Errorf("get default biz fail,err:%s, cond:%+v, rid:%s", err.Error(), *cond, h.readKit.Rid)
+		return 0, err
+	}
+
+	if len(res.Info) == 0 {
+		blog.Errorf("get default biz fail,err:%s, cond:%+v, rid:%s", "no default biz is found", *cond,
+			h.readKit.Rid)
+		return 0, fmt.Errorf("%s", "no default biz is found")
+	}
+
+	appID, err := res.Info[0].Int64(common.BKAppIDField)
+	if err != nil {
+		blog.Errorf("get default biz fail,err:%s, cond:%+v, rid:%s", err.Error(), *cond, h.readKit.Rid)
+		return 0, err
+	}
+	return appID, nil
+}
+
 // updateHosts 更新云主机到本地数据库
 func (h *HostSyncor) updateHosts(hosts []*metadata.CloudHost) (*metadata.SyncResult, error) {
 	syncResult := new(metadata.SyncResult)
@@ -755,6 +895,8 @@ func (h *HostSyncor) updateHosts(hosts []*metadata.CloudHost) (*metadata.SyncRes
 		} else {
 			syncResult.SuccessInfo.Count++
 			syncResult.SuccessInfo.IPs = append(syncResult.SuccessInfo.IPs, host.PrivateIp)
+			syncResult.HostChanges = append(syncResult.HostChanges, newSyncHostChange(metadata.SyncHostChangeUpdate,
+				preDataMap[host.InstanceId], updateInfo))
 		}
 
 		// add audit log.
@@ -834,8 +976,8 @@ func (h *HostSyncor) deleteDestroyedHosts(hostIDs []int64) (*metadata.SyncResult
 	}
 
 	// to change state of cloud host.
-	err = h.logics.CoreAPI.CoreService().Cloud().DeleteDestroyedHostRelated(h.writeKit.Ctx, h.writeKit.Header,
-		&metadata.DeleteDestroyedHostRelatedOption{HostIDs: hostIDs})
+	related, err := h.logics.CoreAPI.CoreService().Cloud().DeleteDestroyedHostRelated(h.writeKit.Ctx,
+		h.writeKit.Header, &metadata.DeleteDestroyedHostRelatedOption{HostIDs: hostIDs})
 	if err != nil {
 		blog.Errorf("deleteDestroyedHosts failed, err:%s, hostIDs:%#v, rid:%s", err.Error(), hostIDs, h.readKit.Rid)
 		return nil, err
@@ -851,9 +993,38 @@ func (h *HostSyncor) deleteDestroyedHosts(hostIDs []int64) (*metadata.SyncResult
 
 	result.SuccessInfo.Count = int64(len(hostIDs))
 	result.SuccessInfo.IPs = innerIPs
+	// 记录主机被删除的关联数据，以便回滚时恢复
+	hostRelated := related.SplitByHost()
+	for _, data := range preData {
+		change := newSyncHostChange(metadata.SyncHostChangeDestroy, data, updateHostData)
+		change.Related = hostRelated[change.HostID]
+		if change.Related == nil {
+			change.Related = new(metadata.DestroyedHostRelated)
+		}
+		result.HostChanges = append(result.HostChanges, change)
+	}
 	return result, nil
 }
 
+// newSyncHostChange 根据主机同步前的数据和同步更新的字段生成主机的变更记录
+func newSyncHostChange(operation string, preData, updateData mapstr.MapStr) metadata.SyncHostChange {
+	hostID, _ := preData.Int64(common.BKHostIDField)
+	instID, _ := preData.String(common.BKCloudInstIDField)
+
+	before := make(mapstr.MapStr)
+	for field := range updateData {
+		before[field] = preData[field]
+	}
+
+	return metadata.SyncHostChange{
+		HostID:     hostID,
+		InstanceID: instID,
+		Operation:  operation,
+		Before:     before,
+		After:      updateData.Clone(),
+	}
+}
+
 // updateDestroyedCloudArea 更新被销毁vpc对应的云区域状态为异常
 func (h *HostSyncor) updateDestroyedCloudArea(cloudIDs []int64) error {
 	input := &metadata.UpdateOption{
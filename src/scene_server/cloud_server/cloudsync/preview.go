@@ -0,0 +1,175 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package cloudsync
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+// Preview 以预览模式执行同步任务，返回同步任务会新增、更新、销毁的主机以及需要新建的云区域，不会对CMDB做任何修改
+func (h *HostSyncor) Preview(task *metadata.CloudSyncTask) (*metadata.CloudSyncPreview, error) {
+	h.initKit(task)
+	return h.preview(task)
+}
+
+func (h *HostSyncor) preview(task *metadata.CloudSyncTask) (*metadata.CloudSyncPreview, error) {
+	accountConf, err := h.logics.GetCloudAccountConf(h.readKit, task.AccountID)
+	if err != nil {
+		blog.Errorf("get cloud account conf failed, taskID: %d, err: %v, rid: %s", task.TaskID, err, h.readKit.Rid)
+		return nil, err
+	}
+
+	hostResource, err := h.getCloudHostResource(task, accountConf)
+	if err != nil {
+		blog.Errorf("get cloud host resource failed, taskID: %d, err: %v, rid: %s", task.TaskID, err, h.readKit.Rid)
+		return nil, err
+	}
+
+	preview := &metadata.CloudSyncPreview{
+		TaskID:        task.TaskID,
+		Add:           make([]metadata.CloudHost, 0),
+		Update:        make([]metadata.CloudHostUpdatePreview, 0),
+		Destroy:       make([]metadata.CloudHost, 0),
+		DestroyedVpcs: make([]metadata.VpcSyncInfo, 0),
+		CloudAreas:    make([]metadata.CloudArea, 0),
+	}
+
+	// 被销毁的vpc下的主机都会被置为已销毁状态
+	if err = h.previewDestroyedVpcs(hostResource, preview); err != nil {
+		blog.Errorf("preview destroyed vpcs failed, taskID: %d, err: %v, rid: %s", task.TaskID, err, h.readKit.Rid)
+		return nil, err
+	}
+
+	if err = h.addCLoudId(accountConf, hostResource, preview); err != nil {
+		blog.Errorf("preview cloud area failed, taskID: %d, err: %v, rid: %s", task.TaskID, err, h.readKit.Rid)
+		return nil, err
+	}
+
+	diffHosts, localHosts, err := h.getDiffHosts(hostResource)
+	if err != nil {
+		blog.Errorf("get diff hosts failed, taskID: %d, err: %v, rid: %s", task.TaskID, err, h.readKit.Rid)
+		return nil, err
+	}
+
+	for _, host := range diffHosts["add"] {
+		preview.Add = append(preview.Add, *host)
+	}
+	for _, host := range diffHosts["update"] {
+		before := localHosts[host.InstanceId]
+		preview.Update = append(preview.Update, metadata.CloudHostUpdatePreview{Before: *before, After: *host})
+	}
+	for _, host := range diffHosts["delete"] {
+		preview.Destroy = append(preview.Destroy, *host)
+	}
+
+	preview.Digest, err = genPreviewDigest(preview)
+	if err != nil {
+		blog.Errorf("generate preview digest failed, taskID: %d, err: %v, rid: %s", task.TaskID, err, h.readKit.Rid)
+		return nil, err
+	}
+
+	return preview, nil
+}
+
+// SyncWithConfirm 确认并执行同步任务，只有当前的同步差异与预览时的一致才会执行同步
+// 比较差异与同步期间持有任务的锁，避免与定时同步同时进行
+func (h *HostSyncor) SyncWithConfirm(task *metadata.CloudSyncTask, digest string) error {
+	h.initKit(task)
+
+	unlock, err := h.lockTask(task.TaskID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	preview, err := h.preview(task)
+	if err != nil {
+		return err
+	}
+
+	if preview.Digest != digest {
+		blog.Errorf("cloud sync diff has changed, taskID: %d, digest: %s, current digest: %s, rid: %s", task.TaskID,
+			digest, preview.Digest, h.readKit.Rid)
+		return h.readKit.CCError.CCError(common.CCErrCloudSyncPreviewChanged)
+	}
+
+	return h.sync(task)
+}
+
+// previewDestroyedVpcs 获取被销毁的vpc下需要置为已销毁状态的主机
+func (h *HostSyncor) previewDestroyedVpcs(hostResource *metadata.CloudHostResource,
+	preview *metadata.CloudSyncPreview) error {
+
+	if len(hostResource.DestroyedVpcs) == 0 {
+		return nil
+	}
+
+	cloudIDs := make([]int64, 0)
+	for _, vpcInfo := range hostResource.DestroyedVpcs {
+		cloudIDs = append(cloudIDs, vpcInfo.CloudID)
+		preview.DestroyedVpcs = append(preview.DestroyedVpcs, *vpcInfo)
+	}
+
+	cond := mapstr.MapStr{common.BKCloudIDField: mapstr.MapStr{common.BKDBIN: cloudIDs}}
+	hosts, err := h.readHosts(cond)
+	if err != nil {
+		blog.Errorf("read destroyed vpc hosts failed, err: %v, cond: %#v, rid: %s", err, cond, h.readKit.Rid)
+		return err
+	}
+
+	for _, host := range hosts {
+		preview.Destroy = append(preview.Destroy, *convertToCloudHost(host))
+	}
+	return nil
+}
+
+// genPreviewDigest 生成预览结果的摘要，各列表先排序以保证相同的差异得到相同的摘要
+func genPreviewDigest(preview *metadata.CloudSyncPreview) (string, error) {
+	sort.Slice(preview.Add, func(i, j int) bool {
+		return preview.Add[i].InstanceId < preview.Add[j].InstanceId
+	})
+	sort.Slice(preview.Update, func(i, j int) bool {
+		return preview.Update[i].After.InstanceId < preview.Update[j].After.InstanceId
+	})
+	sort.Slice(preview.Destroy, func(i, j int) bool {
+		return preview.Destroy[i].HostID < preview.Destroy[j].HostID
+	})
+	sort.Slice(preview.DestroyedVpcs, func(i, j int) bool {
+		return preview.DestroyedVpcs[i].VpcID < preview.DestroyedVpcs[j].VpcID
+	})
+	sort.Slice(preview.CloudAreas, func(i, j int) bool {
+		return preview.CloudAreas[i].VpcID < preview.CloudAreas[j].VpcID
+	})
+
+	content := *preview
+	content.Digest = ""
+	js, err := json.Marshal(content)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(js)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,301 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package cloudsync
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/auditlog"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+const (
+	// rollbackOperationField 同步任务回滚审计中记录回滚的主机变更类型的字段
+	rollbackOperationField = "rollback_operation"
+	// rollbackDataField 同步任务回滚审计中记录主机恢复后数据的字段
+	rollbackDataField = "rollback_data"
+)
+
+// Rollback 将一次同步对主机的修改恢复为同步前的数据，被置为已销毁状态的主机会恢复其ip、状态以及被删除的服务实例、进程和实例关联，
+// 新增的主机如果仍在资源池中则会被删除
+// 同步后又被修改过的主机会跳过，以免覆盖新的数据
+// 回滚使用调用方的kit操作，需要由调用方在事务中执行
+func (h *HostSyncor) Rollback(kit *rest.Kit, task *metadata.CloudSyncTask,
+	history *metadata.SyncHistory) (*metadata.SyncRollbackResult, error) {
+
+	h.readKit = kit
+	h.writeKit = kit
+
+	result := &metadata.SyncRollbackResult{
+		HistoryID:            history.HistoryID,
+		Restored:             make([]int64, 0),
+		Skipped:              make([]int64, 0),
+		RelationsNotRestored: make([]int64, 0),
+	}
+	if len(history.HostChanges) == 0 {
+		return result, nil
+	}
+
+	curDataMap, err := h.getRollbackHosts(history.HostChanges)
+	if err != nil {
+		return nil, err
+	}
+
+	// 被销毁主机删除的关联数据在所有主机回滚后一起恢复
+	related := new(metadata.DestroyedHostRelated)
+	var defaultBizID int64
+
+	for _, change := range history.HostChanges {
+		curData, exists := curDataMap[change.HostID]
+		if !exists || isHostChangedAfterSync(curData, change.After) {
+			blog.Infof("host %d is deleted or changed after sync, skip rollback, historyID: %d, rid: %s",
+				change.HostID, history.HistoryID, kit.Rid)
+			result.Skipped = append(result.Skipped, change.HostID)
+			continue
+		}
+
+		if change.Operation == metadata.SyncHostChangeAdd {
+			if defaultBizID == 0 {
+				if defaultBizID, err = h.getDefaultBizID(); err != nil {
+					return nil, err
+				}
+			}
+
+			inBiz, err := h.isHostOnlyInBiz(change.HostID, defaultBizID)
+			if err != nil {
+				return nil, err
+			}
+			if !inBiz {
+				blog.Infof("added host %d is transferred after sync, skip rollback, historyID: %d, rid: %s",
+					change.HostID, history.HistoryID, kit.Rid)
+				result.Skipped = append(result.Skipped, change.HostID)
+				continue
+			}
+		}
+
+		if err := h.rollbackHostChange(task, history, change, curData, defaultBizID); err != nil {
+			return nil, err
+		}
+
+		result.Restored = append(result.Restored, change.HostID)
+		if change.Operation != metadata.SyncHostChangeDestroy {
+			continue
+		}
+
+		// 同步历史在记录关联数据之前生成时，无法恢复关联数据
+		if change.Related == nil {
+			result.RelationsNotRestored = append(result.RelationsNotRestored, change.HostID)
+			continue
+		}
+		related.ServiceInstances = append(related.ServiceInstances, change.Related.ServiceInstances...)
+		related.Processes = append(related.Processes, change.Related.Processes...)
+		related.ProcessRelations = append(related.ProcessRelations, change.Related.ProcessRelations...)
+		related.InstAssts = append(related.InstAssts, change.Related.InstAssts...)
+	}
+
+	if related.IsEmpty() {
+		return result, nil
+	}
+
+	restoreRes, err := h.logics.CoreAPI.CoreService().Cloud().RestoreDestroyedHostRelated(kit.Ctx, kit.Header,
+		related)
+	if err != nil {
+		blog.Errorf("restore destroyed host related data failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, err
+	}
+	result.RelationsNotRestored = append(result.RelationsNotRestored, restoreRes.NotRestored...)
+
+	return result, nil
+}
+
+// rollbackHostChange 回滚单个主机的变更并记录审计，新增的主机会被删除，其他主机恢复为同步前的数据
+func (h *HostSyncor) rollbackHostChange(task *metadata.CloudSyncTask, history *metadata.SyncHistory,
+	change metadata.SyncHostChange, curData mapstr.MapStr, defaultBizID int64) error {
+
+	kit := h.writeKit
+	hostAudit := auditlog.NewHostAudit(h.logics.CoreAPI.CoreService())
+	taskAudit := auditlog.NewSyncTaskAuditLog(h.logics.CoreAPI.CoreService())
+	restoreData := normalizeRollbackData(change.Before)
+
+	// generate audit log.
+	hostAuditParam := auditlog.NewGenerateAuditCommonParameter(kit, metadata.AuditUpdate).
+		WithUpdateFields(restoreData)
+	if change.Operation == metadata.SyncHostChangeAdd {
+		hostAuditParam = auditlog.NewGenerateAuditCommonParameter(kit, metadata.AuditDelete)
+	}
+	hostLogs, err := hostAudit.GenerateAuditLog(hostAuditParam, 0, []mapstr.MapStr{curData})
+	if err != nil {
+		blog.Errorf("generate host audit log failed, hostID: %d, err: %v, rid: %s", change.HostID, err, kit.Rid)
+		return err
+	}
+
+	taskAuditParam := auditlog.NewGenerateAuditCommonParameter(kit, metadata.AuditUpdate).
+		WithUpdateFields(mapstr.MapStr{
+			common.BKCloudSyncHistoryID: history.HistoryID,
+			common.BKHostIDField:        change.HostID,
+			rollbackOperationField:      change.Operation,
+			rollbackDataField:           restoreData,
+		})
+	taskLog, err := taskAudit.GenerateAuditLog(taskAuditParam, task.TaskID, task)
+	if err != nil {
+		blog.Errorf("generate sync task audit log failed, taskID: %d, err: %v, rid: %s", task.TaskID, err, kit.Rid)
+		return err
+	}
+
+	// to rollback.
+	if change.Operation == metadata.SyncHostChangeAdd {
+		opt := &metadata.DeleteHostRequest{ApplicationID: defaultBizID, HostIDArr: []int64{change.HostID}}
+		if err := h.logics.CoreAPI.CoreService().Host().DeleteHostFromSystem(kit.Ctx, kit.Header, opt); err != nil {
+			blog.Errorf("delete added host %d failed, err: %v, rid: %s", change.HostID, err, kit.Rid)
+			return err
+		}
+	} else if err := h.updateHost(change.InstanceID, restoreData); err != nil {
+		blog.Errorf("rollback host %d failed, err: %v, rid: %s", change.HostID, err, kit.Rid)
+		return err
+	}
+
+	// save audit log.
+	if err := hostAudit.SaveAuditLog(kit, hostLogs...); err != nil {
+		blog.Errorf("save host audit log failed after rollback, err: %v, rid: %s", err, kit.Rid)
+		return err
+	}
+	if err := taskAudit.SaveAuditLog(kit, *taskLog); err != nil {
+		blog.Errorf("save sync task audit log failed after rollback, err: %v, rid: %s", err, kit.Rid)
+		return err
+	}
+	return nil
+}
+
+// isHostOnlyInBiz 判断主机是否只在指定业务下，新增的主机被转移到其他业务后不能通过回滚删除
+func (h *HostSyncor) isHostOnlyInBiz(hostID, bizID int64) (bool, error) {
+	opt := &metadata.HostModuleRelationRequest{
+		HostIDArr: []int64{hostID},
+		Page:      metadata.BasePage{Limit: common.BKNoLimit},
+		Fields:    []string{common.BKAppIDField},
+	}
+	relations, err := h.logics.CoreAPI.CoreService().Host().GetHostModuleRelation(h.readKit.Ctx, h.readKit.Header,
+		opt)
+	if err != nil {
+		blog.Errorf("get host module relation failed, hostID: %d, err: %v, rid: %s", hostID, err, h.readKit.Rid)
+		return false, err
+	}
+
+	for _, relation := range relations.Info {
+		if relation.AppID != bizID {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// getRollbackHosts 获取需要回滚的主机的当前数据，已被删除的主机不会返回
+func (h *HostSyncor) getRollbackHosts(changes []metadata.SyncHostChange) (map[int64]mapstr.MapStr, error) {
+	hostIDs := make([]int64, 0, len(changes))
+	for _, change := range changes {
+		hostIDs = append(hostIDs, change.HostID)
+	}
+
+	query := &metadata.QueryCondition{
+		Condition: mapstr.MapStr{common.BKHostIDField: mapstr.MapStr{common.BKDBIN: hostIDs}},
+		Page:      metadata.BasePage{Limit: common.BKNoLimit},
+	}
+	res, err := h.logics.CoreAPI.CoreService().Instance().ReadInstance(h.readKit.Ctx, h.readKit.Header,
+		common.BKInnerObjIDHost, query)
+	if err != nil {
+		blog.Errorf("read rollback hosts failed, err: %v, hostIDs: %v, rid: %s", err, hostIDs, h.readKit.Rid)
+		return nil, err
+	}
+
+	hostMap := make(map[int64]mapstr.MapStr)
+	for _, host := range res.Info {
+		hostID, err := host.Int64(common.BKHostIDField)
+		if err != nil {
+			blog.Errorf("parse host id failed, err: %v, host: %#v, rid: %s", err, host, h.readKit.Rid)
+			return nil, err
+		}
+		hostMap[hostID] = host
+	}
+	return hostMap, nil
+}
+
+// isHostChangedAfterSync 判断主机被同步修改的字段在同步后是否又被修改过
+func isHostChangedAfterSync(curData, after mapstr.MapStr) bool {
+	for field, value := range after {
+		if formatHostFieldValue(curData[field]) != formatHostFieldValue(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatHostFieldValue 统一主机字段值的格式以便比较，ip等数组字段在读取主机时会被转换为逗号分隔的字符串，
+// 而数字在经过json转换后可能为float64或json.Number类型
+func formatHostFieldValue(value interface{}) string {
+	switch val := value.(type) {
+	case nil:
+		return ""
+	case []string:
+		return strings.Join(val, ",")
+	case []interface{}:
+		items := make([]string, len(val))
+		for i, item := range val {
+			items[i] = formatHostFieldValue(item)
+		}
+		return strings.Join(items, ",")
+	case float64:
+		if val == math.Trunc(val) {
+			return fmt.Sprintf("%d", int64(val))
+		}
+		return fmt.Sprint(val)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// normalizeRollbackData 将同步历史中记录的主机数据转换为可以直接更新的数据，数字在经过json转换后需要转回整数
+func normalizeRollbackData(data mapstr.MapStr) mapstr.MapStr {
+	result := make(mapstr.MapStr, len(data))
+	for field, value := range data {
+		switch val := value.(type) {
+		case nil:
+			// 同步前没有值的字段恢复为空值
+			result[field] = ""
+		case json.Number:
+			if intVal, err := val.Int64(); err == nil {
+				result[field] = intVal
+				continue
+			}
+			result[field] = val
+		case float64:
+			if val == math.Trunc(val) {
+				result[field] = int64(val)
+				continue
+			}
+			result[field] = val
+		default:
+			result[field] = val
+		}
+	}
+	return result
+}
@@ -0,0 +1,117 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package cloudsync
+
+import (
+	"encoding/json"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+func TestNewSyncHostChange(t *testing.T) {
+	preData := mapstr.MapStr{
+		common.BKHostIDField:          int64(1),
+		common.BKCloudInstIDField:     "ins-1",
+		common.BKHostInnerIPField:     "10.0.0.1",
+		common.BKCloudHostStatusField: common.BKCloudHostStatusRunning,
+		common.BKHostNameField:        "host-1",
+	}
+	updateData := mapstr.MapStr{
+		common.BKHostInnerIPField:     []string{},
+		common.BKCloudHostStatusField: common.BKCloudHostStatusDestroyed,
+	}
+
+	change := newSyncHostChange(metadata.SyncHostChangeDestroy, preData, updateData)
+	if change.HostID != 1 || change.InstanceID != "ins-1" || change.Operation != metadata.SyncHostChangeDestroy {
+		t.Fatalf("host change %#v is invalid", change)
+	}
+	if len(change.Before) != 2 || change.Before[common.BKHostInnerIPField] != "10.0.0.1" {
+		t.Fatalf("host change before data %#v is invalid", change.Before)
+	}
+
+	// 修改同步数据不应影响已生成的变更记录
+	updateData[common.BKCloudHostStatusField] = common.BKCloudHostStatusRunning
+	if change.After[common.BKCloudHostStatusField] != common.BKCloudHostStatusDestroyed {
+		t.Fatalf("host change after data %#v is changed", change.After)
+	}
+}
+
+func TestIsHostChangedAfterSync(t *testing.T) {
+	// 经过json转换后的同步数据
+	after := mapstr.MapStr{
+		common.BKCloudIDField:         json.Number("2"),
+		common.BKHostInnerIPField:     []interface{}{},
+		common.BKCloudHostStatusField: common.BKCloudHostStatusDestroyed,
+	}
+
+	curData := mapstr.MapStr{
+		common.BKCloudIDField:         float64(2),
+		common.BKHostInnerIPField:     "",
+		common.BKCloudHostStatusField: common.BKCloudHostStatusDestroyed,
+	}
+	if isHostChangedAfterSync(curData, after) {
+		t.Fatal("host is not changed after sync")
+	}
+
+	curData[common.BKHostInnerIPField] = "10.0.0.2"
+	if !isHostChangedAfterSync(curData, after) {
+		t.Fatal("host inner ip is changed after sync")
+	}
+}
+
+func TestNormalizeRollbackData(t *testing.T) {
+	data := normalizeRollbackData(mapstr.MapStr{
+		common.BKCloudIDField:     json.Number("3"),
+		common.BKHostOuterIPField: nil,
+		common.BKHostInnerIPField: "10.0.0.1",
+	})
+
+	if data[common.BKCloudIDField] != int64(3) || data[common.BKHostOuterIPField] != "" ||
+		data[common.BKHostInnerIPField] != "10.0.0.1" {
+		t.Fatalf("normalized data %#v is invalid", data)
+	}
+}
+
+func TestNewSyncHostAddChange(t *testing.T) {
+	data := mapstr.MapStr{
+		common.BKHostIDField:          int64(2),
+		common.BKCloudIDField:         int64(3),
+		common.BKCloudInstIDField:     "ins-2",
+		common.BKHostInnerIPField:     "10.0.0.2",
+		common.BKHostOuterIPField:     "",
+		common.BKCloudHostStatusField: common.BKCloudHostStatusRunning,
+		common.BKCloudVendor:          "1",
+		common.BKHostNameField:        "host-2",
+	}
+
+	change := newSyncHostAddChange(data)
+	if change.HostID != 2 || change.InstanceID != "ins-2" || change.Operation != metadata.SyncHostChangeAdd {
+		t.Fatalf("host add change %#v is invalid", change)
+	}
+	if len(change.Before) != 0 {
+		t.Fatalf("host add change before data %#v should be empty", change.Before)
+	}
+	if len(change.After) != 6 || change.After[common.BKHostInnerIPField] != "10.0.0.2" {
+		t.Fatalf("host add change after data %#v is invalid", change.After)
+	}
+	if _, exists := change.After[common.BKHostNameField]; exists {
+		t.Fatalf("host add change after data %#v contains the field not synced", change.After)
+	}
+}
@@ -0,0 +1,252 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+// Package fakecloud 本地模拟的云厂商，实现了通用云厂商客户端的默认接口协议，用于在没有真实云账户的情况下测试云主机同步
+package fakecloud
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Region 模拟的地域
+type Region struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
+
+// Vpc 模拟的vpc
+type Vpc struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Instance 模拟的实例
+type Instance struct {
+	ID        string `json:"id"`
+	PrivateIP string `json:"private_ip"`
+	PublicIP  string `json:"public_ip"`
+	State     string `json:"state"`
+	VpcID     string `json:"vpc_id"`
+}
+
+// Provider 模拟的云厂商，所有资源都保存在内存中，可以随时修改以模拟云资源的变化
+type Provider struct {
+	secretID  string
+	secretKey string
+
+	lock      sync.RWMutex
+	regions   map[string]*Region
+	vpcs      map[string]map[string]*Vpc
+	instances map[string]map[string]*Instance
+}
+
+// NewProvider 创建模拟的云厂商，只接受使用指定密钥的请求
+func NewProvider(secretID, secretKey string) *Provider {
+	return &Provider{
+		secretID:  secretID,
+		secretKey: secretKey,
+		regions:   make(map[string]*Region),
+		vpcs:      make(map[string]map[string]*Vpc),
+		instances: make(map[string]map[string]*Instance),
+	}
+}
+
+// Start 启动模拟云厂商的本地http服务，调用方需要在使用后关闭
+func (p *Provider) Start() *httptest.Server {
+	return httptest.NewServer(p)
+}
+
+// AddRegion 添加或替换地域
+func (p *Provider) AddRegion(region Region) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.regions[region.ID] = &region
+}
+
+// AddVpc 在地域下添加或替换vpc
+func (p *Provider) AddVpc(regionID string, vpc Vpc) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if _, exists := p.vpcs[regionID]; !exists {
+		p.vpcs[regionID] = make(map[string]*Vpc)
+	}
+	p.vpcs[regionID][vpc.ID] = &vpc
+}
+
+// DeleteVpc 删除地域下的vpc及其下的所有实例，用于模拟vpc被销毁
+func (p *Provider) DeleteVpc(regionID, vpcID string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	delete(p.vpcs[regionID], vpcID)
+	for id, inst := range p.instances[regionID] {
+		if inst.VpcID == vpcID {
+			delete(p.instances[regionID], id)
+		}
+	}
+}
+
+// AddInstance 在地域下添加或替换实例
+func (p *Provider) AddInstance(regionID string, inst Instance) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if _, exists := p.instances[regionID]; !exists {
+		p.instances[regionID] = make(map[string]*Instance)
+	}
+	p.instances[regionID][inst.ID] = &inst
+}
+
+// DeleteInstance 删除地域下的实例，用于模拟主机被销毁
+func (p *Provider) DeleteInstance(regionID, instID string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	delete(p.instances[regionID], instID)
+}
+
+// ServeHTTP 提供模拟的云厂商接口，支持的接口有：
+// GET /regions
+// GET /regions/{region}/vpcs?vpc-id=xxx
+// GET /regions/{region}/instances?vpc-id=xxx
+// 所有接口都支持limit和offset分页参数，返回数据格式为{"data":{"count":1,"info":[]}}
+func (p *Provider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	secretID, secretKey, ok := r.BasicAuth()
+	if !ok || secretID != p.secretID || secretKey != p.secretKey {
+		http.Error(w, "auth failure", http.StatusUnauthorized)
+		return
+	}
+
+	paths := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(paths) == 1 && paths[0] == "regions":
+		p.writeList(w, r, p.listRegions())
+	case len(paths) == 3 && paths[0] == "regions" && paths[2] == "vpcs":
+		p.writeList(w, r, p.listVpcs(paths[1], r.URL.Query()["vpc-id"]))
+	case len(paths) == 3 && paths[0] == "regions" && paths[2] == "instances":
+		p.writeList(w, r, p.listInstances(paths[1], r.URL.Query()["vpc-id"]))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (p *Provider) listRegions() []interface{} {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	ids := make([]string, 0, len(p.regions))
+	for id := range p.regions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	list := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		list = append(list, *p.regions[id])
+	}
+	return list
+}
+
+func (p *Provider) listVpcs(regionID string, vpcIDs []string) []interface{} {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	ids := make([]string, 0)
+	for id := range p.vpcs[regionID] {
+		if len(vpcIDs) > 0 && !contains(vpcIDs, id) {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	list := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		list = append(list, *p.vpcs[regionID][id])
+	}
+	return list
+}
+
+func (p *Provider) listInstances(regionID string, vpcIDs []string) []interface{} {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	ids := make([]string, 0)
+	for id, inst := range p.instances[regionID] {
+		if len(vpcIDs) > 0 && !contains(vpcIDs, inst.VpcID) {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	list := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		list = append(list, *p.instances[regionID][id])
+	}
+	return list
+}
+
+// writeList 返回分页后的资源列表
+func (p *Provider) writeList(w http.ResponseWriter, r *http.Request, list []interface{}) {
+	query := r.URL.Query()
+	offset, _ := strconv.Atoi(query.Get("offset"))
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = len(list)
+	}
+
+	count := len(list)
+	if offset > count {
+		offset = count
+	}
+	end := offset + limit
+	if end > count {
+		end = count
+	}
+
+	resp := map[string]interface{}{
+		"data": map[string]interface{}{
+			"count": count,
+			"info":  list[offset:end],
+		},
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func contains(arr []string, s string) bool {
+	for _, item := range arr {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,390 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package cloudvendor
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	ccom "configcenter/src/scene_server/cloud_server/common"
+
+	"github.com/tidwall/gjson"
+)
+
+func init() {
+	Register(metadata.GenericCloud, &genericClient{vendorName: metadata.GenericCloud})
+}
+
+// genericClient 通用云厂商客户端，通过云账户上配置的HTTP/JSON接口获取云资源
+type genericClient struct {
+	vendorName string
+	secretID   string
+	secretKey  string
+	conf       *metadata.CloudVendorConf
+	httpCli    *http.Client
+}
+
+const (
+	genericMaxPageSize    int64 = 500
+	genericDefaultTimeout       = 10 * time.Second
+	// genericRegionHolder 请求路径中地域的占位符
+	genericRegionHolder = "{region}"
+	// genericLimitParam genericOffsetParam 分页请求参数
+	genericLimitParam  = "limit"
+	genericOffsetParam = "offset"
+)
+
+var (
+	// errGenericConfNotSet 未配置通用云厂商的接入配置
+	errGenericConfNotSet = errors.New("generic cloud vendor conf is not set")
+)
+
+// defaultRegionsAPI 默认的地域列表查询接口配置
+var defaultRegionsAPI = metadata.CloudResourceAPI{
+	Path:      "/regions",
+	ListPath:  "data.info",
+	TotalPath: "data.count",
+	Fields: map[string]string{
+		common.BKRegion:   "id",
+		"bk_region_name":  "name",
+		"bk_region_state": "state",
+	},
+}
+
+// defaultVpcsAPI 默认的vpc列表查询接口配置
+var defaultVpcsAPI = metadata.CloudResourceAPI{
+	Path:      "/regions/{region}/vpcs",
+	ListPath:  "data.info",
+	TotalPath: "data.count",
+	Fields: map[string]string{
+		common.BKVpcID:   "id",
+		common.BKVpcName: "name",
+	},
+}
+
+// defaultInstancesAPI 默认的实例列表查询接口配置
+var defaultInstancesAPI = metadata.CloudResourceAPI{
+	Path:      "/regions/{region}/instances",
+	ListPath:  "data.info",
+	TotalPath: "data.count",
+	Fields: map[string]string{
+		common.BKCloudInstIDField:     "id",
+		common.BKHostInnerIPField:     "private_ip",
+		common.BKHostOuterIPField:     "public_ip",
+		common.BKCloudHostStatusField: "state",
+		common.BKVpcID:                "vpc_id",
+	},
+}
+
+// NewVendorClient 创建云厂商客户端
+// 通用云厂商需要接入配置才能使用，应通过NewVendorClientWithConf创建
+func (c *genericClient) NewVendorClient(secretID, secretKey string) VendorClient {
+	return &genericClient{
+		vendorName: metadata.GenericCloud,
+		secretID:   secretID,
+		secretKey:  secretKey,
+		httpCli:    &http.Client{Timeout: genericDefaultTimeout},
+	}
+}
+
+// NewVendorClientWithConf 根据云账户配置创建云厂商客户端
+func (c *genericClient) NewVendorClientWithConf(conf metadata.CloudAccountConf) (VendorClient, error) {
+	if rawErr := conf.VendorConf.Validate(); rawErr.ErrCode != 0 {
+		return nil, fmt.Errorf("generic cloud vendor conf is invalid, field: %v", rawErr.Args)
+	}
+
+	timeout := genericDefaultTimeout
+	if conf.VendorConf.Timeout > 0 {
+		timeout = time.Duration(conf.VendorConf.Timeout) * time.Second
+	}
+
+	return &genericClient{
+		vendorName: metadata.GenericCloud,
+		secretID:   conf.SecretID,
+		secretKey:  conf.SecretKey,
+		conf:       conf.VendorConf,
+		httpCli:    &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// GetRegions 获取地域列表
+func (c *genericClient) GetRegions() ([]*metadata.Region, error) {
+	if c.conf == nil {
+		return nil, errGenericConfNotSet
+	}
+
+	api := c.getResourceAPI(c.conf.Regions, defaultRegionsAPI)
+	regionSet := make([]*metadata.Region, 0)
+	_, err := c.listResources("", api, nil, ccom.MaxLimit, func(item gjson.Result) {
+		regionSet = append(regionSet, &metadata.Region{
+			RegionId:    c.getField(item, api, common.BKRegion),
+			RegionName:  c.getField(item, api, "bk_region_name"),
+			RegionState: c.getField(item, api, "bk_region_state"),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return regionSet, nil
+}
+
+// GetVpcs 获取vpc列表
+func (c *genericClient) GetVpcs(region string, opt *ccom.VpcOpt) (*metadata.VpcsInfo, error) {
+	if c.conf == nil {
+		return nil, errGenericConfNotSet
+	}
+
+	if opt == nil {
+		opt = ccom.GetDefaultVpcOpt()
+	}
+
+	api := c.getResourceAPI(c.conf.Vpcs, defaultVpcsAPI)
+	vpcsInfo := &metadata.VpcsInfo{VpcSet: make([]*metadata.Vpc, 0)}
+	totalCnt, err := c.listResources(region, api, opt.Filters, opt.Limit, func(item gjson.Result) {
+		vpcID := c.getField(item, api, common.BKVpcID)
+		vpcName := c.getField(item, api, common.BKVpcName)
+		// 没有vpc名称，则使用vpcid作为名称
+		if vpcName == "" {
+			vpcName = vpcID
+		}
+		vpcsInfo.VpcSet = append(vpcsInfo.VpcSet, &metadata.Vpc{
+			VpcId:   vpcID,
+			VpcName: vpcName,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	vpcsInfo.Count = totalCnt
+
+	return vpcsInfo, nil
+}
+
+// GetInstances 获取实例列表
+func (c *genericClient) GetInstances(region string, opt *ccom.InstanceOpt) (*metadata.InstancesInfo, error) {
+	if c.conf == nil {
+		return nil, errGenericConfNotSet
+	}
+
+	if opt == nil {
+		opt = ccom.GetDefaultInstanceOpt()
+	}
+
+	api := c.getResourceAPI(c.conf.Instances, defaultInstancesAPI)
+	instancesInfo := &metadata.InstancesInfo{InstanceSet: make([]*metadata.Instance, 0)}
+	totalCnt, err := c.listResources(region, api, opt.Filters, opt.Limit, func(item gjson.Result) {
+		instancesInfo.InstanceSet = append(instancesInfo.InstanceSet, &metadata.Instance{
+			InstanceId:    c.getField(item, api, common.BKCloudInstIDField),
+			PrivateIp:     c.getField(item, api, common.BKHostInnerIPField),
+			PublicIp:      c.getField(item, api, common.BKHostOuterIPField),
+			InstanceState: ccom.CovertInstState(c.getField(item, api, common.BKCloudHostStatusField)),
+			VpcId:         c.getField(item, api, common.BKVpcID),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	instancesInfo.Count = totalCnt
+
+	return instancesInfo, nil
+}
+
+// GetInstancesTotalCnt 获取实例总个数
+func (c *genericClient) GetInstancesTotalCnt(region string, opt *ccom.InstanceOpt) (int64, error) {
+	if opt == nil {
+		opt = ccom.GetDefaultInstanceOpt()
+	}
+	// 配置了总数路径时直接将limit设为最小值，能最快地获取到实例总个数，否则只能通过获取全部实例来计算总个数
+	limit := int64(1)
+	if c.conf != nil && c.getResourceAPI(c.conf.Instances, defaultInstancesAPI).TotalPath == "" {
+		limit = ccom.MaxLimit
+	}
+	instOpt := &ccom.InstanceOpt{BaseOpt: ccom.BaseOpt{Filters: opt.Filters, Limit: limit}}
+	instsInfo, err := c.GetInstances(region, instOpt)
+	if err != nil {
+		return 0, err
+	}
+	return instsInfo.Count, nil
+}
+
+// getResourceAPI 获取资源查询接口配置，未配置的部分使用默认值
+func (c *genericClient) getResourceAPI(api *metadata.CloudResourceAPI,
+	defaultAPI metadata.CloudResourceAPI) *metadata.CloudResourceAPI {
+
+	result := defaultAPI
+	if api == nil {
+		return &result
+	}
+
+	// 自定义的请求路径返回的数据中不一定有资源总数，只使用配置的总数路径
+	if api.Path != "" {
+		result.Path = api.Path
+		result.TotalPath = ""
+	}
+	if api.ListPath != "" {
+		result.ListPath = api.ListPath
+	}
+	if api.TotalPath != "" {
+		result.TotalPath = api.TotalPath
+	}
+	if len(api.Fields) > 0 {
+		fields := make(map[string]string, len(defaultAPI.Fields))
+		for field, path := range defaultAPI.Fields {
+			fields[field] = path
+		}
+		for field, path := range api.Fields {
+			fields[field] = path
+		}
+		result.Fields = fields
+	}
+
+	return &result
+}
+
+// getField 根据字段映射获取资源数据中的字段值，字段值为数组时取第一个元素，如多个内网ip的情况
+func (c *genericClient) getField(item gjson.Result, api *metadata.CloudResourceAPI, field string) string {
+	path, ok := api.Fields[field]
+	if !ok || path == "" {
+		return ""
+	}
+
+	value := item.Get(path)
+	if value.IsArray() {
+		values := value.Array()
+		if len(values) == 0 {
+			return ""
+		}
+		return values[0].String()
+	}
+	return value.String()
+}
+
+// listResources 分页获取资源列表，返回资源总个数
+// 在limit小于全部数据量的情况下，获取limit数量的数据，否则获取全部数据
+func (c *genericClient) listResources(region string, api *metadata.CloudResourceAPI, filters []*ccom.Filter,
+	limit int64, handler func(item gjson.Result)) (int64, error) {
+
+	pageSize := limit
+	if pageSize <= 0 || pageSize > genericMaxPageSize {
+		pageSize = genericMaxPageSize
+	}
+
+	var fetched, totalCnt int64
+	loopCnt := 0
+	for {
+		body, err := c.doRequest(region, api.Path, filters, pageSize, fetched)
+		if err != nil {
+			return 0, err
+		}
+
+		items := gjson.GetBytes(body, api.ListPath).Array()
+		for _, item := range items {
+			if limit > 0 && fetched >= limit {
+				break
+			}
+			handler(item)
+			fetched++
+		}
+
+		// 只有配置了总数路径时才能通过总个数判断是否获取了全部数据，否则获取到空页或者不满一页的数据时说明已获取全部数据
+		hasTotal := api.TotalPath != ""
+		if hasTotal {
+			totalCnt = gjson.GetBytes(body, api.TotalPath).Int()
+		}
+
+		// 在获取到limit数量或者全部数据的情况下，退出循环
+		if len(items) == 0 || int64(len(items)) < pageSize || (limit > 0 && fetched >= limit) ||
+			(hasTotal && fetched >= totalCnt) {
+			break
+		}
+
+		loopCnt++
+		if loopCnt > ccom.MaxLoopCnt {
+			blog.Errorf("list generic cloud resource loopCnt: %d, bigger than MaxLoopCnt, path: %s, fetched: %d",
+				loopCnt, api.Path, fetched)
+			return 0, ccom.ErrorLoopCnt
+		}
+	}
+
+	if totalCnt < fetched {
+		totalCnt = fetched
+	}
+	return totalCnt, nil
+}
+
+// doRequest 请求云厂商接口，过滤条件以查询参数的形式传递，如vpc-id=vpc-xxx
+func (c *genericClient) doRequest(region, path string, filters []*ccom.Filter, limit, offset int64) ([]byte,
+	error) {
+
+	query := url.Values{}
+	for _, filter := range filters {
+		if filter == nil || filter.Name == nil {
+			continue
+		}
+		for _, value := range filter.Values {
+			if value != nil {
+				query.Add(*filter.Name, *value)
+			}
+		}
+	}
+	query.Set(genericLimitParam, strconv.FormatInt(limit, 10))
+	query.Set(genericOffsetParam, strconv.FormatInt(offset, 10))
+
+	path = strings.ReplaceAll(path, genericRegionHolder, url.PathEscape(region))
+	reqURL := strings.TrimSuffix(c.conf.Endpoint, "/") + path + "?" + query.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(c.secretID, c.secretKey)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpCli.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		// 与其他云厂商的鉴权失败错误保持一致，以便上层识别为账户密钥错误
+		return nil, fmt.Errorf("AuthFailure, request %s failed, status code: %d", path, resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("request %s failed, status code: %d, body: %s", path, resp.StatusCode, body)
+	}
+
+	if !gjson.ValidBytes(body) {
+		return nil, fmt.Errorf("request %s failed, response is not valid json", path)
+	}
+	return body, nil
+}
@@ -0,0 +1,222 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package cloudvendor
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/cloud_server/cloudvendor/fakecloud"
+	ccom "configcenter/src/scene_server/cloud_server/common"
+)
+
+func newFakeProvider() *fakecloud.Provider {
+	provider := fakecloud.NewProvider("fake-id", "fake-key")
+	provider.AddRegion(fakecloud.Region{ID: "region-1", Name: "地域1", State: "AVAILABLE"})
+	provider.AddRegion(fakecloud.Region{ID: "region-2", Name: "地域2", State: "AVAILABLE"})
+	provider.AddVpc("region-1", fakecloud.Vpc{ID: "vpc-1", Name: "vpc1"})
+	provider.AddVpc("region-1", fakecloud.Vpc{ID: "vpc-2"})
+	for i := 0; i < 1200; i++ {
+		provider.AddInstance("region-1", fakecloud.Instance{
+			ID:        fmt.Sprintf("ins-%04d", i),
+			PrivateIP: fmt.Sprintf("10.0.%d.%d", i/256, i%256),
+			State:     "running",
+			VpcID:     "vpc-1",
+		})
+	}
+	provider.AddInstance("region-1", fakecloud.Instance{ID: "ins-stopped", PrivateIP: "10.1.0.1",
+		PublicIP: "1.1.1.1", State: "stopped", VpcID: "vpc-2"})
+	return provider
+}
+
+func TestGenericCloudClient(t *testing.T) {
+	server := newFakeProvider().Start()
+	defer server.Close()
+
+	client, err := GetVendorClient(metadata.CloudAccountConf{
+		VendorName: metadata.GenericCloud,
+		SecretID:   "fake-id",
+		SecretKey:  "fake-key",
+		VendorConf: &metadata.CloudVendorConf{Endpoint: server.URL},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	regions, err := client.GetRegions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(regions) != 2 || regions[0].RegionId != "region-1" || regions[0].RegionName != "地域1" {
+		t.Fatalf("regions %#v is invalid", regions)
+	}
+
+	vpcs, err := client.GetVpcs("region-1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vpcs.Count != 2 || vpcs.VpcSet[1].VpcName != "vpc-2" {
+		t.Fatalf("vpcs %#v is invalid", vpcs)
+	}
+
+	vpcOpt := &ccom.VpcOpt{BaseOpt: ccom.BaseOpt{
+		Filters: []*ccom.Filter{{Name: ccom.StringPtr("vpc-id"), Values: ccom.StringPtrs([]string{"vpc-2"})}},
+		Limit:   ccom.MaxLimit,
+	}}
+	vpcs, err = client.GetVpcs("region-1", vpcOpt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vpcs.Count != 1 || vpcs.VpcSet[0].VpcId != "vpc-2" {
+		t.Fatalf("filtered vpcs %#v is invalid", vpcs)
+	}
+
+	// 实例个数超过单页数量，需要分页获取
+	instances, err := client.GetInstances("region-1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if instances.Count != 1201 || len(instances.InstanceSet) != 1201 {
+		t.Fatalf("instances count %d, len %d is invalid", instances.Count, len(instances.InstanceSet))
+	}
+
+	instOpt := &ccom.InstanceOpt{BaseOpt: ccom.BaseOpt{
+		Filters: []*ccom.Filter{{Name: ccom.StringPtr("vpc-id"), Values: ccom.StringPtrs([]string{"vpc-2"})}},
+		Limit:   ccom.MaxLimit,
+	}}
+	instances, err = client.GetInstances("region-1", instOpt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(instances.InstanceSet) != 1 {
+		t.Fatalf("filtered instances %#v is invalid", instances.InstanceSet)
+	}
+	inst := instances.InstanceSet[0]
+	if inst.InstanceId != "ins-stopped" || inst.PublicIp != "1.1.1.1" || inst.VpcId != "vpc-2" ||
+		inst.InstanceState != common.BKCloudHostStatusStopped {
+		t.Fatalf("instance %#v is invalid", inst)
+	}
+
+	instOpt = &ccom.InstanceOpt{BaseOpt: ccom.BaseOpt{Limit: 10}}
+	instances, err = client.GetInstances("region-1", instOpt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if instances.Count != 1201 || len(instances.InstanceSet) != 10 {
+		t.Fatalf("limited instances count %d, len %d is invalid", instances.Count, len(instances.InstanceSet))
+	}
+
+	count, err := client.GetInstancesTotalCnt("region-1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1201 {
+		t.Fatalf("instances total count %d is invalid", count)
+	}
+}
+
+func TestGenericCloudFieldMapping(t *testing.T) {
+	server := newFakeProvider().Start()
+	defer server.Close()
+
+	client, err := GetVendorClient(metadata.CloudAccountConf{
+		VendorName: metadata.GenericCloud,
+		SecretID:   "fake-id",
+		SecretKey:  "fake-key",
+		VendorConf: &metadata.CloudVendorConf{
+			Endpoint: server.URL,
+			Vpcs: &metadata.CloudResourceAPI{
+				// 只覆盖部分字段映射，其余字段使用默认配置
+				Fields: map[string]string{common.BKVpcName: "id"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vpcs, err := client.GetVpcs("region-1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vpcs.Count != 2 || vpcs.VpcSet[0].VpcName != "vpc-1" || vpcs.VpcSet[0].VpcId != "vpc-1" {
+		t.Fatalf("vpcs %#v is invalid", vpcs.VpcSet)
+	}
+}
+
+func TestGenericCloudWithoutTotalPath(t *testing.T) {
+	server := newFakeProvider().Start()
+	defer server.Close()
+
+	client, err := GetVendorClient(metadata.CloudAccountConf{
+		VendorName: metadata.GenericCloud,
+		SecretID:   "fake-id",
+		SecretKey:  "fake-key",
+		VendorConf: &metadata.CloudVendorConf{
+			Endpoint: server.URL,
+			// 自定义请求路径时不使用默认的总数路径，需要分页获取直到返回的实例不满一页
+			Instances: &metadata.CloudResourceAPI{Path: "/regions/{region}/instances"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	instances, err := client.GetInstances("region-1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if instances.Count != 1201 || len(instances.InstanceSet) != 1201 {
+		t.Fatalf("instances count %d, len %d is invalid", instances.Count, len(instances.InstanceSet))
+	}
+
+	count, err := client.GetInstancesTotalCnt("region-1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1201 {
+		t.Fatalf("instances total count %d is invalid", count)
+	}
+}
+
+func TestGenericCloudAuthFailure(t *testing.T) {
+	server := newFakeProvider().Start()
+	defer server.Close()
+
+	client, err := GetVendorClient(metadata.CloudAccountConf{
+		VendorName: metadata.GenericCloud,
+		SecretID:   "fake-id",
+		SecretKey:  "wrong-key",
+		VendorConf: &metadata.CloudVendorConf{Endpoint: server.URL},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.GetRegions()
+	if err == nil || !strings.Contains(strings.ToLower(err.Error()), "authfailure") {
+		t.Fatalf("expect auth failure, but got %v", err)
+	}
+
+	_, err = GetVendorClient(metadata.CloudAccountConf{VendorName: metadata.GenericCloud})
+	if err == nil {
+		t.Fatal("expect error for account without vendor conf")
+	}
+}
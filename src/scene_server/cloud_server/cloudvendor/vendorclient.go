@@ -39,6 +39,14 @@ type VendorClient interface {
 	GetInstancesTotalCnt(region string, opt *ccom.InstanceOpt) (int64, error)
 }
 
+// ConfigurableVendorClient 需要根据云账户的接入配置创建的云厂商客户端
+// 如通用云厂商客户端，其接口地址、字段映射等信息都保存在云账户中
+type ConfigurableVendorClient interface {
+	VendorClient
+	// NewVendorClientWithConf 根据云账户配置创建云厂商客户端
+	NewVendorClientWithConf(conf metadata.CloudAccountConf) (VendorClient, error)
+}
+
 // Register 注册云厂商客户端
 func Register(vendorName string, client VendorClient) {
 	vendorClients[vendorName] = client
@@ -51,6 +59,9 @@ func GetVendorClient(conf metadata.CloudAccountConf) (VendorClient, error) {
 	if client, ok = vendorClients[conf.VendorName]; !ok {
 		return nil, fmt.Errorf("vendor %s is not supported", conf.VendorName)
 	}
+	if confClient, ok := client.(ConfigurableVendorClient); ok {
+		return confClient.NewVendorClientWithConf(conf)
+	}
 	cli := client.NewVendorClient(conf.SecretID, conf.SecretKey)
 	return cli, nil
 }
@@ -42,7 +42,7 @@ func (s *Service) VerifyConnectivity(ctx *rest.Contexts) {
 	}
 
 	conf := metadata.CloudAccountConf{VendorName: account.CloudVendor, SecretID: account.SecretID,
-		SecretKey: account.SecretKey}
+		SecretKey: account.SecretKey, VendorConf: account.VendorConf}
 	err := s.Logics.AccountVerify(ctx.Kit, conf)
 	if err != nil {
 		blog.ErrorJSON("cloud account verify failed, cloudvendor:%s, err :%v, rid: %s", account.CloudVendor, err,
@@ -115,8 +115,14 @@ func (s *Service) initRoute(api *restful.WebService) {
 		Handler: s.UpdateSyncTask})
 	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/cloud/sync/task/{bk_task_id}",
 		Handler: s.DeleteSyncTask})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/cloud/sync/task/{bk_task_id}/preview",
+		Handler: s.PreviewSyncTask})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/update/cloud/sync/task/{bk_task_id}/confirm",
+		Handler: s.ConfirmSyncTask})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/cloud/sync/history",
 		Handler: s.SearchSyncHistory})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost,
+		Path: "/update/cloud/sync/task/{bk_task_id}/history/{bk_history_id}/rollback", Handler: s.RollbackSyncHistory})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/cloud/sync/region",
 		Handler: s.SearchSyncRegion})
 
@@ -24,7 +24,9 @@ import (
 	"configcenter/src/common/auth"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/cloud_server/cloudsync"
 )
 
 // SearchVpc TODO
@@ -202,3 +204,127 @@ func (s *Service) SearchSyncRegion(ctx *rest.Contexts) {
 
 	ctx.RespEntity(result)
 }
+
+// PreviewSyncTask 预览同步任务将要产生的主机变更，不会修改任何数据
+func (s *Service) PreviewSyncTask(ctx *rest.Contexts) {
+	task, err := s.getSyncTask(ctx)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, err := cloudsync.NewHostSyncor(s.Logics).Preview(task)
+	if err != nil {
+		blog.Errorf("preview sync task failed, err: %v, taskID: %d, rid: %s", err, task.TaskID, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
+// ConfirmSyncTask 确认预览结果并执行同步，若同步差异在预览后发生了变化则拒绝执行
+func (s *Service) ConfirmSyncTask(ctx *rest.Contexts) {
+	option := new(metadata.ConfirmSyncTaskOption)
+	if err := ctx.DecodeInto(option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := option.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	task, err := s.getSyncTask(ctx)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := cloudsync.NewHostSyncor(s.Logics).SyncWithConfirm(task, option.Digest); err != nil {
+		blog.Errorf("confirm sync task failed, err: %v, taskID: %d, rid: %s", err, task.TaskID, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// getSyncTask 根据路径参数中的任务ID获取同步任务
+func (s *Service) getSyncTask(ctx *rest.Contexts) (*metadata.CloudSyncTask, error) {
+	taskIDStr := ctx.Request.PathParameter(common.BKCloudSyncTaskID)
+	taskID, err := strconv.ParseInt(taskIDStr, 10, 64)
+	if err != nil {
+		return nil, ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKCloudSyncTaskID)
+	}
+
+	option := &metadata.SearchSyncTaskOption{
+		SearchCloudOption: metadata.SearchCloudOption{
+			Condition: mapstr.MapStr{common.BKCloudSyncTaskID: taskID},
+		},
+	}
+	result, err := s.Logics.SearchSyncTask(ctx.Kit, option)
+	if err != nil {
+		blog.Errorf("search sync task failed, err: %v, taskID: %d, rid: %s", err, taskID, ctx.Kit.Rid)
+		return nil, err
+	}
+
+	if len(result.Info) == 0 {
+		return nil, ctx.Kit.CCError.CCError(common.CCErrCommNotFound)
+	}
+
+	return &result.Info[0], nil
+}
+
+// RollbackSyncHistory 回滚一次同步对主机的修改，恢复同步前的主机属性，删除同步新增的主机
+// 被销毁主机在销毁时删除的服务实例、进程和实例关联也会恢复，未能全部恢复的主机在结果的relations_not_restored中列出
+func (s *Service) RollbackSyncHistory(ctx *rest.Contexts) {
+	historyIDStr := ctx.Request.PathParameter(common.BKCloudSyncHistoryID)
+	historyID, err := strconv.ParseInt(historyIDStr, 10, 64)
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKCloudSyncHistoryID))
+		return
+	}
+
+	task, err := s.getSyncTask(ctx)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	option := &metadata.SearchSyncHistoryOption{
+		SearchCloudOption: metadata.SearchCloudOption{
+			Condition: mapstr.MapStr{common.BKCloudSyncHistoryID: historyID},
+		},
+		TaskID: task.TaskID,
+	}
+	histories, err := s.Logics.SearchSyncHistory(ctx.Kit, option)
+	if err != nil {
+		blog.Errorf("search sync history failed, err: %v, historyID: %d, rid: %s", err, historyID, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	if len(histories.Info) == 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommNotFound))
+		return
+	}
+
+	var result *metadata.SyncRollbackResult
+	txnErr := s.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
+		result, err = cloudsync.NewHostSyncor(s.Logics).Rollback(ctx.Kit, task, &histories.Info[0])
+		if err != nil {
+			blog.Errorf("rollback sync history failed, err: %v, historyID: %d, rid: %s", err, historyID,
+				ctx.Kit.Rid)
+			return err
+		}
+
+		return nil
+	})
+	if txnErr != nil {
+		ctx.RespAutoError(txnErr)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
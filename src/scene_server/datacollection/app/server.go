@@ -32,6 +32,7 @@ import (
 	"configcenter/src/common/backbone"
 	cc "configcenter/src/common/backbone/configcenter"
 	"configcenter/src/common/blog"
+	"configcenter/src/common/cryptor"
 	"configcenter/src/common/errors"
 	headerutil "configcenter/src/common/http/header/util"
 	"configcenter/src/common/metadata"
@@ -68,6 +69,9 @@ const (
 	// netCollectPorterName is name of netcollect porter.
 	netCollectPorterName = "netcollect"
 
+	// extraPorterNameSuffix is name suffix of the porter that only receives message from extra sources.
+	extraPorterNameSuffix = "_extra"
+
 	// defaultInitWaitDuration is default duration for new DataCollection init.
 	defaultInitWaitDuration = time.Second
 
@@ -109,6 +113,22 @@ type DataCollectionConfig struct {
 
 	// SnapReportMode hostsnap report mode
 	SnapReportMode string
+
+	// PorterSources extra message sources configs of porters, porter name -> configs.
+	PorterSources map[string]PorterSourceConfig
+}
+
+// PorterSourceConfig is configs for extra message sources of a porter, which are used
+// in the scenarios that collectors can't report message by redis or kafka.
+type PorterSourceConfig struct {
+	// HTTPIngest enables the http ingest endpoint of the porter.
+	HTTPIngest bool
+
+	// SpoolDir is directory that porter reads message files from, it's disabled if empty.
+	SpoolDir string
+
+	// DriverPull enables pulling net device properties with protocol drivers, only netcollect porter supports it.
+	DriverPull bool
 }
 
 // DataCollection is data collection server.
@@ -156,6 +176,9 @@ type DataCollection struct {
 	// registry is prometheus registry.
 	registry prometheus.Registerer
 
+	// cryptor encrypts the passwords of net device targets, it's nil if crypto is not enabled.
+	cryptor cryptor.Cryptor
+
 	// hash collections hash object, that updates target nodes in dynamic mode,
 	// and calculates node base on hash key of data.
 	hash *collections.Hash
@@ -292,6 +315,16 @@ func (c *DataCollection) initConfigs() error {
 		}
 	}
 
+	// extra message sources of porters.
+	c.config.PorterSources = make(map[string]PorterSourceConfig)
+	for _, name := range []string{snapPorterName, middlewarePorterName, netCollectPorterName} {
+		sourceConf := PorterSourceConfig{}
+		sourceConf.HTTPIngest, _ = cc.Bool(fmt.Sprintf("datacollection.%s.source.httpIngest", name))
+		sourceConf.SpoolDir, _ = cc.String(fmt.Sprintf("datacollection.%s.source.spoolDir", name))
+		sourceConf.DriverPull, _ = cc.Bool(fmt.Sprintf("datacollection.%s.source.driverPull", name))
+		c.config.PorterSources[name] = sourceConf
+	}
+
 	c.config.Auth, err = iam.ParseConfigFromKV("authServer", nil)
 	if err != nil {
 		blog.Warnf("parse auth center config failed: %v", err)
@@ -329,8 +362,20 @@ func (c *DataCollection) initModules() error {
 	}
 	blog.Info("DataCollection| init modules, create ESB success[%+v]", c.config.Esb)
 
+	// create cryptor with the common crypto config.
+	cryptoConf, err := cc.Crypto("crypto")
+	if err != nil {
+		return fmt.Errorf("get crypto config, %+v", err)
+	}
+	if cryptoConf.Enabled {
+		if c.cryptor, err = cryptor.NewCrypto(cryptoConf); err != nil {
+			return fmt.Errorf("create cryptor, %+v", err)
+		}
+	}
+	blog.Infof("DataCollection| init modules, enable crypto: %v", cryptoConf.Enabled)
+
 	// build logics comm.
-	c.service.SetLogics(mgoCli, esb)
+	c.service.SetLogics(mgoCli, esb, c.cryptor)
 
 	// connect to cc main redis.
 	redisCli, err := redis.NewFromConfig(c.config.CCRedis)
@@ -401,7 +446,7 @@ func (c *DataCollection) initModules() error {
 	}
 
 	iamCli := new(iam.IAM)
-	if auth.EnableAuthorize() {
+	if auth.EnableIAM() {
 		blog.Info("enable auth center access")
 		iamCli, err = iam.NewIAM(c.config.Auth, c.engine.Metric().Registry())
 		if err != nil {
@@ -503,40 +548,97 @@ func (c *DataCollection) runCollectPorters() {
 			snapPorterName, topic)
 	}
 
-	if c.snapRedisCli != nil {
+	snapSources := c.porterSources(snapPorterName)
+	if metadata.GseConfigReportMode(c.config.SnapReportMode) == metadata.GseConfigReportModeKafka &&
+		len(snapSources) > 0 {
+		// porter names are unique, so the extra sources need a separate porter in kafka mode.
+		name := snapPorterName + extraPorterNameSuffix
+		analyzer := hostsnap.NewHostSnap(c.ctx, c.redisCli, c.db, c.engine, c.authManager)
+
+		porter := collections.NewSimplePorterWithSources(name, c.engine, c.hash, analyzer, nil, c.registry,
+			snapSources...)
+		c.porterManager.AddPorter(porter)
+		blog.Info("DataCollection| create hostsnap analyzer with target porter[%s] on extra sources success", name)
+		snapSources = nil
+	}
+
+	if c.snapRedisCli != nil || len(snapSources) > 0 {
 		topic := c.snapMessageTopic(c.defaultAppID)
 		analyzer := hostsnap.NewHostSnap(c.ctx, c.redisCli, c.db, c.engine, c.authManager)
 
-		porter := collections.NewSimplePorter(snapPorterName, c.engine, c.hash, analyzer, c.snapRedisCli, topic,
-			c.registry)
+		// the delay queue is written by the analyzer with cc main redis.
+		delayQueueCli := c.redisCli
+		if c.snapRedisCli != nil {
+			snapSources = append(snapSources, collections.NewRedisSource(c.snapRedisCli, topic))
+			delayQueueCli = c.snapRedisCli
+		}
+
+		porter := collections.NewSimplePorterWithSources(snapPorterName, c.engine, c.hash, analyzer, delayQueueCli,
+			c.registry, snapSources...)
 		c.porterManager.AddPorter(porter)
 		blog.Info("DataCollection| create redis hostsnap analyzer with target porter[%s] on topic[%s] success",
 			snapPorterName, topic)
 	}
 
-	if c.disRedisCli != nil {
+	disSources := c.porterSources(middlewarePorterName)
+	if c.disRedisCli != nil || len(disSources) > 0 {
 		topic := c.discoverMessageTopic(c.defaultAppID)
 		analyzer := middleware.NewDiscover(c.ctx, c.redisCli, c.engine, c.authManager)
 
-		porter := collections.NewSimplePorter(middlewarePorterName, c.engine, c.hash, analyzer, c.disRedisCli, topic,
-			c.registry)
+		if c.disRedisCli != nil {
+			disSources = append(disSources, collections.NewRedisSource(c.disRedisCli, topic))
+		}
+
+		porter := collections.NewSimplePorterWithSources(middlewarePorterName, c.engine, c.hash, analyzer,
+			c.disRedisCli, c.registry, disSources...)
 		c.porterManager.AddPorter(porter)
 		blog.Info("DataCollection| create kafka discover analyzer with target porter[%s] on topic[%s] success",
 			middlewarePorterName, topic)
 	}
 
-	if c.netRedisCli != nil {
+	netSources := c.porterSources(netCollectPorterName)
+	if c.netRedisCli != nil || len(netSources) > 0 {
 		topic := c.netcollectMessageTopic(c.defaultAppID)
 		analyzer := netcollect.NewNetCollect(c.ctx, c.db, c.authManager)
 
-		porter := collections.NewSimplePorter(netCollectPorterName, c.engine, c.hash, analyzer, c.netRedisCli, topic,
-			c.registry)
+		if c.netRedisCli != nil {
+			netSources = append(netSources, collections.NewRedisSource(c.netRedisCli, topic))
+		}
+
+		porter := collections.NewSimplePorterWithSources(netCollectPorterName, c.engine, c.hash, analyzer,
+			c.netRedisCli, c.registry, netSources...)
 		c.porterManager.AddPorter(porter)
 		blog.Info("DataCollection| create redis netcollect analyzer with target porter[%s] on topic[%s] success",
 			netCollectPorterName, topic)
 	}
 }
 
+// porterSources creates the extra message sources of the porter base on configs.
+func (c *DataCollection) porterSources(porterName string) []collections.Source {
+	sources := make([]collections.Source, 0)
+	sourceConf := c.config.PorterSources[porterName]
+
+	if sourceConf.HTTPIngest {
+		source := collections.NewHTTPSource()
+		c.service.AddIngestSource(porterName, source)
+		sources = append(sources, source)
+		blog.Infof("DataCollection| enable http ingest source for porter[%s]", porterName)
+	}
+
+	if sourceConf.SpoolDir != "" {
+		sources = append(sources, collections.NewSpoolSource(sourceConf.SpoolDir))
+		blog.Infof("DataCollection| enable spool source for porter[%s] on directory[%s]", porterName,
+			sourceConf.SpoolDir)
+	}
+
+	if sourceConf.DriverPull && porterName == netCollectPorterName {
+		sources = append(sources, netcollect.NewDriverSource(c.ctx, c.db, c.hash, c.cryptor))
+		blog.Infof("DataCollection| enable protocol driver source for porter[%s]", porterName)
+	}
+
+	return sources
+}
+
 // Run runs a new datacollection server.
 func (c *DataCollection) Run() error {
 	// init configs.
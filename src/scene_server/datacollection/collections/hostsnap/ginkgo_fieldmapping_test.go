@@ -0,0 +1,181 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package hostsnap
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/tidwall/gjson"
+)
+
+const fieldMappingJson = `{
+    "data": {
+        "mem": {"meminfo": {"total": 8589934592}},
+        "disk": {"usage": [{"total": 10737418240}, {"total": 5368709120}]},
+        "system": {"info": {"kernelVersion": "3.10.0-1160.el7.x86_64"}},
+        "net": {"interface": [{"hardwareaddr": "52:54:00:aa:bb:cc"}, {"hardwareaddr": "52:54:00:dd:ee:ff"}]}
+    }
+}`
+
+// 编译映射规则并获取映射后的值.
+func getMappedValue(mapping metadata.HostSnapFieldMapping, propertyType string) (interface{}, bool) {
+	fm, err := compileFieldMapping(mapping, metadata.Attribute{PropertyID: mapping.PropertyID,
+		PropertyType: propertyType})
+	Expect(err).NotTo(HaveOccurred())
+
+	gson := gjson.Parse(fieldMappingJson)
+	return fm.value(&gson)
+}
+
+var _ = Describe("Hostsnap field mapping", func() {
+	Context("test mapping without transform", func() {
+		It("", func() {
+			value, ok := getMappedValue(metadata.HostSnapFieldMapping{Path: "data.mem.meminfo.total",
+				PropertyID: "mem_bytes"}, common.FieldTypeInt)
+			Expect(ok).To(BeTrue())
+			Expect(value).To(Equal(int64(8589934592)))
+
+			_, ok = getMappedValue(metadata.HostSnapFieldMapping{Path: "data.not.exist", PropertyID: "not_exist"},
+				common.FieldTypeSingleChar)
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Context("test unit transform", func() {
+		It("", func() {
+			value, ok := getMappedValue(metadata.HostSnapFieldMapping{Path: "data.disk.usage.#.total",
+				PropertyID: "disk_total", Transform: &metadata.HostSnapFieldTransform{
+					Type: metadata.HostSnapTransformUnit, FromUnit: "B", ToUnit: "GB"}}, common.FieldTypeInt)
+			Expect(ok).To(BeTrue())
+			Expect(value).To(Equal(int64(15)))
+
+			value, ok = getMappedValue(metadata.HostSnapFieldMapping{Path: "data.mem.meminfo.total",
+				PropertyID: "mem_tb", Transform: &metadata.HostSnapFieldTransform{
+					Type: metadata.HostSnapTransformUnit, FromUnit: "B", ToUnit: "TB", Precision: 3}},
+				common.FieldTypeFloat)
+			Expect(ok).To(BeTrue())
+			Expect(value).To(Equal(0.008))
+		})
+	})
+
+	Context("test regex transform", func() {
+		It("", func() {
+			value, ok := getMappedValue(metadata.HostSnapFieldMapping{Path: "data.system.info.kernelVersion",
+				PropertyID: "kernel_major", Transform: &metadata.HostSnapFieldTransform{
+					Type: metadata.HostSnapTransformRegex, Pattern: `^(\d+\.\d+)`}}, common.FieldTypeSingleChar)
+			Expect(ok).To(BeTrue())
+			Expect(value).To(Equal("3.10"))
+
+			_, ok = getMappedValue(metadata.HostSnapFieldMapping{Path: "data.system.info.kernelVersion",
+				PropertyID: "kernel_arch", Transform: &metadata.HostSnapFieldTransform{
+					Type: metadata.HostSnapTransformRegex, Pattern: `aarch64`}}, common.FieldTypeSingleChar)
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Context("test join transform", func() {
+		It("", func() {
+			value, ok := getMappedValue(metadata.HostSnapFieldMapping{Path: "data.net.interface.#.hardwareaddr",
+				PropertyID: "all_mac", Transform: &metadata.HostSnapFieldTransform{
+					Type: metadata.HostSnapTransformJoin, Separator: ";"}}, common.FieldTypeLongChar)
+			Expect(ok).To(BeTrue())
+			Expect(value).To(Equal("52:54:00:aa:bb:cc;52:54:00:dd:ee:ff"))
+		})
+	})
+
+	Context("test apply mappings to setter", func() {
+		It("", func() {
+			mapper := newFieldMapper(nil, nil, nil)
+			fm, err := compileFieldMapping(metadata.HostSnapFieldMapping{Path: "data.disk.usage.#.total",
+				PropertyID: "disk_total", Transform: &metadata.HostSnapFieldTransform{
+					Type: metadata.HostSnapTransformUnit, FromUnit: "B", ToUnit: "GB"}},
+				metadata.Attribute{PropertyID: "disk_total", PropertyType: common.FieldTypeInt})
+			Expect(err).NotTo(HaveOccurred())
+			mapper.setMappings(map[string][]*fieldMapping{common.BKDefaultOwnerID: {fm}})
+			Expect(mapper.hostFields()).To(ContainElement("disk_total"))
+			Expect(mapper.hostFields()).To(ContainElement(common.BkSupplierAccount))
+
+			gson := gjson.Parse(fieldMappingJson)
+
+			// 主机的值与映射的值一致时不需要更新.
+			setter := make(map[string]interface{})
+			Expect(mapper.apply(&gson, `{"bk_host_id":1,"disk_total":15}`, setter, "")).To(BeFalse())
+			Expect(setter).To(BeEmpty())
+
+			setter = make(map[string]interface{})
+			Expect(mapper.apply(&gson, `{"bk_host_id":1,"disk_total":10}`, setter, "")).To(BeTrue())
+			Expect(setter).To(HaveKeyWithValue("disk_total", int64(15)))
+
+			// 其他开发商的主机不使用该开发商的映射规则.
+			setter = make(map[string]interface{})
+			Expect(mapper.apply(&gson, `{"bk_host_id":2,"bk_supplier_account":"1","disk_total":10}`, setter,
+				"")).To(BeFalse())
+			Expect(setter).To(BeEmpty())
+		})
+	})
+
+	Context("test invalid mapped value", func() {
+		It("", func() {
+			mapper := newFieldMapper(nil, nil, nil)
+			enumFm, err := compileFieldMapping(metadata.HostSnapFieldMapping{Path: "data.system.info.kernelVersion",
+				PropertyID: "kernel_major", Transform: &metadata.HostSnapFieldTransform{
+					Type: metadata.HostSnapTransformRegex, Pattern: `^(\d+)`}},
+				metadata.Attribute{PropertyID: "kernel_major", PropertyType: common.FieldTypeEnum,
+					Option: []interface{}{map[string]interface{}{"id": "4", "name": "4", "type": "text"}}})
+			Expect(err).NotTo(HaveOccurred())
+			intFm, err := compileFieldMapping(metadata.HostSnapFieldMapping{Path: "data.mem.meminfo.total",
+				PropertyID: "mem_bytes"}, metadata.Attribute{PropertyID: "mem_bytes",
+				PropertyType: common.FieldTypeInt})
+			Expect(err).NotTo(HaveOccurred())
+			mapper.setMappings(map[string][]*fieldMapping{common.BKDefaultOwnerID: {enumFm, intFm}})
+
+			// 不在枚举选项中的值被跳过, 不影响其他字段的更新.
+			gson := gjson.Parse(fieldMappingJson)
+			setter := make(map[string]interface{})
+			Expect(mapper.apply(&gson, `{"bk_host_id":1}`, setter, "")).To(BeTrue())
+			Expect(setter).NotTo(HaveKey("kernel_major"))
+			Expect(setter).To(HaveKeyWithValue("mem_bytes", int64(8589934592)))
+		})
+	})
+
+	Context("test check field mapping with host attribute", func() {
+		It("", func() {
+			unit := metadata.HostSnapFieldMapping{Path: "data.mem.meminfo.total", PropertyID: "mem",
+				Transform: &metadata.HostSnapFieldTransform{Type: metadata.HostSnapTransformUnit, FromUnit: "B",
+					ToUnit: "GB"}}
+			Expect(CheckFieldMapping(unit, metadata.Attribute{PropertyID: "mem",
+				PropertyType: common.FieldTypeFloat})).NotTo(HaveOccurred())
+			Expect(CheckFieldMapping(unit, metadata.Attribute{PropertyID: "mem",
+				PropertyType: common.FieldTypeSingleChar})).To(HaveOccurred())
+
+			raw := metadata.HostSnapFieldMapping{Path: "data.mem.meminfo.total", PropertyID: "mem"}
+			Expect(CheckFieldMapping(raw, metadata.Attribute{PropertyID: "mem",
+				PropertyType: common.FieldTypeUser})).To(HaveOccurred())
+		})
+	})
+
+	Context("test managed fields", func() {
+		It("", func() {
+			Expect(IsManagedField(common.BKHostInnerIPField)).To(BeTrue())
+			Expect(IsManagedField(common.BKCloudIDField)).To(BeTrue())
+			Expect(IsManagedField("disk_total")).To(BeFalse())
+		})
+	})
+})
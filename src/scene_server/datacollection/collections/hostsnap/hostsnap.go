@@ -86,6 +86,8 @@ type HostSnap struct {
 	ctx       context.Context
 	db        dal.RDB
 	window    *Window
+	// fieldMapper applies the admin managed field mappings to fill the custom host attributes.
+	fieldMapper *fieldMapper
 }
 
 // NewHostSnap new hostsnap
@@ -101,6 +103,7 @@ func NewHostSnap(ctx context.Context, redisCli redis.Client, db dal.RDB, engine
 		Engine:      engine,
 		filter:      newFilter(),
 		window:      newWindow(),
+		fieldMapper: newFieldMapper(ctx, db, engine),
 	}
 	return h
 }
@@ -369,8 +372,15 @@ func (h *HostSnap) Analyze(msg *string, sourceType string) (bool, error) {
 		setter, raw = parseSetter(&val, innerIP, outerIP)
 	}
 
+	// the mapped host attributes are updated only within the time window no matter which version the
+	// collection plug-in is, so the mapping changes would not cause a large number of host updates at once.
+	mappingChanged := false
+	if h.window.canPassWindow() {
+		mappingChanged = h.fieldMapper.apply(&val, host, setter, rid)
+	}
+
 	// no need to update
-	if !needToUpdate(raw, host, elements[3].String()) {
+	if !needToUpdate(raw, host, elements[3].String()) && !mappingChanged {
 		return false, nil
 	}
 
@@ -1120,7 +1130,7 @@ func (h *HostSnap) getHostByAgentID(header http.Header, rid, agentID string) (st
 
 	opt := &metadata.SearchHostWithAgentID{
 		AgentID: agentID,
-		Fields:  reqireFields,
+		Fields:  h.fieldMapper.hostFields(),
 	}
 
 	host, err := h.Engine.CoreAPI.CacheService().Cache().Host().SearchHostWithAgentID(context.Background(), header, opt)
@@ -1142,7 +1152,7 @@ func (h *HostSnap) getHostByVal(header http.Header, rid string, cloudID int64, i
 		opt := &metadata.SearchHostWithInnerIPOption{
 			InnerIP: ip,
 			CloudID: cloudID,
-			Fields:  reqireFields,
+			Fields:  h.fieldMapper.hostFields(),
 		}
 
 		host, err := h.Engine.CoreAPI.CacheService().Cache().Host().SearchHostWithInnerIPForStatic(context.Background(),
@@ -0,0 +1,410 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package hostsnap
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/backbone"
+	"configcenter/src/common/blog"
+	headerutil "configcenter/src/common/http/header/util"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal"
+
+	"github.com/tidwall/gjson"
+)
+
+// defaultFieldMappingRefreshInterval is the interval of reloading host snapshot field mappings, so the mapping
+// changes take effect in at most one interval.
+const defaultFieldMappingRefreshInterval = time.Minute
+
+// IsManagedField returns if the host attribute is managed by hostsnap itself, these attributes can not be used as
+// the target of host snapshot field mappings.
+func IsManagedField(field string) bool {
+	if field == common.BKCloudIDField || field == common.BKAgentIDField {
+		return true
+	}
+
+	for _, managed := range reqireFields {
+		if field == managed {
+			return true
+		}
+	}
+	return false
+}
+
+// mappableTransforms is the transforms that can be used for each host attribute type that can be mapped, the nil
+// transform means the snapshot value is used directly.
+var mappableTransforms = map[string][]metadata.HostSnapTransformType{
+	common.FieldTypeInt:        {"", metadata.HostSnapTransformUnit, metadata.HostSnapTransformRegex},
+	common.FieldTypeFloat:      {"", metadata.HostSnapTransformUnit, metadata.HostSnapTransformRegex},
+	common.FieldTypeBool:       {"", metadata.HostSnapTransformRegex},
+	common.FieldTypeEnum:       {"", metadata.HostSnapTransformRegex},
+	common.FieldTypeSingleChar: {"", metadata.HostSnapTransformRegex, metadata.HostSnapTransformJoin},
+	common.FieldTypeLongChar:   {"", metadata.HostSnapTransformRegex, metadata.HostSnapTransformJoin},
+}
+
+// CheckFieldMapping checks if the host snapshot field mapping can be applied to the host attribute, the values of
+// the mapped host attribute are validated again with the attribute when the mapping is applied.
+func CheckFieldMapping(mapping metadata.HostSnapFieldMapping, attr metadata.Attribute) error {
+	_, err := compileFieldMapping(mapping, attr)
+	return err
+}
+
+// fieldMapping is the compiled host snapshot field mapping.
+type fieldMapping struct {
+	path       string
+	propertyID string
+	attr       metadata.Attribute
+	transform  *metadata.HostSnapFieldTransform
+	regex      *regexp.Regexp
+}
+
+// compileFieldMapping compiles the host snapshot field mapping with the host attribute.
+func compileFieldMapping(mapping metadata.HostSnapFieldMapping, attr metadata.Attribute) (*fieldMapping, error) {
+	if rawErr := mapping.Validate(); rawErr.ErrCode != 0 {
+		return nil, fmt.Errorf("field mapping is invalid, error code: %d, args: %v", rawErr.ErrCode, rawErr.Args)
+	}
+
+	transforms, exists := mappableTransforms[attr.PropertyType]
+	if !exists {
+		return nil, fmt.Errorf("host attribute type %s can not be mapped", attr.PropertyType)
+	}
+
+	transformType := metadata.HostSnapTransformType("")
+	if mapping.Transform != nil {
+		transformType = mapping.Transform.Type
+	}
+	if !util.InArray(transformType, transforms) {
+		return nil, fmt.Errorf("transform %s can not be used for host attribute type %s", transformType,
+			attr.PropertyType)
+	}
+
+	if attr.PropertyType == common.FieldTypeEnum {
+		if _, err := metadata.ParseEnumOption(attr.Option); err != nil {
+			return nil, fmt.Errorf("parse enum option of host attribute %s failed, err: %v", attr.PropertyID, err)
+		}
+	}
+
+	m := &fieldMapping{
+		path:       mapping.Path,
+		propertyID: mapping.PropertyID,
+		attr:       attr,
+		transform:  mapping.Transform,
+	}
+
+	if m.transform != nil && m.transform.Type == metadata.HostSnapTransformRegex {
+		regex, err := regexp.Compile(m.transform.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		m.regex = regex
+	}
+	return m, nil
+}
+
+// value gets the value of the host attribute from the snapshot message, returns false if the value does not exist
+// or can not be converted to the type of the host attribute.
+func (m *fieldMapping) value(val *gjson.Result) (interface{}, bool) {
+	result := val.Get(m.path)
+	if !result.Exists() {
+		return nil, false
+	}
+
+	if m.transform == nil {
+		return convertPropertyValue(result, m.attr.PropertyType)
+	}
+
+	switch m.transform.Type {
+	case metadata.HostSnapTransformUnit:
+		sum := float64(0)
+		if result.IsArray() {
+			for _, item := range result.Array() {
+				sum += item.Float()
+			}
+		} else {
+			sum = result.Float()
+		}
+
+		converted := sum * metadata.HostSnapByteUnits[m.transform.FromUnit] /
+			metadata.HostSnapByteUnits[m.transform.ToUnit]
+		precision := math.Pow10(m.transform.Precision)
+		converted = math.Round(converted*precision) / precision
+		return convertPropertyValue(gjson.Parse(strconv.FormatFloat(converted, 'f', -1, 64)), m.attr.PropertyType)
+
+	case metadata.HostSnapTransformRegex:
+		matches := m.regex.FindStringSubmatch(result.String())
+		if len(matches) == 0 {
+			return nil, false
+		}
+
+		extracted := matches[0]
+		if len(matches) > 1 {
+			extracted = matches[1]
+		}
+		return convertPropertyValue(gjson.Result{Type: gjson.String, Str: extracted}, m.attr.PropertyType)
+
+	case metadata.HostSnapTransformJoin:
+		separator := m.transform.Separator
+		if separator == "" {
+			separator = ","
+		}
+
+		items := make([]string, 0)
+		for _, item := range result.Array() {
+			items = append(items, item.String())
+		}
+		return convertPropertyValue(gjson.Result{Type: gjson.String, Str: strings.Join(items, separator)},
+			m.attr.PropertyType)
+	}
+
+	return nil, false
+}
+
+// convertPropertyValue converts the snapshot value to the type of the host attribute, numeric values are rounded
+// when the host attribute is int.
+func convertPropertyValue(result gjson.Result, propertyType string) (interface{}, bool) {
+	switch propertyType {
+	case common.FieldTypeInt:
+		num, err := strconv.ParseFloat(strings.TrimSpace(result.String()), 64)
+		if err != nil {
+			return nil, false
+		}
+		return int64(math.Round(num)), true
+	case common.FieldTypeFloat:
+		num, err := strconv.ParseFloat(strings.TrimSpace(result.String()), 64)
+		if err != nil {
+			return nil, false
+		}
+		return num, true
+	case common.FieldTypeBool:
+		b, err := strconv.ParseBool(strings.TrimSpace(result.String()))
+		if err != nil {
+			return nil, false
+		}
+		return b, true
+	default:
+		return result.String(), true
+	}
+}
+
+// fieldMapper applies the admin managed host snapshot field mappings to the host, the mappings are reloaded from db
+// periodically.
+type fieldMapper struct {
+	ctx    context.Context
+	db     dal.RDB
+	engine *backbone.Engine
+
+	lock sync.RWMutex
+	// mappings is the compiled field mappings of each supplier account, the mappings of one supplier account are
+	// only applied to the hosts of this supplier account.
+	mappings map[string][]*fieldMapping
+}
+
+func newFieldMapper(ctx context.Context, db dal.RDB, engine *backbone.Engine) *fieldMapper {
+	m := &fieldMapper{ctx: ctx, db: db, engine: engine, mappings: make(map[string][]*fieldMapping)}
+	if db == nil || engine == nil {
+		return m
+	}
+
+	go m.refresh()
+	return m
+}
+
+func (m *fieldMapper) refresh() {
+	for {
+		if err := m.load(); err != nil {
+			blog.Errorf("load host snapshot field mappings failed, err: %v", err)
+		}
+		time.Sleep(defaultFieldMappingRefreshInterval)
+	}
+}
+
+// load reloads the host snapshot field mappings and the mapped host attributes of all supplier accounts.
+func (m *fieldMapper) load() error {
+	_, rid := newHeaderWithRid()
+
+	mappings := make([]metadata.HostSnapFieldMapping, 0)
+	if err := m.db.Table(common.BKTableNameHostSnapFieldMapping).Find(nil).All(m.ctx, &mappings); err != nil {
+		blog.Errorf("find host snapshot field mappings failed, err: %v, rid: %s", err, rid)
+		return err
+	}
+
+	ownerMappings := make(map[string][]metadata.HostSnapFieldMapping)
+	for _, mapping := range mappings {
+		ownerID := mapping.OwnerID
+		if ownerID == "" {
+			ownerID = common.BKDefaultOwnerID
+		}
+		ownerMappings[ownerID] = append(ownerMappings[ownerID], mapping)
+	}
+
+	compiled := make(map[string][]*fieldMapping, len(ownerMappings))
+	for ownerID, mappings := range ownerMappings {
+		fms, err := m.compileOwnerMappings(ownerID, mappings, rid)
+		if err != nil {
+			return err
+		}
+		compiled[ownerID] = fms
+	}
+
+	m.setMappings(compiled)
+	return nil
+}
+
+// compileOwnerMappings compiles the host snapshot field mappings of one supplier account with its host attributes.
+func (m *fieldMapper) compileOwnerMappings(ownerID string, mappings []metadata.HostSnapFieldMapping, rid string) (
+	[]*fieldMapping, error) {
+
+	header := headerutil.GenCommonHeader(common.CCSystemCollectorUserName, ownerID, rid)
+
+	propertyIDs := make([]string, 0, len(mappings))
+	for _, mapping := range mappings {
+		propertyIDs = append(propertyIDs, mapping.PropertyID)
+	}
+
+	query := &metadata.QueryCondition{
+		Condition: mapstr.MapStr{common.BKPropertyIDField: mapstr.MapStr{common.BKDBIN: propertyIDs}},
+		Page:      metadata.BasePage{Limit: common.BKNoLimit},
+	}
+	attrs, err := m.engine.CoreAPI.CoreService().Model().ReadModelAttr(m.ctx, header, common.BKInnerObjIDHost, query)
+	if err != nil {
+		blog.Errorf("read host attributes of field mappings failed, owner: %s, err: %v, rid: %s", ownerID, err, rid)
+		return nil, err
+	}
+
+	attrMap := make(map[string]metadata.Attribute)
+	for _, attr := range attrs.Info {
+		attrMap[attr.PropertyID] = attr
+	}
+
+	compiled := make([]*fieldMapping, 0, len(mappings))
+	for _, mapping := range mappings {
+		attr, exists := attrMap[mapping.PropertyID]
+		if !exists || IsManagedField(mapping.PropertyID) {
+			blog.Errorf("host attribute %s of field mapping %d is invalid, skip it, rid: %s", mapping.PropertyID,
+				mapping.ID, rid)
+			continue
+		}
+
+		fm, err := compileFieldMapping(mapping, attr)
+		if err != nil {
+			blog.Errorf("compile field mapping %d failed, skip it, err: %v, rid: %s", mapping.ID, err, rid)
+			continue
+		}
+		compiled = append(compiled, fm)
+	}
+	return compiled, nil
+}
+
+func (m *fieldMapper) setMappings(mappings map[string][]*fieldMapping) {
+	m.lock.Lock()
+	m.mappings = mappings
+	m.lock.Unlock()
+}
+
+// getMappings returns the field mappings of the supplier account, the empty supplier account is regarded as the
+// default one.
+func (m *fieldMapper) getMappings(ownerID string) []*fieldMapping {
+	if ownerID == "" {
+		ownerID = common.BKDefaultOwnerID
+	}
+
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.mappings[ownerID]
+}
+
+// hostFields returns the host fields that hostsnap needs, including the mapped host attributes of all supplier
+// accounts and the supplier account to choose the mappings of the host.
+func (m *fieldMapper) hostFields() []string {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	if len(m.mappings) == 0 {
+		return reqireFields
+	}
+
+	fields := make([]string, 0, len(reqireFields)+1)
+	fields = append(fields, reqireFields...)
+	fields = append(fields, common.BkSupplierAccount)
+
+	mapped := make(map[string]struct{})
+	for _, mappings := range m.mappings {
+		for _, mapping := range mappings {
+			if _, exists := mapped[mapping.propertyID]; exists {
+				continue
+			}
+			mapped[mapping.propertyID] = struct{}{}
+			fields = append(fields, mapping.propertyID)
+		}
+	}
+	return fields
+}
+
+// apply sets the mapped host attributes whose values are changed to the setter, returns if any of them is changed.
+// the mapped values that are invalid for the host attribute are skipped, so that they would not fail the update
+// of the other host attributes.
+func (m *fieldMapper) apply(val *gjson.Result, host string, setter map[string]interface{}, rid string) bool {
+	mappings := m.getMappings(gjson.Get(host, common.BkSupplierAccount).String())
+	if len(mappings) == 0 {
+		return false
+	}
+
+	ctx := context.WithValue(context.Background(), common.ContextRequestIDField, rid)
+
+	changed := false
+	for _, mapping := range mappings {
+		value, ok := mapping.value(val)
+		if !ok {
+			continue
+		}
+
+		if rawErr := mapping.attr.Validate(ctx, value, mapping.propertyID); rawErr.ErrCode != 0 {
+			blog.Errorf("mapped value %v of host attribute %s is invalid, skip it, err code: %d, host: %s, rid: %s",
+				value, mapping.propertyID, rawErr.ErrCode, gjson.Get(host, common.BKHostIDField).String(), rid)
+			continue
+		}
+
+		// compare these value with string directly as the built-in fields do.
+		if gjson.Get(host, mapping.propertyID).String() == formatMappedValue(value) {
+			continue
+		}
+
+		setter[mapping.propertyID] = value
+		changed = true
+	}
+	return changed
+}
+
+// formatMappedValue formats the mapped value in the same way as gjson formats the value of the host.
+func formatMappedValue(value interface{}) string {
+	if num, ok := value.(float64); ok {
+		return strconv.FormatFloat(num, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
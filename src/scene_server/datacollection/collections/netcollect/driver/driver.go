@@ -0,0 +1,117 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+// Package driver defines the protocol drivers that pull net device properties directly from the devices,
+// which is used for the devices that can't be collected by the netdevicebeat plugin with snmp.
+package driver
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"configcenter/src/common/metadata"
+)
+
+const (
+	// ProtocolNetconf is protocol of the NETCONF over ssh driver.
+	ProtocolNetconf = "netconf"
+
+	// ProtocolRestconf is protocol of the RESTCONF driver.
+	ProtocolRestconf = "restconf"
+
+	// ProtocolRest is protocol of the vendor REST api driver.
+	ProtocolRest = "rest"
+
+	// ProtocolMock is protocol of the mock driver, it's used for testing.
+	ProtocolMock = "mock"
+)
+
+const (
+	// OptionTimeout is the option of request timeout in seconds.
+	OptionTimeout = "timeout"
+
+	// OptionInsecureSkipVerify is the option that skips verifying the tls certificate or ssh host key.
+	OptionInsecureSkipVerify = "insecure_skip_verify"
+
+	// defaultTimeout is default timeout of collecting a target.
+	defaultTimeout = 30 * time.Second
+)
+
+// Driver pulls the properties of a net device target with a specific protocol.
+type Driver interface {
+	// Collect pulls the values of the properties from the target, the oid of each property is the driver specific
+	// locator of the value. It returns property id -> value, the properties that can't be located are not returned,
+	// and returns error only if the target can't be collected at all.
+	Collect(ctx context.Context, target *metadata.NetcollectTarget,
+		properties []metadata.NetcollectProperty) (map[string]interface{}, error)
+}
+
+var (
+	driverLock sync.RWMutex
+	drivers    = make(map[string]Driver)
+)
+
+// Register registers the driver of the protocol, the registered driver of the same protocol is replaced.
+func Register(protocol string, driver Driver) {
+	driverLock.Lock()
+	defer driverLock.Unlock()
+
+	drivers[protocol] = driver
+}
+
+// GetDriver returns the driver of the protocol.
+func GetDriver(protocol string) (Driver, error) {
+	driverLock.RLock()
+	defer driverLock.RUnlock()
+
+	driver, exists := drivers[protocol]
+	if !exists {
+		return nil, fmt.Errorf("protocol %s is not supported", protocol)
+	}
+	return driver, nil
+}
+
+// Protocols returns all the protocols that have registered drivers.
+func Protocols() []string {
+	driverLock.RLock()
+	defer driverLock.RUnlock()
+
+	protocols := make([]string, 0, len(drivers))
+	for protocol := range drivers {
+		protocols = append(protocols, protocol)
+	}
+	sort.Strings(protocols)
+	return protocols
+}
+
+// timeout returns the collecting timeout of the target.
+func timeout(target *metadata.NetcollectTarget) time.Duration {
+	seconds, err := strconv.Atoi(target.Options[OptionTimeout])
+	if err != nil || seconds <= 0 {
+		return defaultTimeout
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// insecureSkipVerify returns if the target skips verifying the tls certificate or ssh host key.
+func insecureSkipVerify(target *metadata.NetcollectTarget) bool {
+	skip, _ := strconv.ParseBool(target.Options[OptionInsecureSkipVerify])
+	return skip
+}
@@ -0,0 +1,332 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package driver
+
+import (
+	"bufio"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"configcenter/src/common/metadata"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestMockDriver(t *testing.T) {
+	driver, err := GetDriver(ProtocolMock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	target := &metadata.NetcollectTarget{
+		Protocol: ProtocolMock,
+		Options:  map[string]string{"sys.name": "switch-1"},
+	}
+	properties := []metadata.NetcollectProperty{
+		{PropertyID: "bk_inst_name", OID: "sys.name"},
+		{PropertyID: "bk_os", OID: "sys.os"},
+	}
+
+	values, err := driver.Collect(context.Background(), target, properties)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(values, map[string]interface{}{"bk_inst_name": "switch-1"}) {
+		t.Errorf("unexpected values %v", values)
+	}
+
+	target.Options[MockOptionError] = "unreachable"
+	if _, err := driver.Collect(context.Background(), target, properties); err == nil {
+		t.Errorf("mock error option should make collecting fail")
+	}
+
+	if _, err := GetDriver("snmp"); err == nil {
+		t.Errorf("unsupported protocol should return error")
+	}
+}
+
+func newHTTPTarget(t *testing.T, server *httptest.Server, protocol string) *metadata.NetcollectTarget {
+	host, port, err := net.SplitHostPort(strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	portVal, _ := strconv.Atoi(port)
+
+	return &metadata.NetcollectTarget{
+		Address:  host,
+		Port:     portVal,
+		Protocol: protocol,
+		Username: "admin",
+		Password: "secret",
+		Options:  map[string]string{OptionScheme: "http"},
+	}
+}
+
+func TestRestconfDriver(t *testing.T) {
+	requests := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		if user, pwd, ok := r.BasicAuth(); !ok || user != "admin" || pwd != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("Accept") != "application/yang-data+json" {
+			w.WriteHeader(http.StatusNotAcceptable)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/restconf/data/ietf-system:system/hostname":
+			w.Write([]byte(`{"ietf-system:hostname": "switch-1"}`))
+		case "/restconf/data/ietf-system:system-state/platform":
+			w.Write([]byte(`{"ietf-system:platform": {"os-name": "VRP", "os-version": "8.180"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	driver, err := GetDriver(ProtocolRestconf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	properties := []metadata.NetcollectProperty{
+		{PropertyID: "bk_inst_name", OID: "ietf-system:system/hostname"},
+		{PropertyID: "bk_os", OID: "ietf-system:system-state/platform#ietf-system:platform.os-name"},
+		{PropertyID: "bk_os_version", OID: "ietf-system:system-state/platform#ietf-system:platform.os-version"},
+		{PropertyID: "bk_sn", OID: "ietf-hardware:hardware/serial-num"},
+	}
+	values, err := driver.Collect(context.Background(), newHTTPTarget(t, server, ProtocolRestconf), properties)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"bk_inst_name":  "switch-1",
+		"bk_os":         "VRP",
+		"bk_os_version": "8.180",
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("unexpected values %v", values)
+	}
+	if requests["/restconf/data/ietf-system:system-state/platform"] != 1 {
+		t.Errorf("the same path should be requested only once, requests: %v", requests)
+	}
+}
+
+func TestRestDriver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Auth-Token") != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/api/v1/device/info":
+			w.Write([]byte(`{"result": {"name": "switch-2", "ports": [{"id": 1}, {"id": 2}], "mem": 4096}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	driver, err := GetDriver(ProtocolRest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	target := newHTTPTarget(t, server, ProtocolRest)
+	target.Options[OptionBasePath] = "/api/v1/"
+	target.Options[OptionHeaderPrefix+"X-Auth-Token"] = "token"
+
+	properties := []metadata.NetcollectProperty{
+		{PropertyID: "bk_inst_name", OID: "device/info#result.name"},
+		{PropertyID: "port_num", OID: "device/info#result.ports.#"},
+		{PropertyID: "mem", OID: "/device/info#result.mem"},
+	}
+	values, err := driver.Collect(context.Background(), target, properties)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"bk_inst_name": "switch-2",
+		"port_num":     float64(2),
+		"mem":          float64(4096),
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("unexpected values %v", values)
+	}
+
+	// the target can't be connected.
+	server.Close()
+	if _, err := driver.Collect(context.Background(), target, properties); err == nil {
+		t.Errorf("collecting closed server should return error")
+	}
+}
+
+const testNetconfReply = `<?xml version="1.0" encoding="UTF-8"?>
+<rpc-reply message-id="1" xmlns="urn:ietf:params:xml:ns:netconf:base:1.0">
+  <data>
+    <system xmlns="urn:ietf:params:xml:ns:yang:ietf-system">
+      <hostname>switch-3</hostname>
+    </system>
+    <interfaces xmlns="urn:ietf:params:xml:ns:yang:ietf-interfaces">
+      <interface><name>GE1/0/1</name></interface>
+      <interface><name>GE1/0/2</name></interface>
+    </interfaces>
+  </data>
+</rpc-reply>`
+
+func TestParseNetconfReply(t *testing.T) {
+	data, err := parseNetconfReply([]byte(testNetconfReply))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if value, exists := data.value("/sys:system/sys:hostname"); !exists || value != "switch-3" {
+		t.Errorf("unexpected hostname %v", value)
+	}
+	value, exists := data.value("interfaces/interface/name")
+	if !exists || !reflect.DeepEqual(value, []interface{}{"GE1/0/1", "GE1/0/2"}) {
+		t.Errorf("unexpected interface names %v", value)
+	}
+	if _, exists := data.value("interfaces/interface"); exists {
+		t.Errorf("container element should not have value")
+	}
+
+	errReply := `<rpc-reply xmlns="urn:ietf:params:xml:ns:netconf:base:1.0"><rpc-error>
+<error-type>protocol</error-type><error-message>access denied</error-message></rpc-error></rpc-reply>`
+	if _, err := parseNetconfReply([]byte(errReply)); err == nil || !strings.Contains(err.Error(), "access denied") {
+		t.Errorf("unexpected rpc error %v", err)
+	}
+}
+
+// serveNetconf serves a NETCONF over ssh session that replies the get operation with testNetconfReply.
+func serveNetconf(t *testing.T, listener net.Listener, config *ssh.ServerConfig) {
+	conn, err := listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for newChan := range chans {
+		channel, requests, err := newChan.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			for req := range requests {
+				req.Reply(req.Type == "subsystem" && string(req.Payload[4:]) == "netconf", nil)
+			}
+		}()
+
+		reader := bufio.NewReader(channel)
+		if _, err := readNetconfMessage(reader); err != nil {
+			t.Errorf("read client hello failed, err: %v", err)
+			return
+		}
+		writeNetconfMessage(channel, netconfHello)
+
+		rpc, err := readNetconfMessage(reader)
+		if err != nil || !strings.Contains(string(rpc), `<filter type="subtree"><system/></filter>`) {
+			t.Errorf("unexpected get rpc %s, err: %v", rpc, err)
+			return
+		}
+		writeNetconfMessage(channel, testNetconfReply)
+		readNetconfMessage(reader)
+		channel.Close()
+	}
+}
+
+func TestNetconfDriver(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if conn.User() != "admin" || string(password) != "secret" {
+				return nil, ssh.ErrNoAuth
+			}
+			return nil, nil
+		},
+	}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go serveNetconf(t, listener, config)
+
+	driver, err := GetDriver(ProtocolNetconf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	target := &metadata.NetcollectTarget{
+		Address:  "127.0.0.1",
+		Port:     listener.Addr().(*net.TCPAddr).Port,
+		Protocol: ProtocolNetconf,
+		Username: "admin",
+		Password: "secret",
+		Options: map[string]string{
+			OptionHostKey: string(ssh.MarshalAuthorizedKey(signer.PublicKey())),
+			OptionFilter:  "<system/>",
+		},
+	}
+	properties := []metadata.NetcollectProperty{
+		{PropertyID: "bk_inst_name", OID: "system/hostname"},
+		{PropertyID: "bk_sn", OID: "hardware/serial-num"},
+	}
+
+	values, err := driver.Collect(context.Background(), target, properties)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(values, map[string]interface{}{"bk_inst_name": "switch-3"}) {
+		t.Errorf("unexpected values %v", values)
+	}
+
+	// host key is required to connect.
+	delete(target.Options, OptionHostKey)
+	if _, err := driver.Collect(context.Background(), target, properties); err == nil {
+		t.Errorf("collecting without host key should return error")
+	}
+}
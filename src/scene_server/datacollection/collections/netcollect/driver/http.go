@@ -0,0 +1,211 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package driver
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// OptionScheme is the option of http scheme, default is https.
+	OptionScheme = "scheme"
+
+	// OptionBasePath is the option of the path prefix of all the requests.
+	OptionBasePath = "base_path"
+
+	// OptionHeaderPrefix is the prefix of the options that are set as request headers, e.g. "header.X-Auth-Token".
+	OptionHeaderPrefix = "header."
+
+	// locatorSelectorSep separates the request path and the gjson selector of the value in a locator.
+	locatorSelectorSep = "#"
+
+	// maxResponseSize is the max size of a response body that is read.
+	maxResponseSize = 10 << 20
+)
+
+func init() {
+	Register(ProtocolRestconf, &httpDriver{
+		defaultBasePath: "/restconf/data",
+		accept:          "application/yang-data+json",
+		unwrap:          true,
+	})
+	Register(ProtocolRest, &httpDriver{
+		accept: "application/json",
+	})
+}
+
+// httpDriver pulls property values with http GET requests, it's used for RESTCONF and vendor REST apis.
+// The locator of a property is "<path>#<selector>", path is the request path after the base path, and the
+// optional selector is the gjson path of the value in the json response. The responses of the same path
+// are requested only once in a collecting.
+type httpDriver struct {
+	// defaultBasePath is the base path of the requests if it's not set in options.
+	defaultBasePath string
+	// accept is the accept header of the requests.
+	accept string
+	// unwrap defines whether to use the value of the only field as the property value if the selector is not
+	// set, RESTCONF wraps the requested data node with its qualified name, e.g. {"ietf-system:hostname": "sw1"}.
+	unwrap bool
+}
+
+// Collect pulls the values of the properties with http requests.
+func (d *httpDriver) Collect(ctx context.Context, target *metadata.NetcollectTarget,
+	properties []metadata.NetcollectProperty) (map[string]interface{}, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, timeout(target))
+	defer cancel()
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: insecureSkipVerify(target)},
+		},
+	}
+	defer client.CloseIdleConnections()
+
+	responses := make(map[string][]byte)
+	values := make(map[string]interface{})
+	for _, property := range properties {
+		path, selector := splitLocator(property.OID)
+
+		body, exists := responses[path]
+		if !exists {
+			var err error
+			body, err = d.get(ctx, client, target, path)
+			if err != nil {
+				// the target can't be connected, no need to try the other properties.
+				if _, ok := err.(net.Error); ok || ctx.Err() != nil {
+					return nil, err
+				}
+				blog.Errorf("get property %s of target %s from path %s failed, err: %v", property.PropertyID,
+					target.Address, path, err)
+				continue
+			}
+			responses[path] = body
+		}
+
+		value, exists := d.selectValue(body, selector)
+		if !exists {
+			blog.V(4).Infof("property %s of target %s is not found in path %s", property.PropertyID,
+				target.Address, path)
+			continue
+		}
+		values[property.PropertyID] = value
+	}
+
+	return values, nil
+}
+
+// get requests the path of the target and returns the response body.
+func (d *httpDriver) get(ctx context.Context, client *http.Client, target *metadata.NetcollectTarget,
+	path string) ([]byte, error) {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.url(target, path), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", d.accept)
+	if target.Username != "" {
+		req.SetBasicAuth(target.Username, target.Password)
+	}
+	for key, value := range target.Options {
+		if strings.HasPrefix(key, OptionHeaderPrefix) {
+			req.Header.Set(strings.TrimPrefix(key, OptionHeaderPrefix), value)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("http status %d, body: %s", resp.StatusCode, body)
+	}
+	return body, nil
+}
+
+// url returns the request url of the path.
+func (d *httpDriver) url(target *metadata.NetcollectTarget, path string) string {
+	scheme := target.Options[OptionScheme]
+	if scheme == "" {
+		scheme = "https"
+	}
+
+	host := target.Address
+	if target.Port > 0 {
+		host = net.JoinHostPort(target.Address, strconv.Itoa(target.Port))
+	}
+
+	basePath, exists := target.Options[OptionBasePath]
+	if !exists {
+		basePath = d.defaultBasePath
+	}
+
+	urlPath := "/" + strings.TrimLeft(path, "/")
+	if basePath = strings.Trim(basePath, "/"); basePath != "" {
+		urlPath = "/" + basePath + urlPath
+	}
+	return fmt.Sprintf("%s://%s%s", scheme, host, urlPath)
+}
+
+// selectValue selects the property value from the json response.
+func (d *httpDriver) selectValue(body []byte, selector string) (interface{}, bool) {
+	if selector != "" {
+		result := gjson.GetBytes(body, selector)
+		return result.Value(), result.Exists()
+	}
+
+	result := gjson.ParseBytes(body)
+	if d.unwrap && result.IsObject() {
+		fields := result.Map()
+		if len(fields) == 1 {
+			for _, field := range fields {
+				return field.Value(), true
+			}
+		}
+	}
+	return result.Value(), result.Exists()
+}
+
+// splitLocator splits the locator into request path and value selector.
+func splitLocator(locator string) (string, string) {
+	index := strings.Index(locator, locatorSelectorSep)
+	if index < 0 {
+		return locator, ""
+	}
+	return locator[:index], locator[index+1:]
+}
@@ -0,0 +1,52 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package driver
+
+import (
+	"context"
+	"errors"
+
+	"configcenter/src/common/metadata"
+)
+
+// MockOptionError is the option of mock driver that makes collecting fail with the error message.
+const MockOptionError = "mock_error"
+
+func init() {
+	Register(ProtocolMock, &mockDriver{})
+}
+
+// mockDriver returns the option of the target whose key is the oid of the property as the property value,
+// so the whole collecting pipeline can be tested without a real device.
+type mockDriver struct{}
+
+// Collect returns the mock values of the properties from the target options.
+func (d *mockDriver) Collect(_ context.Context, target *metadata.NetcollectTarget,
+	properties []metadata.NetcollectProperty) (map[string]interface{}, error) {
+
+	if errMsg := target.Options[MockOptionError]; errMsg != "" {
+		return nil, errors.New(errMsg)
+	}
+
+	values := make(map[string]interface{})
+	for _, property := range properties {
+		if value, exists := target.Options[property.OID]; exists {
+			values[property.PropertyID] = value
+		}
+	}
+	return values, nil
+}
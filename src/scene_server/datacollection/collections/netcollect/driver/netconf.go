@@ -0,0 +1,342 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package driver
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// OptionHostKey is the option of the ssh host public key of the target, in authorized_keys format.
+	OptionHostKey = "host_key"
+
+	// OptionFilter is the option of the subtree filter of the NETCONF <get> operation.
+	OptionFilter = "filter"
+
+	// netconfDefaultPort is the default port of NETCONF over ssh.
+	netconfDefaultPort = 830
+
+	// netconfDelimiter is the end of message delimiter of NETCONF base:1.0 framing.
+	netconfDelimiter = "]]>]]>"
+
+	// netconfMaxMessageSize is the max size of a NETCONF message that is read.
+	netconfMaxMessageSize = 10 << 20
+
+	netconfHello = `<?xml version="1.0" encoding="UTF-8"?>` +
+		`<hello xmlns="urn:ietf:params:xml:ns:netconf:base:1.0"><capabilities>` +
+		`<capability>urn:ietf:params:netconf:base:1.0</capability></capabilities></hello>`
+
+	netconfGet = `<?xml version="1.0" encoding="UTF-8"?>` +
+		`<rpc message-id="1" xmlns="urn:ietf:params:xml:ns:netconf:base:1.0"><get>%s</get></rpc>`
+
+	netconfClose = `<?xml version="1.0" encoding="UTF-8"?>` +
+		`<rpc message-id="2" xmlns="urn:ietf:params:xml:ns:netconf:base:1.0"><close-session/></rpc>`
+)
+
+func init() {
+	Register(ProtocolNetconf, &netconfDriver{})
+}
+
+// netconfDriver pulls property values with the NETCONF <get> operation over ssh, only base:1.0 end of message
+// framing is supported. The locator of a property is the slash separated element path of the value in the
+// <data> of the reply, e.g. "system/hostname", namespace prefixes of the elements are ignored. If there are
+// several elements matching the path, the property value is the list of their values.
+type netconfDriver struct{}
+
+// Collect pulls the values of the properties with NETCONF.
+func (d *netconfDriver) Collect(ctx context.Context, target *metadata.NetcollectTarget,
+	properties []metadata.NetcollectProperty) (map[string]interface{}, error) {
+
+	reply, err := d.get(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := parseNetconfReply(reply)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]interface{})
+	for _, property := range properties {
+		value, exists := data.value(property.OID)
+		if !exists {
+			blog.V(4).Infof("property %s of target %s is not found in path %s", property.PropertyID,
+				target.Address, property.OID)
+			continue
+		}
+		values[property.PropertyID] = value
+	}
+	return values, nil
+}
+
+// get connects to the target and returns the reply of the <get> operation.
+func (d *netconfDriver) get(ctx context.Context, target *metadata.NetcollectTarget) ([]byte, error) {
+	hostKeyCallback, err := netconfHostKeyCallback(target)
+	if err != nil {
+		return nil, err
+	}
+
+	port := target.Port
+	if port <= 0 {
+		port = netconfDefaultPort
+	}
+	addr := net.JoinHostPort(target.Address, strconv.Itoa(port))
+	deadline := time.Now().Add(timeout(target))
+
+	dialer := &net.Dialer{Deadline: deadline}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	// the deadline limits the whole netconf session.
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	config := &ssh.ClientConfig{
+		User: target.Username,
+		Auth: []ssh.AuthMethod{
+			ssh.Password(target.Password),
+			ssh.KeyboardInteractive(func(_, _ string, questions []string, _ []bool) ([]string, error) {
+				answers := make([]string, len(questions))
+				for i := range answers {
+					answers[i] = target.Password
+				}
+				return answers, nil
+			}),
+		},
+		HostKeyCallback: hostKeyCallback,
+	}
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if err != nil {
+		return nil, err
+	}
+	client := ssh.NewClient(sshConn, chans, reqs)
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	writer, err := session.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	reader := bufio.NewReader(stdout)
+
+	if err := session.RequestSubsystem("netconf"); err != nil {
+		return nil, err
+	}
+
+	// exchange hello messages, the capabilities of the server are not used.
+	if err := writeNetconfMessage(writer, netconfHello); err != nil {
+		return nil, err
+	}
+	if _, err := readNetconfMessage(reader); err != nil {
+		return nil, fmt.Errorf("read netconf hello failed, err: %v", err)
+	}
+
+	filter := ""
+	if subtree := target.Options[OptionFilter]; subtree != "" {
+		filter = `<filter type="subtree">` + subtree + `</filter>`
+	}
+	if err := writeNetconfMessage(writer, fmt.Sprintf(netconfGet, filter)); err != nil {
+		return nil, err
+	}
+	reply, err := readNetconfMessage(reader)
+	if err != nil {
+		return nil, fmt.Errorf("read netconf get reply failed, err: %v", err)
+	}
+
+	// close the session gracefully, the error is ignored since the reply is already received.
+	_ = writeNetconfMessage(writer, netconfClose)
+
+	return reply, nil
+}
+
+// netconfHostKeyCallback returns the ssh host key callback of the target.
+func netconfHostKeyCallback(target *metadata.NetcollectTarget) (ssh.HostKeyCallback, error) {
+	if hostKey := target.Options[OptionHostKey]; hostKey != "" {
+		key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(hostKey))
+		if err != nil {
+			return nil, fmt.Errorf("parse host key failed, err: %v", err)
+		}
+		return ssh.FixedHostKey(key), nil
+	}
+
+	if insecureSkipVerify(target) {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+	return nil, fmt.Errorf("option %s or %s is required", OptionHostKey, OptionInsecureSkipVerify)
+}
+
+// writeNetconfMessage writes a NETCONF message with the end of message delimiter.
+func writeNetconfMessage(writer io.Writer, message string) error {
+	_, err := io.WriteString(writer, message+netconfDelimiter)
+	return err
+}
+
+// readNetconfMessage reads a NETCONF message until the end of message delimiter.
+func readNetconfMessage(reader *bufio.Reader) ([]byte, error) {
+	message := make([]byte, 0)
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		message = append(message, b)
+
+		if bytes.HasSuffix(message, []byte(netconfDelimiter)) {
+			return message[:len(message)-len(netconfDelimiter)], nil
+		}
+		if len(message) > netconfMaxMessageSize {
+			return nil, errors.New("netconf message is too large")
+		}
+	}
+}
+
+// xmlNode is an element of the xml document.
+type xmlNode struct {
+	name     string
+	text     string
+	children []*xmlNode
+}
+
+// parseNetconfReply parses the <rpc-reply> and returns its <data> element.
+func parseNetconfReply(reply []byte) (*xmlNode, error) {
+	root, err := parseXML(reply)
+	if err != nil {
+		return nil, fmt.Errorf("parse netconf reply failed, err: %v", err)
+	}
+
+	if rpcErrors := root.find([]string{"rpc-reply", "rpc-error"}); len(rpcErrors) > 0 {
+		messages := make([]string, 0)
+		for _, rpcError := range rpcErrors {
+			for _, message := range rpcError.find([]string{"error-message"}) {
+				messages = append(messages, strings.TrimSpace(message.text))
+			}
+		}
+		return nil, fmt.Errorf("netconf rpc error: %s", strings.Join(messages, "; "))
+	}
+
+	data := root.find([]string{"rpc-reply", "data"})
+	if len(data) == 0 {
+		return nil, errors.New("netconf reply has no data")
+	}
+	return data[0], nil
+}
+
+// parseXML parses the xml document into a tree whose root is a virtual node containing the document element.
+func parseXML(document []byte) (*xmlNode, error) {
+	root := &xmlNode{}
+	stack := []*xmlNode{root}
+
+	decoder := xml.NewDecoder(bytes.NewReader(document))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		parent := stack[len(stack)-1]
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{name: t.Name.Local}
+			parent.children = append(parent.children, node)
+			stack = append(stack, node)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			parent.text += string(t)
+		}
+	}
+
+	if len(stack) != 1 {
+		return nil, errors.New("xml document is not complete")
+	}
+	return root, nil
+}
+
+// find returns the descendant elements that match the path of element names.
+func (n *xmlNode) find(path []string) []*xmlNode {
+	if len(path) == 0 {
+		return []*xmlNode{n}
+	}
+
+	nodes := make([]*xmlNode, 0)
+	for _, child := range n.children {
+		if child.name == path[0] {
+			nodes = append(nodes, child.find(path[1:])...)
+		}
+	}
+	return nodes
+}
+
+// value returns the text value of the leaf elements that match the locator.
+func (n *xmlNode) value(locator string) (interface{}, bool) {
+	path := make([]string, 0)
+	for _, name := range strings.Split(strings.Trim(locator, "/"), "/") {
+		if index := strings.Index(name, ":"); index >= 0 {
+			name = name[index+1:]
+		}
+		path = append(path, name)
+	}
+
+	values := make([]interface{}, 0)
+	for _, node := range n.find(path) {
+		if len(node.children) > 0 {
+			continue
+		}
+		values = append(values, strings.TrimSpace(node.text))
+	}
+
+	switch len(values) {
+	case 0:
+		return nil, false
+	case 1:
+		return values[0], true
+	default:
+		return values, true
+	}
+}
@@ -0,0 +1,294 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package netcollect
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/cryptor"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/datacollection/collections"
+	"configcenter/src/scene_server/datacollection/collections/netcollect/driver"
+	"configcenter/src/storage/dal"
+)
+
+const (
+	// DriverMessageType is type of the netcollect message that is built from the driver collecting result.
+	DriverMessageType = "netdriver"
+
+	// defaultDriverScanInterval is default interval for scanning the targets that need to be collected.
+	defaultDriverScanInterval = time.Minute
+
+	// maxDriverCollectConcurrency is max number of the targets that are collected at the same time.
+	maxDriverCollectConcurrency = 10
+)
+
+// Collect pulls the properties of the net device from the target with the driver of its protocol,
+// and returns the report of the net device instance. The password of the target is decrypted with
+// the cryptor only for the driver, cryptor is nil if the password is saved in plaintext.
+func Collect(ctx context.Context, db dal.RDB, crypto cryptor.Cryptor, target *metadata.NetcollectTarget) (
+	*metadata.NetcollectReport, error) {
+
+	cond := map[string]interface{}{
+		common.BKDeviceIDField: target.DeviceID,
+		common.BKOwnerIDField:  target.OwnerID,
+	}
+
+	device := new(metadata.NetcollectDevice)
+	if err := db.Table(common.BKTableNameNetcollectDevice).Find(cond).One(ctx, device); err != nil {
+		if db.IsNotFoundError(err) {
+			return nil, fmt.Errorf("net device %d does not exist", target.DeviceID)
+		}
+		return nil, err
+	}
+
+	properties := make([]metadata.NetcollectProperty, 0)
+	if err := db.Table(common.BKTableNameNetcollectProperty).Find(cond).All(ctx, &properties); err != nil {
+		return nil, err
+	}
+	if len(properties) == 0 {
+		return nil, fmt.Errorf("net device %d has no property to collect", target.DeviceID)
+	}
+
+	drv, err := driver.GetDriver(target.Protocol)
+	if err != nil {
+		return nil, err
+	}
+
+	driverTarget, err := DecryptTarget(crypto, target)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := drv.Collect(ctx, driverTarget, properties)
+	if err != nil {
+		return nil, err
+	}
+
+	return newTargetReport(target, device, properties, values), nil
+}
+
+// DecryptTarget returns a copy of the target with the decrypted password, the target itself is not changed.
+func DecryptTarget(crypto cryptor.Cryptor, target *metadata.NetcollectTarget) (*metadata.NetcollectTarget, error) {
+	decrypted := *target
+	if crypto == nil || target.Password == "" {
+		return &decrypted, nil
+	}
+
+	password, err := crypto.Decrypt(target.Password)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt password of net device target %d failed, %v", target.TargetID, err)
+	}
+	decrypted.Password = password
+	return &decrypted, nil
+}
+
+// newTargetReport builds the report of the net device instance from the collected property values.
+func newTargetReport(target *metadata.NetcollectTarget, device *metadata.NetcollectDevice,
+	properties []metadata.NetcollectProperty, values map[string]interface{}) *metadata.NetcollectReport {
+
+	instKey := target.InstKey
+	if instKey == "" {
+		instKey = target.Address
+	}
+
+	report := &metadata.NetcollectReport{
+		CloudID:      target.CloudID,
+		ObjectID:     device.ObjectID,
+		InnerIP:      target.Address,
+		OwnerID:      target.OwnerID,
+		InstKey:      instKey,
+		LastTime:     metadata.Now(),
+		Attributes:   make([]metadata.NetcollectReportAttribute, 0),
+		Associations: make([]metadata.NetcollectReportAssociation, 0),
+	}
+
+	hasInstName := false
+	for _, property := range properties {
+		value, exists := values[property.PropertyID]
+		if !exists {
+			continue
+		}
+		if property.PropertyID == common.BKInstNameField {
+			hasInstName = true
+		}
+		report.Attributes = append(report.Attributes, metadata.NetcollectReportAttribute{
+			PropertyID: property.PropertyID,
+			CurValue:   value,
+		})
+	}
+
+	// the instance is matched by its name when the report is confirmed, so the name must be reported.
+	if !hasInstName && device.ObjectID != common.BKInnerObjIDHost {
+		report.Attributes = append(report.Attributes, metadata.NetcollectReportAttribute{
+			PropertyID: common.BKInstNameField,
+			CurValue:   instKey,
+		})
+	}
+
+	return report
+}
+
+// UpdateTargetStatus saves the collecting status of the target.
+func UpdateTargetStatus(ctx context.Context, db dal.RDB, targetID uint64, collectErr error) error {
+	now := time.Now()
+	status := metadata.NetcollectTargetStatus{LastCollectTime: &now}
+	if collectErr != nil {
+		status.Error = collectErr.Error()
+	}
+
+	cond := map[string]interface{}{"target_id": targetID}
+	return db.Table(common.BKTableNameNetcollectTarget).Update(ctx, cond, map[string]interface{}{"status": status})
+}
+
+// driverMessage is the netcollect message built from the driver collecting result, cloudid and ip
+// are used for the message sharding of the porter.
+type driverMessage struct {
+	CloudID string `json:"cloudid"`
+	IP      string `json:"ip"`
+	ReportMessage
+}
+
+// driverSource is the message source of the netcollect porter that pulls the properties of the net device
+// targets with protocol drivers periodically. Each datacollection node only collects the targets that are
+// sharded to itself, and the collecting results are handled by the netcollect analyzer as reported messages.
+type driverSource struct {
+	ctx      context.Context
+	db       dal.RDB
+	hash     *collections.Hash
+	crypto   cryptor.Cryptor
+	interval time.Duration
+}
+
+// NewDriverSource creates a new Source that pulls net device properties with protocol drivers.
+func NewDriverSource(ctx context.Context, db dal.RDB, hash *collections.Hash,
+	crypto cryptor.Cryptor) collections.Source {
+
+	return &driverSource{ctx: ctx, db: db, hash: hash, crypto: crypto, interval: defaultDriverScanInterval}
+}
+
+// Name returns name of the driver source.
+func (s *driverSource) Name() string {
+	return "driver"
+}
+
+// Sharded returns true as the source only collects the targets that are sharded to this node.
+func (s *driverSource) Sharded() bool {
+	return true
+}
+
+// Receive keeps scanning the targets and collecting the ones that reach their collecting period.
+func (s *driverSource) Receive(handler collections.MessageHandler) error {
+	blog.Infof("DriverSource| start collecting net device targets with drivers %v now!", driver.Protocols())
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.scan(handler); err != nil {
+			blog.Errorf("DriverSource| scan net device targets failed, %v", err)
+		}
+		<-ticker.C
+	}
+}
+
+// scan collects the targets that are sharded to this node and reach their collecting period.
+func (s *driverSource) scan(handler collections.MessageHandler) error {
+	targets := make([]metadata.NetcollectTarget, 0)
+	if err := s.db.Table(common.BKTableNameNetcollectTarget).Find(nil).All(s.ctx, &targets); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	pipeline := make(chan struct{}, maxDriverCollectConcurrency)
+	wg := sync.WaitGroup{}
+
+	for index := range targets {
+		target := &targets[index]
+
+		period, err := util.PeriodToDuration(target.Period)
+		if err != nil || period == 0 {
+			// the target is collected manually.
+			continue
+		}
+		if target.Status.LastCollectTime != nil && target.Status.LastCollectTime.Add(period).After(now) {
+			continue
+		}
+
+		// the same hash key as the netcollect analyzer, so the message would not be ignored by the porter.
+		if !s.hash.IsMatch(fmt.Sprintf("%d:%s", target.CloudID, target.Address)) {
+			continue
+		}
+
+		pipeline <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-pipeline
+				wg.Done()
+			}()
+			s.collect(target, handler)
+		}()
+	}
+
+	wg.Wait()
+	return nil
+}
+
+// collect collects the target and handles the result as a netcollect message.
+func (s *driverSource) collect(target *metadata.NetcollectTarget, handler collections.MessageHandler) {
+	err := s.handleTarget(target, handler)
+	if err != nil {
+		blog.Errorf("DriverSource| collect net device target[%d] %s with %s driver failed, %v", target.TargetID,
+			target.Address, target.Protocol, err)
+	}
+
+	if err := UpdateTargetStatus(s.ctx, s.db, target.TargetID, err); err != nil {
+		blog.Errorf("DriverSource| update status of net device target[%d] failed, %v", target.TargetID, err)
+	}
+}
+
+func (s *driverSource) handleTarget(target *metadata.NetcollectTarget, handler collections.MessageHandler) error {
+	report, err := Collect(s.ctx, s.db, s.crypto, target)
+	if err != nil {
+		return err
+	}
+
+	message := driverMessage{
+		CloudID: strconv.FormatInt(target.CloudID, 10),
+		IP:      target.Address,
+		ReportMessage: ReportMessage{
+			Timestamp: time.Now(),
+			Type:      DriverMessageType,
+			Data:      []metadata.NetcollectReport{*report},
+		},
+	}
+	js, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	return handler(string(js))
+}
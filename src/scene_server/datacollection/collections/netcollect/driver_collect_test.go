@@ -0,0 +1,94 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package netcollect
+
+import (
+	"encoding/json"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/cryptor"
+	"configcenter/src/common/metadata"
+)
+
+func TestNewTargetReport(t *testing.T) {
+	target := &metadata.NetcollectTarget{
+		CloudID: 1,
+		Address: "10.0.0.1",
+		OwnerID: common.BKDefaultOwnerID,
+	}
+	device := &metadata.NetcollectDevice{DeviceID: 1, ObjectID: "bk_switch"}
+	properties := []metadata.NetcollectProperty{
+		{PropertyID: "bk_os", OID: "system/os"},
+		{PropertyID: "bk_sn", OID: "system/sn"},
+	}
+
+	report := newTargetReport(target, device, properties, map[string]interface{}{"bk_os": "VRP"})
+	if report.InstKey != target.Address || report.InnerIP != target.Address || report.CloudID != 1 ||
+		report.ObjectID != "bk_switch" {
+		t.Fatalf("unexpected report %+v", report)
+	}
+
+	values := make(map[string]interface{})
+	for _, attr := range report.Attributes {
+		values[attr.PropertyID] = attr.CurValue
+	}
+	if len(values) != 2 || values["bk_os"] != "VRP" || values[common.BKInstNameField] != target.Address {
+		t.Errorf("unexpected report attributes %v", values)
+	}
+
+	// the message must be able to be sharded and analyzed by the netcollect porter.
+	js, err := json.Marshal(driverMessage{CloudID: "1", IP: target.Address,
+		ReportMessage: ReportMessage{Type: DriverMessageType, Data: []metadata.NetcollectReport{*report}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := make(map[string]interface{})
+	if err := json.Unmarshal(js, &message); err != nil {
+		t.Fatal(err)
+	}
+	if message["cloudid"] != "1" || message["ip"] != target.Address || message["type"] != DriverMessageType {
+		t.Errorf("unexpected driver message %s", js)
+	}
+}
+
+func TestDecryptTarget(t *testing.T) {
+	crypto := cryptor.NewAesEncrpytor("123456781234567812345678")
+	encrypted, err := crypto.Encrypt("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	target := &metadata.NetcollectTarget{TargetID: 1, Password: encrypted}
+	decrypted, err := DecryptTarget(crypto, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decrypted.Password != "secret" || target.Password != encrypted {
+		t.Errorf("unexpected decrypted password %s, target password %s", decrypted.Password, target.Password)
+	}
+
+	// password is saved in plaintext when crypto is not enabled.
+	plain, err := DecryptTarget(nil, &metadata.NetcollectTarget{Password: "secret"})
+	if err != nil || plain.Password != "secret" {
+		t.Errorf("unexpected plaintext password %s, err: %v", plain.Password, err)
+	}
+
+	if _, err := DecryptTarget(crypto, &metadata.NetcollectTarget{Password: "invalid"}); err == nil {
+		t.Error("decrypt invalid password should fail")
+	}
+}
@@ -88,7 +88,7 @@ func (h *NetCollect) Analyze(msg *string, sourceType string) (bool, error) {
 
 func (h *NetCollect) handleReport(report *metadata.NetcollectReport) (err error) {
 	// TODO compare 若有变化才插入
-	if err = h.upsertReport(report); err != nil {
+	if err = UpsertReport(h.ctx, h.db, report); err != nil {
 		blog.Errorf("[data-collection][netcollect] upsert association error: %v", err)
 		return err
 	}
@@ -96,23 +96,24 @@ func (h *NetCollect) handleReport(report *metadata.NetcollectReport) (err error)
 	return nil
 }
 
-func (h *NetCollect) upsertReport(report *metadata.NetcollectReport) error {
+// UpsertReport saves the report of the net device instance, the existing report of the instance is replaced.
+func UpsertReport(ctx context.Context, db dal.RDB, report *metadata.NetcollectReport) error {
 	existFilter := map[string]interface{}{
 		common.BKCloudIDField: report.CloudID,
 		common.BKObjIDField:   report.ObjectID,
 		common.BKInstKeyField: report.InstKey,
 	}
 
-	count, err := h.db.Table(common.BKTableNameNetcollectReport).Find(existFilter).Count(h.ctx)
+	count, err := db.Table(common.BKTableNameNetcollectReport).Find(existFilter).Count(ctx)
 	if err != nil {
 		return err
 	}
 	if count <= 0 {
-		err = h.db.Table(common.BKTableNameNetcollectReport).Insert(h.ctx, report)
+		err = db.Table(common.BKTableNameNetcollectReport).Insert(ctx, report)
 		return err
 	}
 
-	return h.db.Table(common.BKTableNameNetcollectReport).Update(h.ctx, existFilter, report)
+	return db.Table(common.BKTableNameNetcollectReport).Update(ctx, existFilter, report)
 }
 
 // ReportMessage define a netcollect message
@@ -87,12 +87,12 @@ type SimplePorter struct {
 	// msgChan is message channel that analyzer consumes from.
 	msgChan chan *string
 
-	// message channel redis, read collector data
-	// from it by subscribe target topics.
+	// redisCli is used for compensating host snapshot with the messages in the delay queue,
+	// the delay queue is disabled if it's nil.
 	redisCli redis.Client
 
-	// collectors message channels redis topics.
-	topics []string
+	// sources are message sources that porter receives collector messages from.
+	sources []Source
 
 	// metrics.
 	// receiveTotal is message received total stat.
@@ -122,10 +122,19 @@ type SimplePorter struct {
 	needDebug bool
 }
 
-// NewSimplePorter creates a new SimplePorter object.
+// NewSimplePorter creates a new SimplePorter object that receives message from redis topics.
 func NewSimplePorter(name string, engine *backbone.Engine, hash *Hash, analyzer Analyzer,
 	redisCli redis.Client, topics []string, registry prometheus.Registerer) *SimplePorter {
 
+	return NewSimplePorterWithSources(name, engine, hash, analyzer, redisCli, registry,
+		NewRedisSource(redisCli, topics))
+}
+
+// NewSimplePorterWithSources creates a new SimplePorter object that receives message from the sources,
+// redisCli is used for the delay queue of host snapshot, and could be nil if no delay queue is needed.
+func NewSimplePorterWithSources(name string, engine *backbone.Engine, hash *Hash, analyzer Analyzer,
+	redisCli redis.Client, registry prometheus.Registerer, sources ...Source) *SimplePorter {
+
 	return &SimplePorter{
 		name:      name,
 		engine:    engine,
@@ -133,7 +142,7 @@ func NewSimplePorter(name string, engine *backbone.Engine, hash *Hash, analyzer
 		analyzer:  analyzer,
 		msgChan:   make(chan *string, defaultMessageChanSize),
 		redisCli:  redisCli,
-		topics:    topics,
+		sources:   sources,
 		registry:  registry,
 		needDebug: needInternalDebug,
 	}
@@ -328,74 +337,85 @@ func (p *SimplePorter) analyzeLoop() {
 	}
 }
 
-// collectLoop keeps subscribe redis topic and collecting messages from collectors.
+// collectLoop keeps receiving messages from all the sources.
 func (p *SimplePorter) collectLoop() error {
-	for {
-		// subscribe target topics and handle message base on the redis pubsun channel.
-		subChan := p.redisCli.Subscribe(context.Background(), p.topics...)
-		blog.V(4).Infof("SimplePorter[%s]| subscribe topics[%+v] success, receiving message now!", p.name, p.topics)
-
-		// receiving message.
-		for {
-			// ReceiveMessage returns a Message or error ignoring Subscription or Pong
-			// messages. It automatically reconnects to Redis Server and resubscribes
-			// to topics in case of network errors.
-			newMsg, err := subChan.ReceiveMessage()
-			if err != nil {
-				blog.Errorf("SimplePorter[%s]| receive topics[%+v] message failed, %+v", p.name, p.topics, err)
-
-				// internal errors, unsubscribe and try to sub-recv again.
-				subChan.Unsubscribe(p.topics...)
-				subChan.Close()
-				break
+	var wg sync.WaitGroup
+	for _, source := range p.sources {
+		wg.Add(1)
+		go func(source Source) {
+			defer wg.Done()
+
+			blog.Infof("SimplePorter[%s]| start receiving message from source[%s] now!", p.name, source.Name())
+			sharded := source.Sharded()
+			handler := func(message string) error {
+				return p.receive(message, sharded)
+			}
+			if err := source.Receive(handler); err != nil {
+				blog.Errorf("SimplePorter[%s]| receive message from source[%s] failed, %+v", p.name,
+					source.Name(), err)
 			}
-			// metrics stats for message receiving.
-			p.receiveTotal.Inc()
+		}(source)
+	}
+	wg.Wait()
 
-			// ignoring invalid payloads.
-			if len(newMsg.Payload) == 0 {
-				blog.Errorf("SimplePorter[%s]| recved a message with empty payload!", p.name)
+	return nil
+}
 
-				// metrics stats for invalid message.
-				p.receiveInvalidTotal.Inc()
-				continue
-			}
-			// the outermost "ip" field is GSE's control ip. In the gse2.0 scenario, there is no concept of control ip.
-			// at this time, the "ip" field is empty, so the hashkey needs to be combined in order to be compatible with
-			//different scenarios ip and bk_agent_id
-			ip := gjson.Get(newMsg.Payload, "ip").String()
-			agentID := gjson.Get(newMsg.Payload, "bk_agent_id").String()
-			// message data sharding hashring check.
-			hashKey, err := p.analyzer.Hash(gjson.Get(newMsg.Payload, "cloudid").String(), ip+agentID)
-			if err != nil {
-				blog.Errorf("SimplePorter[%s]| calculates message hash key failed, %+v", p.name, err)
-
-				// metrics stats for invalid message.
-				p.receiveInvalidTotal.Inc()
-				continue
-			}
+// receive handles a message received from sources, it checks the message with sharding hashring
+// if the source is not sharded, and adds it to the analyze channel if it belongs to this node.
+func (p *SimplePorter) receive(message string, sharded bool) error {
+	// metrics stats for message receiving.
+	p.receiveTotal.Inc()
 
-			if !p.hash.IsMatch(hashKey) {
-				// ignore message.
-				continue
-			}
+	// ignoring invalid payloads.
+	if len(message) == 0 {
+		blog.Errorf("SimplePorter[%s]| recved a message with empty payload!", p.name)
+
+		// metrics stats for invalid message.
+		p.receiveInvalidTotal.Inc()
+		return fmt.Errorf("empty message")
+	}
 
-			// metrics stats for suitable sharding message.
-			p.receiveShardingTotal.Inc()
+	if sharded {
+		return p.addReceived(message)
+	}
 
-			if err := p.AddMessage(&newMsg.Payload); err != nil {
-				blog.Errorf("SimplePorter[%s]| add message to analyze, %+v", p.name, err)
+	// the outermost "ip" field is GSE's control ip. In the gse2.0 scenario, there is no concept of control ip.
+	// at this time, the "ip" field is empty, so the hashkey needs to be combined in order to be compatible with
+	//different scenarios ip and bk_agent_id
+	ip := gjson.Get(message, "ip").String()
+	agentID := gjson.Get(message, "bk_agent_id").String()
+	// message data sharding hashring check.
+	hashKey, err := p.analyzer.Hash(gjson.Get(message, "cloudid").String(), ip+agentID)
+	if err != nil {
+		blog.Errorf("SimplePorter[%s]| calculates message hash key failed, %+v", p.name, err)
+
+		// metrics stats for invalid message.
+		p.receiveInvalidTotal.Inc()
+		return err
+	}
 
-				// metrics stats for message sending timeout.
-				p.receiveTimeoutTotal.Inc()
-			}
+	if !p.hash.IsMatch(hashKey) {
+		// ignore message.
+		return nil
+	}
 
-			// end of once message receiving loop.
-			continue
-		}
+	return p.addReceived(message)
+}
+
+// addReceived adds a received message that belongs to this node to the analyze channel.
+func (p *SimplePorter) addReceived(message string) error {
+	// metrics stats for suitable sharding message.
+	p.receiveShardingTotal.Inc()
+
+	if err := p.AddMessage(&message); err != nil {
+		blog.Errorf("SimplePorter[%s]| add message to analyze, %+v", p.name, err)
+
+		// metrics stats for message sending timeout.
+		p.receiveTimeoutTotal.Inc()
+		return err
 	}
 
-	// should no-reach.
 	return nil
 }
 
@@ -472,7 +492,9 @@ func (p *SimplePorter) Run() error {
 	}
 
 	// periodically obtain the data reported by the host from the delay queue for compensation update.
-	go p.getMonitorMsgFromDelayQueue()
+	if p.redisCli != nil {
+		go p.getMonitorMsgFromDelayQueue()
+	}
 
 	// fuse controller.
 	go p.fusing()
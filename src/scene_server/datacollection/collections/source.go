@@ -0,0 +1,291 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package collections
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/storage/dal/redis"
+)
+
+const (
+	// defaultSpoolScanInterval is default interval for scanning spool directory.
+	defaultSpoolScanInterval = 5 * time.Second
+
+	// spoolFailedDir is name of sub directory that saves the spool files which can't be decoded.
+	spoolFailedDir = "failed"
+)
+
+// MessageHandler handles a message received from the source. It returns an error if
+// the message is invalid or can't be added to the analyze queue.
+type MessageHandler func(message string) error
+
+// Source is message source of the porter. Porter receives collector messages from the sources,
+// and handles them with the same sharding, analyzing and fusing logics no matter where they come from.
+type Source interface {
+	// Name returns name of the source.
+	Name() string
+
+	// Receive keeps receiving messages and handles each one of them with the handler, it returns
+	// when the source is passive or can't receive messages anymore.
+	Receive(handler MessageHandler) error
+
+	// Sharded returns whether the messages of the source are only delivered to this node. The porter
+	// checks the messages with sharding hashring only when the source is not sharded, otherwise the
+	// messages that are pushed to this node would be dropped.
+	Sharded() bool
+}
+
+// redisSource receives messages by subscribing redis topics.
+type redisSource struct {
+	// message channel redis, read collector data
+	// from it by subscribe target topics.
+	redisCli redis.Client
+
+	// collectors message channels redis topics.
+	topics []string
+}
+
+// NewRedisSource creates a new Source that receives messages from redis pubsub topics.
+func NewRedisSource(redisCli redis.Client, topics []string) Source {
+	return &redisSource{redisCli: redisCli, topics: topics}
+}
+
+// Name returns name of the redis source.
+func (s *redisSource) Name() string {
+	return "redis"
+}
+
+// Sharded returns false as all the nodes subscribe the same redis topics.
+func (s *redisSource) Sharded() bool {
+	return false
+}
+
+// Receive keeps subscribing redis topics and receiving messages from collectors.
+func (s *redisSource) Receive(handler MessageHandler) error {
+	for {
+		// subscribe target topics and handle message base on the redis pubsub channel.
+		subChan := s.redisCli.Subscribe(context.Background(), s.topics...)
+		blog.V(4).Infof("RedisSource| subscribe topics[%+v] success, receiving message now!", s.topics)
+
+		for {
+			// ReceiveMessage returns a Message or error ignoring Subscription or Pong
+			// messages. It automatically reconnects to Redis Server and resubscribes
+			// to topics in case of network errors.
+			newMsg, err := subChan.ReceiveMessage()
+			if err != nil {
+				blog.Errorf("RedisSource| receive topics[%+v] message failed, %+v", s.topics, err)
+
+				// internal errors, unsubscribe and try to sub-recv again.
+				subChan.Unsubscribe(s.topics...)
+				subChan.Close()
+				break
+			}
+
+			// porter records the invalid message, no need to handle the error here.
+			_ = handler(newMsg.Payload)
+		}
+
+		time.Sleep(defaultReSubscribeWaitDuration)
+	}
+}
+
+// HTTPSource is a passive source, messages are pushed to it by the http ingest endpoint.
+type HTTPSource struct {
+	lock    sync.RWMutex
+	handler MessageHandler
+}
+
+// NewHTTPSource creates a new HTTPSource object.
+func NewHTTPSource() *HTTPSource {
+	return &HTTPSource{}
+}
+
+// Name returns name of the http source.
+func (s *HTTPSource) Name() string {
+	return "http"
+}
+
+// Sharded returns true as the messages are pushed to this node only.
+func (s *HTTPSource) Sharded() bool {
+	return true
+}
+
+// Receive registers the message handler, messages would be handled when they are pushed.
+func (s *HTTPSource) Receive(handler MessageHandler) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.handler = handler
+	return nil
+}
+
+// Push pushes a message to the porter that receives from this source.
+func (s *HTTPSource) Push(message string) error {
+	s.lock.RLock()
+	handler := s.handler
+	s.lock.RUnlock()
+
+	if handler == nil {
+		return errors.New("http source is not ready")
+	}
+	return handler(message)
+}
+
+// spoolSource receives messages by reading files in the spool directory. Each file contains one or more
+// json messages, files are deleted after being handled, and the files that can't be decoded are moved
+// to the failed sub directory. When the handler fails, e.g. the analyze queue is full, the file is kept
+// and retried in the next scan, the messages that have been handled before the failure would be handled
+// again then, which is harmless as each message is a full snapshot of the host. Files whose names start with "." or end with ".tmp" are ignored, so the
+// writer should write to a temporary file and rename it after the writing is done.
+type spoolSource struct {
+	dir      string
+	interval time.Duration
+}
+
+// NewSpoolSource creates a new Source that receives messages from files in the spool directory.
+func NewSpoolSource(dir string) Source {
+	return &spoolSource{dir: dir, interval: defaultSpoolScanInterval}
+}
+
+// Name returns name of the spool source.
+func (s *spoolSource) Name() string {
+	return "spool"
+}
+
+// Sharded returns true as the spool directory is local to this node.
+func (s *spoolSource) Sharded() bool {
+	return true
+}
+
+// Receive keeps scanning the spool directory and handles messages in the files.
+func (s *spoolSource) Receive(handler MessageHandler) error {
+	if err := os.MkdirAll(filepath.Join(s.dir, spoolFailedDir), os.ModePerm); err != nil {
+		return fmt.Errorf("create spool directory %s failed, %v", s.dir, err)
+	}
+	blog.Infof("SpoolSource| start scanning spool directory[%s] now!", s.dir)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.scan(handler); err != nil {
+			blog.Errorf("SpoolSource| scan spool directory[%s] failed, %v", s.dir, err)
+		}
+		<-ticker.C
+	}
+}
+
+// scan handles all the spool files in the directory in the order of file name.
+func (s *spoolSource) scan(handler MessageHandler) error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".tmp") {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		path := filepath.Join(s.dir, name)
+		err := s.handleFile(path, handler)
+		if err != nil {
+			decodeErr := new(decodeError)
+			if !errors.As(err, &decodeErr) {
+				// keep the file and the files after it in the spool, they are retried in the next scan.
+				return fmt.Errorf("handle spool file[%s] failed, retry it later, %v", path, err)
+			}
+
+			blog.Errorf("SpoolSource| decode spool file[%s] failed, move it to failed directory, %v", path, err)
+			if err := os.Rename(path, filepath.Join(s.dir, spoolFailedDir, name)); err != nil {
+				blog.Errorf("SpoolSource| move spool file[%s] to failed directory failed, %v", path, err)
+			}
+			continue
+		}
+
+		if err := os.Remove(path); err != nil {
+			blog.Errorf("SpoolSource| remove handled spool file[%s] failed, %v", path, err)
+		}
+	}
+	return nil
+}
+
+// handleFile decodes json messages in the file one by one and handles them.
+func (s *spoolSource) handleFile(path string, handler MessageHandler) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return DecodeMessages(file, handler)
+}
+
+// decodeError is the error returned by DecodeMessages when the content can't be decoded, which is
+// distinguished from the errors returned by the handler.
+type decodeError struct {
+	err error
+}
+
+// Error returns the message of the decoding error.
+func (e *decodeError) Error() string {
+	return fmt.Sprintf("decode message failed, %v", e.err)
+}
+
+// Unwrap returns the original decoding error.
+func (e *decodeError) Unwrap() error {
+	return e.err
+}
+
+// DecodeMessages decodes the json messages in the reader one by one and handles them with the handler,
+// messages could be separated by any whitespace. It returns error when the content can't be decoded or
+// the handler fails, the rest of the messages would not be handled then.
+func DecodeMessages(reader io.Reader, handler MessageHandler) error {
+	decoder := json.NewDecoder(reader)
+	for {
+		message := json.RawMessage{}
+		err := decoder.Decode(&message)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return &decodeError{err: err}
+		}
+
+		if err := handler(string(message)); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,158 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package collections
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDecodeMessages(t *testing.T) {
+	content := `{"ip":"127.0.0.1","cloudid":"0"}
+{
+  "ip": "127.0.0.2",
+  "cloudid": "0"
+} {"ip":"127.0.0.3","cloudid":"0"}`
+
+	messages := make([]string, 0)
+	err := DecodeMessages(strings.NewReader(content), func(message string) error {
+		messages = append(messages, message)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 3 || !strings.Contains(messages[1], "127.0.0.2") {
+		t.Fatalf("decoded messages %v are invalid", messages)
+	}
+
+	handled := 0
+	handlerErr := errors.New("handle message failed")
+	err = DecodeMessages(strings.NewReader(content), func(message string) error {
+		handled++
+		return handlerErr
+	})
+	if err != handlerErr || handled != 1 {
+		t.Fatalf("decode should stop with the handler error, err: %v, handled: %d", err, handled)
+	}
+
+	err = DecodeMessages(strings.NewReader(`{"ip":`), func(string) error { return nil })
+	decodeErr := new(decodeError)
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("decode invalid content should fail with decode error, err: %v", err)
+	}
+}
+
+func TestHTTPSource(t *testing.T) {
+	source := NewHTTPSource()
+	if !source.Sharded() {
+		t.Fatal("messages pushed to http source should skip the sharding check")
+	}
+	if err := source.Push("{}"); err == nil {
+		t.Fatal("push to source without handler should fail")
+	}
+
+	received := ""
+	if err := source.Receive(func(message string) error {
+		received = message
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := source.Push(`{"ip":"127.0.0.1"}`); err != nil {
+		t.Fatal(err)
+	}
+	if received != `{"ip":"127.0.0.1"}` {
+		t.Fatalf("received message %s is invalid", received)
+	}
+}
+
+func TestSpoolSourceScan(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"1.json":     `{"ip":"127.0.0.1"}`,
+		"2.json":     `{"ip":"127.0.0.2"} {"ip":"127.0.0.3"}`,
+		"3.json":     `{"ip":`,
+		"6.json":     `{"ip":"127.0.0.6"}`,
+		"7.json":     `{"ip":"127.0.0.7"}`,
+		"4.json.tmp": `{"ip":"127.0.0.4"}`,
+		".5.json":    `{"ip":"127.0.0.5"}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(dir, spoolFailedDir), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	source := &spoolSource{dir: dir}
+	messages := make([]string, 0)
+	handler := func(message string) error {
+		if strings.Contains(message, "127.0.0.6") {
+			return errors.New("handle message failed")
+		}
+		messages = append(messages, message)
+		return nil
+	}
+	if err := source.scan(handler); err == nil {
+		t.Fatal("scan should fail when the handler fails")
+	}
+
+	if len(messages) != 3 {
+		t.Fatalf("handled messages %v are invalid", messages)
+	}
+
+	for _, name := range []string{"1.json", "2.json", "3.json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Fatalf("spool file %s should be removed, err: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, spoolFailedDir, "3.json")); err != nil {
+		t.Fatalf("spool file that can't be decoded should be moved to failed directory, err: %v", err)
+	}
+	// the file that the handler failed and the files after it are kept in the spool to be retried.
+	for _, name := range []string{"6.json", "7.json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Fatalf("spool file %s should be kept to retry, err: %v", name, err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, spoolFailedDir, name)); !os.IsNotExist(err) {
+			t.Fatalf("spool file %s should not be moved to failed directory, err: %v", name, err)
+		}
+	}
+
+	messages = make([]string, 0)
+	if err := source.scan(func(message string) error {
+		messages = append(messages, message)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("retried messages %v are invalid", messages)
+	}
+	for _, name := range []string{"4.json.tmp", ".5.json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Fatalf("ignored spool file %s should be kept, err: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package logics
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	httpheader "configcenter/src/common/http/header"
+	"configcenter/src/common/mapstr"
+	meta "configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/datacollection/collections/hostsnap"
+)
+
+// CreateHostSnapFieldMapping create host snapshot field mapping
+func (lgc *Logics) CreateHostSnapFieldMapping(pHeader http.Header, mapping meta.HostSnapFieldMapping) (
+	*meta.HostSnapFieldMapping, error) {
+
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(httpheader.GetLanguage(pHeader))
+	rid := httpheader.GetRid(pHeader)
+	ownerID := httpheader.GetSupplierAccount(pHeader)
+
+	if err := lgc.checkHostSnapFieldMapping(pHeader, &mapping, 0, ownerID); err != nil {
+		return nil, err
+	}
+
+	id, err := lgc.db.NextSequence(lgc.ctx, common.BKTableNameHostSnapFieldMapping)
+	if err != nil {
+		blog.Errorf("get host snapshot field mapping id failed, err: %v, rid: %s", err, rid)
+		return nil, defErr.CCError(common.CCErrCommDBInsertFailed)
+	}
+
+	now := util.GetCurrentTimePtr()
+	mapping.ID = int64(id)
+	mapping.OwnerID = ownerID
+	mapping.Creator = httpheader.GetUser(pHeader)
+	mapping.Modifier = mapping.Creator
+	mapping.CreateTime = now
+	mapping.LastTime = now
+
+	if err := lgc.db.Table(common.BKTableNameHostSnapFieldMapping).Insert(lgc.ctx, mapping); err != nil {
+		blog.Errorf("create host snapshot field mapping failed, err: %v, mapping: %#v, rid: %s", err, mapping, rid)
+		return nil, defErr.CCError(common.CCErrCommDBInsertFailed)
+	}
+
+	return &mapping, nil
+}
+
+// UpdateHostSnapFieldMapping update host snapshot field mapping
+func (lgc *Logics) UpdateHostSnapFieldMapping(pHeader http.Header, id int64, mapping meta.HostSnapFieldMapping) error {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(httpheader.GetLanguage(pHeader))
+	rid := httpheader.GetRid(pHeader)
+	ownerID := httpheader.GetSupplierAccount(pHeader)
+
+	cond := mapstr.MapStr{common.BKFieldID: id, common.BkSupplierAccount: ownerID}
+	count, err := lgc.db.Table(common.BKTableNameHostSnapFieldMapping).Find(cond).Count(lgc.ctx)
+	if err != nil {
+		blog.Errorf("count host snapshot field mapping failed, err: %v, cond: %#v, rid: %s", err, cond, rid)
+		return defErr.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	if count == 0 {
+		blog.Errorf("host snapshot field mapping %d is not exist, rid: %s", id, rid)
+		return defErr.CCError(common.CCErrCommNotFound)
+	}
+
+	if err := lgc.checkHostSnapFieldMapping(pHeader, &mapping, id, ownerID); err != nil {
+		return err
+	}
+
+	data := mapstr.MapStr{
+		"path":                   mapping.Path,
+		common.BKPropertyIDField: mapping.PropertyID,
+		"transform":              mapping.Transform,
+		common.ModifierField:     httpheader.GetUser(pHeader),
+		common.LastTimeField:     util.GetCurrentTimePtr(),
+	}
+
+	if err := lgc.db.Table(common.BKTableNameHostSnapFieldMapping).Update(lgc.ctx, cond, data); err != nil {
+		blog.Errorf("update host snapshot field mapping failed, err: %v, id: %d, rid: %s", err, id, rid)
+		return defErr.CCError(common.CCErrCommDBUpdateFailed)
+	}
+
+	return nil
+}
+
+// SearchHostSnapFieldMapping search host snapshot field mappings
+func (lgc *Logics) SearchHostSnapFieldMapping(pHeader http.Header, opt *meta.SearchHostSnapFieldMappingOption) (
+	*meta.SearchHostSnapFieldMapping, error) {
+
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(httpheader.GetLanguage(pHeader))
+	rid := httpheader.GetRid(pHeader)
+
+	cond := mapstr.MapStr{common.BkSupplierAccount: httpheader.GetSupplierAccount(pHeader)}
+	count, err := lgc.db.Table(common.BKTableNameHostSnapFieldMapping).Find(cond).Count(lgc.ctx)
+	if err != nil {
+		blog.Errorf("count host snapshot field mappings failed, err: %v, rid: %s", err, rid)
+		return nil, defErr.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	sort := opt.Page.Sort
+	if sort == "" {
+		sort = common.BKFieldID
+	}
+
+	mappings := make([]meta.HostSnapFieldMapping, 0)
+	err = lgc.db.Table(common.BKTableNameHostSnapFieldMapping).Find(cond).Sort(sort).Start(uint64(opt.Page.Start)).
+		Limit(uint64(opt.Page.Limit)).All(lgc.ctx, &mappings)
+	if err != nil {
+		blog.Errorf("search host snapshot field mappings failed, err: %v, rid: %s", err, rid)
+		return nil, defErr.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	return &meta.SearchHostSnapFieldMapping{Count: count, Info: mappings}, nil
+}
+
+// DeleteHostSnapFieldMapping delete host snapshot field mappings
+func (lgc *Logics) DeleteHostSnapFieldMapping(pHeader http.Header, opt *meta.DeleteHostSnapFieldMappingOption) error {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(httpheader.GetLanguage(pHeader))
+	rid := httpheader.GetRid(pHeader)
+
+	if len(opt.IDs) == 0 {
+		return defErr.CCErrorf(common.CCErrCommParamsNeedSet, "ids")
+	}
+
+	cond := mapstr.MapStr{
+		common.BKFieldID:         mapstr.MapStr{common.BKDBIN: opt.IDs},
+		common.BkSupplierAccount: httpheader.GetSupplierAccount(pHeader),
+	}
+	if err := lgc.db.Table(common.BKTableNameHostSnapFieldMapping).Delete(lgc.ctx, cond); err != nil {
+		blog.Errorf("delete host snapshot field mappings failed, err: %v, ids: %v, rid: %s", err, opt.IDs, rid)
+		return defErr.CCError(common.CCErrCommDBDeleteFailed)
+	}
+
+	return nil
+}
+
+// checkHostSnapFieldMapping checks if the mapping is valid and the host attribute is not mapped by other mappings
+func (lgc *Logics) checkHostSnapFieldMapping(pHeader http.Header, mapping *meta.HostSnapFieldMapping, id int64,
+	ownerID string) error {
+
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(httpheader.GetLanguage(pHeader))
+	rid := httpheader.GetRid(pHeader)
+
+	if rawErr := mapping.Validate(); rawErr.ErrCode != 0 {
+		return rawErr.ToCCError(defErr)
+	}
+
+	// the attributes filled by hostsnap itself can not be overwritten by the mapping.
+	if hostsnap.IsManagedField(mapping.PropertyID) {
+		blog.Errorf("host attribute %s is managed by hostsnap, can not be mapped, rid: %s", mapping.PropertyID, rid)
+		return defErr.CCErrorf(common.CCErrCommParamsInvalid, common.BKPropertyIDField)
+	}
+
+	attrCond := &meta.QueryCondition{
+		Condition: mapstr.MapStr{common.BKPropertyIDField: mapping.PropertyID},
+		Page:      meta.BasePage{Limit: 1},
+	}
+	attrs, err := lgc.CoreAPI.CoreService().Model().ReadModelAttr(context.Background(), pHeader,
+		common.BKInnerObjIDHost, attrCond)
+	if err != nil {
+		blog.Errorf("read host attribute %s failed, err: %v, rid: %s", mapping.PropertyID, err, rid)
+		return err
+	}
+
+	if len(attrs.Info) == 0 {
+		blog.Errorf("host attribute %s is not exist, rid: %s", mapping.PropertyID, rid)
+		return defErr.CCErrorf(common.CCErrCommParamsInvalid, common.BKPropertyIDField)
+	}
+
+	// the host attribute type must be able to hold the mapped values, the values are validated with the host
+	// attribute again when the mapping is applied.
+	if err := hostsnap.CheckFieldMapping(*mapping, attrs.Info[0]); err != nil {
+		blog.Errorf("host attribute %s can not be mapped, err: %v, rid: %s", mapping.PropertyID, err, rid)
+		return defErr.CCErrorf(common.CCErrCommParamsInvalid, "transform")
+	}
+
+	dupCond := mapstr.MapStr{
+		common.BKPropertyIDField: mapping.PropertyID,
+		common.BkSupplierAccount: ownerID,
+		common.BKFieldID:         mapstr.MapStr{common.BKDBNE: id},
+	}
+	count, err := lgc.db.Table(common.BKTableNameHostSnapFieldMapping).Find(dupCond).Count(lgc.ctx)
+	if err != nil {
+		blog.Errorf("count host snapshot field mapping failed, err: %v, cond: %#v, rid: %s", err, dupCond, rid)
+		return defErr.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	if count > 0 {
+		blog.Errorf("host attribute %s is already mapped, rid: %s", mapping.PropertyID, rid)
+		return defErr.CCErrorf(common.CCErrCommDuplicateItem, common.BKPropertyIDField)
+	}
+
+	return nil
+}
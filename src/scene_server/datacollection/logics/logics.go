@@ -21,6 +21,7 @@ import (
 	"context"
 
 	"configcenter/src/common/backbone"
+	"configcenter/src/common/cryptor"
 	"configcenter/src/storage/dal"
 	"configcenter/src/thirdparty/esbserver"
 )
@@ -31,9 +32,14 @@ type Logics struct {
 	db  dal.RDB
 	ESB esbserver.EsbClientInterface
 	ctx context.Context
+
+	// cryptor encrypts the passwords of net device targets, it's nil if crypto is not enabled.
+	cryptor cryptor.Cryptor
 }
 
 // NewLogics TODO
-func NewLogics(ctx context.Context, engine *backbone.Engine, mgoCli dal.RDB, esb esbserver.EsbClientInterface) *Logics {
-	return &Logics{ctx: ctx, db: mgoCli, Engine: engine, ESB: esb}
+func NewLogics(ctx context.Context, engine *backbone.Engine, mgoCli dal.RDB, esb esbserver.EsbClientInterface,
+	crypto cryptor.Cryptor) *Logics {
+
+	return &Logics{ctx: ctx, db: mgoCli, Engine: engine, ESB: esb, cryptor: crypto}
 }
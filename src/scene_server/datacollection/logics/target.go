@@ -0,0 +1,345 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package logics
+
+import (
+	"errors"
+	"net/http"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	httpheader "configcenter/src/common/http/header"
+	"configcenter/src/common/mapstr"
+	meta "configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/datacollection/collections/netcollect"
+	"configcenter/src/scene_server/datacollection/collections/netcollect/driver"
+)
+
+const (
+	// netTargetIDField is the id field of net device target.
+	netTargetIDField = "target_id"
+
+	// defaultNetTargetPeriod is the default collecting period of net device target.
+	defaultNetTargetPeriod = "1H"
+)
+
+// AddNetTarget create net device target that is collected by protocol driver
+func (lgc *Logics) AddNetTarget(pHeader http.Header, target meta.NetcollectTarget) (meta.AddNetTargetResult, error) {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(httpheader.GetLanguage(pHeader))
+	rid := httpheader.GetRid(pHeader)
+	ownerID := httpheader.GetSupplierAccount(pHeader)
+
+	if target.Period == "" {
+		target.Period = defaultNetTargetPeriod
+	}
+	if err := lgc.checkNetTarget(pHeader, &target, 0, ownerID); err != nil {
+		return meta.AddNetTargetResult{TargetID: INVALIDID}, err
+	}
+
+	targetID, err := lgc.db.NextSequence(lgc.ctx, common.BKTableNameNetcollectTarget)
+	if err != nil {
+		blog.Errorf("get net device target id failed, err: %v, rid: %s", err, rid)
+		return meta.AddNetTargetResult{TargetID: INVALIDID}, defErr.CCError(common.CCErrCommDBInsertFailed)
+	}
+
+	now := util.GetCurrentTimePtr()
+	target.TargetID = targetID
+	target.OwnerID = ownerID
+	target.Status = meta.NetcollectTargetStatus{}
+	target.CreateTime = now
+	target.LastTime = now
+	if target.Password, err = lgc.encryptPassword(target.Password); err != nil {
+		blog.Errorf("encrypt password of net device target failed, err: %v, rid: %s", err, rid)
+		return meta.AddNetTargetResult{TargetID: INVALIDID}, defErr.CCError(common.CCErrCommDBInsertFailed)
+	}
+
+	if err := lgc.db.Table(common.BKTableNameNetcollectTarget).Insert(lgc.ctx, target); err != nil {
+		blog.Errorf("create net device target failed, err: %v, address: %s, rid: %s", err, target.Address, rid)
+		return meta.AddNetTargetResult{TargetID: INVALIDID}, defErr.CCError(common.CCErrCommDBInsertFailed)
+	}
+
+	return meta.AddNetTargetResult{TargetID: targetID}, nil
+}
+
+// UpdateNetTarget update net device target, the password is not changed if it's empty
+func (lgc *Logics) UpdateNetTarget(pHeader http.Header, targetID uint64, target meta.NetcollectTarget) error {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(httpheader.GetLanguage(pHeader))
+	rid := httpheader.GetRid(pHeader)
+	ownerID := httpheader.GetSupplierAccount(pHeader)
+
+	if _, err := lgc.getNetTarget(pHeader, targetID); err != nil {
+		return err
+	}
+
+	if target.Period == "" {
+		target.Period = defaultNetTargetPeriod
+	}
+	if err := lgc.checkNetTarget(pHeader, &target, targetID, ownerID); err != nil {
+		return err
+	}
+
+	data := mapstr.MapStr{
+		common.BKDeviceIDField: target.DeviceID,
+		common.BKCloudIDField:  target.CloudID,
+		"address":              target.Address,
+		common.BKInstKeyField:  target.InstKey,
+		"protocol":             target.Protocol,
+		"port":                 target.Port,
+		"username":             target.Username,
+		"options":              target.Options,
+		"period":               target.Period,
+		common.LastTimeField:   util.GetCurrentTimePtr(),
+	}
+	if target.Password != "" {
+		password, err := lgc.encryptPassword(target.Password)
+		if err != nil {
+			blog.Errorf("encrypt password of net device target %d failed, err: %v, rid: %s", targetID, err, rid)
+			return defErr.CCError(common.CCErrCommDBUpdateFailed)
+		}
+		data["password"] = password
+	}
+
+	cond := mapstr.MapStr{netTargetIDField: targetID, common.BkSupplierAccount: ownerID}
+	if err := lgc.db.Table(common.BKTableNameNetcollectTarget).Update(lgc.ctx, cond, data); err != nil {
+		blog.Errorf("update net device target %d failed, err: %v, rid: %s", targetID, err, rid)
+		return defErr.CCError(common.CCErrCommDBUpdateFailed)
+	}
+
+	return nil
+}
+
+// SearchNetTarget search net device targets, the passwords are not returned
+func (lgc *Logics) SearchNetTarget(pHeader http.Header, opt *meta.SearchNetTargetOption) (*meta.SearchNetTarget,
+	error) {
+
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(httpheader.GetLanguage(pHeader))
+	rid := httpheader.GetRid(pHeader)
+	ownerID := httpheader.GetSupplierAccount(pHeader)
+
+	cond := mapstr.MapStr{common.BkSupplierAccount: ownerID}
+	if len(opt.DeviceIDs) > 0 {
+		cond[common.BKDeviceIDField] = mapstr.MapStr{common.BKDBIN: opt.DeviceIDs}
+	}
+	if opt.CloudID != nil {
+		cond[common.BKCloudIDField] = *opt.CloudID
+	}
+	if opt.Address != "" {
+		cond["address"] = opt.Address
+	}
+	if opt.Protocol != "" {
+		cond["protocol"] = opt.Protocol
+	}
+
+	count, err := lgc.db.Table(common.BKTableNameNetcollectTarget).Find(cond).Count(lgc.ctx)
+	if err != nil {
+		blog.Errorf("count net device targets failed, err: %v, cond: %#v, rid: %s", err, cond, rid)
+		return nil, defErr.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	sort := opt.Page.Sort
+	if sort == "" {
+		sort = netTargetIDField
+	}
+
+	targets := make([]meta.NetcollectTarget, 0)
+	err = lgc.db.Table(common.BKTableNameNetcollectTarget).Find(cond).Sort(sort).Start(uint64(opt.Page.Start)).
+		Limit(uint64(opt.Page.Limit)).All(lgc.ctx, &targets)
+	if err != nil {
+		blog.Errorf("search net device targets failed, err: %v, cond: %#v, rid: %s", err, cond, rid)
+		return nil, defErr.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	deviceIDs := make([]uint64, 0)
+	for index := range targets {
+		targets[index].Password = ""
+		deviceIDs = append(deviceIDs, targets[index].DeviceID)
+	}
+
+	if len(deviceIDs) > 0 {
+		devices := make([]meta.NetcollectDevice, 0)
+		deviceCond := mapstr.MapStr{
+			common.BKDeviceIDField:   mapstr.MapStr{common.BKDBIN: deviceIDs},
+			common.BkSupplierAccount: ownerID,
+		}
+		if err := lgc.findDevice([]string{common.BKDeviceIDField, common.BKDeviceNameField}, deviceCond, &devices,
+			"", 0, common.BKNoLimit); err != nil {
+			return nil, defErr.CCError(common.CCErrCommDBSelectFailed)
+		}
+
+		deviceNameMap := make(map[uint64]string)
+		for _, device := range devices {
+			deviceNameMap[device.DeviceID] = device.DeviceName
+		}
+		for index := range targets {
+			targets[index].DeviceName = deviceNameMap[targets[index].DeviceID]
+		}
+	}
+
+	return &meta.SearchNetTarget{Count: count, Info: targets}, nil
+}
+
+// DeleteNetTarget delete net device targets
+func (lgc *Logics) DeleteNetTarget(pHeader http.Header, opt *meta.DeleteNetTargetBatchOpt) error {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(httpheader.GetLanguage(pHeader))
+	rid := httpheader.GetRid(pHeader)
+
+	if len(opt.TargetIDs) == 0 {
+		return defErr.CCErrorf(common.CCErrCommParamsNeedSet, netTargetIDField)
+	}
+
+	cond := mapstr.MapStr{
+		netTargetIDField:         mapstr.MapStr{common.BKDBIN: opt.TargetIDs},
+		common.BkSupplierAccount: httpheader.GetSupplierAccount(pHeader),
+	}
+	if err := lgc.db.Table(common.BKTableNameNetcollectTarget).Delete(lgc.ctx, cond); err != nil {
+		blog.Errorf("delete net device targets failed, err: %v, ids: %v, rid: %s", err, opt.TargetIDs, rid)
+		return defErr.CCError(common.CCErrCommDBDeleteFailed)
+	}
+
+	return nil
+}
+
+// CollectNetTarget collect net device target immediately, the report is saved and can be searched and confirmed
+// the same as the reports collected periodically
+func (lgc *Logics) CollectNetTarget(pHeader http.Header, targetID uint64) (*meta.NetcollectReport, error) {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(httpheader.GetLanguage(pHeader))
+	rid := httpheader.GetRid(pHeader)
+
+	target, err := lgc.getNetTarget(pHeader, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	report, collectErr := netcollect.Collect(lgc.ctx, lgc.db, lgc.cryptor, target)
+	if err := netcollect.UpdateTargetStatus(lgc.ctx, lgc.db, targetID, collectErr); err != nil {
+		blog.Errorf("update status of net device target %d failed, err: %v, rid: %s", targetID, err, rid)
+	}
+	if collectErr != nil {
+		blog.Errorf("collect net device target %d failed, err: %v, rid: %s", targetID, collectErr, rid)
+		return nil, defErr.CCErrorf(common.CCErrCollectNetTargetCollectFail, collectErr.Error())
+	}
+
+	if err := netcollect.UpsertReport(lgc.ctx, lgc.db, report); err != nil {
+		blog.Errorf("save report of net device target %d failed, err: %v, rid: %s", targetID, err, rid)
+		return nil, defErr.CCError(common.CCErrCommDBUpdateFailed)
+	}
+
+	return report, nil
+}
+
+// encryptPassword encrypt the password of net device target before saving it, the password is decrypted only
+// when the target is collected. the password is never saved in plaintext, so it fails if crypto is not enabled
+func (lgc *Logics) encryptPassword(password string) (string, error) {
+	if password == "" {
+		return password, nil
+	}
+	if lgc.cryptor == nil {
+		return "", errors.New("crypto is not enabled")
+	}
+	return lgc.cryptor.Encrypt(password)
+}
+
+// getNetTarget get net device target by id
+func (lgc *Logics) getNetTarget(pHeader http.Header, targetID uint64) (*meta.NetcollectTarget, error) {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(httpheader.GetLanguage(pHeader))
+	rid := httpheader.GetRid(pHeader)
+
+	cond := mapstr.MapStr{
+		netTargetIDField:         targetID,
+		common.BkSupplierAccount: httpheader.GetSupplierAccount(pHeader),
+	}
+
+	target := new(meta.NetcollectTarget)
+	if err := lgc.db.Table(common.BKTableNameNetcollectTarget).Find(cond).One(lgc.ctx, target); err != nil {
+		if lgc.db.IsNotFoundError(err) {
+			blog.Errorf("net device target %d is not exist, rid: %s", targetID, rid)
+			return nil, defErr.CCError(common.CCErrCommNotFound)
+		}
+		blog.Errorf("get net device target %d failed, err: %v, rid: %s", targetID, err, rid)
+		return nil, defErr.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	return target, nil
+}
+
+// checkNetTarget checks if the target is valid and its address is not used by other targets in the same cloud area
+func (lgc *Logics) checkNetTarget(pHeader http.Header, target *meta.NetcollectTarget, targetID uint64,
+	ownerID string) error {
+
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(httpheader.GetLanguage(pHeader))
+	rid := httpheader.GetRid(pHeader)
+
+	if target.Address == "" {
+		return defErr.CCErrorf(common.CCErrCommParamsNeedSet, "address")
+	}
+
+	if target.Port < 0 || target.Port > 65535 {
+		return defErr.CCErrorf(common.CCErrCommParamsInvalid, "port")
+	}
+
+	// refuse to save the password in plaintext, the password can only be saved when crypto is enabled
+	if target.Password != "" && lgc.cryptor == nil {
+		blog.Errorf("crypto is not enabled, can not save the password of net device target, rid: %s", rid)
+		return defErr.CCErrorf(common.CCErrCommParamsInvalid, "password")
+	}
+
+	if _, err := driver.GetDriver(target.Protocol); err != nil {
+		blog.Errorf("net device target protocol is invalid, err: %v, rid: %s", err, rid)
+		return defErr.CCErrorf(common.CCErrCommParamsInvalid, "protocol")
+	}
+
+	period, err := util.FormatPeriod(target.Period)
+	if err != nil {
+		blog.Errorf("net device target period %s is invalid, err: %v, rid: %s", target.Period, err, rid)
+		return defErr.CCError(common.CCErrCollectPeriodFormatFail)
+	}
+	target.Period = period
+
+	if target.DeviceID == 0 {
+		return defErr.CCErrorf(common.CCErrCommParamsNeedSet, common.BKDeviceIDField)
+	}
+
+	deviceCond := mapstr.MapStr{common.BKDeviceIDField: target.DeviceID, common.BkSupplierAccount: ownerID}
+	count, err := lgc.db.Table(common.BKTableNameNetcollectDevice).Find(deviceCond).Count(lgc.ctx)
+	if err != nil {
+		blog.Errorf("count net device %d failed, err: %v, rid: %s", target.DeviceID, err, rid)
+		return defErr.CCError(common.CCErrCommDBSelectFailed)
+	}
+	if count == 0 {
+		blog.Errorf("net device %d is not exist, rid: %s", target.DeviceID, rid)
+		return defErr.CCError(common.CCErrCollectDeviceNotExist)
+	}
+
+	dupCond := mapstr.MapStr{
+		common.BKCloudIDField:    target.CloudID,
+		"address":                target.Address,
+		common.BkSupplierAccount: ownerID,
+		netTargetIDField:         mapstr.MapStr{common.BKDBNE: targetID},
+	}
+	count, err = lgc.db.Table(common.BKTableNameNetcollectTarget).Find(dupCond).Count(lgc.ctx)
+	if err != nil {
+		blog.Errorf("count net device target failed, err: %v, cond: %#v, rid: %s", err, dupCond, rid)
+		return defErr.CCError(common.CCErrCommDBSelectFailed)
+	}
+	if count > 0 {
+		blog.Errorf("net device target %s is already exist, rid: %s", target.Address, rid)
+		return defErr.CCErrorf(common.CCErrCommDuplicateItem, "address")
+	}
+
+	return nil
+}
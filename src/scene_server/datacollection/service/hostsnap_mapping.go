@@ -0,0 +1,129 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/emicklei/go-restful/v3"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	httpheader "configcenter/src/common/http/header"
+	meta "configcenter/src/common/metadata"
+)
+
+// CreateHostSnapFieldMapping create host snapshot field mapping
+func (s *Service) CreateHostSnapFieldMapping(req *restful.Request, resp *restful.Response) {
+	pHeader := req.Request.Header
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(httpheader.GetLanguage(pHeader))
+	rid := httpheader.GetRid(pHeader)
+
+	mapping := meta.HostSnapFieldMapping{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&mapping); err != nil {
+		blog.Errorf("create host snapshot field mapping failed with decode body err: %v, rid: %s", err, rid)
+		_ = resp.WriteError(http.StatusBadRequest,
+			&meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	result, err := s.logics.CreateHostSnapFieldMapping(pHeader, mapping)
+	if err != nil {
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+		return
+	}
+
+	_ = resp.WriteEntity(meta.NewSuccessResp(result))
+}
+
+// UpdateHostSnapFieldMapping update host snapshot field mapping
+func (s *Service) UpdateHostSnapFieldMapping(req *restful.Request, resp *restful.Response) {
+	pHeader := req.Request.Header
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(httpheader.GetLanguage(pHeader))
+	rid := httpheader.GetRid(pHeader)
+
+	id, err := strconv.ParseInt(req.PathParameter(common.BKFieldID), 10, 64)
+	if err != nil || id <= 0 {
+		blog.Errorf("update host snapshot field mapping failed, invalid id: %s, rid: %s",
+			req.PathParameter(common.BKFieldID), rid)
+		_ = resp.WriteError(http.StatusBadRequest,
+			&meta.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, common.BKFieldID)})
+		return
+	}
+
+	mapping := meta.HostSnapFieldMapping{}
+	if err = json.NewDecoder(req.Request.Body).Decode(&mapping); err != nil {
+		blog.Errorf("update host snapshot field mapping failed with decode body err: %v, rid: %s", err, rid)
+		_ = resp.WriteError(http.StatusBadRequest,
+			&meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	if err = s.logics.UpdateHostSnapFieldMapping(pHeader, id, mapping); err != nil {
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+		return
+	}
+
+	_ = resp.WriteEntity(meta.NewSuccessResp(nil))
+}
+
+// SearchHostSnapFieldMapping search host snapshot field mappings
+func (s *Service) SearchHostSnapFieldMapping(req *restful.Request, resp *restful.Response) {
+	pHeader := req.Request.Header
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(httpheader.GetLanguage(pHeader))
+	rid := httpheader.GetRid(pHeader)
+
+	opt := new(meta.SearchHostSnapFieldMappingOption)
+	if err := json.NewDecoder(req.Request.Body).Decode(opt); err != nil {
+		blog.Errorf("search host snapshot field mapping failed with decode body err: %v, rid: %s", err, rid)
+		_ = resp.WriteError(http.StatusBadRequest,
+			&meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	result, err := s.logics.SearchHostSnapFieldMapping(pHeader, opt)
+	if err != nil {
+		_ = resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: err})
+		return
+	}
+
+	_ = resp.WriteEntity(meta.NewSuccessResp(result))
+}
+
+// DeleteHostSnapFieldMapping delete host snapshot field mappings
+func (s *Service) DeleteHostSnapFieldMapping(req *restful.Request, resp *restful.Response) {
+	pHeader := req.Request.Header
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(httpheader.GetLanguage(pHeader))
+	rid := httpheader.GetRid(pHeader)
+
+	opt := new(meta.DeleteHostSnapFieldMappingOption)
+	if err := json.NewDecoder(req.Request.Body).Decode(opt); err != nil {
+		blog.Errorf("delete host snapshot field mapping failed with decode body err: %v, rid: %s", err, rid)
+		_ = resp.WriteError(http.StatusBadRequest,
+			&meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	if err := s.logics.DeleteHostSnapFieldMapping(pHeader, opt); err != nil {
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+		return
+	}
+
+	_ = resp.WriteEntity(meta.NewSuccessResp(nil))
+}
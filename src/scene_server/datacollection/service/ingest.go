@@ -0,0 +1,74 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package service
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful/v3"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	httpheader "configcenter/src/common/http/header"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/datacollection/collections"
+)
+
+// ingestResult is result of pushing collector messages.
+type ingestResult struct {
+	// Total is number of messages in the request.
+	Total int `json:"total"`
+
+	// Failed is number of messages that are invalid or can't be added to the analyze queue.
+	Failed int `json:"failed"`
+}
+
+// IngestMessage receives collector messages pushed by http, the messages are handled by the porter
+// just like the messages received from redis or kafka. Request body contains one or more json
+// messages separated by whitespace.
+func (s *Service) IngestMessage(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(httpheader.GetLanguage(pheader))
+	rid := httpheader.GetRid(pheader)
+
+	porterName := req.PathParameter("porter_name")
+	source, exists := s.ingestSources[porterName]
+	if !exists {
+		blog.Errorf("porter %s has no http ingest source, rid: %s", porterName, rid)
+		resp.WriteError(http.StatusBadRequest,
+			&metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, "porter_name")})
+		return
+	}
+
+	result := ingestResult{}
+	err := collections.DecodeMessages(req.Request.Body, func(message string) error {
+		result.Total++
+		// the failed message is counted in the result, keep handling the rest of the messages.
+		if err := source.Push(message); err != nil {
+			result.Failed++
+		}
+		return nil
+	})
+	if err != nil {
+		blog.Errorf("decode ingest messages failed, porter: %s, err: %v, rid: %s", porterName, err, rid)
+		resp.WriteError(http.StatusBadRequest,
+			&metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(result))
+}
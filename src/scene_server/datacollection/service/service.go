@@ -23,12 +23,14 @@ import (
 
 	"configcenter/src/common"
 	"configcenter/src/common/backbone"
+	"configcenter/src/common/cryptor"
 	"configcenter/src/common/errors"
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/metric"
 	"configcenter/src/common/rdapi"
 	"configcenter/src/common/types"
 	"configcenter/src/common/webservice/restfulservice"
+	"configcenter/src/scene_server/datacollection/collections"
 	"configcenter/src/scene_server/datacollection/logics"
 	"configcenter/src/storage/dal"
 	"configcenter/src/storage/dal/redis"
@@ -49,17 +51,20 @@ type Service struct {
 	disCli  redis.Client
 	netCli  redis.Client
 
+	// ingestSources http ingest sources of porters, porter name -> source.
+	ingestSources map[string]*collections.HTTPSource
+
 	logics *logics.Logics
 }
 
 // NewService creates a new Service object.
 func NewService(ctx context.Context, engine *backbone.Engine) *Service {
-	return &Service{ctx: ctx, engine: engine}
+	return &Service{ctx: ctx, engine: engine, ingestSources: make(map[string]*collections.HTTPSource)}
 }
 
 // SetLogics setups logics comm.
-func (s *Service) SetLogics(db dal.RDB, esb esbserver.EsbClientInterface) {
-	s.logics = logics.NewLogics(s.ctx, s.engine, db, esb)
+func (s *Service) SetLogics(db dal.RDB, esb esbserver.EsbClientInterface, crypto cryptor.Cryptor) {
+	s.logics = logics.NewLogics(s.ctx, s.engine, db, esb, crypto)
 }
 
 // SetDB setups database.
@@ -87,6 +92,11 @@ func (s *Service) SetNetCollectCli(db redis.Client) {
 	s.netCli = db
 }
 
+// AddIngestSource setups http ingest source of the porter.
+func (s *Service) AddIngestSource(porterName string, source *collections.HTTPSource) {
+	s.ingestSources[porterName] = source
+}
+
 // WebService setups a new restful web service.
 func (s *Service) WebService() *restful.Container {
 	container := restful.NewContainer()
@@ -112,6 +122,12 @@ func (s *Service) WebService() *restful.Container {
 	api.Route(api.POST("/netcollect/property/action/search").To(s.SearchProperty))
 	api.Route(api.DELETE("/netcollect/property/action/delete").To(s.DeleteProperty))
 
+	api.Route(api.POST("/netcollect/target/action/create").To(s.CreateNetTarget))
+	api.Route(api.POST("/netcollect/target/{target_id}/action/update").To(s.UpdateNetTarget))
+	api.Route(api.POST("/netcollect/target/action/search").To(s.SearchNetTarget))
+	api.Route(api.DELETE("/netcollect/target/action/delete").To(s.DeleteNetTarget))
+	api.Route(api.POST("/netcollect/target/{target_id}/action/collect").To(s.CollectNetTarget))
+
 	api.Route(api.POST("/netcollect/summary/action/search").To(s.SearchReportSummary))
 	api.Route(api.POST("/netcollect/report/action/search").To(s.SearchReport))
 	api.Route(api.POST("/netcollect/report/action/confirm").To(s.ConfirmReport))
@@ -121,6 +137,13 @@ func (s *Service) WebService() *restful.Container {
 	api.Route(api.POST("/netcollect/collector/action/update").To(s.UpdateCollector))
 	api.Route(api.POST("/netcollect/collector/action/discover").To(s.DiscoverNetDevice))
 
+	api.Route(api.POST("/hostsnap/field_mapping/action/create").To(s.CreateHostSnapFieldMapping))
+	api.Route(api.POST("/hostsnap/field_mapping/{id}/action/update").To(s.UpdateHostSnapFieldMapping))
+	api.Route(api.POST("/hostsnap/field_mapping/action/search").To(s.SearchHostSnapFieldMapping))
+	api.Route(api.DELETE("/hostsnap/field_mapping/action/delete").To(s.DeleteHostSnapFieldMapping))
+
+	api.Route(api.POST("/collect/{porter_name}/action/ingest").To(s.IngestMessage))
+
 	container.Add(api)
 
 	// common api
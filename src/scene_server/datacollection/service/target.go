@@ -0,0 +1,158 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/emicklei/go-restful/v3"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	httpheader "configcenter/src/common/http/header"
+	meta "configcenter/src/common/metadata"
+)
+
+// CreateNetTarget create net device target
+func (s *Service) CreateNetTarget(req *restful.Request, resp *restful.Response) {
+	pHeader := req.Request.Header
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(httpheader.GetLanguage(pHeader))
+	rid := httpheader.GetRid(pHeader)
+
+	target := meta.NetcollectTarget{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&target); err != nil {
+		blog.Errorf("create net device target failed with decode body err: %v, rid: %s", err, rid)
+		_ = resp.WriteError(http.StatusBadRequest,
+			&meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	result, err := s.logics.AddNetTarget(pHeader, target)
+	if err != nil {
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+		return
+	}
+
+	_ = resp.WriteEntity(meta.NewSuccessResp(result))
+}
+
+// UpdateNetTarget update net device target
+func (s *Service) UpdateNetTarget(req *restful.Request, resp *restful.Response) {
+	pHeader := req.Request.Header
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(httpheader.GetLanguage(pHeader))
+	rid := httpheader.GetRid(pHeader)
+
+	targetID, err := checkTargetIDPathParam(defErr, req.PathParameter("target_id"), rid)
+	if err != nil {
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+		return
+	}
+
+	target := meta.NetcollectTarget{}
+	if err = json.NewDecoder(req.Request.Body).Decode(&target); err != nil {
+		blog.Errorf("update net device target failed with decode body err: %v, rid: %s", err, rid)
+		_ = resp.WriteError(http.StatusBadRequest,
+			&meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	if err = s.logics.UpdateNetTarget(pHeader, targetID, target); err != nil {
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+		return
+	}
+
+	_ = resp.WriteEntity(meta.NewSuccessResp(nil))
+}
+
+// SearchNetTarget search net device targets
+func (s *Service) SearchNetTarget(req *restful.Request, resp *restful.Response) {
+	pHeader := req.Request.Header
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(httpheader.GetLanguage(pHeader))
+	rid := httpheader.GetRid(pHeader)
+
+	opt := new(meta.SearchNetTargetOption)
+	if err := json.NewDecoder(req.Request.Body).Decode(opt); err != nil {
+		blog.Errorf("search net device target failed with decode body err: %v, rid: %s", err, rid)
+		_ = resp.WriteError(http.StatusBadRequest,
+			&meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	result, err := s.logics.SearchNetTarget(pHeader, opt)
+	if err != nil {
+		_ = resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: err})
+		return
+	}
+
+	_ = resp.WriteEntity(meta.NewSuccessResp(result))
+}
+
+// DeleteNetTarget delete net device targets
+func (s *Service) DeleteNetTarget(req *restful.Request, resp *restful.Response) {
+	pHeader := req.Request.Header
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(httpheader.GetLanguage(pHeader))
+	rid := httpheader.GetRid(pHeader)
+
+	opt := new(meta.DeleteNetTargetBatchOpt)
+	if err := json.NewDecoder(req.Request.Body).Decode(opt); err != nil {
+		blog.Errorf("delete net device target failed with decode body err: %v, rid: %s", err, rid)
+		_ = resp.WriteError(http.StatusBadRequest,
+			&meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	if err := s.logics.DeleteNetTarget(pHeader, opt); err != nil {
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+		return
+	}
+
+	_ = resp.WriteEntity(meta.NewSuccessResp(nil))
+}
+
+// CollectNetTarget collect net device target immediately and returns the report
+func (s *Service) CollectNetTarget(req *restful.Request, resp *restful.Response) {
+	pHeader := req.Request.Header
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(httpheader.GetLanguage(pHeader))
+	rid := httpheader.GetRid(pHeader)
+
+	targetID, err := checkTargetIDPathParam(defErr, req.PathParameter("target_id"), rid)
+	if err != nil {
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+		return
+	}
+
+	report, err := s.logics.CollectNetTarget(pHeader, targetID)
+	if err != nil {
+		_ = resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: err})
+		return
+	}
+
+	_ = resp.WriteEntity(meta.NewSuccessResp(report))
+}
+
+func checkTargetIDPathParam(defErr errors.DefaultCCErrorIf, id string, rid string) (uint64, error) {
+	targetID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil || targetID == 0 {
+		blog.Errorf("net device target id %s is invalid, rid: %s", id, rid)
+		return 0, defErr.Errorf(common.CCErrCommParamsInvalid, "target_id")
+	}
+
+	return targetID, nil
+}
@@ -23,6 +23,7 @@ import (
 	"sync"
 	"time"
 
+	"configcenter/src/ac"
 	"configcenter/src/ac/extensions"
 	"configcenter/src/ac/iam"
 	"configcenter/src/common/auth"
@@ -244,10 +245,10 @@ func (es *EventServer) initModules() error {
 	blog.Infof("init modules, connected to cc redis, %+v", es.config.Redis)
 
 	// initialize auth authorizer
-	es.service.SetAuthorizer(iam.NewAuthorizer(es.engine.CoreAPI))
+	es.service.SetAuthorizer(ac.NewAuthorizer(es.engine.CoreAPI))
 
 	iamCli := new(iam.IAM)
-	if auth.EnableAuthorize() {
+	if auth.EnableIAM() {
 		blog.Info("enable auth center access")
 		iamCli, err = iam.NewIAM(es.config.Auth, es.engine.Metric().Registry())
 		if err != nil {
@@ -31,6 +31,7 @@ import (
 	"configcenter/src/common/metric"
 	"configcenter/src/common/rdapi"
 	"configcenter/src/common/types"
+	"configcenter/src/common/watch"
 	"configcenter/src/common/webservice/restfulservice"
 	"configcenter/src/scene_server/event_server/sync/hostidentifier"
 	"configcenter/src/storage/dal"
@@ -118,6 +119,10 @@ func (s *Service) initService(web *restful.WebService) {
 
 	utility.AddToRestfulWebService(web)
 
+	// the watch stream api writes the server-sent events directly, so it is not wrapped with rest utility
+	web.Route(web.POST("/watch/resource/{resource}/stream").
+		Produces(watch.EventStreamContentType, restful.MIME_JSON).To(s.WatchEventStream))
+
 }
 
 // Healthz is a HTTP restful interface for health check.
@@ -0,0 +1,346 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	httpheader "configcenter/src/common/http/header"
+	"configcenter/src/common/json"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/watch"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+const (
+	// streamHeartbeatParam is the query parameter of the heartbeat interval seconds of the watch stream
+	streamHeartbeatParam = "bk_heartbeat_interval"
+	// defaultStreamHeartbeat is the default heartbeat interval of the watch stream
+	defaultStreamHeartbeat = 15 * time.Second
+	// minStreamHeartbeat is the minimum heartbeat interval of the watch stream
+	minStreamHeartbeat = 5 * time.Second
+	// maxStreamHeartbeat is the maximum heartbeat interval of the watch stream
+	maxStreamHeartbeat = 60 * time.Second
+	// streamRetryMilliseconds is the reconnection time that the client should wait after the stream is disconnected
+	streamRetryMilliseconds = 3000
+	// streamErrorInterval is the interval to wait before closing the stream when watch failed, so that the clients
+	// with wrong options would not reconnect too frequently
+	streamErrorInterval = 500 * time.Millisecond
+
+	// streamEventHeartbeat is the name of the heartbeat event, it carries the latest cursor
+	streamEventHeartbeat = "heartbeat"
+	// streamEventError is the name of the error event, the stream is closed after it is sent
+	streamEventError = "error"
+)
+
+// WatchEventStream watches the resource events with server-sent events. The watch options are the same as the
+// WatchEvent api, the events are pushed continuously as frames whose id is the event cursor and data is the event
+// detail. Heartbeat frames are sent periodically with the latest cursor as its id. If the stream is disconnected,
+// the client can resume it with the last received cursor in the Last-Event-ID header.
+func (s *Service) WatchEventStream(req *restful.Request, resp *restful.Response) {
+	header := req.Request.Header
+	rid := httpheader.GetRid(header)
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(httpheader.GetLanguage(header))
+
+	body, err := io.ReadAll(req.Request.Body)
+	if err != nil {
+		blog.Errorf("watch event stream, but read request body failed, err: %v, rid: %s", err, rid)
+		writeStreamRespError(resp, defErr.CCError(common.CCErrCommHTTPReadBodyFailed))
+		return
+	}
+
+	options := new(watch.WatchEventOptions)
+	if len(body) != 0 {
+		if err = json.Unmarshal(body, options); err != nil {
+			blog.Errorf("watch event stream, but decode request body failed, err: %v, rid: %s", err, rid)
+			time.Sleep(streamErrorInterval)
+			writeStreamRespError(resp, defErr.CCError(common.CCErrCommJSONUnmarshalFailed))
+			return
+		}
+	}
+	options.Resource = watch.CursorType(req.PathParameter("resource"))
+
+	resumeStreamOptions(header, options)
+
+	if err = options.Validate(false); err != nil {
+		blog.Errorf("watch event stream, but got invalid options, err: %v, rid: %s", err, rid)
+		time.Sleep(streamErrorInterval)
+		writeStreamRespError(resp, defErr.CCErrorf(common.CCErrCommParamsInvalid, err.Error()))
+		return
+	}
+
+	heartbeat, err := parseStreamHeartbeat(req.QueryParameter(streamHeartbeatParam))
+	if err != nil {
+		blog.Errorf("watch event stream, but heartbeat interval is invalid, err: %v, rid: %s", err, rid)
+		writeStreamRespError(resp, defErr.CCErrorf(common.CCErrCommParamsInvalid, streamHeartbeatParam))
+		return
+	}
+
+	flusher, ok := resp.ResponseWriter.(http.Flusher)
+	if !ok {
+		blog.Errorf("watch event stream, but response writer does not support flush, rid: %s", rid)
+		writeStreamRespError(resp, defErr.CCError(common.CCErrCommHTTPDoRequestFailed))
+		return
+	}
+
+	ctx, cancel := context.WithCancel(req.Request.Context())
+	defer cancel()
+
+	stream := &eventStream{writer: resp, flusher: flusher, cursor: options.Cursor}
+	if err = stream.start(); err != nil {
+		blog.Errorf("watch event stream, but start stream failed, err: %v, rid: %s", err, rid)
+		return
+	}
+
+	go stream.keepAlive(ctx, cancel, heartbeat, rid)
+
+	blog.Infof("start watch event stream, resource: %s, cursor: %s, start from: %d, rid: %s", options.Resource,
+		options.Cursor, options.StartFrom, rid)
+
+	for {
+		select {
+		case <-ctx.Done():
+			blog.Infof("watch event stream of %s is closed, last cursor: %s, rid: %s", options.Resource,
+				stream.lastCursor(), rid)
+			return
+		default:
+		}
+
+		result, err := s.watchStreamEvents(ctx, header, options)
+		if err != nil {
+			if ctx.Err() != nil {
+				continue
+			}
+
+			blog.Errorf("watch event stream failed, err: %v, options: %+v, rid: %s", err, options, rid)
+			time.Sleep(streamErrorInterval)
+			stream.sendError(err)
+			return
+		}
+
+		if err = stream.sendEvents(result); err != nil {
+			blog.Errorf("send watch events to stream failed, err: %v, rid: %s", err, rid)
+			return
+		}
+
+		// continue watching from the last received cursor
+		if cursor := stream.lastCursor(); cursor != "" {
+			options.Cursor = cursor
+			options.StartFrom = 0
+		}
+	}
+}
+
+// watchStreamEvents watches events from cache service with the stream's current watch options
+func (s *Service) watchStreamEvents(ctx context.Context, header http.Header, options *watch.WatchEventOptions) (
+	*watch.WatchResp, error) {
+
+	resp, err := s.engine.CoreAPI.CacheService().Cache().Event().WatchEvent(ctx, header, options)
+	if err != nil {
+		return nil, err
+	}
+
+	result := new(watch.WatchResp)
+	if err := json.Unmarshal([]byte(*resp), result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// resumeStreamOptions resumes the stream from the last cursor received by the client if it is reconnecting
+func resumeStreamOptions(header http.Header, options *watch.WatchEventOptions) {
+	if lastCursor := header.Get(watch.LastEventIDHeader); lastCursor != "" {
+		options.Cursor = lastCursor
+		options.StartFrom = 0
+	}
+}
+
+// parseStreamHeartbeat parses the heartbeat interval seconds of the watch stream
+func parseStreamHeartbeat(value string) (time.Duration, error) {
+	if value == "" {
+		return defaultStreamHeartbeat, nil
+	}
+
+	seconds, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+
+	heartbeat := time.Duration(seconds) * time.Second
+	if heartbeat < minStreamHeartbeat || heartbeat > maxStreamHeartbeat {
+		return 0, fmt.Errorf("heartbeat interval should be in [%v, %v]", minStreamHeartbeat, maxStreamHeartbeat)
+	}
+	return heartbeat, nil
+}
+
+// writeStreamRespError responses the error before the stream is started
+func writeStreamRespError(resp *restful.Response, err errors.CCErrorCoder) {
+	_ = resp.WriteEntity(metadata.BaseResp{Result: false, Code: err.GetCode(), ErrMsg: err.Error()})
+}
+
+// eventStream is the server-sent events stream of the watched events
+type eventStream struct {
+	lock    sync.Mutex
+	writer  io.Writer
+	flusher http.Flusher
+	// cursor is the last cursor that is sent to the client
+	cursor string
+	// lastWrite is the time of the last frame that is sent to the client
+	lastWrite time.Time
+}
+
+// start writes the stream headers and the reconnection time to the client
+func (e *eventStream) start() error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if rw, ok := e.writer.(http.ResponseWriter); ok {
+		rw.Header().Set("Content-Type", watch.EventStreamContentType)
+		rw.Header().Set("Cache-Control", "no-cache")
+		rw.Header().Set("Connection", "keep-alive")
+		// disable the buffering of the reverse proxy like nginx
+		rw.Header().Set("X-Accel-Buffering", "no")
+		rw.WriteHeader(http.StatusOK)
+	}
+
+	return e.write(fmt.Sprintf("retry: %d\n\n", streamRetryMilliseconds))
+}
+
+// sendEvents sends the watched events to the client, if no event is watched, only the cursor is updated
+func (e *eventStream) sendEvents(result *watch.WatchResp) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if result == nil || len(result.Events) == 0 {
+		return nil
+	}
+
+	if !result.Watched {
+		// the cursor is sent to the client with the next heartbeat
+		e.cursor = result.Events[len(result.Events)-1].Cursor
+		return nil
+	}
+
+	for _, event := range result.Events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		if err = e.write(encodeStreamFrame(event.Cursor, string(event.EventType), data)); err != nil {
+			return err
+		}
+		e.cursor = event.Cursor
+	}
+	return nil
+}
+
+// keepAlive sends heartbeat frames with the latest cursor periodically, the stream is canceled if sending failed
+func (e *eventStream) keepAlive(ctx context.Context, cancel context.CancelFunc, interval time.Duration, rid string) {
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := e.sendHeartbeat(interval); err != nil {
+			blog.Errorf("send heartbeat to watch event stream failed, err: %v, rid: %s", err, rid)
+			cancel()
+			return
+		}
+	}
+}
+
+// sendHeartbeat sends a heartbeat frame if no frame is sent in the heartbeat interval
+func (e *eventStream) sendHeartbeat(interval time.Duration) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if time.Since(e.lastWrite) < interval {
+		return nil
+	}
+
+	data, err := json.Marshal(map[string]string{"bk_cursor": e.cursor})
+	if err != nil {
+		return err
+	}
+	return e.write(encodeStreamFrame(e.cursor, streamEventHeartbeat, data))
+}
+
+// sendError sends the error frame to the client
+func (e *eventStream) sendError(err error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	resp := metadata.BaseResp{Result: false, Code: common.CCErrCommHTTPDoRequestFailed, ErrMsg: err.Error()}
+	if ccErr, ok := err.(errors.CCErrorCoder); ok {
+		resp.Code = ccErr.GetCode()
+	}
+
+	data, marshalErr := json.Marshal(resp)
+	if marshalErr != nil {
+		return
+	}
+	_ = e.write(encodeStreamFrame("", streamEventError, data))
+}
+
+func (e *eventStream) lastCursor() string {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.cursor
+}
+
+// write writes the frame to the client and flushes it immediately, the caller must hold the lock
+func (e *eventStream) write(frame string) error {
+	if _, err := io.WriteString(e.writer, frame); err != nil {
+		return err
+	}
+	e.flusher.Flush()
+	e.lastWrite = time.Now()
+	return nil
+}
+
+// frameDataReplacer removes the line breaks in the json data, they can only be whitespaces in json since the line
+// breaks in json strings are escaped, and a line break would end the data field of the frame.
+var frameDataReplacer = strings.NewReplacer("\r", "", "\n", "")
+
+// encodeStreamFrame encodes the server-sent event frame
+func encodeStreamFrame(id, event string, data []byte) string {
+	frame := ""
+	if id != "" {
+		frame += "id: " + id + "\n"
+	}
+	if event != "" {
+		frame += "event: " + event + "\n"
+	}
+	return frame + "data: " + frameDataReplacer.Replace(string(data)) + "\n\n"
+}
@@ -0,0 +1,176 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"configcenter/src/common/watch"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeStreamFrame(t *testing.T) {
+	tests := []struct {
+		name   string
+		id     string
+		event  string
+		data   string
+		expect string
+	}{
+		{
+			name:   "event frame",
+			id:     "cursor1",
+			event:  "create",
+			data:   `{"a":1}`,
+			expect: "id: cursor1\nevent: create\ndata: {\"a\":1}\n\n",
+		},
+		{
+			name:   "frame without id",
+			event:  streamEventError,
+			data:   `{"bk_error_code":1}`,
+			expect: "event: error\ndata: {\"bk_error_code\":1}\n\n",
+		},
+		{
+			name:   "frame without id and event",
+			data:   `{}`,
+			expect: "data: {}\n\n",
+		},
+		{
+			name:   "line breaks are removed from data",
+			id:     "cursor2",
+			event:  "update",
+			data:   "{\r\n  \"a\": \"x\\ny\"\n}",
+			expect: "id: cursor2\nevent: update\ndata: {  \"a\": \"x\\ny\"}\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expect, encodeStreamFrame(tt.id, tt.event, []byte(tt.data)))
+		})
+	}
+}
+
+func TestResumeStreamOptions(t *testing.T) {
+	tests := []struct {
+		name       string
+		lastID     string
+		options    watch.WatchEventOptions
+		expectCur  string
+		expectFrom int64
+	}{
+		{
+			name:       "new stream keeps options",
+			options:    watch.WatchEventOptions{Cursor: "cursor1", StartFrom: 0},
+			expectCur:  "cursor1",
+			expectFrom: 0,
+		},
+		{
+			name:       "new stream starts from time",
+			options:    watch.WatchEventOptions{StartFrom: 100},
+			expectCur:  "",
+			expectFrom: 100,
+		},
+		{
+			name:       "resume from last event id",
+			lastID:     "cursor2",
+			options:    watch.WatchEventOptions{Cursor: "cursor1", StartFrom: 100},
+			expectCur:  "cursor2",
+			expectFrom: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := make(http.Header)
+			if tt.lastID != "" {
+				header.Set(watch.LastEventIDHeader, tt.lastID)
+			}
+			options := tt.options
+			resumeStreamOptions(header, &options)
+			require.Equal(t, tt.expectCur, options.Cursor)
+			require.Equal(t, tt.expectFrom, options.StartFrom)
+		})
+	}
+}
+
+func TestParseStreamHeartbeat(t *testing.T) {
+	tests := []struct {
+		value   string
+		expect  time.Duration
+		wantErr bool
+	}{
+		{value: "", expect: defaultStreamHeartbeat},
+		{value: "5", expect: 5 * time.Second},
+		{value: "60", expect: 60 * time.Second},
+		{value: "4", wantErr: true},
+		{value: "61", wantErr: true},
+		{value: "abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			heartbeat, err := parseStreamHeartbeat(tt.value)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expect, heartbeat)
+		})
+	}
+}
+
+func TestEventStream(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	stream := &eventStream{writer: recorder, flusher: recorder, cursor: "cursor0"}
+
+	require.NoError(t, stream.start())
+	require.Equal(t, watch.EventStreamContentType, recorder.Header().Get("Content-Type"))
+	require.Equal(t, "retry: 3000\n\n", recorder.Body.String())
+
+	// the cursor of the events that are not watched is only sent with the heartbeat
+	recorder.Body.Reset()
+	require.NoError(t, stream.sendEvents(&watch.WatchResp{Watched: false,
+		Events: []*watch.WatchEventDetail{{Cursor: "cursor1"}}}))
+	require.Empty(t, recorder.Body.String())
+	require.Equal(t, "cursor1", stream.lastCursor())
+
+	require.NoError(t, stream.sendEvents(&watch.WatchResp{Watched: true, Events: []*watch.WatchEventDetail{
+		{Cursor: "cursor2", EventType: watch.Create},
+		{Cursor: "cursor3", EventType: watch.Delete},
+	}}))
+	frames := strings.Split(strings.TrimSuffix(recorder.Body.String(), "\n\n"), "\n\n")
+	require.Len(t, frames, 2)
+	require.True(t, strings.HasPrefix(frames[0], "id: cursor2\nevent: create\ndata: "))
+	require.True(t, strings.HasPrefix(frames[1], "id: cursor3\nevent: delete\ndata: "))
+	require.Equal(t, "cursor3", stream.lastCursor())
+
+	// no heartbeat is sent if a frame is sent in the interval
+	recorder.Body.Reset()
+	require.NoError(t, stream.sendHeartbeat(time.Minute))
+	require.Empty(t, recorder.Body.String())
+
+	stream.lastWrite = time.Now().Add(-time.Minute)
+	require.NoError(t, stream.sendHeartbeat(time.Minute))
+	require.Equal(t, "id: cursor3\nevent: heartbeat\ndata: {\"bk_cursor\":\"cursor3\"}\n\n", recorder.Body.String())
+}
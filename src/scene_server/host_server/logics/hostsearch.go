@@ -361,7 +361,8 @@ func init() {
 
 	strAttrTypes := []string{common.FieldTypeSingleChar, common.FieldTypeEnum, common.FieldTypeEnumMulti,
 		common.FieldTypeDate, common.FieldTypeTime, common.FieldTypeLongChar, common.FieldTypeUser,
-		common.FieldTypeTimeZone, common.FieldTypeList}
+		common.FieldTypeTimeZone, common.FieldTypeList, common.FieldTypeIPAddr, common.FieldTypeCIDR,
+		common.FieldTypeURL}
 	for _, attrType := range strAttrTypes {
 		attrTypeSupportedOpMap[attrType] = strOpMap
 	}
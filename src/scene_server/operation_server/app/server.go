@@ -74,7 +74,7 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 	}
 
 	iamCli := new(iam.IAM)
-	if auth.EnableAuthorize() {
+	if auth.EnableIAM() {
 		blog.Info("enable auth center access")
 		iamCli, err = iam.NewIAM(operationSvr.Config.Auth, engine.Metric().Registry())
 		if err != nil {
@@ -17,13 +17,19 @@
 package service
 
 import (
+	"encoding/json"
+
 	"configcenter/src/common"
+	"configcenter/src/common/blog"
 	"configcenter/src/common/http/rest"
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/util"
 )
 
+// statisticQueryField is the statistic query field of the chart config
+const statisticQueryField = "statistic_query"
+
 // CreateOperationChart TODO
 func (o *OperationServer) CreateOperationChart(ctx *rest.Contexts) {
 	chartInfo := new(metadata.ChartConfig)
@@ -32,24 +38,24 @@ func (o *OperationServer) CreateOperationChart(ctx *rest.Contexts) {
 		return
 	}
 
-	// 图表是否已经存在
-	filterCondition := mapstr.MapStr{}
-	filterCondition[common.BKObjIDField] = chartInfo.ObjID
-	filterCondition[common.OperationReportType] = chartInfo.ReportType
-	filterCondition["field"] = chartInfo.Field
-	exist, err := o.CoreAPI.CoreService().Operation().SearchChartCommon(ctx.Kit.Ctx, ctx.Kit.Header, filterCondition)
-	if err != nil {
-		ctx.RespErrorCodeOnly(common.CCErrOperationNewAddStatisticFail,
-			"new add operation chart fail, err: %v, rid: %v", err, ctx.Kit.Rid)
-		return
-	}
-	if exist.Data.Count > 0 {
-		ctx.RespErrorCodeOnly(common.CCErrOperationChartAlreadyExist,
-			"create operation chart fail, err: chart already exist, rid: %v", ctx.Kit.Rid)
+	// 统计查询报表同一模型可以有多个，不需要检查图表是否已经存在
+	if chartInfo.ReportType == common.StatisticQueryChart {
+		if chartInfo.StatisticQuery == nil {
+			ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, statisticQueryField))
+			return
+		}
+		if err := o.validateStatisticQuery(ctx.Kit, chartInfo.StatisticQuery); err != nil {
+			ctx.RespAutoError(err)
+			return
+		}
+		chartInfo.ObjID = chartInfo.StatisticQuery.ObjID
+	} else if err := o.checkChartExist(ctx.Kit, chartInfo); err != nil {
+		ctx.RespAutoError(err)
 		return
 	}
 
 	var id uint64
+	var err error
 	resp := new(metadata.SearchChartCommon)
 
 	defer func() {
@@ -67,7 +73,7 @@ func (o *OperationServer) CreateOperationChart(ctx *rest.Contexts) {
 	}()
 
 	// 自定义报表
-	if chartInfo.ReportType == common.OperationCustom {
+	if chartInfo.ReportType == common.OperationCustom || chartInfo.ReportType == common.StatisticQueryChart {
 		result, err := o.Engine.CoreAPI.CoreService().Operation().CreateOperationChart(ctx.Kit.Ctx, ctx.Kit.Header,
 			chartInfo)
 		if err != nil {
@@ -91,6 +97,24 @@ func (o *OperationServer) CreateOperationChart(ctx *rest.Contexts) {
 	id = configID
 }
 
+// checkChartExist check if the chart of the same object, report type and field already exists
+func (o *OperationServer) checkChartExist(kit *rest.Kit, chartInfo *metadata.ChartConfig) error {
+	filterCondition := mapstr.MapStr{}
+	filterCondition[common.BKObjIDField] = chartInfo.ObjID
+	filterCondition[common.OperationReportType] = chartInfo.ReportType
+	filterCondition["field"] = chartInfo.Field
+	exist, err := o.CoreAPI.CoreService().Operation().SearchChartCommon(kit.Ctx, kit.Header, filterCondition)
+	if err != nil {
+		blog.Errorf("new add operation chart fail, err: %v, rid: %v", err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrOperationNewAddStatisticFail)
+	}
+	if exist.Data.Count > 0 {
+		blog.Errorf("create operation chart fail, err: chart already exist, rid: %v", kit.Rid)
+		return kit.CCError.CCError(common.CCErrOperationChartAlreadyExist)
+	}
+	return nil
+}
+
 // DeleteOperationChart TODO
 func (o *OperationServer) DeleteOperationChart(ctx *rest.Contexts) {
 	id := ctx.Request.PathParameter("id")
@@ -119,6 +143,38 @@ func (o *OperationServer) SearchOperationChart(ctx *rest.Contexts) {
 	ctx.RespEntity(result.Data)
 }
 
+// validateStatisticQuery validate the statistic query of the chart, the dimensions and the metric field must be
+// the attributes of the model or the audit log fields, so that the chart can be refreshed
+func (o *OperationServer) validateStatisticQuery(kit *rest.Kit, query *metadata.StatisticQueryOption) error {
+	if rawErr := query.Validate(); rawErr.ErrCode != 0 {
+		return rawErr.ToCCError(kit.CCError)
+	}
+
+	var attrTypes map[string]string
+	if query.Source == metadata.StatisticSourceInstance {
+		input := &metadata.QueryCondition{
+			Condition: mapstr.MapStr{common.BKObjIDField: query.ObjID},
+			Fields:    []string{common.BKPropertyIDField, common.BKPropertyTypeField},
+			Page:      metadata.BasePage{Limit: common.BKNoLimit},
+		}
+		attrs, err := o.CoreAPI.CoreService().Model().ReadModelAttr(kit.Ctx, kit.Header, query.ObjID, input)
+		if err != nil {
+			blog.Errorf("get attributes of object %s failed, err: %v, rid: %s", query.ObjID, err, kit.Rid)
+			return err
+		}
+
+		attrTypes = make(map[string]string, len(attrs.Info))
+		for _, attr := range attrs.Info {
+			attrTypes[attr.PropertyID] = attr.PropertyType
+		}
+	}
+
+	if rawErr := query.ValidateFields(attrTypes); rawErr.ErrCode != 0 {
+		return rawErr.ToCCError(kit.CCError)
+	}
+	return nil
+}
+
 // UpdateOperationChart TODO
 func (o *OperationServer) UpdateOperationChart(ctx *rest.Contexts) {
 	opt := mapstr.MapStr{}
@@ -127,6 +183,25 @@ func (o *OperationServer) UpdateOperationChart(ctx *rest.Contexts) {
 		return
 	}
 
+	if _, exists := opt[statisticQueryField]; exists {
+		query := new(metadata.StatisticQueryOption)
+		raw, err := json.Marshal(opt[statisticQueryField])
+		if err != nil {
+			ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, statisticQueryField))
+			return
+		}
+		if err := json.Unmarshal(raw, query); err != nil {
+			ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, statisticQueryField))
+			return
+		}
+		if err := o.validateStatisticQuery(ctx.Kit, query); err != nil {
+			ctx.RespAutoError(err)
+			return
+		}
+		opt[statisticQueryField] = query
+		opt[common.BKObjIDField] = query.ObjID
+	}
+
 	if _, err := o.Engine.CoreAPI.CoreService().Operation().UpdateOperationChart(ctx.Kit.Ctx, ctx.Kit.Header,
 		opt); err != nil {
 		ctx.RespErrorCodeOnly(common.CCErrOperationUpdateChartFail,
@@ -213,3 +288,27 @@ func (o *OperationServer) UpdateChartPosition(ctx *rest.Contexts) {
 
 	ctx.RespEntity(nil)
 }
+
+// StatisticQuery count instances or audit logs of an object grouped by dimensions and time bucket
+func (o *OperationServer) StatisticQuery(ctx *rest.Contexts) {
+	opt := new(metadata.StatisticQueryOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	ctx.SetReadPreference(common.SecondaryPreferredMode)
+	result, err := o.CoreAPI.CoreService().Operation().StatisticQuery(ctx.Kit.Ctx, ctx.Kit.Header, opt)
+	if err != nil {
+		blog.Errorf("statistic query failed, err: %v, rid: %s", err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
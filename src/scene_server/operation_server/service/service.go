@@ -133,6 +133,8 @@ func (o *OperationServer) newOperationService(web *restful.WebService) {
 		Handler: o.SearchChartData})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/update/operation/chart/position",
 		Handler: o.UpdateChartPosition})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/operation/statistic",
+		Handler: o.StatisticQuery})
 
 	utility.AddToRestfulWebService(web)
 }
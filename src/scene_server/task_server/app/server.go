@@ -107,6 +107,9 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 	// cron job delete history task
 	go taskSrv.Service.TimerDeleteHistoryTask(ctx)
 
+	// cron job create tasks of the due task schedules
+	go taskSrv.Service.TimerTriggerTaskSchedule(ctx)
+
 	if err := backbone.StartServer(ctx, cancel, engine, service.WebService(), true); err != nil {
 		blog.Errorf("start backbone failed, err: %+v", err)
 		return err
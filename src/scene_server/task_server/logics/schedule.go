@@ -0,0 +1,350 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package logics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/task_server/taskconfig"
+
+	"github.com/robfig/cron"
+)
+
+// GetNextScheduleTime get the next trigger time of the cron expression after the specified time
+func GetNextScheduleTime(spec string, after time.Time) (time.Time, error) {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	next := schedule.Next(after)
+	if next.IsZero() {
+		return next, fmt.Errorf("cron expression %s has no next trigger time", spec)
+	}
+	return next, nil
+}
+
+// getScheduleStartTime get the time after which the schedule is triggered, the cron expression is triggered on or
+// after the not before time, so the start time is one second earlier than it.
+func getScheduleStartTime(notBefore *time.Time, now time.Time) time.Time {
+	if notBefore != nil && notBefore.After(now) {
+		return notBefore.Add(-time.Second)
+	}
+	return now
+}
+
+// CreateSchedule create task schedule which creates tasks by the cron expression
+func (lgc *Logics) CreateSchedule(kit *rest.Kit, input *metadata.CreateTaskScheduleRequest) (
+	*metadata.APITaskSchedule, error) {
+
+	if rawErr := input.Validate(taskconfig.IsCodeTaskType); rawErr.ErrCode != 0 {
+		return nil, rawErr.ToCCError(kit.CCError)
+	}
+
+	now := time.Now()
+	nextTime, err := GetNextScheduleTime(input.Cron, getScheduleStartTime(input.NotBefore, now))
+	if err != nil {
+		blog.Errorf("parse task schedule cron %s failed, err: %v, rid: %s", input.Cron, err, kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "cron")
+	}
+
+	schedule := &metadata.APITaskSchedule{
+		ScheduleID:      getStrTaskID("schedule"),
+		TaskType:        input.TaskType,
+		InstID:          input.InstID,
+		Extra:           input.Extra,
+		Data:            input.Data,
+		Cron:            input.Cron,
+		NotBefore:       input.NotBefore,
+		Status:          metadata.APITaskScheduleStatusEnabled,
+		NextTime:        nextTime,
+		User:            kit.User,
+		Header:          GetDBHTTPHeader(kit.Header),
+		SupplierAccount: kit.SupplierAccount,
+		CreateTime:      now,
+		LastTime:        now,
+	}
+
+	if err = lgc.db.Table(common.BKTableNameAPITaskSchedule).Insert(kit.Ctx, schedule); err != nil {
+		blog.Errorf("create task schedule failed, data: %#v, err: %v, rid: %s", schedule, err, kit.Rid)
+		return nil, kit.CCError.Error(common.CCErrCommDBInsertFailed)
+	}
+
+	return schedule, nil
+}
+
+// ListSchedule list task schedules
+func (lgc *Logics) ListSchedule(kit *rest.Kit, input *metadata.ListAPITaskScheduleRequest) (
+	[]metadata.APITaskSchedule, uint64, error) {
+
+	if input.Condition == nil {
+		input.Condition = mapstr.New()
+	}
+
+	if input.Page.IsIllegal() {
+		return nil, 0, kit.CCError.Errorf(common.CCErrCommPageLimitIsExceeded)
+	}
+
+	cnt, err := lgc.db.Table(common.BKTableNameAPITaskSchedule).Find(input.Condition).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("count task schedule failed, cond: %#v, err: %v, rid: %s", input.Condition, err, kit.Rid)
+		return nil, 0, kit.CCError.Error(common.CCErrCommDBSelectFailed)
+	}
+
+	rows := make([]metadata.APITaskSchedule, 0)
+	err = lgc.db.Table(common.BKTableNameAPITaskSchedule).Find(input.Condition).Start(uint64(input.Page.Start)).
+		Limit(uint64(input.Page.Limit)).Sort(input.Page.Sort).All(kit.Ctx, &rows)
+	if err != nil {
+		blog.Errorf("list task schedule failed, input: %#v, err: %v, rid: %s", input, err, kit.Rid)
+		return nil, 0, kit.CCError.Error(common.CCErrCommDBSelectFailed)
+	}
+
+	return rows, cnt, nil
+}
+
+// PauseSchedule pause the enabled task schedules, the tasks that are already created are not affected
+func (lgc *Logics) PauseSchedule(kit *rest.Kit, scheduleIDs []string) error {
+	cond := mapstr.MapStr{
+		metadata.APITaskScheduleIDField: mapstr.MapStr{common.BKDBIN: scheduleIDs},
+		common.BKStatusField:            metadata.APITaskScheduleStatusEnabled,
+	}
+
+	data := mapstr.MapStr{
+		common.BKStatusField: metadata.APITaskScheduleStatusPaused,
+		common.LastTimeField: time.Now(),
+	}
+
+	if err := lgc.db.Table(common.BKTableNameAPITaskSchedule).Update(kit.Ctx, cond, data); err != nil {
+		blog.Errorf("pause task schedule failed, cond: %#v, err: %v, rid: %s", cond, err, kit.Rid)
+		return kit.CCError.Error(common.CCErrCommDBUpdateFailed)
+	}
+
+	return nil
+}
+
+// ResumeSchedule resume the paused task schedules, the trigger times missed during the pause are skipped
+func (lgc *Logics) ResumeSchedule(kit *rest.Kit, scheduleIDs []string) error {
+	cond := mapstr.MapStr{
+		metadata.APITaskScheduleIDField: mapstr.MapStr{common.BKDBIN: scheduleIDs},
+		common.BKStatusField:            metadata.APITaskScheduleStatusPaused,
+	}
+
+	schedules := make([]metadata.APITaskSchedule, 0)
+	err := lgc.db.Table(common.BKTableNameAPITaskSchedule).Find(cond).All(kit.Ctx, &schedules)
+	if err != nil {
+		blog.Errorf("get paused task schedules failed, cond: %#v, err: %v, rid: %s", cond, err, kit.Rid)
+		return kit.CCError.Error(common.CCErrCommDBSelectFailed)
+	}
+
+	now := time.Now()
+	for _, schedule := range schedules {
+		nextTime, err := GetNextScheduleTime(schedule.Cron, getScheduleStartTime(schedule.NotBefore, now))
+		if err != nil {
+			blog.Errorf("parse task schedule %s cron %s failed, err: %v, rid: %s", schedule.ScheduleID,
+				schedule.Cron, err, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "cron")
+		}
+
+		updateCond := mapstr.MapStr{
+			metadata.APITaskScheduleIDField: schedule.ScheduleID,
+			common.BKStatusField:            metadata.APITaskScheduleStatusPaused,
+		}
+
+		data := mapstr.MapStr{
+			common.BKStatusField:                  metadata.APITaskScheduleStatusEnabled,
+			metadata.APITaskScheduleNextTimeField: nextTime,
+			common.LastTimeField:                  now,
+		}
+
+		err = lgc.db.Table(common.BKTableNameAPITaskSchedule).Update(kit.Ctx, updateCond, data)
+		if err != nil {
+			blog.Errorf("resume task schedule failed, cond: %#v, err: %v, rid: %s", updateCond, err, kit.Rid)
+			return kit.CCError.Error(common.CCErrCommDBUpdateFailed)
+		}
+	}
+
+	return nil
+}
+
+// DeleteSchedule delete task schedules, the tasks that are already created are not affected
+func (lgc *Logics) DeleteSchedule(kit *rest.Kit, scheduleIDs []string) error {
+	cond := mapstr.MapStr{
+		metadata.APITaskScheduleIDField: mapstr.MapStr{common.BKDBIN: scheduleIDs},
+	}
+
+	if err := lgc.db.Table(common.BKTableNameAPITaskSchedule).Delete(kit.Ctx, cond); err != nil {
+		blog.Errorf("delete task schedule failed, cond: %#v, err: %v, rid: %s", cond, err, kit.Rid)
+		return kit.CCError.Error(common.CCErrCommDBDeleteFailed)
+	}
+
+	return nil
+}
+
+// ListDueSchedules list the enabled task schedules whose next trigger time is reached
+func (lgc *Logics) ListDueSchedules(ctx context.Context, now time.Time, limit uint64, rid string) (
+	[]metadata.APITaskSchedule, error) {
+
+	cond := mapstr.MapStr{
+		common.BKStatusField:                  metadata.APITaskScheduleStatusEnabled,
+		metadata.APITaskScheduleNextTimeField: mapstr.MapStr{common.BKDBLTE: now},
+	}
+
+	schedules := make([]metadata.APITaskSchedule, 0)
+	err := lgc.db.Table(common.BKTableNameAPITaskSchedule).Find(cond).Sort(metadata.APITaskScheduleNextTimeField).
+		Limit(limit).All(ctx, &schedules)
+	if err != nil {
+		blog.Errorf("list due task schedules failed, cond: %#v, err: %v, rid: %s", cond, err, rid)
+		return nil, err
+	}
+
+	return schedules, nil
+}
+
+// scheduleTrigger is the update of the task schedule to claim one trigger and the update to roll it back
+type scheduleTrigger struct {
+	nextTime     time.Time
+	claimCond    mapstr.MapStr
+	claimData    mapstr.MapStr
+	rollbackCond mapstr.MapStr
+	rollbackData mapstr.MapStr
+}
+
+// newScheduleTrigger generates the trigger of the due task schedule. The trigger is claimed by moving the next trigger
+// time forward only if it is not changed, so that the schedule is only triggered once. The rollback restores the
+// trigger times only if they are not changed after the claim.
+func newScheduleTrigger(schedule *metadata.APITaskSchedule, now time.Time) (*scheduleTrigger, error) {
+	nextTime, err := GetNextScheduleTime(schedule.Cron, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return &scheduleTrigger{
+		nextTime: nextTime,
+		claimCond: mapstr.MapStr{
+			metadata.APITaskScheduleIDField:       schedule.ScheduleID,
+			common.BKStatusField:                  metadata.APITaskScheduleStatusEnabled,
+			metadata.APITaskScheduleNextTimeField: schedule.NextTime,
+		},
+		claimData: mapstr.MapStr{
+			metadata.APITaskScheduleNextTimeField:        nextTime,
+			metadata.APITaskScheduleLastTriggerTimeField: now,
+			common.LastTimeField:                         now,
+		},
+		rollbackCond: mapstr.MapStr{
+			metadata.APITaskScheduleIDField:       schedule.ScheduleID,
+			metadata.APITaskScheduleNextTimeField: nextTime,
+		},
+		rollbackData: mapstr.MapStr{
+			metadata.APITaskScheduleNextTimeField:        schedule.NextTime,
+			metadata.APITaskScheduleLastTriggerTimeField: schedule.LastTriggerTime,
+			common.LastTimeField:                         schedule.LastTime,
+		},
+	}, nil
+}
+
+// TriggerSchedule create a task of the due task schedule and move its next trigger time forward. If the task created
+// by the previous trigger is still unfinished, this trigger is skipped. If the task can not be created, the next
+// trigger time is rolled back so that the schedule is triggered again in the next check, unless the task can never be
+// created by the schedule, in which case the schedule is paused.
+func (lgc *Logics) TriggerSchedule(kit *rest.Kit, schedule *metadata.APITaskSchedule, now time.Time) error {
+	if !taskconfig.IsCodeTaskType(schedule.TaskType) {
+		blog.Errorf("task schedule %s task type %s is not supported, pause it, rid: %s", schedule.ScheduleID,
+			schedule.TaskType, kit.Rid)
+		return lgc.PauseSchedule(kit, []string{schedule.ScheduleID})
+	}
+
+	trigger, err := newScheduleTrigger(schedule, now)
+	if err != nil {
+		blog.Errorf("parse task schedule %s cron %s failed, pause it, err: %v, rid: %s", schedule.ScheduleID,
+			schedule.Cron, err, kit.Rid)
+		return lgc.PauseSchedule(kit, []string{schedule.ScheduleID})
+	}
+
+	cnt, err := lgc.db.Table(common.BKTableNameAPITaskSchedule).UpdateMany(kit.Ctx, trigger.claimCond,
+		trigger.claimData)
+	if err != nil {
+		blog.Errorf("update task schedule next time failed, cond: %#v, err: %v, rid: %s", trigger.claimCond, err,
+			kit.Rid)
+		return kit.CCError.Error(common.CCErrCommDBUpdateFailed)
+	}
+
+	if cnt == 0 {
+		blog.Infof("task schedule %s is paused or already triggered, skip, rid: %s", schedule.ScheduleID, kit.Rid)
+		return nil
+	}
+
+	task, err := lgc.Create(kit, &metadata.CreateTaskRequest{
+		TaskType: schedule.TaskType,
+		InstID:   schedule.InstID,
+		Extra:    schedule.Extra,
+		Data:     schedule.Data,
+	})
+	if err != nil {
+		ccErr, ok := err.(errors.CCErrorCoder)
+		if ok && ccErr.GetCode() == common.CCErrTaskCreateConflict {
+			blog.Infof("task created by schedule %s is unfinished, skip this trigger, rid: %s", schedule.ScheduleID,
+				kit.Rid)
+			return nil
+		}
+
+		// the task parameters of the schedule are invalid, retrying can not create the task
+		if ok && isPermanentCreateTaskErr(ccErr.GetCode()) {
+			blog.Errorf("task schedule %s can not create task, pause it, err: %v, rid: %s", schedule.ScheduleID, err,
+				kit.Rid)
+			return lgc.PauseSchedule(kit, []string{schedule.ScheduleID})
+		}
+		blog.Errorf("create task by schedule %s failed, err: %v, rid: %s", schedule.ScheduleID, err, kit.Rid)
+
+		rollbackErr := lgc.db.Table(common.BKTableNameAPITaskSchedule).Update(kit.Ctx, trigger.rollbackCond,
+			trigger.rollbackData)
+		if rollbackErr != nil {
+			blog.Errorf("roll back task schedule %s next time failed, this trigger is missed, err: %v, rid: %s",
+				schedule.ScheduleID, rollbackErr, kit.Rid)
+		}
+		return err
+	}
+
+	updateCond := mapstr.MapStr{metadata.APITaskScheduleIDField: schedule.ScheduleID}
+	updateData := mapstr.MapStr{metadata.APITaskScheduleLastTaskIDField: task.TaskID}
+	if err = lgc.db.Table(common.BKTableNameAPITaskSchedule).Update(kit.Ctx, updateCond, updateData); err != nil {
+		blog.Errorf("update task schedule %s last task id %s failed, err: %v, rid: %s", schedule.ScheduleID,
+			task.TaskID, err, kit.Rid)
+		return kit.CCError.Error(common.CCErrCommDBUpdateFailed)
+	}
+
+	blog.Infof("task schedule %s created task %s, next time: %v, rid: %s", schedule.ScheduleID, task.TaskID,
+		trigger.nextTime, kit.Rid)
+	return nil
+}
+
+// isPermanentCreateTaskErr returns if the create task error is caused by the invalid task parameters
+func isPermanentCreateTaskErr(code int) bool {
+	switch code {
+	case common.CCErrCommParamsNeedString, common.CCErrCommParamsNeedSet, common.CCErrCommParamsInvalid,
+		common.CCErrCommParamsIsInvalid:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,304 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package logics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	ccErr "configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/mongo/memory"
+	"configcenter/src/storage/dal/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetNextScheduleTime(t *testing.T) {
+	after := time.Date(2024, 1, 1, 10, 30, 0, 0, time.Local)
+
+	tests := []struct {
+		spec    string
+		expect  time.Time
+		wantErr bool
+	}{
+		{spec: "0 * * * *", expect: time.Date(2024, 1, 1, 11, 0, 0, 0, time.Local)},
+		{spec: "*/10 * * * *", expect: time.Date(2024, 1, 1, 10, 40, 0, 0, time.Local)},
+		{spec: "0 2 * * *", expect: time.Date(2024, 1, 2, 2, 0, 0, 0, time.Local)},
+		{spec: "0 0 1 * *", expect: time.Date(2024, 2, 1, 0, 0, 0, 0, time.Local)},
+		{spec: "@daily", expect: time.Date(2024, 1, 2, 0, 0, 0, 0, time.Local)},
+		{spec: "0 0 30 2 *", wantErr: true},
+		{spec: "61 * * * *", wantErr: true},
+		{spec: "* * * *", wantErr: true},
+		{spec: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			next, err := GetNextScheduleTime(tt.spec, after)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expect, next)
+		})
+	}
+}
+
+func TestScheduleNotBefore(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 30, 0, 0, time.Local)
+	past := now.Add(-time.Hour)
+	notBefore := time.Date(2024, 1, 3, 2, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name      string
+		notBefore *time.Time
+		expect    time.Time
+	}{
+		{
+			name:   "no not before time",
+			expect: time.Date(2024, 1, 2, 2, 0, 0, 0, time.Local),
+		},
+		{
+			name:      "not before time is passed",
+			notBefore: &past,
+			expect:    time.Date(2024, 1, 2, 2, 0, 0, 0, time.Local),
+		},
+		{
+			name:      "triggered on the not before time",
+			notBefore: &notBefore,
+			expect:    notBefore,
+		},
+		{
+			name:      "triggered after the not before time",
+			notBefore: func() *time.Time { t := notBefore.Add(time.Minute); return &t }(),
+			expect:    time.Date(2024, 1, 4, 2, 0, 0, 0, time.Local),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, err := GetNextScheduleTime("0 2 * * *", getScheduleStartTime(tt.notBefore, now))
+			require.NoError(t, err)
+			require.Equal(t, tt.expect, next)
+		})
+	}
+}
+
+func TestNewScheduleTrigger(t *testing.T) {
+	lastTrigger := time.Date(2024, 1, 1, 9, 0, 0, 0, time.Local)
+	schedule := &metadata.APITaskSchedule{
+		ScheduleID:      "schedule1",
+		Cron:            "0 * * * *",
+		NextTime:        time.Date(2024, 1, 1, 10, 0, 0, 0, time.Local),
+		LastTriggerTime: &lastTrigger,
+		LastTime:        lastTrigger,
+	}
+
+	// the trigger is checked late, the missed trigger times are skipped
+	now := time.Date(2024, 1, 1, 12, 5, 0, 0, time.Local)
+	trigger, err := newScheduleTrigger(schedule, now)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 1, 1, 13, 0, 0, 0, time.Local), trigger.nextTime)
+
+	// claim only if the schedule is enabled and not triggered by others
+	require.Equal(t, "schedule1", trigger.claimCond[metadata.APITaskScheduleIDField])
+	require.Equal(t, metadata.APITaskScheduleStatusEnabled, trigger.claimCond[common.BKStatusField])
+	require.Equal(t, schedule.NextTime, trigger.claimCond[metadata.APITaskScheduleNextTimeField])
+	require.Equal(t, trigger.nextTime, trigger.claimData[metadata.APITaskScheduleNextTimeField])
+	require.Equal(t, now, trigger.claimData[metadata.APITaskScheduleLastTriggerTimeField])
+
+	// roll back only if the schedule is not changed after the claim
+	require.Equal(t, "schedule1", trigger.rollbackCond[metadata.APITaskScheduleIDField])
+	require.Equal(t, trigger.nextTime, trigger.rollbackCond[metadata.APITaskScheduleNextTimeField])
+	require.Equal(t, schedule.NextTime, trigger.rollbackData[metadata.APITaskScheduleNextTimeField])
+	require.Equal(t, &lastTrigger, trigger.rollbackData[metadata.APITaskScheduleLastTriggerTimeField])
+	require.Equal(t, lastTrigger, trigger.rollbackData[common.LastTimeField])
+
+	schedule.Cron = "invalid"
+	_, err = newScheduleTrigger(schedule, now)
+	require.Error(t, err)
+}
+
+// failInsertDB is the in-memory db whose insertion into the specified table always fails
+type failInsertDB struct {
+	dal.RDB
+	table string
+}
+
+// Table returns the collection whose insertion fails if it is the specified table
+func (db *failInsertDB) Table(name string) types.Table {
+	if name == db.table {
+		return &failInsertTable{Table: db.RDB.Table(name)}
+	}
+	return db.RDB.Table(name)
+}
+
+type failInsertTable struct {
+	types.Table
+}
+
+// Insert always returns error
+func (t *failInsertTable) Insert(context.Context, interface{}) error {
+	return errors.New("insert failed")
+}
+
+func newScheduleTestKit() *rest.Kit {
+	return &rest.Kit{
+		Rid:             "test-rid",
+		Ctx:             context.Background(),
+		CCError:         ccErr.NewFromCtx(ccErr.EmptyErrorsSetting).CreateDefaultCCErrorIf("en"),
+		User:            "admin",
+		SupplierAccount: common.BKDefaultOwnerID,
+	}
+}
+
+// newDueSchedule creates an enabled task schedule whose next trigger time is reached into the db
+func newDueSchedule(t *testing.T, db dal.RDB, taskType string, data []interface{}) *metadata.APITaskSchedule {
+	nextTime := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	schedule := &metadata.APITaskSchedule{
+		ScheduleID:      getStrTaskID("schedule"),
+		TaskType:        taskType,
+		InstID:          1,
+		Data:            data,
+		Cron:            "0 * * * *",
+		Status:          metadata.APITaskScheduleStatusEnabled,
+		NextTime:        nextTime,
+		SupplierAccount: common.BKDefaultOwnerID,
+		CreateTime:      nextTime.Add(-time.Hour),
+		LastTime:        nextTime.Add(-time.Hour),
+	}
+	require.NoError(t, db.Table(common.BKTableNameAPITaskSchedule).Insert(context.Background(), schedule))
+	return schedule
+}
+
+func getSchedule(t *testing.T, db dal.RDB, scheduleID string) *metadata.APITaskSchedule {
+	schedule := new(metadata.APITaskSchedule)
+	cond := mapstr.MapStr{metadata.APITaskScheduleIDField: scheduleID}
+	require.NoError(t, db.Table(common.BKTableNameAPITaskSchedule).Find(cond).One(context.Background(), schedule))
+	return schedule
+}
+
+func countTasks(t *testing.T, db dal.RDB, taskType string) uint64 {
+	cnt, err := db.Table(common.BKTableNameAPITask).Find(mapstr.MapStr{common.BKTaskTypeField: taskType}).
+		Count(context.Background())
+	require.NoError(t, err)
+	return cnt
+}
+
+func TestTriggerScheduleClaim(t *testing.T) {
+	db := memory.New()
+	lgc := NewLogics(nil, db)
+	kit := newScheduleTestKit()
+	now := time.Date(2024, 1, 1, 10, 0, 5, 0, time.UTC)
+
+	schedule := newDueSchedule(t, db, common.SyncSetTaskFlag, []interface{}{"data"})
+	require.NoError(t, lgc.TriggerSchedule(kit, schedule, now))
+
+	require.EqualValues(t, 1, countTasks(t, db, common.SyncSetTaskFlag))
+	triggered := getSchedule(t, db, schedule.ScheduleID)
+	require.True(t, triggered.NextTime.Equal(time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)))
+	require.NotEmpty(t, triggered.LastTaskID)
+
+	// the schedule is already claimed by the previous trigger, the stale one is skipped
+	require.NoError(t, lgc.TriggerSchedule(kit, schedule, now))
+	require.EqualValues(t, 1, countTasks(t, db, common.SyncSetTaskFlag))
+	require.Equal(t, triggered.LastTaskID, getSchedule(t, db, schedule.ScheduleID).LastTaskID)
+}
+
+func TestTriggerScheduleConflictSkip(t *testing.T) {
+	db := memory.New()
+	lgc := NewLogics(nil, db)
+	kit := newScheduleTestKit()
+	now := time.Date(2024, 1, 1, 10, 0, 5, 0, time.UTC)
+
+	// the task created by the previous trigger is still unfinished
+	schedule := newDueSchedule(t, db, common.SyncSetTaskFlag, []interface{}{"data"})
+	task, err := lgc.Create(kit, &metadata.CreateTaskRequest{TaskType: schedule.TaskType, InstID: schedule.InstID,
+		Data: schedule.Data})
+	require.NoError(t, err)
+
+	require.NoError(t, lgc.TriggerSchedule(kit, schedule, now))
+	require.EqualValues(t, 1, countTasks(t, db, common.SyncSetTaskFlag))
+
+	// this trigger is skipped without rolling back the next trigger time
+	skipped := getSchedule(t, db, schedule.ScheduleID)
+	require.True(t, skipped.NextTime.Equal(time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)))
+	require.NotEqual(t, task.TaskID, skipped.LastTaskID)
+	require.Equal(t, metadata.APITaskScheduleStatusEnabled, skipped.Status)
+}
+
+func TestTriggerScheduleRollback(t *testing.T) {
+	memDB := memory.New()
+	db := &failInsertDB{RDB: memDB, table: common.BKTableNameAPITask}
+	lgc := NewLogics(nil, db)
+	kit := newScheduleTestKit()
+	now := time.Date(2024, 1, 1, 10, 0, 5, 0, time.UTC)
+
+	schedule := newDueSchedule(t, db, common.SyncSetTaskFlag, []interface{}{"data"})
+	require.Error(t, lgc.TriggerSchedule(kit, schedule, now))
+
+	// the next trigger time is rolled back, so the schedule is still due and triggered again in the next check
+	rolledBack := getSchedule(t, db, schedule.ScheduleID)
+	require.True(t, rolledBack.NextTime.Equal(schedule.NextTime))
+	require.Nil(t, rolledBack.LastTriggerTime)
+	require.Equal(t, metadata.APITaskScheduleStatusEnabled, rolledBack.Status)
+
+	due, err := lgc.ListDueSchedules(kit.Ctx, now, 10, kit.Rid)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+}
+
+func TestTriggerSchedulePause(t *testing.T) {
+	db := memory.New()
+	lgc := NewLogics(nil, db)
+	kit := newScheduleTestKit()
+	now := time.Date(2024, 1, 1, 10, 0, 5, 0, time.UTC)
+
+	// the task type is not supported by the task server
+	unknownType := newDueSchedule(t, db, "unknown_task", []interface{}{"data"})
+	require.NoError(t, lgc.TriggerSchedule(kit, unknownType, now))
+	require.Equal(t, metadata.APITaskScheduleStatusPaused, getSchedule(t, db, unknownType.ScheduleID).Status)
+	require.EqualValues(t, 0, countTasks(t, db, "unknown_task"))
+
+	// the task data is invalid, the task can never be created
+	invalidData := newDueSchedule(t, db, common.SyncModuleTaskFlag, nil)
+	require.NoError(t, lgc.TriggerSchedule(kit, invalidData, now))
+	require.Equal(t, metadata.APITaskScheduleStatusPaused, getSchedule(t, db, invalidData.ScheduleID).Status)
+	require.EqualValues(t, 0, countTasks(t, db, common.SyncModuleTaskFlag))
+}
+
+func TestCreateScheduleValidate(t *testing.T) {
+	lgc := NewLogics(nil, memory.New())
+	kit := newScheduleTestKit()
+
+	_, err := lgc.CreateSchedule(kit, &metadata.CreateTaskScheduleRequest{TaskType: "unknown_task",
+		Data: []interface{}{"data"}, Cron: "0 * * * *"})
+	require.Error(t, err)
+
+	schedule, err := lgc.CreateSchedule(kit, &metadata.CreateTaskScheduleRequest{TaskType: common.SyncSetTaskFlag,
+		Data: []interface{}{"data"}, Cron: "0 * * * *"})
+	require.NoError(t, err)
+	require.Equal(t, metadata.APITaskScheduleStatusEnabled, schedule.Status)
+}
@@ -57,6 +57,11 @@ func (lgc *Logics) Create(kit *rest.Kit, input *metadata.CreateTaskRequest) (met
 				metadata.APITaskStatusExecute},
 		},
 	}
+	// the excel import tasks of the same model but different extra(business or module scope) can be executed at the
+	// same time, the other tasks are still duplicated by task type and instance id regardless of their extra
+	if input.TaskType == common.ImportExcelTaskFlag && input.Extra != nil {
+		duplicateCond[metadata.APITaskExtraField] = input.Extra
+	}
 
 	duplicateTasks := make([]metadata.APITaskDetail, 0)
 	err := lgc.db.Table(common.BKTableNameAPITask).Find(duplicateCond).All(kit.Ctx, &duplicateTasks)
@@ -85,6 +90,7 @@ func (lgc *Logics) Create(kit *rest.Kit, input *metadata.CreateTaskRequest) (met
 	dbTask.CreateTime = time.Now()
 	dbTask.LastTime = time.Now()
 	dbTask.SupplierAccount = kit.SupplierAccount
+	dbTask.NotBefore = input.NotBefore
 	for _, taskItem := range input.Data {
 		dbTask.Detail = append(dbTask.Detail, metadata.APISubTaskDetail{
 			SubTaskID: getStrTaskID("sid"),
@@ -162,6 +168,7 @@ func (lgc *Logics) CreateBatch(kit *rest.Kit, tasks []metadata.CreateTaskRequest
 		dbTask.TaskID = getStrTaskID("id")
 		dbTask.TaskType = task.TaskType
 		dbTask.InstID = task.InstID
+		dbTask.NotBefore = task.NotBefore
 		dbTask.Detail = make([]metadata.APISubTaskDetail, 0)
 		for _, taskItem := range task.Data {
 			dbTask.Detail = append(dbTask.Detail, metadata.APISubTaskDetail{
@@ -234,6 +241,7 @@ func (lgc *Logics) CreateFieldTemplateBatch(kit *rest.Kit, tasks []metadata.Crea
 		dbTask.TaskID = getStrTaskID("id")
 		dbTask.TaskType = task.TaskType
 		dbTask.InstID = task.InstID
+		dbTask.NotBefore = task.NotBefore
 		dbTask.Extra = task.Extra
 		dbTask.Detail = make([]metadata.APISubTaskDetail, 0)
 		for _, taskItem := range task.Data {
@@ -0,0 +1,57 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package logics
+
+import (
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal/mongo/memory"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateDuplicateTask(t *testing.T) {
+	lgc := NewLogics(nil, memory.New())
+	kit := newScheduleTestKit()
+
+	isConflict := func(err error) bool {
+		ccErr, ok := err.(errors.CCErrorCoder)
+		return ok && ccErr.GetCode() == common.CCErrTaskCreateConflict
+	}
+
+	// the excel import tasks of the same model but different scopes can be executed at the same time
+	_, err := lgc.Create(kit, &metadata.CreateTaskRequest{TaskType: common.ImportExcelTaskFlag, InstID: 1,
+		Extra: "biz:1", Data: []interface{}{"data"}})
+	require.NoError(t, err)
+	_, err = lgc.Create(kit, &metadata.CreateTaskRequest{TaskType: common.ImportExcelTaskFlag, InstID: 1,
+		Extra: "biz:2", Data: []interface{}{"data"}})
+	require.NoError(t, err)
+	_, err = lgc.Create(kit, &metadata.CreateTaskRequest{TaskType: common.ImportExcelTaskFlag, InstID: 1,
+		Extra: "biz:2", Data: []interface{}{"data"}})
+	require.True(t, isConflict(err))
+
+	// the other tasks of the same instance are duplicated regardless of their extra
+	_, err = lgc.Create(kit, &metadata.CreateTaskRequest{TaskType: common.SyncSetTaskFlag, InstID: 1,
+		Extra: "a", Data: []interface{}{"data"}})
+	require.NoError(t, err)
+	_, err = lgc.Create(kit, &metadata.CreateTaskRequest{TaskType: common.SyncSetTaskFlag, InstID: 1,
+		Extra: "b", Data: []interface{}{"data"}})
+	require.True(t, isConflict(err))
+}
@@ -352,23 +352,28 @@ func (tq *TaskQueue) taskLockKey(taskID string) string {
 }
 
 func (tq *TaskQueue) lockTask(ctx context.Context, taskID string, ttl int64) (bool, error) {
+	return tq.service.lock(ctx, tq.taskLockKey(taskID), time.Minute*time.Duration(ttl))
+}
 
-	key := tq.taskLockKey(taskID)
-	locked, err := tq.service.CacheDB.SetNX(ctx, key, time.Now(), time.Minute*time.Duration(ttl)).Result()
+func (tq *TaskQueue) unLockTask(ctx context.Context, taskID string) (err error) {
+	return tq.service.unlock(ctx, tq.taskLockKey(taskID))
+}
+
+// lock locks the key with ttl, returns false if it is already locked.
+func (s *Service) lock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	locked, err := s.CacheDB.SetNX(ctx, key, time.Now(), ttl).Result()
 	if err != nil {
-		blog.Errorf("lock task failed, err: %v, taskID: %s", err, taskID)
-		return false, tq.service.CCErr.Error("zh-cn", common.CCErrTaskLockedTaskFail)
+		blog.Errorf("lock task failed, err: %v, key: %s", err, key)
+		return false, s.CCErr.Error("zh-cn", common.CCErrTaskLockedTaskFail)
 	}
 	return locked, nil
 }
 
-func (tq *TaskQueue) unLockTask(ctx context.Context, taskID string) (err error) {
-
-	key := tq.taskLockKey(taskID)
-	_, err = tq.service.CacheDB.Del(ctx, key).Result()
-	if err != nil {
-		blog.Errorf("unlock task failed, err: %v, taskID: %s", err, taskID)
-		return tq.service.CCErr.Error("zh-cn", common.CCErrTaskUnLockedTaskFail)
+// unlock unlocks the key.
+func (s *Service) unlock(ctx context.Context, key string) error {
+	if _, err := s.CacheDB.Del(ctx, key).Result(); err != nil {
+		blog.Errorf("unlock task failed, err: %v, key: %s", err, key)
+		return s.CCErr.Error("zh-cn", common.CCErrTaskUnLockedTaskFail)
 	}
 	return nil
 }
@@ -390,6 +395,10 @@ func (tq *TaskQueue) getWaitExecute(ctx context.Context, name string) ([]metadat
 		common.BKStatusField: mapstr.MapStr{
 			common.BKDBIN: []metadata.APITaskStatus{metadata.APITaskStatusNew, metadata.APITaskStatusWaitExecute},
 		},
+		// delayed tasks are not executed until their not before time is reached
+		metadata.APITaskNotBeforeField: mapstr.MapStr{
+			common.BKDBNot: mapstr.MapStr{common.BKDBGT: time.Now()},
+		},
 	}
 
 	rows := make([]metadata.APITaskDetail, 0)
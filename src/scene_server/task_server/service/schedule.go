@@ -0,0 +1,229 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	httpheader "configcenter/src/common/http/header"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/task_server/logics"
+)
+
+const (
+	// scheduleCheckInterval is the interval to check the due task schedules
+	scheduleCheckInterval = 10 * time.Second
+	// scheduleBatchSize is the maximum number of due task schedules to trigger in one check
+	scheduleBatchSize = 100
+	// scheduleLockTTL is the ttl of the lock of a task schedule that is being triggered
+	scheduleLockTTL = time.Minute
+)
+
+// CreateTaskSchedule create a task schedule which creates tasks periodically by the cron expression
+func (s *Service) CreateTaskSchedule(ctx *rest.Contexts) {
+	input := new(metadata.CreateTaskScheduleRequest)
+	if err := ctx.DecodeInto(input); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	schedule, err := s.Logics.CreateSchedule(ctx.Kit, input)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(schedule)
+}
+
+// ListTaskSchedule list task schedules by condition
+func (s *Service) ListTaskSchedule(ctx *rest.Contexts) {
+	input := new(metadata.ListAPITaskScheduleRequest)
+	if err := ctx.DecodeInto(input); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	infos, cnt, err := s.Logics.ListSchedule(ctx.Kit, input)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(metadata.ListAPITaskScheduleData{
+		Info:  infos,
+		Count: int64(cnt),
+	})
+}
+
+// PauseTaskSchedule pause task schedules, no task is created by them until they are resumed
+func (s *Service) PauseTaskSchedule(ctx *rest.Contexts) {
+	input := new(metadata.APITaskScheduleIDsOption)
+	if err := ctx.DecodeInto(input); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := input.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	if err := s.Logics.PauseSchedule(ctx.Kit, input.ScheduleIDs); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// ResumeTaskSchedule resume paused task schedules
+func (s *Service) ResumeTaskSchedule(ctx *rest.Contexts) {
+	input := new(metadata.APITaskScheduleIDsOption)
+	if err := ctx.DecodeInto(input); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := input.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	if err := s.Logics.ResumeSchedule(ctx.Kit, input.ScheduleIDs); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// DeleteTaskSchedule delete task schedules
+func (s *Service) DeleteTaskSchedule(ctx *rest.Contexts) {
+	input := new(metadata.APITaskScheduleIDsOption)
+	if err := ctx.DecodeInto(input); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := input.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	if err := s.Logics.DeleteSchedule(ctx.Kit, input.ScheduleIDs); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// TimerTriggerTaskSchedule checks the due task schedules periodically and creates their tasks into the task queue,
+// the created tasks are executed by the task queue like the other tasks.
+func (s *Service) TimerTriggerTaskSchedule(ctx context.Context) {
+	ticker := time.NewTicker(scheduleCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if !s.Engine.ServiceManageInterface.IsMaster() {
+			continue
+		}
+
+		s.triggerDueSchedules(ctx)
+	}
+}
+
+// triggerDueSchedules triggers all the task schedules whose next trigger time is reached
+func (s *Service) triggerDueSchedules(ctx context.Context) {
+	for {
+		rid := util.GenerateRID()
+		now := time.Now()
+
+		schedules, err := s.Logics.ListDueSchedules(ctx, now, scheduleBatchSize, rid)
+		if err != nil {
+			blog.Errorf("list due task schedules failed, err: %v, rid: %s", err, rid)
+			return
+		}
+
+		allTriggered := true
+		for idx := range schedules {
+			schedule := &schedules[idx]
+
+			header := logics.GetDBHTTPHeader(schedule.Header)
+			httpheader.SetRid(header, rid)
+			kit := rest.NewKitFromHeader(header, s.CCErr)
+			kit.Ctx = ctx
+
+			if err := s.triggerSchedule(kit, schedule, now); err != nil {
+				blog.Errorf("trigger task schedule %s failed, err: %v, rid: %s", schedule.ScheduleID, err, rid)
+				allTriggered = false
+			}
+		}
+
+		// the next trigger time of the failed schedules are rolled back, so they are still due and would be listed
+		// again, stop this loop to retry them in the next check instead of triggering them repeatedly
+		if !allTriggered || len(schedules) < scheduleBatchSize {
+			return
+		}
+	}
+}
+
+// triggerSchedule triggers the task schedule with retries, the schedule is locked during triggering to avoid conflict
+func (s *Service) triggerSchedule(kit *rest.Kit, schedule *metadata.APITaskSchedule, now time.Time) error {
+	key := fmt.Sprintf("%s:apiTaskSchedule:%s", common.BKCacheKeyV3Prefix, schedule.ScheduleID)
+	locked, err := s.lock(kit.Ctx, key, scheduleLockTTL)
+	if err != nil {
+		return err
+	}
+	if !locked {
+		blog.Infof("task schedule %s is locked, skip and retry later, rid: %s", schedule.ScheduleID, kit.Rid)
+		return nil
+	}
+
+	defer func() {
+		if err := s.unlock(kit.Ctx, key); err != nil {
+			blog.Errorf("unlock task schedule %s failed, err: %v, rid: %s", schedule.ScheduleID, err, kit.Rid)
+		}
+	}()
+
+	var triggerErr error
+	needReturn := retryWrapper(kit, dbMaxRetry, func() error {
+		if triggerErr = s.Logics.TriggerSchedule(kit, schedule, now); triggerErr != nil {
+			time.Sleep(time.Second)
+			return triggerErr
+		}
+		return nil
+	})
+	if needReturn {
+		return kit.Ctx.Err()
+	}
+
+	return triggerErr
+}
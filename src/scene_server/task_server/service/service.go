@@ -99,6 +99,15 @@ func (s *Service) addAPIService(web *restful.WebService) {
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/task/find/field_template/task_sync_result",
 		Handler: s.ListFieldTmplTaskSyncResult})
 
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/task/schedule/create", Handler: s.CreateTaskSchedule})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/task/schedule/findmany",
+		Handler: s.ListTaskSchedule})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/task/schedule/pause", Handler: s.PauseTaskSchedule})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/task/schedule/resume",
+		Handler: s.ResumeTaskSchedule})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/task/schedule/deletemany",
+		Handler: s.DeleteTaskSchedule})
+
 	utility.AddToRestfulWebService(web)
 
 }
@@ -267,6 +267,10 @@ func (s *Service) deleteRedundancyTask(ctx context.Context, rid string) error {
 		common.LastTimeField: map[string]interface{}{
 			common.BKDBLT: time.Now().AddDate(0, -2, 0),
 		},
+		// delayed tasks that are not executed yet are not redundant
+		metadata.APITaskNotBeforeField: map[string]interface{}{
+			common.BKDBNot: map[string]interface{}{common.BKDBGT: time.Now()},
+		},
 	}
 
 	for {
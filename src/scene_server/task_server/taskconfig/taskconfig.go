@@ -54,6 +54,7 @@ func init() {
 		"/topo/v3/sync/field_template/object/task", 1, 2)
 	AddCodeTaskConfig(common.SyncInstIDRuleTaskFlag, types.CC_MODULE_TOPO,
 		"/topo/v3/sync/id_rule/inst/task", 1, 2)
+	AddCodeTaskConfig(common.ImportExcelTaskFlag, types.CC_MODULE_TOPO, "/topo/v3/internal/import/excel/task", 1, 10)
 }
 
 // AddCodeTaskConfig add task
@@ -72,3 +73,13 @@ func AddCodeTaskConfig(name, srvType, path string, retry, lockTTL int64) {
 func GetCodeTaskConfig() []CodeTaskConfig {
 	return codeTaskConfigArr
 }
+
+// IsCodeTaskType returns if the task type is one of the task queues configured in code
+func IsCodeTaskType(name string) bool {
+	for _, taskConfig := range codeTaskConfigArr {
+		if taskConfig.Name == name {
+			return true
+		}
+	}
+	return false
+}
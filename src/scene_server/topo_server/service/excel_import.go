@@ -0,0 +1,263 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package service
+
+import (
+	"encoding/json"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	httpheader "configcenter/src/common/http/header"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+// CreateExcelImportTask create an asynchronous excel import task, each sub task imports a batch of excel rows
+func (s *Service) CreateExcelImportTask(ctx *rest.Contexts) {
+	opt := new(metadata.CreateExcelImportTaskOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	obj, err := s.Logics.ObjectOperation().FindSingleObject(ctx.Kit, []string{common.BKFieldID}, opt.ObjID)
+	if err != nil {
+		blog.Errorf("find object %s failed, err: %v, rid: %s", opt.ObjID, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	// the task is authorized by the business and module of the option, so they override the ones of the sub tasks
+	taskData := make([]interface{}, len(opt.Tasks))
+	for idx, task := range opt.Tasks {
+		switch task.Kind {
+		case metadata.ExcelImportKindInst:
+			task.Params[common.BKAppIDField] = opt.BizID
+		case metadata.ExcelImportKindAddHost:
+			task.Params[common.BKModuleIDField] = opt.ModuleID
+		}
+		taskData[idx] = task
+	}
+
+	// the task is identified by the object and the business or module it imports into, so that only one import task
+	// of the same object and scope can be executed, while the imports of other scopes are not blocked
+	task, err := s.Engine.CoreAPI.TaskServer().Task().CreateWithExtra(ctx.Kit.Ctx, ctx.Kit.Header,
+		common.ImportExcelTaskFlag, obj.ID, opt.TaskScope(), taskData)
+	if err != nil {
+		blog.Errorf("create excel import task failed, object: %s, err: %v, rid: %s", opt.ObjID, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(metadata.CreateExcelImportTaskResult{TaskID: task.TaskID})
+}
+
+// ExcelImportTaskHandler excel import task handler, imports a batch of excel rows
+func (s *Service) ExcelImportTaskHandler(ctx *rest.Contexts) {
+	task := new(metadata.ExcelImportTaskData)
+	if err := ctx.DecodeInto(task); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	var res *metadata.ImportInstRes
+	var err error
+	switch task.Kind {
+	case metadata.ExcelImportKindInst:
+		batchInfo := new(metadata.InstBatchInfo)
+		if err = mapstr.DecodeFromMapStr(batchInfo, task.Params); err != nil {
+			blog.Errorf("decode import instance params failed, err: %v, rid: %s", err, ctx.Kit.Rid)
+			ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "params"))
+			return
+		}
+		res, err = s.Logics.InstOperation().CreateInstBatch(ctx.Kit, task.ObjID, batchInfo)
+	case metadata.ExcelImportKindAddHost:
+		res, err = s.Engine.CoreAPI.HostServer().AddHostByExcel(ctx.Kit.Ctx, ctx.Kit.Header, task.Params)
+	case metadata.ExcelImportKindUpdateHost:
+		res, err = s.Engine.CoreAPI.HostServer().UpdateImportHosts(ctx.Kit.Ctx, ctx.Kit.Header, task.Params)
+	case metadata.ExcelImportKindAssociation:
+		s.importExcelAssociation(ctx, task)
+		return
+	default:
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "kind"))
+		return
+	}
+
+	if err != nil {
+		blog.Errorf("import excel rows %v failed, kind: %s, err: %v, rid: %s", task.Rows, task.Kind, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(metadata.ExcelImportSubTaskResult{
+		Success: res.Success,
+		Errors:  metadata.NewExcelImportRowErrors("", res.Errors),
+	})
+}
+
+func (s *Service) importExcelAssociation(ctx *rest.Contexts, task *metadata.ExcelImportTaskData) {
+	if task.Association == nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, "association"))
+		return
+	}
+
+	var ret metadata.ResponeImportAssociationData
+	txnErr := s.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
+		var err error
+		ret, err = s.Logics.ImportAssociationOperation().ImportInstAssociation(ctx.Kit, s.Language, task.ObjID,
+			task.Association.AssociationInfoMap, task.Association.AsstObjectUniqueIDMap,
+			task.Association.ObjectUniqueID)
+		return err
+	})
+
+	if txnErr != nil {
+		blog.Errorf("import excel association failed, object: %s, err: %v, rid: %s", task.ObjID, txnErr, ctx.Kit.Rid)
+		ctx.RespAutoError(txnErr)
+		return
+	}
+
+	result := metadata.ExcelImportSubTaskResult{Success: make([]int64, 0),
+		Errors: make([]metadata.ExcelImportRowError, len(ret.ErrMsgMap))}
+	for idx, msg := range ret.ErrMsgMap {
+		result.Errors[idx] = metadata.ExcelImportRowError{Sheet: metadata.ExcelAssociationSheet, Row: int64(msg.Row),
+			Message: msg.Msg}
+	}
+	ctx.RespEntity(result)
+}
+
+// FindExcelImportTask find the progress and the result of the excel import task
+func (s *Service) FindExcelImportTask(ctx *rest.Contexts) {
+	opt := new(metadata.ExcelImportTaskOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	detail, err := s.Engine.CoreAPI.TaskServer().Task().TaskDetail(ctx.Kit.Ctx, ctx.Kit.Header, opt.TaskID)
+	if err != nil {
+		blog.Errorf("find excel import task %s failed, err: %v, rid: %s", opt.TaskID, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	task := detail.Info
+	if task.TaskType != common.ImportExcelTaskFlag {
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrTaskNotFound))
+		return
+	}
+
+	// the task contains the imported data, so only its creator can see it
+	if task.User != ctx.Kit.User {
+		blog.Errorf("user %s can not find excel import task %s created by %s, rid: %s", ctx.Kit.User, task.TaskID,
+			task.User, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommAuthNotHavePermission))
+		return
+	}
+
+	ctx.RespEntity(s.buildExcelImportTaskResult(ctx.Kit, &task))
+}
+
+func (s *Service) buildExcelImportTaskResult(kit *rest.Kit,
+	task *metadata.APITaskDetail) *metadata.ExcelImportTaskResult {
+
+	lang := s.Language.CreateDefaultCCLanguageIf(httpheader.GetLanguage(kit.Header))
+	result := &metadata.ExcelImportTaskResult{
+		TaskID:  task.TaskID,
+		Status:  task.Status,
+		Total:   len(task.Detail),
+		Success: make([]int64, 0),
+		Errors:  make([]metadata.ExcelImportRowError, 0),
+	}
+
+	for _, subTask := range task.Detail {
+		data := new(metadata.ExcelImportTaskData)
+		if err := decodeExcelImportTaskData(subTask.Data, data); err != nil {
+			blog.Errorf("decode sub task %s data failed, err: %v, rid: %s", subTask.SubTaskID, err, kit.Rid)
+			continue
+		}
+		result.Errors = append(result.Errors, data.Errors...)
+
+		switch {
+		case subTask.Status.IsSuccessful():
+			result.Finished++
+			if subTask.Response == nil {
+				continue
+			}
+
+			subResult := new(metadata.ExcelImportSubTaskResult)
+			if err := decodeExcelImportTaskData(subTask.Response.Data, subResult); err != nil {
+				blog.Errorf("decode sub task %s result failed, err: %v, rid: %s", subTask.SubTaskID, err, kit.Rid)
+				continue
+			}
+			result.Success = append(result.Success, subResult.Success...)
+			result.Errors = append(result.Errors, subResult.Errors...)
+
+		case subTask.Status.IsFailure():
+			result.Finished++
+			errMsg := ""
+			if subTask.Response != nil {
+				errMsg = subTask.Response.ErrMsg
+			}
+			result.Errors = append(result.Errors, newExcelImportTaskRowErrors(data, func(row int64) string {
+				return lang.Languagef("import_data_fail", row, errMsg)
+			})...)
+
+		case task.Status.IsFailure():
+			// the task is aborted by a failed sub task, the rest of the sub tasks will not be executed
+			result.Errors = append(result.Errors, newExcelImportTaskRowErrors(data, func(row int64) string {
+				return lang.Languagef("import_task_row_skipped", row)
+			})...)
+		}
+	}
+
+	return result
+}
+
+// newExcelImportTaskRowErrors generate the errors of all the rows of the excel import sub task
+func newExcelImportTaskRowErrors(data *metadata.ExcelImportTaskData,
+	getMsg func(row int64) string) []metadata.ExcelImportRowError {
+
+	if data.Kind == metadata.ExcelImportKindAssociation {
+		return []metadata.ExcelImportRowError{{Sheet: metadata.ExcelAssociationSheet, Message: getMsg(0)}}
+	}
+
+	rowErrs := make([]metadata.ExcelImportRowError, len(data.Rows))
+	for idx, row := range data.Rows {
+		rowErrs[idx] = metadata.ExcelImportRowError{Row: row, Message: getMsg(row)}
+	}
+	return rowErrs
+}
+
+func decodeExcelImportTaskData(data interface{}, result interface{}) error {
+	js, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(js, result)
+}
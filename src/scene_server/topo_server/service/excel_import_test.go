@@ -0,0 +1,88 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package service
+
+import (
+	"net/http"
+	"testing"
+
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/language"
+	"configcenter/src/common/metadata"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildExcelImportTaskResult(t *testing.T) {
+	s := &Service{Language: language.NewFromCtx(language.EmptyLanguageSetting)}
+	kit := &rest.Kit{Rid: "test-rid", Header: make(http.Header)}
+
+	rowErr := metadata.ExcelImportRowError{Row: 8, PropertyID: "port", Message: "port is invalid"}
+	task := &metadata.APITaskDetail{
+		TaskID: "task1",
+		Status: metadata.APITAskStatusFail,
+		Detail: []metadata.APISubTaskDetail{
+			{
+				SubTaskID: "sub1",
+				Data: metadata.ExcelImportTaskData{Kind: metadata.ExcelImportKindInst, Rows: []int64{7, 9},
+					Errors: []metadata.ExcelImportRowError{rowErr}},
+				Status: metadata.APITaskStatusSuccess,
+				Response: &metadata.Response{Data: metadata.ExcelImportSubTaskResult{Success: []int64{7},
+					Errors: []metadata.ExcelImportRowError{{Row: 9, Message: "duplicated"}}}},
+			},
+			{
+				SubTaskID: "sub2",
+				Data:      metadata.ExcelImportTaskData{Kind: metadata.ExcelImportKindInst, Rows: []int64{10, 11}},
+				Status:    metadata.APITAskStatusFail,
+				Response:  &metadata.Response{BaseResp: metadata.BaseResp{ErrMsg: "request failed"}},
+			},
+			{
+				SubTaskID: "sub3",
+				Data:      metadata.ExcelImportTaskData{Kind: metadata.ExcelImportKindInst, Rows: []int64{12}},
+				Status:    metadata.APITaskStatusNew,
+			},
+			{
+				SubTaskID: "sub4",
+				Data:      metadata.ExcelImportTaskData{Kind: metadata.ExcelImportKindAssociation},
+				Status:    metadata.APITaskStatusNew,
+			},
+		},
+	}
+
+	result := s.buildExcelImportTaskResult(kit, task)
+	require.Equal(t, "task1", result.TaskID)
+	require.Equal(t, metadata.APITAskStatusFail, result.Status)
+	require.Equal(t, 4, result.Total)
+	require.Equal(t, 2, result.Finished)
+	require.Equal(t, []int64{7}, result.Success)
+
+	// the pre-check errors, the errors of the successful sub task, the rows of the failed sub task and the rows that
+	// are skipped because the task is aborted
+	rows := make([]int64, len(result.Errors))
+	for idx, err := range result.Errors {
+		rows[idx] = err.Row
+		require.NotEmpty(t, err.Message)
+	}
+	require.Equal(t, []int64{8, 9, 10, 11, 12, 0}, rows)
+	require.Equal(t, rowErr, result.Errors[0])
+	require.Equal(t, metadata.ExcelAssociationSheet, result.Errors[5].Sheet)
+
+	// the sub tasks that are not executed are not reported as errors if the task is still running
+	task.Status = metadata.APITaskStatusExecute
+	result = s.buildExcelImportTaskResult(kit, task)
+	require.Len(t, result.Errors, 4)
+}
@@ -287,3 +287,25 @@ func (s *service) FindInstIDRuleTaskStatus(ctx *rest.Contexts) {
 
 	ctx.RespEntity(map[string]interface{}{common.BKStatusField: resp.Info.Status})
 }
+
+// PreviewIDRule preview the next ids of the id rule without using up the sequence
+func (s *service) PreviewIDRule(ctx *rest.Contexts) {
+	opt := new(metadata.PreviewIDRuleOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	res, err := s.ClientSet.CoreService().IDRule().PreviewIDRule(ctx.Kit.Ctx, ctx.Kit.Header, opt)
+	if err != nil {
+		blog.Errorf("preview id rule failed, opt: %+v, err: %v, rid: %s", opt, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(res)
+}
@@ -41,4 +41,6 @@ func InitIDRule(utility *rest.RestUtility, c *capability.Capability) {
 		Handler: s.SyncInstIDRuleTask})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/inst/id_rule/task_status",
 		Handler: s.FindInstIDRuleTaskStatus})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/id_rule/preview",
+		Handler: s.PreviewIDRule})
 }
@@ -0,0 +1,281 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package localauth
+
+import (
+	"strconv"
+
+	"configcenter/src/ac/local"
+	"configcenter/src/ac/meta"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+)
+
+// CreateRole create local auth role
+func (s *service) CreateRole(ctx *rest.Contexts) {
+	opt := new(metadata.LocalAuthRoleOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := validateRoleOption(ctx.Kit, opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if !s.authorize(ctx) {
+		return
+	}
+
+	role, err := s.ClientSet.CoreService().LocalAuth().CreateRole(ctx.Kit.Ctx, ctx.Kit.Header, opt)
+	if err != nil {
+		blog.Errorf("create local auth role failed, opt: %+v, err: %v, rid: %s", opt, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(role)
+}
+
+// UpdateRole update local auth role
+func (s *service) UpdateRole(ctx *rest.Contexts) {
+	id, err := parsePathID(ctx)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	opt := new(metadata.LocalAuthRoleOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := validateRoleOption(ctx.Kit, opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if !s.authorize(ctx) {
+		return
+	}
+
+	if err := s.ClientSet.CoreService().LocalAuth().UpdateRole(ctx.Kit.Ctx, ctx.Kit.Header, id, opt); err != nil {
+		blog.Errorf("update local auth role %d failed, opt: %+v, err: %v, rid: %s", id, opt, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// DeleteRole delete local auth role
+func (s *service) DeleteRole(ctx *rest.Contexts) {
+	id, err := parsePathID(ctx)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if !s.authorize(ctx) {
+		return
+	}
+
+	if err := s.ClientSet.CoreService().LocalAuth().DeleteRole(ctx.Kit.Ctx, ctx.Kit.Header, id); err != nil {
+		blog.Errorf("delete local auth role %d failed, err: %v, rid: %s", id, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// ListRole list local auth roles
+func (s *service) ListRole(ctx *rest.Contexts) {
+	opt := new(metadata.ListLocalAuthRoleOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	if !s.authorize(ctx) {
+		return
+	}
+
+	res, err := s.ClientSet.CoreService().LocalAuth().ListRole(ctx.Kit.Ctx, ctx.Kit.Header, opt)
+	if err != nil {
+		blog.Errorf("list local auth roles failed, opt: %+v, err: %v, rid: %s", opt, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(res)
+}
+
+// CreateRoleBinding grant local auth role to users
+func (s *service) CreateRoleBinding(ctx *rest.Contexts) {
+	opt := new(metadata.CreateLocalAuthRoleBindingOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	if !s.authorize(ctx) {
+		return
+	}
+
+	binding, err := s.ClientSet.CoreService().LocalAuth().CreateRoleBinding(ctx.Kit.Ctx, ctx.Kit.Header, opt)
+	if err != nil {
+		blog.Errorf("create local auth role binding failed, opt: %+v, err: %v, rid: %s", opt, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(binding)
+}
+
+// UpdateRoleBinding update the users of local auth role binding
+func (s *service) UpdateRoleBinding(ctx *rest.Contexts) {
+	id, err := parsePathID(ctx)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	opt := new(metadata.UpdateLocalAuthRoleBindingOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	if !s.authorize(ctx) {
+		return
+	}
+
+	err = s.ClientSet.CoreService().LocalAuth().UpdateRoleBinding(ctx.Kit.Ctx, ctx.Kit.Header, id, opt)
+	if err != nil {
+		blog.Errorf("update local auth role binding %d failed, opt: %+v, err: %v, rid: %s", id, opt, err,
+			ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// DeleteRoleBinding delete local auth role binding
+func (s *service) DeleteRoleBinding(ctx *rest.Contexts) {
+	id, err := parsePathID(ctx)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if !s.authorize(ctx) {
+		return
+	}
+
+	if err := s.ClientSet.CoreService().LocalAuth().DeleteRoleBinding(ctx.Kit.Ctx, ctx.Kit.Header, id); err != nil {
+		blog.Errorf("delete local auth role binding %d failed, err: %v, rid: %s", id, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// ListRoleBinding list local auth role bindings
+func (s *service) ListRoleBinding(ctx *rest.Contexts) {
+	opt := new(metadata.ListLocalAuthRoleBindingOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	if !s.authorize(ctx) {
+		return
+	}
+
+	res, err := s.ClientSet.CoreService().LocalAuth().ListRoleBinding(ctx.Kit.Ctx, ctx.Kit.Header, opt)
+	if err != nil {
+		blog.Errorf("list local auth role bindings failed, opt: %+v, err: %v, rid: %s", opt, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(res)
+}
+
+// authorize the local auth policies are managed with the global settings permission, the super admins configured
+// in authServer.local.superAdmins always have the permission, so that they can initialize the policies
+func (s *service) authorize(ctx *rest.Contexts) bool {
+	authResp, authorized := s.AuthManager.Authorize(ctx.Kit, meta.ResourceAttribute{Basic: meta.Basic{
+		Type: meta.ConfigAdmin, Action: meta.Update}})
+	if !authorized {
+		ctx.RespNoAuth(authResp)
+		return false
+	}
+	return true
+}
+
+// validateRoleOption validate the role option, and check if the actions are defined in ac/iam
+func validateRoleOption(kit *rest.Kit, opt *metadata.LocalAuthRoleOption) error {
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		return rawErr.ToCCError(kit.CCError)
+	}
+
+	for _, perm := range opt.Permissions {
+		if !local.IsValidAction(perm.Action) {
+			blog.Errorf("local auth action %s is invalid, rid: %s", perm.Action, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, "permissions.action: "+perm.Action)
+		}
+	}
+	return nil
+}
+
+func parsePathID(ctx *rest.Contexts) (int64, error) {
+	id, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKFieldID), 10, 64)
+	if err != nil || id <= 0 {
+		blog.Errorf("parse id %s failed, err: %v, rid: %s", ctx.Request.PathParameter(common.BKFieldID), err,
+			ctx.Kit.Rid)
+		return 0, ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKFieldID)
+	}
+	return id, nil
+}
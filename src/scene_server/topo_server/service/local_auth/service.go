@@ -0,0 +1,56 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+// Package localauth defines the admin apis to manage the roles and role bindings of the built-in local authorizer
+package localauth
+
+import (
+	"net/http"
+
+	"configcenter/src/common/http/rest"
+	"configcenter/src/scene_server/topo_server/service/capability"
+)
+
+type service struct {
+	*capability.Capability
+}
+
+// InitLocalAuth init local auth policy admin service
+func InitLocalAuth(utility *rest.RestUtility, c *capability.Capability) {
+	s := &service{
+		Capability: c,
+	}
+
+	// local auth role
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/local_auth/role",
+		Handler: s.CreateRole})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/local_auth/role/{id}",
+		Handler: s.UpdateRole})
+	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/local_auth/role/{id}",
+		Handler: s.DeleteRole})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/local_auth/role",
+		Handler: s.ListRole})
+
+	// local auth role binding
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/local_auth/role_binding",
+		Handler: s.CreateRoleBinding})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/local_auth/role_binding/{id}",
+		Handler: s.UpdateRoleBinding})
+	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/local_auth/role_binding/{id}",
+		Handler: s.DeleteRoleBinding})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/local_auth/role_binding",
+		Handler: s.ListRoleBinding})
+}
@@ -36,6 +36,8 @@ func (s *Service) initInternalTask(web *restful.WebService) {
 
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/internal/sync/module/task",
 		Handler: s.SyncModuleTaskHandler})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/internal/import/excel/task",
+		Handler: s.ExcelImportTaskHandler})
 
 	utility.AddToRestfulWebService(web)
 }
@@ -24,6 +24,7 @@ import (
 	fieldtmpl "configcenter/src/scene_server/topo_server/service/field_template"
 	"configcenter/src/scene_server/topo_server/service/id_rule"
 	"configcenter/src/scene_server/topo_server/service/kube"
+	localauth "configcenter/src/scene_server/topo_server/service/local_auth"
 
 	"github.com/emicklei/go-restful/v3"
 )
@@ -205,6 +206,10 @@ func (s *Service) initInst(web *restful.WebService) {
 	utility.AddHandler(rest.Action{Verb: http.MethodPost,
 		Path:    "/findmany/inst/association/association_object/inst_base_info",
 		Handler: s.SearchInstAssociationWithOtherObject})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/excel_import/task",
+		Handler: s.CreateExcelImportTask})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/excel_import/task",
+		Handler: s.FindExcelImportTask})
 
 	utility.AddToRestfulWebService(web)
 }
@@ -349,5 +354,7 @@ func (s *Service) initService(web *restful.WebService) {
 
 	idrule.InitIDRule(utility, c)
 
+	localauth.InitLocalAuth(utility, c)
+
 	utility.AddToRestfulWebService(web)
 }
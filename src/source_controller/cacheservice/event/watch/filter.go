@@ -0,0 +1,268 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package watch
+
+import (
+	"fmt"
+	"strings"
+
+	"configcenter/pkg/filter"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/json"
+	"configcenter/src/common/util"
+	"configcenter/src/common/watch"
+	"configcenter/src/source_controller/cacheservice/event"
+	"configcenter/src/storage/stream/types"
+)
+
+// getDetailFields returns the fields of the event detail to get, the fields used by the filter rules are also needed
+// to match the event detail with the rules. returns whether the fields are different from the watched fields.
+func getDetailFields(opts *watch.WatchEventOptions) ([]string, bool) {
+	if opts.Filter.Rules == nil || len(opts.Fields) == 0 {
+		return opts.Fields, false
+	}
+
+	fields := make([]string, len(opts.Fields))
+	copy(fields, opts.Fields)
+
+	for _, ruleField := range opts.Filter.Rules.RuleFields() {
+		// the detail is cut by top level fields, sub fields of object or array field are matched in the field value.
+		field := strings.SplitN(ruleField, ".", 2)[0]
+		if !util.InStrArr(fields, field) {
+			fields = append(fields, field)
+		}
+	}
+
+	return fields, len(fields) != len(opts.Fields)
+}
+
+// matchFilterRules check if the event detail matches the filter rules of the watch options.
+func matchFilterRules(rules *filter.Expression, detail watch.DetailInterface) (bool, error) {
+	jsonDetail, ok := detail.(watch.JsonString)
+	if !ok || len(jsonDetail) == 0 {
+		return false, nil
+	}
+
+	return rules.Match(filter.JsonString(jsonDetail))
+}
+
+// filterEventDetails filters the event details by the filter rules of the watch options, the events whose detail
+// matches the rules are returned. The update events whose resource is updated from matching the rules to not matching
+// them are also returned with Unmatched set. The details of the returned events are cut with the watched fields.
+func (c *Client) filterEventDetails(kit *rest.Kit, opts *watch.WatchEventOptions, key event.Key,
+	details []*watch.WatchEventDetail) ([]*watch.WatchEventDetail, error) {
+
+	if opts.Filter.Rules == nil || len(details) == 0 {
+		return details, nil
+	}
+
+	matchedDetails := make([]*watch.WatchEventDetail, 0)
+	unmatchedIdx := make(map[int]struct{})
+	unmatchedNodes := make([]*watch.ChainNode, 0)
+	for idx, detail := range details {
+		matched, err := matchFilterRules(opts.Filter.Rules, detail.Detail)
+		if err != nil {
+			blog.Errorf("match event detail with filter rules failed, err: %v, cursor: %s, rid: %s", err,
+				detail.Cursor, kit.Rid)
+		}
+
+		if matched {
+			continue
+		}
+
+		unmatchedIdx[idx] = struct{}{}
+		// host identity and biz set relation event details are generated from db, their previous details are unknown
+		if opts.Resource == watch.HostIdentifier || opts.Resource == watch.BizSetRelation {
+			continue
+		}
+		if detail.EventType == watch.Update && detail.ChainNode != nil {
+			unmatchedNodes = append(unmatchedNodes, detail.ChainNode)
+		}
+	}
+
+	leftCursors, err := c.getFilterLeftCursors(kit, opts.Filter.Rules, key, unmatchedNodes)
+	if err != nil {
+		return nil, err
+	}
+
+	_, needCut := getDetailFields(opts)
+	for idx, detail := range details {
+		if _, exists := unmatchedIdx[idx]; exists {
+			if _, left := leftCursors[detail.Cursor]; !left {
+				continue
+			}
+			detail.Unmatched = true
+		}
+
+		if needCut {
+			if jsonDetail, ok := detail.Detail.(watch.JsonString); ok {
+				detailStr := string(jsonDetail)
+				detail.Detail = watch.JsonString(*json.CutJsonDataWithFields(&detailStr, opts.Fields))
+			}
+		}
+		matchedDetails = append(matchedDetails, detail)
+	}
+
+	return matchedDetails, nil
+}
+
+// getFilterLeftCursors get the cursors of the update events whose resource is updated from matching the filter rules
+// to not matching them. Since the chain node does not record the detail before the update, the detail of the previous
+// event of the same resource is used instead. If the previous detail can not be found, the event is regarded as left
+// so that the watcher would not miss it.
+func (c *Client) getFilterLeftCursors(kit *rest.Kit, rules *filter.Expression, key event.Key,
+	nodes []*watch.ChainNode) (map[string]struct{}, error) {
+
+	leftCursors := make(map[string]struct{})
+	if len(nodes) == 0 {
+		return leftCursors, nil
+	}
+
+	nodes, err := c.getRuleFieldsChangedNodes(kit, rules, key, nodes)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, node := range nodes {
+		cond := map[string]interface{}{
+			common.BKOIDField: node.Oid,
+			common.BKFieldID:  map[string]interface{}{common.BKDBLT: node.ID},
+		}
+
+		prevNode := new(watch.ChainNode)
+		err := c.watchDB.Table(key.ChainCollection()).Find(cond).Sort("-"+common.BKFieldID).One(kit.Ctx, prevNode)
+		if err != nil {
+			if !c.watchDB.IsNotFoundError(err) {
+				blog.Errorf("get previous chain node of %s failed, err: %v, rid: %s", node.Cursor, err, kit.Rid)
+				return nil, err
+			}
+			leftCursors[node.Cursor] = struct{}{}
+			continue
+		}
+
+		if prevNode.EventType == watch.Delete {
+			continue
+		}
+
+		prevDetail, err := c.getEventDetailFromRedis(kit, prevNode, nil, key)
+		if err != nil {
+			blog.V(4).Infof("get previous event detail of %s failed, err: %v, rid: %s", node.Cursor, err, kit.Rid)
+			leftCursors[node.Cursor] = struct{}{}
+			continue
+		}
+
+		matched, err := matchFilterRules(rules, watch.JsonString(*prevDetail))
+		if err != nil {
+			blog.Errorf("match previous event detail of %s with filter rules failed, err: %v, rid: %s", node.Cursor,
+				err, kit.Rid)
+		}
+		if matched {
+			leftCursors[node.Cursor] = struct{}{}
+		}
+	}
+
+	return leftCursors, nil
+}
+
+// getRuleFieldsChangedNodes get the update event nodes whose updated or removed fields contain the fields used by the
+// filter rules, the matching result of the other events are not changed by the update. If the changed fields of the
+// event can not be found, the node is returned for further check.
+func (c *Client) getRuleFieldsChangedNodes(kit *rest.Kit, rules *filter.Expression, key event.Key,
+	nodes []*watch.ChainNode) ([]*watch.ChainNode, error) {
+
+	if key.IsGeneralRes() {
+		// general resource event detail has no changed fields
+		return nodes, nil
+	}
+
+	ruleFields := make(map[string]struct{})
+	for _, field := range rules.RuleFields() {
+		ruleFields[strings.SplitN(field, ".", 2)[0]] = struct{}{}
+	}
+
+	detailKeys := make([]string, len(nodes))
+	for idx, node := range nodes {
+		detailKeys[idx] = key.DetailKey(node.Cursor)
+	}
+
+	results, err := c.cache.MGet(kit.Ctx, detailKeys...).Result()
+	if err != nil {
+		blog.Errorf("search event details by keys(%+v) failed, err: %v, rid: %s", detailKeys, err, kit.Rid)
+		return nil, fmt.Errorf("search event details by keys(%+v) failed, err: %v", detailKeys, err)
+	}
+
+	changedNodes := make([]*watch.ChainNode, 0)
+	for idx, result := range results {
+		resultStr, ok := result.(string)
+		if !ok || resultStr == "" {
+			changedNodes = append(changedNodes, nodes[idx])
+			continue
+		}
+
+		eventInfo := new(types.EventInfo)
+		if err := json.Unmarshal([]byte(resultStr), eventInfo); err != nil {
+			blog.Errorf("unmarshal event info %s failed, err: %v, rid: %s", resultStr, err, kit.Rid)
+			changedNodes = append(changedNodes, nodes[idx])
+			continue
+		}
+
+		if isRuleFieldsChanged(ruleFields, eventInfo) {
+			changedNodes = append(changedNodes, nodes[idx])
+		}
+	}
+
+	return changedNodes, nil
+}
+
+// isRuleFieldsChanged check if the fields used by the filter rules are updated or removed in the event.
+func isRuleFieldsChanged(ruleFields map[string]struct{}, eventInfo *types.EventInfo) bool {
+	for field := range eventInfo.UpdatedFields {
+		if _, exists := ruleFields[strings.SplitN(field, ".", 2)[0]]; exists {
+			return true
+		}
+	}
+
+	for _, field := range eventInfo.RemovedFields {
+		if _, exists := ruleFields[strings.SplitN(field, ".", 2)[0]]; exists {
+			return true
+		}
+	}
+
+	return false
+}
+
+// filterEventDetail filters the event detail by the filter rules of the watch options, the detail is set to nil if it
+// does not match the rules, so that the event is converted to a no event cursor.
+func (c *Client) filterEventDetail(kit *rest.Kit, opts *watch.WatchEventOptions, key event.Key,
+	e *watch.WatchEventDetail) error {
+
+	if opts.Filter.Rules == nil || e.Detail == nil {
+		return nil
+	}
+
+	details, err := c.filterEventDetails(kit, opts, key, []*watch.WatchEventDetail{e})
+	if err != nil {
+		return err
+	}
+
+	if len(details) == 0 {
+		e.Detail = nil
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package watch
+
+import (
+	"reflect"
+	"testing"
+
+	"configcenter/pkg/filter"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/json"
+	"configcenter/src/common/watch"
+	"configcenter/src/source_controller/cacheservice/event"
+	"configcenter/src/storage/stream/types"
+)
+
+func newTestFilterRules(t *testing.T) *filter.Expression {
+	raw := `{"condition":"AND","rules":[{"field":"bk_os_type","operator":"equal","value":"1"},
+{"field":"bk_cloud_id","operator":"in","value":[0,1]}]}`
+
+	rules := new(filter.Expression)
+	if err := json.Unmarshal([]byte(raw), rules); err != nil {
+		t.Fatalf("unmarshal filter rules failed, err: %v", err)
+	}
+	return rules
+}
+
+func TestGetDetailFields(t *testing.T) {
+	opts := &watch.WatchEventOptions{Fields: []string{"bk_host_id", "bk_os_type"}}
+	fields, changed := getDetailFields(opts)
+	if changed || !reflect.DeepEqual(fields, opts.Fields) {
+		t.Errorf("fields without filter rules should not be changed, fields: %v", fields)
+	}
+
+	opts.Filter.Rules = newTestFilterRules(t)
+	fields, changed = getDetailFields(opts)
+	if !changed || !reflect.DeepEqual(fields, []string{"bk_host_id", "bk_os_type", "bk_cloud_id"}) {
+		t.Errorf("fields should contain filter rule fields, fields: %v", fields)
+	}
+
+	opts.Fields = nil
+	if fields, changed = getDetailFields(opts); changed || fields != nil {
+		t.Errorf("all fields are watched, fields: %v", fields)
+	}
+}
+
+func TestIsRuleFieldsChanged(t *testing.T) {
+	ruleFields := map[string]struct{}{"bk_os_type": {}, "labels": {}}
+
+	if isRuleFieldsChanged(ruleFields, &types.EventInfo{UpdatedFields: map[string]interface{}{"bk_cpu": 1}}) {
+		t.Errorf("rule fields are not changed")
+	}
+
+	if !isRuleFieldsChanged(ruleFields, &types.EventInfo{UpdatedFields: map[string]interface{}{"bk_os_type": "2"}}) {
+		t.Errorf("updated rule field should be changed")
+	}
+
+	if !isRuleFieldsChanged(ruleFields, &types.EventInfo{RemovedFields: []string{"labels.env"}}) {
+		t.Errorf("removed rule sub field should be changed")
+	}
+}
+
+func TestFilterEventDetails(t *testing.T) {
+	opts := &watch.WatchEventOptions{
+		Resource: watch.Host,
+		Fields:   []string{"bk_host_id"},
+		Filter:   watch.WatchEventFilter{Rules: newTestFilterRules(t)},
+	}
+
+	details := []*watch.WatchEventDetail{
+		{Cursor: "1", EventType: watch.Create, Detail: watch.JsonString(`{"bk_host_id":1,"bk_os_type":"1","bk_cloud_id":0}`)},
+		{Cursor: "2", EventType: watch.Create, Detail: watch.JsonString(`{"bk_host_id":2,"bk_os_type":"2","bk_cloud_id":0}`)},
+		{Cursor: "3", EventType: watch.Delete, Detail: watch.JsonString(`{"bk_host_id":3,"bk_os_type":"1","bk_cloud_id":1}`)},
+		{Cursor: "4", EventType: watch.Create, Detail: watch.JsonString(`{"bk_host_id":4,"bk_os_type":"1","bk_cloud_id":2}`)},
+	}
+
+	c := new(Client)
+	result, err := c.filterEventDetails(&rest.Kit{}, opts, event.HostKey, details)
+	if err != nil {
+		t.Fatalf("filter event details failed, err: %v", err)
+	}
+
+	if len(result) != 2 {
+		t.Fatalf("expect 2 matched events, got %d", len(result))
+	}
+
+	expects := map[string]string{"1": `{"bk_host_id":1}`, "3": `{"bk_host_id":3}`}
+	for _, detail := range result {
+		if string(detail.Detail.(watch.JsonString)) != expects[detail.Cursor] {
+			t.Errorf("event %s detail %s is not cut by watched fields", detail.Cursor, detail.Detail)
+		}
+		if detail.Unmatched {
+			t.Errorf("matched event %s should not be unmatched", detail.Cursor)
+		}
+	}
+}
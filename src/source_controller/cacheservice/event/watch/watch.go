@@ -96,7 +96,8 @@ func (c *Client) WatchWithStartFrom(kit *rest.Kit, key event.Key, opts *watch.Wa
 			}}, nil
 		}
 
-		detail, exists, err := c.getEventDetail(kit, tailNode, opts.Fields, key)
+		fields, _ := getDetailFields(opts)
+		detail, exists, err := c.getEventDetail(kit, tailNode, fields, key)
 		if err != nil {
 			blog.Errorf("get latest event detail failed, err: %v, rid: %s", err, rid)
 			return nil, err
@@ -126,6 +127,11 @@ func (c *Client) WatchWithStartFrom(kit *rest.Kit, key event.Key, opts *watch.Wa
 
 		}
 
+		if err = c.filterEventDetail(kit, opts, key, event); err != nil {
+			blog.Errorf("filter latest event detail failed, err: %v, rid: %s", err, rid)
+			return nil, err
+		}
+
 		// matched the event type.
 		return []*watch.WatchEventDetail{event}, nil
 	}
@@ -185,13 +191,40 @@ func (c *Client) WatchWithStartFrom(kit *rest.Kit, key event.Key, opts *watch.Wa
 	}
 
 	// matched event has been found, get them all.
-	return c.getEventDetailsWithNodes(kit, opts, nodes, key)
+	details, err := c.getEventDetailsWithNodes(kit, opts, nodes, key)
+	if err != nil {
+		return nil, err
+	}
+
+	// all events are filtered out by the filter rules, return the last node's cursor with empty detail
+	if len(details) == 0 {
+		resp := &watch.WatchEventDetail{
+			Cursor:    nodes[len(nodes)-1].Cursor,
+			Resource:  opts.Resource,
+			EventType: "",
+			Detail:    nil,
+		}
+		return []*watch.WatchEventDetail{resp}, nil
+	}
+	return details, nil
 }
 
-// getEventDetailsWithNodes get event details with nodes, first get from redis, then get failed ones from mongo
+// getEventDetailsWithNodes get event details with nodes that matches the filter rules of the watch options
 func (c *Client) getEventDetailsWithNodes(kit *rest.Kit, opts *watch.WatchEventOptions, hitNodes []*watch.ChainNode,
-	key event.Key) (
-	[]*watch.WatchEventDetail, error) {
+	key event.Key) ([]*watch.WatchEventDetail, error) {
+
+	fields, _ := getDetailFields(opts)
+	details, err := c.searchEventDetailsWithNodes(kit, opts, fields, hitNodes, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.filterEventDetails(kit, opts, key, details)
+}
+
+// searchEventDetailsWithNodes get event details with nodes, first get from redis, then get failed ones from mongo
+func (c *Client) searchEventDetailsWithNodes(kit *rest.Kit, opts *watch.WatchEventOptions, fields []string,
+	hitNodes []*watch.ChainNode, key event.Key) ([]*watch.WatchEventDetail, error) {
 
 	if len(hitNodes) == 0 {
 		return make([]*watch.WatchEventDetail, 0), nil
@@ -215,7 +248,7 @@ func (c *Client) getEventDetailsWithNodes(kit *rest.Kit, opts *watch.WatchEventO
 	if len(errNodes) == 0 {
 		resp := make([]*watch.WatchEventDetail, len(details))
 		for idx, detail := range details {
-			detail = *json.CutJsonDataWithFields(&detail, opts.Fields)
+			detail = *json.CutJsonDataWithFields(&detail, fields)
 			resp[idx] = &watch.WatchEventDetail{
 				Cursor:    hitNodes[idx].Cursor,
 				Resource:  opts.Resource,
@@ -227,7 +260,7 @@ func (c *Client) getEventDetailsWithNodes(kit *rest.Kit, opts *watch.WatchEventO
 		return resp, nil
 	}
 
-	indexDetailMap, err := c.searchEventDetailsFromMongo(kit, errNodes, opts.Fields, errCursorIndexMap, key)
+	indexDetailMap, err := c.searchEventDetailsFromMongo(kit, errNodes, fields, errCursorIndexMap, key)
 	if err != nil {
 		blog.Errorf("get details from mongo failed, err: %v, cursors: %+v, rid: %s", err, errNodes, kit.Rid)
 		return nil, err
@@ -243,7 +276,7 @@ func (c *Client) getEventDetailsWithNodes(kit *rest.Kit, opts *watch.WatchEventO
 			if !key.IsGeneralRes() {
 				jsonStr = types.GetEventDetail(&detail)
 			}
-			detail = *json.CutJsonDataWithFields(jsonStr, opts.Fields)
+			detail = *json.CutJsonDataWithFields(jsonStr, fields)
 		}
 
 		resp[idx] = &watch.WatchEventDetail{
@@ -538,7 +571,8 @@ func (c *Client) WatchFromNow(kit *rest.Kit, key event.Key, opts *watch.WatchEve
 		}, nil
 	}
 
-	detail, exists, err := c.getEventDetail(kit, node, opts.Fields, key)
+	fields, _ := getDetailFields(opts)
+	detail, exists, err := c.getEventDetail(kit, node, fields, key)
 	if err != nil {
 		blog.Errorf("watch from now, but get latest event detail failed, err: %v, rid: %s", err, rid)
 		return nil, err
@@ -568,6 +602,11 @@ func (c *Client) WatchFromNow(kit *rest.Kit, key event.Key, opts *watch.WatchEve
 
 	}
 
+	if err = c.filterEventDetail(kit, opts, key, e); err != nil {
+		blog.Errorf("watch from now, but filter latest event detail failed, err: %v, rid: %s", err, rid)
+		return nil, err
+	}
+
 	// matched the event type.
 	return e, nil
 }
@@ -603,7 +642,26 @@ func (c *Client) WatchWithCursor(kit *rest.Kit, key event.Key, opts *watch.Watch
 
 	for {
 		if len(nodes) != 0 {
-			return c.getEventDetailsWithNodes(kit, opts, nodes, key)
+			details, err := c.getEventDetailsWithNodes(kit, opts, nodes, key)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(details) != 0 {
+				return details, nil
+			}
+
+			// all events are filtered out by the filter rules, continue to watch the events after them
+			nodeID = nodes[len(nodes)-1].ID
+			if len(nodes) == eventStep && time.Now().Unix()-start <= timeoutWatchLoopSeconds {
+				searchOpt.id = nodeID
+				nodes, err = c.searchFollowingEventChainNodesByID(kit, searchOpt)
+				if err != nil {
+					blog.Errorf("watch event from cursor: %s failed, err: %v, rid: %s", opts.Cursor, err, kit.Rid)
+					return nil, err
+				}
+				continue
+			}
 		}
 
 		// we got not even one event, sleep a little, and then try to continue the loop watch
@@ -639,7 +697,17 @@ func (c *Client) WatchWithCursor(kit *rest.Kit, key event.Key, opts *watch.Watch
 					return nil, err
 				}
 				if len(nodes) != 0 {
-					return c.getEventDetailsWithNodes(kit, opts, nodes, key)
+					details, err := c.getEventDetailsWithNodes(kit, opts, nodes, key)
+					if err != nil {
+						return nil, err
+					}
+
+					if len(details) != 0 {
+						return details, nil
+					}
+
+					// all events are filtered out, return the last filtered event's cursor with empty detail
+					lastNode = nodes[len(nodes)-1]
 				}
 
 				resp := &watch.WatchEventDetail{
@@ -90,7 +90,7 @@ func (s *cacheService) SetConfig(cfg options.Config, engine *backbone.Engine, er
 	}
 
 	iamCli := new(iam.IAM)
-	if auth.EnableAuthorize() {
+	if auth.EnableIAM() {
 		var rawErr error
 		iamCli, rawErr = iam.NewIAM(cfg.Auth, engine.Metric().Registry())
 		if rawErr != nil {
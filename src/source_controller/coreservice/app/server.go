@@ -31,6 +31,7 @@ import (
 	coresvr "configcenter/src/source_controller/coreservice/service"
 	"configcenter/src/storage/driver/mongodb"
 	"configcenter/src/storage/driver/redis"
+	"configcenter/src/thirdparty/webhook"
 )
 
 // CoreServer the core server
@@ -140,5 +141,10 @@ func initResource(coreSvr *CoreServer, op *options.ServerOption) error {
 		return initErr
 	}
 
+	if err = webhook.Init(""); err != nil {
+		blog.Errorf("init admission webhooks failed, err: %v", err)
+		return err
+	}
+
 	return nil
 }
@@ -217,8 +217,13 @@ func (c *cloudOperation) getSyncTaskCloudVendor(kit *rest.Kit, accountID int64)
 	return result.CloudVendor, nil
 }
 
-// DeleteDestroyedHostRelated TODO
-func (c *cloudOperation) DeleteDestroyedHostRelated(kit *rest.Kit, option *metadata.DeleteDestroyedHostRelatedOption) errors.CCErrorCoder {
+// DeleteDestroyedHostRelated update the destroyed hosts and delete their related data, returns the deleted data
+// so that the sync can be rolled back
+func (c *cloudOperation) DeleteDestroyedHostRelated(kit *rest.Kit,
+	option *metadata.DeleteDestroyedHostRelatedOption) (*metadata.DestroyedHostRelated, errors.CCErrorCoder) {
+
+	related := new(metadata.DestroyedHostRelated)
+
 	// update destroyed host
 	updateHostCond := map[string]interface{}{
 		common.BKHostIDField: map[string]interface{}{
@@ -242,14 +247,15 @@ func (c *cloudOperation) DeleteDestroyedHostRelated(kit *rest.Kit, option *metad
 		},
 	}
 	instances := make([]metadata.ServiceInstance, 0)
-	err = c.dbProxy.Table(common.BKTableNameServiceInstance).Find(serviceInstanceFilter).Fields(common.BKFieldID).All(kit.Ctx, &instances)
+	err = c.dbProxy.Table(common.BKTableNameServiceInstance).Find(serviceInstanceFilter).All(kit.Ctx, &instances)
 	if err != nil {
 		blog.ErrorJSON("DeleteDestroyedHostRelated failed, get service instance IDs err:%s, filter: %#v, rid: %s", err, serviceInstanceFilter, kit.Rid)
-		return kit.CCError.CCErrorf(common.CCErrCommDBSelectFailed)
+		return nil, kit.CCError.CCErrorf(common.CCErrCommDBSelectFailed)
 	}
 	if len(instances) == 0 {
-		return nil
+		return related, nil
 	}
+	related.ServiceInstances = instances
 	serviceInstanceIDs := make([]int64, 0)
 	for _, instance := range instances {
 		serviceInstanceIDs = append(serviceInstanceIDs, instance.ID)
@@ -264,8 +270,9 @@ func (c *cloudOperation) DeleteDestroyedHostRelated(kit *rest.Kit, option *metad
 	relations := make([]metadata.ProcessInstanceRelation, 0)
 	if err := c.dbProxy.Table(common.BKTableNameProcessInstanceRelation).Find(processRelationFilter).All(kit.Ctx, &relations); nil != err {
 		blog.Errorf("DeleteDestroyedHostRelated failed, get process instance relation err:%s, filter: %#v, rid: %s", err, processRelationFilter, kit.Rid)
-		return kit.CCError.CCErrorf(common.CCErrCommDBSelectFailed)
+		return nil, kit.CCError.CCErrorf(common.CCErrCommDBSelectFailed)
 	}
+	related.ProcessRelations = relations
 	processIDs := make([]int64, 0)
 	for _, relation := range relations {
 		processIDs = append(processIDs, relation.ProcessID)
@@ -275,7 +282,7 @@ func (c *cloudOperation) DeleteDestroyedHostRelated(kit *rest.Kit, option *metad
 	if len(processIDs) > 0 {
 		if err := c.dbProxy.Table(common.BKTableNameProcessInstanceRelation).Delete(kit.Ctx, processRelationFilter); nil != err {
 			blog.Errorf("DeleteDestroyedHostRelated failed, delete process instance relation err:%s, filter: %#v, rid: %s", err, processRelationFilter, kit.Rid)
-			return kit.CCError.CCErrorf(common.CCErrCommDBDeleteFailed)
+			return nil, kit.CCError.CCErrorf(common.CCErrCommDBDeleteFailed)
 		}
 
 		processFilter := map[string]interface{}{
@@ -283,9 +290,15 @@ func (c *cloudOperation) DeleteDestroyedHostRelated(kit *rest.Kit, option *metad
 				common.BKDBIN: processIDs,
 			},
 		}
+		processes := make([]metadata.Process, 0)
+		if err := c.dbProxy.Table(common.BKTableNameBaseProcess).Find(processFilter).All(kit.Ctx, &processes); err != nil {
+			blog.Errorf("DeleteDestroyedHostRelated failed, get process instances err:%s, filter: %#v, rid: %s", err, processFilter, kit.Rid)
+			return nil, kit.CCError.CCErrorf(common.CCErrCommDBSelectFailed)
+		}
+		related.Processes = processes
 		if err := c.dbProxy.Table(common.BKTableNameBaseProcess).Delete(kit.Ctx, processFilter); nil != err {
 			blog.Errorf("DeleteDestroyedHostRelated failed, delete process instances err:%s, filter: %#v, rid: %s", err, processFilter, kit.Rid)
-			return kit.CCError.CCErrorf(common.CCErrCommDBDeleteFailed)
+			return nil, kit.CCError.CCErrorf(common.CCErrCommDBDeleteFailed)
 		}
 	}
 
@@ -297,7 +310,7 @@ func (c *cloudOperation) DeleteDestroyedHostRelated(kit *rest.Kit, option *metad
 	}
 	if err := c.dbProxy.Table(common.BKTableNameServiceInstance).Delete(kit.Ctx, serviceInstanceIDFilter); nil != err {
 		blog.Errorf("DeleteDestroyedHostRelated failed, delete service instances err:%s, filter: %#v, rid: %s", err, serviceInstanceIDFilter, kit.Rid)
-		return kit.CCError.CCErrorf(common.CCErrCommDBDeleteFailed)
+		return nil, kit.CCError.CCErrorf(common.CCErrCommDBDeleteFailed)
 	}
 
 	// delete association
@@ -320,11 +333,11 @@ func (c *cloudOperation) DeleteDestroyedHostRelated(kit *rest.Kit, option *metad
 
 	hostAsstTableName := common.GetObjectInstAsstTableName(common.BKInnerObjIDHost, kit.SupplierAccount)
 	hostAssociations := make([]metadata.InstAsst, 0)
-	if err := c.dbProxy.Table(hostAsstTableName).Find(asstFilter).Fields(common.BKObjIDField, common.BKAsstObjIDField).
-		All(kit.Ctx, &hostAssociations); err != nil {
+	if err := c.dbProxy.Table(hostAsstTableName).Find(asstFilter).All(kit.Ctx, &hostAssociations); err != nil {
 		blog.ErrorJSON("find host associations failed, err: %s, filter: %s, rid: %s", err, asstFilter, kit.Rid)
-		return kit.CCError.CCErrorf(common.CCErrCommDBSelectFailed)
+		return nil, kit.CCError.CCErrorf(common.CCErrCommDBSelectFailed)
 	}
+	related.InstAssts = hostAssociations
 
 	objIDMap := make(map[string]struct{})
 	for _, asst := range hostAssociations {
@@ -345,15 +358,114 @@ func (c *cloudOperation) DeleteDestroyedHostRelated(kit *rest.Kit, option *metad
 		if err != nil {
 			blog.ErrorJSON("delete host association failed, err: %s, table: %s, filter: %s, rid: %s", err,
 				asstTableName, asstFilter, kit.Rid)
-			return kit.CCError.CCErrorf(common.CCErrCommDBDeleteFailed)
+			return nil, kit.CCError.CCErrorf(common.CCErrCommDBDeleteFailed)
 		}
 	}
 
 	err = c.dbProxy.Table(hostAsstTableName).Delete(kit.Ctx, asstFilter)
 	if nil != err {
 		blog.Errorf("DeleteDestroyedHostRelated failed, delete inst association err:%s, filter:%s, rid: %s", err, asstFilter, kit.Rid)
-		return kit.CCError.CCErrorf(common.CCErrCommDBDeleteFailed)
+		return nil, kit.CCError.CCErrorf(common.CCErrCommDBDeleteFailed)
 	}
 
-	return nil
+	return related, nil
+}
+
+// RestoreDestroyedHostRelated restore the related data that is deleted when the hosts are destroyed, the service
+// instances whose host is not in their module any more are not restored, nor are their processes
+func (c *cloudOperation) RestoreDestroyedHostRelated(kit *rest.Kit, related *metadata.DestroyedHostRelated) (
+	*metadata.RestoreDestroyedHostRelatedData, errors.CCErrorCoder) {
+
+	result := &metadata.RestoreDestroyedHostRelatedData{NotRestored: make([]int64, 0)}
+	notRestoredHosts := make(map[int64]struct{})
+
+	instances := make([]metadata.ServiceInstance, 0)
+	instanceIDs := make(map[int64]struct{})
+	for _, instance := range related.ServiceInstances {
+		cond := map[string]interface{}{
+			common.BKHostIDField:   instance.HostID,
+			common.BKModuleIDField: instance.ModuleID,
+		}
+		cnt, err := c.dbProxy.Table(common.BKTableNameModuleHostConfig).Find(cond).Count(kit.Ctx)
+		if err != nil {
+			blog.Errorf("count host module relation failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+			return nil, kit.CCError.CCErrorf(common.CCErrCommDBSelectFailed)
+		}
+		if cnt == 0 {
+			blog.Infof("host %d is not in module %d, skip restoring service instance %d, rid: %s", instance.HostID,
+				instance.ModuleID, instance.ID, kit.Rid)
+			notRestoredHosts[instance.HostID] = struct{}{}
+			continue
+		}
+		instances = append(instances, instance)
+		instanceIDs[instance.ID] = struct{}{}
+	}
+
+	relations := make([]metadata.ProcessInstanceRelation, 0)
+	processIDs := make(map[int64]struct{})
+	for _, relation := range related.ProcessRelations {
+		if _, exists := instanceIDs[relation.ServiceInstanceID]; !exists {
+			continue
+		}
+		relations = append(relations, relation)
+		processIDs[relation.ProcessID] = struct{}{}
+	}
+
+	processes := make([]metadata.Process, 0)
+	for _, process := range related.Processes {
+		if _, exists := processIDs[process.ProcessID]; exists {
+			processes = append(processes, process)
+		}
+	}
+
+	if len(instances) > 0 {
+		if err := c.dbProxy.Table(common.BKTableNameServiceInstance).Insert(kit.Ctx, instances); err != nil {
+			blog.Errorf("restore service instances failed, err: %v, rid: %s", err, kit.Rid)
+			return nil, kit.CCError.CCErrorf(common.CCErrCommDBInsertFailed)
+		}
+	}
+
+	if len(processes) > 0 {
+		if err := c.dbProxy.Table(common.BKTableNameBaseProcess).Insert(kit.Ctx, processes); err != nil {
+			blog.Errorf("restore process instances failed, err: %v, rid: %s", err, kit.Rid)
+			return nil, kit.CCError.CCErrorf(common.CCErrCommDBInsertFailed)
+		}
+	}
+
+	if len(relations) > 0 {
+		if err := c.dbProxy.Table(common.BKTableNameProcessInstanceRelation).Insert(kit.Ctx, relations); err != nil {
+			blog.Errorf("restore process instance relations failed, err: %v, rid: %s", err, kit.Rid)
+			return nil, kit.CCError.CCErrorf(common.CCErrCommDBInsertFailed)
+		}
+	}
+
+	// the association is saved in the association tables of both the objects
+	tableAssts := make(map[string][]metadata.InstAsst)
+	asstIDs := make(map[int64]struct{})
+	for _, asst := range related.InstAssts {
+		// the association between two hosts may be restored with both of the hosts
+		if _, exists := asstIDs[asst.ID]; exists {
+			continue
+		}
+		asstIDs[asst.ID] = struct{}{}
+
+		tableName := common.GetObjectInstAsstTableName(asst.ObjectID, kit.SupplierAccount)
+		tableAssts[tableName] = append(tableAssts[tableName], asst)
+		if asst.AsstObjectID != asst.ObjectID {
+			tableName = common.GetObjectInstAsstTableName(asst.AsstObjectID, kit.SupplierAccount)
+			tableAssts[tableName] = append(tableAssts[tableName], asst)
+		}
+	}
+
+	for tableName, assts := range tableAssts {
+		if err := c.dbProxy.Table(tableName).Insert(kit.Ctx, assts); err != nil {
+			blog.Errorf("restore host associations failed, table: %s, err: %v, rid: %s", tableName, err, kit.Rid)
+			return nil, kit.CCError.CCErrorf(common.CCErrCommDBInsertFailed)
+		}
+	}
+
+	for hostID := range notRestoredHosts {
+		result.NotRestored = append(result.NotRestored, hostID)
+	}
+	return result, nil
 }
@@ -253,6 +253,7 @@ type StatisticOperation interface {
 	UpdateOperationChart(kit *rest.Kit, inputParam map[string]interface{}) (interface{}, error)
 	SearchTimerChartData(kit *rest.Kit, inputParam metadata.ChartConfig) (interface{}, error)
 	TimerFreshData(kit *rest.Kit) error
+	StatisticQuery(kit *rest.Kit, opt *metadata.StatisticQueryOption) (*metadata.StatisticResult, error)
 }
 
 // Core core itnerfaces methods
@@ -428,7 +429,10 @@ type CloudOperation interface {
 	CreateSyncHistory(kit *rest.Kit, account *metadata.SyncHistory) (*metadata.SyncHistory, errors.CCErrorCoder)
 	SearchSyncHistory(kit *rest.Kit, option *metadata.SearchSyncHistoryOption) (*metadata.MultipleSyncHistory,
 		errors.CCErrorCoder)
-	DeleteDestroyedHostRelated(kit *rest.Kit, option *metadata.DeleteDestroyedHostRelatedOption) errors.CCErrorCoder
+	DeleteDestroyedHostRelated(kit *rest.Kit, option *metadata.DeleteDestroyedHostRelatedOption) (
+		*metadata.DestroyedHostRelated, errors.CCErrorCoder)
+	RestoreDestroyedHostRelated(kit *rest.Kit, related *metadata.DestroyedHostRelated) (
+		*metadata.RestoreDestroyedHostRelatedData, errors.CCErrorCoder)
 }
 
 // SystemOperation TODO
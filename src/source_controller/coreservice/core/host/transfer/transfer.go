@@ -30,6 +30,7 @@ import (
 	kubetypes "configcenter/src/kube/types"
 	"configcenter/src/storage/driver/mongodb"
 	"configcenter/src/thirdparty/hooks"
+	"configcenter/src/thirdparty/webhook"
 )
 
 type genericTransfer struct {
@@ -63,6 +64,17 @@ func (t *genericTransfer) ValidParameter(kit *rest.Kit) errors.CCErrorCoder {
 		return err
 	}
 
+	transfer := &webhook.TransferInfo{
+		CrossBiz:  t.crossBizTransfer,
+		SrcBizIDs: t.srcBizIDs,
+		BizID:     t.bizID,
+		ModuleIDs: t.moduleIDArr,
+	}
+	if err := webhook.AdmitTransfer(kit, transfer); err != nil {
+		blog.Errorf("admit host transfer failed, err: %v, rid: %s", err, kit.Rid)
+		return err
+	}
+
 	err := t.validParameterInst(kit)
 	if err != nil {
 		return err
@@ -17,6 +17,7 @@
 package instances
 
 import (
+	"reflect"
 	"strconv"
 	"strings"
 
@@ -34,6 +35,7 @@ import (
 	"configcenter/src/storage/driver/mongodb"
 	"configcenter/src/storage/driver/mongodb/instancemapping"
 	"configcenter/src/thirdparty/hooks"
+	"configcenter/src/thirdparty/webhook"
 )
 
 var _ core.InstanceOperation = (*instanceManager)(nil)
@@ -271,6 +273,9 @@ func (m *instanceManager) UpdateModelInstance(kit *rest.Kit, objID string, input
 		return nil, err
 	}
 
+	// the update data is validated and admitted for each instance separately, because the hooks and the mutating
+	// webhooks can change the update data by the instance
+	updateDataArr := make([]mapstr.MapStr, len(origins))
 	for index, origin := range origins {
 		validator := instValidators[index]
 		if validator == nil {
@@ -287,27 +292,55 @@ func (m *instanceManager) UpdateModelInstance(kit *rest.Kit, objID string, input
 			}
 		}
 
-		if err := hooks.UpdateProcessBindInfoHook(kit, objID, origin, inputParam.Data); err != nil {
+		updateData := inputParam.Data.Clone()
+		if err := hooks.UpdateProcessBindInfoHook(kit, objID, origin, updateData); err != nil {
 			return nil, err
 		}
 
-		if err = m.validUpdateInstanceData(kit, objID, inputParam.Data, origin, validator, inputParam.CanEditAll,
+		if err = m.validUpdateInstanceData(kit, objID, updateData, origin, validator, inputParam.CanEditAll,
 			isMainline); err != nil {
 			blog.Errorf("update instance validation failed, err: %v, objID: %s, update data: %#v, inst: %#v, rid: %s",
-				err, objID, inputParam.Data, origin, kit.Rid)
+				err, objID, updateData, origin, kit.Rid)
 			return nil, kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, err.Error())
 		}
+		updateDataArr[index] = updateData
 	}
 
-	err = m.update(kit, objID, inputParam.Data, inputParam.Condition)
+	computedData, err := m.calcUpdateComputedFields(kit, updateDataArr, origins, instValidators)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isSameUpdateData(updateDataArr) {
+		if err = m.updateEachInstance(kit, objID, origins, updateDataArr, computedData); err != nil {
+			return nil, err
+		}
+		return &metadata.UpdatedCount{Count: uint64(len(origins))}, nil
+	}
+
+	updateData := updateDataArr[0]
+
+	// if all the instances have the same computed values, update them together with the update data
+	if len(computedData) == 1 {
+		for key, val := range computedData[0] {
+			updateData[key] = val
+		}
+		computedData = nil
+	}
+
+	err = m.update(kit, objID, updateData, inputParam.Condition)
 	if err != nil {
 		blog.Errorf("update objID(%s) inst failed, err: %v, condition: %#v, data: %#v rid: %s", objID, err,
-			inputParam.Condition, inputParam.Data, kit.Rid)
+			inputParam.Condition, updateData, kit.Rid)
+		return nil, err
+	}
+
+	if err = m.updateComputedFields(kit, objID, origins, computedData); err != nil {
 		return nil, err
 	}
 
 	if objID == common.BKInnerObjIDHost {
-		if err := m.updateHostProcessBindIP(kit, inputParam.Data, origins); err != nil {
+		if err := m.updateHostProcessBindIP(kit, updateData, origins); err != nil {
 			return nil, err
 		}
 	}
@@ -315,6 +348,151 @@ func (m *instanceManager) UpdateModelInstance(kit *rest.Kit, objID string, input
 	return &metadata.UpdatedCount{Count: uint64(len(origins))}, nil
 }
 
+// isSameUpdateData checks if the update data of all the instances are the same, so that they can be updated together
+func isSameUpdateData(updateDataArr []mapstr.MapStr) bool {
+	for _, updateData := range updateDataArr[1:] {
+		if !reflect.DeepEqual(updateData, updateDataArr[0]) {
+			return false
+		}
+	}
+	return true
+}
+
+// updateEachInstance update each instance separately with its own update data and computed field values
+func (m *instanceManager) updateEachInstance(kit *rest.Kit, objID string, origins, updateDataArr,
+	computedData []mapstr.MapStr) error {
+
+	idField := common.GetInstIDField(objID)
+	for index, origin := range origins {
+		updateData := updateDataArr[index]
+		var instComputedData mapstr.MapStr
+		switch len(computedData) {
+		case 0:
+		case 1:
+			instComputedData = computedData[0]
+		default:
+			instComputedData = computedData[index]
+		}
+		for key, val := range instComputedData {
+			updateData[key] = val
+		}
+
+		cond := util.SetModOwner(mapstr.MapStr{idField: origin[idField]}, kit.SupplierAccount)
+		if err := m.update(kit, objID, updateData, cond); err != nil {
+			blog.Errorf("update %s inst %v failed, err: %v, data: %#v, rid: %s", objID, origin[idField], err,
+				updateData, kit.Rid)
+			return err
+		}
+
+		if objID == common.BKInnerObjIDHost {
+			if err := m.updateHostProcessBindIP(kit, updateData, []mapstr.MapStr{origin}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// calcUpdateComputedFields calculate the computed fields of the instances whose referenced fields are updated, returns
+// the computed field values of each instance, or only one of them if the values of all instances are the same
+func (m *instanceManager) calcUpdateComputedFields(kit *rest.Kit, updateDataArr []mapstr.MapStr,
+	origins []mapstr.MapStr, validators []*validator) ([]mapstr.MapStr, error) {
+
+	computedData := make([]mapstr.MapStr, len(origins))
+	hasComputed, allSame := false, true
+	var err error
+	for index, origin := range origins {
+		updateData := updateDataArr[index]
+		computedAttrs := make([]metadata.Attribute, 0)
+		for _, attr := range validators[index].propertySlice {
+			if attr.PropertyType != common.FieldTypeComputed {
+				continue
+			}
+
+			expr, err := metadata.ParseComputedOption(attr.Option)
+			if err != nil {
+				blog.Errorf("parse computed attribute %s option failed, err: %v, rid: %s", attr.PropertyID, err,
+					kit.Rid)
+				return nil, kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, attr.PropertyID)
+			}
+
+			for _, field := range expr.Fields() {
+				if _, exists := updateData[field]; exists {
+					computedAttrs = append(computedAttrs, attr)
+					break
+				}
+			}
+		}
+
+		if len(computedAttrs) > 0 {
+			hasComputed = true
+			if computedData[index], err = calcInstComputedFields(kit, origin, updateData, computedAttrs); err != nil {
+				return nil, err
+			}
+		}
+
+		if index > 0 && !reflect.DeepEqual(computedData[index], computedData[0]) {
+			allSame = false
+		}
+	}
+
+	if !hasComputed {
+		return nil, nil
+	}
+
+	if allSame {
+		return computedData[:1], nil
+	}
+	return computedData, nil
+}
+
+// calcInstComputedFields calculate the computed fields of the instance with the update data merged into it
+func calcInstComputedFields(kit *rest.Kit, origin, updateData mapstr.MapStr,
+	computedAttrs []metadata.Attribute) (mapstr.MapStr, error) {
+
+	newInstData := make(mapstr.MapStr)
+	for k, v := range origin {
+		newInstData[k] = v
+	}
+	for k, v := range updateData {
+		newInstData[k] = v
+	}
+
+	if err := metadata.FillComputedFields(kit.Ctx, newInstData, computedAttrs); err != nil {
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, err.Error())
+	}
+
+	computedData := make(mapstr.MapStr)
+	for _, attr := range computedAttrs {
+		computedData[attr.PropertyID] = newInstData[attr.PropertyID]
+	}
+	return computedData, nil
+}
+
+// updateComputedFields update the computed field values of each instance separately
+func (m *instanceManager) updateComputedFields(kit *rest.Kit, objID string, origins []mapstr.MapStr,
+	computedData []mapstr.MapStr) error {
+
+	if len(computedData) == 0 {
+		return nil
+	}
+
+	idField := common.GetInstIDField(objID)
+	for index, origin := range origins {
+		if len(computedData[index]) == 0 {
+			continue
+		}
+
+		cond := util.SetModOwner(mapstr.MapStr{idField: origin[idField]}, kit.SupplierAccount)
+		if err := m.update(kit, objID, computedData[index], cond); err != nil {
+			blog.Errorf("update %s inst %v computed fields failed, err: %v, data: %#v, rid: %s", objID,
+				origin[idField], err, computedData[index], kit.Rid)
+			return err
+		}
+	}
+	return nil
+}
+
 // updateHostProcessBindIP if hosts' ips are updated, update processes which binds the changed ip
 func (m *instanceManager) updateHostProcessBindIP(kit *rest.Kit, data mapstr.MapStr, origins []mapstr.MapStr) error {
 	updatedHostFirstIPMap := make(map[string]string)
@@ -604,6 +782,11 @@ func (m *instanceManager) DeleteModelInstance(kit *rest.Kit, objID string,
 		}
 	}
 
+	if err := webhook.AdmitDelete(kit, objID, instIDs, origins); err != nil {
+		blog.Errorf("admit delete %s instances %v failed, err: %v, rid: %s", objID, instIDs, err, kit.Rid)
+		return &metadata.DeletedCount{}, err
+	}
+
 	// delete object instance data.
 	err = mongodb.Client().Table(tableName).Delete(kit.Ctx, inputParam.Condition)
 	if nil != err {
@@ -648,7 +831,14 @@ func (m *instanceManager) CascadeDeleteModelInstance(kit *rest.Kit, objID string
 			return &metadata.DeletedCount{}, err
 		}
 		instIDs = append(instIDs, instID)
+	}
+
+	if err := webhook.AdmitDelete(kit, objID, instIDs, origins); err != nil {
+		blog.Errorf("admit cascade delete %s instances %v failed, err: %v, rid: %s", objID, instIDs, err, kit.Rid)
+		return &metadata.DeletedCount{}, err
+	}
 
+	for _, instID := range instIDs {
 		err = m.dependent.DeleteInstAsst(kit, objID, uint64(instID))
 		if nil != err {
 			return &metadata.DeletedCount{}, err
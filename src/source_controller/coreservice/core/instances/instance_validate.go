@@ -32,6 +32,7 @@ import (
 	"configcenter/src/common/valid"
 	"configcenter/src/storage/driver/mongodb"
 	"configcenter/src/thirdparty/hooks"
+	"configcenter/src/thirdparty/webhook"
 )
 
 var updateIgnoreKeys = []string{
@@ -190,11 +191,28 @@ func (m *instanceManager) validCreateInstanceData(kit *rest.Kit, objID string, i
 		return kit.CCError.Errorf(common.CCErrCommParamsIsInvalid, err.Error())
 	}
 
+	// call admission webhooks before validating the instance value, so that the mutated data can be validated
+	if err := webhook.AdmitCreate(kit, objID, instanceData); err != nil {
+		blog.Errorf("admit create %s instance failed, err: %v, rid: %s", objID, err, kit.Rid)
+		return err
+	}
+
+	normalizeNetworkAddr(instanceData, valid.propertySlice)
+
+	// computed fields are always calculated by the server, the values passed by user are ignored
+	if err := metadata.FillComputedFields(kit.Ctx, instanceData, valid.propertySlice); err != nil {
+		return kit.CCError.Errorf(common.CCErrCommParamsIsInvalid, err.Error())
+	}
+
 	err = m.validateCreateInstValue(kit, objID, instanceData, valid)
 	if err != nil {
 		return kit.CCError.Errorf(common.CCErrCommParamsIsInvalid, err.Error())
 	}
 
+	if err := valid.validAttrValidators(kit, instanceData, instanceData); err != nil {
+		return err
+	}
+
 	skip, err := hooks.IsSkipValidateHook(kit, objID, instanceData)
 	if err != nil {
 		blog.Errorf("check is skip validate %s hook failed, err: %v, rid: %s", objID, err, kit.Rid)
@@ -363,6 +381,13 @@ func (m *instanceManager) validUpdateInstanceData(kit *rest.Kit, objID string, u
 		return err
 	}
 
+	// the compiled-in hooks are kept because they are the extension points of the existing downstream builds
+	// and have no effect in this build, the webhooks are called after them with the update data of this instance only
+	if err := webhook.AdmitUpdate(kit, objID, updateData, instanceData); err != nil {
+		blog.Errorf("admit update %s instance failed, err: %v, rid: %s", objID, err, kit.Rid)
+		return err
+	}
+
 	isInnerModel := common.IsInnerModel(objID) || isMainline
 
 	err = m.validOneUpdateInstKeyVal(kit, valid, updateData, instanceData, isInnerModel, canEditAll)
@@ -370,6 +395,20 @@ func (m *instanceManager) validUpdateInstanceData(kit *rest.Kit, objID string, u
 		return err
 	}
 
+	// validator plugins only validate the updated fields, but they can see the whole instance after update
+	newInstData := make(mapstr.MapStr)
+	for k, v := range instanceData {
+		newInstData[k] = v
+	}
+	for k, v := range updateData {
+		newInstData[k] = v
+	}
+	if err := valid.validAttrValidators(kit, updateData, newInstData); err != nil {
+		return err
+	}
+
+	normalizeNetworkAddr(updateData, valid.propertySlice)
+
 	if err := m.changeStringToTime(updateData, valid.propertySlice); err != nil {
 		blog.Errorf("there is an error in converting the time type string to the time type, err: %v, rid: %s", err,
 			kit.Rid)
@@ -404,6 +443,12 @@ func (m *instanceManager) validOneUpdateInstKeyVal(kit *rest.Kit, valid *validat
 			continue
 		}
 
+		// computed field is calculated by the server after the validation, it can not be updated directly
+		if property.PropertyType == common.FieldTypeComputed {
+			delete(updateData, key)
+			continue
+		}
+
 		// right now inner table should be updated as quoted instance, cannot update in source instance
 		if property.PropertyType == common.FieldTypeInnerTable {
 			delete(updateData, key)
@@ -526,6 +571,22 @@ func (m *instanceManager) validCloudID(kit *rest.Kit, objID string, instanceData
 	return nil
 }
 
+// normalizeNetworkAddr store the ipaddr and cidr attribute values in the canonical form, so that they can be
+// matched by the ip_in_cidr operator
+func normalizeNetworkAddr(valData mapstr.MapStr, properties []metadata.Attribute) {
+	for _, field := range properties {
+		if field.PropertyType != common.FieldTypeIPAddr && field.PropertyType != common.FieldTypeCIDR {
+			continue
+		}
+
+		val, ok := valData[field.PropertyID]
+		if !ok || val == nil {
+			continue
+		}
+		valData[field.PropertyID] = metadata.NormalizeNetworkAddr(field.PropertyType, val)
+	}
+}
+
 func (m *instanceManager) changeStringToTime(valData mapstr.MapStr, properties []metadata.Attribute) error {
 	for _, field := range properties {
 		if field.PropertyType != common.FieldTypeTime {
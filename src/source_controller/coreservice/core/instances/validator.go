@@ -22,7 +22,9 @@ import (
 	"configcenter/src/common/errors"
 	"configcenter/src/common/http/rest"
 	"configcenter/src/common/language"
+	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
+	attrvalid "configcenter/src/common/valid/attribute"
 )
 
 type validator struct {
@@ -146,3 +148,15 @@ func NewValidators(kit *rest.Kit, dependent OperationDependences, objID string,
 
 	return bizValidatorMap, nil
 }
+
+// validAttrValidators run the validator plugins attached to the attributes that are set in the data, and the
+// validators whose params reference a field in the data (e.g. field_compare), instance is the whole instance data
+// (the merged data for update) that is used for cross field validation
+func (valid *validator) validAttrValidators(kit *rest.Kit, data, instance mapstr.MapStr) error {
+	for _, property := range valid.propertySlice {
+		if err := attrvalid.RunUpdatedPropertyValidators(kit, property, data, instance); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"time"
 
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
@@ -88,6 +89,10 @@ var ccSysFieldTypeRela = map[string]func(valData mapstr.MapStr, field metadata.A
 	common.FieldTypeTimeZone:     fillLostTimeZoneFieldValue,
 	common.FieldTypeList:         fillLostListFieldValue,
 	common.FieldTypeBool:         fillLostBoolFieldValue,
+	common.FieldTypeIPAddr:       fillLostDefaultFieldValue,
+	common.FieldTypeCIDR:         fillLostDefaultFieldValue,
+	common.FieldTypeURL:          fillLostDefaultFieldValue,
+	common.FieldTypeJSON:         fillLostDefaultFieldValue,
 }
 
 var ccSysFieldTypeCtxRela = map[string]func(ctx context.Context, valData mapstr.MapStr, field metadata.Attribute) error{
@@ -367,6 +372,12 @@ func fillLostBoolFieldValue(valData mapstr.MapStr, field metadata.Attribute) err
 	return nil
 }
 
+// fillLostDefaultFieldValue fill the default value whose validity is checked when the attribute is saved
+func fillLostDefaultFieldValue(valData mapstr.MapStr, field metadata.Attribute) error {
+	valData[field.PropertyID] = field.Default
+	return nil
+}
+
 func getEnumOption(ctx context.Context, val interface{}) ([]metadata.EnumVal, error) {
 	enumOptions, err := metadata.ParseEnumOption(val)
 	if err != nil {
@@ -408,10 +419,20 @@ func fillLostIDRuleFieldValue(ctx context.Context, valData mapstr.MapStr, field
 	return nil
 }
 
+// IDRuleSequenceGetter get the next sequence id of the id rule by the sequence name
+type IDRuleSequenceGetter func(ctx context.Context, seqName string) (uint64, error)
+
 // GetIDRuleVal get id rule value
 func GetIDRuleVal(ctx context.Context, valData mapstr.MapStr, field metadata.Attribute, attrTypeMap map[string]string) (
 	string, error) {
 
+	return RenderIDRuleVal(ctx, valData, field, attrTypeMap, time.Now(), mongodb.Client().NextSequence)
+}
+
+// RenderIDRuleVal render id rule value at the specified time, the self-increasing ids are generated by nextSeq
+func RenderIDRuleVal(ctx context.Context, valData mapstr.MapStr, field metadata.Attribute,
+	attrTypeMap map[string]string, now time.Time, nextSeq IDRuleSequenceGetter) (string, error) {
+
 	rid := util.ExtractRequestIDFromContext(ctx)
 
 	rules, err := metadata.ParseSubIDRules(field.Option)
@@ -440,9 +461,20 @@ func GetIDRuleVal(ctx context.Context, valData mapstr.MapStr, field metadata.Att
 
 			val += util.GetStrByInterface(valData[rule.Val])
 
-		case metadata.GlobalID:
-			seqName := metadata.GetIDRule(common.GlobalIDRule)
-			id, err := mongodb.Client().NextSequence(ctx, seqName)
+		case metadata.GlobalID, metadata.LocalID, metadata.PrefixID, metadata.PeriodID:
+			var seqName string
+			switch rule.Kind {
+			case metadata.GlobalID:
+				seqName = metadata.GetIDRule(common.GlobalIDRule)
+			case metadata.LocalID:
+				seqName = metadata.GetIDRule(field.ObjectID)
+			case metadata.PrefixID:
+				seqName = metadata.GetIDRulePrefixSeqName(field.ObjectID, val)
+			case metadata.PeriodID:
+				seqName = metadata.GetIDRulePeriodSeqName(field.ObjectID, metadata.IDRulePeriod(rule.Val), now)
+			}
+
+			id, err := nextSeq(ctx, seqName)
 			if err != nil {
 				blog.Errorf("get next sequence failed, seq name: %s, err: %v, rid: %s", seqName, err, rid)
 				return "", err
@@ -454,22 +486,24 @@ func GetIDRuleVal(ctx context.Context, valData mapstr.MapStr, field metadata.Att
 			}
 			val += idStr
 
-		case metadata.LocalID:
-			seqName := metadata.GetIDRule(field.ObjectID)
-			id, err := mongodb.Client().NextSequence(ctx, seqName)
+		case metadata.RandomID:
+			val += metadata.GetIDRuleRandomID(rule.Len)
+
+		case metadata.Date:
+			date, err := metadata.FormatIDRuleDate(rule.Val, now)
 			if err != nil {
-				blog.Errorf("get next sequence failed, seq name: %s, err: %v, rid: %s", seqName, err, rid)
+				blog.Errorf("format date failed, layout: %s, err: %v, rid: %s", rule.Val, err, rid)
 				return "", err
 			}
-			idStr, err := metadata.MakeUpDigit(id, rule.Len)
+			val += date
+
+		case metadata.CheckDigit:
+			checkDigit, err := metadata.GetIDRuleCheckDigit(rule.Val, val)
 			if err != nil {
-				blog.Errorf("make up the id failed, id: %d, len: %d, err: %v, rid: %s", id, rule.Len, err, rid)
+				blog.Errorf("get check digit failed, val: %s, rule: %+v, err: %v, rid: %s", val, rule, err, rid)
 				return "", err
 			}
-			val += idStr
-
-		case metadata.RandomID:
-			val += metadata.GetIDRuleRandomID(rule.Len)
+			val += checkDigit
 
 		default:
 			blog.Errorf("option is invalid, val: %v, rid: %s", field.Option, rid)
@@ -0,0 +1,187 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package model
+
+import (
+	"context"
+	"fmt"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/universalsql"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/driver/mongodb"
+)
+
+// getChangedComputedAttrs returns the computed attributes matched by the update condition whose expressions are
+// changed by the update data, the option of the returned attributes is replaced by the updated one
+func (m *modelAttribute) getChangedComputedAttrs(kit *rest.Kit, data mapstr.MapStr, cond universalsql.Condition) (
+	[]metadata.Attribute, error) {
+
+	option, exists := data[metadata.AttributeFieldOption]
+	if !exists {
+		return nil, nil
+	}
+
+	attrs, err := m.search(kit, cond)
+	if err != nil {
+		blog.Errorf("search attributes failed, cond: %#v, err: %v, rid: %s", cond.ToMapStr(), err, kit.Rid)
+		return nil, err
+	}
+
+	changed := make([]metadata.Attribute, 0)
+	for _, attr := range attrs {
+		if attr.PropertyType != common.FieldTypeComputed {
+			continue
+		}
+
+		newExpr, err := metadata.ParseComputedOption(option)
+		if err != nil {
+			blog.Errorf("parse computed attribute %s option failed, err: %v, rid: %s", attr.PropertyID, err, kit.Rid)
+			return nil, kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, metadata.AttributeFieldOption)
+		}
+
+		oldExpr, err := metadata.ParseComputedOption(attr.Option)
+		if err == nil && oldExpr.String() == newExpr.String() {
+			continue
+		}
+
+		attr.Option = option
+		changed = append(changed, attr)
+	}
+	return changed, nil
+}
+
+// computedValueGroup is the instances that have the same computed attribute value
+type computedValueGroup struct {
+	value interface{}
+	ids   []int64
+}
+
+// fillComputedAttrInInstances calculate the computed attribute values of the existing instances page by page, it is
+// called when the computed attribute is created or its expression is changed, because the instance values are
+// only calculated when the instance is created or its referenced fields are updated
+func (m *modelAttribute) fillComputedAttrInInstances(kit *rest.Kit, attr metadata.Attribute) error {
+	// this operation may take a long time, do not use transaction
+	ctx := context.Background()
+
+	expr, err := metadata.ParseComputedOption(attr.Option)
+	if err != nil {
+		blog.Errorf("parse computed attribute %s option failed, err: %v, rid: %s", attr.PropertyID, err, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, metadata.AttributeFieldOption)
+	}
+
+	cond, err := m.getComputedAttrInstCond(ctx, kit, attr)
+	if err != nil {
+		return err
+	}
+	if cond == nil {
+		return nil
+	}
+
+	idField := common.GetInstIDField(attr.ObjectID)
+	fields := append([]string{idField}, expr.Fields()...)
+	table := common.GetInstTableName(attr.ObjectID, kit.SupplierAccount)
+	attrs := []metadata.Attribute{attr}
+
+	lastID := int64(0)
+	for {
+		cond[idField] = mapstr.MapStr{common.BKDBGT: lastID}
+		insts := make([]mapstr.MapStr, 0)
+		err := mongodb.Client().Table(table).Find(cond).Fields(fields...).Sort(idField).
+			Limit(common.BKMaxPageSize).All(ctx, &insts)
+		if err != nil {
+			blog.Errorf("find %s instances failed, cond: %#v, err: %v, rid: %s", attr.ObjectID, cond, err, kit.Rid)
+			return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+		}
+
+		if len(insts) == 0 {
+			return nil
+		}
+
+		// the instances that have the same computed value are updated together
+		groups := make(map[string]*computedValueGroup)
+		for _, inst := range insts {
+			lastID, err = util.GetInt64ByInterface(inst[idField])
+			if err != nil {
+				blog.Errorf("parse %s instance id %v failed, err: %v, rid: %s", attr.ObjectID, inst[idField], err,
+					kit.Rid)
+				return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, idField)
+			}
+
+			if err := metadata.FillComputedFields(kit.Ctx, inst, attrs); err != nil {
+				return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, metadata.AttributeFieldOption)
+			}
+
+			value := inst[attr.PropertyID]
+			key := fmt.Sprintf("%T:%v", value, value)
+			if _, exists := groups[key]; !exists {
+				groups[key] = &computedValueGroup{value: value}
+			}
+			groups[key].ids = append(groups[key].ids, lastID)
+		}
+
+		for _, group := range groups {
+			updateCond := mapstr.MapStr{idField: mapstr.MapStr{common.BKDBIN: group.ids}}
+			updateData := mapstr.MapStr{attr.PropertyID: group.value}
+			if err := mongodb.Client().Table(table).Update(ctx, updateCond, updateData); err != nil {
+				blog.Errorf("update %s instances computed attribute %s failed, ids: %v, err: %v, rid: %s",
+					attr.ObjectID, attr.PropertyID, group.ids, err, kit.Rid)
+				return kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+			}
+		}
+
+		if len(insts) < common.BKMaxPageSize {
+			return nil
+		}
+	}
+}
+
+// getComputedAttrInstCond get the condition of the instances that the computed attribute applies to, returns nil if
+// there is no such instance
+func (m *modelAttribute) getComputedAttrInstCond(ctx context.Context, kit *rest.Kit, attr metadata.Attribute) (
+	mapstr.MapStr, error) {
+
+	cond := util.SetQueryOwner(mapstr.MapStr{}, kit.SupplierAccount)
+
+	if attr.BizID <= 0 {
+		return cond, nil
+	}
+
+	if attr.ObjectID != common.BKInnerObjIDHost {
+		cond[common.BKAppIDField] = attr.BizID
+		return cond, nil
+	}
+
+	// the hosts of the business attribute are got from the host relations
+	relCond := util.SetQueryOwner(mapstr.MapStr{common.BKAppIDField: attr.BizID}, kit.SupplierAccount)
+	hostIDs, err := mongodb.Client().Table(common.BKTableNameModuleHostConfig).Distinct(ctx, common.BKHostIDField,
+		relCond)
+	if err != nil {
+		blog.Errorf("get hosts of biz %d failed, err: %v, rid: %s", attr.BizID, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	if len(hostIDs) == 0 {
+		return nil, nil
+	}
+	cond[common.BKHostIDField] = mapstr.MapStr{common.BKDBIN: hostIDs}
+	return cond, nil
+}
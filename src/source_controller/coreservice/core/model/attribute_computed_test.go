@@ -0,0 +1,110 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package model
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/universalsql/mongo"
+	"configcenter/src/storage/dal/mongo/memory"
+	"configcenter/src/storage/driver/mongodb"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newComputedTestKit() *rest.Kit {
+	return &rest.Kit{
+		Rid:             "test-rid",
+		Ctx:             context.Background(),
+		CCError:         errors.NewFromCtx(errors.EmptyErrorsSetting).CreateDefaultCCErrorIf("en"),
+		User:            "admin",
+		SupplierAccount: common.BKDefaultOwnerID,
+	}
+}
+
+func TestFillComputedAttrInInstances(t *testing.T) {
+	db := memory.New()
+	mongodb.SetClient("", db)
+	kit := newComputedTestKit()
+
+	count := common.BKMaxPageSize + 1
+	insts := make([]interface{}, count)
+	for idx := range insts {
+		insts[idx] = mapstr.MapStr{
+			common.BKInstIDField:     int64(idx + 1),
+			common.BKObjIDField:      "switch",
+			common.BKInstNameField:   fmt.Sprintf("switch-%d", idx),
+			"cpu":                    int64(idx % 3),
+			common.BkSupplierAccount: kit.SupplierAccount,
+		}
+	}
+	table := common.GetInstTableName("switch", kit.SupplierAccount)
+	require.NoError(t, db.Table(table).Insert(kit.Ctx, insts))
+
+	m := new(modelAttribute)
+	attr := metadata.Attribute{ObjectID: "switch", PropertyID: "double_cpu", PropertyType: common.FieldTypeComputed,
+		Option: metadata.ComputedOption{Expression: "cpu * 2"}}
+	require.NoError(t, m.fillComputedAttrInInstances(kit, attr))
+
+	result := make([]mapstr.MapStr, 0)
+	require.NoError(t, db.Table(table).Find(nil).All(kit.Ctx, &result))
+	require.Len(t, result, count)
+	for _, inst := range result {
+		cpu, err := inst.Int64("cpu")
+		require.NoError(t, err)
+		computed, err := inst.Int64("double_cpu")
+		require.NoError(t, err)
+		require.Equal(t, cpu*2, computed)
+	}
+}
+
+func TestGetChangedComputedAttrs(t *testing.T) {
+	db := memory.New()
+	mongodb.SetClient("", db)
+	kit := newComputedTestKit()
+
+	attr := metadata.Attribute{ID: 1, ObjectID: "switch", PropertyID: "double_cpu",
+		PropertyType: common.FieldTypeComputed, Option: metadata.ComputedOption{Expression: "cpu * 2"},
+		OwnerID: kit.SupplierAccount}
+	require.NoError(t, db.Table(common.BKTableNameObjAttDes).Insert(kit.Ctx, attr))
+
+	m := new(modelAttribute)
+	cond := mongo.NewCondition().Element(mongo.Field(common.BKFieldID).Eq(1))
+
+	// the expression is not changed, no need to recalculate the instances
+	data := mapstr.MapStr{metadata.AttributeFieldOption: mapstr.MapStr{"expression": "cpu * 2"}}
+	changed, err := m.getChangedComputedAttrs(kit, data, cond)
+	require.NoError(t, err)
+	require.Empty(t, changed)
+
+	data = mapstr.MapStr{metadata.AttributeFieldOption: mapstr.MapStr{"expression": "cpu * 3"}}
+	changed, err = m.getChangedComputedAttrs(kit, data, cond)
+	require.NoError(t, err)
+	require.Len(t, changed, 1)
+	require.Equal(t, data[metadata.AttributeFieldOption], changed[0].Option)
+
+	changed, err = m.getChangedComputedAttrs(kit, mapstr.MapStr{metadata.AttributeFieldPropertyName: "a"}, cond)
+	require.NoError(t, err)
+	require.Empty(t, changed)
+}
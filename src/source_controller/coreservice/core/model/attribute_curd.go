@@ -142,7 +142,8 @@ func (m *modelAttribute) save(kit *rest.Kit, attribute metadata.Attribute) (id u
 		switch attribute.PropertyType {
 		case common.FieldTypeSingleChar, common.FieldTypeLongChar, common.FieldTypeInt, common.FieldTypeFloat,
 			common.FieldTypeEnum, common.FieldTypeDate, common.FieldTypeTime, common.FieldTypeTimeZone,
-			common.FieldTypeBool, common.FieldTypeList, common.FieldTypeIDRule:
+			common.FieldTypeBool, common.FieldTypeList, common.FieldTypeIDRule, common.FieldTypeIPAddr,
+			common.FieldTypeCIDR, common.FieldTypeURL, common.FieldTypeJSON, common.FieldTypeComputed:
 			isMultiple := false
 			attribute.IsMultiple = &isMultiple
 		case common.FieldTypeUser, common.FieldTypeOrganization, common.FieldTypeEnumQuote, common.FieldTypeEnumMulti:
@@ -158,6 +159,12 @@ func (m *modelAttribute) save(kit *rest.Kit, attribute metadata.Attribute) (id u
 
 		attribute.Default = nil
 	}
+	// 计算字段的值由服务端根据表达式计算得出，不允许用户编辑，也没有默认值
+	if attribute.PropertyType == common.FieldTypeComputed {
+		attribute.IsEditable = false
+		attribute.IsRequired = false
+		attribute.Default = nil
+	}
 	if err = m.saveCheck(kit, attribute); err != nil {
 		return 0, err
 	}
@@ -165,6 +172,12 @@ func (m *modelAttribute) save(kit *rest.Kit, attribute metadata.Attribute) (id u
 	if err = mongodb.Client().Table(common.BKTableNameObjAttDes).Insert(kit.Ctx, attribute); err != nil {
 		return 0, err
 	}
+	// calculate the values of the new computed attribute for the existing instances
+	if attribute.PropertyType == common.FieldTypeComputed {
+		if err = m.fillComputedAttrInInstances(kit, attribute); err != nil {
+			return 0, err
+		}
+	}
 	if attribute.PropertyType == common.FieldTypeIDRule {
 		idx := types.Index{
 			Name:       common.CCLogicIndexNamePrefix + attribute.PropertyID,
@@ -503,6 +516,11 @@ var validAttrPropertyTypes = map[string]struct{}{
 	common.FieldTypeList:         {},
 	common.FieldTypeEnumQuote:    {},
 	common.FieldTypeIDRule:       {},
+	common.FieldTypeIPAddr:       {},
+	common.FieldTypeCIDR:         {},
+	common.FieldTypeURL:          {},
+	common.FieldTypeJSON:         {},
+	common.FieldTypeComputed:     {},
 }
 
 func (m *modelAttribute) checkAttributeValidity(kit *rest.Kit, attribute metadata.Attribute,
@@ -548,6 +566,10 @@ func (m *modelAttribute) checkAttributeValidity(kit *rest.Kit, attribute metadat
 		return err
 	}
 
+	if err := attrvalid.ValidPropertyValidators(kit, attribute.Validators); err != nil {
+		return err
+	}
+
 	if opt, ok := attribute.Option.(string); ok && opt != "" {
 		if common.AttributeOptionMaxLength < utf8.RuneCountInString(opt) {
 			return kit.CCError.Errorf(common.CCErrCommValExceedMaxFailed, lang.Language("model_attr_option_regex"),
@@ -673,6 +695,15 @@ func (m *modelAttribute) checkAttributeDefaultValue(kit *rest.Kit, attribute met
 	case common.FieldTypeList:
 		err = attrvalid.ValidFieldTypeList(kit, attribute.Option, attribute.Default)
 
+	case common.FieldTypeIPAddr, common.FieldTypeCIDR:
+		err = attrvalid.ValidFieldTypeIPOption(kit, propertyType, attribute.Option, attribute.Default)
+
+	case common.FieldTypeURL:
+		err = attrvalid.ValidFieldTypeURLOption(kit, attribute.Option, attribute.Default)
+
+	case common.FieldTypeJSON:
+		err = attrvalid.ValidFieldTypeJSONOption(kit, attribute.Option, attribute.Default)
+
 	default:
 		if propertyType == common.FieldTypeEnum || propertyType == common.FieldTypeEnumMulti ||
 			propertyType == common.FieldTypeEnumQuote {
@@ -742,12 +773,25 @@ func (m *modelAttribute) update(kit *rest.Kit, data mapstr.MapStr, cond universa
 		blog.ErrorJSON("checkUpdate error. data:%s, cond:%s, rid:%s", data, cond, kit.Rid)
 		return cnt, err
 	}
+
+	changedComputedAttrs, err := m.getChangedComputedAttrs(kit, data, cond)
+	if err != nil {
+		return 0, err
+	}
+
 	cnt, err = mongodb.Client().Table(common.BKTableNameObjAttDes).UpdateMany(kit.Ctx, cond.ToMapStr(), data)
 	if nil != err {
 		blog.Errorf("request(%s): database operation is failed, error info is %s", kit.Rid, err.Error())
 		return 0, err
 	}
 
+	// recalculate the computed attribute values of the existing instances with the changed expressions
+	for _, attr := range changedComputedAttrs {
+		if err = m.fillComputedAttrInInstances(kit, attr); err != nil {
+			return 0, err
+		}
+	}
+
 	return cnt, err
 }
 
@@ -822,6 +866,10 @@ func (m *modelAttribute) delete(kit *rest.Kit, cond universalsql.Condition, isFr
 		objIDArrMap[attr.ObjectID] = append(objIDArrMap[attr.ObjectID], attr.ID)
 	}
 
+	if err := m.checkAttrReferencedByComputed(kit, resultAttrs); err != nil {
+		return 0, err
+	}
+
 	if err := m.cleanAttributeFieldInInstances(kit, resultAttrs); err != nil {
 		blog.Errorf("delete object attributes with cond: %v, but delete these attribute in instance failed, "+
 			"err: %v, rid: %s", condMap, err, kit.Rid)
@@ -863,6 +911,58 @@ func (m *modelAttribute) delete(kit *rest.Kit, cond universalsql.Condition, isFr
 	return cnt, err
 }
 
+// checkAttrReferencedByComputed check if the attributes to be deleted are referenced by other computed attributes
+func (m *modelAttribute) checkAttrReferencedByComputed(kit *rest.Kit, attrs []metadata.Attribute) error {
+	delAttrMap := make(map[string]map[string]struct{})
+	for _, attr := range attrs {
+		if _, exists := delAttrMap[attr.ObjectID]; !exists {
+			delAttrMap[attr.ObjectID] = make(map[string]struct{})
+		}
+		delAttrMap[attr.ObjectID][attr.PropertyID] = struct{}{}
+	}
+
+	objIDs := make([]string, 0, len(delAttrMap))
+	for objID := range delAttrMap {
+		objIDs = append(objIDs, objID)
+	}
+
+	cond := mapstr.MapStr{
+		common.BKObjIDField:        mapstr.MapStr{common.BKDBIN: objIDs},
+		common.BKPropertyTypeField: common.FieldTypeComputed,
+	}
+	util.SetQueryOwner(cond, kit.SupplierAccount)
+
+	computedAttrs := make([]metadata.Attribute, 0)
+	err := mongodb.Client().Table(common.BKTableNameObjAttDes).Find(cond).Fields(common.BKObjIDField,
+		common.BKPropertyIDField, metadata.AttributeFieldOption).All(kit.Ctx, &computedAttrs)
+	if err != nil {
+		blog.Errorf("get computed attributes failed, cond: %+v, err: %v, rid: %s", cond, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	for _, computed := range computedAttrs {
+		delAttrs := delAttrMap[computed.ObjectID]
+		if _, exists := delAttrs[computed.PropertyID]; exists {
+			continue
+		}
+
+		expr, err := metadata.ParseComputedOption(computed.Option)
+		if err != nil {
+			blog.Errorf("parse computed attribute %s option failed, err: %v, rid: %s", computed.PropertyID, err,
+				kit.Rid)
+			continue
+		}
+
+		for _, field := range expr.Fields() {
+			if _, exists := delAttrs[field]; exists {
+				return kit.CCError.CCErrorf(common.CCErrCoreServiceAttrReferencedByComputed, field,
+					computed.PropertyID)
+			}
+		}
+	}
+	return nil
+}
+
 type bizObjectFields struct {
 	bizID  int64
 	fields []string
@@ -1224,29 +1324,29 @@ func (m *modelAttribute) saveCheck(kit *rest.Kit, attr metadata.Attribute) error
 		return err
 	}
 
-	if attr.PropertyType != common.FieldTypeIDRule {
+	switch attr.PropertyType {
+	case common.FieldTypeIPAddr, common.FieldTypeCIDR, common.FieldTypeURL, common.FieldTypeJSON:
+		return attrvalid.ValidPropertyOption(kit, attr.PropertyType, attr.Option, attr.Default)
+	case common.FieldTypeIDRule, common.FieldTypeComputed:
+	default:
 		return nil
 	}
 
-	dbAttrs := make([]metadata.Attribute, 0)
-	cond := mapstr.MapStr{common.BKObjIDField: attr.ObjectID}
-	util.SetQueryOwner(cond, kit.SupplierAccount)
-	if err := mongodb.Client().Table(common.BKTableNameObjAttDes).Find(cond).All(kit.Ctx, &dbAttrs); err != nil {
-		blog.Errorf("get %s attributes failed, err: %v, rid: %s", attr.ObjectID, err, kit.Rid)
+	attrTypeMap, err := getObjAttrTypeMap(kit, attr.ObjectID)
+	if err != nil {
 		return err
 	}
 
-	attrTypeMap := make(map[string]string)
-	for _, dbAttr := range dbAttrs {
-		attrTypeMap[dbAttr.PropertyID] = dbAttr.PropertyType
-	}
-
-	err := attrvalid.ValidPropertyOption(kit, attr.PropertyType, attr.Option, attrTypeMap)
+	err = attrvalid.ValidPropertyOption(kit, attr.PropertyType, attr.Option, attrTypeMap)
 	if err != nil {
 		blog.ErrorJSON("valid property option failed, err: %s, data: %s, rid: %s", err, attr, kit.Ctx)
 		return err
 	}
 
+	if attr.PropertyType == common.FieldTypeComputed {
+		return nil
+	}
+
 	if err = checkAddIDRule(kit, attr.ObjectID); err != nil {
 		blog.ErrorJSON("check add asset id, err: %s, data: %s, rid: %s", err, attr, kit.Ctx)
 		return err
@@ -1490,6 +1590,25 @@ func removeIrrelevantValues(data mapstr.MapStr) {
 	data.Remove(common.BKObjIDField)
 }
 
+// getObjAttrTypeMap get the property id to property type map of the object attributes
+func getObjAttrTypeMap(kit *rest.Kit, objID string) (map[string]string, error) {
+	dbAttrs := make([]metadata.Attribute, 0)
+	cond := mapstr.MapStr{common.BKObjIDField: objID}
+	util.SetQueryOwner(cond, kit.SupplierAccount)
+	err := mongodb.Client().Table(common.BKTableNameObjAttDes).Find(cond).
+		Fields(common.BKPropertyIDField, common.BKPropertyTypeField).All(kit.Ctx, &dbAttrs)
+	if err != nil {
+		blog.Errorf("get %s attributes failed, err: %v, rid: %s", objID, err, kit.Rid)
+		return nil, err
+	}
+
+	attrTypeMap := make(map[string]string)
+	for _, dbAttr := range dbAttrs {
+		attrTypeMap[dbAttr.PropertyID] = dbAttr.PropertyType
+	}
+	return attrTypeMap, nil
+}
+
 func checkAttrOption(kit *rest.Kit, data mapstr.MapStr, dbAttributeArr []metadata.Attribute) error {
 	option, exists := data.Get(metadata.AttributeFieldOption)
 	if !exists {
@@ -1518,19 +1637,11 @@ func checkAttrOption(kit *rest.Kit, data mapstr.MapStr, dbAttributeArr []metadat
 	switch propertyType {
 	case common.FieldTypeEnum, common.FieldTypeEnumMulti:
 		extraOpt = isMultiple
-	case common.FieldTypeIDRule:
-		dbAttrs := make([]metadata.Attribute, 0)
-		cond := mapstr.MapStr{common.BKObjIDField: dbAttributeArr[0].ObjectID}
-		util.SetQueryOwner(cond, kit.SupplierAccount)
-		if err := mongodb.Client().Table(common.BKTableNameObjAttDes).Find(cond).All(kit.Ctx, &dbAttrs); err != nil {
-			blog.Errorf("get %s attributes failed, err: %v, rid: %s", dbAttributeArr[0].ObjectID, err, kit.Rid)
+	case common.FieldTypeIDRule, common.FieldTypeComputed:
+		attrTypeMap, err := getObjAttrTypeMap(kit, dbAttributeArr[0].ObjectID)
+		if err != nil {
 			return err
 		}
-
-		attrTypeMap := make(map[string]string)
-		for _, dbAttr := range dbAttrs {
-			attrTypeMap[dbAttr.PropertyID] = dbAttr.PropertyType
-		}
 		extraOpt = attrTypeMap
 	default:
 		extraOpt = data[common.BKDefaultFiled]
@@ -1606,12 +1717,12 @@ func (m *modelAttribute) checkUpdate(kit *rest.Kit, data mapstr.MapStr, cond uni
 		}
 	}
 
-	// 预定义字段，只能更新分组、分组内排序、单位、提示语和option
+	// 预定义字段，只能更新分组、分组内排序、单位、提示语、option和校验器
 	if hasIsPreProperty {
 		_ = data.ForEach(func(key string, val interface{}) error {
 			if key != metadata.AttributeFieldPropertyGroup && key != metadata.AttributeFieldPropertyIndex &&
 				key != metadata.AttributeFieldUnit && key != metadata.AttributeFieldPlaceHolder &&
-				key != metadata.AttributeFieldOption {
+				key != metadata.AttributeFieldOption && key != metadata.AttributeFieldValidators {
 				data.Remove(key)
 			}
 			return nil
@@ -1627,6 +1738,13 @@ func (m *modelAttribute) checkUpdate(kit *rest.Kit, data mapstr.MapStr, cond uni
 	}
 
 	propertyType := dbAttributeArr[0].PropertyType
+	// 计算字段不允许编辑，也没有默认值，忽略这些字段的更新
+	if propertyType == common.FieldTypeComputed {
+		data.Remove(metadata.AttributeFieldIsEditable)
+		data.Remove(metadata.AttributeFieldIsRequired)
+		data.Remove(metadata.AttributeFieldDefault)
+	}
+
 	// 对于枚举，枚举多选，枚举引用字段, 默认值是放在option中的，需要将default置为nil
 	if data[metadata.AttributeFieldDefault] != nil && (propertyType == common.FieldTypeEnum ||
 		propertyType == common.FieldTypeEnumMulti || propertyType == common.FieldTypeEnumQuote) {
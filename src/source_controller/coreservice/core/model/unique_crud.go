@@ -480,6 +480,8 @@ func getBasicDataType(propertyType string) (interface{}, error) {
 		return nil, nil
 	case common.FieldTypeOrganization:
 		return nil, nil
+	case common.FieldTypeIPAddr, common.FieldTypeCIDR, common.FieldTypeURL:
+		return "", nil
 	default:
 		return nil, fmt.Errorf("unsupported type: %s", propertyType)
 	}
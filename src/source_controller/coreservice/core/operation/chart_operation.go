@@ -117,6 +117,12 @@ func (m *operationManager) DeleteOperationChart(kit *rest.Kit, id int64) (interf
 		return nil, kit.CCError.CCError(common.CCErrOperationDeleteChartFail)
 	}
 
+	// remove the saved data of the statistic query chart
+	if err := mongodb.Client().Table(common.BKTableNameChartData).Delete(kit.Ctx, opt); err != nil {
+		blog.Errorf("DeleteOperationChart, delete chart data fail, err: %v, rid: %v", err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrOperationDeleteChartFail)
+	}
+
 	return nil, nil
 }
 
@@ -129,5 +135,14 @@ func (m *operationManager) UpdateOperationChart(kit *rest.Kit, inputParam map[st
 		return nil, kit.CCError.CCError(common.CCErrOperationUpdateChartFail)
 	}
 
+	// the saved data of the statistic query chart is out of date after its query is changed, it is calculated
+	// again when the chart is searched next time
+	if _, exists := inputParam[statisticQueryField]; exists {
+		if err := mongodb.Client().Table(common.BKTableNameChartData).Delete(kit.Ctx, opt); err != nil {
+			blog.Errorf("UpdateOperationChart, delete chart data fail, err: %v, rid: %v", err, kit.Rid)
+			return nil, kit.CCError.CCError(common.CCErrOperationUpdateChartFail)
+		}
+	}
+
 	return nil, nil
 }
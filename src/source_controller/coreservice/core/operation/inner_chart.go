@@ -40,7 +40,7 @@ const (
 // TimerFreshData TODO
 func (m *operationManager) TimerFreshData(kit *rest.Kit) error {
 	wg := &sync.WaitGroup{}
-	wg.Add(3)
+	wg.Add(4)
 	go func(wg *sync.WaitGroup) {
 		if err := m.ModelInstCount(kit, wg); err != nil {
 			blog.Errorf("TimerFreshData, count model's instance, search model info fail ,err: %v, rid: %v", err)
@@ -62,6 +62,13 @@ func (m *operationManager) TimerFreshData(kit *rest.Kit) error {
 		}
 	}(wg)
 
+	go func(wg *sync.WaitGroup) {
+		if err := m.StatisticQueryChartData(kit, wg); err != nil {
+			blog.Errorf("TimerFreshData fail, statistic query chart data fail, err: %v", err)
+			return
+		}
+	}(wg)
+
 	wg.Wait()
 	return nil
 }
@@ -130,6 +130,14 @@ func (m *operationManager) SearchChartData(kit *rest.Kit, inputParam metadata.Ch
 			return nil, err
 		}
 		return data, nil
+	case common.StatisticQueryChart:
+		data, err := m.searchStatisticChartData(kit, inputParam)
+		if err != nil {
+			blog.Errorf("search statistic chart data fail, config id: %d, err: %v, rid: %v", inputParam.ConfigID, err,
+				kit.Rid)
+			return nil, err
+		}
+		return data, nil
 	default:
 		data, err := m.CommonModelStatistic(kit, inputParam)
 		if err != nil {
@@ -299,6 +307,8 @@ func (m *operationManager) SearchTimerChartData(kit *rest.Kit, inputParam metada
 			return nil, err
 		}
 		return chartData.Data, nil
+	case common.StatisticQueryChart:
+		return m.searchStatisticChartData(kit, inputParam)
 	}
 
 	return nil, nil
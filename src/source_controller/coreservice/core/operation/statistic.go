@@ -0,0 +1,308 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package operation
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/driver/mongodb"
+)
+
+// statisticTimeKey is the group id key of the time bucket, dimension keys are d0, d1... because
+// the group id sub field name can not contain dot
+const statisticTimeKey = "time"
+
+// statisticQueryField is the statistic query field of the chart config
+const statisticQueryField = "statistic_query"
+
+// StatisticQuery count the instances or audit logs of an object grouped by the dimensions and time bucket
+func (m *operationManager) StatisticQuery(kit *rest.Kit, opt *metadata.StatisticQueryOption) (
+	*metadata.StatisticResult, error) {
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		return nil, rawErr.ToCCError(kit.CCError)
+	}
+
+	if err := m.validateStatisticFields(kit, opt); err != nil {
+		return nil, err
+	}
+
+	tableName, timeField, cond, err := m.getStatisticSource(kit, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	groupID := M{}
+	for idx, dimension := range opt.Dimensions {
+		groupID[fmt.Sprintf("d%d", idx)] = "$" + dimension
+	}
+
+	if opt.TimeBucket != nil {
+		loc, err := opt.TimeBucket.Location()
+		if err != nil {
+			return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, err.Error())
+		}
+
+		start, end, err := opt.TimeBucket.TimeRange(time.Now())
+		if err != nil {
+			return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, err.Error())
+		}
+
+		timeCond := M{}
+		if start != nil {
+			timeCond[common.BKDBGTE] = *start
+		}
+		if end != nil {
+			timeCond[common.BKDBLT] = *end
+		}
+		if len(timeCond) > 0 {
+			cond = append(cond, M{timeField: timeCond})
+		}
+
+		groupID[statisticTimeKey] = M{"$dateToString": M{
+			"format":   opt.TimeBucket.Unit.DateFormat(),
+			"date":     "$" + timeField,
+			"timezone": loc.String(),
+		}}
+	}
+
+	var metric interface{} = M{common.BKDBSum: 1}
+	if opt.Metric.Type != metadata.StatisticMetricCount {
+		metric = M{"$" + string(opt.Metric.Type): "$" + opt.Metric.Field}
+	}
+
+	var id interface{}
+	if len(groupID) > 0 {
+		id = groupID
+	}
+
+	match := M{}
+	if len(cond) > 0 {
+		match[common.BKDBAND] = cond
+	}
+
+	// one more group is queried to know if the groups exceeding the limit are cut off
+	pipeline := []M{
+		{common.BKDBMatch: match},
+		{common.BKDBGroup: M{"_id": id, "value": metric}},
+		{common.BKDBSort: M{"_id": 1}},
+		{common.BKDBLimit: metadata.StatisticMaxGroups + 1},
+	}
+
+	groups := make([]statisticGroup, 0)
+	if err := mongodb.Client().Table(tableName).AggregateAll(kit.Ctx, pipeline, &groups); err != nil {
+		blog.Errorf("statistic query aggregate failed, table: %s, pipeline: %#v, err: %v, rid: %s", tableName,
+			pipeline, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	result := &metadata.StatisticResult{Info: make([]metadata.StatisticResultItem, 0, len(groups))}
+	if len(groups) > metadata.StatisticMaxGroups {
+		groups = groups[:metadata.StatisticMaxGroups]
+		result.Truncated = true
+	}
+
+	for _, group := range groups {
+		item := metadata.StatisticResultItem{Dimensions: make(map[string]interface{}, len(opt.Dimensions))}
+		for idx, dimension := range opt.Dimensions {
+			item.Dimensions[dimension] = group.ID[fmt.Sprintf("d%d", idx)]
+		}
+		item.Time = util.GetStrByInterface(group.ID[statisticTimeKey])
+
+		// max and min metric of a non-numeric field can not be converted, which is left as zero
+		item.Value, _ = util.GetFloat64ByInterface(group.Value)
+		result.Info = append(result.Info, item)
+	}
+
+	return result, nil
+}
+
+// validateStatisticFields checks the dimensions and the metric field against the attributes of the model for the
+// instance source, or the audit log fields for the audit log source
+func (m *operationManager) validateStatisticFields(kit *rest.Kit, opt *metadata.StatisticQueryOption) error {
+	var attrTypes map[string]string
+	if opt.Source == metadata.StatisticSourceInstance {
+		cond := util.SetQueryOwner(M{common.BKObjIDField: opt.ObjID}, kit.SupplierAccount)
+		attrs := make([]metadata.Attribute, 0)
+		err := mongodb.Client().Table(common.BKTableNameObjAttDes).Find(cond).
+			Fields(common.BKPropertyIDField, common.BKPropertyTypeField).All(kit.Ctx, &attrs)
+		if err != nil {
+			blog.Errorf("get attributes of object %s failed, err: %v, rid: %s", opt.ObjID, err, kit.Rid)
+			return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+		}
+
+		attrTypes = make(map[string]string, len(attrs))
+		for _, attr := range attrs {
+			attrTypes[attr.PropertyID] = attr.PropertyType
+		}
+	}
+
+	if rawErr := opt.ValidateFields(attrTypes); rawErr.ErrCode != 0 {
+		return rawErr.ToCCError(kit.CCError)
+	}
+	return nil
+}
+
+type statisticGroup struct {
+	ID    map[string]interface{} `bson:"_id"`
+	Value interface{}            `bson:"value"`
+}
+
+// getStatisticSource returns the table, the time field and the match conditions of the statistic query source
+func (m *operationManager) getStatisticSource(kit *rest.Kit, opt *metadata.StatisticQueryOption) (string, string,
+	[]M, error) {
+
+	cond := make([]M, 0)
+	if opt.Filter != nil {
+		filterCond, err := opt.Filter.ToMgo()
+		if err != nil {
+			return "", "", nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, err.Error())
+		}
+		cond = append(cond, filterCond)
+	}
+
+	if opt.Source == metadata.StatisticSourceInstance {
+		if metadata.IsCommon(opt.ObjID) {
+			cond = append(cond, M{common.BKObjIDField: opt.ObjID})
+		}
+		return common.GetInstTableName(opt.ObjID, kit.SupplierAccount), common.CreateTimeField, cond, nil
+	}
+
+	resourceTypes := map[string]metadata.ResourceType{
+		common.BKInnerObjIDApp:     metadata.BusinessRes,
+		common.BKInnerObjIDBizSet:  metadata.BizSetRes,
+		common.BKInnerObjIDProject: metadata.ProjectRes,
+		common.BKInnerObjIDSet:     metadata.SetRes,
+		common.BKInnerObjIDModule:  metadata.ModuleRes,
+		common.BKInnerObjIDHost:    metadata.HostRes,
+		common.BKInnerObjIDProc:    metadata.ProcessRes,
+		common.BKInnerObjIDPlat:    metadata.CloudAreaRes,
+	}
+
+	// audit logs of all the supplier accounts are in the same table
+	cond = append(cond, M(util.SetQueryOwner(make(M), kit.SupplierAccount)))
+
+	if resourceType, exists := resourceTypes[opt.ObjID]; exists {
+		cond = append(cond, M{common.BKResourceTypeField: resourceType})
+		return common.BKTableNameAuditLog, common.BKOperationTimeField, cond, nil
+	}
+
+	cond = append(cond, M{
+		common.BKResourceTypeField: M{common.BKDBIN: []metadata.ResourceType{metadata.ModelInstanceRes,
+			metadata.MainlineInstanceRes}},
+		AuditLogInstanceOpDetailModelIDField: opt.ObjID,
+	})
+	return common.BKTableNameAuditLog, common.BKOperationTimeField, cond, nil
+}
+
+// StatisticQueryChartData refresh the data of all the statistic query charts
+func (m *operationManager) StatisticQueryChartData(kit *rest.Kit, wg *sync.WaitGroup) error {
+	defer wg.Done()
+
+	cond := M{common.OperationReportType: common.StatisticQueryChart}
+	charts := make([]metadata.ChartConfig, 0)
+	if err := mongodb.Client().Table(common.BKTableNameChartConfig).Find(cond).All(kit.Ctx, &charts); err != nil {
+		blog.Errorf("search statistic query charts failed, err: %v, rid: %s", err, kit.Rid)
+		return err
+	}
+
+	for _, chart := range charts {
+		if chart.StatisticQuery == nil {
+			continue
+		}
+
+		// one broken chart should not stop the other charts from refreshing
+		if _, err := m.updateStatisticChartData(kit, chart); err != nil {
+			blog.Errorf("refresh statistic query chart %d data failed, err: %v, rid: %s", chart.ConfigID, err,
+				kit.Rid)
+			continue
+		}
+	}
+
+	return nil
+}
+
+// updateStatisticChartData calculates and saves the data of the statistic query chart
+func (m *operationManager) updateStatisticChartData(kit *rest.Kit, chart metadata.ChartConfig) (
+	[]metadata.StatisticResultItem, error) {
+
+	result, err := m.StatisticQuery(kit, chart.StatisticQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Truncated {
+		blog.Warnf("statistic chart %d data exceeds %d groups and is truncated, rid: %s", chart.ConfigID,
+			metadata.StatisticMaxGroups, kit.Rid)
+	}
+
+	chartData := metadata.ChartData{
+		ReportType: common.StatisticQueryChart,
+		ConfigID:   chart.ConfigID,
+		Data:       result.Info,
+		OwnerID:    kit.SupplierAccount,
+		LastTime:   time.Now(),
+	}
+
+	cond := M{common.OperationReportType: common.StatisticQueryChart, common.OperationConfigID: chart.ConfigID}
+	if err := mongodb.Client().Table(common.BKTableNameChartData).Delete(kit.Ctx, cond); err != nil {
+		blog.Errorf("delete statistic chart %d data failed, err: %v, rid: %s", chart.ConfigID, err, kit.Rid)
+		return nil, err
+	}
+
+	if err := mongodb.Client().Table(common.BKTableNameChartData).Insert(kit.Ctx, chartData); err != nil {
+		blog.Errorf("insert statistic chart %d data failed, err: %v, rid: %s", chart.ConfigID, err, kit.Rid)
+		return nil, err
+	}
+
+	return result.Info, nil
+}
+
+// searchStatisticChartData returns the saved data of the statistic query chart, the data is calculated and
+// saved if the chart has not been refreshed yet
+func (m *operationManager) searchStatisticChartData(kit *rest.Kit, chart metadata.ChartConfig) (
+	[]metadata.StatisticResultItem, error) {
+
+	cond := M{common.OperationReportType: common.StatisticQueryChart, common.OperationConfigID: chart.ConfigID}
+	chartData := make([]statisticChartData, 0)
+	if err := mongodb.Client().Table(common.BKTableNameChartData).Find(cond).Limit(1).All(kit.Ctx,
+		&chartData); err != nil {
+		blog.Errorf("search statistic chart %d data failed, err: %v, rid: %s", chart.ConfigID, err, kit.Rid)
+		return nil, err
+	}
+
+	if len(chartData) > 0 {
+		return chartData[0].Data, nil
+	}
+
+	if chart.StatisticQuery == nil {
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, "statistic_query")
+	}
+
+	return m.updateStatisticChartData(kit, chart)
+}
+
+type statisticChartData struct {
+	Data []metadata.StatisticResultItem `bson:"data"`
+}
@@ -0,0 +1,121 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package operation
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal/mongo/memory"
+	"configcenter/src/storage/driver/mongodb"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newStatisticTestKit(supplierAccount string) *rest.Kit {
+	return &rest.Kit{
+		Rid:             "test-rid",
+		Ctx:             context.Background(),
+		CCError:         errors.NewFromCtx(errors.EmptyErrorsSetting).CreateDefaultCCErrorIf("en"),
+		User:            "admin",
+		SupplierAccount: supplierAccount,
+	}
+}
+
+func TestStatisticQueryAuditLogOwner(t *testing.T) {
+	db := memory.New()
+	mongodb.SetClient("", db)
+
+	now := time.Now()
+	logs := make([]interface{}, 0)
+	for _, owner := range []string{"0", "1", "1", "2"} {
+		logs = append(logs, mapstr.MapStr{
+			common.BKResourceTypeField:  metadata.HostRes,
+			common.BKOperationTimeField: now,
+			common.BkSupplierAccount:    owner,
+		})
+	}
+	require.NoError(t, db.Table(common.BKTableNameAuditLog).Insert(context.Background(), logs))
+
+	m := new(operationManager)
+	opt := &metadata.StatisticQueryOption{ObjID: common.BKInnerObjIDHost, Source: metadata.StatisticSourceAuditLog}
+	result, err := m.StatisticQuery(newStatisticTestKit("1"), opt)
+	require.NoError(t, err)
+	require.Len(t, result.Info, 1)
+	// the audit logs of the default supplier account are shared, the ones of the other accounts are excluded
+	require.Equal(t, float64(3), result.Info[0].Value)
+	require.False(t, result.Truncated)
+}
+
+func TestStatisticQueryInvalidField(t *testing.T) {
+	db := memory.New()
+	mongodb.SetClient("", db)
+
+	kit := newStatisticTestKit("0")
+	attr := metadata.Attribute{ObjectID: "switch", PropertyID: "port_num", PropertyType: common.FieldTypeInt,
+		OwnerID: kit.SupplierAccount}
+	require.NoError(t, db.Table(common.BKTableNameObjAttDes).Insert(kit.Ctx, attr))
+
+	m := new(operationManager)
+	opt := &metadata.StatisticQueryOption{ObjID: "switch", Dimensions: []string{"bk_vendor"}}
+	_, err := m.StatisticQuery(kit, opt)
+	require.Error(t, err)
+
+	opt = &metadata.StatisticQueryOption{ObjID: "switch",
+		Metric: metadata.StatisticMetric{Type: metadata.StatisticMetricSum, Field: "port_num"}}
+	_, err = m.StatisticQuery(kit, opt)
+	require.NoError(t, err)
+}
+
+func TestStatisticQueryTruncated(t *testing.T) {
+	db := memory.New()
+	mongodb.SetClient("", db)
+
+	kit := newStatisticTestKit("0")
+	insts := make([]interface{}, metadata.StatisticMaxGroups+1)
+	for idx := range insts {
+		insts[idx] = mapstr.MapStr{
+			common.BKObjIDField:    "switch",
+			common.BKInstNameField: fmt.Sprintf("switch-%04d", idx),
+		}
+	}
+	table := common.GetInstTableName("switch", kit.SupplierAccount)
+	require.NoError(t, db.Table(table).Insert(kit.Ctx, insts))
+	attr := metadata.Attribute{ObjectID: "switch", PropertyID: common.BKInstNameField,
+		PropertyType: common.FieldTypeSingleChar, OwnerID: kit.SupplierAccount}
+	require.NoError(t, db.Table(common.BKTableNameObjAttDes).Insert(kit.Ctx, attr))
+
+	m := new(operationManager)
+	opt := &metadata.StatisticQueryOption{ObjID: "switch", Dimensions: []string{common.BKInstNameField}}
+	result, err := m.StatisticQuery(kit, opt)
+	require.NoError(t, err)
+	require.Len(t, result.Info, metadata.StatisticMaxGroups)
+	require.True(t, result.Truncated)
+
+	require.NoError(t, db.Table(table).Delete(kit.Ctx, mapstr.MapStr{common.BKInstNameField: "switch-0000"}))
+	result, err = m.StatisticQuery(kit, opt)
+	require.NoError(t, err)
+	require.Len(t, result.Info, metadata.StatisticMaxGroups)
+	require.False(t, result.Truncated)
+}
@@ -139,10 +139,26 @@ func (s *coreService) DeleteDestroyedHostRelated(ctx *rest.Contexts) {
 		return
 	}
 
-	err := s.core.CloudOperation().DeleteDestroyedHostRelated(ctx.Kit, &option)
+	related, err := s.core.CloudOperation().DeleteDestroyedHostRelated(ctx.Kit, &option)
 	if err != nil {
 		ctx.RespAutoError(err)
 		return
 	}
-	ctx.RespEntity(nil)
+	ctx.RespEntity(related)
+}
+
+// RestoreDestroyedHostRelated restore the related data that is deleted when the hosts are destroyed
+func (s *coreService) RestoreDestroyedHostRelated(ctx *rest.Contexts) {
+	related := metadata.DestroyedHostRelated{}
+	if err := ctx.DecodeInto(&related); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, err := s.core.CloudOperation().RestoreDestroyedHostRelated(ctx.Kit, &related)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
 }
@@ -18,12 +18,16 @@
 package idrule
 
 import (
+	"context"
+	"time"
+
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/http/rest"
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/util"
+	attrvalid "configcenter/src/common/valid/attribute"
 	"configcenter/src/source_controller/coreservice/core/instances"
 	"configcenter/src/storage/driver/mongodb"
 )
@@ -110,3 +114,116 @@ func (s *service) UpdateInstIDRule(ctx *rest.Contexts) {
 	}
 	ctx.RespEntity(nil)
 }
+
+// PreviewIDRule render the next ids of the id rule without using up the sequence. the ids may differ from the ids
+// that are actually generated if other instances are created in the meantime
+func (s *service) PreviewIDRule(ctx *rest.Contexts) {
+	opt := new(metadata.PreviewIDRuleOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	attr, attrTypeMap, err := s.getPreviewIDRuleAttr(ctx.Kit, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	nextSeq := s.newPreviewSeqGetter(ctx.Kit)
+
+	now := time.Now()
+	ids := make([]string, 0, opt.Count)
+	for i := 0; i < opt.Count; i++ {
+		val, err := instances.RenderIDRuleVal(ctx.Kit.Ctx, opt.Data, attr, attrTypeMap, now, nextSeq)
+		if err != nil {
+			blog.Errorf("render id rule val failed, attr: %+v, err: %v, rid: %s", attr, err, ctx.Kit.Rid)
+			ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, err.Error()))
+			return
+		}
+		ids = append(ids, val)
+	}
+
+	ctx.RespEntity(&metadata.PreviewIDRuleResult{IDs: ids})
+}
+
+// getPreviewIDRuleAttr get the id rule attribute to preview and the attribute type map of the model
+func (s *service) getPreviewIDRuleAttr(kit *rest.Kit, opt *metadata.PreviewIDRuleOption) (metadata.Attribute,
+	map[string]string, error) {
+
+	cond := mapstr.MapStr{common.BKObjIDField: opt.ObjID}
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+	allAttr := make([]metadata.Attribute, 0)
+	if err := mongodb.Client().Table(common.BKTableNameObjAttDes).Find(cond).All(kit.Ctx, &allAttr); err != nil {
+		blog.Errorf("find attribute failed, cond: %+v, err: %v, rid: %s", cond, err, kit.Rid)
+		return metadata.Attribute{}, nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	attr := metadata.Attribute{ObjectID: opt.ObjID, PropertyType: common.FieldTypeIDRule}
+	attrTypeMap := make(map[string]string)
+	for _, attribute := range allAttr {
+		if opt.Rule == "" && attribute.PropertyID == opt.PropertyID {
+			attr = attribute
+		}
+		attrTypeMap[attribute.PropertyID] = attribute.PropertyType
+	}
+
+	// preview the rule that is not saved yet
+	if opt.Rule != "" {
+		attr.Option = metadata.AsstIDOption{Rule: opt.Rule}
+		if err := attrvalid.ValidIDRuleOption(kit, attr.Option, attrTypeMap); err != nil {
+			return metadata.Attribute{}, nil, err
+		}
+		return attr, attrTypeMap, nil
+	}
+
+	if attr.PropertyID != opt.PropertyID || attr.PropertyType != common.FieldTypeIDRule {
+		blog.Errorf("%s id rule attribute %s not exists, rid: %s", opt.ObjID, opt.PropertyID, kit.Rid)
+		return metadata.Attribute{}, nil, kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid,
+			common.BKPropertyIDField)
+	}
+
+	return attr, attrTypeMap, nil
+}
+
+// newPreviewSeqGetter returns a sequence getter that reads the current sequence id from db and increases it in memory
+// by the same step that the db client uses to generate the sequence, which is loaded when the process starts.
+// the sequence name is redirected to the base table the same way as the db client does
+func (s *service) newPreviewSeqGetter(kit *rest.Kit) instances.IDRuleSequenceGetter {
+	step := uint64(1)
+	if dbStep := mongodb.Client().IDGeneratorStep(); dbStep > 0 {
+		step = uint64(dbStep)
+	}
+
+	sequences := make(map[string]uint64)
+	return func(ctx context.Context, seqName string) (uint64, error) {
+		seqName = common.RedirectShardingTable(seqName)
+		seq, exists := sequences[seqName]
+		if !exists {
+			doc := make(mapstr.MapStr)
+			cond := mapstr.MapStr{common.BKFieldDBID: seqName}
+			err := mongodb.Client().Table(common.BKTableNameIDgenerator).Find(cond).One(ctx, &doc)
+			if err != nil && !mongodb.Client().IsNotFoundError(err) {
+				blog.Errorf("get sequence failed, cond: %+v, err: %v, rid: %s", cond, err, kit.Rid)
+				return 0, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+			}
+
+			if doc[common.BKFieldSeqID] != nil {
+				current, err := util.GetInt64ByInterface(doc[common.BKFieldSeqID])
+				if err != nil {
+					blog.Errorf("parse sequence %+v failed, err: %v, rid: %s", doc, err, kit.Rid)
+					return 0, err
+				}
+				seq = uint64(current)
+			}
+		}
+
+		seq += step
+		sequences[seqName] = seq
+		return seq, nil
+	}
+}
@@ -0,0 +1,81 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package idrule
+
+import (
+	"context"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal/mongo/memory"
+	"configcenter/src/storage/driver/mongodb"
+
+	"github.com/stretchr/testify/require"
+)
+
+// stepDB is the in-memory db whose id generator step is loaded as 5
+type stepDB struct {
+	*memory.DB
+}
+
+// IDGeneratorStep returns the step loaded at startup
+func (d *stepDB) IDGeneratorStep() int {
+	return 5
+}
+
+func TestPreviewSeqGetter(t *testing.T) {
+	db := &stepDB{DB: memory.New()}
+	mongodb.SetClient("", db)
+
+	kit := &rest.Kit{
+		Rid:             "test-rid",
+		Ctx:             context.Background(),
+		CCError:         errors.NewFromCtx(errors.EmptyErrorsSetting).CreateDefaultCCErrorIf("en"),
+		SupplierAccount: "0",
+	}
+	doc := mapstr.MapStr{common.BKFieldDBID: metadata.GetIDRule(common.BKInnerObjIDHost), common.BKFieldSeqID: 10}
+	require.NoError(t, db.Table(common.BKTableNameIDgenerator).Insert(kit.Ctx, doc))
+
+	nextSeq := new(service).newPreviewSeqGetter(kit)
+	for _, expected := range []uint64{15, 20} {
+		seq, err := nextSeq(kit.Ctx, metadata.GetIDRule(common.BKInnerObjIDHost))
+		require.NoError(t, err)
+		require.Equal(t, expected, seq)
+	}
+
+	// the sequence that is not generated yet starts from 0
+	seq, err := nextSeq(kit.Ctx, metadata.GetIDRule(common.BKInnerObjIDSet))
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), seq)
+
+	// the sharding table shares the sequence of its base table, same as the db client
+	doc = mapstr.MapStr{common.BKFieldDBID: common.BKTableNameBaseInst, common.BKFieldSeqID: 30}
+	require.NoError(t, db.Table(common.BKTableNameIDgenerator).Insert(kit.Ctx, doc))
+	seq, err = nextSeq(kit.Ctx, common.GetObjectInstTableName("switch", kit.SupplierAccount))
+	require.NoError(t, err)
+	require.Equal(t, uint64(35), seq)
+
+	// preview does not use up the sequence
+	current := make(mapstr.MapStr)
+	cond := mapstr.MapStr{common.BKFieldDBID: metadata.GetIDRule(common.BKInnerObjIDHost)}
+	require.NoError(t, db.Table(common.BKTableNameIDgenerator).Find(cond).One(kit.Ctx, &current))
+	require.EqualValues(t, 10, current[common.BKFieldSeqID])
+}
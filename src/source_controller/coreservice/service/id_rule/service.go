@@ -36,4 +36,6 @@ func InitIDRule(c *capability.Capability) {
 
 	c.Utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/inst/id_rule",
 		Handler: s.UpdateInstIDRule})
+	c.Utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/id_rule/preview",
+		Handler: s.PreviewIDRule})
 }
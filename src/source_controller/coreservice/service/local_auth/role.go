@@ -0,0 +1,217 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package localauth
+
+import (
+	"strconv"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/driver/mongodb"
+)
+
+// CreateRole create local auth role
+func (s *service) CreateRole(ctx *rest.Contexts) {
+	opt := new(metadata.LocalAuthRoleOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	id, err := mongodb.Client().NextSequence(ctx.Kit.Ctx, common.BKTableNameLocalAuthRole)
+	if err != nil {
+		blog.Errorf("get sequence id on the table (%s) failed, err: %v, rid: %s", common.BKTableNameLocalAuthRole, err,
+			ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+		return
+	}
+
+	now := &metadata.Time{Time: time.Now()}
+	role := &metadata.LocalAuthRole{
+		ID:          int64(id),
+		Name:        opt.Name,
+		Description: opt.Description,
+		Permissions: opt.Permissions,
+		OwnerID:     ctx.Kit.SupplierAccount,
+		Creator:     ctx.Kit.User,
+		Modifier:    ctx.Kit.User,
+		CreateTime:  now,
+		LastTime:    now,
+	}
+
+	if err = mongodb.Client().Table(common.BKTableNameLocalAuthRole).Insert(ctx.Kit.Ctx, role); err != nil {
+		blog.Errorf("save local auth role failed, data: %+v, err: %v, rid: %s", role, err, ctx.Kit.Rid)
+		if mongodb.Client().IsDuplicatedError(err) {
+			ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommDuplicateItem, mongodb.GetDuplicateKey(err)))
+			return
+		}
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBInsertFailed))
+		return
+	}
+
+	ctx.RespEntity(role)
+}
+
+// UpdateRole update the name, description and permissions of the local auth role
+func (s *service) UpdateRole(ctx *rest.Contexts) {
+	id, err := parsePathID(ctx)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	opt := new(metadata.LocalAuthRoleOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	cond := util.SetModOwner(mapstr.MapStr{common.BKFieldID: id}, ctx.Kit.SupplierAccount)
+	count, err := mongodb.Client().Table(common.BKTableNameLocalAuthRole).Find(cond).Count(ctx.Kit.Ctx)
+	if err != nil {
+		blog.Errorf("count local auth role failed, cond: %+v, err: %v, rid: %s", cond, err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+		return
+	}
+
+	if count == 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommNotFound, "role"))
+		return
+	}
+
+	data := mapstr.MapStr{
+		common.BKFieldName:        opt.Name,
+		common.BKDescriptionField: opt.Description,
+		"permissions":             opt.Permissions,
+		common.ModifierField:      ctx.Kit.User,
+		common.LastTimeField:      time.Now(),
+	}
+	if err := mongodb.Client().Table(common.BKTableNameLocalAuthRole).Update(ctx.Kit.Ctx, cond, data); err != nil {
+		blog.Errorf("update local auth role failed, cond: %+v, data: %+v, err: %v, rid: %s", cond, data, err,
+			ctx.Kit.Rid)
+		if mongodb.Client().IsDuplicatedError(err) {
+			ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommDuplicateItem, mongodb.GetDuplicateKey(err)))
+			return
+		}
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBUpdateFailed))
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// DeleteRole delete local auth role, the role can not be deleted if it is still bound to users
+func (s *service) DeleteRole(ctx *rest.Contexts) {
+	id, err := parsePathID(ctx)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	bindingCond := util.SetQueryOwner(mapstr.MapStr{"role_id": id}, ctx.Kit.SupplierAccount)
+	count, err := mongodb.Client().Table(common.BKTableNameLocalAuthRoleBinding).Find(bindingCond).Count(ctx.Kit.Ctx)
+	if err != nil {
+		blog.Errorf("count local auth role binding failed, cond: %+v, err: %v, rid: %s", bindingCond, err,
+			ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+		return
+	}
+
+	if count > 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommLocalAuthRoleInUse, id, count))
+		return
+	}
+
+	cond := util.SetModOwner(mapstr.MapStr{common.BKFieldID: id}, ctx.Kit.SupplierAccount)
+	if err := mongodb.Client().Table(common.BKTableNameLocalAuthRole).Delete(ctx.Kit.Ctx, cond); err != nil {
+		blog.Errorf("delete local auth role failed, cond: %+v, err: %v, rid: %s", cond, err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBDeleteFailed))
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// ListRole list local auth roles
+func (s *service) ListRole(ctx *rest.Contexts) {
+	opt := new(metadata.ListLocalAuthRoleOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	cond := mapstr.MapStr{}
+	if len(opt.IDs) > 0 {
+		cond[common.BKFieldID] = mapstr.MapStr{common.BKDBIN: opt.IDs}
+	}
+	if opt.Name != "" {
+		cond[common.BKFieldName] = opt.Name
+	}
+	cond = util.SetQueryOwner(cond, ctx.Kit.SupplierAccount)
+
+	if opt.Page.EnableCount {
+		count, err := mongodb.Client().Table(common.BKTableNameLocalAuthRole).Find(cond).Count(ctx.Kit.Ctx)
+		if err != nil {
+			blog.Errorf("count local auth roles failed, cond: %+v, err: %v, rid: %s", cond, err, ctx.Kit.Rid)
+			ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+			return
+		}
+		ctx.RespEntity(metadata.ListLocalAuthRoleResult{Count: count})
+		return
+	}
+
+	roles := make([]metadata.LocalAuthRole, 0)
+	err := mongodb.Client().Table(common.BKTableNameLocalAuthRole).Find(cond).Start(uint64(opt.Page.Start)).
+		Limit(uint64(opt.Page.Limit)).Sort(opt.Page.Sort).All(ctx.Kit.Ctx, &roles)
+	if err != nil {
+		blog.Errorf("list local auth roles failed, cond: %+v, err: %v, rid: %s", cond, err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+		return
+	}
+
+	ctx.RespEntity(metadata.ListLocalAuthRoleResult{Info: roles})
+}
+
+func parsePathID(ctx *rest.Contexts) (int64, error) {
+	id, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKFieldID), 10, 64)
+	if err != nil || id <= 0 {
+		blog.Errorf("parse id %s failed, err: %v, rid: %s", ctx.Request.PathParameter(common.BKFieldID), err,
+			ctx.Kit.Rid)
+		return 0, ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKFieldID)
+	}
+	return id, nil
+}
@@ -0,0 +1,251 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package localauth
+
+import (
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/driver/mongodb"
+)
+
+// CreateRoleBinding grant the local auth role to users in the scope
+func (s *service) CreateRoleBinding(ctx *rest.Contexts) {
+	opt := new(metadata.CreateLocalAuthRoleBindingOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	roleCond := util.SetQueryOwner(mapstr.MapStr{common.BKFieldID: opt.RoleID}, ctx.Kit.SupplierAccount)
+	count, err := mongodb.Client().Table(common.BKTableNameLocalAuthRole).Find(roleCond).Count(ctx.Kit.Ctx)
+	if err != nil {
+		blog.Errorf("count local auth role failed, cond: %+v, err: %v, rid: %s", roleCond, err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+		return
+	}
+
+	if count == 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, "role_id"))
+		return
+	}
+
+	id, err := mongodb.Client().NextSequence(ctx.Kit.Ctx, common.BKTableNameLocalAuthRoleBinding)
+	if err != nil {
+		blog.Errorf("get sequence id on the table (%s) failed, err: %v, rid: %s",
+			common.BKTableNameLocalAuthRoleBinding, err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+		return
+	}
+
+	now := &metadata.Time{Time: time.Now()}
+	binding := &metadata.LocalAuthRoleBinding{
+		ID:         int64(id),
+		RoleID:     opt.RoleID,
+		Users:      util.StrArrayUnique(opt.Users),
+		Scope:      opt.Scope,
+		OwnerID:    ctx.Kit.SupplierAccount,
+		Creator:    ctx.Kit.User,
+		Modifier:   ctx.Kit.User,
+		CreateTime: now,
+		LastTime:   now,
+	}
+
+	if err = mongodb.Client().Table(common.BKTableNameLocalAuthRoleBinding).Insert(ctx.Kit.Ctx, binding); err != nil {
+		blog.Errorf("save local auth role binding failed, data: %+v, err: %v, rid: %s", binding, err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBInsertFailed))
+		return
+	}
+
+	ctx.RespEntity(binding)
+}
+
+// UpdateRoleBinding update the users of the local auth role binding
+func (s *service) UpdateRoleBinding(ctx *rest.Contexts) {
+	id, err := parsePathID(ctx)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	opt := new(metadata.UpdateLocalAuthRoleBindingOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	cond := util.SetModOwner(mapstr.MapStr{common.BKFieldID: id}, ctx.Kit.SupplierAccount)
+	count, err := mongodb.Client().Table(common.BKTableNameLocalAuthRoleBinding).Find(cond).Count(ctx.Kit.Ctx)
+	if err != nil {
+		blog.Errorf("count local auth role binding failed, cond: %+v, err: %v, rid: %s", cond, err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+		return
+	}
+
+	if count == 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommNotFound, "role binding"))
+		return
+	}
+
+	data := mapstr.MapStr{
+		"users":              util.StrArrayUnique(opt.Users),
+		common.ModifierField: ctx.Kit.User,
+		common.LastTimeField: time.Now(),
+	}
+	err = mongodb.Client().Table(common.BKTableNameLocalAuthRoleBinding).Update(ctx.Kit.Ctx, cond, data)
+	if err != nil {
+		blog.Errorf("update local auth role binding failed, cond: %+v, data: %+v, err: %v, rid: %s", cond, data, err,
+			ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBUpdateFailed))
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// DeleteRoleBinding delete local auth role binding
+func (s *service) DeleteRoleBinding(ctx *rest.Contexts) {
+	id, err := parsePathID(ctx)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	cond := util.SetModOwner(mapstr.MapStr{common.BKFieldID: id}, ctx.Kit.SupplierAccount)
+	if err := mongodb.Client().Table(common.BKTableNameLocalAuthRoleBinding).Delete(ctx.Kit.Ctx, cond); err != nil {
+		blog.Errorf("delete local auth role binding failed, cond: %+v, err: %v, rid: %s", cond, err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBDeleteFailed))
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// ListRoleBinding list local auth role bindings
+func (s *service) ListRoleBinding(ctx *rest.Contexts) {
+	opt := new(metadata.ListLocalAuthRoleBindingOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	cond := mapstr.MapStr{}
+	if len(opt.IDs) > 0 {
+		cond[common.BKFieldID] = mapstr.MapStr{common.BKDBIN: opt.IDs}
+	}
+	if opt.RoleID > 0 {
+		cond["role_id"] = opt.RoleID
+	}
+	if opt.User != "" {
+		cond["users"] = opt.User
+	}
+	if opt.Scope != nil {
+		cond["scope.type"] = opt.Scope.Type
+		cond["scope.id"] = opt.Scope.ID
+	}
+	cond = util.SetQueryOwner(cond, ctx.Kit.SupplierAccount)
+
+	if opt.Page.EnableCount {
+		count, err := mongodb.Client().Table(common.BKTableNameLocalAuthRoleBinding).Find(cond).Count(ctx.Kit.Ctx)
+		if err != nil {
+			blog.Errorf("count local auth role bindings failed, cond: %+v, err: %v, rid: %s", cond, err, ctx.Kit.Rid)
+			ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+			return
+		}
+		ctx.RespEntity(metadata.ListLocalAuthRoleBindingResult{Count: count})
+		return
+	}
+
+	bindings := make([]metadata.LocalAuthRoleBinding, 0)
+	err := mongodb.Client().Table(common.BKTableNameLocalAuthRoleBinding).Find(cond).
+		Start(uint64(opt.Page.Start)).Limit(uint64(opt.Page.Limit)).Sort(opt.Page.Sort).All(ctx.Kit.Ctx, &bindings)
+	if err != nil {
+		blog.Errorf("list local auth role bindings failed, cond: %+v, err: %v, rid: %s", cond, err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+		return
+	}
+
+	ctx.RespEntity(metadata.ListLocalAuthRoleBindingResult{Info: bindings})
+}
+
+// GetUserPolicy get all the role bindings of the user and the roles they refer to, it is used by the local authorizer
+func (s *service) GetUserPolicy(ctx *rest.Contexts) {
+	opt := new(metadata.GetLocalAuthUserPolicyOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if opt.User == "" {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, "user"))
+		return
+	}
+
+	bindingCond := mapstr.MapStr{"users": mapstr.MapStr{common.BKDBIN: []string{opt.User, metadata.LocalAuthAnyUser}}}
+	bindingCond = util.SetQueryOwner(bindingCond, ctx.Kit.SupplierAccount)
+	bindings := make([]metadata.LocalAuthRoleBinding, 0)
+	err := mongodb.Client().Table(common.BKTableNameLocalAuthRoleBinding).Find(bindingCond).All(ctx.Kit.Ctx,
+		&bindings)
+	if err != nil {
+		blog.Errorf("find local auth role bindings failed, cond: %+v, err: %v, rid: %s", bindingCond, err,
+			ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+		return
+	}
+
+	policy := &metadata.LocalAuthUserPolicy{Bindings: bindings, Roles: make([]metadata.LocalAuthRole, 0)}
+	if len(bindings) == 0 {
+		ctx.RespEntity(policy)
+		return
+	}
+
+	roleIDs := make([]int64, 0, len(bindings))
+	for _, binding := range bindings {
+		roleIDs = append(roleIDs, binding.RoleID)
+	}
+
+	roleCond := mapstr.MapStr{common.BKFieldID: mapstr.MapStr{common.BKDBIN: util.IntArrayUnique(roleIDs)}}
+	roleCond = util.SetQueryOwner(roleCond, ctx.Kit.SupplierAccount)
+	err = mongodb.Client().Table(common.BKTableNameLocalAuthRole).Find(roleCond).All(ctx.Kit.Ctx, &policy.Roles)
+	if err != nil {
+		blog.Errorf("find local auth roles failed, cond: %+v, err: %v, rid: %s", roleCond, err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+		return
+	}
+
+	ctx.RespEntity(policy)
+}
@@ -0,0 +1,60 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+// Package localauth defines the storage service of the built-in local authorizer policies
+package localauth
+
+import (
+	"net/http"
+
+	"configcenter/src/common/http/rest"
+	"configcenter/src/source_controller/coreservice/core"
+	"configcenter/src/source_controller/coreservice/service/capability"
+)
+
+type service struct {
+	core core.Core
+}
+
+// InitLocalAuth init local auth policy service
+func InitLocalAuth(c *capability.Capability) {
+	s := &service{
+		core: c.Core,
+	}
+
+	// local auth role
+	c.Utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/local_auth/role",
+		Handler: s.CreateRole})
+	c.Utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/local_auth/role/{id}",
+		Handler: s.UpdateRole})
+	c.Utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/local_auth/role/{id}",
+		Handler: s.DeleteRole})
+	c.Utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/local_auth/role",
+		Handler: s.ListRole})
+
+	// local auth role binding
+	c.Utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/local_auth/role_binding",
+		Handler: s.CreateRoleBinding})
+	c.Utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/local_auth/role_binding/{id}",
+		Handler: s.UpdateRoleBinding})
+	c.Utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/local_auth/role_binding/{id}",
+		Handler: s.DeleteRoleBinding})
+	c.Utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/local_auth/role_binding",
+		Handler: s.ListRoleBinding})
+
+	c.Utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/local_auth/user_policy",
+		Handler: s.GetUserPolicy})
+}
@@ -243,6 +243,25 @@ func (s *coreService) TimerFreshData(ctx *rest.Contexts) {
 	ctx.RespEntity(true)
 }
 
+// StatisticQuery count instances or audit logs of an object by the statistic query
+func (s *coreService) StatisticQuery(ctx *rest.Contexts) {
+	opt := new(metadata.StatisticQueryOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.SetReadPreference(common.SecondaryPreferredMode)
+	result, err := s.core.StatisticOperation().StatisticQuery(ctx.Kit, opt)
+	if err != nil {
+		blog.Errorf("statistic query failed, option: %#v, err: %v, rid: %s", opt, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
 // SearchCloudMapping TODO
 func (s *coreService) SearchCloudMapping(ctx *rest.Contexts) {
 	opt := make(map[string]interface{})
@@ -24,6 +24,7 @@ import (
 	fieldtmpl "configcenter/src/source_controller/coreservice/service/field_template"
 	"configcenter/src/source_controller/coreservice/service/id_rule"
 	"configcenter/src/source_controller/coreservice/service/kube"
+	localauth "configcenter/src/source_controller/coreservice/service/local_auth"
 	modelquote "configcenter/src/source_controller/coreservice/service/model_quote"
 
 	"github.com/emicklei/go-restful/v3"
@@ -382,6 +383,8 @@ func (s *coreService) initOperation(web *restful.WebService) {
 		Handler: s.SearchTimerChartData})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/start/operation/chart/timer",
 		Handler: s.TimerFreshData})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/operation/statistic",
+		Handler: s.StatisticQuery})
 
 	utility.AddToRestfulWebService(web)
 }
@@ -485,6 +488,8 @@ func (s *coreService) initCloudSync(web *restful.WebService) {
 		Handler: s.SearchSyncHistory})
 	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/cloud/sync/destroyed_host_related",
 		Handler: s.DeleteDestroyedHostRelated})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/cloud/sync/destroyed_host_related",
+		Handler: s.RestoreDestroyedHostRelated})
 
 	utility.AddToRestfulWebService(web)
 }
@@ -590,6 +595,7 @@ func (s *coreService) initService(web *restful.WebService) {
 	s.initModelQuote(web)
 	fieldtmpl.InitFieldTemplate(c)
 	idrule.InitIDRule(c)
+	localauth.InitLocalAuth(c)
 
 	c.Utility.AddToRestfulWebService(web)
 }
@@ -17,10 +17,12 @@
 package options
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"sort"
 
+	"configcenter/pkg/filter"
 	"configcenter/pkg/synchronize/types"
 	"configcenter/src/storage/dal/mongo"
 	"configcenter/src/storage/dal/redis"
@@ -47,8 +49,14 @@ type SyncConfig struct {
 	Role SyncRole `mapstructure:"role"`
 	// SyncIntervalHours is the full sync interval, unit: hour
 	SyncIntervalHours int `mapstructure:"syncIntervalHours"`
-	// TransMediumAddr is the transfer medium addresses
+	// TransMediumType is the transfer medium type, default is http
+	TransMediumType TransMediumType `mapstructure:"transferMediumType"`
+	// TransMediumAddr is the transfer medium addresses, used by http transfer medium
 	TransMediumAddr []string `mapstructure:"transferMediumAddress"`
+	// Bundle is the offline file bundle transfer medium config, used by bundle transfer medium
+	Bundle *BundleMediumConfig `mapstructure:"bundle"`
+	// Rules are the resource sync rules that filter and mask the synchronized data, used by source cmdb
+	Rules []SyncRuleConf `mapstructure:"rules"`
 }
 
 // Validate SyncConfig
@@ -71,13 +79,165 @@ func (s *SyncConfig) Validate() error {
 		return fmt.Errorf("invalid sync role: %s", s.Role)
 	}
 
-	if len(s.TransMediumAddr) == 0 {
-		return fmt.Errorf("transfer medium address is not set")
+	switch s.TransMediumType {
+	case "", TransMediumHTTP:
+		if len(s.TransMediumAddr) == 0 {
+			return fmt.Errorf("transfer medium address is not set")
+		}
+	case TransMediumBundle:
+		if s.Bundle == nil {
+			return errors.New("bundle transfer medium config is not set")
+		}
+
+		if err := s.Bundle.Validate(); err != nil {
+			return fmt.Errorf("bundle transfer medium config is invalid, err: %v", err)
+		}
+	default:
+		return fmt.Errorf("invalid transfer medium type: %s", s.TransMediumType)
+	}
+
+	resRuleMap := make(map[types.ResType]struct{})
+	for i, rule := range s.Rules {
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("validate sync rule(index: %d) failed, err: %v", i, err)
+		}
+
+		if _, exists := resRuleMap[rule.Resource]; exists {
+			return fmt.Errorf("%s sync rule is duplicate", rule.Resource)
+		}
+		resRuleMap[rule.Resource] = struct{}{}
+	}
+
+	return nil
+}
+
+// TransMediumType is the transfer medium type
+type TransMediumType string
+
+const (
+	// TransMediumHTTP is the http transfer medium which implements the transfer medium interface protocol
+	TransMediumHTTP TransMediumType = "http"
+	// TransMediumBundle is the offline file bundle transfer medium for air-gapped environments
+	TransMediumBundle TransMediumType = "bundle"
+)
+
+// BundleMediumConfig is the offline file bundle transfer medium config
+type BundleMediumConfig struct {
+	// Dir is the bundle directory, source cmdb writes bundles into it, destination cmdb replays bundles from it
+	Dir string `mapstructure:"dir"`
+	// SignKey is the key used to sign and verify the bundles, must be the same in all environments
+	SignKey string `mapstructure:"signKey"`
+	// MaxMessages is the max message count of one bundle, used by source cmdb
+	MaxMessages int `mapstructure:"maxMessages"`
+	// FlushIntervalSeconds is the interval to flush pending messages into a bundle, used by source cmdb
+	FlushIntervalSeconds int `mapstructure:"flushIntervalSeconds"`
+}
+
+// Validate BundleMediumConfig
+func (s *BundleMediumConfig) Validate() error {
+	if len(s.Dir) == 0 {
+		return errors.New("bundle dir is not set")
+	}
+
+	if len(s.SignKey) == 0 {
+		return errors.New("bundle sign key is not set")
+	}
+
+	if s.MaxMessages < 0 {
+		return fmt.Errorf("invalid bundle max messages: %d", s.MaxMessages)
+	}
+
+	if s.FlushIntervalSeconds < 0 {
+		return fmt.Errorf("invalid bundle flush interval seconds: %d", s.FlushIntervalSeconds)
+	}
+
+	return nil
+}
+
+// SyncRuleConf is the sync rule config for one resource, source cmdb applies the rule to the data before pushing it
+// to the transfer medium in both full sync and incremental sync
+type SyncRuleConf struct {
+	// Resource is the resource type of the sync rule
+	Resource types.ResType `mapstructure:"resource"`
+	// IncludeFields are the only fields to be synchronized, the fields required for synchronization are always included
+	IncludeFields []string `mapstructure:"includeFields"`
+	// ExcludeFields are the fields that are not synchronized
+	ExcludeFields []string `mapstructure:"excludeFields"`
+	// HashFields are the fields whose values are synchronized as the hex encoded sha256 hash of the values
+	HashFields []string `mapstructure:"hashFields"`
+	// HashSalt is the key used to calculate the hmac-sha256 hash of the hash fields, plain sha256 is used if not set
+	HashSalt string `mapstructure:"hashSalt"`
+	// MaskFields are the fields whose values are synchronized as masked values
+	MaskFields []string `mapstructure:"maskFields"`
+	// SkipSubResources are the sub resources(object ids) that are not synchronized
+	SkipSubResources []string `mapstructure:"skipSubResources"`
+	// DropFilter is the json encoded filter expression, the data that matches it is not synchronized
+	DropFilter string `mapstructure:"dropFilter"`
+}
+
+// Validate SyncRuleConf
+func (s *SyncRuleConf) Validate() error {
+	isValidRes := false
+	for _, resType := range types.ListAllResType() {
+		if s.Resource == resType {
+			isValidRes = true
+			break
+		}
+	}
+
+	if !isValidRes {
+		return fmt.Errorf("sync rule resource %s is invalid", s.Resource)
+	}
+
+	if len(s.IncludeFields) > 0 && len(s.ExcludeFields) > 0 {
+		return fmt.Errorf("%s sync rule include fields and exclude fields can not be both set", s.Resource)
+	}
+
+	fieldMap := make(map[string]struct{})
+	for _, fields := range [][]string{s.ExcludeFields, s.HashFields, s.MaskFields} {
+		for _, field := range fields {
+			if field == "" {
+				return fmt.Errorf("%s sync rule has empty field", s.Resource)
+			}
+
+			if _, exists := fieldMap[field]; exists {
+				return fmt.Errorf("%s sync rule field %s can only be excluded, hashed or masked once", s.Resource, field)
+			}
+			fieldMap[field] = struct{}{}
+		}
+	}
+
+	if _, withSubRes := types.ResTypeWithSubResMap[s.Resource]; !withSubRes && len(s.SkipSubResources) > 0 {
+		return fmt.Errorf("%s sync rule can not set skip sub resources", s.Resource)
+	}
+
+	if _, err := s.ParseDropFilter(); err != nil {
+		return fmt.Errorf("%s sync rule drop filter is invalid, err: %v", s.Resource, err)
 	}
 
 	return nil
 }
 
+// ParseDropFilter parse the drop filter expression, returns nil if the drop filter is not set
+func (s *SyncRuleConf) ParseDropFilter() (*filter.Expression, error) {
+	if s.DropFilter == "" {
+		return nil, nil
+	}
+
+	expr := new(filter.Expression)
+	if err := json.Unmarshal([]byte(s.DropFilter), expr); err != nil {
+		return nil, err
+	}
+
+	opt := filter.NewDefaultExprOpt(nil)
+	opt.IgnoreRuleFields = true
+	if err := expr.Validate(opt); err != nil {
+		return nil, err
+	}
+
+	return expr, nil
+}
+
 // SyncRole is the transfer service role in cmdb synchronization
 type SyncRole string
 
@@ -108,6 +108,11 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 	select {
 	case <-ctx.Done():
 	}
+
+	// flush the sync data that is not sent to the transfer medium yet before exiting
+	if err = svr.Service.Close(); err != nil {
+		blog.Errorf("close transfer service failed, err: %v", err)
+	}
 	return nil
 }
 
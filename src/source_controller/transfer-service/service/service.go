@@ -61,6 +61,11 @@ func New(conf *options.Config, engine *backbone.Engine) (*Service, error) {
 	}, nil
 }
 
+// Close releases the resources of the service, it is called when the service is shutting down
+func (s *Service) Close() error {
+	return s.syncer.Close()
+}
+
 // WebService provides web service
 func (s *Service) WebService() *restful.Container {
 	errors.SetGlobalCCError(s.engine.CCErr)
@@ -94,6 +99,14 @@ func (s *Service) initService(api *restful.WebService) {
 	})
 
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/sync/cmdb/data", Handler: s.SyncCmdbData})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/sync/conflict",
+		Handler: s.ListSyncConflicts})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/sync/audit/sub_resource",
+		Handler: s.ListSyncAuditSubRes})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/sync/audit/data",
+		Handler: s.ListSyncAuditData})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/compare/sync/audit/data",
+		Handler: s.CompareSyncAuditData})
 
 	utility.AddToRestfulWebService(api)
 }
@@ -18,6 +18,7 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 
 	"configcenter/pkg/synchronize/types"
 	"configcenter/src/common/blog"
@@ -51,3 +52,77 @@ func (s *Service) SyncCmdbData(cts *rest.Contexts) {
 
 	cts.RespEntity(nil)
 }
+
+// ListSyncConflicts list the sync data that conflicts with the diverged destination data
+func (s *Service) ListSyncConflicts(cts *rest.Contexts) {
+	opt := new(types.ListSyncConflictOpt)
+	if err := cts.DecodeInto(opt); err != nil {
+		cts.RespAutoError(err)
+		return
+	}
+
+	res, err := s.syncer.ListSyncConflicts(cts.Kit, opt)
+	if err != nil {
+		blog.Errorf("list sync conflicts failed, err: %v, opt: %+v, rid: %s", err, opt, cts.Kit.Rid)
+		cts.RespAutoError(err)
+		return
+	}
+
+	cts.RespEntity(res)
+}
+
+// ListSyncAuditSubRes list sub resources of the resource type that need to be audited
+func (s *Service) ListSyncAuditSubRes(cts *rest.Contexts) {
+	opt := new(types.ListSyncAuditSubResOpt)
+	if err := cts.DecodeInto(opt); err != nil {
+		cts.RespAutoError(err)
+		return
+	}
+
+	res, err := s.syncer.ListSyncAuditSubRes(cts.Kit, opt)
+	if err != nil {
+		blog.Errorf("list sync audit sub resources failed, err: %v, opt: %+v, rid: %s", err, opt, cts.Kit.Rid)
+		cts.RespAutoError(err)
+		return
+	}
+
+	cts.RespEntity(res)
+}
+
+// ListSyncAuditData list one interval of source sync data for sync audit
+func (s *Service) ListSyncAuditData(cts *rest.Contexts) {
+	opt := new(types.ListSyncAuditDataOpt)
+	if err := cts.DecodeInto(opt); err != nil {
+		cts.RespAutoError(err)
+		return
+	}
+
+	res, err := s.syncer.ListSyncAuditData(cts.Kit, opt)
+	if err != nil {
+		blog.Errorf("list sync audit data failed, err: %v, opt: %+v, rid: %s", err, opt, cts.Kit.Rid)
+		cts.RespAutoError(err)
+		return
+	}
+
+	cts.RespEntity(res)
+}
+
+// CompareSyncAuditData compare one interval of source sync data with the destination data for sync audit
+func (s *Service) CompareSyncAuditData(cts *rest.Contexts) {
+	rawDataArr := make([]json.RawMessage, 0)
+	opt := &types.SyncAuditData{Data: &types.FullSyncTransData{Data: &rawDataArr}}
+	if err := cts.DecodeInto(opt); err != nil {
+		cts.RespAutoError(err)
+		return
+	}
+
+	res, err := s.syncer.CompareSyncAuditData(cts.Kit, opt)
+	if err != nil {
+		blog.Errorf("compare sync audit data failed, err: %v, res: %s, sub res: %s, rid: %s", err, opt.ResType,
+			opt.SubRes, cts.Kit.Rid)
+		cts.RespAutoError(err)
+		return
+	}
+
+	cts.RespEntity(res)
+}
@@ -0,0 +1,432 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package sync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"configcenter/pkg/synchronize/types"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	commonutil "configcenter/src/common/util"
+	"configcenter/src/source_controller/transfer-service/app/options"
+	"configcenter/src/source_controller/transfer-service/sync/logics"
+	"configcenter/src/source_controller/transfer-service/sync/util"
+	"configcenter/src/source_controller/transfer-service/sync/watch"
+	"configcenter/src/storage/driver/redis"
+)
+
+// ListSyncAuditSubRes list sub resources of the resource type that need to be audited
+func (s *Syncer) ListSyncAuditSubRes(kit *rest.Kit, opt *types.ListSyncAuditSubResOpt) ([]string, error) {
+	if !s.enableSync {
+		return nil, errors.New("sync is disabled")
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		return nil, rawErr.ToCCError(kit.CCError)
+	}
+
+	objIDs, quotedObjIDs, err := s.metadata.GetCommonObjIDs()
+	if err != nil {
+		blog.Errorf("get object ids failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, err
+	}
+
+	var allSubRes []string
+	switch opt.ResType {
+	case types.ObjectInstance:
+		allSubRes = objIDs
+	case types.InstAsst:
+		allSubRes = append(objIDs, common.BKInnerObjIDHost)
+	case types.QuotedInstance:
+		allSubRes = quotedObjIDs
+	}
+
+	subResources := make([]string, 0)
+	for _, subRes := range allSubRes {
+		if s.rules.SkipSubRes(opt.ResType, subRes) {
+			continue
+		}
+		subResources = append(subResources, subRes)
+	}
+
+	return subResources, nil
+}
+
+// ListSyncAuditData list one interval of source sync data for sync audit, the data is the same as full sync data
+func (s *Syncer) ListSyncAuditData(kit *rest.Kit, opt *types.ListSyncAuditDataOpt) (*types.SyncAuditData, error) {
+	if !s.enableSync {
+		return nil, errors.New("sync is disabled")
+	}
+
+	if s.role != options.SyncRoleSrc {
+		return nil, errors.New("sync audit data can only be listed in source cmdb")
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		return nil, rawErr.ToCCError(kit.CCError)
+	}
+
+	syncer, exists := s.resSyncerMap[opt.ResType]
+	if !exists {
+		blog.Errorf("res type %s is invalid, rid: %s", opt.ResType, kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, types.ResTypeField)
+	}
+
+	kt := util.ConvertKit(kit)
+	kt.Ctx = commonutil.SetDBReadPreference(kit.Ctx, common.SecondaryPreferredMode)
+
+	start := opt.Start
+	if start == nil {
+		start = make(map[string]int64)
+	}
+
+	res := &types.SyncAuditData{
+		ResType:   opt.ResType,
+		SubRes:    opt.SubRes,
+		NextStart: make(map[string]int64),
+	}
+
+	// returns the incremental sync cursor at the beginning of the audit to calculate the sync lag
+	if len(start) == 0 && opt.ResType != types.QuotedInstance {
+		cursor, err := watch.GetIncrCursor(kt.Ctx, opt.ResType)
+		if err != nil {
+			blog.Errorf("get %s incr sync cursor failed, err: %v, rid: %s", opt.ResType, err, kit.Rid)
+			return nil, err
+		}
+		res.IncrCursor = cursor
+	}
+
+	// the data of skipped sub resource is not synchronized, so there's no data to audit
+	if s.rules.SkipSubRes(opt.ResType, opt.SubRes) {
+		res.IsAll = true
+		res.Data = &types.FullSyncTransData{
+			Name:  syncer.name,
+			Start: start,
+			End:   make(map[string]int64),
+			Data:  make([]json.RawMessage, 0),
+		}
+		return res, nil
+	}
+
+	// model definitions are identified by key instead of id, so they are audited in one interval to find the
+	// destination model definitions that do not exist in source
+	if types.IsModelResType(opt.ResType) {
+		transData, err := syncer.listAllAuditData(kt)
+		if err != nil {
+			return nil, err
+		}
+
+		res.Data = transData
+		res.IsAll = true
+		return res, nil
+	}
+
+	info, transData, err := syncer.listFullSyncData(kt, opt.SubRes, start, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res.Data = transData
+	res.IsAll = info.IsAll
+	if !info.IsAll {
+		res.NextStart = info.NextStart
+	}
+
+	return res, nil
+}
+
+// listAllAuditData list all source sync data of the resource without sub resource for sync audit in one interval
+func (s *resSyncer) listAllAuditData(kit *util.Kit) (*types.FullSyncTransData, error) {
+	allData := make([]json.RawMessage, 0)
+	start := make(map[string]int64)
+	for {
+		info, transData, err := s.listFullSyncData(kit, "", start, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		// the data is not converted to raw json array if there is no sync rule of the resource
+		raw, err := json.Marshal(transData.Data)
+		if err != nil {
+			blog.Errorf("marshal %s sync audit data failed, err: %v, rid: %s", s.lgc.ResType(), err, kit.Rid)
+			return nil, err
+		}
+
+		data := make([]json.RawMessage, 0)
+		if err = json.Unmarshal(raw, &data); err != nil {
+			blog.Errorf("unmarshal %s sync audit data failed, err: %v, rid: %s", s.lgc.ResType(), err, kit.Rid)
+			return nil, err
+		}
+		allData = append(allData, data...)
+
+		if info.IsAll {
+			break
+		}
+		start = info.NextStart
+	}
+
+	return &types.FullSyncTransData{
+		Name:  s.name,
+		Start: make(map[string]int64),
+		End:   make(map[string]int64),
+		Data:  allData,
+	}, nil
+}
+
+// CompareSyncAuditData compare one interval of source sync data with the destination data for sync audit, this
+// operation is read-only, the destination data is not changed
+func (s *Syncer) CompareSyncAuditData(kit *rest.Kit, opt *types.SyncAuditData) (*types.SyncAuditCompareRes,
+	error) {
+
+	if !s.enableSync {
+		return nil, errors.New("sync is disabled")
+	}
+
+	if s.role != options.SyncRoleDest {
+		return nil, errors.New("sync audit data can only be compared in destination cmdb")
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		return nil, rawErr.ToCCError(kit.CCError)
+	}
+
+	syncer, exists := s.resSyncerMap[opt.ResType]
+	if !exists {
+		blog.Errorf("res type %s is invalid, rid: %s", opt.ResType, kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, types.ResTypeField)
+	}
+
+	kt := util.ConvertKit(kit)
+	kt.Ctx = commonutil.SetDBReadPreference(kit.Ctx, common.SecondaryPreferredMode)
+
+	srcKeys, diffKeys, err := syncer.compareSyncAuditData(kt, opt.SubRes, opt.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	missing, different, redundant, destKeys := diffKeys.classify(srcKeys)
+
+	res := &types.SyncAuditCompareRes{
+		ResType:      opt.ResType,
+		SubRes:       opt.SubRes,
+		SrcCount:     len(srcKeys),
+		DestCount:    len(destKeys),
+		SrcChecksum:  genAuditChecksum(srcKeys),
+		DestChecksum: genAuditChecksum(destKeys),
+		MissingIDs:   sortAuditKeys(missing),
+		DifferentIDs: sortAuditKeys(different),
+		RedundantIDs: sortAuditKeys(redundant),
+	}
+
+	res.LastApplyPushTime, err = getLastApplyPushTime(kit.Ctx, opt.ResType)
+	if err != nil {
+		blog.Errorf("get %s last applied incr sync push time failed, err: %v, rid: %s", opt.ResType, err, kit.Rid)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// auditDiffKeys is the keys of the data to be inserted, updated and deleted by the compare result
+type auditDiffKeys struct {
+	insert, update, delete map[string]struct{}
+}
+
+// compareSyncAuditData compare source sync data with the destination data of the same interval, returns the source
+// data keys and the keys of the different data
+func (s *resSyncer) compareSyncAuditData(kit *util.Kit, subRes string, syncData *types.FullSyncTransData) (
+	map[string]struct{}, *auditDiffKeys, error) {
+
+	resType := s.lgc.ResType()
+
+	rawDataArr, ok := syncData.Data.(*[]json.RawMessage)
+	if !ok {
+		return nil, nil, fmt.Errorf("sync audit data type %T is invalid", syncData.Data)
+	}
+
+	dataArr, err := s.lgc.ParseDataArr(syncData.Name, subRes, *rawDataArr, kit.Rid)
+	if err != nil {
+		blog.Errorf("parse %s-%s sync audit data failed, err: %v, rid: %s", resType, subRes, err, kit.Rid)
+		return nil, nil, err
+	}
+
+	srcKeyArr, err := logics.GetCompDataKeys(dataArr)
+	if err != nil {
+		blog.Errorf("get %s-%s sync audit data keys failed, err: %v, rid: %s", resType, subRes, err, kit.Rid)
+		return nil, nil, err
+	}
+	srcKeys := make(map[string]struct{}, len(srcKeyArr))
+	addAuditKeys(srcKeys, srcKeyArr)
+
+	diffKeys := &auditDiffKeys{
+		insert: make(map[string]struct{}),
+		update: make(map[string]struct{}),
+		delete: make(map[string]struct{}),
+	}
+
+	// loop compare the source data with destination data of the interval, only compare without changing data
+	compData := &types.FullSyncTransData{
+		Name:  syncData.Name,
+		Start: syncData.Start,
+		End:   syncData.End,
+		Data:  dataArr,
+	}
+	isAll := false
+	for !isAll {
+		listOpt := &types.ListDataOpt{
+			SubRes: subRes,
+			Start:  compData.Start,
+			End:    compData.End,
+		}
+		listRes, err := s.lgc.ListData(kit, listOpt)
+		if err != nil {
+			blog.Errorf("list %s data failed, err: %v, opt: %+v, rid: %s", resType, err, *listOpt, kit.Rid)
+			return nil, nil, err
+		}
+
+		compRes, err := s.compareAuditData(kit, subRes, compData, listRes)
+		if err != nil {
+			blog.Errorf("compare %s-%s data failed, err: %v, rid: %s", resType, subRes, err, kit.Rid)
+			return nil, nil, err
+		}
+
+		if err = diffKeys.add(compRes); err != nil {
+			blog.Errorf("get %s-%s compare data keys failed, err: %v, rid: %s", resType, subRes, err, kit.Rid)
+			return nil, nil, err
+		}
+
+		isAll = listRes.IsAll
+		compData.Start = listRes.NextStart
+		compData.Data = compRes.RemainingSrc
+	}
+
+	return srcKeys, diffKeys, nil
+}
+
+// compareAuditData compare one page of source data with destination data without side effects
+func (s *resSyncer) compareAuditData(kit *util.Kit, subRes string, srcInfo *types.FullSyncTransData,
+	destInfo *types.ListDataRes) (*types.CompDataRes, error) {
+
+	if comparer, ok := s.lgc.(logics.AuditComparer); ok {
+		return comparer.CompareAuditData(kit, subRes, srcInfo, destInfo)
+	}
+	return s.lgc.CompareData(kit, subRes, srcInfo, destInfo)
+}
+
+// classify the source data keys and the compare result keys into the keys of the data that is missing in destination,
+// the data that is different in destination, the data that is redundant in destination and all destination data
+func (d *auditDiffKeys) classify(srcKeys map[string]struct{}) (missing, different, redundant,
+	destKeys map[string]struct{}) {
+
+	// missing data is only in source, redundant data is only in destination, data that is deleted then inserted
+	// by the compare result is different data, like the changed relation data
+	missing, different, redundant = make(map[string]struct{}), make(map[string]struct{}), make(map[string]struct{})
+	for key := range d.insert {
+		if _, exists := d.delete[key]; exists {
+			different[key] = struct{}{}
+			continue
+		}
+		missing[key] = struct{}{}
+	}
+	for key := range d.update {
+		different[key] = struct{}{}
+	}
+	for key := range d.delete {
+		if _, exists := d.insert[key]; !exists {
+			redundant[key] = struct{}{}
+		}
+	}
+
+	destKeys = make(map[string]struct{})
+	for key := range srcKeys {
+		if _, exists := missing[key]; !exists {
+			destKeys[key] = struct{}{}
+		}
+	}
+	for key := range redundant {
+		destKeys[key] = struct{}{}
+	}
+
+	return missing, different, redundant, destKeys
+}
+
+// add the keys of the compare result data
+func (d *auditDiffKeys) add(compRes *types.CompDataRes) error {
+	insertKeys, err := logics.GetCompDataKeys(compRes.Insert)
+	if err != nil {
+		return err
+	}
+	addAuditKeys(d.insert, insertKeys)
+
+	updateKeys, err := logics.GetCompDataKeys(compRes.Update)
+	if err != nil {
+		return err
+	}
+	addAuditKeys(d.update, updateKeys)
+
+	deleteKeys, err := logics.GetCompDataKeys(compRes.Delete)
+	if err != nil {
+		return err
+	}
+	addAuditKeys(d.delete, deleteKeys)
+
+	return nil
+}
+
+func addAuditKeys(keyMap map[string]struct{}, keys []string) {
+	for _, key := range keys {
+		keyMap[key] = struct{}{}
+	}
+}
+
+func sortAuditKeys(keyMap map[string]struct{}) []string {
+	keys := make([]string, 0, len(keyMap))
+	for key := range keyMap {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// genAuditChecksum generate the checksum of the sorted data keys
+func genAuditChecksum(keyMap map[string]struct{}) string {
+	sum := sha256.Sum256([]byte(strings.Join(sortAuditKeys(keyMap), ",")))
+	return hex.EncodeToString(sum[:])
+}
+
+// getLastApplyPushTime get the push time of the last applied incremental sync data of the resource type
+func getLastApplyPushTime(ctx context.Context, resType types.ResType) (int64, error) {
+	val, err := redis.Client().HGet(ctx, types.IncrSyncPushTimeKey, string(resType)).Result()
+	if err != nil {
+		if redis.IsNilErr(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	return strconv.ParseInt(val, 10, 64)
+}
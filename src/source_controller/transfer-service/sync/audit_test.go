@@ -0,0 +1,262 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package sync
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"testing"
+
+	"configcenter/pkg/synchronize/types"
+	"configcenter/src/common"
+	"configcenter/src/source_controller/transfer-service/sync/util"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeData is the sync data of fakeLogics, which is identified by the id like DataWithID
+type fakeData struct {
+	ID    int64  `json:"ID"`
+	Value string `json:"Value"`
+}
+
+// fakeLogics is the resource sync logics whose data is stored in memory and listed by pages
+type fakeLogics struct {
+	resType  types.ResType
+	data     []fakeData
+	pageSize int
+	listErr  error
+}
+
+func (l *fakeLogics) ResType() types.ResType {
+	return l.resType
+}
+
+func (l *fakeLogics) ParseDataArr(_, _ string, data any, _ string) (any, error) {
+	raws, ok := data.([]json.RawMessage)
+	if !ok {
+		return nil, errors.New("invalid data")
+	}
+
+	arr := make([]fakeData, len(raws))
+	for i, raw := range raws {
+		if err := json.Unmarshal(raw, &arr[i]); err != nil {
+			return nil, err
+		}
+	}
+	return arr, nil
+}
+
+func (l *fakeLogics) ListData(_ *util.Kit, opt *types.ListDataOpt) (*types.ListDataRes, error) {
+	if l.listErr != nil {
+		return nil, l.listErr
+	}
+
+	dataArr := make([]fakeData, 0)
+	for _, data := range l.data {
+		if data.ID <= opt.Start[common.BKFieldID] {
+			continue
+		}
+		if len(opt.End) > 0 && data.ID > opt.End[common.BKFieldID] {
+			continue
+		}
+		if len(dataArr) == l.pageSize {
+			break
+		}
+		dataArr = append(dataArr, data)
+	}
+
+	res := &types.ListDataRes{
+		IsAll:     len(dataArr) < l.pageSize,
+		Data:      dataArr,
+		NextStart: make(map[string]int64),
+	}
+	if len(dataArr) > 0 {
+		res.NextStart[common.BKFieldID] = dataArr[len(dataArr)-1].ID
+	}
+	return res, nil
+}
+
+// CompareData compare the source data with the destination data whose id is not greater than the last destination id
+func (l *fakeLogics) CompareData(_ *util.Kit, _ string, srcInfo *types.FullSyncTransData,
+	destInfo *types.ListDataRes) (*types.CompDataRes, error) {
+
+	srcArr := srcInfo.Data.([]fakeData)
+	destArr := destInfo.Data.([]fakeData)
+
+	destMap := make(map[int64]fakeData)
+	for _, data := range destArr {
+		destMap[data.ID] = data
+	}
+
+	res := &types.CompDataRes{}
+	insert, update, remaining := make([]fakeData, 0), make([]fakeData, 0), make([]fakeData, 0)
+	srcIDs := make(map[int64]struct{})
+	for _, data := range srcArr {
+		if !destInfo.IsAll && data.ID > destInfo.NextStart[common.BKFieldID] {
+			remaining = append(remaining, data)
+			continue
+		}
+
+		srcIDs[data.ID] = struct{}{}
+		destData, exists := destMap[data.ID]
+		switch {
+		case !exists:
+			insert = append(insert, data)
+		case destData.Value != data.Value:
+			update = append(update, data)
+		}
+	}
+
+	del := make([]fakeData, 0)
+	for _, data := range destArr {
+		if _, exists := srcIDs[data.ID]; !exists {
+			del = append(del, data)
+		}
+	}
+
+	res.Insert, res.Update, res.Delete, res.RemainingSrc = insert, update, del, remaining
+	return res, nil
+}
+
+func (l *fakeLogics) ClassifyUpsertData(_ *util.Kit, _ string, _ any) (any, any, error) {
+	return nil, nil, nil
+}
+
+func (l *fakeLogics) InsertData(_ *util.Kit, _ string, _ any) error {
+	return nil
+}
+
+func (l *fakeLogics) UpdateData(_ *util.Kit, _ string, _ any) error {
+	return nil
+}
+
+func (l *fakeLogics) DeleteData(_ *util.Kit, _ string, _ any) error {
+	return nil
+}
+
+func newFakeAuditData(t *testing.T, dataArr ...fakeData) *types.FullSyncTransData {
+	raws := make([]json.RawMessage, len(dataArr))
+	for i, data := range dataArr {
+		raw, err := json.Marshal(data)
+		require.NoError(t, err)
+		raws[i] = raw
+	}
+
+	return &types.FullSyncTransData{
+		Name:  "src",
+		Start: make(map[string]int64),
+		End:   make(map[string]int64),
+		Data:  &raws,
+	}
+}
+
+func keySet(keys ...string) map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, key := range keys {
+		set[key] = struct{}{}
+	}
+	return set
+}
+
+func TestCompareSyncAuditData(t *testing.T) {
+	syncer := &resSyncer{
+		name: "src",
+		lgc: &fakeLogics{
+			resType:  types.Host,
+			data:     []fakeData{{1, "a"}, {2, "x"}, {4, "d"}, {5, "e"}, {6, "f"}},
+			pageSize: 2,
+		},
+	}
+
+	// the source data is compared with all pages of the destination data
+	srcData := newFakeAuditData(t, fakeData{1, "a"}, fakeData{2, "b"}, fakeData{3, "c"}, fakeData{5, "e"},
+		fakeData{7, "g"})
+	srcKeys, diffKeys, err := syncer.compareSyncAuditData(util.NewKit(), "", srcData)
+	require.NoError(t, err)
+	require.Equal(t, keySet("1", "2", "3", "5", "7"), srcKeys)
+	require.Equal(t, keySet("3", "7"), diffKeys.insert)
+	require.Equal(t, keySet("2"), diffKeys.update)
+	require.Equal(t, keySet("4", "6"), diffKeys.delete)
+
+	missing, different, redundant, destKeys := diffKeys.classify(srcKeys)
+	require.Equal(t, keySet("3", "7"), missing)
+	require.Equal(t, keySet("2"), different)
+	require.Equal(t, keySet("4", "6"), redundant)
+	require.Equal(t, keySet("1", "2", "4", "5", "6"), destKeys)
+
+	// the source data in the interval end is only compared with the destination data in the interval
+	srcData = newFakeAuditData(t, fakeData{1, "a"}, fakeData{2, "x"})
+	srcData.End = map[string]int64{common.BKFieldID: 2}
+	srcKeys, diffKeys, err = syncer.compareSyncAuditData(util.NewKit(), "", srcData)
+	require.NoError(t, err)
+	missing, different, redundant, destKeys = diffKeys.classify(srcKeys)
+	require.Empty(t, missing)
+	require.Empty(t, different)
+	require.Empty(t, redundant)
+	require.Equal(t, genAuditChecksum(srcKeys), genAuditChecksum(destKeys))
+
+	// invalid audit data type
+	_, _, err = syncer.compareSyncAuditData(util.NewKit(), "", &types.FullSyncTransData{Data: "invalid"})
+	require.Error(t, err)
+
+	syncer.lgc.(*fakeLogics).listErr = errors.New("list failed")
+	_, _, err = syncer.compareSyncAuditData(util.NewKit(), "", newFakeAuditData(t, fakeData{1, "a"}))
+	require.Error(t, err)
+}
+
+func TestClassifyAuditDiffKeys(t *testing.T) {
+	// relation data whose associated id is changed is deleted then inserted by the compare result
+	diffKeys := &auditDiffKeys{
+		insert: keySet("1-2", "3-4"),
+		update: keySet("5"),
+		delete: keySet("1-2", "6-7"),
+	}
+
+	missing, different, redundant, destKeys := diffKeys.classify(keySet("1-2", "3-4", "5", "8"))
+	require.Equal(t, keySet("3-4"), missing)
+	require.Equal(t, keySet("1-2", "5"), different)
+	require.Equal(t, keySet("6-7"), redundant)
+	require.Equal(t, keySet("1-2", "5", "6-7", "8"), destKeys)
+}
+
+func TestAuditDiffKeysAdd(t *testing.T) {
+	diffKeys := &auditDiffKeys{insert: keySet(), update: keySet(), delete: keySet()}
+	err := diffKeys.add(&types.CompDataRes{
+		Insert: []fakeData{{1, "a"}},
+		Update: []int64{2, 3},
+		Delete: map[int64][]int64{4: {5, 6}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, keySet("1"), diffKeys.insert)
+	require.Equal(t, keySet("2", "3"), diffKeys.update)
+	require.Equal(t, keySet("4-5", "4-6"), diffKeys.delete)
+
+	err = diffKeys.add(&types.CompDataRes{Insert: []map[string]any{{"name": "no key"}}})
+	require.Error(t, err)
+}
+
+func TestGenAuditChecksum(t *testing.T) {
+	// checksum is irrelevant to the key order
+	require.Equal(t, genAuditChecksum(keySet("1", "2", "3")), genAuditChecksum(keySet("3", "1", "2")))
+	require.NotEqual(t, genAuditChecksum(keySet("1", "2", "3")), genAuditChecksum(keySet("1", "2")))
+
+	keys := sortAuditKeys(keySet("3", "1", "2"))
+	require.True(t, sort.StringsAreSorted(keys))
+	require.Len(t, keys, 3)
+}
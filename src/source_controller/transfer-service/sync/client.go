@@ -47,6 +47,11 @@ func (s *Syncer) SyncCmdbData(kit *rest.Kit, opt *types.SyncCmdbDataOption) erro
 		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "resource_type")
 	}
 
+	if s.rules.SkipSubRes(opt.ResType, opt.SubRes) {
+		blog.Errorf("%s-%s is skipped by sync rules, rid: %s", opt.ResType, opt.SubRes, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "sub_resource")
+	}
+
 	locker := lock.NewLocker(redis.Client())
 	locked, err := locker.Lock(types.FullSyncLockKey, time.Hour)
 	if err != nil {
@@ -90,3 +95,24 @@ func (s *Syncer) SyncCmdbData(kit *rest.Kit, opt *types.SyncCmdbDataOption) erro
 
 	return nil
 }
+
+// ListSyncConflicts list the sync data that conflicts with the diverged destination data
+func (s *Syncer) ListSyncConflicts(kit *rest.Kit, opt *types.ListSyncConflictOpt) (*types.ListSyncConflictRes, error) {
+	if !s.enableSync {
+		return nil, errors.New("sync is disabled")
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		return nil, rawErr.ToCCError(kit.CCError)
+	}
+
+	conflicts, err := s.conflicts.List(util.ConvertKit(kit), opt.ResType)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.ListSyncConflictRes{
+		Count: len(conflicts),
+		Info:  conflicts,
+	}, nil
+}
@@ -48,20 +48,18 @@ func (s *Syncer) loopPullFullSyncData() {
 			continue
 		}
 
-		// get object ids for object instance resource sync
 		var objIDs, quotedObjIDs []string
-		util.RetryWrapper(3, func() (bool, error) {
-			objIDs, quotedObjIDs, err = s.metadata.GetCommonObjIDs()
-			if err != nil {
-				blog.Errorf("get object ids failed, err: %v", err)
-				return true, err
-			}
-			return false, nil
-		})
-
+		objIDsFetched := false
 		for _, resType := range types.ListAllResType() {
 			syncer := s.resSyncerMap[resType]
 
+			// get object ids for object instance resource sync after model definitions are synchronized, so that
+			// the instances of the newly synchronized models are also synchronized
+			if !objIDsFetched && !types.IsModelResType(resType) {
+				objIDs, quotedObjIDs = s.getCommonObjIDs()
+				objIDsFetched = true
+			}
+
 			switch resType {
 			case types.ObjectInstance:
 				for _, objID := range objIDs {
@@ -87,6 +85,21 @@ func (s *Syncer) loopPullFullSyncData() {
 	}
 }
 
+// getCommonObjIDs get object ids and quoted object ids for object instance resource sync
+func (s *Syncer) getCommonObjIDs() ([]string, []string) {
+	var objIDs, quotedObjIDs []string
+	var err error
+	util.RetryWrapper(3, func() (bool, error) {
+		objIDs, quotedObjIDs, err = s.metadata.GetCommonObjIDs()
+		if err != nil {
+			blog.Errorf("get object ids failed, err: %v", err)
+			return true, err
+		}
+		return false, nil
+	})
+	return objIDs, quotedObjIDs
+}
+
 // pullFullSyncData pull full sync data for one resource
 func (s *resSyncer) pullFullSyncData(subRes string, ack bool) {
 	kit := util.NewKit()
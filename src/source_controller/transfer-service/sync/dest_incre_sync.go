@@ -23,6 +23,7 @@ import (
 	"configcenter/pkg/synchronize/types"
 	"configcenter/src/common/blog"
 	"configcenter/src/source_controller/transfer-service/sync/util"
+	"configcenter/src/storage/driver/redis"
 )
 
 // loopPullIncrSyncData loop pull incremental sync data
@@ -124,6 +125,15 @@ func (s *resSyncer) pullIncrSyncData(ack bool) (bool, error) {
 		}
 	}
 
+	// record the push time of the applied incr sync data for sync audit to calculate the sync lag
+	if syncData.PushTime > 0 {
+		err = redis.Client().HSet(kit.Ctx, types.IncrSyncPushTimeKey, string(resType), syncData.PushTime).Err()
+		if err != nil {
+			blog.Errorf("set %s incr sync push time %d failed, err: %v, rid: %s", resType, syncData.PushTime, err,
+				kit.Rid)
+		}
+	}
+
 	blog.Infof("pull %s incr sync data successfully, rid: %s", resType, kit.Rid)
 	return syncInfo.Total != 0, nil
 }
@@ -0,0 +1,114 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package logics
+
+import (
+	"time"
+
+	"configcenter/pkg/synchronize/types"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/source_controller/transfer-service/sync/util"
+	"configcenter/src/storage/driver/mongodb"
+)
+
+const (
+	conflictResTypeField = "resource_type"
+	conflictKeyField     = "key"
+)
+
+// conflictInfo is the sync data conflict info stored in db
+type conflictInfo struct {
+	ResType    types.ResType `bson:"resource_type"`
+	Key        string        `bson:"key"`
+	Reason     string        `bson:"reason"`
+	Data       mapstr.MapStr `bson:"data"`
+	DetectTime time.Time     `bson:"detect_time"`
+}
+
+// ConflictRecorder records the sync data that conflicts with the diverged destination data, the conflicts are stored
+// in the sync data conflict table so that they are kept after restart
+type ConflictRecorder struct{}
+
+// NewConflictRecorder new sync data conflict recorder
+func NewConflictRecorder() *ConflictRecorder {
+	return new(ConflictRecorder)
+}
+
+// add record the conflict of the sync data, the previous conflict of the same data is replaced
+func (r *ConflictRecorder) add(kit *util.Kit, resType types.ResType, key, reason string, data mapstr.MapStr) error {
+	cond := mapstr.MapStr{conflictResTypeField: resType, conflictKeyField: key}
+	conflict := &conflictInfo{
+		ResType:    resType,
+		Key:        key,
+		Reason:     reason,
+		Data:       data,
+		DetectTime: time.Now(),
+	}
+
+	if err := mongodb.Client().Table(common.BKTableNameSyncDataConflict).Upsert(kit.Ctx, cond, conflict); err != nil {
+		blog.Errorf("upsert sync conflict(%+v) failed, err: %v, rid: %s", conflict, err, kit.Rid)
+		return err
+	}
+	return nil
+}
+
+// remove the conflicts of the sync data that are synchronized successfully
+func (r *ConflictRecorder) remove(kit *util.Kit, resType types.ResType, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	cond := mapstr.MapStr{
+		conflictResTypeField: resType,
+		conflictKeyField:     mapstr.MapStr{common.BKDBIN: keys},
+	}
+	if err := mongodb.Client().Table(common.BKTableNameSyncDataConflict).Delete(kit.Ctx, cond); err != nil {
+		blog.Errorf("delete sync conflicts failed, err: %v, cond: %+v, rid: %s", err, cond, kit.Rid)
+		return err
+	}
+	return nil
+}
+
+// List list the sync data conflicts, list all resource type conflicts if resource type is not set
+func (r *ConflictRecorder) List(kit *util.Kit, resType types.ResType) ([]types.SyncConflict, error) {
+	cond := make(mapstr.MapStr)
+	if resType != "" {
+		cond[conflictResTypeField] = resType
+	}
+
+	infos := make([]conflictInfo, 0)
+	err := mongodb.Client().Table(common.BKTableNameSyncDataConflict).Find(cond).Sort(conflictResTypeField+","+conflictKeyField).
+		All(kit.Ctx, &infos)
+	if err != nil {
+		blog.Errorf("list sync conflicts failed, err: %v, cond: %+v, rid: %s", err, cond, kit.Rid)
+		return nil, err
+	}
+
+	conflicts := make([]types.SyncConflict, len(infos))
+	for i, info := range infos {
+		conflicts[i] = types.SyncConflict{
+			ResType:    info.ResType,
+			Key:        info.Key,
+			Reason:     info.Reason,
+			Data:       info.Data,
+			DetectTime: info.DetectTime,
+		}
+	}
+	return conflicts, nil
+}
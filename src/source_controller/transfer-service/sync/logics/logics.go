@@ -37,20 +37,35 @@ type Logics interface {
 	DeleteData(kit *util.Kit, subRes string, data any) error
 }
 
+// AuditComparer is implemented by the resource sync logics whose CompareData changes the sync state, e.g. records
+// the sync data conflicts, sync audit uses CompareAuditData instead to compare data without side effects
+type AuditComparer interface {
+	CompareAuditData(kit *util.Kit, subRes string, srcInfo *types.FullSyncTransData, destInfo *types.ListDataRes) (
+		*types.CompDataRes, error)
+}
+
 // New creates a new resource type to resource sync logics map
 func New(conf *LogicsConfig) map[types.ResType]Logics {
 	lgcMap := map[types.ResType]Logics{
-		types.Biz:             newDataWithIDLogics(conf.genResLgcConf(types.Biz), bizLgc),
-		types.Set:             newDataWithIDLogics(conf.genResLgcConf(types.Set), setLgc),
-		types.Module:          newDataWithIDLogics(conf.genResLgcConf(types.Module), moduleLgc),
-		types.Host:            newDataWithIDLogics(conf.genResLgcConf(types.Host), hostLgc),
-		types.HostRelation:    newRelationLogics(conf.genResLgcConf(types.HostRelation), hostRelLgc),
-		types.ObjectInstance:  newObjInstLogics(conf.genResLgcConf(types.ObjectInstance)),
-		types.InstAsst:        newDataWithIDLogics(conf.genResLgcConf(types.InstAsst), instAsstLgc),
-		types.ServiceInstance: newDataWithIDLogics(conf.genResLgcConf(types.ServiceInstance), serviceInstLgc),
-		types.Process:         newDataWithIDLogics(conf.genResLgcConf(types.Process), procLgc),
-		types.ProcessRelation: newRelationLogics(conf.genResLgcConf(types.ProcessRelation), procRelLgc),
-		types.QuotedInstance:  newDataWithIDLogics(conf.genResLgcConf(types.QuotedInstance), quotedInstLgc),
+		types.ModelClassification: newModelLogics(conf.genResLgcConf(types.ModelClassification),
+			classificationLgc),
+		types.AssociationKind:  newModelLogics(conf.genResLgcConf(types.AssociationKind), asstKindLgc),
+		types.Model:            newModelLogics(conf.genResLgcConf(types.Model), objLgc),
+		types.ModelAttrGroup:   newModelLogics(conf.genResLgcConf(types.ModelAttrGroup), attrGroupLgc),
+		types.ModelAttribute:   newModelLogics(conf.genResLgcConf(types.ModelAttribute), attrLgc),
+		types.ModelUnique:      newModelLogics(conf.genResLgcConf(types.ModelUnique), uniqueLgc),
+		types.ModelAssociation: newModelLogics(conf.genResLgcConf(types.ModelAssociation), objAsstLgc),
+		types.Biz:              newDataWithIDLogics(conf.genResLgcConf(types.Biz), bizLgc),
+		types.Set:              newDataWithIDLogics(conf.genResLgcConf(types.Set), setLgc),
+		types.Module:           newDataWithIDLogics(conf.genResLgcConf(types.Module), moduleLgc),
+		types.Host:             newDataWithIDLogics(conf.genResLgcConf(types.Host), hostLgc),
+		types.HostRelation:     newRelationLogics(conf.genResLgcConf(types.HostRelation), hostRelLgc),
+		types.ObjectInstance:   newObjInstLogics(conf.genResLgcConf(types.ObjectInstance)),
+		types.InstAsst:         newDataWithIDLogics(conf.genResLgcConf(types.InstAsst), instAsstLgc),
+		types.ServiceInstance:  newDataWithIDLogics(conf.genResLgcConf(types.ServiceInstance), serviceInstLgc),
+		types.Process:          newDataWithIDLogics(conf.genResLgcConf(types.Process), procLgc),
+		types.ProcessRelation:  newRelationLogics(conf.genResLgcConf(types.ProcessRelation), procRelLgc),
+		types.QuotedInstance:   newDataWithIDLogics(conf.genResLgcConf(types.QuotedInstance), quotedInstLgc),
 	}
 
 	return lgcMap
@@ -61,6 +76,7 @@ type LogicsConfig struct {
 	Metadata      *metadata.Metadata
 	IDRuleMap     map[types.ResType]map[string][]options.IDRuleInfo
 	SrcInnerIDMap map[string]*options.InnerDataIDConf
+	Conflicts     *ConflictRecorder
 }
 
 func (c *LogicsConfig) genResLgcConf(resType types.ResType) *resLogicsConfig {
@@ -69,6 +85,7 @@ func (c *LogicsConfig) genResLgcConf(resType types.ResType) *resLogicsConfig {
 		metadata:      c.Metadata,
 		idRuleMap:     c.IDRuleMap,
 		srcInnerIDMap: c.SrcInnerIDMap,
+		conflicts:     c.Conflicts,
 	}
 }
 
@@ -78,6 +95,7 @@ type resLogicsConfig struct {
 	metadata      *metadata.Metadata
 	idRuleMap     map[types.ResType]map[string][]options.IDRuleInfo
 	srcInnerIDMap map[string]*options.InnerDataIDConf
+	conflicts     *ConflictRecorder
 }
 
 // ResType get resource type
@@ -0,0 +1,603 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package logics
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"configcenter/pkg/synchronize/types"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	commonutil "configcenter/src/common/util"
+	"configcenter/src/source_controller/transfer-service/sync/metadata"
+	"configcenter/src/source_controller/transfer-service/sync/util"
+	"configcenter/src/storage/driver/mongodb"
+)
+
+// modelData is the model definition data, the ids of model definitions are generated separately in each environment,
+// so model definition data is identified by the key instead of the id
+type modelData struct {
+	Key  string
+	Data mapstr.MapStr
+}
+
+type modelLogics struct {
+	*resLogicsConfig
+	*modelLgc
+}
+
+type modelLgc struct {
+	table string
+	// keyFields are the fields that identify the model definition in all environments
+	keyFields []string
+	// immutableFields are the fields that can not be changed after the model definition is created, destination data
+	// with different values of these fields has diverged from the source data and will not be overwritten
+	immutableFields []string
+	// getKey returns the key of the model definition, the key is generated by the key fields if it is not set
+	getKey func(data mapstr.MapStr) (string, error)
+	// listDest returns the destination data map of key to data for the source data, the destination data is listed by
+	// the key fields if it is not set
+	listDest func(kit *util.Kit, meta *metadata.Metadata, dataArr []modelData) (map[string]mapstr.MapStr, error)
+	// afterList handles the listed model definitions before they are synchronized
+	afterList func(kit *util.Kit, meta *metadata.Metadata, dataArr []mapstr.MapStr) error
+	// convertSrc converts source data into destination data, returns the conflict reason if the source data can not
+	// be synchronized, e.g. the destination data it depends on does not exist
+	convertSrc func(kit *util.Kit, data mapstr.MapStr) (string, error)
+	// beforeDelete is called with the destination data before the model definitions are deleted, so that the
+	// deletion can be retried if it fails
+	beforeDelete func(kit *util.Kit, dataArr []mapstr.MapStr) error
+}
+
+func newModelLogics(conf *resLogicsConfig, lgc *modelLgc) *modelLogics {
+	return &modelLogics{
+		resLogicsConfig: conf,
+		modelLgc:        lgc,
+	}
+}
+
+// ParseDataArr parse data array to actual type
+func (l *modelLogics) ParseDataArr(_, _ string, data any, rid string) (any, error) {
+	arr, err := convertDataArr[mapstr.MapStr](data, rid)
+	if err != nil {
+		return make([]modelData, 0), err
+	}
+
+	return l.convertToModelDataArr(arr, rid), nil
+}
+
+func (l *modelLogics) convertToModelDataArr(arr []mapstr.MapStr, rid string) []modelData {
+	res := make([]modelData, 0)
+	for _, val := range arr {
+		if len(val) == 0 {
+			continue
+		}
+
+		val, _ = parseMapStr(val, nil, nil)
+		key, err := l.key(val)
+		if err != nil {
+			blog.Errorf("get %s data key failed, skip it, err: %v, data: %+v, rid: %s", l.resType, err, val, rid)
+			continue
+		}
+
+		res = append(res, modelData{
+			Key:  key,
+			Data: val,
+		})
+	}
+	return res
+}
+
+// key returns the key of the model definition
+func (l *modelLogics) key(data mapstr.MapStr) (string, error) {
+	if l.getKey != nil {
+		return l.getKey(data)
+	}
+
+	values := make([]string, len(l.keyFields))
+	for i, field := range l.keyFields {
+		value := commonutil.GetStrByInterface(data[field])
+		if value == "" {
+			return "", fmt.Errorf("key field %s is not set", field)
+		}
+		values[i] = value
+	}
+	return strings.Join(values, "/"), nil
+}
+
+// ListData list data
+func (l *modelLogics) ListData(kit *util.Kit, opt *types.ListDataOpt) (*types.ListDataRes, error) {
+	// generate id condition by start and end options
+	idCond := mapstr.MapStr{common.BKDBGT: 0}
+	if len(opt.Start) > 0 {
+		idCond[common.BKDBGT] = opt.Start[common.BKFieldID]
+	}
+
+	if len(opt.End) > 0 && opt.End[common.BKFieldID] != types.InfiniteEndID {
+		idCond[common.BKDBLTE] = opt.End[common.BKFieldID]
+	}
+
+	cond := l.metadata.AddListCond(l.resType, mapstr.MapStr{
+		common.BKFieldID: idCond,
+	})
+
+	// list data from db
+	dataArr := make([]mapstr.MapStr, 0)
+	err := mongodb.Client().Table(l.table).Find(cond).Sort(common.BKFieldID).Limit(common.BKMaxLimitSize).
+		All(kit.Ctx, &dataArr)
+	if err != nil {
+		blog.Errorf("list %s data failed, err: %v, cond: %+v, rid: %s", l.resType, err, cond, kit.Rid)
+		return nil, err
+	}
+
+	if len(dataArr) == 0 {
+		return &types.ListDataRes{
+			IsAll:     true,
+			Data:      make([]mapstr.MapStr, 0),
+			NextStart: make(map[string]int64),
+		}, nil
+	}
+
+	if l.afterList != nil {
+		if err = l.afterList(kit, l.metadata, dataArr); err != nil {
+			blog.Errorf("handle listed %s data failed, err: %v, rid: %s", l.resType, err, kit.Rid)
+			return nil, err
+		}
+	}
+
+	lastID, err := commonutil.GetInt64ByInterface(dataArr[len(dataArr)-1][common.BKFieldID])
+	if err != nil {
+		blog.Errorf("parse %s data id failed, err: %v, data: %+v, rid: %s", l.resType, err, dataArr[len(dataArr)-1],
+			kit.Rid)
+		return nil, err
+	}
+
+	return &types.ListDataRes{
+		IsAll:     len(dataArr) < common.BKMaxLimitSize,
+		Data:      dataArr,
+		NextStart: map[string]int64{common.BKFieldID: lastID},
+	}, nil
+}
+
+// CompareData compare src data with dest data by key, model definitions are not deleted by full sync, because the
+// destination environment may have its own model definitions, deleted model definitions are synced by incremental sync
+func (l *modelLogics) CompareData(kit *util.Kit, subRes string, srcInfo *types.FullSyncTransData,
+	_ *types.ListDataRes) (*types.CompDataRes, error) {
+
+	srcDataArr, ok := srcInfo.Data.([]modelData)
+	if !ok {
+		return nil, fmt.Errorf("src data type %T is invalid", srcInfo.Data)
+	}
+
+	insertData, updateData, err := l.classifyData(kit, srcDataArr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.CompDataRes{
+		Insert:       insertData,
+		Update:       updateData,
+		Delete:       make([]modelData, 0),
+		RemainingSrc: make([]modelData, 0),
+	}, nil
+}
+
+// CompareAuditData compare src data with dest data by key for sync audit without changing the conflict records, the
+// conflict data is reported as the data to be inserted if it does not exist in destination, otherwise it is reported
+// as the data to be updated. the destination data that does not exist in source is reported as the data to be deleted
+// if the source data contains all model definitions, i.e. the source data interval has neither start nor end
+func (l *modelLogics) CompareAuditData(kit *util.Kit, _ string, srcInfo *types.FullSyncTransData,
+	_ *types.ListDataRes) (*types.CompDataRes, error) {
+
+	srcDataArr, ok := srcInfo.Data.([]modelData)
+	if !ok {
+		return nil, fmt.Errorf("src data type %T is invalid", srcInfo.Data)
+	}
+
+	compRes, err := l.compareSrcData(kit, srcDataArr)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, conflict := range compRes.conflicts {
+		if conflict.destExists {
+			compRes.update = append(compRes.update, conflict.data)
+			continue
+		}
+		compRes.insert = append(compRes.insert, conflict.data)
+	}
+
+	deleteData := make([]modelData, 0)
+	if len(srcInfo.Start) == 0 && len(srcInfo.End) == 0 {
+		deleteData, err = l.listRedundantDestData(kit, srcDataArr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &types.CompDataRes{
+		Insert:       compRes.insert,
+		Update:       compRes.update,
+		Delete:       deleteData,
+		RemainingSrc: make([]modelData, 0),
+	}, nil
+}
+
+// listRedundantDestData list the destination data whose key does not exist in source data, preset data is excluded
+// because it is never deleted by sync
+func (l *modelLogics) listRedundantDestData(kit *util.Kit, srcDataArr []modelData) ([]modelData, error) {
+	srcKeys := make(map[string]struct{}, len(srcDataArr))
+	for _, data := range srcDataArr {
+		srcKeys[data.Key] = struct{}{}
+	}
+
+	redundantData := make([]modelData, 0)
+	opt := &types.ListDataOpt{Start: make(map[string]int64)}
+	for {
+		listRes, err := l.ListData(kit, opt)
+		if err != nil {
+			return nil, err
+		}
+
+		destDataArr, ok := listRes.Data.([]mapstr.MapStr)
+		if !ok {
+			return nil, fmt.Errorf("dest data type %T is invalid", listRes.Data)
+		}
+
+		for _, data := range destDataArr {
+			if isPre, _ := data[common.BKIsPre].(bool); isPre {
+				continue
+			}
+
+			key, err := l.key(data)
+			if err != nil {
+				blog.Errorf("get %s dest data key failed, err: %v, data: %+v, rid: %s", l.resType, err, data, kit.Rid)
+				continue
+			}
+
+			if _, exists := srcKeys[key]; !exists {
+				redundantData = append(redundantData, modelData{Key: key, Data: data})
+			}
+		}
+
+		if listRes.IsAll {
+			return redundantData, nil
+		}
+		opt.Start = listRes.NextStart
+	}
+}
+
+// ClassifyUpsertData classify upsert data into insert and update data
+func (l *modelLogics) ClassifyUpsertData(kit *util.Kit, _ string, upsertData any) (any, any, error) {
+	dataArr, ok := upsertData.([]modelData)
+	if !ok {
+		return nil, nil, fmt.Errorf("upsert data type %T is invalid", upsertData)
+	}
+
+	return l.classifyData(kit, dataArr)
+}
+
+// classifyData classify source data into insert and update data, skip and record the conflict data
+func (l *modelLogics) classifyData(kit *util.Kit, dataArr []modelData) ([]modelData, []modelData, error) {
+	compRes, err := l.compareSrcData(kit, dataArr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, conflict := range compRes.conflicts {
+		if err = l.recordConflict(kit, conflict.data, conflict.reason); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err = l.conflicts.remove(kit, l.resType, compRes.resolvedKeys...); err != nil {
+		return nil, nil, err
+	}
+
+	return compRes.insert, compRes.update, nil
+}
+
+// modelCompRes is the compare result of the source model definitions with the destination data
+type modelCompRes struct {
+	insert []modelData
+	update []modelData
+	// conflicts are the source data that conflicts with the destination data
+	conflicts []modelConflict
+	// resolvedKeys are the keys of the sync data that no longer conflicts with the destination data
+	resolvedKeys []string
+}
+
+type modelConflict struct {
+	data       modelData
+	reason     string
+	destExists bool
+}
+
+// compareSrcData compare source data with the destination data, returns the data to be inserted or updated and the
+// conflict data, this function has no side effects on the destination data and the conflict records
+func (l *modelLogics) compareSrcData(kit *util.Kit, dataArr []modelData) (*modelCompRes, error) {
+	res := &modelCompRes{
+		insert:       make([]modelData, 0),
+		update:       make([]modelData, 0),
+		conflicts:    make([]modelConflict, 0),
+		resolvedKeys: make([]string, 0),
+	}
+	if len(dataArr) == 0 {
+		return res, nil
+	}
+
+	destDataMap, err := l.getDestDataMap(kit, dataArr)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, data := range dataArr {
+		destData, exists := destDataMap[data.Key]
+
+		if l.convertSrc != nil {
+			reason, err := l.convertSrc(kit, data.Data)
+			if err != nil {
+				blog.Errorf("convert %s data(%+v) failed, err: %v, rid: %s", l.resType, data.Data, err, kit.Rid)
+				return nil, err
+			}
+
+			if reason != "" {
+				res.conflicts = append(res.conflicts, modelConflict{data: data, reason: reason, destExists: exists})
+				continue
+			}
+		}
+
+		if !exists {
+			res.insert = append(res.insert, data)
+			res.resolvedKeys = append(res.resolvedKeys, data.Key)
+			continue
+		}
+
+		// do not overwrite the destination data that has diverged from the source data
+		if reason := l.compareImmutableFields(data.Data, destData); reason != "" {
+			res.conflicts = append(res.conflicts, modelConflict{data: data, reason: reason, destExists: true})
+			continue
+		}
+
+		res.resolvedKeys = append(res.resolvedKeys, data.Key)
+		data.Data[common.BKFieldID] = destData[common.BKFieldID]
+		if !isModelDataEqual(data.Data, destData) {
+			res.update = append(res.update, data)
+		}
+	}
+
+	return res, nil
+}
+
+// compareImmutableFields returns the conflict reason if the immutable fields of source data and destination data differ
+func (l *modelLogics) compareImmutableFields(src, dest mapstr.MapStr) string {
+	for _, field := range l.immutableFields {
+		if !isModelValueEqual(src[field], dest[field]) {
+			return fmt.Sprintf("%s value %v is different from destination value %v", field, src[field], dest[field])
+		}
+	}
+	return ""
+}
+
+func (l *modelLogics) recordConflict(kit *util.Kit, data modelData, reason string) error {
+	blog.Errorf("%s data %s conflicts with destination data, skip it, reason: %s, rid: %s", l.resType, data.Key,
+		reason, kit.Rid)
+	return l.conflicts.add(kit, l.resType, data.Key, reason, data.Data)
+}
+
+// getDestDataMap get destination data map of key to data for the source data
+func (l *modelLogics) getDestDataMap(kit *util.Kit, dataArr []modelData) (map[string]mapstr.MapStr, error) {
+	if l.listDest != nil {
+		return l.listDest(kit, l.metadata, dataArr)
+	}
+
+	keyConds := make([]mapstr.MapStr, len(dataArr))
+	for i, data := range dataArr {
+		keyCond := make(mapstr.MapStr)
+		for _, field := range l.keyFields {
+			keyCond[field] = data.Data[field]
+		}
+		keyConds[i] = keyCond
+	}
+
+	cond := l.metadata.AddListCond(l.resType, mapstr.MapStr{common.BKDBOR: keyConds})
+	destDataArr := make([]mapstr.MapStr, 0)
+	if err := mongodb.Client().Table(l.table).Find(cond).All(kit.Ctx, &destDataArr); err != nil {
+		blog.Errorf("list %s dest data failed, err: %v, cond: %+v, rid: %s", l.resType, err, cond, kit.Rid)
+		return nil, err
+	}
+
+	destDataMap := make(map[string]mapstr.MapStr)
+	for _, data := range destDataArr {
+		key, err := l.key(data)
+		if err != nil {
+			blog.Errorf("get %s dest data key failed, err: %v, data: %+v, rid: %s", l.resType, err, data, kit.Rid)
+			continue
+		}
+		destDataMap[key] = data
+	}
+	return destDataMap, nil
+}
+
+// modelIgnoredFields are the fields that are generated separately in each environment
+var modelIgnoredFields = map[string]struct{}{"_id": {}, common.BKFieldID: {}, common.CreateTimeField: {},
+	common.LastTimeField: {}}
+
+// isModelDataEqual checks if the fields of the source data are all equal to the destination data
+func isModelDataEqual(src, dest mapstr.MapStr) bool {
+	for field, value := range src {
+		if _, exists := modelIgnoredFields[field]; exists {
+			continue
+		}
+
+		if !isModelValueEqual(value, dest[field]) {
+			return false
+		}
+	}
+	return true
+}
+
+// isModelValueEqual checks if the source value is equal to the destination value, the values are normalized by json
+// because the source value is decoded from json and the destination value is decoded from db
+func isModelValueEqual(src, dest any) bool {
+	var srcVal, destVal any
+	if err := normalizeModelValue(src, &srcVal); err != nil {
+		return false
+	}
+
+	if err := normalizeModelValue(dest, &destVal); err != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(srcVal, destVal)
+}
+
+func normalizeModelValue(value any, result *any) error {
+	js, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(js, result)
+}
+
+// InsertData insert data
+func (l *modelLogics) InsertData(kit *util.Kit, _ string, data any) error {
+	dataArr, ok := data.([]modelData)
+	if !ok {
+		return fmt.Errorf("data type %T is invalid", data)
+	}
+
+	if len(dataArr) == 0 {
+		return nil
+	}
+
+	// model definition ids are generated by the destination environment
+	ids, err := mongodb.Client().NextSequences(kit.Ctx, l.table, len(dataArr))
+	if err != nil {
+		blog.Errorf("generate %d %s ids failed, err: %v, rid: %s", len(dataArr), l.table, err, kit.Rid)
+		return err
+	}
+
+	now := time.Now()
+	insertData := make([]mapstr.MapStr, len(dataArr))
+	for i, info := range dataArr {
+		info.Data[common.BKFieldID] = ids[i]
+		for _, field := range []string{common.CreateTimeField, common.LastTimeField} {
+			if _, exists := info.Data[field]; exists {
+				info.Data[field] = now
+			}
+		}
+		insertData[i] = info.Data
+	}
+
+	err = mongodb.Client().Table(l.table).Insert(kit.Ctx, insertData)
+	if err != nil && !mongodb.Client().IsDuplicatedError(err) {
+		blog.Errorf("insert %s data(%+v) failed, err: %v, rid: %s", l.table, insertData, err, kit.Rid)
+		return err
+	}
+	return nil
+}
+
+// UpdateData update data
+func (l *modelLogics) UpdateData(kit *util.Kit, _ string, data any) error {
+	dataArr, ok := data.([]modelData)
+	if !ok {
+		return fmt.Errorf("data type %T is invalid", data)
+	}
+
+	for _, info := range dataArr {
+		cond := mapstr.MapStr{common.BKFieldID: info.Data[common.BKFieldID]}
+
+		updateData := make(mapstr.MapStr)
+		for field, value := range info.Data {
+			if _, exists := modelIgnoredFields[field]; !exists {
+				updateData[field] = value
+			}
+		}
+		if _, exists := info.Data[common.LastTimeField]; exists {
+			updateData[common.LastTimeField] = time.Now()
+		}
+
+		err := mongodb.Client().Table(l.table).Update(kit.Ctx, cond, updateData)
+		if err != nil {
+			blog.Errorf("update %s data(%+v) failed, err: %v, rid: %s", l.table, info, err, kit.Rid)
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteData delete data, preset model definitions are not deleted
+func (l *modelLogics) DeleteData(kit *util.Kit, _ string, data any) error {
+	dataArr, ok := data.([]modelData)
+	if !ok {
+		return fmt.Errorf("data type %T is invalid", data)
+	}
+
+	if len(dataArr) == 0 {
+		return nil
+	}
+
+	destDataMap, err := l.getDestDataMap(kit, dataArr)
+	if err != nil {
+		return err
+	}
+
+	ids, keys := make([]interface{}, 0), make([]string, 0)
+	delDataArr := make([]mapstr.MapStr, 0)
+	for _, info := range dataArr {
+		keys = append(keys, info.Key)
+		destData, exists := destDataMap[info.Key]
+		if !exists {
+			continue
+		}
+
+		if isPre, _ := destData[common.BKIsPre].(bool); isPre {
+			blog.Errorf("%s data %s is preset in destination, skip deleting it, rid: %s", l.resType, info.Key, kit.Rid)
+			continue
+		}
+
+		ids = append(ids, destData[common.BKFieldID])
+		delDataArr = append(delDataArr, destData)
+	}
+
+	if err = l.conflicts.remove(kit, l.resType, keys...); err != nil {
+		return err
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if l.beforeDelete != nil {
+		if err = l.beforeDelete(kit, delDataArr); err != nil {
+			blog.Errorf("handle %s data to be deleted failed, err: %v, rid: %s", l.table, err, kit.Rid)
+			return err
+		}
+	}
+
+	cond := mapstr.MapStr{common.BKFieldID: mapstr.MapStr{common.BKDBIN: ids}}
+	if err = mongodb.Client().Table(l.table).Delete(kit.Ctx, cond); err != nil {
+		blog.Errorf("delete %s data failed, err: %v, cond: %+v, rid: %s", l.table, err, cond, kit.Rid)
+		return err
+	}
+	return nil
+}
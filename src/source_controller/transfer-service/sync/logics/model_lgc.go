@@ -0,0 +1,318 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package logics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	ccmetadata "configcenter/src/common/metadata"
+	commonutil "configcenter/src/common/util"
+	"configcenter/src/source_controller/transfer-service/sync/metadata"
+	"configcenter/src/source_controller/transfer-service/sync/util"
+	"configcenter/src/storage/driver/mongodb"
+	"configcenter/src/storage/driver/mongodb/instancemapping"
+)
+
+var classificationLgc = &modelLgc{
+	table:     common.BKTableNameObjClassification,
+	keyFields: []string{common.BKClassificationIDField},
+}
+
+var asstKindLgc = &modelLgc{
+	table:     common.BKTableNameAsstDes,
+	keyFields: []string{common.AssociationKindIDField},
+}
+
+var objLgc = &modelLgc{
+	table:     common.BKTableNameObjDes,
+	keyFields: []string{common.BKObjIDField},
+	convertSrc: func(kit *util.Kit, data mapstr.MapStr) (string, error) {
+		return checkModelDataExists(kit, common.BKTableNameObjClassification,
+			mapstr.MapStr{common.BKClassificationIDField: data[common.BKClassificationIDField]})
+	},
+	beforeDelete: func(kit *util.Kit, dataArr []mapstr.MapStr) error {
+		objIDs := make([]string, len(dataArr))
+		for i, data := range dataArr {
+			objIDs[i] = commonutil.GetStrByInterface(data[common.BKObjIDField])
+		}
+		return deleteObjCascadeData(kit, objIDs)
+	},
+}
+
+var attrGroupLgc = &modelLgc{
+	table:     common.BKTableNamePropertyGroup,
+	keyFields: []string{common.BKObjIDField, common.BKPropertyGroupIDField},
+	convertSrc: func(kit *util.Kit, data mapstr.MapStr) (string, error) {
+		return checkModelDataExists(kit, common.BKTableNameObjDes,
+			mapstr.MapStr{common.BKObjIDField: data[common.BKObjIDField]})
+	},
+}
+
+var attrLgc = &modelLgc{
+	table:           common.BKTableNameObjAttDes,
+	keyFields:       []string{common.BKObjIDField, common.BKPropertyIDField},
+	immutableFields: []string{common.BKPropertyTypeField},
+	convertSrc: func(kit *util.Kit, data mapstr.MapStr) (string, error) {
+		// table attribute is stored in a separate table that is not synchronized
+		if data[common.BKPropertyTypeField] == common.FieldTypeInnerTable {
+			return "inner table attribute is not supported", nil
+		}
+
+		// field template is not synchronized, so the attribute is not related to field template in destination
+		data[common.BKTemplateID] = 0
+		return checkModelDataExists(kit, common.BKTableNameObjDes,
+			mapstr.MapStr{common.BKObjIDField: data[common.BKObjIDField]})
+	},
+}
+
+var uniqueLgc = &modelLgc{
+	table:  common.BKTableNameObjUnique,
+	getKey: getUniqueKey,
+	listDest: func(kit *util.Kit, meta *metadata.Metadata, dataArr []modelData) (map[string]mapstr.MapStr, error) {
+		objIDs := make([]string, 0)
+		for _, data := range dataArr {
+			objIDs = append(objIDs, commonutil.GetStrByInterface(data.Data[common.BKObjIDField]))
+		}
+
+		cond := mapstr.MapStr{common.BKObjIDField: mapstr.MapStr{common.BKDBIN: commonutil.StrArrayUnique(objIDs)}}
+		destDataArr := make([]mapstr.MapStr, 0)
+		if err := mongodb.Client().Table(common.BKTableNameObjUnique).Find(cond).All(kit.Ctx, &destDataArr); err != nil {
+			blog.Errorf("list dest unique rules failed, err: %v, cond: %+v, rid: %s", err, cond, kit.Rid)
+			return nil, err
+		}
+
+		// fill the property ids of the unique rule keys to generate the key, then restore the original keys
+		originKeys := make([]any, len(destDataArr))
+		for i, data := range destDataArr {
+			originKeys[i] = data[common.BKObjectUniqueKeys]
+		}
+
+		if err := meta.FillUniqueKeyPropertyIDs(kit.Ctx, destDataArr); err != nil {
+			blog.Errorf("fill dest unique rule key property ids failed, err: %v, rid: %s", err, kit.Rid)
+			return nil, err
+		}
+
+		destDataMap := make(map[string]mapstr.MapStr)
+		for i, data := range destDataArr {
+			key, err := getUniqueKey(data)
+			data[common.BKObjectUniqueKeys] = originKeys[i]
+			if err != nil {
+				blog.Errorf("get dest unique rule(%+v) key failed, err: %v, rid: %s", data, err, kit.Rid)
+				continue
+			}
+			destDataMap[key] = data
+		}
+		return destDataMap, nil
+	},
+	afterList: func(kit *util.Kit, meta *metadata.Metadata, dataArr []mapstr.MapStr) error {
+		return meta.FillUniqueKeyPropertyIDs(kit.Ctx, dataArr)
+	},
+	convertSrc: convertSrcUnique,
+}
+
+var objAsstLgc = &modelLgc{
+	table:     common.BKTableNameObjAsst,
+	keyFields: []string{common.AssociationObjAsstIDField},
+	immutableFields: []string{common.BKObjIDField, common.BKAsstObjIDField, common.AssociationKindIDField,
+		"mapping"},
+	convertSrc: func(kit *util.Kit, data mapstr.MapStr) (string, error) {
+		reason, err := checkModelDataExists(kit, common.BKTableNameAsstDes,
+			mapstr.MapStr{common.AssociationKindIDField: data[common.AssociationKindIDField]})
+		if err != nil || reason != "" {
+			return reason, err
+		}
+
+		for _, field := range []string{common.BKObjIDField, common.BKAsstObjIDField} {
+			reason, err = checkModelDataExists(kit, common.BKTableNameObjDes,
+				mapstr.MapStr{common.BKObjIDField: data[field]})
+			if err != nil || reason != "" {
+				return reason, err
+			}
+		}
+		return "", nil
+	},
+}
+
+// checkModelDataExists check if the model definition that the sync data depends on exists in destination, returns
+// the conflict reason if it does not exist
+func checkModelDataExists(kit *util.Kit, table string, cond mapstr.MapStr) (string, error) {
+	cnt, err := mongodb.Client().Table(table).Find(cond).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("count %s data failed, err: %v, cond: %+v, rid: %s", table, err, cond, kit.Rid)
+		return "", err
+	}
+
+	if cnt == 0 {
+		return fmt.Sprintf("dependent %s data %+v does not exist", table, cond), nil
+	}
+	return "", nil
+}
+
+// getUniqueKey get unique rule key by object id and sorted key property ids
+func getUniqueKey(data mapstr.MapStr) (string, error) {
+	objID := commonutil.GetStrByInterface(data[common.BKObjIDField])
+	if objID == "" {
+		return "", fmt.Errorf("%s is not set", common.BKObjIDField)
+	}
+
+	keys, err := metadata.ParseUniqueKeys(data)
+	if err != nil {
+		return "", err
+	}
+
+	if len(keys) == 0 {
+		return "", fmt.Errorf("unique rule has no keys")
+	}
+
+	propertyIDs := make([]string, len(keys))
+	for i, key := range keys {
+		if key.PropertyID == "" {
+			return "", fmt.Errorf("unique rule key attribute %d is invalid", key.ID)
+		}
+		propertyIDs[i] = key.PropertyID
+	}
+	sort.Strings(propertyIDs)
+
+	return objID + "/" + strings.Join(propertyIDs, ","), nil
+}
+
+// convertSrcUnique convert the source unique rule key attribute ids to the destination attribute ids
+func convertSrcUnique(kit *util.Kit, data mapstr.MapStr) (string, error) {
+	// field template is not synchronized, so the unique rule is not related to field template in destination
+	data[common.BKTemplateID] = 0
+
+	keys, err := metadata.ParseUniqueKeys(data)
+	if err != nil {
+		return "", err
+	}
+
+	propertyIDs := make([]string, len(keys))
+	for i, key := range keys {
+		propertyIDs[i] = key.PropertyID
+	}
+
+	cond := mapstr.MapStr{
+		common.BKObjIDField:      data[common.BKObjIDField],
+		common.BKPropertyIDField: mapstr.MapStr{common.BKDBIN: propertyIDs},
+		common.BKAppIDField:      mapstr.MapStr{common.BKDBIN: []interface{}{0, nil}},
+	}
+
+	attrs := make([]ccmetadata.Attribute, 0)
+	err = mongodb.Client().Table(common.BKTableNameObjAttDes).Find(cond).
+		Fields(common.BKFieldID, common.BKPropertyIDField).All(kit.Ctx, &attrs)
+	if err != nil {
+		blog.Errorf("get unique rule key attributes failed, err: %v, cond: %+v, rid: %s", err, cond, kit.Rid)
+		return "", err
+	}
+
+	propertyAttrIDMap := make(map[string]int64)
+	for _, attr := range attrs {
+		propertyAttrIDMap[attr.PropertyID] = attr.ID
+	}
+
+	destKeys := make([]ccmetadata.UniqueKey, len(keys))
+	for i, key := range keys {
+		attrID, exists := propertyAttrIDMap[key.PropertyID]
+		if !exists {
+			return fmt.Sprintf("unique rule key attribute %s does not exist", key.PropertyID), nil
+		}
+
+		destKeys[i] = ccmetadata.UniqueKey{
+			Kind: key.Kind,
+			ID:   uint64(attrID),
+		}
+	}
+	data[common.BKObjectUniqueKeys] = destKeys
+	return "", nil
+}
+
+// objCascadeTables are the tables of the model definitions that belong to the object, they are deleted with the object
+var objCascadeTables = []string{common.BKTableNamePropertyGroup, common.BKTableNameObjAttDes,
+	common.BKTableNameObjUnique}
+
+// deleteObjCascadeData delete the instances, attribute groups, attributes and unique rules of the objects, like the
+// model deletion of the core service does. the empty sharding tables are dropped by the admin server's periodic model
+// sharding table synchronization
+func deleteObjCascadeData(kit *util.Kit, objIDs []string) error {
+	for _, objID := range objIDs {
+		if err := deleteObjInstances(kit, objID); err != nil {
+			return err
+		}
+	}
+
+	cond := mapstr.MapStr{common.BKObjIDField: mapstr.MapStr{common.BKDBIN: objIDs}}
+	for _, table := range objCascadeTables {
+		if err := mongodb.Client().Table(table).Delete(kit.Ctx, cond); err != nil {
+			blog.Errorf("delete %s data failed, err: %v, cond: %+v, rid: %s", table, err, cond, kit.Rid)
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteObjInstances delete the instances and instance associations of the deleted object. the object can only be
+// deleted without instances in source environment, but the instance deletions are synchronized separately and may
+// not be applied yet when the object deletion is synchronized, so they are deleted here
+func deleteObjInstances(kit *util.Kit, objID string) error {
+	instTable := common.GetObjectInstTableName(objID, common.BKDefaultOwnerID)
+	for {
+		insts := make([]mapstr.MapStr, 0)
+		err := mongodb.Client().Table(instTable).Find(nil).Fields(common.BKInstIDField).
+			Limit(common.BKMaxDeletePageSize).All(kit.Ctx, &insts)
+		if err != nil {
+			blog.Errorf("list %s instance ids failed, err: %v, rid: %s", instTable, err, kit.Rid)
+			return err
+		}
+
+		if len(insts) == 0 {
+			break
+		}
+
+		ids := make([]int64, 0)
+		for _, inst := range insts {
+			id, err := commonutil.GetInt64ByInterface(inst[common.BKInstIDField])
+			if err != nil {
+				blog.Errorf("parse %s instance id failed, err: %v, inst: %+v, rid: %s", instTable, err, inst, kit.Rid)
+				return err
+			}
+			ids = append(ids, id)
+		}
+
+		if err = instancemapping.Delete(kit.Ctx, ids); err != nil {
+			blog.Errorf("delete object instance mapping failed, err: %v, inst ids: %+v, rid: %s", err, ids, kit.Rid)
+			return err
+		}
+
+		cond := mapstr.MapStr{common.BKInstIDField: mapstr.MapStr{common.BKDBIN: ids}}
+		if err = mongodb.Client().Table(instTable).Delete(kit.Ctx, cond); err != nil {
+			blog.Errorf("delete %s data failed, err: %v, cond: %+v, rid: %s", instTable, err, cond, kit.Rid)
+			return err
+		}
+	}
+
+	asstTable := common.GetObjectInstAsstTableName(objID, common.BKDefaultOwnerID)
+	if err := mongodb.Client().Table(asstTable).Delete(kit.Ctx, mapstr.MapStr{}); err != nil {
+		blog.Errorf("delete %s data failed, err: %v, rid: %s", asstTable, err, kit.Rid)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package logics
+
+import (
+	"fmt"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/source_controller/transfer-service/sync/util"
+	"configcenter/src/storage/dal/mongo/memory"
+	"configcenter/src/storage/driver/mongodb"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetUniqueKey(t *testing.T) {
+	// the key property ids are sorted so that the key is irrelevant to the key order
+	key, err := getUniqueKey(mapstr.MapStr{
+		common.BKObjIDField: "switch",
+		common.BKObjectUniqueKeys: []mapstr.MapStr{
+			{"key_kind": "property", "key_id": 3, common.BKPropertyIDField: "sn"},
+			{"key_kind": "property", "key_id": 2, common.BKPropertyIDField: "name"},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "switch/name,sn", key)
+
+	// object id is not set
+	_, err = getUniqueKey(mapstr.MapStr{
+		common.BKObjectUniqueKeys: []mapstr.MapStr{{"key_kind": "property", "key_id": 2,
+			common.BKPropertyIDField: "name"}},
+	})
+	require.Error(t, err)
+
+	// unique rule has no keys
+	_, err = getUniqueKey(mapstr.MapStr{common.BKObjIDField: "switch"})
+	require.Error(t, err)
+
+	// key property id is not filled
+	_, err = getUniqueKey(mapstr.MapStr{
+		common.BKObjIDField:       "switch",
+		common.BKObjectUniqueKeys: []mapstr.MapStr{{"key_kind": "property", "key_id": 2}},
+	})
+	require.Error(t, err)
+
+	// keys are invalid
+	_, err = getUniqueKey(mapstr.MapStr{common.BKObjIDField: "switch", common.BKObjectUniqueKeys: "invalid"})
+	require.Error(t, err)
+}
+
+func TestAttrConvertSrc(t *testing.T) {
+	// inner table attribute conflicts without checking the destination data
+	reason, err := attrLgc.convertSrc(nil, mapstr.MapStr{
+		common.BKObjIDField:        "switch",
+		common.BKPropertyIDField:   "table",
+		common.BKPropertyTypeField: common.FieldTypeInnerTable,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, reason)
+}
+
+func TestDeleteObjCascadeData(t *testing.T) {
+	db := memory.New()
+	mongodb.SetClient("", db)
+	kit := util.NewKit()
+
+	// the instance deletions of the object are not synchronized yet when the object is deleted
+	instTable := common.GetObjectInstTableName("switch", common.BKDefaultOwnerID)
+	insts := make([]interface{}, common.BKMaxDeletePageSize+1)
+	mappings := make([]interface{}, len(insts))
+	for i := range insts {
+		insts[i] = mapstr.MapStr{common.BKInstIDField: i + 1, common.BKObjIDField: "switch",
+			common.BKInstNameField: fmt.Sprintf("switch-%d", i)}
+		mappings[i] = mapstr.MapStr{common.BKInstIDField: i + 1, common.BKObjIDField: "switch"}
+	}
+	require.NoError(t, db.Table(instTable).Insert(kit.Ctx, insts))
+	require.NoError(t, db.Table("cc_ObjectBaseMapping").Insert(kit.Ctx, mappings))
+
+	asstTable := common.GetObjectInstAsstTableName("switch", common.BKDefaultOwnerID)
+	require.NoError(t, db.Table(asstTable).Insert(kit.Ctx, mapstr.MapStr{common.BKFieldID: 1,
+		common.BKObjIDField: "switch", common.BKInstIDField: 1}))
+
+	for _, objID := range []string{"switch", "router"} {
+		require.NoError(t, db.Table(common.BKTableNameObjAttDes).Insert(kit.Ctx,
+			mapstr.MapStr{common.BKObjIDField: objID, common.BKPropertyIDField: "name"}))
+	}
+
+	require.NoError(t, deleteObjCascadeData(kit, []string{"switch"}))
+
+	for _, table := range []string{instTable, asstTable, "cc_ObjectBaseMapping"} {
+		cnt, err := db.Table(table).Find(nil).Count(kit.Ctx)
+		require.NoError(t, err)
+		require.Zero(t, cnt, table)
+	}
+
+	// the model definitions of the other objects are not deleted
+	attrs := make([]mapstr.MapStr, 0)
+	require.NoError(t, db.Table(common.BKTableNameObjAttDes).Find(nil).All(kit.Ctx, &attrs))
+	require.Len(t, attrs, 1)
+	require.Equal(t, "router", attrs[0][common.BKObjIDField])
+}
@@ -0,0 +1,182 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package logics
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"configcenter/pkg/synchronize/types"
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/source_controller/transfer-service/sync/metadata"
+	"configcenter/src/source_controller/transfer-service/sync/util"
+	"configcenter/src/storage/dal/mongo/memory"
+	"configcenter/src/storage/driver/mongodb"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestModelLogicsKey(t *testing.T) {
+	objLogics := newModelLogics(&resLogicsConfig{resType: types.Model}, objLgc)
+	key, err := objLogics.key(mapstr.MapStr{common.BKObjIDField: "switch"})
+	require.NoError(t, err)
+	require.Equal(t, "switch", key)
+
+	attrLogics := newModelLogics(&resLogicsConfig{resType: types.ModelAttribute}, attrLgc)
+	key, err = attrLogics.key(mapstr.MapStr{common.BKObjIDField: "switch", common.BKPropertyIDField: "name"})
+	require.NoError(t, err)
+	require.Equal(t, "switch/name", key)
+
+	_, err = attrLogics.key(mapstr.MapStr{common.BKObjIDField: "switch"})
+	require.Error(t, err)
+
+	// unique rule key is generated by the key property ids
+	uniqueLogics := newModelLogics(&resLogicsConfig{resType: types.ModelUnique}, uniqueLgc)
+	key, err = uniqueLogics.key(mapstr.MapStr{
+		common.BKObjIDField: "switch",
+		common.BKObjectUniqueKeys: []mapstr.MapStr{
+			{"key_kind": "property", "key_id": 2, common.BKPropertyIDField: "sn"},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "switch/sn", key)
+}
+
+func TestModelLogicsParseDataArr(t *testing.T) {
+	lgc := newModelLogics(&resLogicsConfig{resType: types.ModelAttribute}, attrLgc)
+	raws := []json.RawMessage{
+		json.RawMessage(`{"_id":"abc","id":1,"bk_obj_id":"switch","bk_property_id":"name","placeholder":"a"}`),
+		json.RawMessage(`{}`),
+		json.RawMessage(`{"id":2,"bk_obj_id":"switch"}`),
+		json.RawMessage(`{"id":3,"bk_obj_id":"switch","bk_property_id":"sn","bk_property_index":1.5}`),
+	}
+
+	res, err := lgc.ParseDataArr("", "", raws, "")
+	require.NoError(t, err)
+
+	// empty data and data without key are skipped
+	dataArr, ok := res.([]modelData)
+	require.True(t, ok)
+	require.Len(t, dataArr, 2)
+
+	require.Equal(t, "switch/name", dataArr[0].Key)
+	require.Equal(t, int64(1), dataArr[0].Data[common.BKFieldID])
+	require.NotContains(t, dataArr[0].Data, "_id")
+
+	require.Equal(t, "switch/sn", dataArr[1].Key)
+	require.Equal(t, 1.5, dataArr[1].Data["bk_property_index"])
+
+	_, err = lgc.ParseDataArr("", "", "invalid", "")
+	require.Error(t, err)
+}
+
+func TestIsModelDataEqual(t *testing.T) {
+	src := mapstr.MapStr{
+		common.BKFieldID:            int64(1),
+		common.BKObjIDField:         "switch",
+		common.BKObjectUniqueKeys:   []mapstr.MapStr{{"key_kind": "property", "key_id": json.Number("2")}},
+		common.LastTimeField:        "2024-01-01T00:00:00Z",
+		common.BKPropertyIndexField: int64(1),
+	}
+	dest := mapstr.MapStr{
+		common.BKFieldID:            int64(10),
+		common.BKObjIDField:         "switch",
+		common.BKObjectUniqueKeys:   []interface{}{map[string]interface{}{"key_kind": "property", "key_id": int32(2)}},
+		common.LastTimeField:        time.Now(),
+		common.BKPropertyIndexField: float64(1),
+		"extra":                     "dest only",
+	}
+
+	// ids and times are generated in each environment, values are compared after normalized by json
+	require.True(t, isModelDataEqual(src, dest))
+
+	dest[common.BKObjectUniqueKeys] = []interface{}{map[string]interface{}{"key_kind": "property", "key_id": 3}}
+	require.False(t, isModelDataEqual(src, dest))
+
+	dest[common.BKObjectUniqueKeys] = src[common.BKObjectUniqueKeys]
+	delete(dest, common.BKObjIDField)
+	require.False(t, isModelDataEqual(src, dest))
+}
+
+func TestCompareImmutableFields(t *testing.T) {
+	lgc := newModelLogics(&resLogicsConfig{resType: types.ModelAttribute}, attrLgc)
+	src := mapstr.MapStr{common.BKPropertyTypeField: common.FieldTypeSingleChar, common.BKPropertyNameField: "a"}
+
+	dest := mapstr.MapStr{common.BKPropertyTypeField: common.FieldTypeSingleChar, common.BKPropertyNameField: "b"}
+	require.Empty(t, lgc.compareImmutableFields(src, dest))
+
+	dest[common.BKPropertyTypeField] = common.FieldTypeInt
+	require.NotEmpty(t, lgc.compareImmutableFields(src, dest))
+
+	// model definitions without immutable fields never conflict with the destination data
+	objLogics := newModelLogics(&resLogicsConfig{resType: types.Model}, objLgc)
+	require.Empty(t, objLogics.compareImmutableFields(src, dest))
+}
+
+func TestModelLogicsCompareAuditData(t *testing.T) {
+	db := memory.New()
+	mongodb.SetClient("", db)
+	kit := util.NewKit()
+
+	require.NoError(t, db.Table(common.BKTableNameObjClassification).Insert(kit.Ctx,
+		mapstr.MapStr{common.BKFieldID: 1, common.BKClassificationIDField: "net"}))
+	destObjs := []interface{}{
+		mapstr.MapStr{common.BKFieldID: 1, common.BKObjIDField: "switch", common.BKClassificationIDField: "net"},
+		mapstr.MapStr{common.BKFieldID: 2, common.BKObjIDField: "router", common.BKClassificationIDField: "net"},
+		mapstr.MapStr{common.BKFieldID: 3, common.BKObjIDField: "hub", common.BKClassificationIDField: "net"},
+		mapstr.MapStr{common.BKFieldID: 4, common.BKObjIDField: "host", common.BKClassificationIDField: "net",
+			common.BKIsPre: true},
+	}
+	require.NoError(t, db.Table(common.BKTableNameObjDes).Insert(kit.Ctx, destObjs))
+
+	lgc := newModelLogics(&resLogicsConfig{resType: types.Model, metadata: new(metadata.Metadata),
+		conflicts: NewConflictRecorder()}, objLgc)
+	srcData := []modelData{
+		{Key: "switch", Data: mapstr.MapStr{common.BKObjIDField: "switch", common.BKClassificationIDField: "net"}},
+		{Key: "server", Data: mapstr.MapStr{common.BKObjIDField: "server", common.BKClassificationIDField: "net"}},
+		// the classifications of these models do not exist in destination, so they conflict with destination
+		{Key: "router", Data: mapstr.MapStr{common.BKObjIDField: "router", common.BKClassificationIDField: "x"}},
+		{Key: "firewall", Data: mapstr.MapStr{common.BKObjIDField: "firewall", common.BKClassificationIDField: "x"}},
+	}
+
+	srcInfo := &types.FullSyncTransData{Start: make(map[string]int64), End: make(map[string]int64), Data: srcData}
+	res, err := lgc.CompareAuditData(kit, "", srcInfo, nil)
+	require.NoError(t, err)
+
+	keys := func(data any) []string {
+		keys, err := GetCompDataKeys(data)
+		require.NoError(t, err)
+		return keys
+	}
+	require.ElementsMatch(t, []string{"server", "firewall"}, keys(res.Insert))
+	require.ElementsMatch(t, []string{"router"}, keys(res.Update))
+	// the preset model that does not exist in source is not reported
+	require.ElementsMatch(t, []string{"hub"}, keys(res.Delete))
+
+	// sync audit does not record the conflicts
+	cnt, err := db.Table(common.BKTableNameSyncDataConflict).Find(nil).Count(kit.Ctx)
+	require.NoError(t, err)
+	require.Zero(t, cnt)
+
+	// the redundant destination data is not reported for a part of source data
+	srcInfo.Start = map[string]int64{common.BKFieldID: 1}
+	res, err = lgc.CompareAuditData(kit, "", srcInfo, nil)
+	require.NoError(t, err)
+	require.Empty(t, keys(res.Delete))
+}
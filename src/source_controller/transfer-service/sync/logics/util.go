@@ -19,6 +19,7 @@ package logics
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
 
 	"configcenter/pkg/synchronize/types"
 	"configcenter/src/common"
@@ -96,3 +97,71 @@ func getObjResType(objID string) types.ResType {
 		return types.ObjectInstance
 	}
 }
+
+// compDataKeyInfo is used to parse the key of the compare data, which can be DataWithID, RelationData or modelData
+type compDataKeyInfo struct {
+	ID  *int64    `json:"ID"`
+	IDs *[2]int64 `json:"IDs"`
+	Key *string   `json:"Key"`
+}
+
+// GetCompDataKeys get the unique keys of the data in compare data result or parsed data array, the keys are used to
+// identify the data in sync audit report, relation data key is the two associated ids joined by "-"
+func GetCompDataKeys(data any) ([]string, error) {
+	if data == nil {
+		return make([]string, 0), nil
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// relation data delete info is a map of the first id to the second ids
+	if len(raw) > 0 && raw[0] == '{' {
+		idMap := make(map[int64][]int64)
+		if err = json.Unmarshal(raw, &idMap); err != nil {
+			return nil, err
+		}
+
+		keys := make([]string, 0)
+		for id, relIDs := range idMap {
+			for _, relID := range relIDs {
+				keys = append(keys, fmt.Sprintf("%d-%d", id, relID))
+			}
+		}
+		return keys, nil
+	}
+
+	rawArr := make([]json.RawMessage, 0)
+	if err = json.Unmarshal(raw, &rawArr); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, len(rawArr))
+	for i, rawData := range rawArr {
+		// data id array
+		if len(rawData) > 0 && rawData[0] != '{' {
+			keys[i] = string(rawData)
+			continue
+		}
+
+		keyInfo := new(compDataKeyInfo)
+		if err = json.Unmarshal(rawData, keyInfo); err != nil {
+			return nil, err
+		}
+
+		switch {
+		case keyInfo.IDs != nil:
+			keys[i] = fmt.Sprintf("%d-%d", keyInfo.IDs[0], keyInfo.IDs[1])
+		case keyInfo.ID != nil:
+			keys[i] = strconv.FormatInt(*keyInfo.ID, 10)
+		case keyInfo.Key != nil:
+			keys[i] = *keyInfo.Key
+		default:
+			return nil, fmt.Errorf("data %s has no key", rawData)
+		}
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,194 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package medium
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"configcenter/pkg/synchronize/types"
+	"configcenter/src/common/blog"
+)
+
+const (
+	// bundleFileSuffix is the suffix of the bundle file, bundle file is a gzip compressed json lines file, the first
+	// line is the bundle manifest, the other lines are the sync data messages
+	bundleFileSuffix = ".bundle"
+	// bundleSignSuffix is the suffix of the bundle signature file, which stores the hex encoded hmac-sha256 signature
+	// of the bundle file, the signature file must be transferred after the bundle file
+	bundleSignSuffix = ".sig"
+	// bundleSpoolDir is the directory that stores the messages that are not flushed into bundle yet
+	bundleSpoolDir = ".spool"
+	// bundleDoneDir is the directory that stores the bundles that are all consumed and acknowledged
+	bundleDoneDir = "done"
+	// bundleCorruptDir is the directory that stores the bundles that can not be verified or decoded
+	bundleCorruptDir = "corrupt"
+	// bundleSeqSuffix is the suffix of the file in the spool directory that stores the sequence of the last bundle
+	bundleSeqSuffix = ".seq"
+	// bundleCursorFile is the file that stores the consume cursor of all queues
+	bundleCursorFile = ".cursor.json"
+	// bundleVersion is the current bundle format version
+	bundleVersion = 1
+
+	defaultBundleMaxMessages   = 1000
+	defaultBundleFlushInterval = time.Minute
+)
+
+// BundleOption is the offline file bundle transfer medium option
+type BundleOption struct {
+	// Name is the transfer service name, used as the prefix of the bundle file name
+	Name string
+	// Dir is the bundle directory
+	Dir string
+	// SignKey is the key used to sign and verify the bundles
+	SignKey string
+	// MaxMessages is the max message count of one bundle
+	MaxMessages int
+	// FlushInterval is the interval to flush pending messages into a bundle
+	FlushInterval time.Duration
+}
+
+// NewBundleMedium new offline file bundle transfer medium client, source cmdb pushes sync data into signed and
+// compressed bundle files, destination cmdb replays the bundles transferred from the source environment in order
+func NewBundleMedium(opt *BundleOption) (ClientI, error) {
+	if opt == nil || opt.Dir == "" || opt.SignKey == "" {
+		return nil, errors.New("bundle dir or sign key is not set")
+	}
+
+	if opt.MaxMessages <= 0 {
+		opt.MaxMessages = defaultBundleMaxMessages
+	}
+
+	if opt.FlushInterval <= 0 {
+		opt.FlushInterval = defaultBundleFlushInterval
+	}
+
+	dirs := []string{opt.Dir, filepath.Join(opt.Dir, bundleSpoolDir), filepath.Join(opt.Dir, bundleDoneDir),
+		filepath.Join(opt.Dir, bundleCorruptDir)}
+	for _, dir := range dirs {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			blog.Errorf("create bundle dir %s failed, err: %v", dir, err)
+			return nil, err
+		}
+	}
+
+	writer, err := newBundleWriter(opt)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := newBundleReader(opt)
+	if err != nil {
+		return nil, err
+	}
+
+	go writer.loopFlush(opt.FlushInterval)
+
+	return &bundleMediumCli{writer: writer, reader: reader}, nil
+}
+
+// bundleMediumCli defines offline file bundle transfer medium client
+type bundleMediumCli struct {
+	writer *bundleWriter
+	reader *bundleReader
+}
+
+// Close stops flushing pending messages periodically and flush them into bundle
+func (b *bundleMediumCli) Close() error {
+	return b.writer.stop()
+}
+
+// PushSyncData push sync data into bundle
+func (b *bundleMediumCli) PushSyncData(_ context.Context, _ http.Header, opt *types.PushSyncDataOpt) error {
+	data, err := json.Marshal(opt.Data)
+	if err != nil {
+		return fmt.Errorf("marshal sync data failed, err: %v", err)
+	}
+
+	msg := &bundleMessage{
+		ResType:     opt.ResType,
+		SubRes:      opt.SubRes,
+		IsIncrement: opt.IsIncrement,
+		Data:        data,
+	}
+	return b.writer.push(msg)
+}
+
+// PullSyncData pull sync data from bundles
+func (b *bundleMediumCli) PullSyncData(_ context.Context, _ http.Header, opt *types.PullSyncDataOpt) (
+	*types.PullSyncDataRes, error) {
+
+	return b.reader.pull(opt)
+}
+
+// bundleManifest is the manifest of the bundle, which is the first line of the bundle
+type bundleManifest struct {
+	Version    int       `json:"version"`
+	Name       string    `json:"name"`
+	Count      int       `json:"count"`
+	CreateTime time.Time `json:"create_time"`
+}
+
+// bundleMessage is one sync data message in the bundle
+type bundleMessage struct {
+	ResType     types.ResType   `json:"resource_type"`
+	SubRes      string          `json:"sub_resource"`
+	IsIncrement bool            `json:"is_increment"`
+	Data        json.RawMessage `json:"data"`
+}
+
+// queueKey returns the key of the abstract message queue that the message belongs to
+func queueKey(resType types.ResType, subRes string, isIncrement bool) string {
+	return fmt.Sprintf("%s:%s:%t", resType, subRes, isIncrement)
+}
+
+// signBundle returns the hex encoded hmac-sha256 signature of the bundle data
+func signBundle(key []byte, data []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifyBundle verify the signature of the bundle data
+func verifyBundle(key []byte, data []byte, sign string) bool {
+	expected, err := hex.DecodeString(sign)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// writeFileAtomic write file by renaming a temporary file, so that the file is never partially written
+func writeFileAtomic(path string, data []byte) error {
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
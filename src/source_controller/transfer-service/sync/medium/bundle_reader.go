@@ -0,0 +1,511 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package medium
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"configcenter/pkg/synchronize/types"
+	"configcenter/src/common/blog"
+	types2 "configcenter/src/common/types"
+	"configcenter/src/common/util"
+	"configcenter/src/thirdparty/monitor"
+	"configcenter/src/thirdparty/monitor/meta"
+)
+
+// maxCachedBundles is the max count of the decoded bundles that are cached in memory
+const maxCachedBundles = 5
+
+// bundleReader replays the sync data messages in the bundles in order
+type bundleReader struct {
+	lock       sync.Mutex
+	dir        string
+	cursorPath string
+	key        []byte
+	// cursors is the map of queue key to the consume cursor of the queue, it is persisted after each pull
+	cursors map[string]*bundleCursor
+	// counts is the map of bundle name to the message count of each queue in the bundle
+	counts map[string]map[string]int
+	// cache is the map of bundle name to the messages of each queue in the bundle
+	cache      map[string]map[string][]json.RawMessage
+	cacheOrder []string
+}
+
+// bundleCursor is the consume cursor of one queue
+type bundleCursor struct {
+	// Current is the bundle name of the last consumed message
+	Current string `json:"current"`
+	// Positions is the map of bundle name to the index of the last consumed message of the queue in the bundle
+	Positions map[string]int `json:"positions"`
+	// Acked defines if the last consumed message is acknowledged
+	Acked bool `json:"acked"`
+}
+
+func newBundleReader(opt *BundleOption) (*bundleReader, error) {
+	r := &bundleReader{
+		dir:        opt.Dir,
+		cursorPath: filepath.Join(opt.Dir, bundleCursorFile),
+		key:        []byte(opt.SignKey),
+		cursors:    make(map[string]*bundleCursor),
+		counts:     make(map[string]map[string]int),
+		cache:      make(map[string]map[string][]json.RawMessage),
+	}
+
+	data, err := os.ReadFile(r.cursorPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return r, nil
+		}
+		blog.Errorf("read bundle cursor file %s failed, err: %v", r.cursorPath, err)
+		return nil, err
+	}
+
+	if err = json.Unmarshal(data, &r.cursors); err != nil {
+		blog.Errorf("unmarshal bundle cursor file %s failed, err: %v", r.cursorPath, err)
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// pull returns the next message of the queue, if ack is false, returns the last consumed message that is not
+// acknowledged. The consume cursor is persisted, so a partially consumed bundle is resumed after restart.
+func (r *bundleReader) pull(opt *types.PullSyncDataOpt) (*types.PullSyncDataRes, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	key := queueKey(opt.ResType, opt.SubRes, opt.IsIncrement)
+	cursor, exists := r.cursors[key]
+	if !exists {
+		cursor = &bundleCursor{Positions: make(map[string]int)}
+		r.cursors[key] = cursor
+	}
+
+	bundles, err := r.listBundles()
+	if err != nil {
+		return nil, err
+	}
+
+	bundles, err = r.quarantineCorruptBundles(bundles)
+	if err != nil {
+		return nil, err
+	}
+
+	bundleMap := make(map[string]struct{}, len(bundles))
+	for _, bundle := range bundles {
+		bundleMap[bundle] = struct{}{}
+	}
+
+	// returns the last consumed message again if it is not acknowledged
+	if !opt.Ack && cursor.Current != "" && !cursor.Acked {
+		if _, exists = bundleMap[cursor.Current]; exists {
+			return r.getMessage(key, cursor, bundles)
+		}
+		blog.Warnf("bundle %s of the last consumed %s message is removed, skip it", cursor.Current, key)
+	}
+
+	// acknowledge the last consumed message and move the cursor to the next message
+	if cursor.Current != "" {
+		cursor.Acked = true
+	}
+
+	found := false
+	for _, bundle := range bundles {
+		counts, err := r.getCounts(bundle)
+		if err != nil {
+			return nil, err
+		}
+
+		pos, exists := cursor.Positions[bundle]
+		if !exists {
+			pos = -1
+		}
+
+		if pos+1 < counts[key] {
+			cursor.Current = bundle
+			cursor.Positions[bundle] = pos + 1
+			cursor.Acked = false
+			found = true
+			break
+		}
+	}
+
+	if err = r.saveCursors(); err != nil {
+		return nil, err
+	}
+
+	res := &types.PullSyncDataRes{Total: 0}
+	if found {
+		res, err = r.getMessage(key, cursor, bundles)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err = r.archiveBundles(bundles); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// getMessage returns the last consumed message of the queue and the count of the remaining messages after it
+func (r *bundleReader) getMessage(key string, cursor *bundleCursor, bundles []string) (*types.PullSyncDataRes,
+	error) {
+
+	data, err := r.loadBundle(cursor.Current)
+	if err != nil {
+		return nil, err
+	}
+
+	pos := cursor.Positions[cursor.Current]
+	if pos < 0 || pos >= len(data[key]) {
+		return nil, fmt.Errorf("%s cursor position %d is out of range of bundle %s", key, pos, cursor.Current)
+	}
+
+	var total int64
+	for _, bundle := range bundles {
+		counts, err := r.getCounts(bundle)
+		if err != nil {
+			return nil, err
+		}
+
+		bundlePos, exists := cursor.Positions[bundle]
+		if !exists {
+			bundlePos = -1
+		}
+
+		if remain := counts[key] - 1 - bundlePos; remain > 0 {
+			total += int64(remain)
+		}
+	}
+
+	return &types.PullSyncDataRes{Total: total, Info: data[key][pos]}, nil
+}
+
+// listBundles list the names of the signed bundles in the bundle directory in order
+func (r *bundleReader) listBundles() ([]string, error) {
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		blog.Errorf("read bundle dir %s failed, err: %v", r.dir, err)
+		return nil, err
+	}
+
+	fileMap := make(map[string]struct{}, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			fileMap[entry.Name()] = struct{}{}
+		}
+	}
+
+	bundles := make([]string, 0)
+	for file := range fileMap {
+		if !strings.HasSuffix(file, bundleFileSuffix) {
+			continue
+		}
+
+		// skip the bundle whose signature file is not transferred yet
+		if _, exists := fileMap[file+bundleSignSuffix]; !exists {
+			continue
+		}
+		bundles = append(bundles, file)
+	}
+
+	sort.Strings(bundles)
+	return bundles, nil
+}
+
+// getCounts returns the message count of each queue in the bundle
+func (r *bundleReader) getCounts(bundle string) (map[string]int, error) {
+	counts, exists := r.counts[bundle]
+	if exists {
+		return counts, nil
+	}
+
+	if _, err := r.loadBundle(bundle); err != nil {
+		return nil, err
+	}
+
+	return r.counts[bundle], nil
+}
+
+// loadBundle verify and decode the bundle, returns the messages of each queue in the bundle
+func (r *bundleReader) loadBundle(bundle string) (map[string][]json.RawMessage, error) {
+	data, exists := r.cache[bundle]
+	if exists {
+		return data, nil
+	}
+
+	bundlePath := filepath.Join(r.dir, bundle)
+	raw, err := os.ReadFile(bundlePath)
+	if err != nil {
+		blog.Errorf("read bundle %s failed, err: %v", bundlePath, err)
+		return nil, err
+	}
+
+	sign, err := os.ReadFile(bundlePath + bundleSignSuffix)
+	if err != nil {
+		blog.Errorf("read bundle %s signature failed, err: %v", bundlePath, err)
+		return nil, err
+	}
+
+	if !verifyBundle(r.key, raw, strings.TrimSpace(string(sign))) {
+		blog.Errorf("bundle %s signature is invalid", bundlePath)
+		return nil, &bundleCorruptError{bundle: bundle, err: errors.New("signature is invalid")}
+	}
+
+	data, err = decodeBundle(raw)
+	if err != nil {
+		blog.Errorf("decode bundle %s failed, err: %v", bundlePath, err)
+		return nil, &bundleCorruptError{bundle: bundle, err: err}
+	}
+
+	counts := make(map[string]int, len(data))
+	for key, messages := range data {
+		counts[key] = len(messages)
+	}
+	r.counts[bundle] = counts
+
+	r.cache[bundle] = data
+	r.cacheOrder = append(r.cacheOrder, bundle)
+	if len(r.cacheOrder) > maxCachedBundles {
+		delete(r.cache, r.cacheOrder[0])
+		r.cacheOrder = r.cacheOrder[1:]
+	}
+
+	return data, nil
+}
+
+// bundleCorruptError is the error of the bundle that can not be verified or decoded
+type bundleCorruptError struct {
+	bundle string
+	err    error
+}
+
+// Error returns the error message
+func (e *bundleCorruptError) Error() string {
+	return fmt.Sprintf("bundle %s is corrupt, err: %v", e.bundle, e.err)
+}
+
+// quarantineCorruptBundles move the bundles that can not be verified or decoded into the corrupt directory and send
+// an alarm, returns the other bundles. The corrupt bundles are skipped so that they do not block the consumption of
+// the following bundles, the correct bundles can be copied to the bundle directory again to be consumed.
+func (r *bundleReader) quarantineCorruptBundles(bundles []string) ([]string, error) {
+	validBundles := make([]string, 0, len(bundles))
+	for _, bundle := range bundles {
+		_, err := r.getCounts(bundle)
+		if err == nil {
+			validBundles = append(validBundles, bundle)
+			continue
+		}
+
+		corruptErr := new(bundleCorruptError)
+		if !errors.As(err, &corruptErr) {
+			return nil, err
+		}
+
+		if err = r.quarantineBundle(corruptErr); err != nil {
+			return nil, err
+		}
+	}
+
+	return validBundles, nil
+}
+
+// quarantineBundle move the corrupt bundle into the corrupt directory and clear its consume positions
+func (r *bundleReader) quarantineBundle(corruptErr *bundleCorruptError) error {
+	bundle := corruptErr.bundle
+	blog.Errorf("%v, move it to %s dir and skip it", corruptErr, bundleCorruptDir)
+	monitor.Collect(&meta.Alarm{
+		RequestID: util.GenerateRID(),
+		Type:      meta.LogicFatalError,
+		Detail:    fmt.Sprintf("sync %v, it is skipped, please copy the correct bundle again", corruptErr),
+		Module:    types2.CC_MODULE_TRANSFERSERVICE,
+		Dimension: map[string]string{"bundle": bundle},
+	})
+
+	corruptDir := filepath.Join(r.dir, bundleCorruptDir)
+	if err := os.MkdirAll(corruptDir, 0755); err != nil {
+		blog.Errorf("create bundle dir %s failed, err: %v", corruptDir, err)
+		return err
+	}
+
+	for _, file := range []string{bundle, bundle + bundleSignSuffix} {
+		err := os.Rename(filepath.Join(r.dir, file), filepath.Join(corruptDir, file))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			blog.Errorf("move bundle file %s to corrupt dir failed, err: %v", file, err)
+			return err
+		}
+	}
+
+	for _, cursor := range r.cursors {
+		delete(cursor.Positions, bundle)
+		if cursor.Current == bundle {
+			cursor.Current = ""
+			cursor.Acked = false
+		}
+	}
+	return r.saveCursors()
+}
+
+// decodeBundle decode the gzip compressed bundle data into the messages of each queue
+func decodeBundle(raw []byte) (map[string][]json.RawMessage, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	reader := bufio.NewReader(zr)
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		return nil, fmt.Errorf("read bundle manifest failed, err: %v", err)
+	}
+
+	manifest := new(bundleManifest)
+	if err = json.Unmarshal(line, manifest); err != nil {
+		return nil, fmt.Errorf("unmarshal bundle manifest failed, err: %v", err)
+	}
+
+	if manifest.Version != bundleVersion {
+		return nil, fmt.Errorf("bundle version %d is not supported", manifest.Version)
+	}
+
+	data := make(map[string][]json.RawMessage)
+	count := 0
+	for {
+		line, err = reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		if len(bytes.TrimSpace(line)) > 0 {
+			msg := new(bundleMessage)
+			if jsonErr := json.Unmarshal(line, msg); jsonErr != nil {
+				return nil, fmt.Errorf("unmarshal bundle message(index: %d) failed, err: %v", count, jsonErr)
+			}
+
+			key := queueKey(msg.ResType, msg.SubRes, msg.IsIncrement)
+			data[key] = append(data[key], msg.Data)
+			count++
+		}
+
+		if err == io.EOF {
+			break
+		}
+	}
+
+	if count != manifest.Count {
+		return nil, fmt.Errorf("bundle message count %d is not equal to manifest count %d", count, manifest.Count)
+	}
+
+	return data, nil
+}
+
+// saveCursors persist the consume cursors of all queues
+func (r *bundleReader) saveCursors() error {
+	data, err := json.Marshal(r.cursors)
+	if err != nil {
+		return err
+	}
+
+	if err = writeFileAtomic(r.cursorPath, data); err != nil {
+		blog.Errorf("save bundle cursor file %s failed, err: %v", r.cursorPath, err)
+		return err
+	}
+	return nil
+}
+
+// archiveBundles move the bundles whose messages are all consumed and acknowledged into the done directory
+func (r *bundleReader) archiveBundles(bundles []string) error {
+	bundleMap := make(map[string]struct{}, len(bundles))
+	archived := false
+	for _, bundle := range bundles {
+		bundleMap[bundle] = struct{}{}
+
+		counts, exists := r.counts[bundle]
+		if !exists || !r.isBundleDone(bundle, counts) {
+			continue
+		}
+
+		for _, file := range []string{bundle, bundle + bundleSignSuffix} {
+			if err := os.Rename(filepath.Join(r.dir, file), filepath.Join(r.dir, bundleDoneDir, file)); err != nil {
+				blog.Errorf("move bundle file %s to done dir failed, err: %v", file, err)
+				return err
+			}
+		}
+
+		blog.Infof("bundle %s is all consumed, move it to done dir", bundle)
+		delete(bundleMap, bundle)
+		delete(r.counts, bundle)
+		delete(r.cache, bundle)
+		archived = true
+	}
+
+	if !archived {
+		return nil
+	}
+
+	// clear the positions of the bundles that are archived or removed
+	for _, cursor := range r.cursors {
+		for bundle := range cursor.Positions {
+			if _, exists := bundleMap[bundle]; !exists {
+				delete(cursor.Positions, bundle)
+			}
+		}
+	}
+
+	for i := 0; i < len(r.cacheOrder); i++ {
+		if _, exists := r.cache[r.cacheOrder[i]]; !exists {
+			r.cacheOrder = append(r.cacheOrder[:i], r.cacheOrder[i+1:]...)
+			i--
+		}
+	}
+
+	return r.saveCursors()
+}
+
+// isBundleDone check if all messages of the bundle are consumed and acknowledged
+func (r *bundleReader) isBundleDone(bundle string, counts map[string]int) bool {
+	for key, count := range counts {
+		cursor, exists := r.cursors[key]
+		if !exists {
+			return false
+		}
+
+		pos, exists := cursor.Positions[bundle]
+		if !exists || pos < count-1 {
+			return false
+		}
+
+		if cursor.Current == bundle && !cursor.Acked {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,198 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package medium
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"configcenter/pkg/synchronize/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestBundle writes a signed bundle with the messages, returns the bundle name
+func writeTestBundle(t *testing.T, dir, name string, key []byte, count int, messages ...*bundleMessage) string {
+	manifest, err := json.Marshal(&bundleManifest{Version: bundleVersion, Name: "src", Count: count})
+	require.NoError(t, err)
+
+	buf := new(bytes.Buffer)
+	zw := gzip.NewWriter(buf)
+	_, err = zw.Write(append(manifest, '\n'))
+	require.NoError(t, err)
+	for _, msg := range messages {
+		line, err := json.Marshal(msg)
+		require.NoError(t, err)
+		_, err = zw.Write(append(line, '\n'))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), buf.Bytes(), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+bundleSignSuffix), []byte(signBundle(key, buf.Bytes())),
+		0644))
+	return name
+}
+
+func newTestMessage(data string) *bundleMessage {
+	return &bundleMessage{ResType: types.Model, SubRes: "sub", Data: json.RawMessage(`"` + data + `"`)}
+}
+
+func newTestReader(t *testing.T, dir string) *bundleReader {
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, bundleDoneDir), 0755))
+	r, err := newBundleReader(&BundleOption{Dir: dir, SignKey: "key"})
+	require.NoError(t, err)
+	return r
+}
+
+func TestDecodeBundle(t *testing.T) {
+	dir := t.TempDir()
+	key := []byte("key")
+
+	name := writeTestBundle(t, dir, "src-1.bundle", key, 2, newTestMessage("a"),
+		&bundleMessage{ResType: types.Model, SubRes: "sub", IsIncrement: true, Data: json.RawMessage(`"b"`)})
+	raw, err := os.ReadFile(filepath.Join(dir, name))
+	require.NoError(t, err)
+	data, err := decodeBundle(raw)
+	require.NoError(t, err)
+	require.Len(t, data, 2)
+	require.Equal(t, `"a"`, string(data[queueKey(types.Model, "sub", false)][0]))
+	require.Equal(t, `"b"`, string(data[queueKey(types.Model, "sub", true)][0]))
+
+	// message count is not equal to the manifest
+	name = writeTestBundle(t, dir, "src-2.bundle", key, 3, newTestMessage("a"))
+	raw, err = os.ReadFile(filepath.Join(dir, name))
+	require.NoError(t, err)
+	_, err = decodeBundle(raw)
+	require.Error(t, err)
+
+	_, err = decodeBundle([]byte("not gzip"))
+	require.Error(t, err)
+}
+
+func TestBundleReaderPull(t *testing.T) {
+	dir := t.TempDir()
+	key := []byte("key")
+	writeTestBundle(t, dir, "src-1.bundle", key, 2, newTestMessage("a"), newTestMessage("b"))
+	writeTestBundle(t, dir, "src-2.bundle", key, 1, newTestMessage("c"))
+	// the bundle whose signature is not transferred is not consumed
+	name := writeTestBundle(t, dir, "src-3.bundle", key, 1, newTestMessage("d"))
+	require.NoError(t, os.Remove(filepath.Join(dir, name+bundleSignSuffix)))
+
+	r := newTestReader(t, dir)
+	pullOpt := &types.PullSyncDataOpt{ResType: types.Model, SubRes: "sub"}
+
+	res, err := r.pull(pullOpt)
+	require.NoError(t, err)
+	require.Equal(t, `"a"`, string(res.Info))
+	require.Equal(t, int64(2), res.Total)
+
+	// the message that is not acknowledged is returned again
+	res, err = r.pull(pullOpt)
+	require.NoError(t, err)
+	require.Equal(t, `"a"`, string(res.Info))
+
+	pullOpt.Ack = true
+	res, err = r.pull(pullOpt)
+	require.NoError(t, err)
+	require.Equal(t, `"b"`, string(res.Info))
+	require.Equal(t, int64(1), res.Total)
+
+	// the cursor is resumed after restart
+	r = newTestReader(t, dir)
+	pullOpt.Ack = false
+	res, err = r.pull(pullOpt)
+	require.NoError(t, err)
+	require.Equal(t, `"b"`, string(res.Info))
+
+	// the bundle is archived after all its messages are acknowledged
+	pullOpt.Ack = true
+	res, err = r.pull(pullOpt)
+	require.NoError(t, err)
+	require.Equal(t, `"c"`, string(res.Info))
+	require.Equal(t, int64(0), res.Total)
+	_, err = os.Stat(filepath.Join(dir, bundleDoneDir, "src-1.bundle"))
+	require.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, "src-1.bundle"))
+	require.True(t, os.IsNotExist(err))
+
+	res, err = r.pull(pullOpt)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), res.Total)
+	require.Empty(t, res.Info)
+	_, err = os.Stat(filepath.Join(dir, bundleDoneDir, "src-2.bundle"))
+	require.NoError(t, err)
+
+	// the unsigned bundle is consumed after its signature is transferred
+	raw, err := os.ReadFile(filepath.Join(dir, name))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+bundleSignSuffix), []byte(signBundle(key, raw)), 0644))
+	res, err = r.pull(pullOpt)
+	require.NoError(t, err)
+	require.Equal(t, `"d"`, string(res.Info))
+}
+
+func TestBundleReaderCorruptBundle(t *testing.T) {
+	dir := t.TempDir()
+	key := []byte("key")
+	writeTestBundle(t, dir, "src-1.bundle", []byte("other key"), 1, newTestMessage("a"))
+	writeTestBundle(t, dir, "src-2.bundle", key, 1, newTestMessage("b"))
+	// the message count is not equal to the manifest
+	writeTestBundle(t, dir, "src-3.bundle", key, 2, newTestMessage("c"))
+	writeTestBundle(t, dir, "src-4.bundle", key, 1, newTestMessage("d"))
+
+	// the corrupt bundles are quarantined and skipped, the other bundles are consumed in order
+	r := newTestReader(t, dir)
+	pullOpt := &types.PullSyncDataOpt{ResType: types.Model, SubRes: "sub"}
+	res, err := r.pull(pullOpt)
+	require.NoError(t, err)
+	require.Equal(t, `"b"`, string(res.Info))
+	require.Equal(t, int64(1), res.Total)
+
+	for _, file := range []string{"src-1.bundle", "src-1.bundle" + bundleSignSuffix, "src-3.bundle",
+		"src-3.bundle" + bundleSignSuffix} {
+		_, err = os.Stat(filepath.Join(dir, bundleCorruptDir, file))
+		require.NoError(t, err)
+		_, err = os.Stat(filepath.Join(dir, file))
+		require.True(t, os.IsNotExist(err))
+	}
+
+	pullOpt.Ack = true
+	res, err = r.pull(pullOpt)
+	require.NoError(t, err)
+	require.Equal(t, `"d"`, string(res.Info))
+
+	// the bundle that is corrupt after it is partially consumed is skipped too
+	writeTestBundle(t, dir, "src-5.bundle", key, 2, newTestMessage("e"), newTestMessage("f"))
+	res, err = r.pull(pullOpt)
+	require.NoError(t, err)
+	require.Equal(t, `"e"`, string(res.Info))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "src-5.bundle"+bundleSignSuffix), []byte("invalid"), 0644))
+	r = newTestReader(t, dir)
+	res, err = r.pull(pullOpt)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), res.Total)
+	require.Empty(t, res.Info)
+	_, err = os.Stat(filepath.Join(dir, bundleCorruptDir, "src-5.bundle"))
+	require.NoError(t, err)
+	require.NotContains(t, r.cursors[queueKey(types.Model, "sub", false)].Positions, "src-5.bundle")
+}
@@ -0,0 +1,95 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package medium
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"configcenter/pkg/synchronize/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignBundle(t *testing.T) {
+	key := []byte("key")
+	data := []byte("bundle data")
+
+	sign := signBundle(key, data)
+	require.True(t, verifyBundle(key, data, sign))
+	require.False(t, verifyBundle([]byte("other key"), data, sign))
+	require.False(t, verifyBundle(key, []byte("changed data"), sign))
+	require.False(t, verifyBundle(key, data, "not hex"))
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, writeFileAtomic(path, []byte("v1")))
+	require.NoError(t, writeFileAtomic(path, []byte("v2")))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "v2", string(data))
+
+	_, err = os.Stat(path + ".tmp")
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestNewBundleMedium(t *testing.T) {
+	_, err := NewBundleMedium(&BundleOption{Dir: t.TempDir()})
+	require.Error(t, err)
+	_, err = NewBundleMedium(&BundleOption{SignKey: "key"})
+	require.Error(t, err)
+}
+
+func TestBundleMedium(t *testing.T) {
+	dir := t.TempDir()
+	opt := &BundleOption{Name: "src", Dir: dir, SignKey: "key", MaxMessages: 2}
+
+	cli, err := NewBundleMedium(opt)
+	require.NoError(t, err)
+	for _, data := range []string{"a", "b", "c"} {
+		err = cli.PushSyncData(context.Background(), nil, &types.PushSyncDataOpt{ResType: types.Model,
+			SubRes: "sub", Data: data})
+		require.NoError(t, err)
+	}
+	err = cli.PushSyncData(context.Background(), nil, &types.PushSyncDataOpt{ResType: types.Model, SubRes: "sub",
+		IsIncrement: true, Data: "d"})
+	require.NoError(t, err)
+	require.NoError(t, cli.(*bundleMediumCli).Close())
+
+	// the messages of each queue are pulled in order
+	pullOpt := &types.PullSyncDataOpt{ResType: types.Model, SubRes: "sub", Ack: true}
+	for idx, expect := range []string{`"a"`, `"b"`, `"c"`} {
+		res, err := cli.PullSyncData(context.Background(), nil, pullOpt)
+		require.NoError(t, err)
+		require.Equal(t, expect, string(res.Info))
+		require.Equal(t, int64(2-idx), res.Total)
+	}
+
+	res, err := cli.PullSyncData(context.Background(), nil, pullOpt)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), res.Total)
+	require.Empty(t, res.Info)
+
+	res, err = cli.PullSyncData(context.Background(), nil, &types.PullSyncDataOpt{ResType: types.Model,
+		SubRes: "sub", IsIncrement: true, Ack: true})
+	require.NoError(t, err)
+	require.Equal(t, `"d"`, string(res.Info))
+}
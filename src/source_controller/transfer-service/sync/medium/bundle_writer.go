@@ -0,0 +1,338 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package medium
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"configcenter/src/common/blog"
+)
+
+// bundleWriter writes the pushed sync data messages into bundles
+type bundleWriter struct {
+	lock        sync.Mutex
+	name        string
+	dir         string
+	spoolPath   string
+	seqPath     string
+	key         []byte
+	maxMessages int
+	// count is the message count in the spool file
+	count int
+	// lastSeq is the sequence of the last flushed bundle, bundle sequence is used to sort the bundles, it is persisted
+	// before the bundle is written so that it never goes backwards, even if the system clock does
+	lastSeq int64
+	// stopCh is closed to stop flushing the spool file periodically
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+func newBundleWriter(opt *BundleOption) (*bundleWriter, error) {
+	w := &bundleWriter{
+		name:        opt.Name,
+		dir:         opt.Dir,
+		spoolPath:   filepath.Join(opt.Dir, bundleSpoolDir, opt.Name+".jsonl"),
+		seqPath:     filepath.Join(opt.Dir, bundleSpoolDir, opt.Name+bundleSeqSuffix),
+		key:         []byte(opt.SignKey),
+		maxMessages: opt.MaxMessages,
+		stopCh:      make(chan struct{}),
+	}
+
+	if err := w.loadSeq(); err != nil {
+		blog.Errorf("load bundle sequence of %s failed, err: %v", w.name, err)
+		return nil, err
+	}
+
+	if err := w.recoverBundles(); err != nil {
+		blog.Errorf("recover unsigned bundles in %s failed, err: %v", w.dir, err)
+		return nil, err
+	}
+
+	if err := w.recoverSpool(); err != nil {
+		blog.Errorf("recover bundle spool file %s failed, err: %v", w.spoolPath, err)
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// loadSeq load the sequence of the last bundle, which is the larger one of the persisted sequence and the sequence
+// of the existing bundles, so the sequence keeps increasing if the sequence file is lost or the bundles are generated
+// by the previous version which uses the wall-clock time as the sequence
+func (w *bundleWriter) loadSeq() error {
+	data, err := os.ReadFile(w.seqPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if err == nil {
+		w.lastSeq, err = strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+		if err != nil {
+			return fmt.Errorf("parse bundle sequence file %s failed, err: %v", w.seqPath, err)
+		}
+	}
+
+	prefix := w.name + "-"
+	for _, dir := range []string{w.dir, filepath.Join(w.dir, bundleDoneDir), filepath.Join(w.dir, bundleCorruptDir)} {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return err
+		}
+
+		for _, entry := range entries {
+			file := entry.Name()
+			if entry.IsDir() || !strings.HasPrefix(file, prefix) || !strings.HasSuffix(file, bundleFileSuffix) {
+				continue
+			}
+
+			seq, err := strconv.ParseInt(strings.TrimSuffix(strings.TrimPrefix(file, prefix), bundleFileSuffix), 10,
+				64)
+			if err == nil && seq > w.lastSeq {
+				w.lastSeq = seq
+			}
+		}
+	}
+
+	return nil
+}
+
+// recoverBundles finish the flush interrupted by the last shutdown. The spool file is removed after the bundle file is
+// written and before the signature file is written, so an unsigned bundle is dropped if the spool file still exists
+// because its messages would be flushed again, otherwise the bundle is signed since its messages are only in it.
+func (w *bundleWriter) recoverBundles() error {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return err
+	}
+
+	fileMap := make(map[string]struct{}, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			fileMap[entry.Name()] = struct{}{}
+		}
+	}
+
+	_, err = os.Stat(w.spoolPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	spoolExists := err == nil
+
+	for file := range fileMap {
+		if !strings.HasPrefix(file, w.name+"-") || !strings.HasSuffix(file, bundleFileSuffix) {
+			continue
+		}
+		if _, exists := fileMap[file+bundleSignSuffix]; exists {
+			continue
+		}
+
+		bundlePath := filepath.Join(w.dir, file)
+		if spoolExists {
+			blog.Warnf("bundle %s is not signed and its messages are still in spool file, drop it", bundlePath)
+			if err = os.Remove(bundlePath); err != nil {
+				return err
+			}
+			continue
+		}
+
+		data, err := os.ReadFile(bundlePath)
+		if err != nil {
+			return err
+		}
+
+		blog.Warnf("bundle %s is not signed and its spool file is removed, sign it", bundlePath)
+		if err = writeFileAtomic(bundlePath+bundleSignSuffix, []byte(signBundle(w.key, data))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recoverSpool flush the messages that are pushed before the last shutdown into bundle
+func (w *bundleWriter) recoverSpool() error {
+	data, err := os.ReadFile(w.spoolPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	// drop the partially written message, the message push is not returned successfully
+	end := bytes.LastIndexByte(data, '\n') + 1
+	if end != len(data) {
+		blog.Warnf("bundle spool file %s has partially written message, drop it", w.spoolPath)
+		if err = writeFileAtomic(w.spoolPath, data[:end]); err != nil {
+			return err
+		}
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.count = bytes.Count(data[:end], []byte{'\n'})
+	return w.flush()
+}
+
+// push append the message into the spool file, flush the spool file into bundle when it is full
+func (w *bundleWriter) push(msg *bundleMessage) error {
+	line, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshal bundle message failed, err: %v", err)
+	}
+	line = append(line, '\n')
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	file, err := os.OpenFile(w.spoolPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		blog.Errorf("open bundle spool file %s failed, err: %v", w.spoolPath, err)
+		return err
+	}
+
+	_, err = file.Write(line)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		blog.Errorf("write bundle spool file %s failed, err: %v", w.spoolPath, err)
+		return err
+	}
+
+	w.count++
+	if w.count < w.maxMessages {
+		return nil
+	}
+
+	return w.flush()
+}
+
+// loopFlush flush the spool file into bundle periodically until the writer is stopped, so that the messages are not
+// delayed for a long time
+func (w *bundleWriter) loopFlush(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stopCh:
+			return
+		case <-ticker.C:
+		}
+
+		w.lock.Lock()
+		if err := w.flush(); err != nil {
+			blog.Errorf("flush bundle spool file %s failed, err: %v", w.spoolPath, err)
+		}
+		w.lock.Unlock()
+	}
+}
+
+// stop stops flushing the spool file periodically and flush the pending messages into bundle
+func (w *bundleWriter) stop() error {
+	w.stopOnce.Do(func() {
+		close(w.stopCh)
+	})
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.flush()
+}
+
+// flush compress the messages in the spool file into a signed bundle, must be called with lock held
+func (w *bundleWriter) flush() error {
+	if w.count == 0 {
+		return nil
+	}
+
+	data, err := os.ReadFile(w.spoolPath)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := json.Marshal(&bundleManifest{
+		Version:    bundleVersion,
+		Name:       w.name,
+		Count:      w.count,
+		CreateTime: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	buf := new(bytes.Buffer)
+	zw := gzip.NewWriter(buf)
+	if _, err = zw.Write(append(manifest, '\n')); err != nil {
+		return err
+	}
+	if _, err = zw.Write(data); err != nil {
+		return err
+	}
+	if err = zw.Close(); err != nil {
+		return err
+	}
+
+	// persist the sequence before the bundle is written, so that the sequence is not reused after restart
+	seq := w.lastSeq + 1
+	if err = writeFileAtomic(w.seqPath, []byte(strconv.FormatInt(seq, 10))); err != nil {
+		blog.Errorf("write bundle sequence file %s failed, err: %v", w.seqPath, err)
+		return err
+	}
+	w.lastSeq = seq
+	bundlePath := filepath.Join(w.dir, fmt.Sprintf("%s-%019d%s", w.name, seq, bundleFileSuffix))
+
+	if err = writeFileAtomic(bundlePath, buf.Bytes()); err != nil {
+		blog.Errorf("write bundle %s failed, err: %v", bundlePath, err)
+		return err
+	}
+
+	// the spool file is removed before the signature file is written, so that the messages are not flushed again
+	// after the bundle is consumable, the interrupted flush is finished by recoverBundles
+	if err = os.Remove(w.spoolPath); err != nil {
+		blog.Errorf("remove bundle spool file %s failed, err: %v", w.spoolPath, err)
+		if removeErr := os.Remove(bundlePath); removeErr != nil {
+			blog.Errorf("remove unsigned bundle %s failed, err: %v", bundlePath, removeErr)
+		}
+		return err
+	}
+	count := w.count
+	w.count = 0
+
+	// signature file is written after the bundle file, bundle without signature file is not consumed
+	if err = writeFileAtomic(bundlePath+bundleSignSuffix, []byte(signBundle(w.key, buf.Bytes()))); err != nil {
+		blog.Errorf("write bundle %s signature failed, it is signed after restart, err: %v", bundlePath, err)
+		return err
+	}
+
+	blog.Infof("flush %d messages into bundle %s", count, bundlePath)
+	return nil
+}
@@ -0,0 +1,195 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package medium
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"configcenter/pkg/synchronize/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestWriter(t *testing.T, dir string, maxMessages int) *bundleWriter {
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, bundleSpoolDir), 0755))
+	w, err := newBundleWriter(&BundleOption{Name: "src", Dir: dir, SignKey: "key", MaxMessages: maxMessages})
+	require.NoError(t, err)
+	return w
+}
+
+// listTestBundles returns the bundle files and signature files in the directory
+func listTestBundles(t *testing.T, dir string) (bundles []string, signs []string) {
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	for _, entry := range entries {
+		switch {
+		case strings.HasSuffix(entry.Name(), bundleFileSuffix):
+			bundles = append(bundles, entry.Name())
+		case strings.HasSuffix(entry.Name(), bundleSignSuffix):
+			signs = append(signs, entry.Name())
+		}
+	}
+	return bundles, signs
+}
+
+func pullAllTestMessages(t *testing.T, dir string) []string {
+	r := newTestReader(t, dir)
+	messages := make([]string, 0)
+	for {
+		res, err := r.pull(&types.PullSyncDataOpt{ResType: types.Model, SubRes: "sub", Ack: true})
+		require.NoError(t, err)
+		if len(res.Info) == 0 {
+			return messages
+		}
+		messages = append(messages, string(res.Info))
+	}
+}
+
+func TestBundleWriterPush(t *testing.T) {
+	dir := t.TempDir()
+	w := newTestWriter(t, dir, 2)
+
+	require.NoError(t, w.push(newTestMessage("a")))
+	bundles, _ := listTestBundles(t, dir)
+	require.Empty(t, bundles)
+
+	// the spool file is flushed into bundle when it is full
+	require.NoError(t, w.push(newTestMessage("b")))
+	bundles, signs := listTestBundles(t, dir)
+	require.Len(t, bundles, 1)
+	require.Len(t, signs, 1)
+	_, err := os.Stat(w.spoolPath)
+	require.True(t, os.IsNotExist(err))
+
+	require.NoError(t, w.push(newTestMessage("c")))
+	require.NoError(t, w.stop())
+	bundles, _ = listTestBundles(t, dir)
+	require.Len(t, bundles, 2)
+
+	require.Equal(t, []string{`"a"`, `"b"`, `"c"`}, pullAllTestMessages(t, dir))
+}
+
+func TestBundleWriterLoopFlush(t *testing.T) {
+	dir := t.TempDir()
+	w := newTestWriter(t, dir, 100)
+	require.NoError(t, w.push(newTestMessage("a")))
+
+	done := make(chan struct{})
+	go func() {
+		w.loopFlush(10 * time.Millisecond)
+		close(done)
+	}()
+
+	require.Eventually(t, func() bool {
+		bundles, _ := listTestBundles(t, dir)
+		return len(bundles) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, w.stop())
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("loop flush is not stopped")
+	}
+
+	// stop can be called repeatedly
+	require.NoError(t, w.stop())
+}
+
+func TestBundleWriterRecoverSpool(t *testing.T) {
+	dir := t.TempDir()
+	w := newTestWriter(t, dir, 100)
+	require.NoError(t, w.push(newTestMessage("a")))
+	require.NoError(t, w.push(newTestMessage("b")))
+
+	// the partially written message is dropped when recovering
+	file, err := os.OpenFile(w.spoolPath, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"resource_type":"model"`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	newTestWriter(t, dir, 100)
+	require.Equal(t, []string{`"a"`, `"b"`}, pullAllTestMessages(t, dir))
+}
+
+func TestBundleWriterRecoverBundles(t *testing.T) {
+	// crashed after the bundle is written and before the spool file is removed
+	dir := t.TempDir()
+	w := newTestWriter(t, dir, 100)
+	require.NoError(t, w.push(newTestMessage("a")))
+	require.NoError(t, os.Rename(w.spoolPath, w.spoolPath+".bak"))
+	require.NoError(t, w.push(newTestMessage("a")))
+	require.NoError(t, w.stop())
+	require.NoError(t, os.Rename(w.spoolPath+".bak", w.spoolPath))
+	bundles, signs := listTestBundles(t, dir)
+	require.Len(t, bundles, 1)
+	require.NoError(t, os.Remove(filepath.Join(dir, signs[0])))
+
+	newTestWriter(t, dir, 100)
+	bundles, signs = listTestBundles(t, dir)
+	require.Len(t, bundles, 1)
+	require.Len(t, signs, 1)
+	require.Equal(t, []string{`"a"`}, pullAllTestMessages(t, dir))
+
+	// crashed after the spool file is removed and before the bundle is signed
+	dir = t.TempDir()
+	w = newTestWriter(t, dir, 100)
+	require.NoError(t, w.push(newTestMessage("b")))
+	require.NoError(t, w.stop())
+	_, signs = listTestBundles(t, dir)
+	require.NoError(t, os.Remove(filepath.Join(dir, signs[0])))
+	require.Empty(t, pullAllTestMessages(t, dir))
+
+	newTestWriter(t, dir, 100)
+	bundles, signs = listTestBundles(t, dir)
+	require.Len(t, bundles, 1)
+	require.Len(t, signs, 1)
+	require.Equal(t, []string{`"b"`}, pullAllTestMessages(t, dir))
+}
+
+func TestBundleWriterSequence(t *testing.T) {
+	dir := t.TempDir()
+	w := newTestWriter(t, dir, 1)
+	require.NoError(t, w.push(newTestMessage("a")))
+	require.NoError(t, w.push(newTestMessage("b")))
+
+	// the sequence is persisted, so it keeps increasing after restart even if the bundles are consumed and archived
+	require.Equal(t, []string{`"a"`, `"b"`}, pullAllTestMessages(t, dir))
+	w = newTestWriter(t, dir, 1)
+	require.Equal(t, int64(2), w.lastSeq)
+	require.NoError(t, w.push(newTestMessage("c")))
+	bundles, _ := listTestBundles(t, dir)
+	require.Equal(t, []string{"src-0000000000000000003.bundle"}, bundles)
+
+	// the sequence continues from the existing bundles if the sequence file is lost, e.g. the bundles are generated
+	// by the previous version which uses the wall-clock time as the sequence
+	require.NoError(t, os.Remove(w.seqPath))
+	clockSeqBundle := "src-1700000000000000000.bundle"
+	writeTestBundle(t, dir, clockSeqBundle, w.key, 1, newTestMessage("d"))
+	w = newTestWriter(t, dir, 1)
+	require.Equal(t, int64(1700000000000000000), w.lastSeq)
+	require.NoError(t, w.push(newTestMessage("e")))
+	bundles, _ = listTestBundles(t, dir)
+	require.Equal(t, []string{"src-0000000000000000003.bundle", clockSeqBundle, "src-1700000000000000001.bundle"},
+		bundles)
+	require.Equal(t, []string{`"c"`, `"d"`, `"e"`}, pullAllTestMessages(t, dir))
+}
@@ -71,3 +71,8 @@ func (t *transMediumCli) PullSyncData(ctx context.Context, h http.Header, opt *t
 
 	return &resp.Data, nil
 }
+
+// Close the http transfer medium client sends the sync data synchronously, there is nothing to release
+func (t *transMediumCli) Close() error {
+	return nil
+}
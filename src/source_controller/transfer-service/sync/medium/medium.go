@@ -34,6 +34,8 @@ import (
 type ClientI interface {
 	PushSyncData(ctx context.Context, h http.Header, opt *types.PushSyncDataOpt) error
 	PullSyncData(ctx context.Context, h http.Header, opt *types.PullSyncDataOpt) (*types.PullSyncDataRes, error)
+	// Close release the resources of the client, the pushed sync data that is not sent yet is sent before closing
+	Close() error
 }
 
 // NewTransferMedium new transfer medium client
@@ -213,6 +213,19 @@ var bizRelatedResTypeMap = map[types.ResType]struct{}{types.Biz: {}, types.Objec
 func (m *Metadata) AddListCond(resType types.ResType, cond mapstr.MapStr) mapstr.MapStr {
 	extraCond := make(mapstr.MapStr)
 	switch resType {
+	case types.Model:
+		// do not sync mainline models and table attribute models, mainline topology can not be changed by sync
+		extraCond[common.BKClassificationIDField] = mapstr.MapStr{common.BKDBNIN: []string{
+			metadata.ClassificationBizTopoID, metadata.ClassificationTableID}}
+		return mergeCond(cond, extraCond)
+	case types.ModelAttrGroup, types.ModelAttribute:
+		// do not sync biz custom attributes and attribute groups
+		extraCond[common.BKAppIDField] = mapstr.MapStr{common.BKDBIN: []interface{}{0, nil}}
+		return mergeCond(cond, extraCond)
+	case types.ModelAssociation:
+		// do not sync mainline associations
+		extraCond[common.AssociationKindIDField] = mapstr.MapStr{common.BKDBNE: common.AssociationKindMainline}
+		return mergeCond(cond, extraCond)
 	case types.Biz, types.ObjectInstance:
 		// do not sync host pool and blueking biz
 		if m.blueking == nil || m.blueking.bizID == 0 {
@@ -302,6 +315,19 @@ func (m *Metadata) ParseEventDetail(eventType watch.EventType, resType types.Res
 	}
 
 	switch resType {
+	case types.Model:
+		// do not sync mainline models and table attribute models
+		classificationID := gjson.GetBytes(detail, common.BKClassificationIDField).String()
+		return event, classificationID != metadata.ClassificationBizTopoID &&
+			classificationID != metadata.ClassificationTableID
+	case types.ModelAttrGroup, types.ModelAttribute:
+		// do not sync biz custom attributes and attribute groups
+		return event, gjson.GetBytes(detail, common.BKAppIDField).Int() == 0
+	case types.ModelAssociation:
+		// do not sync mainline associations
+		return event, gjson.GetBytes(detail, common.AssociationKindIDField).String() != common.AssociationKindMainline
+	case types.ModelUnique:
+		return m.parseUniqueEvent(event)
 	case types.Biz:
 		// do not sync host pool biz
 		return event, gjson.GetBytes(detail, common.BKAppIDField).Int() != m.InnerIDInfo.HostPool.Biz
@@ -405,3 +431,94 @@ func (m *Metadata) parseHostRelEvent(event *types.EventInfo) (*types.EventInfo,
 	m.blueking.hostModuleMap[hostID][moduleID] = struct{}{}
 	return nil, false
 }
+
+// parseUniqueEvent fill the property ids of the unique rule keys into the unique rule event detail
+func (m *Metadata) parseUniqueEvent(event *types.EventInfo) (*types.EventInfo, bool) {
+	unique := make(mapstr.MapStr)
+	if err := json.Unmarshal(event.Detail, &unique); err != nil {
+		blog.Errorf("unmarshal unique rule event detail(%s) failed, err: %v", event.Detail, err)
+		return nil, false
+	}
+
+	ctx := commonutil.SetDBReadPreference(context.Background(), common.SecondaryPreferredMode)
+	if err := m.FillUniqueKeyPropertyIDs(ctx, []mapstr.MapStr{unique}); err != nil {
+		blog.Errorf("fill unique rule(%s) key property ids failed, err: %v", event.Detail, err)
+		return nil, false
+	}
+
+	detail, err := json.Marshal(unique)
+	if err != nil {
+		blog.Errorf("marshal unique rule(%+v) failed, err: %v", unique, err)
+		return nil, false
+	}
+	event.Detail = detail
+
+	return event, true
+}
+
+// UniqueKey is the unique rule key for synchronization, the attribute ids of unique rule keys are different in each
+// environment, so the property ids of the source unique rule keys are filled for synchronization
+type UniqueKey struct {
+	Kind       string `json:"key_kind" bson:"key_kind"`
+	ID         int64  `json:"key_id" bson:"key_id"`
+	PropertyID string `json:"bk_property_id,omitempty" bson:"-"`
+}
+
+// ParseUniqueKeys parse unique rule keys
+func ParseUniqueKeys(unique mapstr.MapStr) ([]UniqueKey, error) {
+	raw, err := json.Marshal(unique[common.BKObjectUniqueKeys])
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]UniqueKey, 0)
+	if err = json.Unmarshal(raw, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// FillUniqueKeyPropertyIDs fill the property ids of the unique rule keys by the key attribute ids of this environment
+func (m *Metadata) FillUniqueKeyPropertyIDs(ctx context.Context, uniques []mapstr.MapStr) error {
+	uniqueKeysMap := make(map[int][]UniqueKey)
+	attrIDs := make([]int64, 0)
+	for i, unique := range uniques {
+		keys, err := ParseUniqueKeys(unique)
+		if err != nil {
+			return fmt.Errorf("parse unique rule(%+v) keys failed, err: %v", unique, err)
+		}
+
+		for _, key := range keys {
+			attrIDs = append(attrIDs, key.ID)
+		}
+		uniqueKeysMap[i] = keys
+	}
+
+	if len(attrIDs) == 0 {
+		return nil
+	}
+
+	attrs := make([]metadata.Attribute, 0)
+	attrCond := mapstr.MapStr{common.BKFieldID: mapstr.MapStr{common.BKDBIN: commonutil.IntArrayUnique(attrIDs)}}
+	err := mongodb.Client().Table(common.BKTableNameObjAttDes).Find(attrCond).
+		Fields(common.BKFieldID, common.BKPropertyIDField).All(ctx, &attrs)
+	if err != nil {
+		blog.Errorf("get unique rule key attributes failed, err: %v, cond: %+v", err, attrCond)
+		return err
+	}
+
+	attrIDPropertyMap := make(map[int64]string)
+	for _, attr := range attrs {
+		attrIDPropertyMap[attr.ID] = attr.PropertyID
+	}
+
+	for i, keys := range uniqueKeysMap {
+		for j, key := range keys {
+			// unique rule with invalid key attribute has no property id and will not be synchronized
+			keys[j].PropertyID = attrIDPropertyMap[key.ID]
+		}
+		uniques[i][common.BKObjectUniqueKeys] = keys
+	}
+
+	return nil
+}
@@ -0,0 +1,346 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+// Package rule defines the source cmdb sync rules that filter and mask the synchronized data
+package rule
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"strings"
+
+	"configcenter/pkg/filter"
+	"configcenter/pkg/synchronize/types"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/watch"
+	"configcenter/src/source_controller/transfer-service/app/options"
+	"configcenter/src/source_controller/transfer-service/sync/util"
+
+	"github.com/tidwall/gjson"
+)
+
+// MaskedValue is the synchronized value of the masked string field
+const MaskedValue = "******"
+
+// resTypeKeyFieldsMap is the resource type to the fields that are required for synchronization map, these fields
+// are always synchronized and can not be excluded, hashed or masked
+var resTypeKeyFieldsMap = map[types.ResType][]string{
+	types.ModelClassification: {common.BKFieldID, common.BKClassificationIDField},
+	types.AssociationKind:     {common.BKFieldID, common.AssociationKindIDField},
+	types.Model:               {common.BKFieldID, common.BKObjIDField, common.BKClassificationIDField},
+	types.ModelAttrGroup:      {common.BKFieldID, common.BKObjIDField, common.BKPropertyGroupIDField},
+	types.ModelAttribute: {common.BKFieldID, common.BKObjIDField, common.BKPropertyIDField,
+		common.BKPropertyTypeField},
+	types.ModelUnique: {common.BKFieldID, common.BKObjIDField, common.BKObjectUniqueKeys},
+	types.ModelAssociation: {common.BKFieldID, common.AssociationObjAsstIDField, common.BKObjIDField,
+		common.BKAsstObjIDField, common.AssociationKindIDField},
+	types.Biz:    {common.BKAppIDField},
+	types.Set:    {common.BKSetIDField, common.BKAppIDField, common.BKParentIDField},
+	types.Module: {common.BKModuleIDField, common.BKSetIDField, common.BKAppIDField, common.BKParentIDField},
+	types.Host:   {common.BKHostIDField},
+	types.HostRelation: {common.BKHostIDField, common.BKModuleIDField, common.BKSetIDField,
+		common.BKAppIDField},
+	types.ObjectInstance: {common.BKInstIDField, common.BKObjIDField, common.BKAppIDField},
+	types.InstAsst: {common.BKFieldID, common.BKInstIDField, common.BKObjIDField, common.BKAsstInstIDField,
+		common.BKAsstObjIDField, common.AssociationObjAsstIDField, common.AssociationKindIDField},
+	types.ServiceInstance: {common.BKFieldID, common.BKAppIDField, common.BKModuleIDField, common.BKHostIDField},
+	types.Process:         {common.BKProcessIDField, common.BKAppIDField},
+	types.ProcessRelation: {common.BKProcessIDField, common.BKServiceInstanceIDField, common.BKHostIDField,
+		common.BKAppIDField},
+	types.QuotedInstance: {common.BKFieldID, common.BKInstIDField},
+}
+
+// Rules is the source cmdb sync rules, the data is filtered and masked by the rules before it is pushed
+type Rules struct {
+	resRuleMap map[types.ResType]*resRule
+}
+
+type resRule struct {
+	resType       types.ResType
+	includeFields map[string]struct{}
+	excludeFields map[string]struct{}
+	hashFields    map[string]struct{}
+	hashSalt      []byte
+	maskFields    map[string]struct{}
+	skipSubRes    map[string]struct{}
+	dropFilter    *filter.Expression
+}
+
+// New creates the sync rules by the sync rule config
+func New(conf []options.SyncRuleConf) (*Rules, error) {
+	rules := &Rules{
+		resRuleMap: make(map[types.ResType]*resRule),
+	}
+
+	for _, ruleConf := range conf {
+		keyFieldMap := make(map[string]struct{})
+		for _, field := range resTypeKeyFieldsMap[ruleConf.Resource] {
+			keyFieldMap[field] = struct{}{}
+		}
+
+		for _, fields := range [][]string{ruleConf.ExcludeFields, ruleConf.HashFields, ruleConf.MaskFields} {
+			for _, field := range fields {
+				if _, exists := keyFieldMap[field]; exists {
+					return nil, fmt.Errorf("%s field %s is required for synchronization, can not be excluded, hashed "+
+						"or masked", ruleConf.Resource, field)
+				}
+			}
+		}
+
+		dropFilter, err := ruleConf.ParseDropFilter()
+		if err != nil {
+			return nil, fmt.Errorf("parse %s drop filter failed, err: %v", ruleConf.Resource, err)
+		}
+
+		rule := &resRule{
+			resType:       ruleConf.Resource,
+			excludeFields: convertArrToMap(ruleConf.ExcludeFields),
+			hashFields:    convertArrToMap(ruleConf.HashFields),
+			hashSalt:      []byte(ruleConf.HashSalt),
+			maskFields:    convertArrToMap(ruleConf.MaskFields),
+			skipSubRes:    convertArrToMap(ruleConf.SkipSubResources),
+			dropFilter:    dropFilter,
+		}
+
+		if len(ruleConf.IncludeFields) > 0 {
+			rule.includeFields = convertArrToMap(ruleConf.IncludeFields)
+			for field := range keyFieldMap {
+				rule.includeFields[field] = struct{}{}
+			}
+		}
+
+		rules.resRuleMap[ruleConf.Resource] = rule
+	}
+
+	return rules, nil
+}
+
+func convertArrToMap(arr []string) map[string]struct{} {
+	m := make(map[string]struct{}, len(arr))
+	for _, val := range arr {
+		m[val] = struct{}{}
+	}
+	return m
+}
+
+// SkipSubRes checks if the sub resource is skipped by the sync rules
+func (r *Rules) SkipSubRes(resType types.ResType, subRes string) bool {
+	rule, exists := r.resRuleMap[resType]
+	if !exists {
+		return false
+	}
+
+	_, skip := rule.skipSubRes[subRes]
+	return skip
+}
+
+// FilterFullSyncData filter and mask the full sync data array, the data that matches the drop filter is removed
+func (r *Rules) FilterFullSyncData(kit *util.Kit, resType types.ResType, data any) (any, error) {
+	rule, exists := r.resRuleMap[resType]
+	if !exists {
+		return data, nil
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		blog.Errorf("marshal %s full sync data failed, err: %v, rid: %s", resType, err, kit.Rid)
+		return nil, err
+	}
+
+	rawDataArr := make([]json.RawMessage, 0)
+	if err = json.Unmarshal(raw, &rawDataArr); err != nil {
+		blog.Errorf("unmarshal %s full sync data(%s) failed, err: %v, rid: %s", resType, raw, err, kit.Rid)
+		return nil, err
+	}
+
+	result := make([]json.RawMessage, 0, len(rawDataArr))
+	for _, rawData := range rawDataArr {
+		detail, drop, err := rule.apply(kit, rawData)
+		if err != nil {
+			blog.Errorf("apply %s sync rule to data(%s) failed, err: %v, rid: %s", resType, rawData, err, kit.Rid)
+			return nil, err
+		}
+
+		if !drop {
+			result = append(result, detail)
+		}
+	}
+
+	return result, nil
+}
+
+// FilterEvents filter and mask the incremental sync events. The create events whose detail matches the drop filter
+// are removed, the update events are converted to delete events to remove the data that is already synchronized, and
+// the delete events are kept. The events of the skipped sub resources are removed.
+func (r *Rules) FilterEvents(kit *util.Kit, events []*types.EventInfo) []*types.EventInfo {
+	result := make([]*types.EventInfo, 0, len(events))
+	for _, event := range events {
+		rule, exists := r.resRuleMap[event.ResType]
+		if !exists {
+			result = append(result, event)
+			continue
+		}
+
+		if len(event.SubRes) > 0 && len(rule.skipSubRes) > 0 {
+			subRes := make([]string, 0, len(event.SubRes))
+			for _, res := range event.SubRes {
+				if _, skip := rule.skipSubRes[res]; !skip {
+					subRes = append(subRes, res)
+				}
+			}
+
+			if len(subRes) == 0 {
+				continue
+			}
+			event.SubRes = subRes
+		}
+
+		detail, drop, err := rule.apply(kit, event.Detail)
+		if err != nil {
+			blog.Errorf("apply %s sync rule to event detail(%s) failed, skip it, err: %v, rid: %s", event.ResType,
+				event.Detail, err, kit.Rid)
+			continue
+		}
+
+		if drop {
+			switch event.EventType {
+			case watch.Create:
+				continue
+			case watch.Update:
+				event.EventType = watch.Delete
+			}
+		}
+
+		event.Detail = detail
+		result = append(result, event)
+	}
+
+	return result
+}
+
+// apply the sync rule to the data, returns the converted data and if the data is dropped. the data that can not be
+// matched by the drop filter is not dropped, so that one unexpected record does not block the synchronization
+func (r *resRule) apply(kit *util.Kit, data json.RawMessage) (json.RawMessage, bool, error) {
+	if r.dropFilter != nil {
+		matched, err := r.dropFilter.Match(filter.JsonString(data))
+		if err != nil {
+			blog.Errorf("match %s drop filter failed, do not drop it, record: %s, err: %v, rid: %s", r.resType,
+				r.recordID(data), err, kit.Rid)
+		}
+
+		if matched {
+			return data, true, nil
+		}
+	}
+
+	if r.includeFields == nil && len(r.excludeFields) == 0 && len(r.hashFields) == 0 && len(r.maskFields) == 0 {
+		return data, false, nil
+	}
+
+	fieldMap := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &fieldMap); err != nil {
+		return nil, false, err
+	}
+
+	for field, value := range fieldMap {
+		if r.includeFields != nil {
+			if _, exists := r.includeFields[field]; !exists {
+				delete(fieldMap, field)
+				continue
+			}
+		}
+
+		if _, exists := r.excludeFields[field]; exists {
+			delete(fieldMap, field)
+			continue
+		}
+
+		if _, exists := r.hashFields[field]; exists {
+			hashed, err := r.hash(value)
+			if err != nil {
+				return nil, false, err
+			}
+			fieldMap[field] = hashed
+			continue
+		}
+
+		if _, exists := r.maskFields[field]; exists {
+			fieldMap[field] = mask(value)
+		}
+	}
+
+	result, err := json.Marshal(fieldMap)
+	if err != nil {
+		return nil, false, err
+	}
+	return result, false, nil
+}
+
+// recordID returns the key fields that identify the data, used to locate the data in logs
+func (r *resRule) recordID(data json.RawMessage) string {
+	keyFields := resTypeKeyFieldsMap[r.resType]
+	if len(keyFields) == 0 {
+		return string(data)
+	}
+
+	ids := make([]string, 0, 2)
+	for _, field := range keyFields[:min(2, len(keyFields))] {
+		ids = append(ids, field+"="+gjson.GetBytes(data, field).Raw)
+	}
+	return strings.Join(ids, ",")
+}
+
+// hash returns the hex encoded hash of the value, the string value is hashed by its content, null value is not hashed
+func (r *resRule) hash(value json.RawMessage) (json.RawMessage, error) {
+	if string(value) == "null" {
+		return value, nil
+	}
+
+	content := []byte(value)
+	var str string
+	if err := json.Unmarshal(value, &str); err == nil {
+		content = []byte(str)
+	}
+
+	var h hash.Hash
+	if len(r.hashSalt) > 0 {
+		h = hmac.New(sha256.New, r.hashSalt)
+	} else {
+		h = sha256.New()
+	}
+	h.Write(content)
+
+	return json.Marshal(hex.EncodeToString(h.Sum(nil)))
+}
+
+// mask returns the masked value, the non-empty string value is masked as MaskedValue, other values are set to null
+func mask(value json.RawMessage) json.RawMessage {
+	var str string
+	if err := json.Unmarshal(value, &str); err == nil {
+		if str == "" {
+			return value
+		}
+		masked, _ := json.Marshal(MaskedValue)
+		return masked
+	}
+
+	return json.RawMessage("null")
+}
@@ -0,0 +1,302 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package rule
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"configcenter/pkg/synchronize/types"
+	"configcenter/src/common"
+	"configcenter/src/common/watch"
+	"configcenter/src/source_controller/transfer-service/app/options"
+	"configcenter/src/source_controller/transfer-service/sync/util"
+
+	"github.com/stretchr/testify/require"
+)
+
+func sha256Hex(salt, content string) string {
+	if salt == "" {
+		sum := sha256.Sum256([]byte(content))
+		return hex.EncodeToString(sum[:])
+	}
+
+	h := hmac.New(sha256.New, []byte(salt))
+	h.Write([]byte(content))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func applyTestRule(t *testing.T, conf options.SyncRuleConf, data string) (map[string]any, bool) {
+	rules, err := New([]options.SyncRuleConf{conf})
+	require.NoError(t, err)
+
+	res, drop, err := rules.resRuleMap[conf.Resource].apply(util.NewKit(), json.RawMessage(data))
+	require.NoError(t, err)
+
+	result := make(map[string]any)
+	require.NoError(t, json.Unmarshal(res, &result))
+	return result, drop
+}
+
+func TestNew(t *testing.T) {
+	// key fields can not be excluded, hashed or masked
+	for _, conf := range []options.SyncRuleConf{
+		{Resource: types.Host, ExcludeFields: []string{common.BKHostIDField}},
+		{Resource: types.Host, HashFields: []string{common.BKHostIDField}},
+		{Resource: types.ObjectInstance, MaskFields: []string{common.BKObjIDField}},
+	} {
+		_, err := New([]options.SyncRuleConf{conf})
+		require.Error(t, err)
+	}
+
+	_, err := New([]options.SyncRuleConf{{Resource: types.Host, DropFilter: "invalid"}})
+	require.Error(t, err)
+
+	// key fields are always included
+	rules, err := New([]options.SyncRuleConf{{Resource: types.Host, IncludeFields: []string{"bk_host_innerip"}}})
+	require.NoError(t, err)
+	require.Contains(t, rules.resRuleMap[types.Host].includeFields, common.BKHostIDField)
+}
+
+func TestApplyFields(t *testing.T) {
+	data := `{"bk_host_id":1,"bk_host_innerip":"127.0.0.1","operator":"admin","bk_asset_id":"asset",
+		"bk_comment":"comment","bk_sn":"","bk_cpu":8}`
+
+	res, drop := applyTestRule(t, options.SyncRuleConf{Resource: types.Host,
+		IncludeFields: []string{"bk_host_innerip", "bk_comment"}, MaskFields: []string{"bk_comment"}}, data)
+	require.False(t, drop)
+	require.Equal(t, map[string]any{"bk_host_id": float64(1), "bk_host_innerip": "127.0.0.1",
+		"bk_comment": MaskedValue}, res)
+
+	res, _ = applyTestRule(t, options.SyncRuleConf{Resource: types.Host, ExcludeFields: []string{"operator"},
+		HashFields: []string{"bk_asset_id", "bk_cpu"}, HashSalt: "salt",
+		MaskFields: []string{"bk_comment", "bk_sn"}}, data)
+	require.Equal(t, map[string]any{
+		"bk_host_id":      float64(1),
+		"bk_host_innerip": "127.0.0.1",
+		"bk_asset_id":     sha256Hex("salt", "asset"),
+		// non-string value is hashed by its json value
+		"bk_cpu":     sha256Hex("salt", "8"),
+		"bk_comment": MaskedValue,
+		// empty string is not masked
+		"bk_sn": "",
+	}, res)
+
+	// null value is not hashed, non-string value is masked as null
+	res, _ = applyTestRule(t, options.SyncRuleConf{Resource: types.Host, HashFields: []string{"bk_asset_id"},
+		MaskFields: []string{"bk_cpu"}}, `{"bk_host_id":1,"bk_asset_id":null,"bk_cpu":8}`)
+	require.Equal(t, map[string]any{"bk_host_id": float64(1), "bk_asset_id": nil, "bk_cpu": nil}, res)
+
+	// data is not changed if no field rule is set
+	rules, err := New([]options.SyncRuleConf{{Resource: types.Host}})
+	require.NoError(t, err)
+	raw, drop, err := rules.resRuleMap[types.Host].apply(util.NewKit(), json.RawMessage(data))
+	require.NoError(t, err)
+	require.False(t, drop)
+	require.Equal(t, data, string(raw))
+}
+
+func TestApplyNestedFields(t *testing.T) {
+	data := `{"bk_process_id":1,"bk_biz_id":2,"bind_info":[{"ip":"127.0.0.1","port":"80"}],
+		"extra":{"password":"secret","inner":{"password":"secret"}},"password":"secret"}`
+
+	// field rules only apply to the first level fields, nested fields with the same name are not changed
+	res, _ := applyTestRule(t, options.SyncRuleConf{Resource: types.Process,
+		MaskFields: []string{"password"}}, data)
+	require.Equal(t, MaskedValue, res["password"])
+	require.Equal(t, map[string]any{"password": "secret", "inner": map[string]any{"password": "secret"}},
+		res["extra"])
+
+	// the nested object and array are masked as null
+	res, _ = applyTestRule(t, options.SyncRuleConf{Resource: types.Process,
+		MaskFields: []string{"extra", "bind_info"}}, data)
+	require.Nil(t, res["extra"])
+	require.Nil(t, res["bind_info"])
+	require.Equal(t, "secret", res["password"])
+
+	// the nested object is hashed by its json value
+	res, _ = applyTestRule(t, options.SyncRuleConf{Resource: types.Process, HashFields: []string{"bind_info"}},
+		`{"bk_process_id":1,"bk_biz_id":2,"bind_info":[{"ip":"127.0.0.1"}]}`)
+	require.Equal(t, sha256Hex("", `[{"ip":"127.0.0.1"}]`), res["bind_info"])
+
+	// the nested object is excluded or included as a whole
+	res, _ = applyTestRule(t, options.SyncRuleConf{Resource: types.Process, IncludeFields: []string{"extra"}},
+		data)
+	require.Equal(t, map[string]any{"bk_process_id": float64(1), "bk_biz_id": float64(2),
+		"extra": map[string]any{"password": "secret", "inner": map[string]any{"password": "secret"}}}, res)
+
+	res, _ = applyTestRule(t, options.SyncRuleConf{Resource: types.Process, ExcludeFields: []string{"extra"}}, data)
+	require.NotContains(t, res, "extra")
+	require.Contains(t, res, "bind_info")
+}
+
+func TestDropFilter(t *testing.T) {
+	testCases := []struct {
+		filter string
+		data   string
+		drop   bool
+	}{
+		{
+			filter: `{"condition":"AND","rules":[{"field":"bk_os_type","operator":"equal","value":"2"}]}`,
+			data:   `{"bk_host_id":1,"bk_os_type":"2"}`,
+			drop:   true,
+		},
+		{
+			filter: `{"condition":"AND","rules":[{"field":"bk_os_type","operator":"equal","value":"2"}]}`,
+			data:   `{"bk_host_id":1,"bk_os_type":"1"}`,
+			drop:   false,
+		},
+		{
+			filter: `{"condition":"OR","rules":[{"field":"bk_os_type","operator":"equal","value":"2"},
+				{"field":"bk_cpu","operator":"greater","value":16}]}`,
+			data: `{"bk_host_id":1,"bk_os_type":"1","bk_cpu":32}`,
+			drop: true,
+		},
+		// nested field matched by field path
+		{
+			filter: `{"condition":"AND","rules":[{"field":"extra.env","operator":"equal","value":"prod"}]}`,
+			data:   `{"bk_host_id":1,"extra":{"env":"prod"}}`,
+			drop:   true,
+		},
+		{
+			filter: `{"condition":"AND","rules":[{"field":"extra.env","operator":"equal","value":"prod"}]}`,
+			data:   `{"bk_host_id":1,"extra":{"env":"test"},"env":"prod"}`,
+			drop:   false,
+		},
+		// nested field matched by filter object operator
+		{
+			filter: `{"condition":"AND","rules":[{"field":"extra","operator":"filter_object","value":
+				{"field":"env","operator":"in","value":["prod","pre"]}}]}`,
+			data: `{"bk_host_id":1,"extra":{"env":"pre"}}`,
+			drop: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		conf := options.SyncRuleConf{Resource: types.Host, DropFilter: testCase.filter,
+			MaskFields: []string{"bk_os_type"}}
+		res, drop := applyTestRule(t, conf, testCase.data)
+		require.Equal(t, testCase.drop, drop, testCase.data)
+
+		// dropped data is not converted, so that it is matched by the original data
+		if drop {
+			expected := make(map[string]any)
+			require.NoError(t, json.Unmarshal([]byte(testCase.data), &expected))
+			require.Equal(t, expected, res)
+		}
+	}
+}
+
+func TestDropFilterMatchError(t *testing.T) {
+	// the object value can not be compared by the equal operator, the record is kept instead of failing the sync
+	rules, err := New([]options.SyncRuleConf{{Resource: types.Host,
+		DropFilter: `{"field":"bk_os_type","operator":"equal","value":"2"}`}})
+	require.NoError(t, err)
+
+	data := []map[string]any{
+		{"bk_host_id": 1, "bk_os_type": map[string]any{"name": "linux"}},
+		{"bk_host_id": 2, "bk_os_type": "2"},
+		{"bk_host_id": 3, "bk_os_type": "1"},
+	}
+	res, err := rules.FilterFullSyncData(util.NewKit(), types.Host, data)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	require.JSONEq(t, `{"bk_host_id":1,"bk_os_type":{"name":"linux"}}`, string(res.([]json.RawMessage)[0]))
+	require.JSONEq(t, `{"bk_host_id":3,"bk_os_type":"1"}`, string(res.([]json.RawMessage)[1]))
+
+	events := []*types.EventInfo{
+		{EventType: watch.Create, ResType: types.Host, Detail: json.RawMessage(`{"bk_host_id":1,"bk_os_type":{}}`)},
+	}
+	require.Len(t, rules.FilterEvents(util.NewKit(), events), 1)
+
+	require.Equal(t, "bk_host_id=1", rules.resRuleMap[types.Host].recordID(json.RawMessage(`{"bk_host_id":1}`)))
+}
+
+func TestSkipSubRes(t *testing.T) {
+	rules, err := New([]options.SyncRuleConf{{Resource: types.ObjectInstance,
+		SkipSubResources: []string{"bk_switch"}}})
+	require.NoError(t, err)
+
+	require.True(t, rules.SkipSubRes(types.ObjectInstance, "bk_switch"))
+	require.False(t, rules.SkipSubRes(types.ObjectInstance, "bk_router"))
+	require.False(t, rules.SkipSubRes(types.InstAsst, "bk_switch"))
+}
+
+func TestFilterFullSyncData(t *testing.T) {
+	rules, err := New([]options.SyncRuleConf{{Resource: types.Host, ExcludeFields: []string{"operator"},
+		DropFilter: `{"condition":"AND","rules":[{"field":"bk_os_type","operator":"equal","value":"2"}]}`}})
+	require.NoError(t, err)
+
+	data := []map[string]any{
+		{"bk_host_id": 1, "bk_os_type": "1", "operator": "admin"},
+		{"bk_host_id": 2, "bk_os_type": "2", "operator": "admin"},
+	}
+	res, err := rules.FilterFullSyncData(util.NewKit(), types.Host, data)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.JSONEq(t, `{"bk_host_id":1,"bk_os_type":"1"}`, string(res.([]json.RawMessage)[0]))
+
+	// data of the resource type without sync rule is not changed
+	res, err = rules.FilterFullSyncData(util.NewKit(), types.Biz, data)
+	require.NoError(t, err)
+	require.Equal(t, data, res)
+}
+
+func TestFilterEvents(t *testing.T) {
+	rules, err := New([]options.SyncRuleConf{{Resource: types.ObjectInstance, MaskFields: []string{"secret"},
+		SkipSubResources: []string{"bk_switch"},
+		DropFilter:       `{"condition":"AND","rules":[{"field":"env","operator":"equal","value":"prod"}]}`}})
+	require.NoError(t, err)
+
+	events := []*types.EventInfo{
+		{EventType: watch.Create, ResType: types.ObjectInstance, Oid: "1", SubRes: []string{"bk_router"},
+			Detail: json.RawMessage(`{"bk_inst_id":1,"bk_obj_id":"bk_router","secret":"a"}`)},
+		{EventType: watch.Create, ResType: types.ObjectInstance, Oid: "2", SubRes: []string{"bk_switch"},
+			Detail: json.RawMessage(`{"bk_inst_id":2,"bk_obj_id":"bk_switch"}`)},
+		{EventType: watch.Create, ResType: types.ObjectInstance, Oid: "3", SubRes: []string{"bk_router"},
+			Detail: json.RawMessage(`{"bk_inst_id":3,"bk_obj_id":"bk_router","env":"prod"}`)},
+		{EventType: watch.Update, ResType: types.ObjectInstance, Oid: "4", SubRes: []string{"bk_router"},
+			Detail: json.RawMessage(`{"bk_inst_id":4,"bk_obj_id":"bk_router","env":"prod"}`)},
+		{EventType: watch.Delete, ResType: types.ObjectInstance, Oid: "5", SubRes: []string{"bk_router"},
+			Detail: json.RawMessage(`{"bk_inst_id":5,"bk_obj_id":"bk_router","env":"prod"}`)},
+		{EventType: watch.Create, ResType: types.ObjectInstance, Oid: "6", SubRes: []string{"bk_router"},
+			Detail: json.RawMessage(`invalid`)},
+		{EventType: watch.Create, ResType: types.Biz, Oid: "7",
+			Detail: json.RawMessage(`{"bk_biz_id":1,"secret":"a"}`)},
+	}
+
+	res := rules.FilterEvents(util.NewKit(), events)
+	require.Len(t, res, 4)
+
+	// the event detail is masked
+	require.Equal(t, "1", res[0].Oid)
+	require.JSONEq(t, `{"bk_inst_id":1,"bk_obj_id":"bk_router","secret":"******"}`, string(res[0].Detail))
+
+	// the skipped sub resource and the create event that matches the drop filter are removed, the update event that
+	// matches the drop filter is converted to delete event, the delete event is kept
+	require.Equal(t, "4", res[1].Oid)
+	require.Equal(t, watch.Delete, res[1].EventType)
+	require.Equal(t, "5", res[2].Oid)
+	require.Equal(t, watch.Delete, res[2].EventType)
+
+	// the event of the resource type without sync rule is not changed
+	require.Equal(t, "7", res[3].Oid)
+	require.JSONEq(t, `{"bk_biz_id":1,"secret":"a"}`, string(res[3].Detail))
+}
@@ -87,6 +87,11 @@ func (s *Syncer) loopPushFullSyncData(interval time.Duration) {
 // pushFullSyncData push full sync data for one resource
 func (s *resSyncer) pushFullSyncData(subRes string) {
 	kit := util.NewKit()
+	if s.rules.SkipSubRes(s.lgc.ResType(), subRes) {
+		blog.V(4).Infof("%s-%s is skipped by sync rules, rid: %s", s.lgc.ResType(), subRes, kit.Rid)
+		return
+	}
+
 	startTime := time.Now()
 	blog.Infof("start push %s-%s full sync data, start time: %s, rid: %s", s.lgc.ResType(), subRes, startTime, kit.Rid)
 
@@ -118,16 +123,11 @@ func (s *resSyncer) pushFullSyncData(subRes string) {
 func (s *resSyncer) doOnePushFullSyncDataStep(kit *util.Kit, subRes string, start, end map[string]int64) (bool,
 	map[string]int64, error) {
 
-	// list data from the start index
-	listOpt := &types.ListDataOpt{
-		SubRes: subRes,
-		Start:  start,
-		End:    end,
-	}
-
-	info, err := s.lgc.ListData(kit, listOpt)
+	info, transData, err := s.listFullSyncData(kit, subRes, start, end)
 	if err != nil {
-		blog.Errorf("list %s data failed, err: %v, opt: %+v, rid: %s", s.lgc.ResType(), err, *listOpt, kit.Rid)
+		if info != nil {
+			return false, start, err
+		}
 		// start from the next interval
 		nextStart := make(map[string]int64)
 		for field, id := range start {
@@ -136,23 +136,12 @@ func (s *resSyncer) doOnePushFullSyncDataStep(kit *util.Kit, subRes string, star
 		return false, nextStart, err
 	}
 
-	// all data has been listed, do not have a sync interval end
-	syncEnd := info.NextStart
-	if info.IsAll {
-		syncEnd = make(map[string]int64)
-	}
-
 	// push full sync data to transfer medium
 	pushOpt := &types.PushSyncDataOpt{
 		ResType:     s.lgc.ResType(),
 		SubRes:      subRes,
 		IsIncrement: false,
-		Data: &types.FullSyncTransData{
-			Name:  s.name,
-			Start: start,
-			End:   syncEnd,
-			Data:  info.Data,
-		},
+		Data:        transData,
 	}
 	err = s.transMedium.PushSyncData(kit.Ctx, kit.Header, pushOpt)
 	if err != nil {
@@ -163,3 +152,41 @@ func (s *resSyncer) doOnePushFullSyncDataStep(kit *util.Kit, subRes string, star
 
 	return info.IsAll, info.NextStart, nil
 }
+
+// listFullSyncData list one interval of full sync data from the start index, returns nil list data result if list
+// data failed, the full sync data is also used by the sync audit
+func (s *resSyncer) listFullSyncData(kit *util.Kit, subRes string, start, end map[string]int64) (*types.ListDataRes,
+	*types.FullSyncTransData, error) {
+
+	// list data from the start index
+	listOpt := &types.ListDataOpt{
+		SubRes: subRes,
+		Start:  start,
+		End:    end,
+	}
+
+	info, err := s.lgc.ListData(kit, listOpt)
+	if err != nil {
+		blog.Errorf("list %s data failed, err: %v, opt: %+v, rid: %s", s.lgc.ResType(), err, *listOpt, kit.Rid)
+		return nil, nil, err
+	}
+
+	// all data has been listed, do not have a sync interval end
+	syncEnd := info.NextStart
+	if info.IsAll {
+		syncEnd = make(map[string]int64)
+	}
+
+	// filter and mask data by sync rules, the interval is still pushed so that the dropped data is removed in dest
+	data, err := s.rules.FilterFullSyncData(kit, s.lgc.ResType(), info.Data)
+	if err != nil {
+		return info, nil, err
+	}
+
+	return info, &types.FullSyncTransData{
+		Name:  s.name,
+		Start: start,
+		End:   syncEnd,
+		Data:  data,
+	}, nil
+}
@@ -0,0 +1,142 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"configcenter/pkg/synchronize/types"
+	"configcenter/src/common"
+	"configcenter/src/source_controller/transfer-service/app/options"
+	"configcenter/src/source_controller/transfer-service/sync/rule"
+	"configcenter/src/source_controller/transfer-service/sync/util"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMedium is the transfer medium that records the pushed data
+type fakeMedium struct {
+	pushed  []*types.PushSyncDataOpt
+	pushErr error
+}
+
+func (m *fakeMedium) PushSyncData(_ context.Context, _ http.Header, opt *types.PushSyncDataOpt) error {
+	if m.pushErr != nil {
+		return m.pushErr
+	}
+	m.pushed = append(m.pushed, opt)
+	return nil
+}
+
+func (m *fakeMedium) PullSyncData(_ context.Context, _ http.Header, _ *types.PullSyncDataOpt) (
+	*types.PullSyncDataRes, error) {
+	return nil, errors.New("not supported")
+}
+
+func (m *fakeMedium) Close() error {
+	return nil
+}
+
+func newFakeFullSyncer(t *testing.T) (*resSyncer, *fakeMedium) {
+	rules, err := rule.New([]options.SyncRuleConf{{Resource: types.Host,
+		DropFilter: `{"condition":"AND","rules":[{"field":"Value","operator":"equal","value":"x"}]}`}})
+	require.NoError(t, err)
+
+	transMedium := new(fakeMedium)
+	return &resSyncer{
+		name:        "src",
+		transMedium: transMedium,
+		lgc: &fakeLogics{
+			resType:  types.Host,
+			data:     []fakeData{{1, "a"}, {2, "x"}, {3, "c"}},
+			pageSize: 2,
+		},
+		rules: rules,
+	}, transMedium
+}
+
+func TestDoOnePushFullSyncDataStep(t *testing.T) {
+	syncer, transMedium := newFakeFullSyncer(t)
+
+	isAll, nextStart, err := syncer.doOnePushFullSyncDataStep(util.NewKit(), "", make(map[string]int64), nil)
+	require.NoError(t, err)
+	require.False(t, isAll)
+	require.Equal(t, map[string]int64{common.BKFieldID: 2}, nextStart)
+
+	// the interval is pushed with the data that is not dropped by the sync rules
+	require.Len(t, transMedium.pushed, 1)
+	pushed := transMedium.pushed[0]
+	require.Equal(t, types.Host, pushed.ResType)
+	require.False(t, pushed.IsIncrement)
+	transData := pushed.Data.(*types.FullSyncTransData)
+	require.Equal(t, "src", transData.Name)
+	require.Empty(t, transData.Start)
+	require.Equal(t, map[string]int64{common.BKFieldID: 2}, transData.End)
+	require.Equal(t, []json.RawMessage{json.RawMessage(`{"ID":1,"Value":"a"}`)}, transData.Data)
+
+	// the last interval has no end
+	isAll, _, err = syncer.doOnePushFullSyncDataStep(util.NewKit(), "", nextStart, nil)
+	require.NoError(t, err)
+	require.True(t, isAll)
+	transData = transMedium.pushed[1].Data.(*types.FullSyncTransData)
+	require.Equal(t, nextStart, transData.Start)
+	require.Empty(t, transData.End)
+	require.Equal(t, []json.RawMessage{json.RawMessage(`{"ID":3,"Value":"c"}`)}, transData.Data)
+
+	// push failed, the next start is returned so that the interval is retried from the same start by the caller
+	transMedium.pushErr = errors.New("push failed")
+	_, _, err = syncer.doOnePushFullSyncDataStep(util.NewKit(), "", make(map[string]int64), nil)
+	require.Error(t, err)
+
+	// list failed, start from the next interval
+	syncer.lgc.(*fakeLogics).listErr = errors.New("list failed")
+	_, nextStart, err = syncer.doOnePushFullSyncDataStep(util.NewKit(), "", map[string]int64{common.BKFieldID: 2},
+		nil)
+	require.Error(t, err)
+	require.Equal(t, map[string]int64{common.BKFieldID: 3}, nextStart)
+}
+
+func TestListFullSyncData(t *testing.T) {
+	syncer, transMedium := newFakeFullSyncer(t)
+
+	// the sync audit data is the same as the full sync data
+	info, transData, err := syncer.listFullSyncData(util.NewKit(), "", make(map[string]int64), nil)
+	require.NoError(t, err)
+	require.False(t, info.IsAll)
+	require.Equal(t, map[string]int64{common.BKFieldID: 2}, info.NextStart)
+
+	_, _, err = syncer.doOnePushFullSyncDataStep(util.NewKit(), "", make(map[string]int64), nil)
+	require.NoError(t, err)
+	require.Equal(t, transMedium.pushed[0].Data, transData)
+
+	// list data with the interval end
+	info, transData, err = syncer.listFullSyncData(util.NewKit(), "", make(map[string]int64),
+		map[string]int64{common.BKFieldID: 1})
+	require.NoError(t, err)
+	require.True(t, info.IsAll)
+	require.Empty(t, transData.End)
+	require.Equal(t, []json.RawMessage{json.RawMessage(`{"ID":1,"Value":"a"}`)}, transData.Data)
+
+	syncer.lgc.(*fakeLogics).listErr = errors.New("list failed")
+	info, _, err = syncer.listFullSyncData(util.NewKit(), "", make(map[string]int64), nil)
+	require.Error(t, err)
+	require.Nil(t, info)
+}
@@ -32,6 +32,7 @@ import (
 	"configcenter/src/source_controller/transfer-service/sync/logics"
 	"configcenter/src/source_controller/transfer-service/sync/medium"
 	"configcenter/src/source_controller/transfer-service/sync/metadata"
+	"configcenter/src/source_controller/transfer-service/sync/rule"
 	"configcenter/src/source_controller/transfer-service/sync/watch"
 	"configcenter/src/storage/driver/mongodb"
 	"configcenter/src/storage/stream"
@@ -43,9 +44,13 @@ import (
 // Syncer is cmdb data syncer
 type Syncer struct {
 	enableSync   bool
+	role         options.SyncRole
 	isMaster     discovery.ServiceManageInterface
 	metadata     *metadata.Metadata
 	resSyncerMap map[types.ResType]*resSyncer
+	conflicts    *logics.ConflictRecorder
+	rules        *rule.Rules
+	transMedium  medium.ClientI
 }
 
 // NewSyncer new cmdb data syncer
@@ -77,24 +82,35 @@ func NewSyncer(conf *options.Config, isMaster discovery.ServiceManageInterface,
 		return nil, err
 	}
 
-	transMedium, err := medium.NewTransferMedium(conf.Sync.TransMediumAddr, reg)
+	transMedium, err := newTransferMedium(conf.Sync, reg)
 	if err != nil {
-		blog.Errorf("new transfer medium failed, err: %v, addr: %+v", err, conf.Sync.TransMediumAddr)
+		return nil, err
+	}
+
+	rules, err := rule.New(conf.Sync.Rules)
+	if err != nil {
+		blog.Errorf("new sync rules failed, err: %v", err)
 		return nil, err
 	}
 
 	idRuleMap, srcInnerIDMap := parseDestExConf(conf)
+	conflicts := logics.NewConflictRecorder()
 	resLgcMap := logics.New(&logics.LogicsConfig{
 		Metadata:      meta,
 		IDRuleMap:     idRuleMap,
 		SrcInnerIDMap: srcInnerIDMap,
+		Conflicts:     conflicts,
 	})
 
 	syncer := &Syncer{
 		enableSync:   true,
+		role:         conf.Sync.Role,
 		isMaster:     isMaster,
 		metadata:     meta,
 		resSyncerMap: make(map[types.ResType]*resSyncer),
+		conflicts:    conflicts,
+		rules:        rules,
+		transMedium:  transMedium,
 	}
 
 	for _, resType := range types.ListAllResType() {
@@ -107,6 +123,7 @@ func NewSyncer(conf *options.Config, isMaster discovery.ServiceManageInterface,
 			lgc:         lgc,
 			name:        conf.Sync.Name,
 			transMedium: transMedium,
+			rules:       rules,
 		}
 	}
 
@@ -118,6 +135,47 @@ func NewSyncer(conf *options.Config, isMaster discovery.ServiceManageInterface,
 	return syncer, nil
 }
 
+// Close closes the transfer medium client of the syncer, it is called when the service is shutting down
+func (s *Syncer) Close() error {
+	if !s.enableSync || s.transMedium == nil {
+		return nil
+	}
+
+	if err := s.transMedium.Close(); err != nil {
+		blog.Errorf("close transfer medium failed, err: %v", err)
+		return err
+	}
+	return nil
+}
+
+// newTransferMedium new transfer medium client by the transfer medium type
+func newTransferMedium(conf *options.SyncConfig, reg prometheus.Registerer) (medium.ClientI, error) {
+	switch conf.TransMediumType {
+	case "", options.TransMediumHTTP:
+		transMedium, err := medium.NewTransferMedium(conf.TransMediumAddr, reg)
+		if err != nil {
+			blog.Errorf("new transfer medium failed, err: %v, addr: %+v", err, conf.TransMediumAddr)
+			return nil, err
+		}
+		return transMedium, nil
+	case options.TransMediumBundle:
+		transMedium, err := medium.NewBundleMedium(&medium.BundleOption{
+			Name:          conf.Name,
+			Dir:           conf.Bundle.Dir,
+			SignKey:       conf.Bundle.SignKey,
+			MaxMessages:   conf.Bundle.MaxMessages,
+			FlushInterval: time.Duration(conf.Bundle.FlushIntervalSeconds) * time.Second,
+		})
+		if err != nil {
+			blog.Errorf("new bundle transfer medium failed, err: %v, dir: %s", err, conf.Bundle.Dir)
+			return nil, err
+		}
+		return transMedium, nil
+	default:
+		return nil, fmt.Errorf("invalid transfer medium type: %s", conf.TransMediumType)
+	}
+}
+
 func parseDestExConf(conf *options.Config) (map[types.ResType]map[string][]options.IDRuleInfo,
 	map[string]*options.InnerDataIDConf) {
 
@@ -173,7 +231,7 @@ func (s *Syncer) run(conf *options.Config, loopW stream.LoopInterface, transMedi
 			return nil
 		}
 
-		watcher, err := watch.New(conf.Sync.Name, loopW, s.isMaster, s.metadata, cacheCli, transMedium)
+		watcher, err := watch.New(conf.Sync.Name, loopW, s.isMaster, s.metadata, cacheCli, transMedium, s.rules)
 		if err != nil {
 			blog.Errorf("new watcher failed, err: %v", err)
 			return err
@@ -204,4 +262,5 @@ type resSyncer struct {
 	transMedium medium.ClientI
 	lgc         logics.Logics
 	metadata    *metadata.Metadata
+	rules       *rule.Rules
 }
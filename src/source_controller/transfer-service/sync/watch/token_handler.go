@@ -49,6 +49,32 @@ type tokenInfo struct {
 	Token       string                      `bson:"token"`
 	Cursor      map[watch.CursorType]string `bson:"cursor"`
 	StartAtTime *metadata.Time              `bson:"start_at_time"`
+	// LastPushTime is the unix time when the last incremental sync data is pushed to the transfer medium
+	LastPushTime int64 `bson:"last_push_time"`
+}
+
+const lastPushTimeField = "last_push_time"
+
+// GetIncrCursor get the incremental sync cursor info of the resource type
+func GetIncrCursor(ctx context.Context, resType synctypes.ResType) (*synctypes.SyncAuditIncrCursor, error) {
+	info, err := newTokenHandler(resType).getWatchTokenInfo(ctx, common.BKCursorField, common.BKStartAtTimeField,
+		lastPushTimeField)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor := &synctypes.SyncAuditIncrCursor{
+		Cursor:       make(map[string]string),
+		LastPushTime: info.LastPushTime,
+	}
+	for cursorType, value := range info.Cursor {
+		cursor.Cursor[string(cursorType)] = value
+	}
+	if info.StartAtTime != nil {
+		cursor.StartAtTime = &info.StartAtTime.Time
+	}
+
+	return cursor, nil
 }
 
 // SetLastWatchToken set last event watch token
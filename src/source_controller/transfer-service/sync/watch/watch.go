@@ -30,6 +30,7 @@ import (
 	"configcenter/src/common/util"
 	"configcenter/src/source_controller/transfer-service/sync/medium"
 	syncmeta "configcenter/src/source_controller/transfer-service/sync/metadata"
+	"configcenter/src/source_controller/transfer-service/sync/rule"
 	"configcenter/src/storage/driver/mongodb"
 	"configcenter/src/storage/stream"
 )
@@ -42,12 +43,14 @@ type Watcher struct {
 	metadata      *syncmeta.Metadata
 	cacheCli      cacheservice.CacheServiceClientInterface
 	transMedium   medium.ClientI
+	rules         *rule.Rules
 	tokenHandlers map[types.ResType]*tokenHandler
 }
 
 // New new cmdb data syncer event watcher
 func New(name string, loopW stream.LoopInterface, isMaster discovery.ServiceManageInterface, meta *syncmeta.Metadata,
-	cacheCli cacheservice.CacheServiceClientInterface, transMedium medium.ClientI) (*Watcher, error) {
+	cacheCli cacheservice.CacheServiceClientInterface, transMedium medium.ClientI, rules *rule.Rules) (*Watcher,
+	error) {
 
 	// create cmdb data syncer event watch token table
 	ctx := util.SetDBReadPreference(context.Background(), common.SecondaryPreferredMode)
@@ -86,6 +89,7 @@ func New(name string, loopW stream.LoopInterface, isMaster discovery.ServiceMana
 		metadata:      meta,
 		cacheCli:      cacheCli,
 		transMedium:   transMedium,
+		rules:         rules,
 		tokenHandlers: make(map[types.ResType]*tokenHandler),
 	}
 
@@ -179,6 +179,8 @@ type eventDetailMap struct {
 }
 
 func (w *Watcher) pushSyncData(kit *util.Kit, events []*types.EventInfo) error {
+	// filter and mask events by sync rules before they are pushed to transfer medium
+	events = w.rules.FilterEvents(kit, events)
 	eventInfoMap := w.classifyEvents(kit, events)
 
 	// push upsert and delete event info to transfer medium
@@ -201,6 +203,7 @@ func (w *Watcher) pushSyncData(kit *util.Kit, events []*types.EventInfo) error {
 			continue
 		}
 
+		pushTime := time.Now().Unix()
 		pushOpt := &types.PushSyncDataOpt{
 			ResType:     resType,
 			IsIncrement: true,
@@ -208,6 +211,7 @@ func (w *Watcher) pushSyncData(kit *util.Kit, events []*types.EventInfo) error {
 				Name:       w.name,
 				UpsertInfo: upsertInfo,
 				DeleteInfo: deleteInfo,
+				PushTime:   pushTime,
 			},
 		}
 		err := w.transMedium.PushSyncData(kit.Ctx, kit.Header, pushOpt)
@@ -215,6 +219,11 @@ func (w *Watcher) pushSyncData(kit *util.Kit, events []*types.EventInfo) error {
 			blog.Errorf("push %s incr sync data failed, err: %v, opt: %+v, rid: %s", resType, err, *pushOpt, kit.Rid)
 			return err
 		}
+
+		// record the last push time for sync audit, the failure only affects the sync lag in audit report
+		if handler, exists := w.tokenHandlers[resType]; exists {
+			_ = handler.setWatchTokenInfo(kit.Ctx, mapstr.MapStr{lastPushTimeField: pushTime})
+		}
 	}
 
 	return nil
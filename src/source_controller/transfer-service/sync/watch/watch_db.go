@@ -38,6 +38,34 @@ var resTypeWatchOptMap = map[synctypes.ResType]types.Options{
 		EventStruct: new(metadata.ServiceInstance),
 		Collection:  common.BKTableNameServiceInstance,
 	},
+	synctypes.ModelClassification: {
+		EventStruct: new(map[string]interface{}),
+		Collection:  common.BKTableNameObjClassification,
+	},
+	synctypes.AssociationKind: {
+		EventStruct: new(map[string]interface{}),
+		Collection:  common.BKTableNameAsstDes,
+	},
+	synctypes.Model: {
+		EventStruct: new(map[string]interface{}),
+		Collection:  common.BKTableNameObjDes,
+	},
+	synctypes.ModelAttrGroup: {
+		EventStruct: new(map[string]interface{}),
+		Collection:  common.BKTableNamePropertyGroup,
+	},
+	synctypes.ModelAttribute: {
+		EventStruct: new(map[string]interface{}),
+		Collection:  common.BKTableNameObjAttDes,
+	},
+	synctypes.ModelUnique: {
+		EventStruct: new(map[string]interface{}),
+		Collection:  common.BKTableNameObjUnique,
+	},
+	synctypes.ModelAssociation: {
+		EventStruct: new(map[string]interface{}),
+		Collection:  common.BKTableNameObjAsst,
+	},
 }
 
 // watchDB watch db events for resource that are not watched by flow
@@ -41,6 +41,9 @@ type DB interface {
 	// NextSequences 批量获取新序列号(非事务)
 	NextSequences(ctx context.Context, sequenceName string, num int) ([]uint64, error)
 
+	// IDGeneratorStep 获取序列号的步长，为进程启动时加载的配置，NextSequence 每次按该步长递增
+	IDGeneratorStep() int
+
 	// Ping 健康检查
 	Ping() error // 健康检查
 
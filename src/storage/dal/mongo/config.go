@@ -25,6 +25,7 @@ import (
 	"configcenter/src/common/ssl"
 	"configcenter/src/storage/dal"
 	"configcenter/src/storage/dal/mongo/local"
+	"configcenter/src/storage/dal/types"
 )
 
 const (
@@ -63,6 +64,54 @@ type Config struct {
 	SocketTimeout int
 	DisableInsert bool
 	TLSConf       *ssl.TLSClientConfig
+	// ReadPref is the default read preference of all collections
+	ReadPref types.ReadPref
+	// CollReadPref is the read preference of specified collections, it takes precedence over the default
+	CollReadPref map[string]types.ReadPref
+}
+
+// ReadPrefConfig is the read preference config in the mongodb configuration file
+type ReadPrefConfig struct {
+	// Mode is the default read preference mode name, e.g. secondaryPreferred
+	Mode string `mapstructure:"mode"`
+	// MaxStalenessSeconds is the default max replication lag of the secondary that can be read
+	MaxStalenessSeconds int `mapstructure:"maxStalenessSeconds"`
+	// Collections is the read preference of specified collections
+	Collections []CollReadPrefConfig `mapstructure:"collections"`
+}
+
+// CollReadPrefConfig is the read preference config of one collection
+type CollReadPrefConfig struct {
+	Name                string `mapstructure:"name"`
+	Mode                string `mapstructure:"mode"`
+	MaxStalenessSeconds int    `mapstructure:"maxStalenessSeconds"`
+}
+
+// Parse parse the read preference config into the default read preference and the collection read preferences
+func (c ReadPrefConfig) Parse() (types.ReadPref, map[string]types.ReadPref, error) {
+	mode, err := local.ParseReadPrefMode(c.Mode)
+	if err != nil {
+		return types.ReadPref{}, nil, err
+	}
+	readPref := types.ReadPref{Mode: mode, MaxStaleness: time.Duration(c.MaxStalenessSeconds) * time.Second}
+
+	collReadPref := make(map[string]types.ReadPref)
+	for _, coll := range c.Collections {
+		if coll.Name == "" {
+			return types.ReadPref{}, nil, fmt.Errorf("read preference collection name is not set")
+		}
+
+		mode, err = local.ParseReadPrefMode(coll.Mode)
+		if err != nil {
+			return types.ReadPref{}, nil, fmt.Errorf("collection %s %v", coll.Name, err)
+		}
+		collReadPref[coll.Name] = types.ReadPref{
+			Mode:         mode,
+			MaxStaleness: time.Duration(coll.MaxStalenessSeconds) * time.Second,
+		}
+	}
+
+	return readPref, collReadPref, nil
 }
 
 // BuildURI return mongo uri according to  https://docs.mongodb.com/manual/reference/connection-string/
@@ -92,6 +141,8 @@ func (c Config) GetMongoConf() local.MongoConf {
 		SocketTimeout: c.SocketTimeout,
 		DisableInsert: c.DisableInsert,
 		TLS:           c.TLSConf,
+		ReadPref:      c.ReadPref,
+		CollReadPref:  c.CollReadPref,
 	}
 }
 
@@ -104,6 +155,8 @@ func (c Config) GetMongoClient() (db dal.RDB, err error) {
 		RsName:        c.RsName,
 		SocketTimeout: c.SocketTimeout,
 		TLS:           c.TLSConf,
+		ReadPref:      c.ReadPref,
+		CollReadPref:  c.CollReadPref,
 	}
 	db, err = local.NewMgo(mongoConf, time.Minute)
 	if err != nil {
@@ -56,6 +56,14 @@ func initMongoMetric() {
 			Buckets:   []float64{0.02, 0.04, 0.06, 0.08, 0.1, 0.3, 0.5, 0.7, 1, 5, 10, 20, 30, 60},
 		}, []string{"collection", "operation"})
 		metrics.Register().MustRegister(mtc.operDuration)
+
+		mtc.readPrefCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "mongo",
+			Name:      "read_preference_count",
+			Help:      "the total read operation count with mongodb grouped by the chosen read preference",
+		}, []string{"collection", "operation", "read_preference"})
+		metrics.Register().MustRegister(mtc.readPrefCount)
 	})
 }
 
@@ -82,6 +90,8 @@ type mongoMetric struct {
 	totalErrorCount *prometheus.CounterVec
 	// record the operate duration with mongodb
 	operDuration *prometheus.HistogramVec
+	// record the read operation count with mongodb by the chosen read preference
+	readPrefCount *prometheus.CounterVec
 }
 
 func (m *mongoMetric) collectOperCount(collection string, operation oper) {
@@ -116,3 +126,15 @@ func (m *mongoMetric) collectOperDuration(collection string, operation oper, dur
 		"operation":  string(operation),
 	}).Observe(duration.Seconds())
 }
+
+func (m *mongoMetric) collectReadPref(collection string, operation oper, readPref string) {
+	if m == nil {
+		return
+	}
+
+	m.readPrefCount.With(prometheus.Labels{
+		"collection":      collection,
+		"operation":       string(operation),
+		"read_preference": readPref,
+	}).Inc()
+}
@@ -43,7 +43,6 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
-	"go.mongodb.org/mongo-driver/mongo/readpref"
 	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
 	"go.mongodb.org/mongo-driver/x/mongo/driver/connstring"
 )
@@ -63,6 +62,10 @@ type mongoCliConf struct {
 	idGenStep int
 	// disableInsert defines if insert operation for specific tables are disabled
 	disableInsert bool
+	// readPref is the default read preference of all collections
+	readPref types.ReadPref
+	// collReadPref is the read preference of specified collections, it takes precedence over the default
+	collReadPref map[string]types.ReadPref
 }
 
 var _ dal.DB = new(Mongo)
@@ -77,6 +80,10 @@ type MongoConf struct {
 	SocketTimeout  int
 	DisableInsert  bool
 	TLS            *ssl.TLSClientConfig
+	// ReadPref is the default read preference of all collections
+	ReadPref types.ReadPref
+	// CollReadPref is the read preference of specified collections, it takes precedence over the default
+	CollReadPref map[string]types.ReadPref
 }
 
 // NewMgo returns new RDB
@@ -135,6 +142,8 @@ func NewMgo(config MongoConf, timeout time.Duration) (*Mongo, error) {
 		tm:     &TxnManager{},
 		conf: &mongoCliConf{
 			disableInsert: config.DisableInsert,
+			readPref:      config.ReadPref,
+			collReadPref:  config.CollReadPref,
 		},
 	}
 
@@ -471,7 +480,7 @@ func (f *Find) All(ctx context.Context, result interface{}) error {
 		return err
 	}
 
-	opt := getCollectionOption(ctx)
+	opt := f.getCollectionOption(ctx, f.collName, findOper, f.option.ReadPref)
 
 	return f.tm.AutoRunWithTxn(ctx, f.dbc, func(ctx context.Context) error {
 		cursor, err := f.dbc.Database(f.dbname).Collection(f.collName, opt).Find(ctx, f.filter, findOpts)
@@ -532,7 +541,7 @@ func (f *Find) List(ctx context.Context, result interface{}) (int64, error) {
 		return 0, err
 	}
 
-	opt := getCollectionOption(ctx)
+	opt := f.getCollectionOption(ctx, f.collName, findOper, f.option.ReadPref)
 
 	var total int64
 	err = f.tm.AutoRunWithTxn(ctx, f.dbc, func(ctx context.Context) error {
@@ -579,7 +588,7 @@ func (f *Find) One(ctx context.Context, result interface{}) error {
 		return err
 	}
 
-	opt := getCollectionOption(ctx)
+	opt := f.getCollectionOption(ctx, f.collName, findOper, f.option.ReadPref)
 	return f.tm.AutoRunWithTxn(ctx, f.dbc, func(ctx context.Context) error {
 		cursor, err := f.dbc.Database(f.dbname).Collection(f.collName, opt).Find(ctx, f.filter, findOpts)
 		if err != nil {
@@ -612,7 +621,7 @@ func (f *Find) Count(ctx context.Context) (uint64, error) {
 		return 0, err
 	}
 
-	opt := getCollectionOption(ctx)
+	opt := f.getCollectionOption(ctx, f.collName, countOper, f.option.ReadPref)
 
 	sessCtx, _, useTxn, err := f.tm.GetTxnContext(ctx, f.dbc)
 	if err != nil {
@@ -888,12 +897,12 @@ func (c *Collection) tryArchiveDeletedDoc(ctx context.Context, filter types.Filt
 }
 
 func (c *Mongo) redirectTable(tableName string) string {
-	if common.IsObjectInstShardingTable(tableName) {
-		tableName = common.BKTableNameBaseInst
-	} else if common.IsObjectInstAsstShardingTable(tableName) {
-		tableName = common.BKTableNameInstAsst
-	}
-	return tableName
+	return common.RedirectShardingTable(tableName)
+}
+
+// IDGeneratorStep 获取序列号的步长，为进程启动时加载的配置
+func (c *Mongo) IDGeneratorStep() int {
+	return c.conf.idGenStep
 }
 
 // NextSequence 获取新序列号(非事务)
@@ -1275,6 +1284,7 @@ func (c *Collection) AggregateAll(ctx context.Context, pipeline interface{}, res
 	}()
 
 	var aggregateOption *options.AggregateOptions
+	var readPref *types.ReadPref
 	for _, opt := range opts {
 		if opt == nil {
 			continue
@@ -1282,9 +1292,12 @@ func (c *Collection) AggregateAll(ctx context.Context, pipeline interface{}, res
 		if opt.AllowDiskUse != nil {
 			aggregateOption = &options.AggregateOptions{AllowDiskUse: opt.AllowDiskUse}
 		}
+		if opt.ReadPref != nil {
+			readPref = opt.ReadPref
+		}
 	}
 
-	opt := getCollectionOption(ctx)
+	opt := c.getCollectionOption(ctx, c.collName, aggregateOper, readPref)
 
 	return c.tm.AutoRunWithTxn(ctx, c.dbc, func(ctx context.Context) error {
 		cursor, err := c.dbc.Database(c.dbname).Collection(c.collName, opt).Aggregate(ctx, pipeline, aggregateOption)
@@ -1307,7 +1320,7 @@ func (c *Collection) AggregateOne(ctx context.Context, pipeline interface{}, res
 		mtc.collectOperDuration(c.collName, aggregateOper, time.Since(start))
 	}()
 
-	opt := getCollectionOption(ctx)
+	opt := c.getCollectionOption(ctx, c.collName, aggregateOper, nil)
 
 	return c.tm.AutoRunWithTxn(ctx, c.dbc, func(ctx context.Context) error {
 		cursor, err := c.dbc.Database(c.dbname).Collection(c.collName, opt).Aggregate(ctx, pipeline)
@@ -1340,7 +1353,7 @@ func (c *Collection) Distinct(ctx context.Context, field string, filter types.Fi
 		filter = bson.M{}
 	}
 
-	opt := getCollectionOption(ctx)
+	opt := c.getCollectionOption(ctx, c.collName, distinctOper, nil)
 	var results []interface{} = nil
 	err := c.tm.AutoRunWithTxn(ctx, c.dbc, func(ctx context.Context) error {
 		var err error
@@ -1367,6 +1380,9 @@ func (f *Find) Option(opts ...*types.FindOpts) {
 		if opt.WithCount != nil {
 			f.option.WithCount = opt.WithCount
 		}
+		if opt.ReadPref != nil {
+			f.option.ReadPref = opt.ReadPref
+		}
 	}
 }
 
@@ -1522,45 +1538,3 @@ func validHostType(collection string, projection map[string]int, result interfac
 	}
 	return nil
 }
-
-const (
-	// reference doc:
-	// https://docs.mongodb.com/manual/core/read-preference-staleness/#replica-set-read-preference-max-staleness
-	// this is the minimum value of maxStalenessSeconds allowed.
-	// specifying a smaller maxStalenessSeconds value will raise an error. Clients estimate secondaries’ staleness
-	// by periodically checking the latest write date of each replica set member. Since these checks are infrequent,
-	// the staleness estimate is coarse. Thus, clients cannot enforce a maxStalenessSeconds value of less than
-	// 90 seconds.
-	maxStalenessSeconds = 90 * time.Second
-)
-
-func getCollectionOption(ctx context.Context) *options.CollectionOptions {
-	var opt *options.CollectionOptions
-	switch util.GetDBReadPreference(ctx) {
-
-	case common.NilMode:
-
-	case common.PrimaryMode:
-		opt = &options.CollectionOptions{
-			ReadPreference: readpref.Primary(),
-		}
-	case common.PrimaryPreferredMode:
-		opt = &options.CollectionOptions{
-			ReadPreference: readpref.PrimaryPreferred(readpref.WithMaxStaleness(maxStalenessSeconds)),
-		}
-	case common.SecondaryMode:
-		opt = &options.CollectionOptions{
-			ReadPreference: readpref.Secondary(readpref.WithMaxStaleness(maxStalenessSeconds)),
-		}
-	case common.SecondaryPreferredMode:
-		opt = &options.CollectionOptions{
-			ReadPreference: readpref.SecondaryPreferred(readpref.WithMaxStaleness(maxStalenessSeconds)),
-		}
-	case common.NearestMode:
-		opt = &options.CollectionOptions{
-			ReadPreference: readpref.Nearest(readpref.WithMaxStaleness(maxStalenessSeconds)),
-		}
-	}
-
-	return opt
-}
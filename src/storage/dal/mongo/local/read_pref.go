@@ -0,0 +1,149 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package local
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal/types"
+
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+const (
+	// reference doc:
+	// https://docs.mongodb.com/manual/core/read-preference-staleness/#replica-set-read-preference-max-staleness
+	// this is the minimum value of maxStalenessSeconds allowed.
+	// specifying a smaller maxStalenessSeconds value will raise an error. Clients estimate secondaries’ staleness
+	// by periodically checking the latest write date of each replica set member. Since these checks are infrequent,
+	// the staleness estimate is coarse. Thus, clients cannot enforce a maxStalenessSeconds value of less than
+	// 90 seconds.
+	maxStalenessSeconds = 90 * time.Second
+
+	// defaultReadPrefName is the read preference metric label when no read preference is set, in which case the
+	// read preference in the mongodb uri is used, which is primary by default
+	defaultReadPrefName = "default"
+)
+
+// readPrefNameMap is the mapping of read preference mode and its name in config and metrics
+var readPrefNameMap = map[common.ReadPreferenceMode]string{
+	common.PrimaryMode:            "primary",
+	common.PrimaryPreferredMode:   "primaryPreferred",
+	common.SecondaryMode:          "secondary",
+	common.SecondaryPreferredMode: "secondaryPreferred",
+	common.NearestMode:            "nearest",
+}
+
+// ParseReadPrefMode parse read preference mode from its name in config, e.g. secondaryPreferred
+func ParseReadPrefMode(name string) (common.ReadPreferenceMode, error) {
+	if name == "" {
+		return common.NilMode, nil
+	}
+
+	for mode, modeName := range readPrefNameMap {
+		if strings.EqualFold(name, modeName) {
+			return mode, nil
+		}
+	}
+	return common.NilMode, fmt.Errorf("read preference mode %s is invalid", name)
+}
+
+// getCollectionOption get the collection option with the read preference of the read operation, the read preference
+// is chosen by the following priority:
+// 1. the read preference in the operation options.
+// 2. the read preference in the context, which is set by util.SetDBReadPreference.
+// 3. the read preference of the collection in config, it is not used in transaction that must read from primary.
+// 4. the default read preference in config, it is not used in transaction too.
+func (c *Mongo) getCollectionOption(ctx context.Context, collName string, operation oper,
+	optPref *types.ReadPref) *options.CollectionOptions {
+
+	pref := c.getReadPref(ctx, collName, optPref)
+
+	var rp *readpref.ReadPref
+	switch pref.Mode {
+	case common.NilMode:
+	case common.PrimaryMode:
+		rp = readpref.Primary()
+	case common.PrimaryPreferredMode:
+		rp = readpref.PrimaryPreferred(readpref.WithMaxStaleness(pref.MaxStaleness))
+	case common.SecondaryMode:
+		rp = readpref.Secondary(readpref.WithMaxStaleness(pref.MaxStaleness))
+	case common.SecondaryPreferredMode:
+		rp = readpref.SecondaryPreferred(readpref.WithMaxStaleness(pref.MaxStaleness))
+	case common.NearestMode:
+		rp = readpref.Nearest(readpref.WithMaxStaleness(pref.MaxStaleness))
+	default:
+		blog.Errorf("read preference mode %s of collection %s is invalid, use default", pref.Mode, collName)
+	}
+
+	if rp == nil {
+		mtc.collectReadPref(collName, operation, defaultReadPrefName)
+		return nil
+	}
+
+	mtc.collectReadPref(collName, operation, readPrefNameMap[pref.Mode])
+	return &options.CollectionOptions{ReadPreference: rp}
+}
+
+func (c *Mongo) getReadPref(ctx context.Context, collName string, optPref *types.ReadPref) types.ReadPref {
+	if optPref != nil && optPref.Mode != common.NilMode {
+		return c.fillMaxStaleness(*optPref)
+	}
+
+	if mode := util.GetDBReadPreference(ctx); mode != common.NilMode {
+		return c.fillMaxStaleness(types.ReadPref{Mode: mode})
+	}
+
+	// read operation in transaction must read from primary
+	if txnID, _ := ctx.Value(common.TransactionIdHeader).(string); txnID != "" {
+		return types.ReadPref{}
+	}
+
+	if pref, exists := c.conf.collReadPref[collName]; exists {
+		return c.fillMaxStaleness(pref)
+	}
+
+	// the sharding tables use the read preference of their base table if they are not configured separately
+	if pref, exists := c.conf.collReadPref[c.redirectTable(collName)]; exists {
+		return c.fillMaxStaleness(pref)
+	}
+
+	return c.fillMaxStaleness(c.conf.readPref)
+}
+
+// fillMaxStaleness use the default max staleness if it is not set, and adjust it to the minimum value if it is less
+func (c *Mongo) fillMaxStaleness(pref types.ReadPref) types.ReadPref {
+	if pref.Mode == common.NilMode || pref.Mode == common.PrimaryMode {
+		return pref
+	}
+
+	if pref.MaxStaleness == 0 {
+		pref.MaxStaleness = c.conf.readPref.MaxStaleness
+	}
+
+	if pref.MaxStaleness < maxStalenessSeconds {
+		pref.MaxStaleness = maxStalenessSeconds
+	}
+	return pref
+}
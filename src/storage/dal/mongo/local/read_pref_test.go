@@ -0,0 +1,80 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package local
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReadPrefMode(t *testing.T) {
+	mode, err := ParseReadPrefMode("secondaryPreferred")
+	require.NoError(t, err)
+	require.Equal(t, common.SecondaryPreferredMode, mode)
+
+	mode, err = ParseReadPrefMode("")
+	require.NoError(t, err)
+	require.Equal(t, common.NilMode, mode)
+
+	_, err = ParseReadPrefMode("secondaryOnly")
+	require.Error(t, err)
+}
+
+func TestGetReadPref(t *testing.T) {
+	mgo := &Mongo{conf: &mongoCliConf{
+		readPref: types.ReadPref{Mode: common.SecondaryPreferredMode, MaxStaleness: 120 * time.Second},
+		collReadPref: map[string]types.ReadPref{
+			common.BKTableNameBaseHost: {Mode: common.SecondaryMode},
+			common.BKTableNameBaseInst: {Mode: common.NearestMode, MaxStaleness: time.Second},
+		},
+	}}
+	ctx := context.Background()
+
+	// default read preference
+	pref := mgo.getReadPref(ctx, common.BKTableNameBaseApp, nil)
+	require.Equal(t, types.ReadPref{Mode: common.SecondaryPreferredMode, MaxStaleness: 120 * time.Second}, pref)
+
+	// collection read preference uses the default max staleness
+	pref = mgo.getReadPref(ctx, common.BKTableNameBaseHost, nil)
+	require.Equal(t, types.ReadPref{Mode: common.SecondaryMode, MaxStaleness: 120 * time.Second}, pref)
+
+	// sharding table uses the read preference of its base table, max staleness is adjusted to the minimum value
+	pref = mgo.getReadPref(ctx, common.GetObjectInstTableName("biz_set", "0"), nil)
+	require.Equal(t, types.ReadPref{Mode: common.NearestMode, MaxStaleness: maxStalenessSeconds}, pref)
+
+	// context read preference takes precedence over config
+	primaryCtx := util.SetDBReadPreference(ctx, common.PrimaryMode)
+	pref = mgo.getReadPref(primaryCtx, common.BKTableNameBaseHost, nil)
+	require.Equal(t, types.ReadPref{Mode: common.PrimaryMode}, pref)
+
+	// option read preference takes precedence over context
+	opt := types.NewFindOpts().SetReadPref(common.SecondaryMode, 0)
+	pref = mgo.getReadPref(primaryCtx, common.BKTableNameBaseHost, opt.ReadPref)
+	require.Equal(t, types.ReadPref{Mode: common.SecondaryMode, MaxStaleness: 120 * time.Second}, pref)
+
+	// transaction does not use the read preference in config
+	txnCtx := context.WithValue(ctx, common.TransactionIdHeader, "txn")
+	pref = mgo.getReadPref(txnCtx, common.BKTableNameBaseHost, nil)
+	require.Equal(t, types.ReadPref{}, pref)
+}
@@ -0,0 +1,815 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"configcenter/src/storage/dal/types"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// AggregateAll aggregate all operation
+func (c *Collection) AggregateAll(ctx context.Context, pipeline interface{}, result interface{},
+	opts ...*types.AggregateOpts) error {
+
+	docs, err := c.aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	return decodeDocs(docs, result)
+}
+
+// AggregateOne aggregate one operation
+func (c *Collection) AggregateOne(ctx context.Context, pipeline interface{}, result interface{}) error {
+	docs, err := c.aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+
+	if len(docs) == 0 {
+		return types.ErrDocumentNotFound
+	}
+	return decodeDoc(docs[0], result)
+}
+
+func (c *Collection) aggregate(ctx context.Context, pipeline interface{}) ([]bson.D, error) {
+	stages, err := toDocArray(pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	docs := c.readTable(ctx, c.collName).docs
+	for _, stage := range stages {
+		if len(stage) != 1 {
+			return nil, fmt.Errorf("a pipeline stage specification object must contain exactly one field")
+		}
+
+		if docs, err = c.runStage(ctx, docs, stage[0].Key, stage[0].Value); err != nil {
+			return nil, err
+		}
+	}
+	return docs, nil
+}
+
+// runStage runs one aggregation stage, the input documents are never changed
+func (c *Collection) runStage(ctx context.Context, docs []bson.D, name string, spec interface{}) ([]bson.D, error) {
+	switch name {
+	case "$match":
+		filter, ok := spec.(bson.D)
+		if !ok {
+			return nil, fmt.Errorf("the match filter must be an expression in an object")
+		}
+		matched, _, err := matchDocs(docs, filter)
+		return matched, err
+	case "$project":
+		return mapDocs(docs, spec, projectStage)
+	case "$addFields", "$set":
+		return mapDocs(docs, spec, addFieldsStage)
+	case "$unset":
+		return mapDocs(docs, spec, unsetStage)
+	case "$replaceRoot":
+		return mapDocs(docs, spec, replaceRootStage)
+	case "$group":
+		return groupStage(docs, spec)
+	case "$sort":
+		keys, ok := spec.(bson.D)
+		if !ok {
+			return nil, fmt.Errorf("the $sort key specification must be an object")
+		}
+		sorted := append(make([]bson.D, 0, len(docs)), docs...)
+		sortDocs(sorted, keys)
+		return sorted, nil
+	case "$skip":
+		skip, ok := toInt64(spec)
+		if !ok || skip < 0 {
+			return nil, fmt.Errorf("invalid argument to $skip stage: %v", spec)
+		}
+		if skip >= int64(len(docs)) {
+			return make([]bson.D, 0), nil
+		}
+		return docs[skip:], nil
+	case "$limit":
+		limit, ok := toInt64(spec)
+		if !ok || limit <= 0 {
+			return nil, fmt.Errorf("invalid argument to $limit stage: %v", spec)
+		}
+		if limit < int64(len(docs)) {
+			return docs[:limit], nil
+		}
+		return docs, nil
+	case "$count":
+		field, ok := spec.(string)
+		if !ok || field == "" {
+			return nil, fmt.Errorf("the count field must be a non-empty string")
+		}
+		if len(docs) == 0 {
+			return make([]bson.D, 0), nil
+		}
+		return []bson.D{{{Key: field, Value: int32(len(docs))}}}, nil
+	case "$unwind":
+		return unwindStage(docs, spec)
+	case "$lookup":
+		return c.lookupStage(ctx, docs, spec)
+	default:
+		return nil, fmt.Errorf("unrecognized pipeline stage name: '%s'", name)
+	}
+}
+
+func mapDocs(docs []bson.D, spec interface{}, mapper func(doc bson.D, spec interface{}) (bson.D, error)) ([]bson.D,
+	error) {
+
+	result := make([]bson.D, len(docs))
+	for i, doc := range docs {
+		newDoc, err := mapper(doc, spec)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = newDoc
+	}
+	return result, nil
+}
+
+// projectStage supports inclusion, exclusion and computed fields, _id is included unless it is excluded explicitly
+func projectStage(doc bson.D, spec interface{}) (bson.D, error) {
+	fields, ok := spec.(bson.D)
+	if !ok || len(fields) == 0 {
+		return nil, fmt.Errorf("$project specification must be a nonempty object")
+	}
+
+	projection := make(map[string]int)
+	computed := bson.D{}
+	for _, field := range fields {
+		switch val := field.Value.(type) {
+		case bool:
+			projection[field.Key] = boolToInt(val)
+		case int32, int64, float64:
+			projection[field.Key] = boolToInt(!valuesEqual(val, int32(0)))
+		default:
+			computed = append(computed, field)
+		}
+	}
+
+	if len(computed) == 0 {
+		return projectDoc(doc, projection), nil
+	}
+
+	if _, exists := projection["_id"]; !exists {
+		projection["_id"] = 1
+	}
+	for field, val := range projection {
+		if val == 0 && field != "_id" {
+			return nil, fmt.Errorf("invalid $project :: cannot use exclusion on field %s in inclusion projection",
+				field)
+		}
+	}
+
+	result := bson.D{}
+	if len(projection) > 1 || projection["_id"] == 1 {
+		result = projectDoc(doc, projection)
+	}
+	return setExprFields(doc, result, computed)
+}
+
+func boolToInt(val bool) int {
+	if val {
+		return 1
+	}
+	return 0
+}
+
+// addFieldsStage adds or overwrites the computed fields
+func addFieldsStage(doc bson.D, spec interface{}) (bson.D, error) {
+	fields, ok := spec.(bson.D)
+	if !ok {
+		return nil, fmt.Errorf("$addFields specification stage must be an object")
+	}
+	return setExprFields(doc, copyDoc(doc), fields)
+}
+
+// setExprFields evaluates the expressions on the root document and sets the results to the target document
+func setExprFields(root, target bson.D, fields bson.D) (bson.D, error) {
+	for _, field := range fields {
+		val, exists, err := evalExpr(root, field.Value)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			continue
+		}
+		if target, err = setPath(target, field.Key, val); err != nil {
+			return nil, err
+		}
+	}
+	return target, nil
+}
+
+func unsetStage(doc bson.D, spec interface{}) (bson.D, error) {
+	fields := make([]interface{}, 0)
+	switch val := spec.(type) {
+	case string:
+		fields = append(fields, val)
+	case bson.A:
+		fields = val
+	default:
+		return nil, fmt.Errorf("$unset specification must be a string or an array")
+	}
+
+	result := copyDoc(doc)
+	for _, field := range fields {
+		path, ok := field.(string)
+		if !ok {
+			return nil, fmt.Errorf("$unset specification must be a string or an array containing only string values")
+		}
+		result = unsetPath(result, path)
+	}
+	return result, nil
+}
+
+func replaceRootStage(doc bson.D, spec interface{}) (bson.D, error) {
+	opt, ok := spec.(bson.D)
+	if !ok {
+		return nil, fmt.Errorf("$replaceRoot specification must be an object")
+	}
+
+	newRoot, _ := getField(opt, "newRoot")
+	val, _, err := evalExpr(doc, newRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	newDoc, ok := val.(bson.D)
+	if !ok {
+		return nil, fmt.Errorf("'newRoot' expression must evaluate to an object, but resulting value was: %v", val)
+	}
+	return newDoc, nil
+}
+
+// unwindStage supports both the string path and the document specification
+func unwindStage(docs []bson.D, spec interface{}) ([]bson.D, error) {
+	var path, indexField string
+	var preserve bool
+	switch val := spec.(type) {
+	case string:
+		path = val
+	case bson.D:
+		p, _ := getField(val, "path")
+		path, _ = p.(string)
+		i, _ := getField(val, "includeArrayIndex")
+		indexField, _ = i.(string)
+		pr, _ := getField(val, "preserveNullAndEmptyArrays")
+		preserve = isTrue(pr)
+	}
+
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("path option to $unwind stage should be prefixed with a '$': %s", path)
+	}
+	path = strings.TrimPrefix(path, "$")
+
+	result := make([]bson.D, 0, len(docs))
+	for _, doc := range docs {
+		val, exists := getPath(doc, path)
+		arr, isArr := val.(bson.A)
+		if !exists || val == nil || (isArr && len(arr) == 0) {
+			if preserve {
+				newDoc := copyDoc(doc)
+				if isArr {
+					newDoc = unsetPath(newDoc, path)
+				}
+				if indexField != "" {
+					newDoc = setField(newDoc, indexField, nil)
+				}
+				result = append(result, newDoc)
+			}
+			continue
+		}
+
+		if !isArr {
+			arr = bson.A{val}
+		}
+		for i, item := range arr {
+			newDoc, err := setPath(copyDoc(doc), path, copyValue(item))
+			if err != nil {
+				return nil, err
+			}
+			if indexField != "" {
+				var idx interface{} = int64(i)
+				if !isArr {
+					idx = nil
+				}
+				newDoc = setField(newDoc, indexField, idx)
+			}
+			result = append(result, newDoc)
+		}
+	}
+	return result, nil
+}
+
+// lookupStage supports the equality match between the local field and the foreign field
+func (c *Collection) lookupStage(ctx context.Context, docs []bson.D, spec interface{}) ([]bson.D, error) {
+	opt, ok := spec.(bson.D)
+	if !ok {
+		return nil, fmt.Errorf("the $lookup specification must be an object")
+	}
+
+	params := make(map[string]string)
+	for _, name := range []string{"from", "localField", "foreignField", "as"} {
+		val, _ := getField(opt, name)
+		str, ok := val.(string)
+		if !ok || str == "" {
+			return nil, fmt.Errorf("$lookup argument '%s' must be a non-empty string", name)
+		}
+		params[name] = str
+	}
+
+	foreignDocs := c.readTable(ctx, params["from"]).docs
+	result := make([]bson.D, len(docs))
+	for i, doc := range docs {
+		localValues, exists := lookupPath(doc, strings.Split(params["localField"], "."))
+		if !exists {
+			localValues = []interface{}{nil}
+		}
+		localValues = expandValues(localValues)
+
+		joined := bson.A{}
+		for _, foreign := range foreignDocs {
+			foreignValues, exists := lookupPath(foreign, strings.Split(params["foreignField"], "."))
+			if !exists {
+				foreignValues = []interface{}{nil}
+			}
+			if containsAny(expandValues(foreignValues), localValues) {
+				joined = append(joined, copyDoc(foreign))
+			}
+		}
+
+		newDoc, err := setPath(copyDoc(doc), params["as"], joined)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = newDoc
+	}
+	return result, nil
+}
+
+func containsAny(values, targets []interface{}) bool {
+	for _, val := range values {
+		for _, target := range targets {
+			if valuesEqual(val, target) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// groupStage groups the documents by the _id expression and calculates the accumulators, the groups are returned in
+// the order of their first occurrence
+func groupStage(docs []bson.D, spec interface{}) ([]bson.D, error) {
+	fields, ok := spec.(bson.D)
+	if !ok {
+		return nil, fmt.Errorf("a group's fields must be specified in an object")
+	}
+
+	idExpr, exists := getField(fields, "_id")
+	if !exists {
+		return nil, fmt.Errorf("a group specification must include an _id")
+	}
+
+	type group struct {
+		id     interface{}
+		values [][]interface{}
+	}
+	groups := make([]*group, 0)
+	groupMap := make(map[string]*group)
+
+	accumulators := make([]bson.E, 0)
+	for _, field := range fields {
+		if field.Key == "_id" {
+			continue
+		}
+		acc, ok := field.Value.(bson.D)
+		if !ok || len(acc) != 1 {
+			return nil, fmt.Errorf("the field '%s' must be an accumulator object", field.Key)
+		}
+		accumulators = append(accumulators, bson.E{Key: field.Key, Value: acc[0]})
+	}
+
+	for _, doc := range docs {
+		id, _, err := evalExpr(doc, idExpr)
+		if err != nil {
+			return nil, err
+		}
+
+		key := valueKey(id)
+		g, exists := groupMap[key]
+		if !exists {
+			g = &group{id: id, values: make([][]interface{}, len(accumulators))}
+			groupMap[key] = g
+			groups = append(groups, g)
+		}
+
+		for i, acc := range accumulators {
+			val, exists, err := evalExpr(doc, acc.Value.(bson.E).Value)
+			if err != nil {
+				return nil, err
+			}
+			if !exists {
+				val = missingValue{}
+			}
+			g.values[i] = append(g.values[i], val)
+		}
+	}
+
+	result := make([]bson.D, len(groups))
+	for i, g := range groups {
+		doc := bson.D{{Key: "_id", Value: g.id}}
+		for j, acc := range accumulators {
+			op := acc.Value.(bson.E).Key
+			val, err := accumulate(op, g.values[j])
+			if err != nil {
+				return nil, err
+			}
+			doc = append(doc, bson.E{Key: acc.Key, Value: val})
+		}
+		result[i] = doc
+	}
+	return result, nil
+}
+
+// missingValue marks the accumulator value of the document that does not have the field
+type missingValue struct{}
+
+func accumulate(op string, values []interface{}) (interface{}, error) {
+	existValues := make([]interface{}, 0, len(values))
+	for _, val := range values {
+		if _, missing := val.(missingValue); !missing {
+			existValues = append(existValues, val)
+		}
+	}
+
+	switch op {
+	case "$sum":
+		return sumValues(existValues), nil
+	case "$avg":
+		numbers := make([]interface{}, 0)
+		for _, val := range existValues {
+			if isNumber(val) {
+				numbers = append(numbers, val)
+			}
+		}
+		if len(numbers) == 0 {
+			return nil, nil
+		}
+		sum, _ := toFloat(sumValues(numbers))
+		return sum / float64(len(numbers)), nil
+	case "$min", "$max":
+		var result interface{}
+		for _, val := range existValues {
+			if val == nil {
+				continue
+			}
+			cmp := 0
+			if result != nil {
+				cmp = compareValues(val, result)
+			}
+			if result == nil || (op == "$min" && cmp < 0) || (op == "$max" && cmp > 0) {
+				result = val
+			}
+		}
+		return result, nil
+	case "$first", "$last":
+		if len(values) == 0 {
+			return nil, nil
+		}
+		val := values[0]
+		if op == "$last" {
+			val = values[len(values)-1]
+		}
+		if _, missing := val.(missingValue); missing {
+			return nil, nil
+		}
+		return val, nil
+	case "$push":
+		return append(bson.A{}, existValues...), nil
+	case "$addToSet":
+		result := bson.A{}
+		for _, val := range existValues {
+			if !containsValue(result, val) {
+				result = append(result, val)
+			}
+		}
+		return result, nil
+	case "$count":
+		return int32(len(values)), nil
+	default:
+		return nil, fmt.Errorf("unknown group operator '%s'", op)
+	}
+}
+
+// sumValues sums the numbers like mongodb, non-numeric values are ignored, the result type is widened when needed
+func sumValues(values []interface{}) interface{} {
+	var result interface{} = int32(0)
+	for _, val := range values {
+		if isNumber(val) {
+			result = addNumbers(result, val)
+		}
+	}
+	return result
+}
+
+// evalExpr evaluates the aggregation expression on the document, returns false if the expression refers to a
+// missing field
+func evalExpr(doc bson.D, expr interface{}) (interface{}, bool, error) {
+	switch v := expr.(type) {
+	case string:
+		if v == "$$ROOT" || v == "$$CURRENT" {
+			return doc, true, nil
+		}
+		if !strings.HasPrefix(v, "$") {
+			return v, true, nil
+		}
+		return evalFieldPath(doc, strings.TrimPrefix(v, "$"))
+	case bson.A:
+		arr := make(bson.A, len(v))
+		for i, item := range v {
+			val, _, err := evalExpr(doc, item)
+			if err != nil {
+				return nil, false, err
+			}
+			arr[i] = val
+		}
+		return arr, true, nil
+	case bson.D:
+		if len(v) == 1 && strings.HasPrefix(v[0].Key, "$") {
+			val, err := evalOperator(doc, v[0].Key, v[0].Value)
+			return val, true, err
+		}
+		result := bson.D{}
+		for _, e := range v {
+			val, exists, err := evalExpr(doc, e.Value)
+			if err != nil {
+				return nil, false, err
+			}
+			if exists {
+				result = append(result, bson.E{Key: e.Key, Value: val})
+			}
+		}
+		return result, true, nil
+	default:
+		return expr, true, nil
+	}
+}
+
+// evalFieldPath returns the value of the field path, the values in the array are collected like mongodb
+func evalFieldPath(doc bson.D, path string) (interface{}, bool, error) {
+	val, exists := getPath(doc, path)
+	if exists {
+		return copyValue(val), true, nil
+	}
+
+	values, exists := lookupPath(doc, strings.Split(path, "."))
+	if !exists {
+		return nil, false, nil
+	}
+	if len(values) == 1 {
+		return copyValue(values[0]), true, nil
+	}
+	return copyValue(bson.A(values)), true, nil
+}
+
+// evalArgs evaluates the operator arguments, a single argument is treated as an array with one element
+func evalArgs(doc bson.D, args interface{}) ([]interface{}, error) {
+	arr, ok := args.(bson.A)
+	if !ok {
+		arr = bson.A{args}
+	}
+
+	values := make([]interface{}, len(arr))
+	for i, arg := range arr {
+		val, _, err := evalExpr(doc, arg)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = val
+	}
+	return values, nil
+}
+
+func evalOperator(doc bson.D, op string, args interface{}) (interface{}, error) {
+	if op == "$literal" {
+		return args, nil
+	}
+	if op == "$cond" {
+		return evalCond(doc, args)
+	}
+
+	values, err := evalArgs(doc, args)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case "$eq", "$ne", "$gt", "$gte", "$lt", "$lte":
+		if len(values) != 2 {
+			return nil, fmt.Errorf("expression %s takes exactly 2 arguments", op)
+		}
+		return compareByOperator(op, values[0], values[1]), nil
+	case "$and":
+		for _, val := range values {
+			if !isTrue(val) {
+				return false, nil
+			}
+		}
+		return true, nil
+	case "$or":
+		for _, val := range values {
+			if isTrue(val) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "$not":
+		return len(values) == 0 || !isTrue(values[0]), nil
+	case "$ifNull":
+		for _, val := range values {
+			if val != nil {
+				return val, nil
+			}
+		}
+		return nil, nil
+	case "$in":
+		if len(values) != 2 {
+			return nil, fmt.Errorf("expression $in takes exactly 2 arguments")
+		}
+		arr, ok := values[1].(bson.A)
+		if !ok {
+			return nil, fmt.Errorf("$in requires an array as a second argument")
+		}
+		return containsValue(arr, values[0]), nil
+	case "$size":
+		arr, ok := values[0].(bson.A)
+		if !ok {
+			return nil, fmt.Errorf("the argument to $size must be an array")
+		}
+		return int32(len(arr)), nil
+	case "$arrayElemAt":
+		return evalArrayElemAt(values)
+	case "$add", "$subtract", "$multiply", "$divide":
+		return evalArithmetic(op, values)
+	case "$sum":
+		if arr, ok := values[0].(bson.A); ok && len(values) == 1 {
+			values = arr
+		}
+		return sumValues(values), nil
+	case "$concat":
+		var builder strings.Builder
+		for _, val := range values {
+			if val == nil {
+				return nil, nil
+			}
+			str, ok := val.(string)
+			if !ok {
+				return nil, fmt.Errorf("$concat only supports strings, not %T", val)
+			}
+			builder.WriteString(str)
+		}
+		return builder.String(), nil
+	case "$toLower", "$toUpper", "$toString":
+		if values[0] == nil {
+			return "", nil
+		}
+		str := fmt.Sprint(values[0])
+		if op == "$toLower" {
+			return strings.ToLower(str), nil
+		}
+		if op == "$toUpper" {
+			return strings.ToUpper(str), nil
+		}
+		return str, nil
+	default:
+		return nil, fmt.Errorf("unrecognized expression '%s'", op)
+	}
+}
+
+func compareByOperator(op string, a, b interface{}) bool {
+	cmp := compareValues(a, b)
+	switch op {
+	case "$eq":
+		return cmp == 0
+	case "$ne":
+		return cmp != 0
+	case "$gt":
+		return cmp > 0
+	case "$gte":
+		return cmp >= 0
+	case "$lt":
+		return cmp < 0
+	default:
+		return cmp <= 0
+	}
+}
+
+// evalCond supports both the array form [if, then, else] and the document form {if, then, else}
+func evalCond(doc bson.D, args interface{}) (interface{}, error) {
+	var ifExpr, thenExpr, elseExpr interface{}
+	switch v := args.(type) {
+	case bson.A:
+		if len(v) != 3 {
+			return nil, fmt.Errorf("expression $cond takes exactly 3 arguments")
+		}
+		ifExpr, thenExpr, elseExpr = v[0], v[1], v[2]
+	case bson.D:
+		ifExpr, _ = getField(v, "if")
+		thenExpr, _ = getField(v, "then")
+		elseExpr, _ = getField(v, "else")
+	default:
+		return nil, fmt.Errorf("expression $cond takes exactly 3 arguments")
+	}
+
+	cond, _, err := evalExpr(doc, ifExpr)
+	if err != nil {
+		return nil, err
+	}
+
+	result := elseExpr
+	if isTrue(cond) {
+		result = thenExpr
+	}
+	val, _, err := evalExpr(doc, result)
+	return val, err
+}
+
+func evalArrayElemAt(values []interface{}) (interface{}, error) {
+	if len(values) != 2 {
+		return nil, fmt.Errorf("expression $arrayElemAt takes exactly 2 arguments")
+	}
+
+	arr, ok := values[0].(bson.A)
+	idx, isInt := toInt64(values[1])
+	if !ok || !isInt {
+		return nil, fmt.Errorf("$arrayElemAt requires an array and an integer index")
+	}
+
+	if idx < 0 {
+		idx += int64(len(arr))
+	}
+	if idx < 0 || idx >= int64(len(arr)) {
+		return nil, nil
+	}
+	return arr[idx], nil
+}
+
+func evalArithmetic(op string, values []interface{}) (interface{}, error) {
+	for _, val := range values {
+		if val == nil {
+			return nil, nil
+		}
+		if !isNumber(val) {
+			return nil, fmt.Errorf("%s only supports numeric types, not %T", op, val)
+		}
+	}
+
+	switch op {
+	case "$add":
+		return sumValues(values), nil
+	case "$multiply":
+		var result interface{} = int32(1)
+		for _, val := range values {
+			result = mulNumbers(result, val)
+		}
+		return result, nil
+	}
+
+	if len(values) != 2 {
+		return nil, fmt.Errorf("expression %s takes exactly 2 arguments", op)
+	}
+
+	if op == "$subtract" {
+		return addNumbers(values[0], mulNumbers(values[1], int32(-1))), nil
+	}
+
+	dividend, _ := toFloat(values[0])
+	divisor, _ := toFloat(values[1])
+	if divisor == 0 || math.IsNaN(divisor) {
+		return nil, fmt.Errorf("can't $divide by zero")
+	}
+	return dividend / divisor, nil
+}
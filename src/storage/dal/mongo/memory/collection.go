@@ -0,0 +1,691 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	tableutil "configcenter/src/common/util/table"
+	"configcenter/src/storage/dal/types"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Collection implement client.Collection interface
+type Collection struct {
+	collName string
+	*DB
+}
+
+// Find 查询多个并反序列化到 Result
+func (c *Collection) Find(filter types.Filter, opts ...*types.FindOpts) types.Find {
+	find := &Find{
+		Collection: c,
+		filter:     filter,
+		projection: make(map[string]int),
+	}
+
+	find.Option(opts...)
+
+	return find
+}
+
+// Find define a find operation
+type Find struct {
+	*Collection
+
+	projection map[string]int
+	filter     types.Filter
+	start      int64
+	limit      int64
+	sort       bson.D
+
+	option types.FindOpts
+}
+
+// Fields 查询字段
+func (f *Find) Fields(fields ...string) types.Find {
+	for _, field := range fields {
+		if len(field) <= 0 {
+			continue
+		}
+		f.projection[field] = 1
+	}
+	return f
+}
+
+// Sort 查询排序, the sort format is the same as mongodb implementation, e.g. "host_id, -host_name" or
+// "host_id:1, host_name:-1"
+func (f *Find) Sort(sort string) types.Find {
+	if sort == "" {
+		return f
+	}
+
+	f.sort = bson.D{}
+	for _, sortItem := range strings.Split(sort, ",") {
+		sortItemArr := strings.Split(strings.TrimSpace(sortItem), ":")
+		sortKey := strings.TrimLeft(sortItemArr[0], "+-")
+		desc := strings.HasPrefix(sortItemArr[0], "-")
+		if len(sortItemArr) == 2 {
+			desc = strings.TrimSpace(sortItemArr[1]) == "-1"
+		}
+
+		if desc {
+			f.sort = append(f.sort, bson.E{Key: sortKey, Value: -1})
+		} else {
+			f.sort = append(f.sort, bson.E{Key: sortKey, Value: 1})
+		}
+	}
+	return f
+}
+
+// Start 查询上标
+func (f *Find) Start(start uint64) types.Find {
+	f.start = int64(start)
+	return f
+}
+
+// Limit 查询限制
+func (f *Find) Limit(limit uint64) types.Find {
+	f.limit = int64(limit)
+	return f
+}
+
+// Option set find options
+func (f *Find) Option(opts ...*types.FindOpts) {
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if opt.WithObjectID != nil {
+			f.option.WithObjectID = opt.WithObjectID
+		}
+		if opt.WithCount != nil {
+			f.option.WithCount = opt.WithCount
+		}
+	}
+}
+
+// All 查询多个
+func (f *Find) All(ctx context.Context, result interface{}) error {
+	docs, err := f.find(ctx)
+	if err != nil {
+		return err
+	}
+	return decodeDocs(docs, result)
+}
+
+// List 查询多个数据， 当分页中start值为零的时候返回满足条件总行数
+func (f *Find) List(ctx context.Context, result interface{}) (int64, error) {
+	var total int64
+	if f.start == 0 || (f.option.WithCount != nil && *f.option.WithCount) {
+		cnt, err := f.Count(ctx)
+		if err != nil {
+			return 0, err
+		}
+		total = int64(cnt)
+	}
+
+	if err := f.All(ctx, result); err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// One 查询一个
+func (f *Find) One(ctx context.Context, result interface{}) error {
+	docs, err := f.find(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(docs) == 0 {
+		return types.ErrDocumentNotFound
+	}
+	return decodeDoc(docs[0], result)
+}
+
+// Count 统计数量
+func (f *Find) Count(ctx context.Context) (uint64, error) {
+	docs, _, err := f.filterDocs(ctx, f.filter)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(len(docs)), nil
+}
+
+// find returns the matched documents after sort, skip, limit and projection
+func (f *Find) find(ctx context.Context) ([]bson.D, error) {
+	docs, _, err := f.filterDocs(ctx, f.filter)
+	if err != nil {
+		return nil, err
+	}
+
+	sortDocs(docs, f.sort)
+
+	if f.start > 0 {
+		if f.start >= int64(len(docs)) {
+			return make([]bson.D, 0), nil
+		}
+		docs = docs[f.start:]
+	}
+	if f.limit > 0 && f.limit < int64(len(docs)) {
+		docs = docs[:f.limit]
+	}
+
+	projection := f.genProjection()
+	result := make([]bson.D, len(docs))
+	for i, doc := range docs {
+		result[i] = projectDoc(doc, projection)
+	}
+	return result, nil
+}
+
+// genProjection generates the projection in the same way as the mongodb implementation, _id is not returned unless
+// WithObjectID option is set or _id is specified in the fields
+func (f *Find) genProjection() map[string]int {
+	projection := make(map[string]int, len(f.projection)+1)
+	for field, val := range f.projection {
+		projection[field] = val
+	}
+
+	if f.option.WithObjectID != nil && *f.option.WithObjectID {
+		if len(projection) > 0 {
+			projection["_id"] = 1
+		}
+	} else if _, exists := projection["_id"]; !exists {
+		projection["_id"] = 0
+	}
+	return projection
+}
+
+// filterDocs returns the documents that match the filter and their positions in the table in the context
+func (c *Collection) filterDocs(ctx context.Context, filter types.Filter) ([]bson.D, []int, error) {
+	filterDoc, err := toDoc(filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return matchDocs(c.readTable(ctx, c.collName).docs, filterDoc)
+}
+
+// matchDocs returns the documents that match the filter and their positions in the docs
+func matchDocs(docs []bson.D, filter bson.D) ([]bson.D, []int, error) {
+	matched := make([]bson.D, 0)
+	positions := make([]int, 0)
+	for i, doc := range docs {
+		ok, err := matchDoc(doc, filter)
+		if err != nil {
+			return nil, nil, err
+		}
+		if ok {
+			matched = append(matched, doc)
+			positions = append(positions, i)
+		}
+	}
+	return matched, positions, nil
+}
+
+// sortDocs sorts the documents by the sort keys in place, the order of the documents with the same sort keys is kept
+func sortDocs(docs []bson.D, sortKeys bson.D) {
+	if len(sortKeys) == 0 {
+		return
+	}
+
+	sort.SliceStable(docs, func(i, j int) bool {
+		for _, key := range sortKeys {
+			direction, _ := util.GetInt64ByInterface(key.Value)
+			desc := direction < 0
+			cmp := compareValues(sortValue(docs[i], key.Key, desc), sortValue(docs[j], key.Key, desc))
+			if cmp == 0 {
+				continue
+			}
+			if desc {
+				return cmp > 0
+			}
+			return cmp < 0
+		}
+		return false
+	})
+}
+
+// sortValue returns the value used to sort the document, like mongodb, the array uses its min element for ascending
+// sort and its max element for descending sort
+func sortValue(doc bson.D, path string, desc bool) interface{} {
+	val, exists := getPath(doc, path)
+	if !exists {
+		return nil
+	}
+
+	arr, ok := val.(bson.A)
+	if !ok || len(arr) == 0 {
+		return val
+	}
+
+	result := arr[0]
+	for _, item := range arr[1:] {
+		cmp := compareValues(item, result)
+		if (desc && cmp > 0) || (!desc && cmp < 0) {
+			result = item
+		}
+	}
+	return result
+}
+
+// projectDoc returns a copy of the document with the projection applied, the projection is an inclusion projection
+// if any field other than _id is included, otherwise it is an exclusion projection
+func projectDoc(doc bson.D, projection map[string]int) bson.D {
+	includes := make([]string, 0)
+	for field, val := range projection {
+		if val != 0 && field != "_id" {
+			includes = append(includes, field)
+		}
+	}
+
+	idVal, idSet := projection["_id"]
+	if len(includes) == 0 && (!idSet || idVal == 0) {
+		result := copyDoc(doc)
+		for field, val := range projection {
+			if val == 0 {
+				result = unsetPath(result, field)
+			}
+		}
+		return result
+	}
+
+	if !idSet || idVal != 0 {
+		includes = append(includes, "_id")
+	}
+	return includeDocPaths(doc, includes)
+}
+
+// includeDocPaths returns a copy of the document that only contains the dotted paths
+func includeDocPaths(doc bson.D, paths []string) bson.D {
+	result := bson.D{}
+	for _, e := range doc {
+		whole := false
+		subPaths := make([]string, 0)
+		for _, path := range paths {
+			if path == e.Key {
+				whole = true
+				break
+			}
+			if strings.HasPrefix(path, e.Key+".") {
+				subPaths = append(subPaths, strings.TrimPrefix(path, e.Key+"."))
+			}
+		}
+
+		switch {
+		case whole:
+			result = append(result, bson.E{Key: e.Key, Value: copyValue(e.Value)})
+		case len(subPaths) > 0:
+			if val, ok := includeSubPaths(e.Value, subPaths); ok {
+				result = append(result, bson.E{Key: e.Key, Value: val})
+			}
+		}
+	}
+	return result
+}
+
+func includeSubPaths(val interface{}, paths []string) (interface{}, bool) {
+	switch v := val.(type) {
+	case bson.D:
+		return includeDocPaths(v, paths), true
+	case bson.A:
+		arr := make(bson.A, 0, len(v))
+		for _, item := range v {
+			if subVal, ok := includeSubPaths(item, paths); ok {
+				arr = append(arr, subVal)
+			}
+		}
+		return arr, true
+	default:
+		return nil, false
+	}
+}
+
+// ensureID generates the _id field for the document if it is not set, the _id field is the first field like mongodb
+func ensureID(doc bson.D) bson.D {
+	if _, exists := getField(doc, "_id"); exists {
+		return doc
+	}
+	return append(bson.D{{Key: "_id", Value: primitive.NewObjectID()}}, doc...)
+}
+
+// Insert 插入数据, docs 可以为 单个数据 或者 多个数据
+func (c *Collection) Insert(ctx context.Context, docs interface{}) error {
+	rows, err := toDocs(docs)
+	if err != nil {
+		return err
+	}
+
+	if len(rows) == 0 {
+		// same as mongodb driver InsertMany
+		return errors.New("must provide at least one element in input slice")
+	}
+
+	return c.writeTable(ctx, c.collName, func(t *table) error {
+		return t.insert(c.collName, rows)
+	})
+}
+
+func (t *table) insert(coll string, docs []bson.D) error {
+	for _, doc := range docs {
+		doc = ensureID(doc)
+		if err := t.checkUnique(coll, doc, -1); err != nil {
+			return err
+		}
+		t.docs = append(t.docs, doc)
+	}
+	return nil
+}
+
+// Update 更新数据
+func (c *Collection) Update(ctx context.Context, filter types.Filter, doc interface{}) error {
+	_, err := c.UpdateMany(ctx, filter, doc)
+	return err
+}
+
+// UpdateMany 更新数据, 返回修改成功的条数
+func (c *Collection) UpdateMany(ctx context.Context, filter types.Filter, doc interface{}) (uint64, error) {
+	return c.update(ctx, filter, bson.D{{Key: "$set", Value: doc}}, true, false)
+}
+
+// Upsert 数据存在更新数据，否则新加数据
+func (c *Collection) Upsert(ctx context.Context, filter types.Filter, doc interface{}) error {
+	_, err := c.update(ctx, filter, bson.D{{Key: "$set", Value: doc}}, false, true)
+	return err
+}
+
+// UpdateMultiModel 根据不同的操作符去更新数据
+func (c *Collection) UpdateMultiModel(ctx context.Context, filter types.Filter, updateModel ...types.ModeUpdate) error {
+	data := bson.D{}
+	for _, item := range updateModel {
+		if _, exists := getField(data, "$"+item.Op); exists {
+			return errors.New(item.Op + " appear multiple times")
+		}
+		data = append(data, bson.E{Key: "$" + item.Op, Value: item.Doc})
+	}
+
+	_, err := c.update(ctx, filter, data, true, false)
+	return err
+}
+
+// update updates the documents that match the filter by the update operators, returns the modified count
+func (c *Collection) update(ctx context.Context, filter types.Filter, update interface{}, multi, upsert bool) (
+	uint64, error) {
+
+	filterDoc, err := toDoc(filter)
+	if err != nil {
+		return 0, err
+	}
+
+	updateDoc, err := toDoc(update)
+	if err != nil {
+		return 0, err
+	}
+
+	var modified uint64
+	err = c.writeTable(ctx, c.collName, func(t *table) error {
+		modified = 0
+		matched := false
+		for i, doc := range t.docs {
+			ok, err := matchDoc(doc, filterDoc)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+			matched = true
+
+			newDoc, err := applyUpdate(doc, updateDoc, false)
+			if err != nil {
+				return err
+			}
+
+			if !valuesEqual(doc, newDoc) {
+				t.docs[i] = newDoc
+				if err = t.checkUnique(c.collName, newDoc, i); err != nil {
+					return err
+				}
+				modified++
+			}
+
+			if !multi {
+				break
+			}
+		}
+
+		if matched || !upsert {
+			return nil
+		}
+
+		baseDoc, err := upsertBaseDoc(filterDoc)
+		if err != nil {
+			return err
+		}
+		newDoc, err := applyUpdate(baseDoc, updateDoc, true)
+		if err != nil {
+			return err
+		}
+		return t.insert(c.collName, []bson.D{newDoc})
+	})
+	if err != nil {
+		return 0, err
+	}
+	return modified, nil
+}
+
+// Delete 删除数据
+func (c *Collection) Delete(ctx context.Context, filter types.Filter) error {
+	_, err := c.DeleteMany(ctx, filter)
+	return err
+}
+
+// DeleteMany 删除数据， 返回删除的行数, the deleted documents are archived like the mongodb implementation
+func (c *Collection) DeleteMany(ctx context.Context, filter types.Filter) (uint64, error) {
+	filterDoc, err := toDoc(filter)
+	if err != nil {
+		return 0, err
+	}
+
+	colls := []string{c.collName}
+	delArchiveTable, needArchive := tableutil.GetDelArchiveTable(c.collName)
+	if needArchive {
+		colls = append(colls, delArchiveTable)
+	}
+
+	var deleteCount uint64
+	err = c.writeTables(ctx, colls, func(tables []*table) error {
+		t := tables[0]
+		deleted, positions, err := matchDocs(t.docs, filterDoc)
+		if err != nil {
+			return err
+		}
+		deleteCount = uint64(len(deleted))
+		if deleteCount == 0 {
+			return nil
+		}
+
+		remain := make([]bson.D, 0, len(t.docs)-len(deleted))
+		posIdx := 0
+		for i, doc := range t.docs {
+			if posIdx < len(positions) && positions[posIdx] == i {
+				posIdx++
+				continue
+			}
+			remain = append(remain, doc)
+		}
+		t.docs = remain
+
+		if !needArchive {
+			return nil
+		}
+		archives, err := c.genDelArchives(deleted)
+		if err != nil {
+			return err
+		}
+		return tables[1].insert(delArchiveTable, archives)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return deleteCount, nil
+}
+
+// genDelArchives generates the delete archive documents for the deleted documents
+func (c *Collection) genDelArchives(docs []bson.D) ([]bson.D, error) {
+	projection := make(map[string]int)
+	for _, field := range tableutil.GetDelArchiveFields(c.collName) {
+		projection[field] = 1
+	}
+
+	archives := make([]bson.D, len(docs))
+	for idx, doc := range docs {
+		oid, _ := getField(doc, "_id")
+		objectID, _ := oid.(primitive.ObjectID)
+
+		detail := copyDoc(doc)
+		if len(projection) > 0 {
+			detail = projectDoc(doc, projection)
+		}
+
+		archive, err := toDoc(metadata.DeleteArchive{
+			Oid:    objectID.Hex(),
+			Detail: removeField(detail, "_id"),
+			Time:   time.Now(),
+			Coll:   c.collName,
+		})
+		if err != nil {
+			return nil, err
+		}
+		archives[idx] = archive
+	}
+	return archives, nil
+}
+
+// CreateIndex 创建索引
+func (c *Collection) CreateIndex(ctx context.Context, index types.Index) error {
+	return c.BatchCreateIndexes(ctx, []types.Index{index})
+}
+
+// BatchCreateIndexes 批量创建索引
+func (c *Collection) BatchCreateIndexes(ctx context.Context, indexes []types.Index) error {
+	return c.writeTable(ctx, c.collName, func(t *table) error {
+		for _, index := range indexes {
+			err := t.addIndex(c.collName, index)
+			// ignore the index that has same keys with the existing one but its name is different like mongodb
+			// implementation
+			if err != nil && !strings.Contains(err.Error(), "already exists with a different name") {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DropIndex remove index by name
+func (c *Collection) DropIndex(ctx context.Context, indexName string) error {
+	return c.writeTable(ctx, c.collName, func(t *table) error {
+		return t.dropIndex(indexName)
+	})
+}
+
+// Indexes get all indexes for the collection
+func (c *Collection) Indexes(ctx context.Context) ([]types.Index, error) {
+	indexes := c.readTable(ctx, c.collName).indexes
+	return append(make([]types.Index, 0, len(indexes)), indexes...), nil
+}
+
+// AddColumn add a new column for the collection
+func (c *Collection) AddColumn(ctx context.Context, column string, value interface{}) error {
+	filter := bson.D{{Key: column, Value: bson.D{{Key: "$exists", Value: false}}}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: column, Value: value}}}}
+	_, err := c.update(ctx, filter, update, true, false)
+	return err
+}
+
+// RenameColumn rename a column for the collection
+func (c *Collection) RenameColumn(ctx context.Context, filter types.Filter, oldName, newColumn string) error {
+	update := bson.D{{Key: "$rename", Value: bson.D{{Key: oldName, Value: newColumn}}}}
+	_, err := c.update(ctx, filter, update, true, false)
+	return err
+}
+
+// DropColumn remove a column by the name
+func (c *Collection) DropColumn(ctx context.Context, field string) error {
+	return c.DropDocsColumn(ctx, field, nil)
+}
+
+// DropColumns remove many columns by the name
+func (c *Collection) DropColumns(ctx context.Context, filter types.Filter, fields []string) error {
+	unsetFields := bson.D{}
+	for _, field := range fields {
+		unsetFields = append(unsetFields, bson.E{Key: field, Value: ""})
+	}
+
+	_, err := c.update(ctx, filter, bson.D{{Key: "$unset", Value: unsetFields}}, true, false)
+	return err
+}
+
+// DropDocsColumn remove a column by the name for doc use filter
+func (c *Collection) DropDocsColumn(ctx context.Context, field string, filter types.Filter) error {
+	return c.DropColumns(ctx, filter, []string{field})
+}
+
+// Distinct Finds the distinct values for a specified field across a single collection, the array values are
+// expanded like mongodb
+func (c *Collection) Distinct(ctx context.Context, field string, filter types.Filter) ([]interface{}, error) {
+	docs, _, err := c.filterDocs(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]interface{}, 0)
+	existing := make(map[string]struct{})
+	for _, doc := range docs {
+		values, exists := lookupPath(doc, strings.Split(field, "."))
+		if !exists {
+			continue
+		}
+
+		for _, val := range values {
+			items := []interface{}{val}
+			if arr, ok := val.(bson.A); ok {
+				items = arr
+			}
+
+			for _, item := range items {
+				key := valueKey(item)
+				if _, ok := existing[key]; ok {
+					continue
+				}
+				existing[key] = struct{}{}
+				results = append(results, copyValue(item))
+			}
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,617 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package memory
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"configcenter/src/common/util"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// toDoc converts a document of any type that can be marshaled to bson into the ordered document used in memory, the
+// values are converted to bson primitive types so that they are compared just like in mongodb
+func toDoc(val interface{}) (bson.D, error) {
+	if val == nil {
+		return bson.D{}, nil
+	}
+
+	raw, err := bson.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+
+	return rawToDoc(raw)
+}
+
+// toValue converts a value of any type into the bson primitive type
+func toValue(val interface{}) (interface{}, error) {
+	doc, err := toDoc(bson.D{{Key: "v", Value: val}})
+	if err != nil {
+		return nil, err
+	}
+	return doc[0].Value, nil
+}
+
+func rawToDoc(raw bson.Raw) (bson.D, error) {
+	elements, err := raw.Elements()
+	if err != nil {
+		return nil, err
+	}
+
+	doc := make(bson.D, 0, len(elements))
+	for _, element := range elements {
+		val, err := rawToValue(element.Value())
+		if err != nil {
+			return nil, err
+		}
+		doc = append(doc, bson.E{Key: element.Key(), Value: val})
+	}
+	return doc, nil
+}
+
+func rawToValue(rv bson.RawValue) (interface{}, error) {
+	switch rv.Type {
+	case bsontype.EmbeddedDocument:
+		return rawToDoc(rv.Document())
+	case bsontype.Array:
+		values, err := rv.Array().Values()
+		if err != nil {
+			return nil, err
+		}
+		arr := make(bson.A, len(values))
+		for i, value := range values {
+			if arr[i], err = rawToValue(value); err != nil {
+				return nil, err
+			}
+		}
+		return arr, nil
+	case bsontype.Null, bsontype.Undefined:
+		return nil, nil
+	case bsontype.Int32:
+		return rv.Int32(), nil
+	case bsontype.Int64:
+		return rv.Int64(), nil
+	case bsontype.Double:
+		return rv.Double(), nil
+	case bsontype.String:
+		return rv.StringValue(), nil
+	case bsontype.Boolean:
+		return rv.Boolean(), nil
+	case bsontype.ObjectID:
+		return rv.ObjectID(), nil
+	case bsontype.DateTime:
+		return primitive.DateTime(rv.DateTime()), nil
+	case bsontype.Timestamp:
+		t, i := rv.Timestamp()
+		return primitive.Timestamp{T: t, I: i}, nil
+	case bsontype.Decimal128:
+		return rv.Decimal128(), nil
+	case bsontype.Regex:
+		pattern, options := rv.Regex()
+		return primitive.Regex{Pattern: pattern, Options: options}, nil
+	case bsontype.Binary:
+		subtype, data := rv.Binary()
+		return primitive.Binary{Subtype: subtype, Data: data}, nil
+	default:
+		return nil, fmt.Errorf("bson type %s is not supported", rv.Type)
+	}
+}
+
+// toDocs converts a single document or a slice of documents into ordered documents
+func toDocs(docs interface{}) ([]bson.D, error) {
+	rows := util.ConvertToInterfaceSlice(docs)
+	result := make([]bson.D, len(rows))
+	for i, row := range rows {
+		doc, err := toDoc(row)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = doc
+	}
+	return result, nil
+}
+
+// toDocArray converts an array value like aggregate pipeline into ordered documents
+func toDocArray(val interface{}) ([]bson.D, error) {
+	arr, err := toValue(val)
+	if err != nil {
+		return nil, err
+	}
+
+	values, ok := arr.(bson.A)
+	if !ok {
+		return nil, fmt.Errorf("value type %T is not an array", val)
+	}
+
+	docs := make([]bson.D, len(values))
+	for i, value := range values {
+		doc, ok := value.(bson.D)
+		if !ok {
+			return nil, fmt.Errorf("array element type %T is not a document", value)
+		}
+		docs[i] = doc
+	}
+	return docs, nil
+}
+
+// decodeDoc decodes the document into the result just like the mongodb driver does
+func decodeDoc(doc bson.D, result interface{}) error {
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(raw, result)
+}
+
+// decodeDocs decodes the documents into the result slice address just like the mongodb driver does
+func decodeDocs(docs []bson.D, result interface{}) error {
+	resultv := reflect.ValueOf(result)
+	if resultv.Kind() != reflect.Ptr || resultv.Elem().Kind() != reflect.Slice {
+		return errors.New("result argument must be a slice address")
+	}
+
+	elemt := resultv.Elem().Type().Elem()
+	slice := reflect.MakeSlice(resultv.Elem().Type(), 0, len(docs))
+	for _, doc := range docs {
+		elemp := reflect.New(elemt)
+		if err := decodeDoc(doc, elemp.Interface()); err != nil {
+			return err
+		}
+		slice = reflect.Append(slice, elemp.Elem())
+	}
+
+	resultv.Elem().Set(slice)
+	return nil
+}
+
+// copyValue deep copies the bson value, so that the stored documents are never changed by the caller
+func copyValue(val interface{}) interface{} {
+	switch v := val.(type) {
+	case bson.D:
+		return copyDoc(v)
+	case bson.A:
+		arr := make(bson.A, len(v))
+		for i, item := range v {
+			arr[i] = copyValue(item)
+		}
+		return arr
+	default:
+		return val
+	}
+}
+
+func copyDoc(doc bson.D) bson.D {
+	result := make(bson.D, len(doc))
+	for i, e := range doc {
+		result[i] = bson.E{Key: e.Key, Value: copyValue(e.Value)}
+	}
+	return result
+}
+
+// getField returns the value of the top level field of the document
+func getField(doc bson.D, key string) (interface{}, bool) {
+	for _, e := range doc {
+		if e.Key == key {
+			return e.Value, true
+		}
+	}
+	return nil, false
+}
+
+// setField sets the value of the top level field of the document, the field is appended if it does not exist
+func setField(doc bson.D, key string, val interface{}) bson.D {
+	for i, e := range doc {
+		if e.Key == key {
+			doc[i].Value = val
+			return doc
+		}
+	}
+	return append(doc, bson.E{Key: key, Value: val})
+}
+
+// removeField removes the top level field of the document
+func removeField(doc bson.D, key string) bson.D {
+	for i, e := range doc {
+		if e.Key == key {
+			return append(doc[:i:i], doc[i+1:]...)
+		}
+	}
+	return doc
+}
+
+// getPath returns the value of the dotted path, array elements can be accessed by index
+func getPath(doc bson.D, path string) (interface{}, bool) {
+	var cur interface{} = doc
+	for _, key := range strings.Split(path, ".") {
+		switch v := cur.(type) {
+		case bson.D:
+			val, exists := getField(v, key)
+			if !exists {
+				return nil, false
+			}
+			cur = val
+		case bson.A:
+			idx, err := strconv.Atoi(key)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return nil, false
+			}
+			cur = v[idx]
+		default:
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// lookupPath returns all values that the dotted path refers to, arrays in the middle of the path are traversed just
+// like mongodb does, the returned bool value indicates whether the path exists
+func lookupPath(val interface{}, keys []string) ([]interface{}, bool) {
+	if len(keys) == 0 {
+		return []interface{}{val}, true
+	}
+
+	switch v := val.(type) {
+	case bson.D:
+		field, exists := getField(v, keys[0])
+		if !exists {
+			return nil, false
+		}
+		return lookupPath(field, keys[1:])
+	case bson.A:
+		if idx, err := strconv.Atoi(keys[0]); err == nil {
+			if idx < 0 || idx >= len(v) {
+				return nil, false
+			}
+			return lookupPath(v[idx], keys[1:])
+		}
+
+		values, found := make([]interface{}, 0), false
+		for _, item := range v {
+			if _, ok := item.(bson.D); !ok {
+				continue
+			}
+			itemValues, exists := lookupPath(item, keys)
+			if exists {
+				found = true
+				values = append(values, itemValues...)
+			}
+		}
+		return values, found
+	default:
+		return nil, false
+	}
+}
+
+// setPath sets the value of the dotted path, the missing embedded documents are created
+func setPath(doc bson.D, path string, val interface{}) (bson.D, error) {
+	keys := strings.SplitN(path, ".", 2)
+	if len(keys) == 1 {
+		return setField(doc, path, val), nil
+	}
+
+	child, exists := getField(doc, keys[0])
+	if !exists || child == nil {
+		child = bson.D{}
+	}
+
+	switch v := child.(type) {
+	case bson.D:
+		newChild, err := setPath(v, keys[1], val)
+		if err != nil {
+			return nil, err
+		}
+		return setField(doc, keys[0], newChild), nil
+	case bson.A:
+		newChild, err := setArrayPath(v, keys[1], val)
+		if err != nil {
+			return nil, err
+		}
+		return setField(doc, keys[0], newChild), nil
+	default:
+		return nil, fmt.Errorf("cannot create field in element {%s: %v}", keys[0], child)
+	}
+}
+
+func setArrayPath(arr bson.A, path string, val interface{}) (bson.A, error) {
+	keys := strings.SplitN(path, ".", 2)
+	idx, err := strconv.Atoi(keys[0])
+	if err != nil || idx < 0 {
+		return nil, fmt.Errorf("cannot create field %s in array", keys[0])
+	}
+
+	for len(arr) <= idx {
+		arr = append(arr, nil)
+	}
+
+	if len(keys) == 1 {
+		arr[idx] = val
+		return arr, nil
+	}
+
+	child, ok := arr[idx].(bson.D)
+	if !ok {
+		if arr[idx] != nil {
+			return nil, fmt.Errorf("cannot create field %s in element %v", keys[1], arr[idx])
+		}
+		child = bson.D{}
+	}
+	newChild, err := setPath(child, keys[1], val)
+	if err != nil {
+		return nil, err
+	}
+	arr[idx] = newChild
+	return arr, nil
+}
+
+// unsetPath removes the dotted path from the document
+func unsetPath(doc bson.D, path string) bson.D {
+	keys := strings.SplitN(path, ".", 2)
+	if len(keys) == 1 {
+		return removeField(doc, path)
+	}
+
+	child, exists := getField(doc, keys[0])
+	if !exists {
+		return doc
+	}
+
+	switch v := child.(type) {
+	case bson.D:
+		return setField(doc, keys[0], unsetPath(v, keys[1]))
+	case bson.A:
+		subKeys := strings.SplitN(keys[1], ".", 2)
+		idx, err := strconv.Atoi(subKeys[0])
+		if err != nil || idx < 0 || idx >= len(v) {
+			return doc
+		}
+		if len(subKeys) == 1 {
+			// mongodb sets the unset array element to null
+			v[idx] = nil
+			return doc
+		}
+		if elem, ok := v[idx].(bson.D); ok {
+			v[idx] = unsetPath(elem, subKeys[1])
+		}
+		return doc
+	default:
+		return doc
+	}
+}
+
+// typeOrder returns the bson type sort order defined by mongodb
+func typeOrder(val interface{}) int {
+	switch val.(type) {
+	case nil:
+		return 1
+	case int32, int64, float64, primitive.Decimal128:
+		return 2
+	case string:
+		return 3
+	case bson.D:
+		return 4
+	case bson.A:
+		return 5
+	case primitive.Binary:
+		return 6
+	case primitive.ObjectID:
+		return 7
+	case bool:
+		return 8
+	case primitive.DateTime:
+		return 9
+	case primitive.Timestamp:
+		return 10
+	case primitive.Regex:
+		return 11
+	default:
+		return 12
+	}
+}
+
+func toFloat(val interface{}) (float64, bool) {
+	switch v := val.(type) {
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case primitive.Decimal128:
+		f, err := strconv.ParseFloat(v.String(), 64)
+		if err != nil {
+			return math.NaN(), true
+		}
+		return f, true
+	default:
+		return 0, false
+	}
+}
+
+func isNumber(val interface{}) bool {
+	_, ok := toFloat(val)
+	return ok
+}
+
+// compareValues compares two bson values in the order defined by mongodb, returns -1, 0 or 1
+func compareValues(a, b interface{}) int {
+	orderA, orderB := typeOrder(a), typeOrder(b)
+	if orderA != orderB {
+		return compareInt(int64(orderA), int64(orderB))
+	}
+
+	switch va := a.(type) {
+	case nil:
+		return 0
+	case int32, int64, float64, primitive.Decimal128:
+		return compareNumbers(a, b)
+	case string:
+		return strings.Compare(va, b.(string))
+	case bson.D:
+		vb := b.(bson.D)
+		for i := 0; i < len(va) && i < len(vb); i++ {
+			if cmp := strings.Compare(va[i].Key, vb[i].Key); cmp != 0 {
+				return cmp
+			}
+			if cmp := compareValues(va[i].Value, vb[i].Value); cmp != 0 {
+				return cmp
+			}
+		}
+		return compareInt(int64(len(va)), int64(len(vb)))
+	case bson.A:
+		vb := b.(bson.A)
+		for i := 0; i < len(va) && i < len(vb); i++ {
+			if cmp := compareValues(va[i], vb[i]); cmp != 0 {
+				return cmp
+			}
+		}
+		return compareInt(int64(len(va)), int64(len(vb)))
+	case primitive.Binary:
+		vb := b.(primitive.Binary)
+		if cmp := compareInt(int64(len(va.Data)), int64(len(vb.Data))); cmp != 0 {
+			return cmp
+		}
+		if cmp := compareInt(int64(va.Subtype), int64(vb.Subtype)); cmp != 0 {
+			return cmp
+		}
+		return bytes.Compare(va.Data, vb.Data)
+	case primitive.ObjectID:
+		vb := b.(primitive.ObjectID)
+		return bytes.Compare(va[:], vb[:])
+	case bool:
+		vb := b.(bool)
+		if va == vb {
+			return 0
+		}
+		if !va {
+			return -1
+		}
+		return 1
+	case primitive.DateTime:
+		return compareInt(int64(va), int64(b.(primitive.DateTime)))
+	case primitive.Timestamp:
+		return primitive.CompareTimestamp(va, b.(primitive.Timestamp))
+	case primitive.Regex:
+		vb := b.(primitive.Regex)
+		if cmp := strings.Compare(va.Pattern, vb.Pattern); cmp != 0 {
+			return cmp
+		}
+		return strings.Compare(va.Options, vb.Options)
+	default:
+		return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+	}
+}
+
+func compareNumbers(a, b interface{}) int {
+	// compare int64 values directly to avoid the precision loss of large integers
+	ia, okA := toInt64(a)
+	ib, okB := toInt64(b)
+	if okA && okB {
+		return compareInt(ia, ib)
+	}
+
+	fa, _ := toFloat(a)
+	fb, _ := toFloat(b)
+	switch {
+	case fa < fb:
+		return -1
+	case fa > fb:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func toInt64(val interface{}) (int64, bool) {
+	switch v := val.(type) {
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+func compareInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func valuesEqual(a, b interface{}) bool {
+	return compareValues(a, b) == 0
+}
+
+// valueKey returns a string that identifies the bson value, equal values have the same key
+func valueKey(val interface{}) string {
+	switch v := val.(type) {
+	case int32, int64, float64, primitive.Decimal128:
+		f, _ := toFloat(v)
+		if i, ok := toInt64(v); ok {
+			return "n:" + strconv.FormatInt(i, 10)
+		}
+		if f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+			return "n:" + strconv.FormatInt(int64(f), 10)
+		}
+		return "n:" + strconv.FormatFloat(f, 'g', -1, 64)
+	case bson.D:
+		keys := make([]string, len(v))
+		for i, e := range v {
+			keys[i] = strconv.Quote(e.Key) + ":" + valueKey(e.Value)
+		}
+		return "d:{" + strings.Join(keys, ",") + "}"
+	case bson.A:
+		keys := make([]string, len(v))
+		for i, item := range v {
+			keys[i] = valueKey(item)
+		}
+		return "a:[" + strings.Join(keys, ",") + "]"
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprintf("%d:%#v", typeOrder(v), v)
+	}
+}
+
+// formatValue formats the bson value like the mongodb shell, it is used in error messages
+func formatValue(val interface{}) string {
+	switch v := val.(type) {
+	case string:
+		return strconv.Quote(v)
+	case nil:
+		return "null"
+	case primitive.ObjectID:
+		return fmt.Sprintf("ObjectId('%s')", v.Hex())
+	default:
+		return fmt.Sprint(v)
+	}
+}
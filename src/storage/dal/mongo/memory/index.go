@@ -0,0 +1,254 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package memory
+
+import (
+	"fmt"
+	"strings"
+
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal/types"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const idIndexName = "_id_"
+
+// table is the in memory collection data, the documents are never changed in place, so that the table can be copied
+// cheaply for transactions
+type table struct {
+	docs    []bson.D
+	indexes []types.Index
+	// version is increased by every committed write, it is used to detect transaction write conflicts
+	version uint64
+}
+
+func newTable() *table {
+	return &table{
+		docs: make([]bson.D, 0),
+		indexes: []types.Index{{
+			Keys: bson.D{{Key: "_id", Value: int32(1)}},
+			Name: idIndexName,
+		}},
+	}
+}
+
+// clone returns a copy of the table that shares the immutable documents
+func (t *table) clone() *table {
+	return &table{
+		docs:    append(make([]bson.D, 0, len(t.docs)), t.docs...),
+		indexes: append(make([]types.Index, 0, len(t.indexes)), t.indexes...),
+		version: t.version,
+	}
+}
+
+// genIndexName generates the default index name like mongodb, e.g. bk_obj_id_1_bk_inst_id_-1
+func genIndexName(keys bson.D) string {
+	names := make([]string, 0, len(keys)*2)
+	for _, key := range keys {
+		names = append(names, key.Key, fmt.Sprint(key.Value))
+	}
+	return strings.Join(names, "_")
+}
+
+// normalizeIndex validates the index and fills the default index name
+func normalizeIndex(index types.Index) (types.Index, error) {
+	if len(index.Keys) == 0 {
+		return index, fmt.Errorf("index keys can not be empty")
+	}
+
+	keys := make(bson.D, len(index.Keys))
+	for idx, key := range index.Keys {
+		val, err := util.GetInt32ByInterface(key.Value)
+		if err != nil {
+			return index, err
+		}
+		keys[idx] = bson.E{Key: key.Key, Value: val}
+	}
+	index.Keys = keys
+
+	if index.Name == "" {
+		index.Name = genIndexName(keys)
+	}
+	return index, nil
+}
+
+// addIndex adds the index to the table, the index that is exactly the same as the existing one is ignored
+func (t *table) addIndex(coll string, index types.Index) error {
+	index, err := normalizeIndex(index)
+	if err != nil {
+		return err
+	}
+
+	for _, existing := range t.indexes {
+		sameKeys := valuesEqual(existing.Keys, index.Keys)
+		if existing.Name == index.Name {
+			if sameKeys && existing.Unique == index.Unique {
+				return nil
+			}
+			return fmt.Errorf("IndexOptionsConflict: an index with name %s already exists with different options",
+				index.Name)
+		}
+		if sameKeys && existing.Unique == index.Unique {
+			// same as mongodb, the index with same keys but different name is treated as already exists
+			return fmt.Errorf("index %s already exists with a different name: %s", index.Name, existing.Name)
+		}
+	}
+
+	indexes := append(t.indexes, index)
+	for i, doc := range t.docs {
+		if err = checkUniqueIndex(coll, t.docs, index, doc, i); err != nil {
+			return err
+		}
+	}
+	t.indexes = indexes
+	return nil
+}
+
+// dropIndex drops the index by name, dropping a nonexistent index is ignored
+func (t *table) dropIndex(name string) error {
+	if name == idIndexName {
+		return fmt.Errorf("cannot drop _id index")
+	}
+
+	for i, index := range t.indexes {
+		if index.Name == name {
+			t.indexes = append(t.indexes[:i:i], t.indexes[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+// checkUnique checks if the document violates the unique indexes of the table, the document at the skip position
+// is the original document that is being updated, use -1 for inserted document
+func (t *table) checkUnique(coll string, doc bson.D, skip int) error {
+	id, _ := getField(doc, "_id")
+	for i, other := range t.docs {
+		if i == skip {
+			continue
+		}
+		if otherID, _ := getField(other, "_id"); valuesEqual(id, otherID) {
+			return dupKeyError(coll, idIndexName, bson.D{{Key: "_id", Value: id}})
+		}
+	}
+
+	for _, index := range t.indexes {
+		if err := checkUniqueIndex(coll, t.docs, index, doc, skip); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkUniqueIndex(coll string, docs []bson.D, index types.Index, doc bson.D, skip int) error {
+	if !index.Unique {
+		return nil
+	}
+
+	var partialFilter bson.D
+	if len(index.PartialFilterExpression) > 0 {
+		var err error
+		if partialFilter, err = toDoc(index.PartialFilterExpression); err != nil {
+			return err
+		}
+		if matched, err := matchDoc(doc, partialFilter); err != nil || !matched {
+			return err
+		}
+	}
+
+	keys, keyValues := indexKeys(index, doc)
+	for i, other := range docs {
+		if i == skip {
+			continue
+		}
+
+		if partialFilter != nil {
+			if matched, err := matchDoc(other, partialFilter); err != nil || !matched {
+				if err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		otherKeys, _ := indexKeys(index, other)
+		for key := range otherKeys {
+			if _, exists := keys[key]; exists {
+				return dupKeyError(coll, index.Name, keyValues[key])
+			}
+		}
+	}
+	return nil
+}
+
+// indexKeys returns all index keys of the document, array values generates multiple keys like mongodb multikey index
+func indexKeys(index types.Index, doc bson.D) (map[string]struct{}, map[string]bson.D) {
+	combos := []bson.D{{}}
+	for _, key := range index.Keys {
+		values, exists := lookupPath(doc, strings.Split(key.Key, "."))
+		if !exists || len(values) == 0 {
+			values = []interface{}{nil}
+		}
+
+		fieldValues := make([]interface{}, 0)
+		for _, val := range values {
+			arr, isArr := val.(bson.A)
+			if !isArr {
+				fieldValues = append(fieldValues, val)
+				continue
+			}
+			if len(arr) == 0 {
+				fieldValues = append(fieldValues, nil)
+			}
+			fieldValues = append(fieldValues, arr...)
+		}
+
+		newCombos := make([]bson.D, 0, len(combos)*len(fieldValues))
+		for _, combo := range combos {
+			for _, val := range fieldValues {
+				newCombo := append(append(bson.D{}, combo...), bson.E{Key: key.Key, Value: val})
+				newCombos = append(newCombos, newCombo)
+			}
+		}
+		combos = newCombos
+	}
+
+	keys := make(map[string]struct{}, len(combos))
+	keyValues := make(map[string]bson.D, len(combos))
+	for _, combo := range combos {
+		values := make(bson.A, len(combo))
+		for i, e := range combo {
+			values[i] = e.Value
+		}
+		key := valueKey(values)
+		keys[key] = struct{}{}
+		keyValues[key] = combo
+	}
+	return keys, keyValues
+}
+
+// dupKeyError returns the duplicate key error in the same format as mongodb, so that the error can be parsed by the
+// same logics, e.g. E11000 duplicate key error collection: cmdb.cc_ObjDes index: idx_obj_id dup key: { bk_obj_id: "a" }
+func dupKeyError(coll, indexName string, keyValues bson.D) error {
+	fields := make([]string, len(keyValues))
+	for i, e := range keyValues {
+		fields[i] = e.Key + ": " + formatValue(e.Value)
+	}
+	return fmt.Errorf("E11000 duplicate key error collection: %s.%s index: %s dup key: { %s }", dbName, coll,
+		indexName, strings.Join(fields, ", "))
+}
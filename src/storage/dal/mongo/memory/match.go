@@ -0,0 +1,398 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package memory
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// matchDoc checks if the document matches the mongodb query filter
+func matchDoc(doc bson.D, filter bson.D) (bool, error) {
+	for _, e := range filter {
+		matched, err := matchElement(doc, e)
+		if err != nil {
+			return false, err
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func matchElement(doc bson.D, e bson.E) (bool, error) {
+	switch e.Key {
+	case "$and", "$or", "$nor":
+		subFilters, err := toFilterArray(e.Key, e.Value)
+		if err != nil {
+			return false, err
+		}
+		return matchLogical(doc, e.Key, subFilters)
+	case "$comment":
+		return true, nil
+	}
+
+	if strings.HasPrefix(e.Key, "$") {
+		return false, fmt.Errorf("unknown top level operator: %s", e.Key)
+	}
+
+	values, exists := lookupPath(doc, strings.Split(e.Key, "."))
+	if cond, ok := e.Value.(bson.D); ok && isOperatorDoc(cond) {
+		return matchOperators(values, exists, cond)
+	}
+	return matchEqual(values, exists, e.Value)
+}
+
+func toFilterArray(op string, val interface{}) ([]bson.D, error) {
+	arr, ok := val.(bson.A)
+	if !ok || len(arr) == 0 {
+		return nil, fmt.Errorf("%s must be a nonempty array", op)
+	}
+
+	filters := make([]bson.D, len(arr))
+	for i, item := range arr {
+		filter, ok := item.(bson.D)
+		if !ok {
+			return nil, fmt.Errorf("%s argument's entries must be objects", op)
+		}
+		filters[i] = filter
+	}
+	return filters, nil
+}
+
+func matchLogical(doc bson.D, op string, filters []bson.D) (bool, error) {
+	for _, filter := range filters {
+		matched, err := matchDoc(doc, filter)
+		if err != nil {
+			return false, err
+		}
+
+		switch {
+		case op == "$and" && !matched:
+			return false, nil
+		case op == "$or" && matched:
+			return true, nil
+		case op == "$nor" && matched:
+			return false, nil
+		}
+	}
+	return op != "$or", nil
+}
+
+// isOperatorDoc checks if the document is a query operator document like {$gt: 1}
+func isOperatorDoc(doc bson.D) bool {
+	return len(doc) > 0 && strings.HasPrefix(doc[0].Key, "$")
+}
+
+// expandValues returns the values and the elements of the array values, mongodb matches both of them
+func expandValues(values []interface{}) []interface{} {
+	result := make([]interface{}, 0, len(values))
+	for _, val := range values {
+		result = append(result, val)
+		if arr, ok := val.(bson.A); ok {
+			result = append(result, arr...)
+		}
+	}
+	return result
+}
+
+func matchEqual(values []interface{}, exists bool, target interface{}) (bool, error) {
+	if regex, ok := target.(primitive.Regex); ok {
+		return matchRegex(values, regex)
+	}
+
+	if target == nil && !exists {
+		return true, nil
+	}
+
+	for _, val := range expandValues(values) {
+		if valuesEqual(val, target) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func matchIn(values []interface{}, exists bool, target interface{}) (bool, error) {
+	arr, ok := target.(bson.A)
+	if !ok {
+		return false, fmt.Errorf("$in needs an array")
+	}
+
+	for _, item := range arr {
+		matched, err := matchEqual(values, exists, item)
+		if err != nil {
+			return false, err
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func matchOperators(values []interface{}, exists bool, cond bson.D) (bool, error) {
+	for _, e := range cond {
+		var matched bool
+		var err error
+
+		switch e.Key {
+		case "$options":
+			if _, hasRegex := getField(cond, "$regex"); !hasRegex {
+				return false, fmt.Errorf("$options needs a $regex")
+			}
+			continue
+		case "$regex":
+			var regex primitive.Regex
+			regex, err = parseRegexOperator(cond, e.Value)
+			if err == nil {
+				matched, err = matchRegex(values, regex)
+			}
+		case "$not":
+			matched, err = matchNot(values, exists, e.Value)
+		default:
+			matched, err = matchOperator(values, exists, e.Key, e.Value)
+		}
+
+		if err != nil {
+			return false, err
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func matchNot(values []interface{}, exists bool, cond interface{}) (bool, error) {
+	var matched bool
+	var err error
+	switch v := cond.(type) {
+	case primitive.Regex:
+		matched, err = matchRegex(values, v)
+	case bson.D:
+		if !isOperatorDoc(v) {
+			return false, fmt.Errorf("$not needs a regex or a document with operators")
+		}
+		matched, err = matchOperators(values, exists, v)
+	default:
+		return false, fmt.Errorf("$not needs a regex or a document")
+	}
+	return !matched, err
+}
+
+func matchOperator(values []interface{}, exists bool, op string, target interface{}) (bool, error) {
+	switch op {
+	case "$eq":
+		return matchEqual(values, exists, target)
+	case "$ne":
+		matched, err := matchEqual(values, exists, target)
+		return !matched, err
+	case "$in":
+		return matchIn(values, exists, target)
+	case "$nin":
+		matched, err := matchIn(values, exists, target)
+		return !matched, err
+	case "$gt", "$gte", "$lt", "$lte":
+		return matchCompare(values, exists, op, target), nil
+	case "$exists":
+		return exists == isTrue(target), nil
+	case "$size":
+		size, ok := toInt64(target)
+		if !ok {
+			if f, isFloat := target.(float64); isFloat && f == float64(int64(f)) {
+				size, ok = int64(f), true
+			}
+		}
+		if !ok {
+			return false, fmt.Errorf("$size needs a number")
+		}
+		for _, val := range values {
+			if arr, isArr := val.(bson.A); isArr && int64(len(arr)) == size {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "$all":
+		return matchAll(values, target)
+	case "$elemMatch":
+		return matchElemMatch(values, target)
+	default:
+		return false, fmt.Errorf("unknown operator: %s", op)
+	}
+}
+
+func matchCompare(values []interface{}, exists bool, op string, target interface{}) bool {
+	if target == nil {
+		// only $gte and $lte match null values like $eq
+		if op == "$gt" || op == "$lt" {
+			return false
+		}
+		matched, _ := matchEqual(values, exists, nil)
+		return matched
+	}
+
+	for _, val := range expandValues(values) {
+		// mongodb only compares values of the same type bracket
+		if typeOrder(val) != typeOrder(target) {
+			continue
+		}
+
+		cmp := compareValues(val, target)
+		switch {
+		case op == "$gt" && cmp > 0, op == "$gte" && cmp >= 0, op == "$lt" && cmp < 0, op == "$lte" && cmp <= 0:
+			return true
+		}
+	}
+	return false
+}
+
+func matchAll(values []interface{}, target interface{}) (bool, error) {
+	arr, ok := target.(bson.A)
+	if !ok {
+		return false, fmt.Errorf("$all needs an array")
+	}
+	if len(arr) == 0 {
+		return false, nil
+	}
+
+	for _, item := range arr {
+		if cond, isDoc := item.(bson.D); isDoc && len(cond) == 1 && cond[0].Key == "$elemMatch" {
+			matched, err := matchElemMatch(values, cond[0].Value)
+			if err != nil || !matched {
+				return false, err
+			}
+			continue
+		}
+
+		matched, err := matchEqual(values, true, item)
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func matchElemMatch(values []interface{}, target interface{}) (bool, error) {
+	cond, ok := target.(bson.D)
+	if !ok {
+		return false, fmt.Errorf("$elemMatch needs an object")
+	}
+
+	for _, val := range values {
+		arr, isArr := val.(bson.A)
+		if !isArr {
+			continue
+		}
+
+		for _, elem := range arr {
+			var matched bool
+			var err error
+			if isOperatorDoc(cond) {
+				matched, err = matchOperators([]interface{}{elem}, true, cond)
+			} else if elemDoc, isDoc := elem.(bson.D); isDoc {
+				matched, err = matchDoc(elemDoc, cond)
+			}
+
+			if err != nil {
+				return false, err
+			}
+			if matched {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func parseRegexOperator(cond bson.D, val interface{}) (primitive.Regex, error) {
+	var regex primitive.Regex
+	switch v := val.(type) {
+	case string:
+		regex.Pattern = v
+	case primitive.Regex:
+		regex = v
+	default:
+		return regex, fmt.Errorf("$regex has to be a string")
+	}
+
+	if options, exists := getField(cond, "$options"); exists {
+		optStr, ok := options.(string)
+		if !ok {
+			return regex, fmt.Errorf("$options has to be a string")
+		}
+		regex.Options = optStr
+	}
+	return regex, nil
+}
+
+func compileRegex(regex primitive.Regex) (*regexp.Regexp, error) {
+	flags := ""
+	for _, opt := range regex.Options {
+		switch opt {
+		case 'i', 'm', 's':
+			flags += string(opt)
+		}
+	}
+
+	pattern := regex.Pattern
+	if flags != "" {
+		pattern = "(?" + flags + ")" + pattern
+	}
+	return regexp.Compile(pattern)
+}
+
+func matchRegex(values []interface{}, regex primitive.Regex) (bool, error) {
+	re, err := compileRegex(regex)
+	if err != nil {
+		return false, err
+	}
+
+	for _, val := range expandValues(values) {
+		switch v := val.(type) {
+		case string:
+			if re.MatchString(v) {
+				return true, nil
+			}
+		case primitive.Regex:
+			if v == regex {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// isTrue checks if the value is true in mongodb, which means it is not false, null or zero
+func isTrue(val interface{}) bool {
+	switch v := val.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	default:
+		if f, ok := toFloat(v); ok {
+			return f != 0
+		}
+		return true
+	}
+}
@@ -0,0 +1,378 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+// Package memory is an in-memory implementation of the storage dal.DB interface, it follows the behaviors of the
+// mongodb implementation as far as possible, so that the logics based on dal.DB can be tested without a mongodb.
+// Transactions are supported by the snapshot of the tables that are accessed in the transaction, the transaction is
+// identified by the transaction id in context just like the mongodb implementation.
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/redis"
+	"configcenter/src/storage/dal/types"
+	// use the same bson decoders as the mongodb implementation to decode the query results
+	_ "configcenter/src/storage/dal/mongo/local"
+)
+
+// dbName is the database name used in error messages
+const dbName = "cmdb"
+
+// writeConflictErr is returned when the tables written in a transaction are changed by others after the transaction
+// snapshot is taken, the error message is the same as mongodb so that the caller can retry the transaction
+var writeConflictErr = errors.New("WriteConflict error: this operation conflicted with another operation. " +
+	"Please retry your operation or multi-document transaction")
+
+// DB is the in-memory db
+type DB struct {
+	lock      sync.RWMutex
+	tables    map[string]*table
+	sequences map[string]uint64
+	txns      map[string]*txnSession
+}
+
+var _ dal.DB = new(DB)
+
+// txnSession is the transaction session data
+type txnSession struct {
+	// tables are the snapshots of the tables that are accessed in the transaction
+	tables map[string]*table
+	// baseVersions are the table versions when the snapshots are taken
+	baseVersions map[string]uint64
+	// written are the tables that are written in the transaction
+	written map[string]struct{}
+	// conflicted defines if the transaction has write conflict, the transaction should be retried if so
+	conflicted bool
+}
+
+// New create a new empty in-memory db
+func New() *DB {
+	return &DB{
+		tables:    make(map[string]*table),
+		sequences: make(map[string]uint64),
+		txns:      make(map[string]*txnSession),
+	}
+}
+
+// getTxnID get transaction id from context, returns empty string if it is not a transaction context
+func getTxnID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(common.TransactionIdHeader).(string)
+	return id
+}
+
+// readTable returns the table data that can be read in the context, the returned table must not be changed
+func (c *DB) readTable(ctx context.Context, coll string) *table {
+	txnID := getTxnID(ctx)
+	if txnID == "" {
+		c.lock.RLock()
+		defer c.lock.RUnlock()
+		if t, exists := c.tables[coll]; exists {
+			return t
+		}
+		return newTable()
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.getTxnTable(txnID, coll)
+}
+
+// getTxnTable get the snapshot of the table in the transaction, the snapshot is taken at the first access
+func (c *DB) getTxnTable(txnID, coll string) *table {
+	sess, exists := c.txns[txnID]
+	if !exists {
+		sess = &txnSession{
+			tables:       make(map[string]*table),
+			baseVersions: make(map[string]uint64),
+			written:      make(map[string]struct{}),
+		}
+		c.txns[txnID] = sess
+	}
+
+	if t, exists := sess.tables[coll]; exists {
+		return t
+	}
+
+	t, exists := c.tables[coll]
+	if !exists {
+		t = newTable()
+	}
+	sess.tables[coll] = t
+	sess.baseVersions[coll] = t.version
+	return t
+}
+
+// writeTable runs the write function on a copy of the table data in the context, the changes take effect only when
+// the write function succeeds, so that the write operation is atomic
+func (c *DB) writeTable(ctx context.Context, coll string, write func(t *table) error) error {
+	return c.writeTables(ctx, []string{coll}, func(tables []*table) error {
+		return write(tables[0])
+	})
+}
+
+// writeTables runs the write function on copies of multiple tables atomically, e.g. delete with archive
+func (c *DB) writeTables(ctx context.Context, colls []string, write func(tables []*table) error) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	tables := make([]*table, len(colls))
+	txnID := getTxnID(ctx)
+	if txnID == "" {
+		for i, coll := range colls {
+			t, exists := c.tables[coll]
+			if !exists {
+				t = newTable()
+			}
+			tables[i] = t.clone()
+		}
+
+		if err := write(tables); err != nil {
+			return err
+		}
+		for i, coll := range colls {
+			tables[i].version++
+			c.tables[coll] = tables[i]
+		}
+		return nil
+	}
+
+	for i, coll := range colls {
+		tables[i] = c.getTxnTable(txnID, coll).clone()
+	}
+
+	sess := c.txns[txnID]
+	for _, coll := range colls {
+		if c.isTxnConflicted(sess, coll) {
+			sess.conflicted = true
+			return writeConflictErr
+		}
+	}
+
+	if err := write(tables); err != nil {
+		return err
+	}
+	for i, coll := range colls {
+		sess.tables[coll] = tables[i]
+		sess.written[coll] = struct{}{}
+	}
+	return nil
+}
+
+// isTxnConflicted checks if the table is changed by others after the transaction snapshot is taken
+func (c *DB) isTxnConflicted(sess *txnSession, coll string) bool {
+	var version uint64
+	if t, exists := c.tables[coll]; exists {
+		version = t.version
+	}
+	return version != sess.baseVersions[coll]
+}
+
+// CommitTransaction commit the transaction
+func (c *DB) CommitTransaction(ctx context.Context, cap *metadata.TxnCapable) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	sess, exists := c.txns[cap.SessionID]
+	if !exists {
+		// no db operation with transaction is executed, same as mongodb, return directly
+		return nil
+	}
+
+	for coll := range sess.written {
+		if c.isTxnConflicted(sess, coll) {
+			sess.conflicted = true
+			return fmt.Errorf("commit transaction: %s failed, err: %v", cap.SessionID, writeConflictErr)
+		}
+	}
+
+	for coll := range sess.written {
+		t := sess.tables[coll]
+		t.version = sess.baseVersions[coll] + 1
+		c.tables[coll] = t
+	}
+	delete(c.txns, cap.SessionID)
+	return nil
+}
+
+// AbortTransaction abort the transaction, returns if the transaction needs to be retried
+func (c *DB) AbortTransaction(ctx context.Context, cap *metadata.TxnCapable) (bool, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	sess, exists := c.txns[cap.SessionID]
+	if !exists {
+		return false, nil
+	}
+
+	delete(c.txns, cap.SessionID)
+	return sess.conflicted, nil
+}
+
+// InitTxnManager is not needed for the in-memory db, because the transaction session is stored in memory
+func (c *DB) InitTxnManager(r redis.Client) error {
+	return nil
+}
+
+// Table collection operation
+func (c *DB) Table(collName string) types.Table {
+	return &Collection{collName: collName, DB: c}
+}
+
+// redirectTable redirects the sharding table to the base table for sequence name, same as mongodb implementation
+func (c *DB) redirectTable(tableName string) string {
+	return common.RedirectShardingTable(tableName)
+}
+
+// IDGeneratorStep the sequences of the in-memory db are always increased by step 1
+func (c *DB) IDGeneratorStep() int {
+	return 1
+}
+
+// NextSequence get the next sequence number, the sequence is not in transaction
+func (c *DB) NextSequence(ctx context.Context, sequenceName string) (uint64, error) {
+	sequences, err := c.NextSequences(ctx, sequenceName, 1)
+	if err != nil {
+		return 0, err
+	}
+	return sequences[0], nil
+}
+
+// NextSequences get the next sequence numbers in batch, the sequences are not in transaction
+func (c *DB) NextSequences(ctx context.Context, sequenceName string, num int) ([]uint64, error) {
+	if num == 0 {
+		return make([]uint64, 0), nil
+	}
+	sequenceName = c.redirectTable(sequenceName)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	sequences := make([]uint64, num)
+	for i := range sequences {
+		c.sequences[sequenceName]++
+		sequences[i] = c.sequences[sequenceName]
+	}
+	return sequences, nil
+}
+
+// SetSequence set the current sequence number, the next sequence number will be the value plus one, it is used to
+// prepare test data with specified ids
+func (c *DB) SetSequence(sequenceName string, value uint64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.sequences[c.redirectTable(sequenceName)] = value
+}
+
+// Ping the in-memory db is always available
+func (c *DB) Ping() error {
+	return nil
+}
+
+// Close the in-memory db, nothing needs to be released
+func (c *DB) Close() error {
+	return nil
+}
+
+// HasTable checks if the table exists
+func (c *DB) HasTable(ctx context.Context, name string) (bool, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	_, exists := c.tables[name]
+	return exists, nil
+}
+
+// ListTables list all table names in order
+func (c *DB) ListTables(ctx context.Context) ([]string, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	names := make([]string, 0, len(c.tables))
+	for name := range c.tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// DropTable drops the table, dropping a nonexistent table is ignored just like the mongodb driver
+func (c *DB) DropTable(ctx context.Context, name string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.tables, name)
+	return nil
+}
+
+// CreateTable creates the table
+func (c *DB) CreateTable(ctx context.Context, name string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if _, exists := c.tables[name]; exists {
+		return fmt.Errorf("(NamespaceExists) Collection %s.%s already exists.", dbName, name)
+	}
+	c.tables[name] = newTable()
+	return nil
+}
+
+// RenameTable renames the table
+func (c *DB) RenameTable(ctx context.Context, prevName, currName string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	t, exists := c.tables[prevName]
+	if !exists {
+		return fmt.Errorf("(NamespaceNotFound) source namespace %s.%s does not exist", dbName, prevName)
+	}
+	if _, exists = c.tables[currName]; exists {
+		return fmt.Errorf("(NamespaceExists) target namespace %s.%s exists", dbName, currName)
+	}
+
+	c.tables[currName] = t
+	delete(c.tables, prevName)
+	return nil
+}
+
+// IsDuplicatedError check duplicated error
+func (c *DB) IsDuplicatedError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	for _, msg := range []string{"E11000 duplicate", "IndexOptionsConflict", "already exists with a different name",
+		"NamespaceExists"} {
+		if strings.Contains(err.Error(), msg) {
+			return true
+		}
+	}
+	return err == types.ErrDuplicated
+}
+
+// IsNotFoundError check the not found error
+func (c *DB) IsNotFoundError(err error) bool {
+	return err == types.ErrDocumentNotFound
+}
@@ -0,0 +1,235 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal/types"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const testTable = "cc_TestTable"
+
+func prepareData(t *testing.T) (*DB, context.Context) {
+	db := New()
+	ctx := context.Background()
+	docs := []mapstr.MapStr{
+		{"id": 1, "name": "a", "tags": []string{"x", "y"}, "info": mapstr.MapStr{"level": 3}},
+		{"id": 2, "name": "b", "tags": []string{"y"}, "info": mapstr.MapStr{"level": 1}},
+		{"id": 3, "name": "c", "info": mapstr.MapStr{"level": 2}},
+	}
+	require.NoError(t, db.Table(testTable).Insert(ctx, docs))
+	return db, ctx
+}
+
+func TestFind(t *testing.T) {
+	db, ctx := prepareData(t)
+
+	result := make([]mapstr.MapStr, 0)
+	err := db.Table(testTable).Find(mapstr.MapStr{"tags": "y"}).Fields("id", "info.level").Sort("-id").All(ctx,
+		&result)
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	require.EqualValues(t, 2, result[0]["id"])
+	require.NotContains(t, result[0], "_id")
+	require.NotContains(t, result[0], "name")
+	level, err := result[0].MapStr("info")
+	require.NoError(t, err)
+	require.Len(t, level, 1)
+	require.EqualValues(t, 1, level["level"])
+
+	cond := mapstr.MapStr{
+		"$or": []mapstr.MapStr{
+			{"info.level": mapstr.MapStr{common.BKDBGTE: 3}},
+			{"name": mapstr.MapStr{common.BKDBIN: []string{"c"}}},
+		},
+	}
+	cnt, err := db.Table(testTable).Find(cond).Count(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, cnt)
+
+	result = make([]mapstr.MapStr, 0)
+	total, err := db.Table(testTable).Find(nil).Sort("id").Start(1).Limit(1).
+		List(ctx, &result)
+	require.NoError(t, err)
+	require.EqualValues(t, 0, total)
+	require.Len(t, result, 1)
+	require.EqualValues(t, 2, result[0]["id"])
+
+	one := make(mapstr.MapStr)
+	err = db.Table(testTable).Find(mapstr.MapStr{"name": "c"}, types.NewFindOpts().SetWithObjectID(true)).One(ctx,
+		&one)
+	require.NoError(t, err)
+	require.Contains(t, one, "_id")
+
+	err = db.Table(testTable).Find(mapstr.MapStr{"name": "d"}).One(ctx, &one)
+	require.True(t, db.IsNotFoundError(err))
+
+	values, err := db.Table(testTable).Distinct(ctx, "tags", nil)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []interface{}{"x", "y"}, values)
+}
+
+func TestUpdate(t *testing.T) {
+	db, ctx := prepareData(t)
+
+	cnt, err := db.Table(testTable).UpdateMany(ctx, mapstr.MapStr{"id": mapstr.MapStr{common.BKDBLT: 3}},
+		mapstr.MapStr{"name": "b", "info.level": 5})
+	require.NoError(t, err)
+	require.EqualValues(t, 2, cnt)
+
+	err = db.Table(testTable).UpdateMultiModel(ctx, mapstr.MapStr{"id": 3},
+		types.ModeUpdate{Op: "set", Doc: mapstr.MapStr{"name": "cc"}},
+		types.ModeUpdate{Op: types.UpdateOpAddToSet, Doc: mapstr.MapStr{"tags": "z"}})
+	require.NoError(t, err)
+
+	one := make(mapstr.MapStr)
+	require.NoError(t, db.Table(testTable).Find(mapstr.MapStr{"id": 3}).One(ctx, &one))
+	require.Equal(t, "cc", one["name"])
+	require.EqualValues(t, []interface{}{"z"}, one["tags"])
+
+	err = db.Table(testTable).Upsert(ctx, mapstr.MapStr{"id": 4}, mapstr.MapStr{"name": "d"})
+	require.NoError(t, err)
+	require.NoError(t, db.Table(testTable).Find(mapstr.MapStr{"id": 4}).One(ctx, &one))
+	require.Equal(t, "d", one["name"])
+
+	require.NoError(t, db.Table(testTable).DropColumn(ctx, "tags"))
+	cnt, err = db.Table(testTable).Find(mapstr.MapStr{"tags": mapstr.MapStr{common.BKDBExists: true}}).Count(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 0, cnt)
+
+	deleted, err := db.Table(testTable).DeleteMany(ctx, mapstr.MapStr{"id": mapstr.MapStr{common.BKDBGT: 2}})
+	require.NoError(t, err)
+	require.EqualValues(t, 2, deleted)
+}
+
+func TestUniqueIndex(t *testing.T) {
+	db, ctx := prepareData(t)
+
+	index := types.Index{Keys: bson.D{{Key: "name", Value: 1}}, Name: "idx_name", Unique: true}
+	require.NoError(t, db.Table(testTable).CreateIndex(ctx, index))
+
+	err := db.Table(testTable).Insert(ctx, mapstr.MapStr{"id": 4, "name": "a"})
+	require.True(t, db.IsDuplicatedError(err))
+
+	err = db.Table(testTable).Update(ctx, mapstr.MapStr{"id": 2}, mapstr.MapStr{"name": "a"})
+	require.True(t, db.IsDuplicatedError(err))
+
+	// the failed update does not change any data
+	cnt, err := db.Table(testTable).Find(mapstr.MapStr{"name": "a"}).Count(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, cnt)
+
+	indexes, err := db.Table(testTable).Indexes(ctx)
+	require.NoError(t, err)
+	require.Len(t, indexes, 2)
+}
+
+func TestAggregate(t *testing.T) {
+	db, ctx := prepareData(t)
+
+	pipeline := []mapstr.MapStr{
+		{common.BKDBMatch: mapstr.MapStr{"info.level": mapstr.MapStr{common.BKDBGT: 1}}},
+		{"$unwind": mapstr.MapStr{"path": "$tags", "preserveNullAndEmptyArrays": true}},
+		{common.BKDBGroup: mapstr.MapStr{
+			"_id":   "$tags",
+			"count": mapstr.MapStr{common.BKDBSum: 1},
+			"ids":   mapstr.MapStr{"$push": "$id"},
+		}},
+		{common.BKDBSort: mapstr.MapStr{"_id": 1}},
+	}
+
+	result := make([]struct {
+		ID    *string `bson:"_id"`
+		Count int64   `bson:"count"`
+		IDs   []int64 `bson:"ids"`
+	}, 0)
+	require.NoError(t, db.Table(testTable).AggregateAll(ctx, pipeline, &result))
+	require.Len(t, result, 3)
+	require.Nil(t, result[0].ID)
+	require.Equal(t, []int64{3}, result[0].IDs)
+	require.Equal(t, "x", *result[1].ID)
+	require.EqualValues(t, 1, result[2].Count)
+
+	count := make(mapstr.MapStr)
+	pipeline = []mapstr.MapStr{{"$count": "total"}}
+	require.NoError(t, db.Table(testTable).AggregateOne(ctx, pipeline, &count))
+	require.EqualValues(t, 3, count["total"])
+}
+
+func TestTransaction(t *testing.T) {
+	db, ctx := prepareData(t)
+
+	txnCtx := context.WithValue(ctx, common.TransactionIdHeader, "txn1")
+	txnCap := &metadata.TxnCapable{SessionID: "txn1"}
+	require.NoError(t, db.Table(testTable).Insert(txnCtx, mapstr.MapStr{"id": 4}))
+
+	// the uncommitted data is only visible in the transaction
+	cnt, err := db.Table(testTable).Find(nil).Count(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 3, cnt)
+	cnt, err = db.Table(testTable).Find(nil).Count(txnCtx)
+	require.NoError(t, err)
+	require.EqualValues(t, 4, cnt)
+
+	require.NoError(t, db.CommitTransaction(ctx, txnCap))
+	cnt, err = db.Table(testTable).Find(nil).Count(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 4, cnt)
+
+	// aborted transaction does not change data
+	require.NoError(t, db.Table(testTable).Delete(txnCtx, nil))
+	retry, err := db.AbortTransaction(ctx, txnCap)
+	require.NoError(t, err)
+	require.False(t, retry)
+	cnt, err = db.Table(testTable).Find(nil).Count(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 4, cnt)
+
+	// the transaction conflicts with the data written by others after its snapshot is taken
+	require.NoError(t, db.Table(testTable).Update(txnCtx, mapstr.MapStr{"id": 1}, mapstr.MapStr{"name": "t"}))
+	require.NoError(t, db.Table(testTable).Update(ctx, mapstr.MapStr{"id": 2}, mapstr.MapStr{"name": "o"}))
+	require.Error(t, db.CommitTransaction(ctx, txnCap))
+	retry, err = db.AbortTransaction(ctx, txnCap)
+	require.NoError(t, err)
+	require.True(t, retry)
+}
+
+func TestSequence(t *testing.T) {
+	db := New()
+	ctx := context.Background()
+
+	db.SetSequence(common.BKTableNameBaseHost, 10)
+	id, err := db.NextSequence(ctx, common.BKTableNameBaseHost)
+	require.NoError(t, err)
+	require.EqualValues(t, 11, id)
+
+	ids, err := db.NextSequences(ctx, common.GetObjectInstTableName("test", "0"), 2)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{1, 2}, ids)
+
+	id, err = db.NextSequence(ctx, common.BKTableNameBaseInst)
+	require.NoError(t, err)
+	require.EqualValues(t, 3, id)
+}
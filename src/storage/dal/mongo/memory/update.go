@@ -0,0 +1,294 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package memory
+
+import (
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// applyUpdate applies the mongodb update operators to a copy of the document, isInsert defines if the document is
+// inserted by upsert, in which case $setOnInsert takes effect
+func applyUpdate(doc bson.D, update bson.D, isInsert bool) (bson.D, error) {
+	result := copyDoc(doc)
+	id, hasID := getField(doc, "_id")
+
+	for _, e := range update {
+		fields, ok := e.Value.(bson.D)
+		if !ok {
+			return nil, fmt.Errorf("modifiers operate on fields but we found type %T instead", e.Value)
+		}
+
+		var err error
+		for _, field := range fields {
+			result, err = applyUpdateOperator(result, e.Key, field.Key, copyValue(field.Value), isInsert)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	newID, hasNewID := getField(result, "_id")
+	if hasID && (!hasNewID || !valuesEqual(id, newID)) {
+		return nil, fmt.Errorf("performing an update on the path '_id' would modify the immutable field '_id'")
+	}
+
+	return result, nil
+}
+
+func applyUpdateOperator(doc bson.D, op, path string, val interface{}, isInsert bool) (bson.D, error) {
+	switch op {
+	case "$set":
+		return setPath(doc, path, val)
+	case "$setOnInsert":
+		if !isInsert {
+			return doc, nil
+		}
+		return setPath(doc, path, val)
+	case "$unset":
+		return unsetPath(doc, path), nil
+	case "$inc", "$mul":
+		return updateNumber(doc, op, path, val)
+	case "$min", "$max":
+		old, exists := getPath(doc, path)
+		cmp := compareValues(val, old)
+		if !exists || (op == "$min" && cmp < 0) || (op == "$max" && cmp > 0) {
+			return setPath(doc, path, val)
+		}
+		return doc, nil
+	case "$push", "$addToSet":
+		return updateArrayAdd(doc, op, path, val)
+	case "$pull", "$pullAll":
+		return updateArrayPull(doc, op, path, val)
+	case "$rename":
+		newPath, ok := val.(string)
+		if !ok {
+			return nil, fmt.Errorf("the 'to' field for $rename must be a string")
+		}
+		old, exists := getPath(doc, path)
+		if !exists {
+			return doc, nil
+		}
+		return setPath(unsetPath(doc, path), newPath, old)
+	default:
+		return nil, fmt.Errorf("unknown modifier: %s", op)
+	}
+}
+
+func updateNumber(doc bson.D, op, path string, val interface{}) (bson.D, error) {
+	if !isNumber(val) {
+		return nil, fmt.Errorf("cannot %s with non-numeric argument: {%s: %v}", strings.TrimPrefix(op, "$"), path,
+			val)
+	}
+
+	old, exists := getPath(doc, path)
+	if !exists || old == nil {
+		if op == "$mul" {
+			return setPath(doc, path, mulNumbers(int32(0), val))
+		}
+		return setPath(doc, path, val)
+	}
+
+	if !isNumber(old) {
+		return nil, fmt.Errorf("cannot apply %s to a value of non-numeric type", op)
+	}
+
+	if op == "$mul" {
+		return setPath(doc, path, mulNumbers(old, val))
+	}
+	return setPath(doc, path, addNumbers(old, val))
+}
+
+// addNumbers adds two numbers, the result type is the wider type of them just like mongodb
+func addNumbers(a, b interface{}) interface{} {
+	ia, okA := toInt64(a)
+	ib, okB := toInt64(b)
+	if okA && okB {
+		_, isA32 := a.(int32)
+		_, isB32 := b.(int32)
+		sum := ia + ib
+		if isA32 && isB32 && sum == int64(int32(sum)) {
+			return int32(sum)
+		}
+		return sum
+	}
+
+	fa, _ := toFloat(a)
+	fb, _ := toFloat(b)
+	return fa + fb
+}
+
+func mulNumbers(a, b interface{}) interface{} {
+	ia, okA := toInt64(a)
+	ib, okB := toInt64(b)
+	if okA && okB {
+		_, isA32 := a.(int32)
+		_, isB32 := b.(int32)
+		product := ia * ib
+		if isA32 && isB32 && product == int64(int32(product)) {
+			return int32(product)
+		}
+		return product
+	}
+
+	fa, _ := toFloat(a)
+	fb, _ := toFloat(b)
+	return fa * fb
+}
+
+func updateArrayAdd(doc bson.D, op, path string, val interface{}) (bson.D, error) {
+	items := bson.A{val}
+	if modifier, ok := val.(bson.D); ok && isOperatorDoc(modifier) {
+		each, exists := getField(modifier, "$each")
+		if !exists {
+			return nil, fmt.Errorf("unknown modifier %s for %s", modifier[0].Key, op)
+		}
+		if items, ok = each.(bson.A); !ok {
+			return nil, fmt.Errorf("the argument to $each in %s must be an array", op)
+		}
+	}
+
+	old, exists := getPath(doc, path)
+	arr := bson.A{}
+	if exists && old != nil {
+		var ok bool
+		if arr, ok = old.(bson.A); !ok {
+			return nil, fmt.Errorf("the field '%s' must be an array", path)
+		}
+	}
+
+	newArr := append(bson.A{}, arr...)
+	for _, item := range items {
+		if op == "$addToSet" && containsValue(newArr, item) {
+			continue
+		}
+		newArr = append(newArr, item)
+	}
+	return setPath(doc, path, newArr)
+}
+
+func updateArrayPull(doc bson.D, op, path string, val interface{}) (bson.D, error) {
+	old, exists := getPath(doc, path)
+	if !exists || old == nil {
+		return doc, nil
+	}
+
+	arr, ok := old.(bson.A)
+	if !ok {
+		return nil, fmt.Errorf("cannot apply %s to a non-array value", op)
+	}
+
+	var pullItems bson.A
+	if op == "$pullAll" {
+		if pullItems, ok = val.(bson.A); !ok {
+			return nil, fmt.Errorf("$pullAll requires an array argument")
+		}
+	}
+
+	newArr := bson.A{}
+	for _, item := range arr {
+		var matched bool
+		var err error
+		if op == "$pullAll" {
+			matched = containsValue(pullItems, item)
+		} else {
+			matched, err = matchPullCond(item, val)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if !matched {
+			newArr = append(newArr, item)
+		}
+	}
+	return setPath(doc, path, newArr)
+}
+
+// matchPullCond checks if the array element matches the $pull condition, which can be a value or a query
+func matchPullCond(item interface{}, cond interface{}) (bool, error) {
+	condDoc, ok := cond.(bson.D)
+	if !ok {
+		return matchEqual([]interface{}{item}, true, cond)
+	}
+
+	if isOperatorDoc(condDoc) {
+		return matchOperators([]interface{}{item}, true, condDoc)
+	}
+
+	itemDoc, ok := item.(bson.D)
+	if !ok {
+		return false, nil
+	}
+	return matchDoc(itemDoc, condDoc)
+}
+
+func containsValue(arr bson.A, val interface{}) bool {
+	for _, item := range arr {
+		if valuesEqual(item, val) {
+			return true
+		}
+	}
+	return false
+}
+
+// upsertBaseDoc generates the base document to insert by upsert from the equality conditions of the filter
+func upsertBaseDoc(filter bson.D) (bson.D, error) {
+	doc := bson.D{}
+	var err error
+	for _, e := range filter {
+		switch {
+		case e.Key == "$and":
+			subFilters, ok := e.Value.(bson.A)
+			if !ok {
+				continue
+			}
+			for _, sub := range subFilters {
+				subDoc, ok := sub.(bson.D)
+				if !ok {
+					continue
+				}
+				base, err := upsertBaseDoc(subDoc)
+				if err != nil {
+					return nil, err
+				}
+				for _, baseElem := range base {
+					if doc, err = setPath(doc, baseElem.Key, baseElem.Value); err != nil {
+						return nil, err
+					}
+				}
+			}
+		case strings.HasPrefix(e.Key, "$"):
+			continue
+		default:
+			val := e.Value
+			if cond, ok := val.(bson.D); ok && isOperatorDoc(cond) {
+				eqVal, exists := getField(cond, "$eq")
+				if !exists {
+					continue
+				}
+				val = eqVal
+			}
+			if doc, err = setPath(doc, e.Key, copyValue(val)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return doc, nil
+}
@@ -20,6 +20,9 @@ package types
 import (
 	"context"
 	"errors"
+	"time"
+
+	"configcenter/src/common"
 
 	"go.mongodb.org/mongo-driver/bson"
 )
@@ -134,6 +137,7 @@ type Index struct {
 type FindOpts struct {
 	WithObjectID *bool
 	WithCount    *bool
+	ReadPref     *ReadPref
 }
 
 // NewFindOpts TODO
@@ -153,9 +157,17 @@ func (f *FindOpts) SetWithCount(bl bool) *FindOpts {
 	return f
 }
 
+// SetReadPref set the read preference of the find operation, it takes precedence over the read preference in the
+// context and the collection default read preference in config
+func (f *FindOpts) SetReadPref(mode common.ReadPreferenceMode, maxStaleness time.Duration) *FindOpts {
+	f.ReadPref = &ReadPref{Mode: mode, MaxStaleness: maxStaleness}
+	return f
+}
+
 // AggregateOpts TODO
 type AggregateOpts struct {
 	AllowDiskUse *bool
+	ReadPref     *ReadPref
 }
 
 // NewAggregateOpts TODO
@@ -168,3 +180,19 @@ func (a *AggregateOpts) SetAllowDiskUse(bl bool) *AggregateOpts {
 	a.AllowDiskUse = &bl
 	return a
 }
+
+// SetReadPref set the read preference of the aggregate operation, it takes precedence over the read preference in the
+// context and the collection default read preference in config
+func (a *AggregateOpts) SetReadPref(mode common.ReadPreferenceMode, maxStaleness time.Duration) *AggregateOpts {
+	a.ReadPref = &ReadPref{Mode: mode, MaxStaleness: maxStaleness}
+	return a
+}
+
+// ReadPref is the mongodb read preference of the read operation
+type ReadPref struct {
+	// Mode is the read preference mode, use common.PrimaryMode to force reading from primary for read-after-write
+	Mode common.ReadPreferenceMode
+	// MaxStaleness is the max replication lag of the secondary that can be read, it is not used for primary mode,
+	// the default max staleness is used if it is not set
+	MaxStaleness time.Duration
+}
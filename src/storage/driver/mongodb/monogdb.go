@@ -103,6 +103,12 @@ func InitClient(prefix string, config *mongo.Config) errors.CCErrorCoder {
 	return nil
 }
 
+// SetClient set the db client of the prefix directly, it is used to replace the mongodb client with other dal.DB
+// implementation like the in-memory db in unit tests
+func SetClient(prefix string, db dal.DB) {
+	dbMap[prefix] = db
+}
+
 // UpdateConfig update mongodb configuration
 func UpdateConfig(prefix string, config mongo.Config) {
 	// 不支持热更新
@@ -0,0 +1,177 @@
+package cloud_server_test
+
+import (
+	"context"
+	"fmt"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/cloud_server/cloudvendor/fakecloud"
+	"configcenter/src/test"
+	"configcenter/src/test/util"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("generic cloud vendor test", func() {
+	var provider *fakecloud.Provider
+	var endpoint string
+	var closeProvider func()
+
+	BeforeEach(func() {
+		clearAccountData()
+		clearSyncTaskData()
+
+		provider = fakecloud.NewProvider("generic-id", "generic-key")
+		provider.AddRegion(fakecloud.Region{ID: "region-1", Name: "地域1", State: "AVAILABLE"})
+		provider.AddVpc("region-1", fakecloud.Vpc{ID: "vpc-1", Name: "vpc1"})
+		provider.AddInstance("region-1", fakecloud.Instance{ID: "ins-1", PrivateIP: "10.0.0.1", State: "running",
+			VpcID: "vpc-1"})
+		server := provider.Start()
+		endpoint = server.URL
+		closeProvider = server.Close
+	})
+
+	AfterEach(func() {
+		closeProvider()
+	})
+
+	It("create generic cloud account with vendor conf", func() {
+		account := map[string]interface{}{
+			"bk_account_name": "genericAccount",
+			"bk_cloud_vendor": metadata.GenericCloud,
+			"bk_secret_id":    "generic-id",
+			"bk_secret_key":   "generic-key",
+			"bk_description":  "通用云厂商账户",
+			"bk_vendor_conf":  map[string]interface{}{"endpoint": endpoint},
+		}
+		rsp, err := cloudServerClient.CreateAccount(context.Background(), header, account)
+		util.RegisterResponseWithRid(rsp, header)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rsp.Result).To(Equal(true))
+	})
+
+	It("create generic cloud account without vendor conf", func() {
+		account := map[string]interface{}{
+			"bk_account_name": "genericAccount",
+			"bk_cloud_vendor": metadata.GenericCloud,
+			"bk_secret_id":    "generic-id",
+			"bk_secret_key":   "generic-key",
+		}
+		rsp, err := cloudServerClient.CreateAccount(context.Background(), header, account)
+		util.RegisterResponseWithRid(rsp, header)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rsp.Result).To(Equal(false))
+		Expect(rsp.Code).To(Equal(common.CCErrCommParamsNeedSet))
+	})
+
+	It("preview and confirm generic cloud sync task", func() {
+		account := map[string]interface{}{
+			"bk_account_name": "genericAccount",
+			"bk_cloud_vendor": metadata.GenericCloud,
+			"bk_secret_id":    "generic-id",
+			"bk_secret_key":   "generic-key",
+			"bk_vendor_conf":  map[string]interface{}{"endpoint": endpoint},
+		}
+		rsp, err := cloudServerClient.CreateAccount(context.Background(), header, account)
+		util.RegisterResponseWithRid(rsp, header)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rsp.Result).To(Equal(true))
+
+		// 清空数据后账户和任务的自增id都从1开始
+		task := map[string]interface{}{
+			"bk_task_name":     "genericTask",
+			"bk_account_id":    1,
+			"bk_resource_type": "host",
+			"bk_sync_all":      false,
+			"bk_sync_vpcs": []map[string]interface{}{
+				{
+					"bk_vpc_id":   "vpc-1",
+					"bk_region":   "region-1",
+					"bk_sync_dir": 1,
+					"bk_cloud_id": 0,
+				},
+			},
+		}
+		rsp, err = cloudServerClient.CreateSyncTask(context.Background(), header, task)
+		util.RegisterResponseWithRid(rsp, header)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rsp.Result).To(Equal(true))
+
+		previewRsp, err := cloudServerClient.PreviewSyncTask(context.Background(), header, 1)
+		util.RegisterResponseWithRid(previewRsp, header)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(previewRsp.Result).To(Equal(true))
+		Expect(len(previewRsp.Data.Add)).To(Equal(1))
+		Expect(previewRsp.Data.Add[0].InstanceId).To(Equal("ins-1"))
+		Expect(previewRsp.Data.Digest).NotTo(BeEmpty())
+
+		// 预览后云端新增了主机，使用旧的摘要确认同步会失败
+		provider.AddInstance("region-1", fakecloud.Instance{ID: "ins-2", PrivateIP: "10.0.0.2", State: "running",
+			VpcID: "vpc-1"})
+		rsp, err = cloudServerClient.ConfirmSyncTask(context.Background(), header, 1,
+			&metadata.ConfirmSyncTaskOption{Digest: previewRsp.Data.Digest})
+		util.RegisterResponseWithRid(rsp, header)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rsp.Result).To(Equal(false))
+		Expect(rsp.Code).To(Equal(common.CCErrCloudSyncPreviewChanged))
+	})
+
+	It("sync generic cloud hosts of more than one page", func() {
+		// 单页最多获取500个实例，vpc下共有601个实例，需要分页获取
+		for i := 0; i < 600; i++ {
+			provider.AddInstance("region-1", fakecloud.Instance{ID: fmt.Sprintf("ins-page-%03d", i),
+				PrivateIP: fmt.Sprintf("10.1.%d.%d", i/256, i%256), State: "running", VpcID: "vpc-1"})
+		}
+
+		account := map[string]interface{}{
+			"bk_account_name": "genericAccount",
+			"bk_cloud_vendor": metadata.GenericCloud,
+			"bk_secret_id":    "generic-id",
+			"bk_secret_key":   "generic-key",
+			"bk_vendor_conf":  map[string]interface{}{"endpoint": endpoint},
+		}
+		rsp, err := cloudServerClient.CreateAccount(context.Background(), header, account)
+		util.RegisterResponseWithRid(rsp, header)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rsp.Result).To(Equal(true))
+
+		task := map[string]interface{}{
+			"bk_task_name":     "genericTask",
+			"bk_account_id":    1,
+			"bk_resource_type": "host",
+			"bk_sync_all":      false,
+			"bk_sync_vpcs": []map[string]interface{}{
+				{
+					"bk_vpc_id":   "vpc-1",
+					"bk_region":   "region-1",
+					"bk_sync_dir": 1,
+					"bk_cloud_id": 0,
+				},
+			},
+		}
+		rsp, err = cloudServerClient.CreateSyncTask(context.Background(), header, task)
+		util.RegisterResponseWithRid(rsp, header)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rsp.Result).To(Equal(true))
+
+		previewRsp, err := cloudServerClient.PreviewSyncTask(context.Background(), header, 1)
+		util.RegisterResponseWithRid(previewRsp, header)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(previewRsp.Result).To(Equal(true))
+		Expect(len(previewRsp.Data.Add)).To(Equal(601))
+
+		// 确认同步会执行云主机同步器的同步逻辑
+		rsp, err = cloudServerClient.ConfirmSyncTask(context.Background(), header, 1,
+			&metadata.ConfirmSyncTaskOption{Digest: previewRsp.Data.Digest})
+		util.RegisterResponseWithRid(rsp, header)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rsp.Result).To(Equal(true))
+
+		cnt, err := test.GetDB().Table(common.BKTableNameBaseHost).Find(map[string]interface{}{
+			common.BKCloudVendor: metadata.GenericCloud}).Count(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cnt).To(Equal(uint64(601)))
+	})
+})
@@ -0,0 +1,109 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"configcenter/src/common/ssl"
+)
+
+// maxResponseBodySize is the max size of the webhook response body
+const maxResponseBodySize = 1 << 20
+
+// webhook is the admission webhook client
+type webhook struct {
+	conf   Config
+	client *http.Client
+}
+
+func newWebhook(conf Config) (*webhook, error) {
+	tlsConf, _, err := ssl.NewTLSConfigFromConf(conf.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("generate webhook %s tls config failed, err: %v", conf.Name, err)
+	}
+	if conf.TLS != nil {
+		tlsConf.InsecureSkipVerify = conf.TLS.InsecureSkipVerify
+	}
+
+	transport := &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		TLSHandshakeTimeout: 5 * time.Second,
+		TLSClientConfig:     tlsConf,
+		MaxIdleConnsPerHost: 100,
+		IdleConnTimeout:     90 * time.Second,
+	}
+
+	return &webhook{
+		conf: conf,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   conf.timeout(),
+		},
+	}, nil
+}
+
+// call sends the admission request to the webhook and returns the admission response
+func (w *webhook) call(ctx context.Context, header http.Header, req *Request) (*Response, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshal admission request failed, err: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, w.conf.timeout())
+	defer cancel()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, w.conf.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("new admission request failed, err: %v", err)
+	}
+	for key, values := range header {
+		httpReq.Header[key] = values
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("do admission request failed, err: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBodySize))
+	if err != nil {
+		return nil, fmt.Errorf("read admission response failed, err: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("admission response status code %d is invalid, body: %s", resp.StatusCode, respBody)
+	}
+
+	// use json number like the cmdb api request body, so that the patched numbers can be validated as integers
+	result := new(Response)
+	decoder := json.NewDecoder(bytes.NewReader(respBody))
+	decoder.UseNumber()
+	if err = decoder.Decode(result); err != nil {
+		return nil, fmt.Errorf("unmarshal admission response %s failed, err: %v", respBody, err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,212 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package webhook
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/ssl"
+)
+
+// Type is the admission webhook type
+type Type string
+
+const (
+	// Mutating webhook can modify the object by returning a patch, it is called before all validating webhooks
+	Mutating Type = "mutating"
+	// Validating webhook can only allow or reject the request
+	Validating Type = "validating"
+)
+
+// Operation is the operation that triggers the admission webhook
+type Operation string
+
+const (
+	// Create instance operation
+	Create Operation = "create"
+	// Update instance operation
+	Update Operation = "update"
+	// Delete instance operation
+	Delete Operation = "delete"
+	// Transfer host operation
+	Transfer Operation = "transfer"
+)
+
+// FailurePolicy defines how to handle the request when the webhook call failed
+type FailurePolicy string
+
+const (
+	// Fail rejects the request when the webhook call failed
+	Fail FailurePolicy = "Fail"
+	// Ignore ignores the webhook call failure and continues the request
+	Ignore FailurePolicy = "Ignore"
+)
+
+const (
+	// matchAllObjects is the object id that matches all objects
+	matchAllObjects = "*"
+	// defaultTimeoutSeconds is the default webhook call timeout
+	defaultTimeoutSeconds = 5
+	// maxTimeoutSeconds is the max webhook call timeout, so that the api request will not be blocked too long
+	maxTimeoutSeconds = 30
+)
+
+// config is the admission webhook config
+type config struct {
+	Webhooks []Config `mapstructure:"webhooks"`
+}
+
+// Config is the config of one admission webhook
+type Config struct {
+	// Name is the unique name of the webhook
+	Name string `mapstructure:"name"`
+	// Type is the webhook type, mutating or validating
+	Type Type `mapstructure:"type"`
+	// URL is the http(s) address that receives the admission request
+	URL string `mapstructure:"url"`
+	// Objects is the object ids that the webhook is registered for, "*" means all objects
+	Objects []string `mapstructure:"objects"`
+	// Operations is the operations that the webhook is registered for
+	Operations []Operation `mapstructure:"operations"`
+	// TimeoutSeconds is the webhook call timeout, default is 5 seconds
+	TimeoutSeconds int `mapstructure:"timeoutSeconds"`
+	// FailurePolicy defines how to handle the webhook call failure, default is Fail
+	FailurePolicy FailurePolicy `mapstructure:"failurePolicy"`
+	// TLS is the tls config used to call the https webhook
+	TLS *ssl.TLSClientConfig `mapstructure:"tls"`
+}
+
+// Validate validates the webhook config and fills the default values
+func (c *Config) Validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("webhook name is not set")
+	}
+
+	switch c.Type {
+	case Mutating, Validating:
+	default:
+		return fmt.Errorf("webhook %s type %s is invalid", c.Name, c.Type)
+	}
+
+	u, err := url.Parse(c.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("webhook %s url %s is invalid", c.Name, c.URL)
+	}
+
+	if len(c.Objects) == 0 {
+		return fmt.Errorf("webhook %s objects is not set", c.Name)
+	}
+
+	if len(c.Operations) == 0 {
+		return fmt.Errorf("webhook %s operations is not set", c.Name)
+	}
+	for _, op := range c.Operations {
+		switch op {
+		case Create, Update, Delete, Transfer:
+		default:
+			return fmt.Errorf("webhook %s operation %s is invalid", c.Name, op)
+		}
+	}
+
+	switch {
+	case c.TimeoutSeconds == 0:
+		c.TimeoutSeconds = defaultTimeoutSeconds
+	case c.TimeoutSeconds < 0 || c.TimeoutSeconds > maxTimeoutSeconds:
+		return fmt.Errorf("webhook %s timeoutSeconds %d must be in range 1~%d", c.Name, c.TimeoutSeconds,
+			maxTimeoutSeconds)
+	}
+
+	switch c.FailurePolicy {
+	case "":
+		c.FailurePolicy = Fail
+	case Fail, Ignore:
+	default:
+		return fmt.Errorf("webhook %s failurePolicy %s is invalid", c.Name, c.FailurePolicy)
+	}
+
+	return nil
+}
+
+func (c *Config) timeout() time.Duration {
+	return time.Duration(c.TimeoutSeconds) * time.Second
+}
+
+// matches checks if the webhook is registered for the object and operation
+func (c *Config) matches(objID string, op Operation) bool {
+	var opMatched bool
+	for _, operation := range c.Operations {
+		if operation == op {
+			opMatched = true
+			break
+		}
+	}
+	if !opMatched {
+		return false
+	}
+
+	for _, obj := range c.Objects {
+		if obj == matchAllObjects || obj == objID {
+			return true
+		}
+	}
+	return false
+}
+
+// Request is the admission request sent to the webhook
+type Request struct {
+	// UID is the unique id of the admission request, it is the request id of cmdb
+	UID string `json:"uid"`
+	// ObjectID is the object id of the instance, it is host for host transfer
+	ObjectID  string    `json:"bk_obj_id"`
+	Operation Operation `json:"operation"`
+	// User is the user who sends the request
+	User            string `json:"user"`
+	SupplierAccount string `json:"bk_supplier_account"`
+	// Object is the instance data to create, or the update data for update operation
+	Object mapstr.MapStr `json:"object,omitempty"`
+	// OldObjects is the origin instance data for update and delete operation
+	OldObjects []mapstr.MapStr `json:"old_objects,omitempty"`
+	// InstIDs is the ids of the instances to delete
+	InstIDs []int64 `json:"inst_ids,omitempty"`
+	// Transfer is the host transfer info for transfer operation
+	Transfer *TransferInfo `json:"transfer,omitempty"`
+}
+
+// TransferInfo is the host transfer info
+type TransferInfo struct {
+	// CrossBiz defines if the hosts are transferred from other businesses
+	CrossBiz bool `json:"cross_biz"`
+	// SrcBizIDs is the source business ids for cross business transfer
+	SrcBizIDs []int64 `json:"src_biz_ids,omitempty"`
+	// BizID is the destination business id
+	BizID int64 `json:"bk_biz_id"`
+	// ModuleIDs is the destination module ids
+	ModuleIDs []int64 `json:"bk_module_ids"`
+}
+
+// Response is the admission response returned by the webhook
+type Response struct {
+	// Allowed defines if the request is allowed
+	Allowed bool `json:"allowed"`
+	// Message is the reason why the request is rejected
+	Message string `json:"message"`
+	// Patch is the merge patch that the mutating webhook applies to the object, keys with null value are removed
+	Patch mapstr.MapStr `json:"patch"`
+}
@@ -0,0 +1,182 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+// Package webhook defines the admission webhooks that are called when instances are created, updated, deleted or
+// hosts are transferred, the webhooks can reject the request or modify the instance data like kubernetes admission
+// webhooks, so that the downstream users can extend the validation logics without recompiling cmdb.
+package webhook
+
+import (
+	"fmt"
+	"net/http"
+
+	"configcenter/src/common"
+	cc "configcenter/src/common/backbone/configcenter"
+	"configcenter/src/common/blog"
+	ccErr "configcenter/src/common/errors"
+	httpheader "configcenter/src/common/http/header"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+)
+
+// webhooks is all the registered admission webhooks, mutating webhooks are placed before validating webhooks
+var webhooks []*webhook
+
+// Init the admission webhooks by the config.
+func Init(prefix string) error {
+	configKey := "webhook"
+	if prefix != "" {
+		configKey = prefix + ".webhook"
+	}
+
+	if !cc.IsExist(configKey) {
+		return nil
+	}
+
+	conf := new(config)
+	if err := cc.UnmarshalKey(configKey, conf); err != nil {
+		return fmt.Errorf("parse webhook config by prefix %s failed, err: %v", prefix, err)
+	}
+
+	hooks, err := newWebhooks(conf.Webhooks)
+	if err != nil {
+		return err
+	}
+
+	webhooks = hooks
+	blog.Infof("init %d admission webhooks success", len(webhooks))
+	return nil
+}
+
+func newWebhooks(confs []Config) ([]*webhook, error) {
+	mutating, validating := make([]*webhook, 0), make([]*webhook, 0)
+	names := make(map[string]struct{})
+	for _, conf := range confs {
+		if err := conf.Validate(); err != nil {
+			return nil, err
+		}
+
+		if _, exists := names[conf.Name]; exists {
+			return nil, fmt.Errorf("webhook name %s is duplicated", conf.Name)
+		}
+		names[conf.Name] = struct{}{}
+
+		hook, err := newWebhook(conf)
+		if err != nil {
+			return nil, err
+		}
+
+		if conf.Type == Mutating {
+			mutating = append(mutating, hook)
+		} else {
+			validating = append(validating, hook)
+		}
+	}
+
+	return append(mutating, validating...), nil
+}
+
+// AdmitCreate calls the admission webhooks before the instance is created, the mutating webhooks patch is applied to
+// the instance data in place
+func AdmitCreate(kit *rest.Kit, objID string, data mapstr.MapStr) ccErr.CCErrorCoder {
+	return admit(kit, &Request{ObjectID: objID, Operation: Create, Object: data})
+}
+
+// AdmitUpdate calls the admission webhooks before the instance is updated, the mutating webhooks patch is applied to
+// the update data in place
+func AdmitUpdate(kit *rest.Kit, objID string, updateData mapstr.MapStr, origin mapstr.MapStr) ccErr.CCErrorCoder {
+	return admit(kit, &Request{ObjectID: objID, Operation: Update, Object: updateData,
+		OldObjects: []mapstr.MapStr{origin}})
+}
+
+// AdmitDelete calls the admission webhooks before the instances are deleted
+func AdmitDelete(kit *rest.Kit, objID string, instIDs []int64, origins []mapstr.MapStr) ccErr.CCErrorCoder {
+	if len(instIDs) == 0 {
+		return nil
+	}
+	return admit(kit, &Request{ObjectID: objID, Operation: Delete, InstIDs: instIDs, OldObjects: origins})
+}
+
+// AdmitTransfer calls the admission webhooks before the hosts are transferred
+func AdmitTransfer(kit *rest.Kit, transfer *TransferInfo) ccErr.CCErrorCoder {
+	return admit(kit, &Request{ObjectID: common.BKInnerObjIDHost, Operation: Transfer, Transfer: transfer})
+}
+
+func admit(kit *rest.Kit, req *Request) ccErr.CCErrorCoder {
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	req.UID = kit.Rid
+	req.User = kit.User
+	req.SupplierAccount = kit.SupplierAccount
+
+	header := make(http.Header)
+	httpheader.SetRid(header, kit.Rid)
+	httpheader.SetUser(header, kit.User)
+	httpheader.SetSupplierAccount(header, kit.SupplierAccount)
+
+	for _, hook := range webhooks {
+		if !hook.conf.matches(req.ObjectID, req.Operation) {
+			continue
+		}
+
+		resp, err := hook.call(kit.Ctx, header, req)
+		if err != nil {
+			if hook.conf.FailurePolicy == Ignore {
+				blog.Warnf("call webhook %s failed, ignore it, err: %v, rid: %s", hook.conf.Name, err, kit.Rid)
+				continue
+			}
+			blog.Errorf("call webhook %s failed, err: %v, rid: %s", hook.conf.Name, err, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCommWebhookCallFailed, hook.conf.Name, err.Error())
+		}
+
+		if !resp.Allowed {
+			blog.Errorf("request is rejected by webhook %s, message: %s, rid: %s", hook.conf.Name, resp.Message,
+				kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCommWebhookRejected, hook.conf.Name, resp.Message)
+		}
+
+		if hook.conf.Type == Mutating && len(resp.Patch) > 0 {
+			applyPatch(req, resp.Patch)
+			blog.V(4).Infof("object is patched by webhook %s, patch: %v, rid: %s", hook.conf.Name, resp.Patch,
+				kit.Rid)
+		}
+	}
+
+	return nil
+}
+
+// applyPatch applies the merge patch to the request object, the patch is ignored if the operation has no object.
+// the fields that identify the instance can not be patched.
+func applyPatch(req *Request, patch mapstr.MapStr) {
+	if req.Object == nil {
+		return
+	}
+
+	for key, value := range patch {
+		switch key {
+		case common.BKOwnerIDField, common.BKObjIDField, common.GetInstIDField(req.ObjectID):
+			continue
+		}
+
+		if value == nil {
+			delete(req.Object, key)
+			continue
+		}
+		req.Object[key] = value
+	}
+}
@@ -0,0 +1,217 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testServer is a local admission webhook server that records the received requests
+type testServer struct {
+	*httptest.Server
+	lock     sync.Mutex
+	requests []*Request
+}
+
+func newTestServer(t *testing.T, handler func(req *Request) *Response) *testServer {
+	s := new(testServer)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := new(Request)
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		s.lock.Lock()
+		s.requests = append(s.requests, req)
+		s.lock.Unlock()
+
+		_ = json.NewEncoder(w).Encode(handler(req))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *testServer) received() []*Request {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.requests
+}
+
+func setWebhooks(t *testing.T, confs ...Config) {
+	hooks, err := newWebhooks(confs)
+	require.NoError(t, err)
+	webhooks = hooks
+	t.Cleanup(func() {
+		webhooks = nil
+	})
+}
+
+func newKit() *rest.Kit {
+	return &rest.Kit{
+		Rid:             "test-rid",
+		Ctx:             context.Background(),
+		CCError:         errors.NewFromCtx(errors.EmptyErrorsSetting).CreateDefaultCCErrorIf("en"),
+		User:            "admin",
+		SupplierAccount: "0",
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	conf := Config{Name: "a", Type: Validating, URL: "http://127.0.0.1:8080/admit", Objects: []string{"*"},
+		Operations: []Operation{Create}}
+	require.NoError(t, conf.Validate())
+	require.Equal(t, defaultTimeoutSeconds, conf.TimeoutSeconds)
+	require.Equal(t, Fail, conf.FailurePolicy)
+
+	invalid := []Config{
+		{Name: "", Type: Validating, URL: "http://a", Objects: []string{"*"}, Operations: []Operation{Create}},
+		{Name: "a", Type: "x", URL: "http://a", Objects: []string{"*"}, Operations: []Operation{Create}},
+		{Name: "a", Type: Validating, URL: "ftp://a", Objects: []string{"*"}, Operations: []Operation{Create}},
+		{Name: "a", Type: Validating, URL: "http://a", Operations: []Operation{Create}},
+		{Name: "a", Type: Validating, URL: "http://a", Objects: []string{"*"}, Operations: []Operation{"x"}},
+		{Name: "a", Type: Validating, URL: "http://a", Objects: []string{"*"}, Operations: []Operation{Create},
+			TimeoutSeconds: 100},
+		{Name: "a", Type: Validating, URL: "http://a", Objects: []string{"*"}, Operations: []Operation{Create},
+			FailurePolicy: "x"},
+	}
+	for idx := range invalid {
+		require.Error(t, invalid[idx].Validate(), "config index %d", idx)
+	}
+
+	_, err := newWebhooks([]Config{conf, conf})
+	require.Error(t, err)
+}
+
+func TestAdmitMatch(t *testing.T) {
+	server := newTestServer(t, func(req *Request) *Response {
+		return &Response{Allowed: true}
+	})
+	setWebhooks(t, Config{Name: "biz", Type: Validating, URL: server.URL, Objects: []string{common.BKInnerObjIDApp},
+		Operations: []Operation{Create, Delete}})
+
+	kit := newKit()
+	require.Nil(t, AdmitCreate(kit, common.BKInnerObjIDApp, mapstr.MapStr{common.BKAppNameField: "biz"}))
+	require.Nil(t, AdmitCreate(kit, common.BKInnerObjIDHost, mapstr.MapStr{common.BKHostInnerIPField: "127.0.0.1"}))
+	require.Nil(t, AdmitUpdate(kit, common.BKInnerObjIDApp, mapstr.MapStr{}, mapstr.MapStr{}))
+	require.Nil(t, AdmitDelete(kit, common.BKInnerObjIDApp, []int64{2, 3}, []mapstr.MapStr{{}, {}}))
+
+	requests := server.received()
+	require.Len(t, requests, 2)
+	require.Equal(t, Create, requests[0].Operation)
+	require.Equal(t, common.BKInnerObjIDApp, requests[0].ObjectID)
+	require.Equal(t, "biz", requests[0].Object[common.BKAppNameField])
+	require.Equal(t, "test-rid", requests[0].UID)
+	require.Equal(t, "admin", requests[0].User)
+	require.Equal(t, Delete, requests[1].Operation)
+	require.Equal(t, []int64{2, 3}, requests[1].InstIDs)
+}
+
+func TestAdmitReject(t *testing.T) {
+	server := newTestServer(t, func(req *Request) *Response {
+		if req.Transfer != nil && req.Transfer.CrossBiz {
+			return &Response{Allowed: false, Message: "cross biz transfer is forbidden"}
+		}
+		return &Response{Allowed: true}
+	})
+	setWebhooks(t, Config{Name: "transfer", Type: Validating, URL: server.URL, Objects: []string{"*"},
+		Operations: []Operation{Transfer}})
+
+	kit := newKit()
+	require.Nil(t, AdmitTransfer(kit, &TransferInfo{BizID: 2, ModuleIDs: []int64{3}}))
+
+	err := AdmitTransfer(kit, &TransferInfo{CrossBiz: true, SrcBizIDs: []int64{1}, BizID: 2, ModuleIDs: []int64{3}})
+	require.NotNil(t, err)
+	require.Equal(t, common.CCErrCommWebhookRejected, err.GetCode())
+
+	requests := server.received()
+	require.Len(t, requests, 2)
+	require.Equal(t, common.BKInnerObjIDHost, requests[1].ObjectID)
+	require.Equal(t, []int64{1}, requests[1].Transfer.SrcBizIDs)
+}
+
+func TestAdmitMutate(t *testing.T) {
+	mutating := newTestServer(t, func(req *Request) *Response {
+		return &Response{Allowed: true, Patch: mapstr.MapStr{"operator": "admin", "remark": nil,
+			common.BKInstIDField: 100}}
+	})
+	validating := newTestServer(t, func(req *Request) *Response {
+		if req.Object["operator"] != "admin" {
+			return &Response{Allowed: false, Message: "operator is not set"}
+		}
+		return &Response{Allowed: true}
+	})
+	// validating webhook is configured first to check that mutating webhooks are called before it
+	setWebhooks(t,
+		Config{Name: "validate", Type: Validating, URL: validating.URL, Objects: []string{"*"},
+			Operations: []Operation{Create, Update}},
+		Config{Name: "mutate", Type: Mutating, URL: mutating.URL, Objects: []string{"*"},
+			Operations: []Operation{Create, Update}},
+	)
+
+	kit := newKit()
+	data := mapstr.MapStr{common.BKInstNameField: "a", "remark": "b"}
+	require.Nil(t, AdmitCreate(kit, "test_obj", data))
+	require.Equal(t, mapstr.MapStr{common.BKInstNameField: "a", "operator": "admin"}, data)
+
+	origin := mapstr.MapStr{common.BKInstIDField: 1, common.BKInstNameField: "a"}
+	updateData := mapstr.MapStr{common.BKInstNameField: "b"}
+	require.Nil(t, AdmitUpdate(kit, "test_obj", updateData, origin))
+	require.Equal(t, mapstr.MapStr{common.BKInstNameField: "b", "operator": "admin"}, updateData)
+
+	requests := validating.received()
+	require.Len(t, requests, 2)
+	require.Len(t, requests[1].OldObjects, 1)
+}
+
+func TestAdmitFailurePolicy(t *testing.T) {
+	slow := newTestServer(t, func(req *Request) *Response {
+		time.Sleep(2 * time.Second)
+		return &Response{Allowed: true}
+	})
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(broken.Close)
+
+	setWebhooks(t,
+		Config{Name: "slow", Type: Validating, URL: slow.URL, Objects: []string{"*"},
+			Operations: []Operation{Delete}, TimeoutSeconds: 1, FailurePolicy: Ignore},
+		Config{Name: "broken", Type: Validating, URL: broken.URL, Objects: []string{"*"},
+			Operations: []Operation{Create}},
+	)
+
+	kit := newKit()
+	require.Nil(t, AdmitDelete(kit, "test_obj", []int64{1}, []mapstr.MapStr{{}}))
+
+	err := AdmitCreate(kit, "test_obj", mapstr.MapStr{})
+	require.NotNil(t, err)
+	require.Equal(t, common.CCErrCommWebhookCallFailed, err.GetCode())
+}
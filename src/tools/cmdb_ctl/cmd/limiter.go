@@ -43,6 +43,8 @@ const (
 ./tool_ctl limiter ls
 # 配置策略，对url限制请求次数
 ./tool_ctl limiter set --rule='{"rulename":"rule1","appcode":"gse","user":"admin","ip":"","method":"POST","url":"^/api/v3/module/search/[^\\s/]+/[0-9]+/[0-9]+/?$","limit":1000,"ttl":60,"denyall":false}'
+# 配置策略，使用令牌桶对url限流，并限制并发数
+./tool_ctl limiter set --rule='{"rulename":"rule2","appcode":"gse","url":"^/api/v3/findmany/hosts/search/?$","limit":100,"ttl":1,"mode":"token_bucket","burst":200,"concurrency":20}'
 # 配置策略，将url直接禁掉
 ./tool_ctl limiter set --rule='{"rulename":"rule1","appcode":"gse","user":"admin","url":"^/api/v3/module/search/[^\\s/]+/[0-9]+/[0-9]+/?$","denyall":true}'
 # 获取某些策略详情
@@ -67,9 +69,15 @@ const (
 | limit    | int64  | 否   | api请求限制总次数                                            |
 | ttl      | int64  | 否   | 策略存活时间，单位为秒                                       |
 | denyall  | bool   | 否   | 是否直接禁掉请求，默认为false，为true时忽略limit和ttl参数    |
+| mode     | string | 否   | 限流算法，fixed_window(固定窗口，默认)或token_bucket(令牌桶) |
+| burst    | int64  | 否   | 令牌桶容量，仅token_bucket模式可配置，默认为limit            |
+| concurrency | int64 | 否 | 匹配该策略的请求的最大并发数，默认为0，表示不限制并发        |
  
 appcode、user、ip、method、url需要至少配置一项  
 denyall配置为false的情况下，limit和ttl配置才能生效
+token_bucket模式下每ttl秒向令牌桶补充limit个令牌，每个请求消耗一个令牌，允许的突发请求数为burst  
+命中策略的请求的响应会带上X-RateLimit-Limit、X-RateLimit-Remaining、X-RateLimit-Reset头，被限流时还会带上Retry-After头  
+各策略当前的使用情况可以通过apiserver的 GET /api/v3/find/api_limiter/usage 接口查询
 ********************************************************
 		`
 )
@@ -0,0 +1,464 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	synctypes "configcenter/pkg/synchronize/types"
+	headerutil "configcenter/src/common/http/header/util"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/types"
+	"configcenter/src/common/util"
+	"configcenter/src/tools/cmdb_ctl/app/config"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	// syncAuditIntro sync audit introduction
+	syncAuditIntro = `
+********************************************************
+示例:
+以下命令是在配置了ZK_ADDR环境变量的情况下使用，没有配置时也可以通过命令行参数--zk-addr指定，或者通过--addr直接指定transfer-service地址
+# 在源环境导出所有资源的同步审计数据，在目标环境进行对比，并输出审计报告
+./tool_ctl sync-audit export | ssh dest-host ./tool_ctl sync-audit compare
+# 在源环境导出主机的同步审计数据到文件
+./tool_ctl sync-audit export --rsc=host --output=host.audit
+# 在目标环境对比某个模型实例的同步审计数据，并将审计报告输出到文件
+./tool_ctl sync-audit compare --rsc=object_instance --sub-rsc=bk_switch --input=inst.audit --output=report.txt
+********************************************************
+		`
+)
+
+func init() {
+	rootCmd.AddCommand(NewSyncAuditCommand())
+}
+
+type syncAuditConf struct {
+	addr        string
+	resource    string
+	subResource string
+	input       string
+	output      string
+}
+
+func (c *syncAuditConf) addFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&c.addr, "addr", "", "the transfer-service address, like 127.0.0.1:60013, "+
+		"discovered from zookeeper if not set")
+	cmd.PersistentFlags().StringVar(&c.resource, "rsc", "", "the sync resource type to audit, audit all sync "+
+		"resource types if not set")
+	cmd.PersistentFlags().StringVar(&c.subResource, "sub-rsc", "", "the sub resource to audit, can be the object ID "+
+		"of object_instance, inst_asst or quoted_instance resource, audit all sub resources if not set")
+	cmd.PersistentFlags().StringVar(&c.input, "input", "-", "the sync audit data file to compare, '-' means stdin")
+	cmd.PersistentFlags().StringVar(&c.output, "output", "-", "the file to write the exported sync audit data or "+
+		"the audit report, '-' means stdout")
+}
+
+// NewSyncAuditCommand new sync audit command
+func NewSyncAuditCommand() *cobra.Command {
+	conf := new(syncAuditConf)
+
+	cmd := &cobra.Command{
+		Use:   "sync-audit",
+		Short: "read-only sync consistency audit between source and destination cmdb",
+		Long:  syncAuditIntro,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "export",
+		Short: "export sync audit data from source cmdb transfer-service",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runExportSyncAuditData(conf)
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "compare",
+		Short: "compare sync audit data with destination cmdb by transfer-service and print the audit report",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCompareSyncAuditData(conf)
+		},
+	})
+
+	conf.addFlags(cmd)
+	return cmd
+}
+
+func (c *syncAuditConf) validate() error {
+	if c.resource == "" {
+		if c.subResource != "" {
+			return fmt.Errorf("sub-rsc can only be set when rsc is set")
+		}
+		return nil
+	}
+
+	resType := synctypes.ResType(c.resource)
+	if _, withSubRes := synctypes.ResTypeWithSubResMap[resType]; withSubRes && c.subResource == "" {
+		return nil
+	}
+
+	if rawErr := resType.Validate(c.subResource); rawErr.ErrCode != 0 {
+		return fmt.Errorf("rsc %s or sub-rsc %s is invalid", c.resource, c.subResource)
+	}
+	return nil
+}
+
+func (c *syncAuditConf) getResTypes() []synctypes.ResType {
+	if c.resource != "" {
+		return []synctypes.ResType{synctypes.ResType(c.resource)}
+	}
+	return synctypes.ListAllResType()
+}
+
+func runExportSyncAuditData(c *syncAuditConf) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	server, err := getTransferServiceAddr(c.addr)
+	if err != nil {
+		return err
+	}
+
+	writer := io.Writer(os.Stdout)
+	if c.output != "-" {
+		file, err := os.Create(c.output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		writer = file
+	}
+	bufWriter := bufio.NewWriter(writer)
+	defer bufWriter.Flush()
+
+	for _, resType := range c.getResTypes() {
+		subResources := []string{""}
+		if _, withSubRes := synctypes.ResTypeWithSubResMap[resType]; withSubRes {
+			subResources = []string{c.subResource}
+			if c.subResource == "" {
+				subResources = make([]string, 0)
+				opt := &synctypes.ListSyncAuditSubResOpt{ResType: resType}
+				if err = doTransferServiceRequest(server, "/findmany/sync/audit/sub_resource", opt,
+					&subResources); err != nil {
+					return fmt.Errorf("list %s sub resources failed, err: %v", resType, err)
+				}
+			}
+		}
+
+		for _, subRes := range subResources {
+			if err = exportSyncAuditData(server, resType, subRes, bufWriter); err != nil {
+				return fmt.Errorf("export %s-%s sync audit data failed, err: %v", resType, subRes, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// exportSyncAuditData export all sync audit data of the resource, each interval of data is written as a json line
+func exportSyncAuditData(server string, resType synctypes.ResType, subRes string, writer io.Writer) error {
+	opt := &synctypes.ListSyncAuditDataOpt{
+		ResType: resType,
+		SubRes:  subRes,
+		Start:   make(map[string]int64),
+	}
+
+	for {
+		data := make(json.RawMessage, 0)
+		if err := doTransferServiceRequest(server, "/findmany/sync/audit/data", opt, &data); err != nil {
+			return err
+		}
+
+		line := new(bytes.Buffer)
+		if err := json.Compact(line, data); err != nil {
+			return err
+		}
+		line.WriteByte('\n')
+		if _, err := writer.Write(line.Bytes()); err != nil {
+			return err
+		}
+
+		page := new(synctypes.SyncAuditData)
+		if err := json.Unmarshal(data, page); err != nil {
+			return err
+		}
+
+		if page.IsAll {
+			return nil
+		}
+		opt.Start = page.NextStart
+	}
+}
+
+// syncAuditReport is the sync audit report of one resource
+type syncAuditReport struct {
+	resType           synctypes.ResType
+	subRes            string
+	srcCount          int
+	destCount         int
+	srcChecksums      []string
+	destChecksums     []string
+	missingIDs        []string
+	differentIDs      []string
+	redundantIDs      []string
+	lastPushTime      int64
+	lastApplyPushTime int64
+}
+
+func runCompareSyncAuditData(c *syncAuditConf) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	server, err := getTransferServiceAddr(c.addr)
+	if err != nil {
+		return err
+	}
+
+	reader := io.Reader(os.Stdin)
+	if c.input != "-" {
+		file, err := os.Open(c.input)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	reports, err := c.compareSyncAuditData(server, reader)
+	if err != nil {
+		return err
+	}
+
+	writer := io.Writer(os.Stdout)
+	if c.output != "-" {
+		file, err := os.Create(c.output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		writer = file
+	}
+
+	return printSyncAuditReports(writer, reports)
+}
+
+// compareSyncAuditData compare the exported sync audit data read from the reader with the destination cmdb, returns
+// the sync audit reports of the resources in the order they are read
+func (c *syncAuditConf) compareSyncAuditData(server string, reader io.Reader) ([]*syncAuditReport, error) {
+	reports := make([]*syncAuditReport, 0)
+	reportMap := make(map[string]*syncAuditReport)
+
+	decoder := json.NewDecoder(reader)
+	for {
+		data := make(json.RawMessage, 0)
+		if err := decoder.Decode(&data); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("decode sync audit data failed, err: %v", err)
+		}
+
+		page := new(synctypes.SyncAuditData)
+		if err := json.Unmarshal(data, page); err != nil {
+			return nil, fmt.Errorf("decode sync audit data failed, err: %v", err)
+		}
+
+		if c.resource != "" && string(page.ResType) != c.resource {
+			continue
+		}
+		if c.subResource != "" && page.SubRes != c.subResource {
+			continue
+		}
+
+		res := new(synctypes.SyncAuditCompareRes)
+		if err := doTransferServiceRequest(server, "/compare/sync/audit/data", data, res); err != nil {
+			return nil, fmt.Errorf("compare %s-%s sync audit data failed, err: %v", page.ResType, page.SubRes, err)
+		}
+
+		key := string(page.ResType) + "/" + page.SubRes
+		report, exists := reportMap[key]
+		if !exists {
+			report = &syncAuditReport{resType: page.ResType, subRes: page.SubRes}
+			reportMap[key] = report
+			reports = append(reports, report)
+		}
+
+		report.srcCount += res.SrcCount
+		report.destCount += res.DestCount
+		report.srcChecksums = append(report.srcChecksums, res.SrcChecksum)
+		report.destChecksums = append(report.destChecksums, res.DestChecksum)
+		report.missingIDs = append(report.missingIDs, res.MissingIDs...)
+		report.differentIDs = append(report.differentIDs, res.DifferentIDs...)
+		report.redundantIDs = append(report.redundantIDs, res.RedundantIDs...)
+		report.lastApplyPushTime = res.LastApplyPushTime
+		if page.IncrCursor != nil {
+			report.lastPushTime = page.IncrCursor.LastPushTime
+		}
+	}
+
+	return reports, nil
+}
+
+func printSyncAuditReports(writer io.Writer, reports []*syncAuditReport) error {
+	buf := new(bytes.Buffer)
+	inconsistentCnt := 0
+
+	for _, report := range reports {
+		name := string(report.resType)
+		if report.subRes != "" {
+			name += "/" + report.subRes
+		}
+
+		consistent := len(report.missingIDs) == 0 && len(report.differentIDs) == 0 && len(report.redundantIDs) == 0
+		if consistent {
+			buf.WriteString(WithGreenColor(fmt.Sprintf("%s: consistent", name)))
+		} else {
+			inconsistentCnt++
+			buf.WriteString(WithRedColor(fmt.Sprintf("%s: inconsistent", name)))
+		}
+
+		_, _ = fmt.Fprintf(buf, "  src count: %d, dest count: %d\n", report.srcCount, report.destCount)
+		_, _ = fmt.Fprintf(buf, "  src checksum:  %s\n", combineChecksums(report.srcChecksums))
+		_, _ = fmt.Fprintf(buf, "  dest checksum: %s\n", combineChecksums(report.destChecksums))
+		_, _ = fmt.Fprintf(buf, "  missing ids(%d): %s\n", len(report.missingIDs), strings.Join(report.missingIDs, ","))
+		_, _ = fmt.Fprintf(buf, "  different ids(%d): %s\n", len(report.differentIDs),
+			strings.Join(report.differentIDs, ","))
+		_, _ = fmt.Fprintf(buf, "  redundant ids(%d): %s\n", len(report.redundantIDs),
+			strings.Join(report.redundantIDs, ","))
+		_, _ = fmt.Fprintf(buf, "  incremental sync lag: %s\n", formatSyncLag(report.lastPushTime,
+			report.lastApplyPushTime))
+	}
+
+	_, _ = fmt.Fprintf(buf, "\naudited %d resources, %d inconsistent\n", len(reports), inconsistentCnt)
+
+	_, err := writer.Write(buf.Bytes())
+	return err
+}
+
+// combineChecksums combine the checksums of all intervals into the checksum of the resource
+func combineChecksums(checksums []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(checksums, ",")))
+	return hex.EncodeToString(sum[:])
+}
+
+// formatSyncLag format the lag between the last pushed incremental sync data in source and the last applied one
+// in destination
+func formatSyncLag(lastPushTime, lastApplyPushTime int64) string {
+	if lastPushTime == 0 {
+		return "unknown, no incremental sync data is pushed"
+	}
+
+	lag := lastPushTime - lastApplyPushTime
+	if lag < 0 {
+		lag = 0
+	}
+
+	applyTime := "never"
+	if lastApplyPushTime != 0 {
+		applyTime = time.Unix(lastApplyPushTime, 0).Format(time.RFC3339)
+	}
+
+	return fmt.Sprintf("%s (last push: %s, last applied push: %s)", time.Duration(lag)*time.Second,
+		time.Unix(lastPushTime, 0).Format(time.RFC3339), applyTime)
+}
+
+// getTransferServiceAddr get transfer-service address, discover it from zookeeper if the address is not specified
+func getTransferServiceAddr(addr string) (string, error) {
+	if addr != "" {
+		return addr, nil
+	}
+
+	zk, err := config.NewZkService(config.Conf.Zk)
+	if err != nil {
+		return "", fmt.Errorf("new zk client failed, err: %v", err)
+	}
+
+	path := types.CC_SERV_BASEPATH + "/" + types.CC_MODULE_TRANSFERSERVICE
+	children, err := zk.ZkCli.GetChildren(path)
+	if err != nil {
+		return "", fmt.Errorf("get transfer service failed, err: %v", err)
+	}
+
+	for _, child := range children {
+		node, err := zk.ZkCli.Get(path + "/" + child)
+		if err != nil {
+			return "", err
+		}
+		svr := new(types.ServerInfo)
+		if err := json.Unmarshal([]byte(node), svr); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s:%d", svr.RegisterIP, svr.Port), nil
+	}
+
+	return "", fmt.Errorf("no transfer service")
+}
+
+// doTransferServiceRequest do transfer-service post request and decode the response data
+func doTransferServiceRequest(server, path string, opt interface{}, data interface{}) error {
+	body, ok := opt.(json.RawMessage)
+	if !ok {
+		var err error
+		body, err = json.Marshal(opt)
+		if err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("http://%s/transfer/v3%s", server, path),
+		bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header = headerutil.GenCommonHeader("cmdb_tool", "0", util.GenerateRID())
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	result := &struct {
+		metadata.BaseResp `json:",inline"`
+		Data              json.RawMessage `json:"data"`
+	}{}
+	if err = json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return err
+	}
+
+	if !result.Result {
+		return fmt.Errorf("request failed, code: %d, err: %s", result.Code, result.ErrMsg)
+	}
+
+	return json.Unmarshal(result.Data, data)
+}
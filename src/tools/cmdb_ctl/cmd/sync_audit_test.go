@@ -0,0 +1,223 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	synctypes "configcenter/pkg/synchronize/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newSyncAuditServer new a fake transfer-service server, returns the server address without scheme
+func newSyncAuditServer(t *testing.T, handlers map[string]func(body []byte) (any, error)) string {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := new(bytes.Buffer)
+		_, err := body.ReadFrom(r.Body)
+		require.NoError(t, err)
+
+		resp := map[string]any{"result": true, "bk_error_code": 0}
+		handler, exists := handlers[strings.TrimPrefix(r.URL.Path, "/transfer/v3")]
+		if !exists {
+			resp = map[string]any{"result": false, "bk_error_code": 1199000, "bk_error_msg": "not found"}
+		} else if data, err := handler(body.Bytes()); err != nil {
+			resp = map[string]any{"result": false, "bk_error_code": 1199000, "bk_error_msg": err.Error()}
+		} else {
+			resp["data"] = data
+		}
+
+		require.NoError(t, json.NewEncoder(w).Encode(resp))
+	}))
+	t.Cleanup(server.Close)
+
+	return strings.TrimPrefix(server.URL, "http://")
+}
+
+func TestSyncAuditConfValidate(t *testing.T) {
+	testCases := []struct {
+		conf  syncAuditConf
+		valid bool
+	}{
+		{conf: syncAuditConf{}, valid: true},
+		{conf: syncAuditConf{subResource: "bk_switch"}, valid: false},
+		{conf: syncAuditConf{resource: "host"}, valid: true},
+		{conf: syncAuditConf{resource: "invalid"}, valid: false},
+		{conf: syncAuditConf{resource: "object_instance"}, valid: true},
+		{conf: syncAuditConf{resource: "object_instance", subResource: "bk_switch"}, valid: true},
+	}
+
+	for _, testCase := range testCases {
+		err := testCase.conf.validate()
+		require.Equal(t, testCase.valid, err == nil, "%+v", testCase.conf)
+	}
+
+	require.Equal(t, []synctypes.ResType{synctypes.Host}, (&syncAuditConf{resource: "host"}).getResTypes())
+	require.Equal(t, synctypes.ListAllResType(), new(syncAuditConf).getResTypes())
+}
+
+func TestExportSyncAuditData(t *testing.T) {
+	server := newSyncAuditServer(t, map[string]func(body []byte) (any, error){
+		"/findmany/sync/audit/data": func(body []byte) (any, error) {
+			opt := new(synctypes.ListSyncAuditDataOpt)
+			if err := json.Unmarshal(body, opt); err != nil {
+				return nil, err
+			}
+
+			// the data is returned in two intervals
+			if len(opt.Start) == 0 {
+				return &synctypes.SyncAuditData{ResType: opt.ResType, NextStart: map[string]int64{"id": 2},
+					Data: &synctypes.FullSyncTransData{Name: "src", Data: []int{1, 2}}}, nil
+			}
+			return &synctypes.SyncAuditData{ResType: opt.ResType, IsAll: true,
+				Data: &synctypes.FullSyncTransData{Name: "src", Start: opt.Start, Data: []int{3}}}, nil
+		},
+	})
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, exportSyncAuditData(server, synctypes.Host, "", buf))
+
+	// each interval is written as a json line
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	page := new(synctypes.SyncAuditData)
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), page))
+	require.False(t, page.IsAll)
+	require.Equal(t, map[string]int64{"id": 2}, page.NextStart)
+
+	page = new(synctypes.SyncAuditData)
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), page))
+	require.True(t, page.IsAll)
+	require.Equal(t, map[string]int64{"id": 2}, page.Data.Start)
+
+	// write or request failed
+	require.Error(t, exportSyncAuditData(server, synctypes.Biz, "", new(syncAuditFailWriter)))
+	server = newSyncAuditServer(t, nil)
+	require.Error(t, exportSyncAuditData(server, synctypes.Host, "", new(bytes.Buffer)))
+}
+
+// syncAuditFailWriter is the writer that always fails
+type syncAuditFailWriter struct{}
+
+func (w *syncAuditFailWriter) Write([]byte) (int, error) {
+	return 0, bytes.ErrTooLarge
+}
+
+func TestCompareSyncAuditData(t *testing.T) {
+	compareResMap := map[string]*synctypes.SyncAuditCompareRes{
+		"host/": {SrcCount: 2, DestCount: 2, SrcChecksum: "a", DestChecksum: "a", LastApplyPushTime: 90},
+		"host/2": {SrcCount: 2, DestCount: 2, SrcChecksum: "b", DestChecksum: "c", MissingIDs: []string{"3"},
+			RedundantIDs: []string{"4"}, LastApplyPushTime: 100},
+		"object_instance/bk_switch": {SrcCount: 1, DestCount: 1, SrcChecksum: "d", DestChecksum: "e",
+			DifferentIDs: []string{"5"}},
+	}
+	server := newSyncAuditServer(t, map[string]func(body []byte) (any, error){
+		"/compare/sync/audit/data": func(body []byte) (any, error) {
+			page := new(synctypes.SyncAuditData)
+			if err := json.Unmarshal(body, page); err != nil {
+				return nil, err
+			}
+
+			key := string(page.ResType) + "/" + page.SubRes
+			if len(page.Data.Start) > 0 {
+				key += "2"
+			}
+			return compareResMap[key], nil
+		},
+	})
+
+	input := strings.Join([]string{
+		`{"resource_type":"host","data":{"name":"src","start":{},"data":[1,2]},"is_all":false,` +
+			`"incr_cursor":{"last_push_time":100}}`,
+		`{"resource_type":"host","data":{"name":"src","start":{"id":2},"data":[3,4]},"is_all":true}`,
+		`{"resource_type":"object_instance","sub_resource":"bk_switch","data":{"name":"src","data":[5]},` +
+			`"is_all":true}`,
+	}, "\n")
+
+	// the compare results of all intervals of one resource are merged into one report
+	reports, err := new(syncAuditConf).compareSyncAuditData(server, strings.NewReader(input))
+	require.NoError(t, err)
+	require.Len(t, reports, 2)
+
+	host := reports[0]
+	require.Equal(t, synctypes.Host, host.resType)
+	require.Equal(t, 4, host.srcCount)
+	require.Equal(t, 4, host.destCount)
+	require.Equal(t, []string{"a", "b"}, host.srcChecksums)
+	require.Equal(t, []string{"a", "c"}, host.destChecksums)
+	require.Equal(t, []string{"3"}, host.missingIDs)
+	require.Empty(t, host.differentIDs)
+	require.Equal(t, []string{"4"}, host.redundantIDs)
+	require.Equal(t, int64(100), host.lastPushTime)
+	require.Equal(t, int64(100), host.lastApplyPushTime)
+
+	inst := reports[1]
+	require.Equal(t, "bk_switch", inst.subRes)
+	require.Equal(t, []string{"5"}, inst.differentIDs)
+
+	// only the specified resource is compared
+	reports, err = (&syncAuditConf{resource: "object_instance", subResource: "bk_switch"}).compareSyncAuditData(
+		server, strings.NewReader(input))
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	require.Equal(t, synctypes.ObjectInstance, reports[0].resType)
+
+	_, err = new(syncAuditConf).compareSyncAuditData(server, strings.NewReader("invalid"))
+	require.Error(t, err)
+}
+
+func TestPrintSyncAuditReports(t *testing.T) {
+	reports := []*syncAuditReport{
+		{resType: synctypes.Host, srcCount: 1, destCount: 1, srcChecksums: []string{"a"}, destChecksums: []string{"a"}},
+		{resType: synctypes.ObjectInstance, subRes: "bk_switch", srcCount: 2, destCount: 1,
+			srcChecksums: []string{"b"}, destChecksums: []string{"c"}, missingIDs: []string{"1", "2"},
+			redundantIDs: []string{"3"}},
+	}
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, printSyncAuditReports(buf, reports))
+
+	output := buf.String()
+	require.Contains(t, output, "host: consistent")
+	require.Contains(t, output, "object_instance/bk_switch: inconsistent")
+	require.Contains(t, output, "missing ids(2): 1,2")
+	require.Contains(t, output, "redundant ids(1): 3")
+	require.Contains(t, output, "audited 2 resources, 1 inconsistent")
+}
+
+func TestCombineChecksums(t *testing.T) {
+	require.Equal(t, combineChecksums([]string{"a", "b"}), combineChecksums([]string{"a", "b"}))
+	require.NotEqual(t, combineChecksums([]string{"a", "b"}), combineChecksums([]string{"b", "a"}))
+}
+
+func TestFormatSyncLag(t *testing.T) {
+	require.Contains(t, formatSyncLag(0, 0), "unknown")
+
+	lastPush := time.Now().Unix()
+	require.True(t, strings.HasPrefix(formatSyncLag(lastPush, lastPush-90), "1m30s"))
+	require.Contains(t, formatSyncLag(lastPush, 0), "last applied push: never")
+
+	// the applied push time can be later than the pushed time that is got before it
+	require.True(t, strings.HasPrefix(formatSyncLag(lastPush, lastPush+10), "0s"))
+}
@@ -37,7 +37,9 @@ import (
 func (lgc *Logics) GetDepartment(c *gin.Context, config *options.Config) (*metadata.DepartmentData,
 	errors.CCErrorCoder) {
 	if config.LoginVersion == common.BKOpenSourceLoginPluginVersion ||
-		config.LoginVersion == common.BKSkipLoginPluginVersion {
+		config.LoginVersion == common.BKSkipLoginPluginVersion ||
+		config.LoginVersion == common.BKOIDCLoginPluginVersion ||
+		config.LoginVersion == common.BKLDAPLoginPluginVersion {
 		return &metadata.DepartmentData{}, nil
 	}
 
@@ -62,7 +64,9 @@ func (lgc *Logics) GetDepartment(c *gin.Context, config *options.Config) (*metad
 func (lgc *Logics) GetDepartmentProfile(c *gin.Context, config *options.Config) (*metadata.DepartmentProfileData,
 	errors.CCErrorCoder) {
 	if config.LoginVersion == common.BKOpenSourceLoginPluginVersion ||
-		config.LoginVersion == common.BKSkipLoginPluginVersion {
+		config.LoginVersion == common.BKSkipLoginPluginVersion ||
+		config.LoginVersion == common.BKOIDCLoginPluginVersion ||
+		config.LoginVersion == common.BKLDAPLoginPluginVersion {
 		return &metadata.DepartmentProfileData{}, nil
 	}
 
@@ -86,7 +90,9 @@ func (lgc *Logics) GetDepartmentProfile(c *gin.Context, config *options.Config)
 func (lgc *Logics) GetAllDepartment(c *gin.Context, config *options.Config, orgIDs []int64) (*metadata.DepartmentData,
 	errors.CCErrorCoder) {
 	if config.LoginVersion == common.BKOpenSourceLoginPluginVersion ||
-		config.LoginVersion == common.BKSkipLoginPluginVersion {
+		config.LoginVersion == common.BKSkipLoginPluginVersion ||
+		config.LoginVersion == common.BKOIDCLoginPluginVersion ||
+		config.LoginVersion == common.BKLDAPLoginPluginVersion {
 		return &metadata.DepartmentData{}, nil
 	}
 
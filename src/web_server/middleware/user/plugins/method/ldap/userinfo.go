@@ -0,0 +1,77 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+// Package ldap defines ldap login method, the user name and password submitted from the login page is verified by ldap
+package ldap
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	httpheader "configcenter/src/common/http/header"
+	"configcenter/src/common/metadata"
+	"configcenter/src/web_server/middleware/user/plugins/manager"
+	"configcenter/src/web_server/middleware/user/plugins/sso"
+	ssoldap "configcenter/src/web_server/middleware/user/plugins/sso/ldap"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	plugin := &metadata.LoginPluginInfo{
+		Name:       "ldap system",
+		Version:    common.BKLDAPLoginPluginVersion,
+		HandleFunc: &user{},
+	}
+	manager.RegisterPlugin(plugin)
+}
+
+type user struct{}
+
+// LoginUser user login
+func (m *user) LoginUser(c *gin.Context, config map[string]string, isMultiOwner bool) (*metadata.LoginUserInfo, bool) {
+	identity, ok := sso.LoadIdentity(c)
+	if !ok {
+		return nil, false
+	}
+
+	conf, err := sso.LoadConfig()
+	if err != nil {
+		blog.Errorf("load sso config failed, err: %v, rid: %s", err, httpheader.GetRid(c.Request.Header))
+		return nil, false
+	}
+
+	return sso.NewLoginUserInfo(c, conf, identity), true
+}
+
+// GetLoginUrl get login url
+func (m *user) GetLoginUrl(c *gin.Context, config map[string]string, input *metadata.LogoutRequestParams) string {
+	return sso.LocalLoginURL(c, input.HTTPScheme)
+}
+
+// VerifyPassword verifies the user name and password by ldap
+func (m *user) VerifyPassword(c *gin.Context, config map[string]string, userName,
+	password string) *errors.RawErrorInfo {
+
+	return ssoldap.Login(c, userName, password)
+}
+
+// GetUserList get user list
+func (m *user) GetUserList(c *gin.Context, config map[string]string) ([]*metadata.LoginSystemUserInfo,
+	*errors.RawErrorInfo) {
+
+	return sso.CurrentUserList(c), nil
+}
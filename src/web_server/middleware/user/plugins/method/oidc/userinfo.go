@@ -0,0 +1,200 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+// Package oidc defines openid connect single sign-on login method, ldap can be used as the fallback login method
+package oidc
+
+import (
+	"reflect"
+	"sync"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	httpheader "configcenter/src/common/http/header"
+	"configcenter/src/common/metadata"
+	"configcenter/src/web_server/middleware/user/plugins/manager"
+	"configcenter/src/web_server/middleware/user/plugins/sso"
+	"configcenter/src/web_server/middleware/user/plugins/sso/ldap"
+	ssooidc "configcenter/src/web_server/middleware/user/plugins/sso/oidc"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	plugin := &metadata.LoginPluginInfo{
+		Name:       "openid connect single sign-on system",
+		Version:    common.BKOIDCLoginPluginVersion,
+		HandleFunc: &user{},
+	}
+	manager.RegisterPlugin(plugin)
+}
+
+// session keys of the login request that is redirected to the openid provider
+const (
+	stateSessionKey    = "oidc_state"
+	nonceSessionKey    = "oidc_nonce"
+	redirectSessionKey = "oidc_redirect"
+)
+
+type user struct {
+	lock     sync.Mutex
+	provider *ssooidc.Provider
+}
+
+// getProvider returns the openid provider client, it is rebuilt when the config changes
+func (m *user) getProvider() (*ssooidc.Provider, error) {
+	conf, err := ssooidc.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.provider != nil {
+		oldConf, newConf := *m.provider.Config(), *conf
+		oldConf.TLS, newConf.TLS = nil, nil
+		if reflect.DeepEqual(oldConf, newConf) {
+			return m.provider, nil
+		}
+	}
+
+	m.provider = ssooidc.NewProvider(conf)
+	return m.provider, nil
+}
+
+// LoginUser user login
+func (m *user) LoginUser(c *gin.Context, config map[string]string, isMultiOwner bool) (*metadata.LoginUserInfo, bool) {
+	identity, ok := sso.LoadIdentity(c)
+	if !ok {
+		return nil, false
+	}
+
+	conf, err := sso.LoadConfig()
+	if err != nil {
+		blog.Errorf("load sso config failed, err: %v, rid: %s", err, httpheader.GetRid(c.Request.Header))
+		return nil, false
+	}
+
+	return sso.NewLoginUserInfo(c, conf, identity), true
+}
+
+// GetLoginUrl get the login url of the openid provider, returns the cmdb login page if the provider is unavailable
+func (m *user) GetLoginUrl(c *gin.Context, config map[string]string, input *metadata.LogoutRequestParams) string {
+	rid := httpheader.GetRid(c.Request.Header)
+
+	loginURL, err := m.getAuthCodeURL(c, input)
+	if err != nil {
+		blog.Errorf("get openid connect login url failed, use login page instead, err: %v, rid: %s", err, rid)
+		return sso.LocalLoginURL(c, input.HTTPScheme)
+	}
+	return loginURL
+}
+
+func (m *user) getAuthCodeURL(c *gin.Context, input *metadata.LogoutRequestParams) (string, error) {
+	provider, err := m.getProvider()
+	if err != nil {
+		return "", err
+	}
+
+	state, err := sso.RandomString()
+	if err != nil {
+		return "", err
+	}
+
+	nonce, err := sso.RandomString()
+	if err != nil {
+		return "", err
+	}
+
+	loginURL, err := provider.AuthCodeURL(c.Request.Context(), state, nonce)
+	if err != nil {
+		return "", err
+	}
+
+	session := sessions.Default(c)
+	session.Set(stateSessionKey, state)
+	session.Set(nonceSessionKey, nonce)
+	session.Set(redirectSessionKey, sso.SiteURL(input.HTTPScheme)+c.Request.URL.String())
+	if err = session.Save(); err != nil {
+		return "", err
+	}
+	return loginURL, nil
+}
+
+// HandleCallback handles the redirection back from the openid provider, returns the url before login
+func (m *user) HandleCallback(c *gin.Context, config map[string]string) (string, *errors.RawErrorInfo) {
+	rid := httpheader.GetRid(c.Request.Header)
+
+	if errCode := c.Query("error"); errCode != "" {
+		blog.Errorf("openid provider responds error %s: %s, rid: %s", errCode, c.Query("error_description"), rid)
+		return "", &errors.RawErrorInfo{ErrCode: common.CCErrWebSSOLoginFailed, Args: []interface{}{errCode}}
+	}
+
+	session := sessions.Default(c)
+	state, _ := session.Get(stateSessionKey).(string)
+	nonce, _ := session.Get(nonceSessionKey).(string)
+	redirectURL, _ := session.Get(redirectSessionKey).(string)
+	if state == "" || state != c.Query("state") {
+		blog.Errorf("openid connect login state does not match, rid: %s", rid)
+		return "", &errors.RawErrorInfo{ErrCode: common.CCErrWebSSOLoginFailed, Args: []interface{}{"invalid state"}}
+	}
+
+	// the state can only be used once
+	session.Delete(stateSessionKey)
+	session.Delete(nonceSessionKey)
+	session.Delete(redirectSessionKey)
+
+	provider, err := m.getProvider()
+	if err != nil {
+		blog.Errorf("get openid provider failed, err: %v, rid: %s", err, rid)
+		return "", &errors.RawErrorInfo{ErrCode: common.CCErrWebSSOLoginFailed, Args: []interface{}{err.Error()}}
+	}
+
+	identity, err := provider.Exchange(c.Request.Context(), c.Query("code"), nonce)
+	if err != nil {
+		blog.Errorf("exchange openid connect authorization code failed, err: %v, rid: %s", err, rid)
+		return "", &errors.RawErrorInfo{ErrCode: common.CCErrWebSSOLoginFailed, Args: []interface{}{err.Error()}}
+	}
+
+	conf, err := sso.LoadConfig()
+	if err != nil {
+		blog.Errorf("load sso config failed, err: %v, rid: %s", err, rid)
+		return "", &errors.RawErrorInfo{ErrCode: common.CCErrWebSSOLoginFailed, Args: []interface{}{err.Error()}}
+	}
+
+	if err = sso.SaveIdentity(c, conf, identity); err != nil {
+		blog.Errorf("save openid connect user %s identity failed, err: %v, rid: %s", identity.UserName, err, rid)
+		return "", &errors.RawErrorInfo{ErrCode: common.CCErrWebSSOLoginFailed, Args: []interface{}{err.Error()}}
+	}
+	return redirectURL, nil
+}
+
+// VerifyPassword verifies the user name and password by ldap as the fallback login method
+func (m *user) VerifyPassword(c *gin.Context, config map[string]string, userName,
+	password string) *errors.RawErrorInfo {
+
+	return ldap.Login(c, userName, password)
+}
+
+// GetUserList get user list
+func (m *user) GetUserList(c *gin.Context, config map[string]string) ([]*metadata.LoginSystemUserInfo,
+	*errors.RawErrorInfo) {
+
+	return sso.CurrentUserList(c), nil
+}
@@ -0,0 +1,192 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package ldap
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ber tags used by the ldap protocol, only the subset of BER that ldap v3 needs is supported
+const (
+	classApplication byte = 0x40
+	classContext     byte = 0x80
+	constructed      byte = 0x20
+
+	tagBoolean     byte = 0x01
+	tagInteger     byte = 0x02
+	tagOctetString byte = 0x04
+	tagEnumerated  byte = 0x0a
+	tagSequence         = 0x10 | constructed
+	tagSet              = 0x11 | constructed
+
+	// maxPacketLength is the max length of a received packet, used to avoid allocating huge memory
+	maxPacketLength = 16 << 20
+)
+
+// packet is a BER encoded element, primitive element has value and constructed element has children
+type packet struct {
+	tag      byte
+	value    []byte
+	children []*packet
+}
+
+func newPrimitive(tag byte, value []byte) *packet {
+	return &packet{tag: tag, value: value}
+}
+
+func newString(tag byte, s string) *packet {
+	return &packet{tag: tag, value: []byte(s)}
+}
+
+func newInt(tag byte, v int64) *packet {
+	return &packet{tag: tag, value: encodeInt(v)}
+}
+
+func newBool(v bool) *packet {
+	if v {
+		return &packet{tag: tagBoolean, value: []byte{0xff}}
+	}
+	return &packet{tag: tagBoolean, value: []byte{0x00}}
+}
+
+func newConstructed(tag byte, children ...*packet) *packet {
+	return &packet{tag: tag, children: children}
+}
+
+func (p *packet) isConstructed() bool {
+	return p.tag&constructed != 0
+}
+
+func (p *packet) int() int64 {
+	return decodeInt(p.value)
+}
+
+func (p *packet) string() string {
+	return string(p.value)
+}
+
+// child returns the child at the index, returns an empty packet if it does not exist to simplify the parsing
+func (p *packet) child(index int) *packet {
+	if index < 0 || index >= len(p.children) {
+		return new(packet)
+	}
+	return p.children[index]
+}
+
+func (p *packet) encode() []byte {
+	content := p.value
+	if p.isConstructed() {
+		content = make([]byte, 0)
+		for _, child := range p.children {
+			content = append(content, child.encode()...)
+		}
+	}
+
+	data := append([]byte{p.tag}, encodeLength(len(content))...)
+	return append(data, content...)
+}
+
+func encodeLength(length int) []byte {
+	if length < 0x80 {
+		return []byte{byte(length)}
+	}
+
+	data := make([]byte, 0)
+	for ; length > 0; length >>= 8 {
+		data = append([]byte{byte(length)}, data...)
+	}
+	return append([]byte{0x80 | byte(len(data))}, data...)
+}
+
+// encodeInt encodes the integer in the minimal two's complement form
+func encodeInt(v int64) []byte {
+	data := []byte{byte(v)}
+	for v > 0x7f || v < -0x80 {
+		v >>= 8
+		data = append([]byte{byte(v)}, data...)
+	}
+	return data
+}
+
+func decodeInt(data []byte) int64 {
+	if len(data) == 0 {
+		return 0
+	}
+
+	v := int64(int8(data[0]))
+	for _, b := range data[1:] {
+		v = v<<8 | int64(b)
+	}
+	return v
+}
+
+// readPacket reads a BER element from the reader, indefinite length form is not supported
+func readPacket(reader io.Reader) (*packet, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, err
+	}
+
+	length := int(header[1])
+	if length&0x80 != 0 {
+		num := length & 0x7f
+		if num == 0 || num > 4 {
+			return nil, fmt.Errorf("unsupported ber length form %#x", header[1])
+		}
+
+		lengthBytes := make([]byte, num)
+		if _, err := io.ReadFull(reader, lengthBytes); err != nil {
+			return nil, err
+		}
+
+		length = 0
+		for _, b := range lengthBytes {
+			length = length<<8 | int(b)
+		}
+	}
+
+	if length > maxPacketLength {
+		return nil, fmt.Errorf("ber packet length %d exceeds max length %d", length, maxPacketLength)
+	}
+
+	content := make([]byte, length)
+	if _, err := io.ReadFull(reader, content); err != nil {
+		return nil, err
+	}
+
+	p := &packet{tag: header[0]}
+	if !p.isConstructed() {
+		p.value = content
+		return p, nil
+	}
+
+	contentReader := bytes.NewReader(content)
+	for contentReader.Len() > 0 {
+		child, err := readPacket(contentReader)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		p.children = append(p.children, child)
+	}
+	return p, nil
+}
@@ -0,0 +1,256 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package ldap
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ldap protocol operation tags
+const (
+	opBindRequest       = classApplication | constructed | 0
+	opBindResponse      = classApplication | constructed | 1
+	opUnbindRequest     = classApplication | 2
+	opSearchRequest     = classApplication | constructed | 3
+	opSearchResultEntry = classApplication | constructed | 4
+	opSearchResultDone  = classApplication | constructed | 5
+	opSearchResultRef   = classApplication | constructed | 19
+
+	authSimple = classContext | 0
+)
+
+// ldap result codes
+const (
+	resultSuccess            = 0
+	resultSizeLimitExceeded  = 4
+	resultInvalidCredentials = 49
+
+	scopeWholeSubtree = 2
+	derefNever        = 0
+	protocolVersion   = 3
+)
+
+// ErrInvalidCredentials is returned when the user does not exist or the password is wrong
+var ErrInvalidCredentials = errors.New("invalid ldap credentials")
+
+// resultError is the error result returned by the ldap server
+type resultError struct {
+	code    int64
+	message string
+}
+
+// Error returns the error message
+func (e *resultError) Error() string {
+	return fmt.Sprintf("ldap result code %d, message: %s", e.code, e.message)
+}
+
+// entry is the ldap entry returned by search, the attribute names are in lower case
+type entry struct {
+	dn    string
+	attrs map[string][]string
+}
+
+func (e *entry) first(attr string) string {
+	values := e.attrs[strings.ToLower(attr)]
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// conn is a synchronous ldap connection, requests are sent one by one
+type conn struct {
+	netConn net.Conn
+	reader  *bufio.Reader
+	msgID   int64
+}
+
+// dial connects to the ldap server of the url with the scheme ldap or ldaps
+func dial(addr string, timeout time.Duration, tlsConf *tls.Config) (*conn, error) {
+	u, err := url.Parse(addr)
+	if err != nil {
+		return nil, fmt.Errorf("parse ldap url %s failed, err: %v", addr, err)
+	}
+
+	host := u.Host
+	dialer := &net.Dialer{Timeout: timeout}
+	var netConn net.Conn
+	switch u.Scheme {
+	case "ldap":
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "389")
+		}
+		netConn, err = dialer.Dial("tcp", host)
+	case "ldaps":
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "636")
+		}
+		if tlsConf == nil {
+			tlsConf = new(tls.Config)
+		}
+		if tlsConf.ServerName == "" {
+			tlsConf.ServerName = u.Hostname()
+		}
+		netConn, err = tls.DialWithDialer(dialer, "tcp", host, tlsConf)
+	default:
+		return nil, fmt.Errorf("ldap url %s scheme is invalid, only ldap and ldaps are supported", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("connect to ldap server %s failed, err: %v", addr, err)
+	}
+
+	if err = netConn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	return &conn{netConn: netConn, reader: bufio.NewReader(netConn)}, nil
+}
+
+func (c *conn) send(op *packet) (int64, error) {
+	c.msgID++
+	msg := newConstructed(tagSequence, newInt(tagInteger, c.msgID), op)
+	if _, err := c.netConn.Write(msg.encode()); err != nil {
+		return 0, fmt.Errorf("send ldap request failed, err: %v", err)
+	}
+	return c.msgID, nil
+}
+
+// receive reads the next response operation of the message
+func (c *conn) receive(msgID int64) (*packet, error) {
+	for {
+		msg, err := readPacket(c.reader)
+		if err != nil {
+			return nil, fmt.Errorf("read ldap response failed, err: %v", err)
+		}
+
+		if msg.tag != tagSequence || len(msg.children) < 2 {
+			return nil, errors.New("ldap response is invalid")
+		}
+
+		// skip the unsolicited notifications whose message id is 0
+		if msg.child(0).int() != msgID {
+			continue
+		}
+		return msg.child(1), nil
+	}
+}
+
+// bind does a simple bind, empty password is rejected because it is an unauthenticated bind that always succeeds
+func (c *conn) bind(dn, password string) error {
+	if password == "" {
+		return ErrInvalidCredentials
+	}
+
+	req := newConstructed(opBindRequest, newInt(tagInteger, protocolVersion), newString(tagOctetString, dn),
+		newString(authSimple, password))
+	msgID, err := c.send(req)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.receive(msgID)
+	if err != nil {
+		return err
+	}
+
+	if resp.tag != opBindResponse {
+		return fmt.Errorf("unexpected ldap bind response tag %#x", resp.tag)
+	}
+	return parseResult(resp)
+}
+
+// search searches the entries under the base dn in the whole subtree
+func (c *conn) search(baseDN, filter string, attrs []string, sizeLimit int64) ([]*entry, error) {
+	filterPacket, err := compileFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	attrPacket := newConstructed(tagSequence)
+	for _, attr := range attrs {
+		attrPacket.children = append(attrPacket.children, newString(tagOctetString, attr))
+	}
+
+	req := newConstructed(opSearchRequest, newString(tagOctetString, baseDN), newInt(tagEnumerated, scopeWholeSubtree),
+		newInt(tagEnumerated, derefNever), newInt(tagInteger, sizeLimit), newInt(tagInteger, 0), newBool(false),
+		filterPacket, attrPacket)
+	msgID, err := c.send(req)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*entry, 0)
+	for {
+		resp, err := c.receive(msgID)
+		if err != nil {
+			return nil, err
+		}
+
+		switch resp.tag {
+		case opSearchResultEntry:
+			entries = append(entries, parseEntry(resp))
+		case opSearchResultRef:
+			// referrals are not followed
+		case opSearchResultDone:
+			err := parseResult(resp)
+			var resErr *resultError
+			if errors.As(err, &resErr) && resErr.code == resultSizeLimitExceeded {
+				return entries, nil
+			}
+			return entries, err
+		default:
+			return nil, fmt.Errorf("unexpected ldap search response tag %#x", resp.tag)
+		}
+	}
+}
+
+// close sends the unbind request and closes the connection
+func (c *conn) close() {
+	_, _ = c.send(newPrimitive(opUnbindRequest, nil))
+	_ = c.netConn.Close()
+}
+
+func parseResult(resp *packet) error {
+	code := resp.child(0).int()
+	switch code {
+	case resultSuccess:
+		return nil
+	case resultInvalidCredentials:
+		return ErrInvalidCredentials
+	default:
+		return &resultError{code: code, message: resp.child(2).string()}
+	}
+}
+
+func parseEntry(resp *packet) *entry {
+	e := &entry{dn: resp.child(0).string(), attrs: make(map[string][]string)}
+	for _, attr := range resp.child(1).children {
+		name := strings.ToLower(attr.child(0).string())
+		for _, value := range attr.child(1).children {
+			e.attrs[name] = append(e.attrs[name], value.string())
+		}
+	}
+	return e
+}
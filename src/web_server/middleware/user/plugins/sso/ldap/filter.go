@@ -0,0 +1,158 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package ldap
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ldap filter choice tags
+const (
+	filterAnd      = classContext | constructed | 0
+	filterOr       = classContext | constructed | 1
+	filterNot      = classContext | constructed | 2
+	filterEquality = classContext | constructed | 3
+	filterPresent  = classContext | 7
+)
+
+// EscapeFilter escapes the special characters of the value used in the ldap filter as RFC 4515 defines
+func EscapeFilter(value string) string {
+	var builder strings.Builder
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case '\\', '*', '(', ')', 0:
+			builder.WriteString(fmt.Sprintf("\\%02x", c))
+		default:
+			builder.WriteByte(c)
+		}
+	}
+	return builder.String()
+}
+
+// compileFilter compiles the string filter into ber packet, supports and, or, not, equality and present filters
+func compileFilter(filter string) (*packet, error) {
+	p, rest, err := parseFilter(strings.TrimSpace(filter))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ldap filter %s, err: %v", filter, err)
+	}
+
+	if rest != "" {
+		return nil, fmt.Errorf("invalid ldap filter %s, unexpected %s", filter, rest)
+	}
+	return p, nil
+}
+
+func parseFilter(filter string) (*packet, string, error) {
+	if len(filter) < 3 || filter[0] != '(' {
+		return nil, "", errors.New("filter must be enclosed in parentheses")
+	}
+
+	switch filter[1] {
+	case '&', '|':
+		tag := byte(filterAnd)
+		if filter[1] == '|' {
+			tag = filterOr
+		}
+
+		p := newConstructed(tag)
+		rest := filter[2:]
+		for !strings.HasPrefix(rest, ")") {
+			child, next, err := parseFilter(rest)
+			if err != nil {
+				return nil, "", err
+			}
+			p.children = append(p.children, child)
+			rest = next
+		}
+
+		if len(p.children) == 0 {
+			return nil, "", errors.New("and/or filter has no sub filter")
+		}
+		return p, rest[1:], nil
+	case '!':
+		child, rest, err := parseFilter(filter[2:])
+		if err != nil {
+			return nil, "", err
+		}
+
+		if !strings.HasPrefix(rest, ")") {
+			return nil, "", errors.New("not filter must have exactly one sub filter")
+		}
+		return newConstructed(filterNot, child), rest[1:], nil
+	}
+
+	end := strings.IndexByte(filter, ')')
+	if end < 0 {
+		return nil, "", errors.New("filter is not closed")
+	}
+
+	item := filter[1:end]
+	idx := strings.IndexByte(item, '=')
+	if idx <= 0 {
+		return nil, "", fmt.Errorf("filter item %s is invalid", item)
+	}
+
+	attr, value := item[:idx], item[idx+1:]
+	if strings.ContainsAny(attr, "~<>:") {
+		return nil, "", fmt.Errorf("filter item %s is not supported", item)
+	}
+
+	if value == "*" {
+		return newString(filterPresent, attr), filter[end+1:], nil
+	}
+
+	if strings.Contains(value, "*") {
+		return nil, "", fmt.Errorf("substring filter item %s is not supported", item)
+	}
+
+	decoded, err := unescapeFilterValue(value)
+	if err != nil {
+		return nil, "", err
+	}
+
+	p := newConstructed(filterEquality, newString(tagOctetString, attr), newString(tagOctetString, decoded))
+	return p, filter[end+1:], nil
+}
+
+func unescapeFilterValue(value string) (string, error) {
+	if !strings.Contains(value, "\\") {
+		return value, nil
+	}
+
+	var builder strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' {
+			builder.WriteByte(value[i])
+			continue
+		}
+
+		if i+3 > len(value) {
+			return "", fmt.Errorf("filter value %s has invalid escape", value)
+		}
+
+		b, err := hex.DecodeString(value[i+1 : i+3])
+		if err != nil {
+			return "", fmt.Errorf("filter value %s has invalid escape, err: %v", value, err)
+		}
+		builder.Write(b)
+		i += 2
+	}
+	return builder.String(), nil
+}
@@ -0,0 +1,254 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+// Package ldap authenticates the login user by ldap simple bind, it implements the minimal ldap v3 client protocol
+package ldap
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"configcenter/src/common"
+	cc "configcenter/src/common/backbone/configcenter"
+	"configcenter/src/common/blog"
+	ccErr "configcenter/src/common/errors"
+	httpheader "configcenter/src/common/http/header"
+	"configcenter/src/web_server/middleware/user/plugins/sso"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	configKey = "webServer.login.ldap"
+
+	defaultUserFilter      = "(uid=%s)"
+	defaultUserNameAttr    = "uid"
+	defaultDisplayNameAttr = "displayName"
+	defaultEmailAttr       = "mail"
+	defaultPhoneAttr       = "mobile"
+	defaultGroupAttr       = "memberOf"
+	defaultTimeout         = 10
+)
+
+// Config is the ldap login configuration
+type Config struct {
+	// Enabled defines if the user name and password is verified by ldap
+	Enabled bool `mapstructure:"enabled"`
+	// URL is the ldap server url, like ldaps://127.0.0.1:636, ldap:// url is only allowed when AllowInsecure is set
+	URL string `mapstructure:"url"`
+	// AllowInsecure defines if the plaintext ldap:// url is allowed, the passwords are sent in plaintext if so
+	AllowInsecure bool `mapstructure:"allowInsecure"`
+	// BindDN and BindPassword is the account used to search the login user, anonymous search is used if empty
+	BindDN       string `mapstructure:"bindDN"`
+	BindPassword string `mapstructure:"bindPassword"`
+	// BaseDN is the dn to search the login user under
+	BaseDN string `mapstructure:"baseDN"`
+	// UserFilter is the filter to search the login user, %s is replaced by the escaped user name
+	UserFilter string `mapstructure:"userFilter"`
+	// UserNameAttr is the attribute of the canonical user name, its value in the matched entry is used as the login
+	// user name, so that the same user is not logged in as different users by the case or alias of the input name
+	UserNameAttr    string `mapstructure:"userNameAttr"`
+	DisplayNameAttr string `mapstructure:"displayNameAttr"`
+	EmailAttr       string `mapstructure:"emailAttr"`
+	PhoneAttr       string `mapstructure:"phoneAttr"`
+	// GroupAttr is the attribute of the user groups, the first rdn value of the group dn is used as the group name
+	GroupAttr string `mapstructure:"groupAttr"`
+	// TimeoutSeconds is the timeout of the whole authentication
+	TimeoutSeconds int `mapstructure:"timeoutSeconds"`
+	// TLS is the tls config used by ldaps
+	TLS *tls.Config `mapstructure:"-"`
+}
+
+// LoadConfig load the ldap login configuration
+func LoadConfig() (*Config, error) {
+	conf := new(Config)
+	if !cc.IsExist(configKey) {
+		return conf, nil
+	}
+
+	if err := cc.UnmarshalKey(configKey, conf); err != nil {
+		return nil, fmt.Errorf("parse %s config failed, err: %v", configKey, err)
+	}
+
+	if !conf.Enabled {
+		return conf, nil
+	}
+
+	tlsConf, err := cc.GetClientTLSConfig(configKey + ".tls")
+	if err != nil {
+		return nil, fmt.Errorf("parse %s tls config failed, err: %v", configKey, err)
+	}
+	conf.TLS = tlsConf
+
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+// Validate validates the ldap config and set the default values
+func (c *Config) Validate() error {
+	if c.URL == "" {
+		return fmt.Errorf("%s.url is not set", configKey)
+	}
+
+	if strings.HasPrefix(strings.ToLower(c.URL), "ldap://") && !c.AllowInsecure {
+		return fmt.Errorf("%s.url %s is plaintext ldap, use ldaps or set %s.allowInsecure", configKey, c.URL,
+			configKey)
+	}
+
+	if c.BaseDN == "" {
+		return fmt.Errorf("%s.baseDN is not set", configKey)
+	}
+
+	if c.UserFilter == "" {
+		c.UserFilter = defaultUserFilter
+	}
+
+	if strings.Count(c.UserFilter, "%s") != 1 {
+		return fmt.Errorf("%s.userFilter %s must contain exactly one %%s", configKey, c.UserFilter)
+	}
+
+	if _, err := compileFilter(fmt.Sprintf(c.UserFilter, "user")); err != nil {
+		return err
+	}
+
+	if c.UserNameAttr == "" {
+		c.UserNameAttr = defaultUserNameAttr
+	}
+	if c.DisplayNameAttr == "" {
+		c.DisplayNameAttr = defaultDisplayNameAttr
+	}
+	if c.EmailAttr == "" {
+		c.EmailAttr = defaultEmailAttr
+	}
+	if c.PhoneAttr == "" {
+		c.PhoneAttr = defaultPhoneAttr
+	}
+	if c.GroupAttr == "" {
+		c.GroupAttr = defaultGroupAttr
+	}
+	if c.TimeoutSeconds <= 0 {
+		c.TimeoutSeconds = defaultTimeout
+	}
+	return nil
+}
+
+// Authenticate searches the user by the user name and verifies the password by binding as the user entry
+func Authenticate(conf *Config, userName, password string) (*sso.Identity, error) {
+	if userName == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	c, err := dial(conf.URL, time.Duration(conf.TimeoutSeconds)*time.Second, conf.TLS)
+	if err != nil {
+		return nil, err
+	}
+	defer c.close()
+
+	if conf.BindDN != "" {
+		if err = c.bind(conf.BindDN, conf.BindPassword); err != nil {
+			return nil, fmt.Errorf("bind ldap search account %s failed, err: %v", conf.BindDN, err)
+		}
+	}
+
+	filter := fmt.Sprintf(conf.UserFilter, EscapeFilter(userName))
+	attrs := []string{conf.UserNameAttr, conf.DisplayNameAttr, conf.EmailAttr, conf.PhoneAttr, conf.GroupAttr}
+	entries, err := c.search(conf.BaseDN, filter, attrs, 2)
+	if err != nil {
+		return nil, fmt.Errorf("search ldap user by filter %s failed, err: %v", filter, err)
+	}
+
+	switch len(entries) {
+	case 0:
+		return nil, ErrInvalidCredentials
+	case 1:
+	default:
+		return nil, fmt.Errorf("ldap filter %s matches multiple users", filter)
+	}
+
+	user := entries[0]
+	if err = c.bind(user.dn, password); err != nil {
+		return nil, err
+	}
+
+	canonicalName := user.first(conf.UserNameAttr)
+	if canonicalName == "" {
+		return nil, fmt.Errorf("ldap user %s has no user name attribute %s", user.dn, conf.UserNameAttr)
+	}
+
+	identity := &sso.Identity{
+		UserName: canonicalName,
+		ChName:   user.first(conf.DisplayNameAttr),
+		Email:    user.first(conf.EmailAttr),
+		Phone:    user.first(conf.PhoneAttr),
+		Groups:   make([]string, 0),
+	}
+	for _, group := range user.attrs[strings.ToLower(conf.GroupAttr)] {
+		identity.Groups = append(identity.Groups, groupName(group))
+	}
+	return identity, nil
+}
+
+// groupName returns the first rdn value of the group dn, e.g. ops for cn=ops,ou=groups,dc=example,dc=com
+func groupName(dn string) string {
+	rdn := strings.SplitN(dn, ",", 2)[0]
+	idx := strings.IndexByte(rdn, '=')
+	if idx < 0 {
+		return strings.TrimSpace(rdn)
+	}
+	return strings.TrimSpace(rdn[idx+1:])
+}
+
+// Login verifies the user name and password by ldap, and saves the login identity into session if succeeds
+func Login(c *gin.Context, userName, password string) *ccErr.RawErrorInfo {
+	rid := httpheader.GetRid(c.Request.Header)
+
+	conf, err := LoadConfig()
+	if err != nil {
+		blog.Errorf("load ldap config failed, err: %v, rid: %s", err, rid)
+		return &ccErr.RawErrorInfo{ErrCode: common.CCErrWebSSOLoginFailed, Args: []interface{}{err.Error()}}
+	}
+
+	if !conf.Enabled {
+		return &ccErr.RawErrorInfo{ErrCode: common.CCErrWebPasswordLoginNotSupported}
+	}
+
+	identity, err := Authenticate(conf, userName, password)
+	if err != nil {
+		if errors.Is(err, ErrInvalidCredentials) {
+			blog.Warnf("ldap user %s login with invalid credentials, rid: %s", userName, rid)
+			return &ccErr.RawErrorInfo{ErrCode: common.CCErrWebUsernamePasswdWrong}
+		}
+		blog.Errorf("authenticate ldap user %s failed, err: %v, rid: %s", userName, err, rid)
+		return &ccErr.RawErrorInfo{ErrCode: common.CCErrWebSSOLoginFailed, Args: []interface{}{err.Error()}}
+	}
+
+	ssoConf, err := sso.LoadConfig()
+	if err != nil {
+		blog.Errorf("load sso config failed, err: %v, rid: %s", err, rid)
+		return &ccErr.RawErrorInfo{ErrCode: common.CCErrWebSSOLoginFailed, Args: []interface{}{err.Error()}}
+	}
+
+	if err = sso.SaveIdentity(c, ssoConf, identity); err != nil {
+		blog.Errorf("save ldap user %s login identity failed, err: %v, rid: %s", userName, err, rid)
+		return &ccErr.RawErrorInfo{ErrCode: common.CCErrWebSSOLoginFailed, Args: []interface{}{err.Error()}}
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package ldap
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeUser struct {
+	dn       string
+	password string
+	attrs    map[string][]string
+}
+
+// fakeServer is a minimal ldap server that supports simple bind and equality search on the uid attribute
+type fakeServer struct {
+	listener net.Listener
+	users    map[string]fakeUser
+}
+
+func newFakeServer(t *testing.T, users map[string]fakeUser) *fakeServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := &fakeServer{listener: listener, users: users}
+	t.Cleanup(func() { listener.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *fakeServer) url() string {
+	return "ldap://" + s.listener.Addr().String()
+}
+
+func (s *fakeServer) serve() {
+	for {
+		c, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(c)
+	}
+}
+
+func (s *fakeServer) handle(c net.Conn) {
+	defer c.Close()
+	reader := bufio.NewReader(c)
+	for {
+		msg, err := readPacket(reader)
+		if err != nil {
+			return
+		}
+
+		msgID, op := msg.child(0).int(), msg.child(1)
+		reply := func(resp *packet) {
+			_, _ = c.Write(newConstructed(tagSequence, newInt(tagInteger, msgID), resp).encode())
+		}
+
+		switch op.tag {
+		case opBindRequest:
+			code := int64(resultInvalidCredentials)
+			for _, user := range s.users {
+				if user.dn == op.child(1).string() && user.password == op.child(2).string() {
+					code = resultSuccess
+				}
+			}
+			reply(newConstructed(opBindResponse, newInt(tagEnumerated, code), newString(tagOctetString, ""),
+				newString(tagOctetString, "")))
+		case opSearchRequest:
+			filter := op.child(6)
+			if filter.tag == filterEquality && filter.child(0).string() == "uid" {
+				if user, exists := s.users[filter.child(1).string()]; exists {
+					attrs := newConstructed(tagSequence)
+					for name, values := range user.attrs {
+						valuePacket := newConstructed(tagSet)
+						for _, value := range values {
+							valuePacket.children = append(valuePacket.children, newString(tagOctetString, value))
+						}
+						attrs.children = append(attrs.children,
+							newConstructed(tagSequence, newString(tagOctetString, name), valuePacket))
+					}
+					reply(newConstructed(opSearchResultEntry, newString(tagOctetString, user.dn), attrs))
+				}
+			}
+			reply(newConstructed(opSearchResultDone, newInt(tagEnumerated, resultSuccess),
+				newString(tagOctetString, ""), newString(tagOctetString, "")))
+		case opUnbindRequest:
+			return
+		}
+	}
+}
+
+func newTestConfig(t *testing.T, url string) *Config {
+	conf := &Config{URL: url, AllowInsecure: true, BindDN: "cn=admin,dc=example,dc=com", BindPassword: "admin",
+		BaseDN: "dc=example,dc=com"}
+	require.NoError(t, conf.Validate())
+	return conf
+}
+
+func TestConfigValidate(t *testing.T) {
+	conf := &Config{URL: "ldap://127.0.0.1:389", BaseDN: "dc=example,dc=com"}
+	require.Error(t, conf.Validate())
+
+	conf.AllowInsecure = true
+	require.NoError(t, conf.Validate())
+	require.Equal(t, defaultUserNameAttr, conf.UserNameAttr)
+
+	conf = &Config{URL: "ldaps://127.0.0.1:636", BaseDN: "dc=example,dc=com"}
+	require.NoError(t, conf.Validate())
+}
+
+func TestAuthenticate(t *testing.T) {
+	alice := fakeUser{
+		dn:       "uid=alice,ou=people,dc=example,dc=com",
+		password: "pass",
+		attrs: map[string][]string{
+			"uid":         {"alice"},
+			"displayName": {"Alice"},
+			"mail":        {"alice@example.com"},
+			"memberOf":    {"cn=ops,ou=groups,dc=example,dc=com", "cn=dev,ou=groups,dc=example,dc=com"},
+		},
+	}
+	server := newFakeServer(t, map[string]fakeUser{
+		"admin": {dn: "cn=admin,dc=example,dc=com", password: "admin"},
+		"alice": alice,
+		// ldap servers usually match the uid case-insensitively
+		"ALICE": alice,
+		"nouid": {dn: "cn=nouid,ou=people,dc=example,dc=com", password: "pass"},
+	})
+	conf := newTestConfig(t, server.url())
+
+	identity, err := Authenticate(conf, "alice", "pass")
+	require.NoError(t, err)
+	require.Equal(t, "alice", identity.UserName)
+	require.Equal(t, "Alice", identity.ChName)
+	require.Equal(t, "alice@example.com", identity.Email)
+	require.Equal(t, []string{"ops", "dev"}, identity.Groups)
+
+	// the canonical user name of the matched entry is used
+	identity, err = Authenticate(conf, "ALICE", "pass")
+	require.NoError(t, err)
+	require.Equal(t, "alice", identity.UserName)
+
+	_, err = Authenticate(conf, "nouid", "pass")
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrInvalidCredentials)
+
+	_, err = Authenticate(conf, "alice", "wrong")
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+
+	_, err = Authenticate(conf, "alice", "")
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+
+	_, err = Authenticate(conf, "bob", "pass")
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+
+	// wrong search account is not regarded as wrong user password
+	conf.BindPassword = "wrong"
+	_, err = Authenticate(conf, "alice", "pass")
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestCompileFilter(t *testing.T) {
+	p, err := compileFilter("(&(objectClass=person)(|(uid=a\\2ab)(!(mail=*))))")
+	require.NoError(t, err)
+	require.Equal(t, byte(filterAnd), p.tag)
+	require.Equal(t, "a*b", p.child(1).child(0).child(1).string())
+	require.Equal(t, byte(filterPresent), p.child(1).child(1).child(0).tag)
+
+	decoded, err := readPacket(bytes.NewReader(p.encode()))
+	require.NoError(t, err)
+	require.Equal(t, p.encode(), decoded.encode())
+
+	for _, filter := range []string{"uid=a", "(uid=a", "(uid=a*)", "(uid>=a)", "(&)", "(uid=a)(uid=b)", "(uid=\\2)"} {
+		_, err = compileFilter(filter)
+		require.Error(t, err, filter)
+	}
+
+	require.Equal(t, "a\\2a\\28\\29\\5c", EscapeFilter("a*()\\"))
+}
+
+func TestEncodeInt(t *testing.T) {
+	for _, v := range []int64{0, 1, 127, 128, 255, 256, -1, -128, -129, 1 << 40} {
+		require.Equal(t, v, decodeInt(encodeInt(v)))
+	}
+	require.Equal(t, []byte{0x00, 0x80}, encodeInt(128))
+	require.Equal(t, []byte{0x82, 0x01, 0x00}, encodeLength(256))
+}
@@ -0,0 +1,495 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+// Package oidc implements the openid connect authorization code flow used by the single sign-on login plugin
+package oidc
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	cc "configcenter/src/common/backbone/configcenter"
+	"configcenter/src/web_server/middleware/user/plugins/sso"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	configKey = "webServer.login.oidc"
+
+	defaultUserNameClaim    = "preferred_username"
+	defaultDisplayNameClaim = "name"
+	defaultEmailClaim       = "email"
+	defaultPhoneClaim       = "phone_number"
+	defaultGroupsClaim      = "groups"
+	defaultTimeout          = 10
+
+	// maxResponseLength is the max length of the responses from the openid provider
+	maxResponseLength = 1 << 20
+)
+
+// Config is the openid connect login configuration
+type Config struct {
+	// Issuer is the issuer url of the openid provider, the discovery document is at issuer/.well-known/openid-configuration
+	Issuer       string `mapstructure:"issuer"`
+	ClientID     string `mapstructure:"clientId"`
+	ClientSecret string `mapstructure:"clientSecret"`
+	// RedirectURL is the callback url of cmdb registered in the openid provider, like http://cmdb/login/callback
+	RedirectURL string   `mapstructure:"redirectUrl"`
+	Scopes      []string `mapstructure:"scopes"`
+	// the claims used to fill the login user info
+	UserNameClaim    string `mapstructure:"userNameClaim"`
+	DisplayNameClaim string `mapstructure:"displayNameClaim"`
+	EmailClaim       string `mapstructure:"emailClaim"`
+	PhoneClaim       string `mapstructure:"phoneClaim"`
+	GroupsClaim      string `mapstructure:"groupsClaim"`
+	// TimeoutSeconds is the timeout of the requests to the openid provider
+	TimeoutSeconds int `mapstructure:"timeoutSeconds"`
+	// TLS is the tls config of the requests to the openid provider
+	TLS *tls.Config `mapstructure:"-"`
+}
+
+// LoadConfig load the openid connect login configuration
+func LoadConfig() (*Config, error) {
+	conf := new(Config)
+	if err := cc.UnmarshalKey(configKey, conf); err != nil {
+		return nil, fmt.Errorf("parse %s config failed, err: %v", configKey, err)
+	}
+
+	tlsConf, err := cc.GetClientTLSConfig(configKey + ".tls")
+	if err != nil {
+		return nil, fmt.Errorf("parse %s tls config failed, err: %v", configKey, err)
+	}
+	conf.TLS = tlsConf
+
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+// Validate validates the openid connect config and set the default values
+func (c *Config) Validate() error {
+	if c.Issuer == "" {
+		return fmt.Errorf("%s.issuer is not set", configKey)
+	}
+
+	if c.ClientID == "" {
+		return fmt.Errorf("%s.clientId is not set", configKey)
+	}
+
+	if c.RedirectURL == "" {
+		return fmt.Errorf("%s.redirectUrl is not set", configKey)
+	}
+
+	hasOpenID := false
+	for _, scope := range c.Scopes {
+		if scope == "openid" {
+			hasOpenID = true
+			break
+		}
+	}
+	if !hasOpenID {
+		c.Scopes = append([]string{"openid"}, c.Scopes...)
+	}
+	if len(c.Scopes) == 1 {
+		c.Scopes = append(c.Scopes, "profile", "email")
+	}
+
+	if c.UserNameClaim == "" {
+		c.UserNameClaim = defaultUserNameClaim
+	}
+	if c.DisplayNameClaim == "" {
+		c.DisplayNameClaim = defaultDisplayNameClaim
+	}
+	if c.EmailClaim == "" {
+		c.EmailClaim = defaultEmailClaim
+	}
+	if c.PhoneClaim == "" {
+		c.PhoneClaim = defaultPhoneClaim
+	}
+	if c.GroupsClaim == "" {
+		c.GroupsClaim = defaultGroupsClaim
+	}
+	if c.TimeoutSeconds <= 0 {
+		c.TimeoutSeconds = defaultTimeout
+	}
+	return nil
+}
+
+// discovery is the openid provider metadata
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+}
+
+// Provider is the openid connect client of an openid provider, it caches the discovery document and the signing keys
+type Provider struct {
+	conf   *Config
+	client *http.Client
+
+	lock      sync.RWMutex
+	discovery *discovery
+	keys      map[string]*rsa.PublicKey
+}
+
+// NewProvider new openid provider client by the validated config
+func NewProvider(conf *Config) *Provider {
+	return &Provider{
+		conf: conf,
+		client: &http.Client{
+			Timeout:   time.Duration(conf.TimeoutSeconds) * time.Second,
+			Transport: &http.Transport{TLSClientConfig: conf.TLS, Proxy: http.ProxyFromEnvironment},
+		},
+		keys: make(map[string]*rsa.PublicKey),
+	}
+}
+
+// Config returns the config of the provider
+func (p *Provider) Config() *Config {
+	return p.conf
+}
+
+// AuthCodeURL returns the url of the openid provider to redirect the user to for login
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce string) (string, error) {
+	disc, err := p.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{
+		"response_type": {"code"},
+		"client_id":     {p.conf.ClientID},
+		"redirect_uri":  {p.conf.RedirectURL},
+		"scope":         {strings.Join(p.conf.Scopes, " ")},
+		"state":         {state},
+		"nonce":         {nonce},
+	}
+
+	if strings.Contains(disc.AuthorizationEndpoint, "?") {
+		return disc.AuthorizationEndpoint + "&" + params.Encode(), nil
+	}
+	return disc.AuthorizationEndpoint + "?" + params.Encode(), nil
+}
+
+// tokenResponse is the response of the token endpoint
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Exchange exchanges the authorization code for the id token, verifies it and returns the identity of the user
+func (p *Provider) Exchange(ctx context.Context, code, nonce string) (*sso.Identity, error) {
+	if code == "" {
+		return nil, errors.New("authorization code is empty")
+	}
+
+	disc, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.conf.RedirectURL},
+		"client_id":     {p.conf.ClientID},
+		"client_secret": {p.conf.ClientSecret},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, disc.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	token := new(tokenResponse)
+	if err = p.do(req, token); err != nil {
+		if token.Error != "" {
+			return nil, fmt.Errorf("exchange token failed, error: %s, description: %s", token.Error,
+				token.ErrorDescription)
+		}
+		return nil, fmt.Errorf("exchange token failed, err: %v", err)
+	}
+
+	if token.IDToken == "" {
+		return nil, errors.New("token response has no id_token")
+	}
+
+	claims, err := p.verifyIDToken(ctx, disc, token.IDToken, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	// some providers only return the profile and groups in the user info endpoint
+	_, hasUserName := claims[p.conf.UserNameClaim]
+	_, hasGroups := claims[p.conf.GroupsClaim]
+	if (!hasUserName || !hasGroups) && disc.UserInfoEndpoint != "" && token.AccessToken != "" {
+		userInfo, err := p.getUserInfo(ctx, disc, token.AccessToken)
+		if err != nil {
+			return nil, err
+		}
+
+		if sub, _ := userInfo["sub"].(string); sub != claims["sub"] {
+			return nil, fmt.Errorf("user info subject %s does not match id token subject %v", sub, claims["sub"])
+		}
+
+		for key, value := range userInfo {
+			if _, exists := claims[key]; !exists {
+				claims[key] = value
+			}
+		}
+	}
+
+	return p.toIdentity(claims)
+}
+
+func (p *Provider) verifyIDToken(ctx context.Context, disc *discovery, rawToken, nonce string) (jwt.MapClaims,
+	error) {
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(rawToken, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("id token signing method %v is not supported", token.Header["alg"])
+		}
+		kid, _ := token.Header["kid"].(string)
+		return p.getKey(ctx, disc, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("verify id token failed, err: %v", err)
+	}
+
+	if !claims.VerifyIssuer(disc.Issuer, true) {
+		return nil, fmt.Errorf("id token issuer %v does not match %s", claims["iss"], disc.Issuer)
+	}
+
+	if !claims.VerifyAudience(p.conf.ClientID, true) {
+		return nil, fmt.Errorf("id token audience %v does not contain %s", claims["aud"], p.conf.ClientID)
+	}
+
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return nil, errors.New("id token has no expiration time")
+	}
+
+	if tokenNonce, _ := claims["nonce"].(string); tokenNonce != nonce {
+		return nil, errors.New("id token nonce does not match")
+	}
+	return claims, nil
+}
+
+func (p *Provider) toIdentity(claims jwt.MapClaims) (*sso.Identity, error) {
+	identity := &sso.Identity{
+		UserName: stringClaim(claims, p.conf.UserNameClaim),
+		ChName:   stringClaim(claims, p.conf.DisplayNameClaim),
+		Email:    stringClaim(claims, p.conf.EmailClaim),
+		Phone:    stringClaim(claims, p.conf.PhoneClaim),
+		Groups:   make([]string, 0),
+	}
+
+	if identity.UserName == "" {
+		return nil, fmt.Errorf("claim %s of the user name is not set", p.conf.UserNameClaim)
+	}
+
+	switch groups := claims[p.conf.GroupsClaim].(type) {
+	case string:
+		identity.Groups = append(identity.Groups, groups)
+	case []interface{}:
+		for _, group := range groups {
+			if name, ok := group.(string); ok {
+				identity.Groups = append(identity.Groups, name)
+			}
+		}
+	}
+	return identity, nil
+}
+
+func stringClaim(claims jwt.MapClaims, name string) string {
+	value, _ := claims[name].(string)
+	return value
+}
+
+func (p *Provider) getUserInfo(ctx context.Context, disc *discovery, accessToken string) (map[string]interface{},
+	error) {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, disc.UserInfoEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	userInfo := make(map[string]interface{})
+	if err = p.do(req, &userInfo); err != nil {
+		return nil, fmt.Errorf("get user info failed, err: %v", err)
+	}
+	return userInfo, nil
+}
+
+func (p *Provider) getDiscovery(ctx context.Context) (*discovery, error) {
+	p.lock.RLock()
+	disc := p.discovery
+	p.lock.RUnlock()
+	if disc != nil {
+		return disc, nil
+	}
+
+	discURL := strings.TrimRight(p.conf.Issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	disc = new(discovery)
+	if err = p.do(req, disc); err != nil {
+		return nil, fmt.Errorf("get openid provider discovery document failed, err: %v", err)
+	}
+
+	if strings.TrimRight(disc.Issuer, "/") != strings.TrimRight(p.conf.Issuer, "/") {
+		return nil, fmt.Errorf("discovery issuer %s does not match %s", disc.Issuer, p.conf.Issuer)
+	}
+
+	if disc.AuthorizationEndpoint == "" || disc.TokenEndpoint == "" || disc.JwksURI == "" {
+		return nil, errors.New("discovery document lacks authorization, token or jwks endpoint")
+	}
+
+	p.lock.Lock()
+	p.discovery = disc
+	p.lock.Unlock()
+	return disc, nil
+}
+
+// jsonWebKey is the rsa public key in the jwks document
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// getKey returns the signing key of the kid, the jwks is refreshed when the key is not found for key rotation
+func (p *Provider) getKey(ctx context.Context, disc *discovery, kid string) (*rsa.PublicKey, error) {
+	if key := p.findKey(kid); key != nil {
+		return key, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, disc.JwksURI, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	jwks := new(struct {
+		Keys []jsonWebKey `json:"keys"`
+	})
+	if err = p.do(req, jwks); err != nil {
+		return nil, fmt.Errorf("get openid provider jwks failed, err: %v", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, jwk := range jwks.Keys {
+		if jwk.Kty != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+
+		key, err := parseRSAKey(jwk)
+		if err != nil {
+			return nil, fmt.Errorf("parse jwk %s failed, err: %v", jwk.Kid, err)
+		}
+		keys[jwk.Kid] = key
+	}
+
+	p.lock.Lock()
+	p.keys = keys
+	p.lock.Unlock()
+
+	if key := p.findKey(kid); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("signing key %s is not found", kid)
+}
+
+// findKey finds the key of the kid, the only key is used if the token does not specify the kid
+func (p *Provider) findKey(kid string) *rsa.PublicKey {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key
+		}
+	}
+	return p.keys[kid]
+}
+
+func parseRSAKey(jwk jsonWebKey) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(jwk.N, "="))
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(jwk.E, "="))
+	if err != nil {
+		return nil, err
+	}
+
+	exponent := new(big.Int).SetBytes(e)
+	if len(n) == 0 || !exponent.IsInt64() || exponent.Int64() <= 1 || exponent.Int64() > 1<<31-1 {
+		return nil, errors.New("rsa key is invalid")
+	}
+
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+}
+
+// do sends the request and decodes the json response into result, non 200 response is regarded as failure but the
+// body is still decoded to get the error details
+func (p *Provider) do(req *http.Request, result interface{}) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseLength))
+	if err != nil {
+		return err
+	}
+
+	decodeErr := json.Unmarshal(body, result)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s responds status code %d", req.URL.Path, resp.StatusCode)
+	}
+
+	if decodeErr != nil {
+		return fmt.Errorf("decode %s response failed, err: %v", req.URL.Path, decodeErr)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package oidc
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"configcenter/src/web_server/middleware/user/plugins/sso/oidc/oidctest"
+
+	"github.com/stretchr/testify/require"
+)
+
+const redirectURL = "http://cmdb.example.com/login/callback"
+
+func newTestProvider(t *testing.T, claims map[string]interface{}) (*Provider, *oidctest.Server) {
+	server, err := oidctest.NewServer("cmdb", "secret", claims)
+	require.NoError(t, err)
+	t.Cleanup(server.Close)
+
+	conf := &Config{Issuer: server.Issuer(), ClientID: "cmdb", ClientSecret: "secret", RedirectURL: redirectURL}
+	require.NoError(t, conf.Validate())
+	return NewProvider(conf), server
+}
+
+// login visits the authorization endpoint and returns the query of the redirection back to cmdb
+func login(t *testing.T, p *Provider, state, nonce string) url.Values {
+	authURL, err := p.AuthCodeURL(context.Background(), state, nonce)
+	require.NoError(t, err)
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	require.Equal(t, redirectURL, location.Scheme+"://"+location.Host+location.Path)
+	return location.Query()
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	p, _ := newTestProvider(t, map[string]interface{}{
+		"sub":                "1001",
+		"preferred_username": "alice",
+		"name":               "Alice",
+		"email":              "alice@example.com",
+		"groups":             []string{"ops", "dev"},
+	})
+
+	query := login(t, p, "state1", "nonce1")
+	require.Equal(t, "state1", query.Get("state"))
+
+	identity, err := p.Exchange(context.Background(), query.Get("code"), "nonce1")
+	require.NoError(t, err)
+	require.Equal(t, "alice", identity.UserName)
+	require.Equal(t, "Alice", identity.ChName)
+	require.Equal(t, "alice@example.com", identity.Email)
+	require.Equal(t, []string{"ops", "dev"}, identity.Groups)
+
+	// the authorization code can only be used once
+	_, err = p.Exchange(context.Background(), query.Get("code"), "nonce1")
+	require.Error(t, err)
+}
+
+func TestExchangeWithWrongNonce(t *testing.T) {
+	p, _ := newTestProvider(t, map[string]interface{}{"sub": "1001", "preferred_username": "alice"})
+
+	query := login(t, p, "state", "nonce")
+	_, err := p.Exchange(context.Background(), query.Get("code"), "other")
+	require.Error(t, err)
+}
+
+func TestExchangeWithUserInfoClaims(t *testing.T) {
+	p, server := newTestProvider(t, nil)
+	server.SetClaims(map[string]interface{}{"sub": "1002"},
+		map[string]interface{}{"preferred_username": "bob", "groups": "admin"})
+
+	query := login(t, p, "state", "nonce")
+	identity, err := p.Exchange(context.Background(), query.Get("code"), "nonce")
+	require.NoError(t, err)
+	require.Equal(t, "bob", identity.UserName)
+	require.Equal(t, []string{"admin"}, identity.Groups)
+}
+
+func TestExchangeWithoutUserName(t *testing.T) {
+	p, _ := newTestProvider(t, map[string]interface{}{"sub": "1003"})
+
+	query := login(t, p, "state", "nonce")
+	_, err := p.Exchange(context.Background(), query.Get("code"), "nonce")
+	require.Error(t, err)
+}
+
+func TestExchangeWithWrongClientSecret(t *testing.T) {
+	p, _ := newTestProvider(t, map[string]interface{}{"sub": "1001", "preferred_username": "alice"})
+	p.conf.ClientSecret = "wrong"
+
+	query := login(t, p, "state", "nonce")
+	_, err := p.Exchange(context.Background(), query.Get("code"), "nonce")
+	require.Error(t, err)
+}
@@ -0,0 +1,217 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+// Package sso defines the common logics of the single sign-on login plugins, including the login identity stored in
+// session and the mapping from the user groups to the supplier account and default business
+package sso
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"configcenter/src/common"
+	cc "configcenter/src/common/backbone/configcenter"
+	"configcenter/src/common/metadata"
+	webCommon "configcenter/src/web_server/common"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// configKey is the configuration key of the login plugins
+	configKey = "webServer.login"
+	// identitySessionKey is the session key to store the login identity
+	identitySessionKey = "sso_identity"
+	// defaultLoginTimeout is the default seconds that the login identity is valid
+	defaultLoginTimeout = 24 * 60 * 60
+	// AnyGroup is the group that matches all users in the group mapping
+	AnyGroup = "*"
+)
+
+// Config is the common configuration of the single sign-on login plugins
+type Config struct {
+	// LoginTimeout is the seconds that the login identity is valid
+	LoginTimeout int64 `mapstructure:"loginTimeout"`
+	// GroupMapping maps the user groups to the supplier account and default business, matched in order
+	GroupMapping []GroupMapping `mapstructure:"groupMapping"`
+}
+
+// GroupMapping maps the group of the login user to the supplier account and default business
+type GroupMapping struct {
+	Group           string `mapstructure:"group"`
+	SupplierAccount string `mapstructure:"supplierAccount"`
+	DefaultBizID    int64  `mapstructure:"defaultBizID"`
+}
+
+// LoadConfig load the common configuration of the single sign-on login plugins
+func LoadConfig() (*Config, error) {
+	conf := new(Config)
+	if cc.IsExist(configKey) {
+		if err := cc.UnmarshalKey(configKey, conf); err != nil {
+			return nil, fmt.Errorf("parse %s config failed, err: %v", configKey, err)
+		}
+	}
+
+	if conf.LoginTimeout <= 0 {
+		conf.LoginTimeout = defaultLoginTimeout
+	}
+
+	for _, mapping := range conf.GroupMapping {
+		if mapping.Group == "" {
+			return nil, fmt.Errorf("%s.groupMapping group can not be empty", configKey)
+		}
+		if mapping.DefaultBizID < 0 {
+			return nil, fmt.Errorf("%s.groupMapping default biz id %d is invalid", configKey, mapping.DefaultBizID)
+		}
+	}
+	return conf, nil
+}
+
+// Identity is the user identity authenticated by the single sign-on server or ldap
+type Identity struct {
+	UserName string   `json:"username"`
+	ChName   string   `json:"chname"`
+	Email    string   `json:"email"`
+	Phone    string   `json:"phone"`
+	Groups   []string `json:"groups"`
+	// ExpireAt is the unix time when the login identity expires
+	ExpireAt int64 `json:"expire_at"`
+}
+
+// SaveIdentity save the login identity into session, it expires after the login timeout
+func SaveIdentity(c *gin.Context, conf *Config, identity *Identity) error {
+	identity.ExpireAt = time.Now().Unix() + conf.LoginTimeout
+	js, err := json.Marshal(identity)
+	if err != nil {
+		return err
+	}
+
+	session := sessions.Default(c)
+	session.Set(identitySessionKey, string(js))
+	return session.Save()
+}
+
+// LoadIdentity load the login identity from session, returns false if the user is not logged in or is expired
+func LoadIdentity(c *gin.Context) (*Identity, bool) {
+	js, ok := sessions.Default(c).Get(identitySessionKey).(string)
+	if !ok || js == "" {
+		return nil, false
+	}
+
+	identity := new(Identity)
+	if err := json.Unmarshal([]byte(js), identity); err != nil {
+		return nil, false
+	}
+
+	if identity.UserName == "" || identity.ExpireAt <= time.Now().Unix() {
+		return nil, false
+	}
+	return identity, true
+}
+
+// MatchGroup returns the supplier account and default business of the first group mapping that matches the groups,
+// the default supplier account is used if no mapping matches
+func MatchGroup(groups []string, mappings []GroupMapping) (string, int64) {
+	for _, mapping := range mappings {
+		if mapping.Group != AnyGroup && !containsGroup(groups, mapping.Group) {
+			continue
+		}
+
+		ownerID := mapping.SupplierAccount
+		if ownerID == "" {
+			ownerID = common.BKDefaultOwnerID
+		}
+		return ownerID, mapping.DefaultBizID
+	}
+	return common.BKDefaultOwnerID, 0
+}
+
+func containsGroup(groups []string, group string) bool {
+	for _, g := range groups {
+		if strings.EqualFold(g, group) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewLoginUserInfo generate the login user info of the identity, and set the supplier account cookie
+func NewLoginUserInfo(c *gin.Context, conf *Config, identity *Identity) *metadata.LoginUserInfo {
+	ownerID, bizID := MatchGroup(identity.Groups, conf.GroupMapping)
+	c.SetCookie(common.HTTPCookieSupplierAccount, ownerID, 0, "/", "", false, false)
+
+	chName := identity.ChName
+	if chName == "" {
+		chName = identity.UserName
+	}
+
+	return &metadata.LoginUserInfo{
+		UserName:     identity.UserName,
+		ChName:       chName,
+		Phone:        identity.Phone,
+		Email:        identity.Email,
+		OnwerUin:     ownerID,
+		Language:     webCommon.GetLanguageByHTTPRequest(c),
+		DefaultBizID: bizID,
+		Extra:        map[string]interface{}{"groups": identity.Groups},
+	}
+}
+
+// CurrentUserList returns the current login user as the user list, because the single sign-on servers do not provide
+// a standard way to list all the users
+func CurrentUserList(c *gin.Context) []*metadata.LoginSystemUserInfo {
+	identity, ok := LoadIdentity(c)
+	if !ok {
+		return make([]*metadata.LoginSystemUserInfo, 0)
+	}
+
+	return []*metadata.LoginSystemUserInfo{{CnName: identity.ChName, EnName: identity.UserName}}
+}
+
+// SiteURL returns the cmdb site url of the http scheme
+func SiteURL(httpScheme string) string {
+	var siteURL string
+	var err error
+	if common.LogoutHTTPSchemeHTTPS == httpScheme {
+		siteURL, err = cc.String("webServer.site.httpsDomainUrl")
+	} else {
+		siteURL, err = cc.String("webServer.site.domainUrl")
+	}
+	if err != nil {
+		siteURL = ""
+	}
+	return strings.TrimRight(siteURL, "/")
+}
+
+// LocalLoginURL returns the cmdb login page url that logs in with user name and password
+func LocalLoginURL(c *gin.Context, httpScheme string) string {
+	siteURL := SiteURL(httpScheme)
+	return fmt.Sprintf("%s/login?c_url=%s%s", siteURL, siteURL, c.Request.URL.String())
+}
+
+// RandomString generate a random hex string used as the state or nonce of the login request
+func RandomString() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
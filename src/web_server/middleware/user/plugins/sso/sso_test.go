@@ -0,0 +1,49 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package sso
+
+import (
+	"testing"
+
+	"configcenter/src/common"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchGroup(t *testing.T) {
+	mappings := []GroupMapping{
+		{Group: "ops", SupplierAccount: "1", DefaultBizID: 3},
+		{Group: "dev", DefaultBizID: 5},
+		{Group: AnyGroup, SupplierAccount: "2"},
+	}
+
+	ownerID, bizID := MatchGroup([]string{"dev", "OPS"}, mappings)
+	require.Equal(t, "1", ownerID)
+	require.Equal(t, int64(3), bizID)
+
+	ownerID, bizID = MatchGroup([]string{"dev"}, mappings)
+	require.Equal(t, common.BKDefaultOwnerID, ownerID)
+	require.Equal(t, int64(5), bizID)
+
+	ownerID, bizID = MatchGroup(nil, mappings)
+	require.Equal(t, "2", ownerID)
+	require.Equal(t, int64(0), bizID)
+
+	ownerID, bizID = MatchGroup([]string{"ops"}, nil)
+	require.Equal(t, common.BKDefaultOwnerID, ownerID)
+	require.Equal(t, int64(0), bizID)
+}
@@ -88,6 +88,7 @@ func (m *publicUser) LoginUser(c *gin.Context) bool {
 	session.Set(common.WEBSessionOwnerUinKey, userInfo.OnwerUin)
 	session.Set(common.WEBSessionAvatarUrlKey, userInfo.AvatarUrl)
 	session.Set(common.WEBSessionOwnerUinListeKey, string(strOwnerUinList))
+	session.Set(common.WEBSessionDefaultBizKey, userInfo.DefaultBizID)
 	if userInfo.MultiSupplier {
 		session.Set(common.WEBSessionMultiSupplierKey, common.LoginSystemMultiSupplierTrue)
 	} else {
@@ -122,3 +123,28 @@ func (m *publicUser) GetUserList(c *gin.Context) ([]*metadata.LoginSystemUserInf
 	user := plugins.CurrentPlugin(m.config.LoginVersion)
 	return user.GetUserList(c, m.config.ConfigMap)
 }
+
+// HandleCallback handle the redirection back from the single sign-on system
+func (m *publicUser) HandleCallback(c *gin.Context) (string, *errors.RawErrorInfo) {
+	user := plugins.CurrentPlugin(m.config.LoginVersion)
+	callbackPlugin, ok := user.(metadata.LoginCallbackPluginInterface)
+	if !ok {
+		return "", &errors.RawErrorInfo{
+			ErrCode: common.CCErrWebUnknownLoginVersion,
+			Args:    []interface{}{m.config.LoginVersion},
+		}
+	}
+
+	return callbackPlugin.HandleCallback(c, m.config.ConfigMap)
+}
+
+// VerifyPassword verify the user name and password by the login plugin
+func (m *publicUser) VerifyPassword(c *gin.Context, userName, password string) (bool, *errors.RawErrorInfo) {
+	user := plugins.CurrentPlugin(m.config.LoginVersion)
+	passwordPlugin, ok := user.(metadata.LoginPasswordPluginInterface)
+	if !ok {
+		return false, nil
+	}
+
+	return true, passwordPlugin.VerifyPassword(c, m.config.ConfigMap, userName, password)
+}
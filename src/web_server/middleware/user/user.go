@@ -37,6 +37,10 @@ type LoginInterface interface {
 	GetLoginUrl(c *gin.Context) string
 	// GetUserList 获取不同登录方式下对应的用户列表
 	GetUserList(c *gin.Context) ([]*metadata.LoginSystemUserInfo, *errors.RawErrorInfo)
+	// HandleCallback 处理单点登录系统登录后的回调，返回登录前访问的URL
+	HandleCallback(c *gin.Context) (string, *errors.RawErrorInfo)
+	// VerifyPassword 使用登录插件校验用户名和密码，登录插件不支持时返回false
+	VerifyPassword(c *gin.Context, userName, password string) (bool, *errors.RawErrorInfo)
 }
 
 // NewUser return user instance by type
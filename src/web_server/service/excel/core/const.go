@@ -98,6 +98,9 @@ const (
 
 	// AddInst 添加实例
 	AddInst HandleType = "addInst"
+
+	// AddBiz 添加或更新业务
+	AddBiz HandleType = "addBiz"
 )
 
 // AsstOp 关联关系操作
@@ -113,3 +116,21 @@ const (
 
 // AsstOps 关联关系操作数组
 var AsstOps = []string{string(AsstOpAdd), string(AsstOpDel)}
+
+// instance record const define, the records are used when importing or exporting csv and json lines files
+const (
+	// AsstRecordIDKey 关联关系记录中「关联标识」的键
+	AsstRecordIDKey = "bk_obj_asst_id"
+
+	// AsstRecordOPKey 关联关系记录中「操作」的键
+	AsstRecordOPKey = "operate"
+
+	// AsstRecordSrcInstKey 关联关系记录中「源实例」的键
+	AsstRecordSrcInstKey = "src_inst"
+
+	// AsstRecordDstInstKey 关联关系记录中「目标实例」的键
+	AsstRecordDstInstKey = "dst_inst"
+)
+
+// AsstRecordKeys 关联关系记录的键
+var AsstRecordKeys = []string{AsstRecordIDKey, AsstRecordOPKey, AsstRecordSrcInstKey, AsstRecordDstInstKey}
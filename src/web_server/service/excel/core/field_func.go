@@ -274,7 +274,8 @@ func (d *Client) GetInstWithOrgName(kit *rest.Kit, ccLang language.DefaultCCLang
 func getAllOrganization(kit *rest.Kit, orgIDs []int64) (*metadata.DepartmentData, errors.CCErrorCoder) {
 
 	loginVersion, _ := cc.String("webServer.login.version")
-	if loginVersion == common.BKOpenSourceLoginPluginVersion || loginVersion == common.BKSkipLoginPluginVersion {
+	if loginVersion == common.BKOpenSourceLoginPluginVersion || loginVersion == common.BKSkipLoginPluginVersion ||
+		loginVersion == common.BKOIDCLoginPluginVersion || loginVersion == common.BKLDAPLoginPluginVersion {
 		return &metadata.DepartmentData{}, nil
 	}
 
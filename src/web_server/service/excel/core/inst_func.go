@@ -18,6 +18,8 @@ package core
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
 
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
@@ -25,6 +27,7 @@ import (
 	"configcenter/src/common/http/rest"
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
 
 	"github.com/pkg/errors"
 )
@@ -99,6 +102,8 @@ func (d *Client) HandleImportedInst(kit *rest.Kit, param *ImportedParam) ([]int6
 	case AddInst:
 		result, err = d.ApiClient.AddInstByImport(kit.Ctx, kit.Header, kit.SupplierAccount,
 			param.ObjID, param.Req)
+	case AddBiz:
+		return d.handleImportedBiz(kit, param)
 	default:
 		err = fmt.Errorf("handle type is invalid, type: %s", param.HandleType)
 	}
@@ -116,3 +121,99 @@ func (d *Client) HandleImportedInst(kit *rest.Kit, param *ImportedParam) ([]int6
 
 	return result.Success, result.Errors
 }
+
+// handleImportedBiz create or update the imported businesses one by one, because businesses can not be created in
+// batch. the business is updated if its id is set or a business with the same name exists, otherwise it is created
+func (d *Client) handleImportedBiz(kit *rest.Kit, param *ImportedParam) ([]int64, []string) {
+	defLang := param.Language.CreateDefaultCCLanguageIf(httpheader.GetLanguage(kit.Header))
+
+	rows := make([]int, 0, len(param.Instances))
+	names := make([]string, 0)
+	for row, biz := range param.Instances {
+		rows = append(rows, row)
+		if name := util.GetStrByInterface(biz[common.BKAppNameField]); name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Ints(rows)
+
+	errMsg := make([]string, 0)
+	existBizIDs, err := d.getBizIDsByName(kit, names)
+	if err != nil {
+		for _, row := range rows {
+			errMsg = append(errMsg, defLang.Languagef("import_data_fail", row, err.Error()))
+		}
+		return nil, errMsg
+	}
+
+	success := make([]int64, 0)
+	for _, row := range rows {
+		biz := param.Instances[row]
+		bizID, _ := util.GetInt64ByInterface(biz[common.BKAppIDField])
+		if bizID == 0 {
+			bizID = existBizIDs[util.GetStrByInterface(biz[common.BKAppNameField])]
+		}
+
+		data := make(map[string]interface{}, len(biz))
+		for key, val := range biz {
+			if key != common.BKAppIDField {
+				data[key] = val
+			}
+		}
+
+		if err := d.saveImportedBiz(kit, bizID, data); err != nil {
+			blog.Errorf("save imported biz failed, row: %d, biz id: %d, err: %v, rid: %s", row, bizID, err, kit.Rid)
+			errMsg = append(errMsg, defLang.Languagef("import_data_fail", row, err.Error()))
+			continue
+		}
+		success = append(success, int64(row))
+	}
+
+	return success, errMsg
+}
+
+// getBizIDsByName get the ids of the businesses with the names, returns the map of business name to id
+func (d *Client) getBizIDsByName(kit *rest.Kit, names []string) (map[string]int64, error) {
+	bizIDs := make(map[string]int64)
+	if len(names) == 0 {
+		return bizIDs, nil
+	}
+
+	names = util.StrArrayUnique(names)
+	cond := &metadata.QueryBusinessRequest{
+		Fields:    []string{common.BKAppIDField, common.BKAppNameField},
+		Page:      metadata.BasePage{Limit: len(names)},
+		Condition: mapstr.MapStr{common.BKAppNameField: mapstr.MapStr{common.BKDBIN: names}},
+	}
+	bizs, err := d.GetBiz(kit, cond)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, biz := range bizs {
+		bizID, err := biz.Int64(common.BKAppIDField)
+		if err != nil {
+			blog.Errorf("get biz id failed, biz: %v, err: %v, rid: %s", biz, err, kit.Rid)
+			return nil, err
+		}
+		bizIDs[util.GetStrByInterface(biz[common.BKAppNameField])] = bizID
+	}
+	return bizIDs, nil
+}
+
+// saveImportedBiz create the business if the business id is not set, otherwise update it
+func (d *Client) saveImportedBiz(kit *rest.Kit, bizID int64, data map[string]interface{}) error {
+	if bizID == 0 {
+		resp, err := d.ApiClient.CreateBiz(kit.Ctx, kit.SupplierAccount, kit.Header, data)
+		if err != nil {
+			return err
+		}
+		return resp.CCError()
+	}
+
+	resp, err := d.ApiClient.UpdateBiz(kit.Ctx, kit.SupplierAccount, strconv.FormatInt(bizID, 10), kit.Header, data)
+	if err != nil {
+		return err
+	}
+	return resp.CCError()
+}
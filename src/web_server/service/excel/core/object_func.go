@@ -103,6 +103,10 @@ func (d *Client) GetObjColProp(kit *rest.Kit, cond mapstr.MapStr) ([]ColProp, er
 			IsRequire: attr.IsRequired, Option: attr.Option, Group: attr.PropertyGroup, RefSheet: attr.PropertyName,
 			Length: PropertyNormalLen,
 		}
+		// 计算字段的值由服务端计算得出，不可编辑
+		if attr.PropertyType == common.FieldTypeComputed {
+			colProp.NotEditable = true
+		}
 
 		result[idx] = colProp
 	}
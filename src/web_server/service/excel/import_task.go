@@ -0,0 +1,245 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package excel
+
+import (
+	"fmt"
+	"net/http"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/web_server/service/excel/core"
+	"configcenter/src/web_server/service/excel/operator/inst/importer"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ValidateInst validate the instances to be imported without importing them
+func (s *service) ValidateInst(c *gin.Context) {
+	s.validateImportFunc(c, c.Param(common.BKObjIDField), core.AddInst)
+}
+
+// ValidateAddHost validate the hosts to be imported without importing them
+func (s *service) ValidateAddHost(c *gin.Context) {
+	s.validateImportFunc(c, common.BKInnerObjIDHost, core.AddHost)
+}
+
+// ValidateUpdateHost validate the hosts to be updated without updating them
+func (s *service) ValidateUpdateHost(c *gin.Context) {
+	s.validateImportFunc(c, common.BKInnerObjIDHost, core.UpdateHost)
+}
+
+// importErrorsResult the errors of the excel rows
+type importErrorsResult struct {
+	Errors []metadata.ExcelImportRowError `json:"errors"`
+}
+
+func (s *service) validateImportFunc(c *gin.Context, objID string, handleType core.HandleType) {
+	kit := rest.NewKitFromHeader(c.Request.Header, s.engine.CCErr)
+	op := s.newInstImporter(c, kit, objID, handleType)
+	if op == nil {
+		return
+	}
+
+	rowErrs, err := op.Validate()
+	if err != nil {
+		blog.Errorf("validate excel import data failed, err: %v, rid: %s", err, kit.Rid)
+		cleanImporter(kit, op)
+		c.String(http.StatusInternalServerError, fmt.Errorf("validate import data failed, err: %+v", err).Error())
+		return
+	}
+
+	if err := op.Clean(); err != nil {
+		blog.Errorf("clean importer resource failed, err: %v, rid: %s", err, kit.Rid)
+		c.String(http.StatusInternalServerError, fmt.Errorf("clean importer resource failed, err: %+v", err).Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, metadata.NewSuccessResp(importErrorsResult{Errors: rowErrs}))
+}
+
+// CreateInstImportTask create an asynchronous task to import the instances
+func (s *service) CreateInstImportTask(c *gin.Context) {
+	s.createImportTaskFunc(c, c.Param(common.BKObjIDField), core.AddInst)
+}
+
+// CreateAddHostImportTask create an asynchronous task to import the hosts
+func (s *service) CreateAddHostImportTask(c *gin.Context) {
+	s.createImportTaskFunc(c, common.BKInnerObjIDHost, core.AddHost)
+}
+
+// CreateUpdateHostImportTask create an asynchronous task to update the hosts
+func (s *service) CreateUpdateHostImportTask(c *gin.Context) {
+	s.createImportTaskFunc(c, common.BKInnerObjIDHost, core.UpdateHost)
+}
+
+// importTaskResult the result of creating the excel import task, the errors are the rows that fail the pre-check,
+// the task id is empty if none of the rows need to be imported
+type importTaskResult struct {
+	TaskID string                         `json:"task_id"`
+	Errors []metadata.ExcelImportRowError `json:"errors"`
+}
+
+func (s *service) createImportTaskFunc(c *gin.Context, objID string, handleType core.HandleType) {
+	kit := rest.NewKitFromHeader(c.Request.Header, s.engine.CCErr)
+	op := s.newInstImporter(c, kit, objID, handleType)
+	if op == nil {
+		return
+	}
+
+	opt, rowErrs, err := op.BuildTaskOption()
+	if err != nil {
+		blog.Errorf("build excel import task failed, err: %v, rid: %s", err, kit.Rid)
+		cleanImporter(kit, op)
+		c.String(http.StatusInternalServerError, fmt.Errorf("build import task failed, err: %+v", err).Error())
+		return
+	}
+
+	if err := op.Clean(); err != nil {
+		blog.Errorf("clean importer resource failed, err: %v, rid: %s", err, kit.Rid)
+		c.String(http.StatusInternalServerError, fmt.Errorf("clean importer resource failed, err: %+v", err).Error())
+		return
+	}
+
+	result := importTaskResult{Errors: rowErrs}
+	if opt == nil {
+		c.JSON(http.StatusOK, metadata.NewSuccessResp(result))
+		return
+	}
+
+	task, err := s.apiCli.CreateExcelImportTask(kit.Ctx, kit.Header, opt)
+	if err != nil {
+		blog.Errorf("create excel import task failed, err: %v, rid: %s", err, kit.Rid)
+		c.JSON(http.StatusOK, getCCErrResp(err))
+		return
+	}
+	result.TaskID = task.TaskID
+
+	c.JSON(http.StatusOK, metadata.NewSuccessResp(result))
+}
+
+// FindImportTask find the progress and the result of the excel import task
+func (s *service) FindImportTask(c *gin.Context) {
+	kit := rest.NewKitFromHeader(c.Request.Header, s.engine.CCErr)
+	opt := &metadata.ExcelImportTaskOption{TaskID: c.Param(common.BKTaskIDField)}
+
+	result, err := s.apiCli.FindExcelImportTask(kit.Ctx, kit.Header, opt)
+	if err != nil {
+		blog.Errorf("find excel import task %s failed, err: %v, rid: %s", opt.TaskID, err, kit.Rid)
+		c.JSON(http.StatusOK, getCCErrResp(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, metadata.NewSuccessResp(result))
+}
+
+// InstImportErrorReport download the error report of the instance import excel
+func (s *service) InstImportErrorReport(c *gin.Context) {
+	s.importErrorReportFunc(c, c.Param(common.BKObjIDField), core.AddInst)
+}
+
+// AddHostImportErrorReport download the error report of the host import excel
+func (s *service) AddHostImportErrorReport(c *gin.Context) {
+	s.importErrorReportFunc(c, common.BKInnerObjIDHost, core.AddHost)
+}
+
+// UpdateHostImportErrorReport download the error report of the host update excel
+func (s *service) UpdateHostImportErrorReport(c *gin.Context) {
+	s.importErrorReportFunc(c, common.BKInnerObjIDHost, core.UpdateHost)
+}
+
+// importErrorReportFunc annotate the errors in the uploaded excel and return it, the errors are the ones of the
+// import task if the task id is specified, otherwise the excel is validated to get the errors
+func (s *service) importErrorReportFunc(c *gin.Context, objID string, handleType core.HandleType) {
+	kit := rest.NewKitFromHeader(c.Request.Header, s.engine.CCErr)
+	op := s.newInstImporter(c, kit, objID, handleType)
+	if op == nil {
+		return
+	}
+
+	// the error report is annotated in the uploaded excel, the errors of the records can be got by validation
+	if op.IsRecordFile() {
+		cleanImporter(kit, op)
+		c.JSON(http.StatusOK, getErrResp(kit, common.CCErrInvalidFileTypeFail, "the error report only supports excel"))
+		return
+	}
+
+	var rowErrs []metadata.ExcelImportRowError
+	if taskID := c.PostForm(common.BKTaskIDField); taskID != "" {
+		opt := &metadata.ExcelImportTaskOption{TaskID: taskID}
+		task, err := s.apiCli.FindExcelImportTask(kit.Ctx, kit.Header, opt)
+		if err != nil {
+			blog.Errorf("find excel import task %s failed, err: %v, rid: %s", taskID, err, kit.Rid)
+			cleanImporter(kit, op)
+			c.JSON(http.StatusOK, getCCErrResp(err))
+			return
+		}
+		rowErrs = task.Errors
+	} else {
+		var err error
+		rowErrs, err = op.Validate()
+		if err != nil {
+			blog.Errorf("validate excel import data failed, err: %v, rid: %s", err, kit.Rid)
+			cleanImporter(kit, op)
+			c.String(http.StatusInternalServerError, fmt.Errorf("validate import data failed, err: %+v", err).Error())
+			return
+		}
+	}
+
+	if err := op.WriteErrorReport(rowErrs); err != nil {
+		blog.Errorf("write excel import error report failed, err: %v, rid: %s", err, kit.Rid)
+		cleanImporter(kit, op)
+		c.JSON(http.StatusOK, getErrResp(kit, common.CCErrWebCreateEXCELFail, err.Error()))
+		return
+	}
+
+	if err := op.GetExcel().Close(); err != nil {
+		blog.Errorf("close excel io failed, err: %v, rid: %s", err, kit.Rid)
+		if err := op.GetExcel().Clean(); err != nil {
+			blog.Errorf("remove excel error report failed, err: %v, rid: %s", err, kit.Rid)
+		}
+		c.JSON(http.StatusOK, getErrResp(kit, common.CCErrWebCreateEXCELFail, err.Error()))
+		return
+	}
+
+	addDownExcelHttpHeader(c, fmt.Sprintf("bk_cmdb_import_error_%s.xlsx", objID))
+	c.File(op.GetExcel().GetFilePath())
+
+	if err := op.GetExcel().Clean(); err != nil {
+		blog.Errorf("remove excel error report failed, err: %v, rid: %s", err, kit.Rid)
+		return
+	}
+}
+
+// cleanImporter remove the uploaded file of the importer when the request fails before the file is cleaned
+func cleanImporter(kit *rest.Kit, op *importer.Importer) {
+	if err := op.Clean(); err != nil {
+		blog.Errorf("clean importer resource failed, err: %v, rid: %s", err, kit.Rid)
+	}
+}
+
+func getCCErrResp(err error) metadata.BaseResp {
+	code := common.CCErrCommHTTPDoRequestFailed
+	if ccErr, ok := err.(errors.CCErrorCoder); ok {
+		code = ccErr.GetCode()
+	}
+
+	return metadata.BaseResp{Code: code, ErrMsg: err.Error()}
+}
@@ -64,20 +64,8 @@ func ExportParam(param ExportParamI) BuildExporterFunc {
 
 // Export export data to excel
 func (e *Exporter) Export() error {
-	cond, err := e.exportParam.GetPropCond()
-	if err != nil {
-		blog.Errorf("get property condition failed, err: %v, rid: %s", err, e.GetKit().Rid)
-		return err
-	}
-	colProps, err := e.GetClient().GetSortedColProp(e.GetKit(), cond)
-	if err != nil {
-		blog.Errorf("get sorted column property failed, err: %v, rid: %s", err, e.GetKit().Rid)
-		return err
-	}
-
-	colProps, err = e.addExtraProp(colProps)
+	colProps, err := e.getColProps()
 	if err != nil {
-		blog.Errorf("add extra property failed, err: %v, rid: %s", err, e.GetKit().Rid)
 		return err
 	}
 
@@ -100,6 +88,28 @@ func (e *Exporter) Export() error {
 	return nil
 }
 
+// getColProps get the exported properties, including the extra properties
+func (e *Exporter) getColProps() ([]core.ColProp, error) {
+	cond, err := e.exportParam.GetPropCond()
+	if err != nil {
+		blog.Errorf("get property condition failed, err: %v, rid: %s", err, e.GetKit().Rid)
+		return nil, err
+	}
+	colProps, err := e.GetClient().GetSortedColProp(e.GetKit(), cond)
+	if err != nil {
+		blog.Errorf("get sorted column property failed, err: %v, rid: %s", err, e.GetKit().Rid)
+		return nil, err
+	}
+
+	colProps, err = e.addExtraProp(colProps)
+	if err != nil {
+		blog.Errorf("add extra property failed, err: %v, rid: %s", err, e.GetKit().Rid)
+		return nil, err
+	}
+
+	return colProps, nil
+}
+
 func (e *Exporter) addExtraProp(colProps []core.ColProp) ([]core.ColProp, error) {
 	result := make([]core.ColProp, 0)
 	idColIdx := common.HostAddMethodExcelDefaultIndex
@@ -373,6 +383,35 @@ func (e *Exporter) exportAsst(instIDs []int64) error {
 }
 
 func (e *Exporter) getInstAsst(instIDs []int64) ([][]excel.Cell, error) {
+	instAsstArr, err := e.findInstAsst(instIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. 获取实例关联关系中，源实例和目标实例的唯一标识信息; 这里当前模型的唯一标识会单独获取
+	asstData, err := e.getInstAsstData(instAsstArr)
+	if err != nil {
+		blog.Errorf("get instance association data failed, instAsstArr: %v, err: %v, rid: %s", instAsstArr, err,
+			e.GetKit().Rid)
+		return nil, err
+	}
+
+	// 4. 构造需要写到excel的关联关系数据
+	result := make([][]excel.Cell, len(asstData))
+	for idx, data := range asstData {
+		row := make([]excel.Cell, core.AsstDstInstColIdx+1)
+		row[core.AsstIDColIdx] = excel.Cell{Value: data.asstID}
+		row[core.AsstSrcInstColIdx] = excel.Cell{Value: data.srcInst}
+		row[core.AsstDstInstColIdx] = excel.Cell{Value: data.destInst}
+
+		result[idx] = row
+	}
+
+	return result, nil
+}
+
+// findInstAsst find the associations of the instances that need to be exported
+func (e *Exporter) findInstAsst(instIDs []int64) ([]*metadata.InstAsst, error) {
 	// 1. 获取需要导出的模型关联关系，以及判断是否有自关联的关联关系
 	asstObjUniqueIDMap := e.exportParam.GetAsstObjUniqueIDMap()
 	asstObjIDMap := make(map[string]struct{})
@@ -420,26 +459,7 @@ func (e *Exporter) getInstAsst(instIDs []int64) ([][]excel.Cell, error) {
 		return nil, err
 	}
 
-	// 3. 获取实例关联关系中，源实例和目标实例的唯一标识信息; 这里当前模型的唯一标识会单独获取
-	asstData, err := e.getInstAsstData(instAsstArr)
-	if err != nil {
-		blog.Errorf("get instance association data failed, instAsstArr: %v, err: %v, rid: %s", instAsstArr, err,
-			e.GetKit().Rid)
-		return nil, err
-	}
-
-	// 4. 构造需要写到excel的关联关系数据
-	result := make([][]excel.Cell, len(asstData))
-	for idx, data := range asstData {
-		row := make([]excel.Cell, core.AsstDstInstColIdx+1)
-		row[core.AsstIDColIdx] = excel.Cell{Value: data.asstID}
-		row[core.AsstSrcInstColIdx] = excel.Cell{Value: data.srcInst}
-		row[core.AsstDstInstColIdx] = excel.Cell{Value: data.destInst}
-
-		result[idx] = row
-	}
-
-	return result, nil
+	return instAsstArr, nil
 }
 
 type instAsstData struct {
@@ -17,6 +17,7 @@
 package exporter
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -42,6 +43,11 @@ func init() {
 	handleInstFieldFuncMap[common.FieldTypeEnumMulti] = getHandleEnumMultiFieldFunc()
 	handleInstFieldFuncMap[common.FieldTypeBool] = getHandleBoolFieldFunc()
 	handleInstFieldFuncMap[common.FieldTypeInnerTable] = getHandleTableFieldFunc()
+	handleInstFieldFuncMap[common.FieldTypeIPAddr] = getHandleCharFieldFunc()
+	handleInstFieldFuncMap[common.FieldTypeCIDR] = getHandleCharFieldFunc()
+	handleInstFieldFuncMap[common.FieldTypeURL] = getHandleCharFieldFunc()
+	handleInstFieldFuncMap[common.FieldTypeJSON] = getHandleJSONFieldFunc()
+	handleInstFieldFuncMap[common.FieldTypeComputed] = getHandleCharFieldFunc()
 
 	handleSpecialInstFieldFuncMap[common.BKCloudIDField] = getHandleInstCloudAreaFunc()
 }
@@ -58,6 +64,23 @@ func getHandleCharFieldFunc() handleInstFieldFunc {
 	}
 }
 
+func getHandleJSONFieldFunc() handleInstFieldFunc {
+	return func(e *Exporter, property *core.ColProp, val interface{}) ([][]excel.Cell, error) {
+		if val == nil {
+			return [][]excel.Cell{getRowWithOneCell()}, nil
+		}
+
+		jsonVal, err := json.Marshal(val)
+		if err != nil {
+			blog.Errorf("value is not a valid json, val: %v, err: %v", val, err)
+			return [][]excel.Cell{getRowWithOneCell()}, nil
+		}
+
+		handleFunc := getDefaultHandleFieldFunc()
+		return handleFunc(e, property, core.HandleDDE(string(jsonVal)))
+	}
+}
+
 func getHandleInstFieldFunc(property *core.ColProp) handleInstFieldFunc {
 	handleFunc, isSpecial := handleSpecialInstFieldFuncMap[property.ID]
 	if isSpecial {
@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 
+	"configcenter/pkg/excel"
 	"configcenter/src/common"
 	"configcenter/src/common/condition"
 	"configcenter/src/common/http/rest"
@@ -48,6 +49,9 @@ type ExportParamI interface {
 
 	// GetObjUniqueID get object unique id
 	GetObjUniqueID() int64
+
+	// GetFormat get the format of the exported file
+	GetFormat() excel.Format
 }
 
 // GetExportParamInterface 根据模型id返回对应的接口类型
@@ -73,6 +77,9 @@ type BaseParam struct {
 	// 自关联的时候，规定左边对象使用到的唯一索引
 	ObjUniqueID int64 `json:"object_unique_id"`
 
+	// Format 导出的文件格式，可选值为xlsx、csv、jsonl，默认为xlsx
+	Format excel.Format `json:"format"`
+
 	cursor *cursor
 }
 
@@ -86,6 +93,23 @@ func (b *BaseParam) GetObjUniqueID() int64 {
 	return b.ObjUniqueID
 }
 
+// GetFormat get the format of the exported file
+func (b *BaseParam) GetFormat() excel.Format {
+	if b.Format == "" {
+		return excel.FormatXlsx
+	}
+
+	return b.Format
+}
+
+func (b *BaseParam) validateFormat() error {
+	if b.GetFormat() != excel.FormatXlsx && !excel.IsRecordFormat(b.GetFormat()) {
+		return fmt.Errorf("format %s is invalid", b.Format)
+	}
+
+	return nil
+}
+
 // InstParam export instance parameter
 type InstParam struct {
 	BaseParam `json:",inline"`
@@ -142,7 +166,7 @@ func (e *InstParam) Validate(kit *rest.Kit, lang language.DefaultCCLanguageIf) e
 		return fmt.Errorf("bk_inst_ids exceed max length: %d", common.BKInstMaxExportLimit)
 	}
 
-	return nil
+	return e.validateFormat()
 }
 
 // HostParam export host parameter
@@ -248,7 +272,7 @@ func (e *HostParam) Validate(kit *rest.Kit, lang language.DefaultCCLanguageIf) e
 		return fmt.Errorf(lang.Languagef("export_page_limit_err", common.BKMaxOnceExportLimit))
 	}
 
-	return nil
+	return e.validateFormat()
 }
 
 // BizParam export biz parameter
@@ -333,7 +357,7 @@ func (e *BizParam) Validate(kit *rest.Kit, lang language.DefaultCCLanguageIf) er
 		return fmt.Errorf("bk_biz_ids exceed max length: %d", common.BKInstMaxExportLimit)
 	}
 
-	return nil
+	return e.validateFormat()
 }
 
 // ProjectParam export project parameter
@@ -420,7 +444,7 @@ func (e *ProjectParam) Validate(kit *rest.Kit, lang language.DefaultCCLanguageIf
 		return fmt.Errorf("bk_biz_ids exceed max length: %d", common.BKInstMaxExportLimit)
 	}
 
-	return nil
+	return e.validateFormat()
 }
 
 type cursor struct {
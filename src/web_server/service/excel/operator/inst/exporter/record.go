@@ -0,0 +1,269 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package exporter
+
+import (
+	"io"
+
+	"configcenter/pkg/excel"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/web_server/service/excel/core"
+)
+
+// ExportRecords export the instances to the writer as csv or json lines records page by page, the keys of the
+// records are the property ids, the values are the same as the values exported to excel. a trailing error record is
+// written if the export fails after the header is written, so that the incomplete file can be recognized
+func (e *Exporter) ExportRecords(w io.Writer) error {
+	colProps, err := e.getColProps()
+	if err != nil {
+		return err
+	}
+
+	writer, err := excel.NewRecordWriter(e.exportParam.GetFormat(), w)
+	if err != nil {
+		blog.Errorf("create record writer failed, err: %v, rid: %s", err, e.GetKit().Rid)
+		return err
+	}
+
+	return e.writeErrorOnFailure(writer, e.exportInstRecords(writer, colProps))
+}
+
+// writeErrorOnFailure write the export error as the trailing error record if the export fails, returns the error
+func (e *Exporter) writeErrorOnFailure(writer excel.RecordWriter, exportErr error) error {
+	if exportErr == nil {
+		return nil
+	}
+
+	if err := writer.WriteError(exportErr); err != nil {
+		blog.Errorf("write export error record failed, err: %v, rid: %s", err, e.GetKit().Rid)
+	}
+	return exportErr
+}
+
+func (e *Exporter) exportInstRecords(writer excel.RecordWriter, colProps []core.ColProp) error {
+	keys := make([]string, 0, len(colProps))
+	for _, property := range colProps {
+		if property.NotExport {
+			continue
+		}
+		keys = append(keys, property.ID)
+	}
+
+	if err := writer.WriteHeader(keys); err != nil {
+		blog.Errorf("write record header failed, keys: %v, err: %v, rid: %s", keys, err, e.GetKit().Rid)
+		return err
+	}
+
+	for e.exportParam.HasInstCond() {
+		instCond, err := e.exportParam.GetInstCond()
+		if err != nil {
+			blog.Errorf("get instance condition failed, err: %v, rid: %s", err, e.GetKit().Rid)
+			return err
+		}
+
+		if err := e.exportRecordsByCond(writer, instCond, colProps); err != nil {
+			blog.Errorf("export instance records by condition failed, err: %v, rid: %s", err, e.GetKit().Rid)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *Exporter) exportRecordsByCond(writer excel.RecordWriter, cond interface{}, colProps []core.ColProp) error {
+	insts, err := e.getInst(cond)
+	if err != nil {
+		blog.Errorf("get instance failed, objID: %s, cond: %v, err: %v, rid: %s", e.GetObjID(), cond, err,
+			e.GetKit().Rid)
+		return err
+	}
+
+	if len(insts) == 0 {
+		return nil
+	}
+
+	insts, _, err = e.enrichInst(insts, colProps)
+	if err != nil {
+		blog.Errorf("enrich instance field failed, err: %v, rid: %s", err, e.GetKit().Rid)
+		return err
+	}
+
+	for _, inst := range insts {
+		record, err := e.handleInstRecord(inst, colProps)
+		if err != nil {
+			blog.ErrorJSON("convert an instance to record failed, inst: %s, property: %s, err: %s, rid: %s", inst,
+				colProps, err, e.GetKit().Rid)
+			return err
+		}
+
+		if err := writer.Write(record); err != nil {
+			blog.Errorf("write instance record failed, err: %v, rid: %s", err, e.GetKit().Rid)
+			return err
+		}
+	}
+
+	// flush the records of each page, so that the exported data is not buffered in memory
+	return writer.Flush()
+}
+
+// handleInstRecord convert an instance to a record by the functions that convert it to excel cells, the value of
+// the table field is an array of the table rows
+func (e *Exporter) handleInstRecord(inst mapstr.MapStr, colProps []core.ColProp) (map[string]interface{}, error) {
+	record := make(map[string]interface{})
+	for _, property := range colProps {
+		if property.NotExport {
+			continue
+		}
+
+		val, ok := inst[property.ID]
+		if !ok {
+			continue
+		}
+
+		handleFunc := getHandleInstFieldFunc(&property)
+		rows, err := handleFunc(e, &property, val)
+		if err != nil {
+			blog.ErrorJSON("handle instance failed, property: %s, val: %s, err: %s, rid: %s", property, val, err,
+				e.GetKit().Rid)
+			return nil, err
+		}
+
+		if property.PropertyType != common.FieldTypeInnerTable {
+			if len(rows) > 0 && len(rows[0]) > 0 && rows[0][0].Value != nil {
+				record[property.ID] = rows[0][0].Value
+			}
+			continue
+		}
+
+		option, err := metadata.ParseTableAttrOption(property.Option)
+		if err != nil {
+			return nil, err
+		}
+
+		table := make([]map[string]interface{}, 0, len(rows))
+		for _, row := range rows {
+			tableRow := make(map[string]interface{})
+			for idx, cell := range row {
+				if idx >= len(option.Header) || cell.Value == nil {
+					continue
+				}
+				tableRow[option.Header[idx].PropertyID] = cell.Value
+			}
+			table = append(table, tableRow)
+		}
+		record[property.ID] = table
+	}
+
+	return record, nil
+}
+
+// ExportAsstRecords export the associations of the instances to the writer as csv or json lines records page by
+// page, the keys of the records are core.AsstRecordKeys, a trailing error record is written if the export fails
+func (e *Exporter) ExportAsstRecords(w io.Writer) error {
+	writer, err := excel.NewRecordWriter(e.exportParam.GetFormat(), w)
+	if err != nil {
+		blog.Errorf("create record writer failed, err: %v, rid: %s", err, e.GetKit().Rid)
+		return err
+	}
+
+	return e.writeErrorOnFailure(writer, e.exportAsstRecords(writer))
+}
+
+func (e *Exporter) exportAsstRecords(writer excel.RecordWriter) error {
+	if err := writer.WriteHeader(core.AsstRecordKeys); err != nil {
+		blog.Errorf("write record header failed, err: %v, rid: %s", err, e.GetKit().Rid)
+		return err
+	}
+
+	// 未设置, 不导出关联关系数据
+	if len(e.exportParam.GetAsstObjUniqueIDMap()) == 0 {
+		return writer.Flush()
+	}
+
+	// the self association of two exported instances is found by both of them, so it needs to be deduplicated
+	exported := make(map[instAsstData]struct{})
+	instIDKey := metadata.GetInstIDFieldByObjID(e.GetObjID())
+	for e.exportParam.HasInstCond() {
+		instCond, err := e.exportParam.GetInstCond()
+		if err != nil {
+			blog.Errorf("get instance condition failed, err: %v, rid: %s", err, e.GetKit().Rid)
+			return err
+		}
+
+		insts, err := e.getInst(instCond)
+		if err != nil {
+			blog.Errorf("get instance failed, objID: %s, cond: %v, err: %v, rid: %s", e.GetObjID(), instCond, err,
+				e.GetKit().Rid)
+			return err
+		}
+
+		if len(insts) == 0 {
+			continue
+		}
+
+		instIDs := make([]int64, 0, len(insts))
+		for _, inst := range insts {
+			instID, err := inst.Int64(instIDKey)
+			if err != nil {
+				blog.Errorf("parse instance(%+v) id(key:%s) failed, err: %v, objID: %s, rid: %s", inst, instIDKey, err,
+					e.GetObjID(), e.GetKit().Rid)
+				continue
+			}
+			instIDs = append(instIDs, instID)
+		}
+
+		instAsstArr, err := e.findInstAsst(instIDs)
+		if err != nil {
+			return err
+		}
+
+		asstData, err := e.getInstAsstData(instAsstArr)
+		if err != nil {
+			blog.Errorf("get instance association data failed, instAsstArr: %v, err: %v, rid: %s", instAsstArr, err,
+				e.GetKit().Rid)
+			return err
+		}
+
+		for _, data := range asstData {
+			if _, ok := exported[data]; ok {
+				continue
+			}
+			exported[data] = struct{}{}
+
+			record := map[string]interface{}{
+				core.AsstRecordIDKey:      data.asstID,
+				core.AsstRecordOPKey:      string(core.AsstOpAdd),
+				core.AsstRecordSrcInstKey: data.srcInst,
+				core.AsstRecordDstInstKey: data.destInst,
+			}
+			if err := writer.Write(record); err != nil {
+				blog.Errorf("write association record failed, err: %v, rid: %s", err, e.GetKit().Rid)
+				return err
+			}
+		}
+
+		if err := writer.Flush(); err != nil {
+			blog.Errorf("flush association records failed, err: %v, rid: %s", err, e.GetKit().Rid)
+			return err
+		}
+	}
+
+	return nil
+}
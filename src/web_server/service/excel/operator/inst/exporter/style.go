@@ -78,7 +78,8 @@ func handlePropertyTypeNumFmt(propType string) int {
 		return 1
 	case common.FieldTypeFloat:
 		return 2
-	case common.FieldTypeLongChar, common.FieldTypeSingleChar:
+	case common.FieldTypeLongChar, common.FieldTypeSingleChar, common.FieldTypeIPAddr, common.FieldTypeCIDR,
+		common.FieldTypeURL, common.FieldTypeJSON:
 		return 49
 	case common.FieldTypeInnerTable,
 		common.FieldTypeEnum,
@@ -230,6 +231,11 @@ func setExcel(excel *excel.Excel) styleOperatorFunc {
 }
 
 func (s *styleCreator) getStyle(style styleType, propTypes ...string) (int, error) {
+	// records exported to csv or json lines files have no style
+	if s.excel == nil {
+		return 0, nil
+	}
+
 	result, ok := s.styleMap[style]
 	if !ok {
 		styleFunc := createStyleFuncMap[style]
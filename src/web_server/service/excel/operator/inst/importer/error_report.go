@@ -0,0 +1,186 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package importer
+
+import (
+	"fmt"
+	"strings"
+
+	"configcenter/pkg/excel"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	httpheader "configcenter/src/common/http/header"
+	"configcenter/src/common/metadata"
+	"configcenter/src/web_server/service/excel/core"
+)
+
+const (
+	// errorCellColor 错误报告中不合法单元格的颜色
+	errorCellColor = "ffc7ce"
+	// errorBorderColor 错误报告中不合法单元格的边框颜色
+	errorBorderColor = "d4d4d4"
+)
+
+var errorCellBorder = []excel.Border{
+	{Type: excel.Left, Color: errorBorderColor, Style: 1}, {Type: excel.Right, Color: errorBorderColor, Style: 1},
+	{Type: excel.Top, Color: errorBorderColor, Style: 1}, {Type: excel.Bottom, Color: errorBorderColor, Style: 1},
+}
+
+// validatedFieldTypes the types of the fields that have data validations in the excel template, the prompt of the
+// error message can not be added to their cells
+var validatedFieldTypes = map[string]struct{}{
+	common.FieldTypeInt:   {},
+	common.FieldTypeFloat: {},
+	common.FieldTypeEnum:  {},
+	common.FieldTypeBool:  {},
+}
+
+// WriteErrorReport annotate the failed rows in the excel and save it as the error report: the invalid cells are
+// marked red with the error message as their prompt, and all the error messages of a row are written to the error
+// column at the end of the row
+func (i *Importer) WriteErrorReport(rowErrs []metadata.ExcelImportRowError) error {
+	if i.record != nil {
+		return fmt.Errorf("error report of %s file is not supported", i.record.format)
+	}
+
+	styleID, err := i.GetExcel().NewStyle(&excel.Style{
+		Fill:   &excel.Fill{Type: excel.Pattern, Color: []string{errorCellColor}, Pattern: 1},
+		Border: errorCellBorder,
+	})
+	if err != nil {
+		blog.Errorf("create error cell style failed, err: %v, rid: %s", err, i.GetKit().Rid)
+		return err
+	}
+
+	instErrs := make([]metadata.ExcelImportRowError, 0)
+	asstErrs := make([]metadata.ExcelImportRowError, 0)
+	for _, rowErr := range rowErrs {
+		// the errors that do not belong to a row, like too many rows, are not written to the report
+		if rowErr.Row <= 0 {
+			continue
+		}
+
+		if rowErr.Sheet == metadata.ExcelAssociationSheet {
+			asstErrs = append(asstErrs, rowErr)
+			continue
+		}
+		instErrs = append(instErrs, rowErr)
+	}
+
+	if err := i.writeInstErrors(instErrs, styleID); err != nil {
+		blog.Errorf("write instance errors to excel failed, err: %v, rid: %s", err, i.GetKit().Rid)
+		return err
+	}
+
+	if len(asstErrs) != 0 {
+		if err := i.writeRowErrors(core.AsstSheet, core.AsstStartRowIdx, asstErrs); err != nil {
+			blog.Errorf("write association errors to excel failed, err: %v, rid: %s", err, i.GetKit().Rid)
+			return err
+		}
+	}
+
+	return i.GetExcel().Save()
+}
+
+func (i *Importer) writeInstErrors(rowErrs []metadata.ExcelImportRowError, styleID int) error {
+	reader, err := i.GetExcel().NewReader(i.GetObjID())
+	if err != nil {
+		return err
+	}
+
+	propertyMap, err := i.getPropertyMap(reader)
+	if err != nil {
+		return err
+	}
+
+	if err := reader.Close(); err != nil {
+		return err
+	}
+
+	propMap := make(map[string]PropWithTable)
+	for _, prop := range propertyMap {
+		propMap[prop.ID] = prop
+	}
+
+	for _, rowErr := range rowErrs {
+		prop, ok := propMap[rowErr.PropertyID]
+		if !ok {
+			continue
+		}
+
+		row := int(rowErr.Row) - 1
+		if err := i.GetExcel().SetCellStyle(i.GetObjID(), prop.ExcelColIndex, row, styleID); err != nil {
+			return err
+		}
+
+		if _, ok := validatedFieldTypes[prop.PropertyType]; ok || prop.ID == common.BKCloudIDField {
+			continue
+		}
+
+		sqref, err := excel.GetCellSqref(prop.ExcelColIndex, row)
+		if err != nil {
+			return err
+		}
+
+		param := &excel.ValidationParam{Type: excel.Prompt, Sqref: sqref,
+			Option: &excel.PromptOption{Title: prop.Name, Message: rowErr.Message}}
+		if err := i.GetExcel().AddValidation(i.GetObjID(), param); err != nil {
+			return err
+		}
+	}
+
+	return i.writeRowErrors(i.GetObjID(), core.NameRowIdx, rowErrs)
+}
+
+// writeRowErrors write the error messages of the rows to the error column after the last column of the sheet
+func (i *Importer) writeRowErrors(sheet string, headerRow int, rowErrs []metadata.ExcelImportRowError) error {
+	rows, err := i.GetExcel().StreamingRead(sheet)
+	if err != nil {
+		return err
+	}
+
+	errCol := 0
+	for _, row := range rows {
+		if len(row) > errCol {
+			errCol = len(row)
+		}
+	}
+
+	lang := i.GetLang().CreateDefaultCCLanguageIf(httpheader.GetLanguage(i.GetKit().Header))
+	header := lang.Language("import_error_column_name")
+	if err := i.GetExcel().SetCellValue(sheet, errCol, headerRow, header); err != nil {
+		return err
+	}
+
+	rowMsgs := make(map[int64][]string)
+	rowOrder := make([]int64, 0)
+	for _, rowErr := range rowErrs {
+		if _, ok := rowMsgs[rowErr.Row]; !ok {
+			rowOrder = append(rowOrder, rowErr.Row)
+		}
+		rowMsgs[rowErr.Row] = append(rowMsgs[rowErr.Row], rowErr.Message)
+	}
+
+	for _, row := range rowOrder {
+		msg := strings.Join(rowMsgs[row], "\n")
+		if err := i.GetExcel().SetCellValue(sheet, errCol, int(row)-1, msg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
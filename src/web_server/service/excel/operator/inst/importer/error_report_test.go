@@ -0,0 +1,67 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package importer
+
+import (
+	"testing"
+
+	"configcenter/pkg/excel"
+	"configcenter/src/common/metadata"
+	"configcenter/src/web_server/service/excel/core"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteErrorReport(t *testing.T) {
+	importer := newTestImporter(t, testRows)
+
+	rowErrs, err := importer.Validate()
+	require.NoError(t, err)
+	// the error that does not belong to a row is not written to the report
+	rowErrs = append(rowErrs, metadata.ExcelImportRowError{Message: "too many rows"})
+	require.NoError(t, importer.WriteErrorReport(rowErrs))
+	require.NoError(t, importer.GetExcel().Close())
+
+	report, err := excel.NewExcel(excel.FilePath(importer.GetExcel().GetFilePath()), excel.OpenOrCreate())
+	require.NoError(t, err)
+	rows, err := report.StreamingRead(testObjID)
+	require.NoError(t, err)
+
+	// the error column is added after the last column of the sheet
+	errCol := len(testAttrs)
+	require.NotEmpty(t, rows[core.NameRowIdx][errCol])
+
+	rowMsgs := make(map[int64]string)
+	for _, rowErr := range rowErrs {
+		if rowErr.Row > 0 {
+			rowMsgs[rowErr.Row] = rowErr.Message
+		}
+	}
+	for idx := core.InstRowIdx; idx < len(rows); idx++ {
+		msg, failed := rowMsgs[int64(idx+1)]
+		if !failed {
+			require.LessOrEqual(t, len(rows[idx]), errCol, "row %d", idx+1)
+			continue
+		}
+		require.Equal(t, msg, rows[idx][errCol], "row %d", idx+1)
+	}
+}
+
+func TestWriteErrorReportOfRecordFile(t *testing.T) {
+	importer := &Importer{record: &recordFile{format: excel.FormatCSV}}
+	require.Error(t, importer.WriteErrorReport(nil))
+}
@@ -34,6 +34,8 @@ import (
 type Importer struct {
 	*operator.BaseOp
 	param ImportParamI
+	// record the imported csv or json lines file, the excel of the base operator is not set if it is set
+	record *recordFile
 }
 
 type BuildImporterFunc func(importer *Importer) error
@@ -68,6 +70,10 @@ func Param(param ImportParamI) BuildImporterFunc {
 
 // Clean close importer file io and remove excel file
 func (i *Importer) Clean() error {
+	if i.record != nil {
+		return i.record.clean()
+	}
+
 	if err := i.GetExcel().Close(); err != nil {
 		blog.Errorf("close excel failed, err: %v, rid: %s", err, i.GetKit().Rid)
 		return err
@@ -105,7 +111,8 @@ func (i *Importer) Handle() (mapstr.MapStr, error) {
 		return nil, err
 	}
 
-	if hasErrMsg || len(i.param.GetAsstObjUniqueIDMap()) == 0 {
+	// the associations of the record files are imported separately by HandleAsst
+	if hasErrMsg || len(i.param.GetAsstObjUniqueIDMap()) == 0 || i.record != nil {
 		return result, nil
 	}
 
@@ -114,16 +121,7 @@ func (i *Importer) Handle() (mapstr.MapStr, error) {
 }
 
 func (i *Importer) getAsstInfo() (mapstr.MapStr, error) {
-	exist, err := i.GetExcel().IsSheetExist(core.AsstSheet)
-	if err != nil {
-		return nil, err
-	}
-
-	if !exist {
-		return mapstr.MapStr{"association": mapstr.New()}, nil
-	}
-
-	asstInfo, err := i.getAsstFromExcel()
+	asstInfo, err := i.readAsst()
 	if err != nil {
 		blog.Errorf("get association info from excel failed, err: %v, rid: %s", err, i.GetKit().Rid)
 		return nil, err
@@ -175,6 +173,29 @@ type excelAsstInfo struct {
 	errMsg []metadata.RowMsgData
 }
 
+// readAsst read the associations from the association sheet of excel or the association record file, returns nil
+// if there is no association data
+func (i *Importer) readAsst() (*excelAsstInfo, error) {
+	if i.record != nil {
+		if !i.record.isAsst {
+			return nil, nil
+		}
+
+		return i.getAsstFromRecord()
+	}
+
+	exist, err := i.GetExcel().IsSheetExist(core.AsstSheet)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exist {
+		return nil, nil
+	}
+
+	return i.getAsstFromExcel()
+}
+
 func (i *Importer) getAsstFromExcel() (*excelAsstInfo, error) {
 	reader, err := i.GetExcel().NewReader(core.AsstSheet)
 	if err != nil {
@@ -201,7 +222,7 @@ func (i *Importer) getAsstFromExcel() (*excelAsstInfo, error) {
 
 		if len(row) < core.AsstDstInstColIdx+1 {
 			msg := lang.Languagef("web_excel_row_handle_error", core.AsstSheet, reader.GetCurIdx()+1)
-			errMsg = append(errMsg, metadata.RowMsgData{Row: reader.GetCurIdx(), Msg: msg})
+			errMsg = append(errMsg, metadata.RowMsgData{Row: reader.GetCurIdx() + 1, Msg: msg})
 			continue
 		}
 
@@ -251,33 +272,19 @@ func (i *Importer) getAsstFromExcel() (*excelAsstInfo, error) {
 	return result, nil
 }
 
-func (i *Importer) preCheck(excelMsg *ExcelMsg) ([]string, error) {
-	reader, err := i.GetExcel().NewReader(i.GetObjID())
+func (i *Importer) preCheck(src instSource) ([]string, error) {
+	instCount, err := src.count()
 	if err != nil {
-		blog.Errorf("create excel io reader failed, sheet: %s, err: %v, rid: %s", i.GetObjID(), err, i.GetKit().Rid)
+		blog.Errorf("count the imported instances failed, err: %v, rid: %s", err, i.GetKit().Rid)
 		return nil, err
 	}
-	var instCount int
-	for reader.Next() {
-		instCount++
-	}
-
-	// 实例数需要减去excel表头占用的行数
-	instCount -= core.InstHeaderLen
-
-	// 如果存在合并多行作为一个实例，那么需要将这些多出来的行数减掉
-	if excelMsg != nil && len(excelMsg.mergeRowRes) != 0 {
-		for start, end := range excelMsg.mergeRowRes {
-			instCount -= end - start
-		}
-	}
 
 	lang := i.GetLang().CreateDefaultCCLanguageIf(httpheader.GetLanguage(i.GetKit().Header))
 	if instCount > common.ExcelImportMaxRow {
 		return []string{lang.Languagef("web_excel_import_too_much", common.ExcelImportMaxRow)}, nil
 	}
 
-	exist, err := i.isAsstExist()
+	exist, err := src.hasAsst()
 	if err != nil {
 		blog.Errorf("check if there is associated data failed, err: %v, rid: %s", err, i.GetKit().Rid)
 		return nil, err
@@ -319,86 +326,240 @@ func (i *Importer) isAsstExist() (bool, error) {
 }
 
 func (i *Importer) importInst() (mapstr.MapStr, bool, error) {
-	reader, err := i.GetExcel().NewReader(i.GetObjID())
+	var successMsg []int64
+	walkRes, err := i.walkInst(false, func(insts map[int]map[string]interface{}) ([]string, error) {
+		req, err := i.param.BuildParam(insts)
+		if err != nil {
+			blog.Errorf("get import instances parameter failed, err: %v, rid: %s", err, i.GetKit().Rid)
+			return nil, err
+		}
+		importParam := &core.ImportedParam{Language: i.GetLang(), ObjID: i.GetObjID(), Instances: insts,
+			Req: req, HandleType: i.param.GetHandleType()}
+		successRes, errRes := i.GetClient().HandleImportedInst(i.GetKit(), importParam)
+		successMsg = append(successMsg, successRes...)
+		return errRes, nil
+	})
 	if err != nil {
-		blog.Errorf("create excel io reader failed, sheet: %s, err: %v, rid: %s", i.GetObjID(), err, i.GetKit().Rid)
 		return nil, false, err
 	}
-	excelMsg, err := i.getExcelMsg(reader)
+
+	result := mapstr.New()
+	if walkRes.preCheckFailed {
+		result["error"] = walkRes.errMsg
+		return result, true, nil
+	}
+
+	result["success"] = successMsg
+	result["error"] = walkRes.errMsg
+	return result, len(walkRes.errMsg) > 0, nil
+}
+
+// instBatchHandler handle a batch of instances read from excel, the key of the instances is the excel row number,
+// returns the error messages of the instances
+type instBatchHandler func(insts map[int]map[string]interface{}) ([]string, error)
+
+type walkInstResult struct {
+	// preCheckFailed the excel is not walked because it fails the pre-check, the reason is in errMsg
+	preCheckFailed bool
+	errMsg         []string
+	// fieldErrs the errors of the invalid fields, only set when the fields are checked
+	fieldErrs []metadata.ExcelImportRowError
+}
+
+// walkInst read the instances from excel and handle them in batches, the instances that fail the special check are
+// not handled, neither are the instances that have invalid fields if checkField is set
+func (i *Importer) walkInst(checkField bool, handler instBatchHandler) (*walkInstResult, error) {
+	src, err := i.newInstSource()
 	if err != nil {
-		blog.Errorf("get object excel message failed, err: %v, rid: %s", err, i.GetKit().Rid)
-		return nil, false, err
+		blog.Errorf("create instance source failed, err: %v, rid: %s", err, i.GetKit().Rid)
+		return nil, err
 	}
 
-	result := mapstr.New()
-	var errMsg []string
-	errMsg, err = i.preCheck(excelMsg)
+	result := new(walkInstResult)
+	result.errMsg, err = i.preCheck(src)
 	if err != nil {
-		return nil, false, err
+		return nil, err
 	}
-	if len(errMsg) != 0 {
-		result["error"] = errMsg
-		return result, true, nil
+	if len(result.errMsg) != 0 {
+		result.preCheckFailed = true
+		return result, nil
 	}
 
 	lang := i.GetLang().CreateDefaultCCLanguageIf(httpheader.GetLanguage(i.GetKit().Header))
-	var successMsg []int64
 	insts := make(map[int]map[string]interface{})
-
-	hasDoneNext := false
-	for hasDoneNext || reader.Next() {
-		hasDoneNext = false
-		// skip excel header
-		if reader.GetCurIdx() < core.InstRowIdx-1 {
-			continue
+	handleBatch := func() error {
+		var errRes []string
+		insts, errRes = i.doSpecialOp(insts)
+		result.errMsg = append(result.errMsg, errRes...)
+		if len(insts) != 0 {
+			errRes, err := handler(insts)
+			if err != nil {
+				return err
+			}
+			result.errMsg = append(result.errMsg, errRes...)
 		}
 
-		idx := reader.GetCurIdx() + 1
-		inst, err := i.getNextInst(reader, excelMsg)
+		insts = make(map[int]map[string]interface{})
+		return nil
+	}
+
+	for src.next() {
+		idx := src.row()
+		inst, fieldErrs, err := i.getNextInst(src)
 		if err != nil {
 			blog.Errorf("get next instance from excel failed, err: %v, rid: %s", err, i.GetKit().Rid)
-			errMsg = append(errMsg, lang.Languagef("import_data_fail", idx, err.Error()))
+			result.errMsg = append(result.errMsg, lang.Languagef("import_data_fail", idx, err.Error()))
 			continue
 		}
-		if inst != nil {
-			insts[idx] = inst
-		}
-		if len(insts) < onceImportLimit && reader.Next() {
-			hasDoneNext = true
+		if inst == nil {
 			continue
 		}
 
-		var errRes []string
-		insts, errRes = i.doSpecialOp(insts)
-		errMsg = append(errMsg, errRes...)
-		if len(insts) == 0 {
+		if checkField {
+			rowErrs := i.checkInstFields(idx, inst, fieldErrs, src.propertyMap())
+			if len(rowErrs) != 0 {
+				result.fieldErrs = append(result.fieldErrs, rowErrs...)
+				continue
+			}
+		}
+
+		insts[idx] = inst
+		if len(insts) < onceImportLimit {
 			continue
 		}
 
-		req, err := i.param.BuildParam(insts)
-		if err != nil {
-			blog.Errorf("get import instances parameter failed, err: %v, rid: %s", err, i.GetKit().Rid)
-			return nil, false, err
+		if err := handleBatch(); err != nil {
+			return nil, err
 		}
-		importParam := &core.ImportedParam{Language: i.GetLang(), ObjID: i.GetObjID(), Instances: insts,
-			Req: req, HandleType: i.param.GetHandleType()}
-		successRes, errRes := i.GetClient().HandleImportedInst(i.GetKit(), importParam)
-		if len(successRes) != 0 {
-			successMsg = append(successMsg, successRes...)
+	}
+
+	if len(insts) != 0 {
+		if err := handleBatch(); err != nil {
+			return nil, err
 		}
-		errMsg = append(errMsg, errRes...)
+	}
 
-		insts = make(map[int]map[string]interface{})
+	if err := src.close(); err != nil {
+		blog.Errorf("close instance source failed, err: %v, rid: %s", err, i.GetKit().Rid)
+		return nil, err
 	}
 
-	result["success"] = successMsg
-	result["error"] = errMsg
-	if err := reader.Close(); err != nil {
-		blog.Errorf("close read excel io failed, err: %v, rid: %s", err, i.GetKit().Rid)
-		return nil, false, err
+	return result, nil
+}
+
+// instSource the source of the imported instances
+type instSource interface {
+	// propertyMap returns the properties of the instances, the key is the position of the property in a row
+	propertyMap() map[int]PropWithTable
+	// next move to the next instance, returns false if there is no more instance
+	next() bool
+	// row returns the row number of the current instance, which is used in the error messages
+	row() int
+	// rows returns the rows of the current instance, an instance has multiple rows if it has a table field
+	rows() ([][]string, error)
+	// count returns the number of the instances
+	count() (int, error)
+	// hasAsst returns if there is association data besides the instances
+	hasAsst() (bool, error)
+	close() error
+}
+
+func (i *Importer) newInstSource() (instSource, error) {
+	if i.record != nil {
+		return i.newRecordInstSource()
+	}
+
+	reader, err := i.GetExcel().NewReader(i.GetObjID())
+	if err != nil {
+		blog.Errorf("create excel io reader failed, sheet: %s, err: %v, rid: %s", i.GetObjID(), err, i.GetKit().Rid)
+		return nil, err
+	}
+	excelMsg, err := i.getExcelMsg(reader)
+	if err != nil {
+		blog.Errorf("get object excel message failed, err: %v, rid: %s", err, i.GetKit().Rid)
+		return nil, err
 	}
 
-	return result, len(errMsg) > 0, nil
+	return &excelInstSource{importer: i, reader: reader, excelMsg: excelMsg}, nil
+}
+
+// excelInstSource the instances in the object sheet of the imported excel
+type excelInstSource struct {
+	importer *Importer
+	reader   *excel.Reader
+	excelMsg *ExcelMsg
+}
+
+func (e *excelInstSource) propertyMap() map[int]PropWithTable {
+	return e.excelMsg.propertyMap
+}
+
+func (e *excelInstSource) next() bool {
+	for e.reader.Next() {
+		// skip excel header
+		if e.reader.GetCurIdx() < core.InstRowIdx-1 {
+			continue
+		}
+
+		return true
+	}
+
+	return false
+}
+
+func (e *excelInstSource) row() int {
+	return e.reader.GetCurIdx() + 1
+}
+
+func (e *excelInstSource) rows() ([][]string, error) {
+	rows := make([][]string, 0)
+	row, err := e.reader.CurRow()
+	if err != nil {
+		return nil, err
+	}
+	rows = append(rows, row)
+
+	endRow, ok := e.excelMsg.mergeRowRes[e.reader.GetCurIdx()]
+	if ok {
+		for endRow != e.reader.GetCurIdx() && e.reader.Next() {
+			row, err := e.reader.CurRow()
+			if err != nil {
+				return nil, err
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	return rows, nil
+}
+
+func (e *excelInstSource) count() (int, error) {
+	reader, err := e.importer.GetExcel().NewReader(e.importer.GetObjID())
+	if err != nil {
+		return 0, err
+	}
+	var instCount int
+	for reader.Next() {
+		instCount++
+	}
+
+	// 实例数需要减去excel表头占用的行数
+	instCount -= core.InstHeaderLen
+
+	// 如果存在合并多行作为一个实例，那么需要将这些多出来的行数减掉
+	for start, end := range e.excelMsg.mergeRowRes {
+		instCount -= end - start
+	}
+
+	return instCount, nil
+}
+
+func (e *excelInstSource) hasAsst() (bool, error) {
+	return e.importer.isAsstExist()
+}
+
+func (e *excelInstSource) close() error {
+	return e.reader.Close()
 }
 
 func (i *Importer) getExcelMsg(reader *excel.Reader) (*ExcelMsg, error) {
@@ -418,21 +579,10 @@ func (i *Importer) getExcelMsg(reader *excel.Reader) (*ExcelMsg, error) {
 }
 
 func (i *Importer) getPropertyMap(reader *excel.Reader) (map[int]PropWithTable, error) {
-
-	cond := mapstr.MapStr{
-		common.BKObjIDField: i.GetObjID(),
-		common.BKAppIDField: i.param.GetBizID(),
-	}
-	colProps, err := i.GetClient().GetObjColProp(i.GetKit(), cond)
+	colProps, err := i.getColProps()
 	if err != nil {
-		blog.Errorf("get property failed, err: %v, rid: %s", err, i.GetKit().Rid)
 		return nil, err
 	}
-	handleType := i.param.GetHandleType()
-	if handleType == core.UpdateHost || handleType == core.AddInst {
-		lang := i.GetLang().CreateDefaultCCLanguageIf(httpheader.GetLanguage(i.GetKit().Header))
-		colProps = append(colProps, core.GetIDProp(core.PropDefaultColIdx, i.GetObjID(), lang))
-	}
 
 	propMap := make(map[string]core.ColProp)
 	for _, prop := range colProps {
@@ -460,6 +610,26 @@ func (i *Importer) getPropertyMap(reader *excel.Reader) (map[int]PropWithTable,
 	return i.buildPropWithTable(propMap, idRow, tableIDRow)
 }
 
+// getColProps get the properties of the imported instances, including the id property which is used to update them
+func (i *Importer) getColProps() ([]core.ColProp, error) {
+	cond := mapstr.MapStr{
+		common.BKObjIDField: i.GetObjID(),
+		common.BKAppIDField: i.param.GetBizID(),
+	}
+	colProps, err := i.GetClient().GetObjColProp(i.GetKit(), cond)
+	if err != nil {
+		blog.Errorf("get property failed, err: %v, rid: %s", err, i.GetKit().Rid)
+		return nil, err
+	}
+	handleType := i.param.GetHandleType()
+	if handleType == core.UpdateHost || handleType == core.AddInst {
+		lang := i.GetLang().CreateDefaultCCLanguageIf(httpheader.GetLanguage(i.GetKit().Header))
+		colProps = append(colProps, core.GetIDProp(core.PropDefaultColIdx, i.GetObjID(), lang))
+	}
+
+	return colProps, nil
+}
+
 func (i *Importer) buildPropWithTable(propMap map[string]core.ColProp, idRow []string, tableIDRow []string) (
 	map[int]PropWithTable, error) {
 
@@ -564,28 +734,17 @@ func (i *Importer) getMergeRowRes() (map[int]int, error) {
 	return result, nil
 }
 
-func (i *Importer) getNextInst(reader *excel.Reader, excelMsg *ExcelMsg) (map[string]interface{}, error) {
-	rows := make([][]string, 0)
-	row, err := reader.CurRow()
+// getNextInst get the next instance from the source, the fields whose values can not be parsed are returned with
+// the errors, the key of them is the property id
+func (i *Importer) getNextInst(src instSource) (map[string]interface{}, map[string]error, error) {
+	rows, err := src.rows()
 	if err != nil {
-		blog.Errorf("read data from excel failed, err: %v, rid: %s", err, i.GetKit().Rid)
-		return nil, err
-	}
-	rows = append(rows, row)
-
-	endRow, ok := excelMsg.mergeRowRes[reader.GetCurIdx()]
-	if ok {
-		for endRow != reader.GetCurIdx() && reader.Next() {
-			row, err := reader.CurRow()
-			if err != nil {
-				blog.Errorf("read data from excel failed, err: %v, rid: %s", err, i.GetKit().Rid)
-				return nil, err
-			}
-			rows = append(rows, row)
-		}
+		blog.Errorf("read instance data failed, err: %v, rid: %s", err, i.GetKit().Rid)
+		return nil, nil, err
 	}
 
 	inst := make(map[string]interface{})
+	fieldErrs := make(map[string]error)
 	hasInst := false
 	for idx, val := range rows[0] {
 		if val == "" {
@@ -593,26 +752,32 @@ func (i *Importer) getNextInst(reader *excel.Reader, excelMsg *ExcelMsg) (map[st
 		}
 		hasInst = true
 
-		prop, ok := excelMsg.propertyMap[idx]
+		prop, ok := src.propertyMap()[idx]
 		if !ok {
 			continue
 		}
 
+		// computed field is calculated by the server, its value in excel is ignored
+		if prop.PropertyType == common.FieldTypeComputed {
+			continue
+		}
+
 		handleFunc := getHandleInstFieldFunc(&prop)
 
 		value, err := handleFunc(i, &prop, rows)
 		if err != nil {
 			blog.ErrorJSON("handle instance failed, property: %s, data: %s, err: %s, rid: %s", prop, rows, err,
 				i.GetKit().Rid)
+			fieldErrs[prop.ID] = err
 		}
 		inst[prop.ID] = value
 	}
 
 	if !hasInst {
-		return nil, nil
+		return nil, nil, nil
 	}
 
-	return inst, nil
+	return inst, fieldErrs, nil
 }
 
 func (i *Importer) doSpecialOp(insts map[int]map[string]interface{}) (map[int]map[string]interface{}, []string) {
@@ -889,32 +1054,48 @@ func (i *Importer) getCheckHostRes(hosts map[int]map[string]interface{}) (*check
 }
 
 func (i *Importer) importAssociation() (mapstr.MapStr, error) {
-	exist, err := i.GetExcel().IsSheetExist(core.AsstSheet)
+	result := mapstr.New()
+	input, _, err := i.getImportedAsst()
 	if err != nil {
 		return nil, err
 	}
 
-	result := mapstr.New()
-	if !exist {
+	if input == nil {
 		return result, nil
 	}
 
-	asstInfo, err := i.getAsstFromExcel()
+	asstResp, err := i.GetClient().ImportAssociation(i.GetKit(), i.GetObjID(), input)
 	if err != nil {
-		blog.Errorf("get association info from excel failed, err: %v, rid: %s", err, i.GetKit().Rid)
+		blog.Errorf("import association failed, input: %v, err: %v, rid: %s", input, err, i.GetKit().Rid)
 		return nil, err
 	}
+
+	if len(asstResp.ErrMsgMap) != 0 {
+		result["error"] = asstResp.ErrMsgMap
+	}
+
+	return result, nil
+}
+
+// getImportedAsst get the associations that need to be imported from excel and the errors of the invalid rows of
+// the association sheet, the returned associations is nil if there is nothing to import
+func (i *Importer) getImportedAsst() (*metadata.RequestImportAssociation, []metadata.RowMsgData, error) {
+	asstInfo, err := i.readAsst()
+	if err != nil {
+		blog.Errorf("get association info from excel failed, err: %v, rid: %s", err, i.GetKit().Rid)
+		return nil, nil, err
+	}
 	asstObjUniqueIDMap := i.param.GetAsstObjUniqueIDMap()
 
 	if asstInfo == nil || asstObjUniqueIDMap == nil {
-		return result, nil
+		return nil, nil, nil
 	}
 
 	// 将不需要导入的关联关系数据过滤出来
 	associations, err := i.GetClient().FindAsstByAsstID(i.GetKit(), i.GetObjID(), asstInfo.asstIDs)
 	if err != nil {
 		blog.Errorf("find model association by bk_obj_asst_id failed, err: %v, rid: %s", err, i.GetKit().Rid)
-		return nil, err
+		return nil, nil, err
 	}
 
 	skipAsstID := make(map[string]struct{})
@@ -939,7 +1120,7 @@ func (i *Importer) importAssociation() (mapstr.MapStr, error) {
 		importedAsst[idx] = asst
 	}
 	if len(importedAsst) == 0 {
-		return result, nil
+		return nil, asstInfo.errMsg, nil
 	}
 
 	// 导入指定的关联关系
@@ -949,15 +1130,5 @@ func (i *Importer) importAssociation() (mapstr.MapStr, error) {
 		ObjectUniqueID:        i.param.GetObjUniqueID(),
 	}
 
-	asstResp, err := i.GetClient().ImportAssociation(i.GetKit(), i.GetObjID(), input)
-	if err != nil {
-		blog.Errorf("import association failed, input: %v, err: %v, rid: %s", input, err, i.GetKit().Rid)
-		return nil, err
-	}
-
-	if len(asstResp.ErrMsgMap) != 0 {
-		result["error"] = asstResp.ErrMsgMap
-	}
-
-	return result, nil
+	return input, asstInfo.errMsg, nil
 }
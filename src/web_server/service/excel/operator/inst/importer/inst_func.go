@@ -1,6 +1,7 @@
 package importer
 
 import (
+	"encoding/json"
 	"fmt"
 	"regexp"
 	"strconv"
@@ -38,6 +39,7 @@ func init() {
 	handleInstFieldFuncMap[common.FieldTypeOrganization] = getHandleOrgFieldFunc()
 	handleInstFieldFuncMap[common.FieldTypeUser] = getHandleUserFieldFunc()
 	handleInstFieldFuncMap[common.FieldTypeInnerTable] = getHandleTableFieldFunc()
+	handleInstFieldFuncMap[common.FieldTypeJSON] = getHandleJSONFieldFunc()
 
 	handleSpecialFieldFuncMap[common.BKCloudIDField] = getCloudAreaFieldFunc()
 }
@@ -115,6 +117,27 @@ func getHandleBoolFieldFunc() handleInstFieldFunc {
 	}
 }
 
+func getHandleJSONFieldFunc() handleInstFieldFunc {
+	return func(i *Importer, property *PropWithTable, rows [][]string) (interface{}, error) {
+		if len(rows) == 0 || len(rows[0]) < property.ExcelColIndex {
+			blog.Errorf("instance is invalid, data: %v, rid: %s", rows, i.GetKit().Rid)
+			return nil, fmt.Errorf("instance is invalid")
+		}
+
+		val := rows[0][property.ExcelColIndex]
+		decoder := json.NewDecoder(strings.NewReader(val))
+		decoder.UseNumber()
+		var jsonVal interface{}
+		if err := decoder.Decode(&jsonVal); err != nil {
+			blog.Errorf("failed to convert string to json value, val: %v, err: %v, rid: %s", val, err,
+				i.GetKit().Rid)
+			return nil, err
+		}
+
+		return jsonVal, nil
+	}
+}
+
 func getHandleEnumFieldFunc() handleInstFieldFunc {
 	return func(i *Importer, property *PropWithTable, rows [][]string) (interface{}, error) {
 		if len(rows) == 0 || len(rows[0]) < property.ExcelColIndex {
@@ -99,6 +99,31 @@ func (i *InstParam) GetBizID() int64 {
 	return i.BizID
 }
 
+// BizParam import business parameter
+type BizParam struct {
+	BaseParam `json:",inline"`
+}
+
+// BuildParam get import businesses parameter
+func (b *BizParam) BuildParam(insts map[int]map[string]interface{}) (mapstr.MapStr, error) {
+	param := mapstr.MapStr{
+		"input_type": common.InputTypeExcel,
+		"BatchInfo":  insts,
+	}
+
+	return param, nil
+}
+
+// GetHandleType get handle type
+func (b *BizParam) GetHandleType() core.HandleType {
+	return core.AddBiz
+}
+
+// GetBizID get business id
+func (b *BizParam) GetBizID() int64 {
+	return 0
+}
+
 // AddHostParam import add host parameter
 type AddHostParam struct {
 	BaseParam `json:",inline"`
@@ -0,0 +1,384 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"configcenter/pkg/excel"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	httpheader "configcenter/src/common/http/header"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/web_server/service/excel/core"
+)
+
+// recordFile the imported csv or json lines file
+type recordFile struct {
+	format   excel.Format
+	filePath string
+	// isAsst the records of the file are associations instead of instances
+	isAsst bool
+}
+
+// RecordFile set the imported csv or json lines file of instances, the format is decided by the file extension
+func RecordFile(filePath string) BuildImporterFunc {
+	return func(importer *Importer) error {
+		var err error
+		importer.record, err = newRecordFile(filePath, false)
+		return err
+	}
+}
+
+// AsstRecordFile set the imported csv or json lines file of associations, the format is decided by the file
+// extension
+func AsstRecordFile(filePath string) BuildImporterFunc {
+	return func(importer *Importer) error {
+		var err error
+		importer.record, err = newRecordFile(filePath, true)
+		return err
+	}
+}
+
+func newRecordFile(filePath string, isAsst bool) (*recordFile, error) {
+	format := excel.Format(strings.TrimPrefix(filepath.Ext(filePath), "."))
+	if !excel.IsRecordFormat(format) {
+		return nil, fmt.Errorf("record format %s is not supported", format)
+	}
+
+	return &recordFile{format: format, filePath: filePath, isAsst: isAsst}, nil
+}
+
+func (r *recordFile) open() (*os.File, excel.RecordReader, error) {
+	file, err := os.Open(r.filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reader, err := excel.NewRecordReader(r.format, file)
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	return file, reader, nil
+}
+
+func (r *recordFile) clean() error {
+	return os.Remove(r.filePath)
+}
+
+// IsRecordFile returns if the imported file is a csv or json lines file
+func (i *Importer) IsRecordFile() bool {
+	return i.record != nil
+}
+
+// HandleAsst import the associations of the association record file
+func (i *Importer) HandleAsst() (mapstr.MapStr, error) {
+	if i.record == nil || !i.record.isAsst {
+		return nil, fmt.Errorf("the imported file is not an association record file")
+	}
+
+	return i.importAssociation()
+}
+
+func (i *Importer) newRecordInstSource() (*recordInstSource, error) {
+	if i.record.isAsst {
+		return nil, fmt.Errorf("the imported file is not an instance record file")
+	}
+
+	colProps, err := i.getColProps()
+	if err != nil {
+		return nil, err
+	}
+
+	file, reader, err := i.record.open()
+	if err != nil {
+		blog.Errorf("open record file failed, err: %v, rid: %s", err, i.GetKit().Rid)
+		return nil, err
+	}
+
+	src := &recordInstSource{
+		importer:  i,
+		file:      file,
+		reader:    reader,
+		propMap:   make(map[int]PropWithTable),
+		propIDIdx: make(map[string]int),
+	}
+
+	// records have no header of excel, so the properties are laid out in a row in turn, and the sub properties of
+	// a table field take the positions starting from the position of the table field
+	idx := 0
+	for _, prop := range colProps {
+		if _, exists := src.propIDIdx[prop.ID]; exists {
+			continue
+		}
+		prop.ExcelColIndex = idx
+		src.propIDIdx[prop.ID] = idx
+
+		if prop.PropertyType != common.FieldTypeInnerTable {
+			src.propMap[idx] = PropWithTable{ColProp: prop}
+			idx++
+			continue
+		}
+
+		option, err := metadata.ParseTableAttrOption(prop.Option)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+
+		subProperties := make(map[int]PropWithTable, len(option.Header))
+		for _, attr := range option.Header {
+			subProperties[idx] = PropWithTable{ColProp: core.ColProp{ID: attr.PropertyID, Name: attr.PropertyName,
+				PropertyType: attr.PropertyType, Option: attr.Option, IsRequire: attr.IsRequired,
+				Length: core.PropertyNormalLen, ExcelColIndex: idx}}
+			idx++
+		}
+
+		prop.Length = len(subProperties)
+		src.propMap[prop.ExcelColIndex] = PropWithTable{ColProp: prop, subProperties: subProperties}
+		if len(subProperties) == 0 {
+			idx++
+		}
+	}
+	src.width = idx
+
+	return src, nil
+}
+
+// recordInstSource the instances in the imported record file
+type recordInstSource struct {
+	importer  *Importer
+	file      *os.File
+	reader    excel.RecordReader
+	propMap   map[int]PropWithTable
+	propIDIdx map[string]int
+	width     int
+}
+
+func (r *recordInstSource) propertyMap() map[int]PropWithTable {
+	return r.propMap
+}
+
+func (r *recordInstSource) next() bool {
+	return r.reader.Next()
+}
+
+func (r *recordInstSource) row() int {
+	return r.reader.Row()
+}
+
+// rows convert the current record to rows like the rows of excel, the values of the array fields are joined by line
+// breaks like the excel cells, and each element of the table field takes a row
+func (r *recordInstSource) rows() ([][]string, error) {
+	rows := [][]string{make([]string, r.width)}
+	for key, val := range r.reader.Record() {
+		idx, ok := r.propIDIdx[key]
+		if !ok {
+			continue
+		}
+		prop := r.propMap[idx]
+
+		if prop.PropertyType == common.FieldTypeInnerTable {
+			var err error
+			rows, err = r.fillTableRows(rows, &prop, val)
+			if err != nil {
+				return nil, fmt.Errorf("%s is invalid, err: %v", key, err)
+			}
+			continue
+		}
+
+		cell, err := recordValueToCell(&prop, val)
+		if err != nil {
+			return nil, fmt.Errorf("%s is invalid, err: %v", key, err)
+		}
+		rows[0][idx] = cell
+	}
+
+	return rows, nil
+}
+
+func (r *recordInstSource) fillTableRows(rows [][]string, prop *PropWithTable, val interface{}) ([][]string,
+	error) {
+
+	// the table of csv record is a json array
+	if str, ok := val.(string); ok {
+		decoder := json.NewDecoder(strings.NewReader(str))
+		decoder.UseNumber()
+		if err := decoder.Decode(&val); err != nil {
+			return nil, err
+		}
+	}
+
+	table, ok := val.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("table value is not an array")
+	}
+
+	for len(rows) < len(table) {
+		rows = append(rows, make([]string, r.width))
+	}
+
+	for rowIdx, item := range table {
+		tableRow, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("table row is not an object")
+		}
+
+		for idx, subProp := range prop.subProperties {
+			subVal, exists := tableRow[subProp.ID]
+			if !exists {
+				continue
+			}
+
+			cell, err := recordValueToCell(&subProp, subVal)
+			if err != nil {
+				return nil, err
+			}
+			rows[rowIdx][idx] = cell
+		}
+	}
+
+	return rows, nil
+}
+
+// recordValueToCell convert a record value to the value of excel cell, so that it can be handled in the same way
+func recordValueToCell(prop *PropWithTable, val interface{}) (string, error) {
+	switch v := val.(type) {
+	case string:
+		return v, nil
+	case []interface{}:
+		if prop.PropertyType == common.FieldTypeJSON {
+			break
+		}
+
+		cells := make([]string, len(v))
+		for idx, item := range v {
+			cell, err := excel.RecordValueToString(item)
+			if err != nil {
+				return "", err
+			}
+			cells[idx] = cell
+		}
+		return strings.Join(cells, "\n"), nil
+	}
+
+	return excel.RecordValueToString(val)
+}
+
+func (r *recordInstSource) count() (int, error) {
+	file, reader, err := r.importer.record.open()
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	var instCount int
+	for reader.Next() {
+		instCount++
+	}
+
+	return instCount, reader.Err()
+}
+
+// hasAsst the associations are imported by the association record file
+func (r *recordInstSource) hasAsst() (bool, error) {
+	return false, nil
+}
+
+func (r *recordInstSource) close() error {
+	if err := r.reader.Err(); err != nil {
+		r.file.Close()
+		return err
+	}
+
+	return r.file.Close()
+}
+
+func (i *Importer) getAsstFromRecord() (*excelAsstInfo, error) {
+	file, reader, err := i.record.open()
+	if err != nil {
+		blog.Errorf("open record file failed, err: %v, rid: %s", err, i.GetKit().Rid)
+		return nil, err
+	}
+	defer file.Close()
+	lang := i.GetLang().CreateDefaultCCLanguageIf(httpheader.GetLanguage(i.GetKit().Header))
+
+	result := &excelAsstInfo{
+		asstIDs:        make([]string, 0),
+		statisticalMap: make(map[string]metadata.ObjectAsstIDStatisticsInfo),
+		asstInfoMap:    make(map[int]metadata.ExcelAssociation),
+		errMsg:         make([]metadata.RowMsgData, 0),
+	}
+
+	for reader.Next() {
+		idx := reader.Row()
+		record := reader.Record()
+
+		values := make(map[string]string, len(core.AsstRecordKeys))
+		for _, key := range core.AsstRecordKeys {
+			val, err := excel.RecordValueToString(record[key])
+			if err != nil || val == "" {
+				break
+			}
+			values[key] = val
+		}
+
+		if len(values) != len(core.AsstRecordKeys) {
+			msg := lang.Languagef("web_excel_row_handle_error", core.AsstSheet, idx)
+			result.errMsg = append(result.errMsg, metadata.RowMsgData{Row: idx, Msg: msg})
+			continue
+		}
+
+		asstID := values[core.AsstRecordIDKey]
+		statisticalInfo, ok := result.statisticalMap[asstID]
+		if !ok {
+			result.asstIDs = append(result.asstIDs, asstID)
+			statisticalInfo = metadata.ObjectAsstIDStatisticsInfo{}
+		}
+
+		operate := core.GetAsstOpFlag(core.AsstOp(values[core.AsstRecordOPKey]))
+		switch operate {
+		case metadata.ExcelAssociationOperateDelete:
+			statisticalInfo.Delete += 1
+		case metadata.ExcelAssociationOperateAdd:
+			statisticalInfo.Create += 1
+		}
+
+		result.statisticalMap[asstID] = statisticalInfo
+
+		result.asstInfoMap[idx] = metadata.ExcelAssociation{
+			ObjectAsstID: asstID,
+			Operate:      operate,
+			SrcPrimary:   values[core.AsstRecordSrcInstKey],
+			DstPrimary:   values[core.AsstRecordDstInstKey],
+		}
+	}
+
+	if err := reader.Err(); err != nil {
+		blog.Errorf("read association records failed, err: %v, rid: %s", err, i.GetKit().Rid)
+		return nil, err
+	}
+
+	return result, nil
+}
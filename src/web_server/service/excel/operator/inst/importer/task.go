@@ -0,0 +1,215 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package importer
+
+import (
+	"sort"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	httpheader "configcenter/src/common/http/header"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/web_server/service/excel/core"
+)
+
+// checkedFieldTypes the types of the fields that can be validated without querying other resources, the other
+// fields are validated by the server when they are imported
+var checkedFieldTypes = map[string]struct{}{
+	common.FieldTypeSingleChar: {},
+	common.FieldTypeLongChar:   {},
+	common.FieldTypeInt:        {},
+	common.FieldTypeFloat:      {},
+	common.FieldTypeEnum:       {},
+	common.FieldTypeEnumMulti:  {},
+	common.FieldTypeDate:       {},
+	common.FieldTypeTime:       {},
+	common.FieldTypeTimeZone:   {},
+	common.FieldTypeBool:       {},
+	common.FieldTypeList:       {},
+	common.FieldTypeIPAddr:     {},
+	common.FieldTypeCIDR:       {},
+	common.FieldTypeURL:        {},
+	common.FieldTypeJSON:       {},
+}
+
+// checkInstFields check the fields of an instance read from excel, returns the errors of the invalid fields
+func (i *Importer) checkInstFields(row int, inst map[string]interface{}, fieldErrs map[string]error,
+	propertyMap map[int]PropWithTable) []metadata.ExcelImportRowError {
+
+	lang := i.GetLang().CreateDefaultCCLanguageIf(httpheader.GetLanguage(i.GetKit().Header))
+	// the fields that are not filled are not updated when updating hosts, so they are not required
+	checkRequired := i.param.GetHandleType() != core.UpdateHost
+
+	cols := make([]int, 0, len(propertyMap))
+	for col := range propertyMap {
+		cols = append(cols, col)
+	}
+	sort.Ints(cols)
+
+	rowErrs := make([]metadata.ExcelImportRowError, 0)
+	for _, col := range cols {
+		prop := propertyMap[col]
+		rowErr := metadata.ExcelImportRowError{Row: int64(row), PropertyID: prop.ID}
+
+		if err, exists := fieldErrs[prop.ID]; exists {
+			rowErr.Message = lang.Languagef("import_row_field_invalid", row, prop.Name, err.Error())
+			rowErrs = append(rowErrs, rowErr)
+			continue
+		}
+
+		if _, ok := checkedFieldTypes[prop.PropertyType]; !ok {
+			continue
+		}
+
+		val, exists := inst[prop.ID]
+		if !exists || val == nil {
+			if checkRequired && prop.IsRequire {
+				rowErr.Message = lang.Languagef("import_row_required_field_empty", row, prop.Name)
+				rowErrs = append(rowErrs, rowErr)
+			}
+			continue
+		}
+
+		attr := &metadata.Attribute{ObjectID: i.GetObjID(), PropertyID: prop.ID, PropertyName: prop.Name,
+			PropertyType: prop.PropertyType, Option: prop.Option, IsRequired: prop.IsRequire}
+		if rawErr := attr.Validate(i.GetKit().Ctx, val, prop.ID); rawErr.ErrCode != 0 {
+			errMsg := rawErr.ToCCError(i.GetKit().CCError).Error()
+			rowErr.Message = lang.Languagef("import_row_field_invalid", row, prop.Name, errMsg)
+			rowErrs = append(rowErrs, rowErr)
+		}
+	}
+
+	return rowErrs
+}
+
+// Validate check the instances and the associations in excel without importing them, returns the errors of rows
+func (i *Importer) Validate() ([]metadata.ExcelImportRowError, error) {
+	walkRes, err := i.walkInst(true, func(insts map[int]map[string]interface{}) ([]string, error) {
+		return nil, nil
+	})
+	if err != nil {
+		blog.Errorf("validate excel instances failed, err: %v, rid: %s", err, i.GetKit().Rid)
+		return nil, err
+	}
+
+	rowErrs := append(walkRes.fieldErrs, metadata.NewExcelImportRowErrors("", walkRes.errMsg)...)
+	if walkRes.preCheckFailed {
+		return rowErrs, nil
+	}
+
+	_, asstErrs, err := i.getImportedAsst()
+	if err != nil {
+		blog.Errorf("validate excel associations failed, err: %v, rid: %s", err, i.GetKit().Rid)
+		return nil, err
+	}
+	rowErrs = append(rowErrs, newAsstRowErrors(asstErrs)...)
+
+	sortRowErrors(rowErrs)
+	return rowErrs, nil
+}
+
+var handleTypeKindMap = map[core.HandleType]metadata.ExcelImportKind{
+	core.AddInst:    metadata.ExcelImportKindInst,
+	core.AddHost:    metadata.ExcelImportKindAddHost,
+	core.UpdateHost: metadata.ExcelImportKindUpdateHost,
+}
+
+// BuildTaskOption build the option to create the excel import task, each sub task imports a batch of instances, the
+// associations are imported by the last sub task if all the instances pass the pre-check. the rows that fail the
+// pre-check are not imported, their errors are returned and also attached to the task. the returned option is nil
+// if there is nothing to import
+func (i *Importer) BuildTaskOption() (*metadata.CreateExcelImportTaskOption, []metadata.ExcelImportRowError,
+	error) {
+
+	opt := &metadata.CreateExcelImportTaskOption{
+		Kind:  handleTypeKindMap[i.param.GetHandleType()],
+		ObjID: i.GetObjID(),
+		BizID: i.param.GetBizID(),
+		Tasks: make([]metadata.ExcelImportTaskData, 0),
+	}
+	if param, ok := i.param.(*AddHostParam); ok {
+		opt.ModuleID = param.ModuleID
+	}
+
+	walkRes, err := i.walkInst(true, func(insts map[int]map[string]interface{}) ([]string, error) {
+		params, err := i.param.BuildParam(insts)
+		if err != nil {
+			blog.Errorf("get import instances parameter failed, err: %v, rid: %s", err, i.GetKit().Rid)
+			return nil, err
+		}
+
+		task := metadata.ExcelImportTaskData{Kind: opt.Kind, ObjID: opt.ObjID, Params: params,
+			Rows: make([]int64, 0, len(insts))}
+		for _, row := range util.SortedMapIntKeys(insts) {
+			task.Rows = append(task.Rows, int64(row))
+		}
+		opt.Tasks = append(opt.Tasks, task)
+		return nil, nil
+	})
+	if err != nil {
+		blog.Errorf("build excel import tasks failed, err: %v, rid: %s", err, i.GetKit().Rid)
+		return nil, nil, err
+	}
+
+	rowErrs := append(walkRes.fieldErrs, metadata.NewExcelImportRowErrors("", walkRes.errMsg)...)
+	if walkRes.preCheckFailed {
+		return nil, rowErrs, nil
+	}
+
+	// keep the same as the synchronous import, associations are imported only when all instances are valid
+	if len(rowErrs) == 0 && len(i.param.GetAsstObjUniqueIDMap()) != 0 {
+		asst, asstErrs, err := i.getImportedAsst()
+		if err != nil {
+			blog.Errorf("get imported associations failed, err: %v, rid: %s", err, i.GetKit().Rid)
+			return nil, nil, err
+		}
+		rowErrs = append(rowErrs, newAsstRowErrors(asstErrs)...)
+
+		if asst != nil {
+			opt.Tasks = append(opt.Tasks, metadata.ExcelImportTaskData{Kind: metadata.ExcelImportKindAssociation,
+				ObjID: opt.ObjID, Association: asst})
+		}
+	}
+
+	sortRowErrors(rowErrs)
+	if len(opt.Tasks) == 0 {
+		return nil, rowErrs, nil
+	}
+
+	opt.Tasks[0].Errors = rowErrs
+	return opt, rowErrs, nil
+}
+
+func newAsstRowErrors(msgs []metadata.RowMsgData) []metadata.ExcelImportRowError {
+	rowErrs := make([]metadata.ExcelImportRowError, len(msgs))
+	for idx, msg := range msgs {
+		rowErrs[idx] = metadata.ExcelImportRowError{Sheet: metadata.ExcelAssociationSheet, Row: int64(msg.Row),
+			Message: msg.Msg}
+	}
+	return rowErrs
+}
+
+// sortRowErrors sort the row errors by sheet and row, the errors of the object sheet come first
+func sortRowErrors(rowErrs []metadata.ExcelImportRowError) {
+	sort.SliceStable(rowErrs, func(i, j int) bool {
+		if rowErrs[i].Sheet != rowErrs[j].Sheet {
+			return rowErrs[i].Sheet < rowErrs[j].Sheet
+		}
+		return rowErrs[i].Row < rowErrs[j].Row
+	})
+}
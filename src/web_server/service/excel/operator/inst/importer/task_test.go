@@ -0,0 +1,198 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package importer
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"configcenter/pkg/excel"
+	"configcenter/src/apimachinery/apiserver"
+	modelquote "configcenter/src/apimachinery/apiserver/model_quote"
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/language"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/web_server/service/excel/core"
+	"configcenter/src/web_server/service/excel/operator"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testObjID = "switch"
+
+// fakeAPIClient returns the attributes of the imported object, the other apis are not used in the tests
+type fakeAPIClient struct {
+	apiserver.ApiServerClientInterface
+	modelquote.Interface
+	attrs []metadata.Attribute
+}
+
+// ModelQuote returns the fake model quote client
+func (f *fakeAPIClient) ModelQuote() modelquote.Interface {
+	return f
+}
+
+// GetObjectAttrWithTable returns the attributes of the imported object
+func (f *fakeAPIClient) GetObjectAttrWithTable(context.Context, http.Header, mapstr.MapStr) ([]metadata.Attribute,
+	error) {
+	return f.attrs, nil
+}
+
+var testAttrs = []metadata.Attribute{
+	{ObjectID: testObjID, PropertyID: common.BKInstNameField, PropertyName: "name",
+		PropertyType: common.FieldTypeSingleChar, IsRequired: true},
+	{ObjectID: testObjID, PropertyID: "port", PropertyName: "port", PropertyType: common.FieldTypeInt,
+		Option: map[string]interface{}{"min": "1", "max": "65535"}},
+}
+
+// newTestImporter write the instance rows to the object sheet of a new excel, and create an importer of it
+func newTestImporter(t *testing.T, rows [][]string) *Importer {
+	filePath := filepath.Join(t.TempDir(), "import.xlsx")
+	file, err := excel.NewExcel(excel.FilePath(filePath), excel.OpenOrCreate())
+	require.NoError(t, err)
+	require.NoError(t, file.CreateSheet(testObjID))
+
+	// the name, type and id header rows of the properties, the table rows are empty
+	for col, attr := range testAttrs {
+		require.NoError(t, file.SetCellValue(testObjID, col, core.NameRowIdx, attr.PropertyName))
+		require.NoError(t, file.SetCellValue(testObjID, col, core.TypeRowIdx, attr.PropertyType))
+		require.NoError(t, file.SetCellValue(testObjID, col, core.IDRowIdx, attr.PropertyID))
+		require.NoError(t, file.SetCellValue(testObjID, col, core.TableIDRowIdx, ""))
+	}
+	for idx, row := range rows {
+		for col, val := range row {
+			require.NoError(t, file.SetCellValue(testObjID, col, core.InstRowIdx+idx, val))
+		}
+	}
+	require.NoError(t, file.Save())
+	require.NoError(t, file.Close())
+
+	kit := &rest.Kit{
+		Rid:             "test-rid",
+		Header:          make(http.Header),
+		Ctx:             context.Background(),
+		CCError:         errors.NewFromCtx(errors.EmptyErrorsSetting).CreateDefaultCCErrorIf("en"),
+		User:            "admin",
+		SupplierAccount: common.BKDefaultOwnerID,
+	}
+	client := &core.Client{ApiClient: &fakeAPIClient{attrs: testAttrs}}
+	lang := language.NewFromCtx(language.EmptyLanguageSetting)
+	baseOp, err := operator.NewBaseOp(operator.FilePath(filePath), operator.Client(client),
+		operator.ObjID(testObjID), operator.Kit(kit), operator.Language(lang))
+	require.NoError(t, err)
+
+	importer, err := NewImporter(BaseOperator(baseOp), Param(&InstParam{BizID: 0}))
+	require.NoError(t, err)
+	return importer
+}
+
+// testRows are the instance rows, the excel row number of the first one is 7
+var testRows = [][]string{
+	{"switch1", "80"},
+	{"switch2", "abc"},
+	{"", "443"},
+	{"switch4", "70000"},
+	{"switch5", "8080"},
+}
+
+func getRowErrorRows(rowErrs []metadata.ExcelImportRowError) []int64 {
+	rows := make([]int64, len(rowErrs))
+	for idx, rowErr := range rowErrs {
+		rows[idx] = rowErr.Row
+	}
+	return rows
+}
+
+func TestCheckInstFields(t *testing.T) {
+	importer := newTestImporter(t, nil)
+	propertyMap := map[int]PropWithTable{
+		0: {ColProp: core.ColProp{ID: common.BKInstNameField, Name: "name", PropertyType: common.FieldTypeSingleChar,
+			IsRequire: true}},
+		1: {ColProp: core.ColProp{ID: "port", Name: "port", PropertyType: common.FieldTypeInt,
+			Option: map[string]interface{}{"min": "1", "max": "65535"}}},
+		2: {ColProp: core.ColProp{ID: "owner", Name: "owner", PropertyType: common.FieldTypeUser}},
+	}
+
+	// the valid instance, the field that can not be checked locally is skipped
+	rowErrs := importer.checkInstFields(7, map[string]interface{}{common.BKInstNameField: "switch1", "port": int64(80),
+		"owner": 1}, nil, propertyMap)
+	require.Empty(t, rowErrs)
+
+	// the required field is empty and the int field exceeds the max value
+	rowErrs = importer.checkInstFields(8, map[string]interface{}{"port": int64(70000)}, nil, propertyMap)
+	require.Len(t, rowErrs, 2)
+	require.Equal(t, common.BKInstNameField, rowErrs[0].PropertyID)
+	require.Equal(t, "port", rowErrs[1].PropertyID)
+	require.Equal(t, []int64{8, 8}, getRowErrorRows(rowErrs))
+
+	// the field that can not be parsed from excel
+	rowErrs = importer.checkInstFields(9, map[string]interface{}{common.BKInstNameField: "switch3"},
+		map[string]error{"port": errors.New(common.CCErrCommParamsInvalid, "invalid syntax")}, propertyMap)
+	require.Len(t, rowErrs, 1)
+	require.Equal(t, "port", rowErrs[0].PropertyID)
+}
+
+func TestValidate(t *testing.T) {
+	importer := newTestImporter(t, testRows)
+
+	rowErrs, err := importer.Validate()
+	require.NoError(t, err)
+	require.Equal(t, []int64{8, 9, 10}, getRowErrorRows(rowErrs))
+	for _, rowErr := range rowErrs {
+		require.NotEmpty(t, rowErr.Message)
+	}
+
+	// the excel without instances fails the pre-check
+	importer = newTestImporter(t, nil)
+	rowErrs, err = importer.Validate()
+	require.NoError(t, err)
+	require.Len(t, rowErrs, 1)
+	require.Zero(t, rowErrs[0].Row)
+}
+
+func TestBuildTaskOption(t *testing.T) {
+	importer := newTestImporter(t, testRows)
+
+	opt, rowErrs, err := importer.BuildTaskOption()
+	require.NoError(t, err)
+	require.Equal(t, []int64{8, 9, 10}, getRowErrorRows(rowErrs))
+
+	// only the valid rows are imported, the errors are attached to the first sub task
+	require.NotNil(t, opt)
+	require.Equal(t, metadata.ExcelImportKindInst, opt.Kind)
+	require.Equal(t, testObjID, opt.ObjID)
+	require.Len(t, opt.Tasks, 1)
+	require.Equal(t, []int64{7, 11}, opt.Tasks[0].Rows)
+	require.Equal(t, rowErrs, opt.Tasks[0].Errors)
+
+	batchInfo, ok := opt.Tasks[0].Params["BatchInfo"].(map[int]map[string]interface{})
+	require.True(t, ok)
+	require.Equal(t, "switch1", batchInfo[7][common.BKInstNameField])
+	require.Equal(t, int64(8080), batchInfo[11]["port"])
+
+	// nothing to import if all rows are invalid
+	importer = newTestImporter(t, [][]string{{"", "80"}})
+	opt, rowErrs, err = importer.BuildTaskOption()
+	require.NoError(t, err)
+	require.Nil(t, opt)
+	require.Len(t, rowErrs, 1)
+}
@@ -0,0 +1,177 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 Tencent. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package excel
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	httpheader "configcenter/src/common/http/header"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/web_server/service/excel/core"
+	"configcenter/src/web_server/service/excel/operator"
+	"configcenter/src/web_server/service/excel/operator/inst/exporter"
+	"configcenter/src/web_server/service/excel/operator/inst/importer"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportInstAsst export the associations of the instances as csv or json lines records
+func (s *service) ExportInstAsst(c *gin.Context) {
+	objID := c.Param(common.BKObjIDField)
+	kit := rest.NewKitFromHeader(c.Request.Header, s.engine.CCErr)
+	input := exporter.GetExportParamInterface(objID)
+
+	if err := c.BindJSON(input); err != nil {
+		c.JSON(http.StatusOK, metadata.BaseResp{Code: common.CCErrCommJSONUnmarshalFailed, ErrMsg: err.Error()})
+		return
+	}
+	lang := s.engine.Language.CreateDefaultCCLanguageIf(httpheader.GetLanguage(kit.Header))
+	if err := input.Validate(kit, lang); err != nil {
+		c.JSON(http.StatusOK, getErrResp(kit, common.CCErrWebGetObjectFail, err.Error()))
+		return
+	}
+
+	// the associations are exported to the association sheet when the instances are exported to excel
+	if _, ok := recordFileTypes[FileType(input.GetFormat())]; !ok {
+		c.JSON(http.StatusOK, getErrResp(kit, common.CCErrCommParamsInvalid, "format"))
+		return
+	}
+
+	s.exportRecordFunc(c, kit, objID, input, true)
+}
+
+// exportRecordFunc export the instances or their associations as csv or json lines records, the records are written
+// to the response page by page
+func (s *service) exportRecordFunc(c *gin.Context, kit *rest.Kit, objID string, input exporter.ExportParamI,
+	isAsst bool) {
+
+	client := &core.Client{ApiClient: s.apiCli, GinCtx: c}
+	baseOp, err := operator.NewBaseOp(operator.Client(client), operator.ObjID(objID), operator.Kit(kit),
+		operator.Language(s.engine.Language))
+	if err != nil {
+		blog.Errorf("create base operator failed, err: %v, rid: %s", err, kit.Rid)
+		c.JSON(http.StatusOK, getErrResp(kit, common.CCErrWebGetObjectFail, err.Error()))
+		return
+	}
+
+	tmplOp, err := exporter.NewTmplOp(exporter.BaseOperator(baseOp))
+	if err != nil {
+		blog.Errorf("create template operator failed, err: %v, rid: %s", err, kit.Rid)
+		c.JSON(http.StatusOK, getErrResp(kit, common.CCErrWebGetObjectFail, err.Error()))
+		return
+	}
+
+	op, err := exporter.NewExporter(exporter.TmplOperator(tmplOp), exporter.ExportParam(input))
+	if err != nil {
+		blog.Errorf("create exporter failed, err: %v, rid: %s", err, kit.Rid)
+		c.String(http.StatusInternalServerError, fmt.Errorf("create exporter failed, err: %+v", err).Error())
+		return
+	}
+
+	fileType := FileType(input.GetFormat())
+	fileName := getExportFileName(objID, fileType)
+	exportFunc := op.ExportRecords
+	if isAsst {
+		fileName = fmt.Sprintf("bk_cmdb_export_inst_association_%s.%s", objID, fileType)
+		exportFunc = op.ExportAsstRecords
+	}
+
+	writer := &recordRespWriter{ctx: c, fileName: fileName, fileType: fileType}
+	if err := exportFunc(writer); err != nil {
+		blog.Errorf("export records failed, objID: %s, err: %v, rid: %s", objID, err, kit.Rid)
+		// the response status can not be changed if some records have been written, the exporter has written a
+		// trailing error record to the file, and the error is also sent in the trailer of the response
+		if !writer.written {
+			c.String(http.StatusInternalServerError, fmt.Errorf("export records failed, err: %+v", err).Error())
+			return
+		}
+		c.Writer.Header().Set(exportErrorTrailer, strings.ReplaceAll(err.Error(), "\n", " "))
+		return
+	}
+
+	// no record is written, the headers of the downloaded file still need to be set
+	if !writer.written {
+		addDownRecordHttpHeader(c, fileName, fileType)
+		c.Status(http.StatusOK)
+	}
+}
+
+// recordRespWriter write the exported records to the response, the headers of the downloaded file are set when the
+// first record is written, so that the error response can be returned if the export fails before that
+type recordRespWriter struct {
+	ctx      *gin.Context
+	fileName string
+	fileType FileType
+	written  bool
+}
+
+// Write write data to the response
+func (r *recordRespWriter) Write(p []byte) (int, error) {
+	if !r.written {
+		addDownRecordHttpHeader(r.ctx, r.fileName, r.fileType)
+		r.ctx.Status(http.StatusOK)
+		r.written = true
+	}
+
+	return r.ctx.Writer.Write(p)
+}
+
+// exportErrorTrailer is the http trailer that contains the error when the export fails after some records have been
+// written, it is empty if the export succeeds
+const exportErrorTrailer = "X-Bkcmdb-Export-Error"
+
+func addDownRecordHttpHeader(c *gin.Context, name string, fileType FileType) {
+	if fileType == FileTypeCSV {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+	} else {
+		c.Header("Content-Type", "application/x-ndjson; charset=utf-8")
+	}
+	c.Header("Content-Disposition", "attachment; filename="+name)
+	c.Header("Cache-Control", "must-revalidate, post-check=0, pre-check=0")
+	c.Header("Pragma", "no-cache")
+	c.Header("Expires", "0")
+	c.Header("Trailer", exportErrorTrailer)
+}
+
+// ImportInstAsst import the associations of the instances from csv or json lines records
+func (s *service) ImportInstAsst(c *gin.Context) {
+	objID := c.Param(common.BKObjIDField)
+	kit := rest.NewKitFromHeader(c.Request.Header, s.engine.CCErr)
+	op := s.newImporter(c, kit, objID, ImportTypeInstAsst, &importer.InstParam{})
+	if op == nil {
+		return
+	}
+
+	result, err := op.HandleAsst()
+	if err != nil {
+		blog.Errorf("handle association import request failed, err: %v, rid: %s", err, kit.Rid)
+		c.String(http.StatusInternalServerError, fmt.Errorf("handle import request failed, err: %+v", err).Error())
+		return
+	}
+	if err := op.Clean(); err != nil {
+		blog.Errorf("clean importer resource failed, err: %v, rid: %s", err, kit.Rid)
+		c.String(http.StatusInternalServerError, fmt.Errorf("clean importer resource failed, err: %+v", err).Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, metadata.NewSuccessResp(result))
+}
@@ -54,8 +54,18 @@ const (
 	FileTypeZip FileType = "zip"
 	// FileTypeYaml 文件格式yaml
 	FileTypeYaml FileType = "yaml"
+	// FileTypeCSV 文件格式csv
+	FileTypeCSV FileType = "csv"
+	// FileTypeJSONL 文件格式json lines
+	FileTypeJSONL FileType = "jsonl"
 )
 
+// recordFileTypes 以记录的形式导入导出实例的文件格式
+var recordFileTypes = map[FileType]struct{}{
+	FileTypeCSV:   {},
+	FileTypeJSONL: {},
+}
+
 // ImportType 导入类型
 type ImportType string
 
@@ -68,14 +78,17 @@ const (
 	ImportTypeObjectYaml ImportType = "importObjectYaml"
 	// ImportTypeObjectAttr 导入类型为导入模型属性字段
 	ImportTypeObjectAttr ImportType = "importObjectAttr"
+	// ImportTypeInstAsst 导入类型为导入实例关联关系
+	ImportTypeInstAsst ImportType = "importInstAsst"
 )
 
 // ImportTypeMap 导入类型与文件类型对应关系map
 var ImportTypeMap = map[ImportType][]FileType{
-	ImportTypeInst:       {FileTypeXlsx, FileTypeXls},
+	ImportTypeInst:       {FileTypeXlsx, FileTypeXls, FileTypeCSV, FileTypeJSONL},
 	ImportTypeObjectAttr: {FileTypeXlsx, FileTypeXls},
 	ImportTypeObject:     {FileTypeZip},
 	ImportTypeObjectYaml: {FileTypeYaml},
+	ImportTypeInstAsst:   {FileTypeCSV, FileTypeJSONL},
 }
 
 // BuildTemplate build excel download template
@@ -173,6 +186,11 @@ func (s *service) exportInstFunc(c *gin.Context, objID string) {
 		return
 	}
 
+	if _, ok := recordFileTypes[FileType(input.GetFormat())]; ok {
+		s.exportRecordFunc(c, kit, objID, input, false)
+		return
+	}
+
 	// 1. 初始化导出excel对象
 	dir := fmt.Sprintf("%s/export", webCommon.ResourcePath)
 	filePath := fmt.Sprintf("%s/%s", dir, fmt.Sprintf("%dinst.xlsx", time.Now().UnixNano()))
@@ -214,16 +232,7 @@ func (s *service) exportInstFunc(c *gin.Context, objID string) {
 	}
 
 	// 3. 将excel文件返回，并删除临时文件
-	switch objID {
-	case common.BKInnerObjIDHost:
-		addDownExcelHttpHeader(c, "bk_cmdb_export_host.xlsx")
-	case common.BKInnerObjIDApp:
-		addDownExcelHttpHeader(c, "bk_cmdb_export_biz.xlsx")
-	case common.BKInnerObjIDProject:
-		addDownExcelHttpHeader(c, "bk_cmdb_export_project.xlsx")
-	default:
-		addDownExcelHttpHeader(c, fmt.Sprintf("bk_cmdb_export_inst_%s.xlsx", objID))
-	}
+	addDownExcelHttpHeader(c, getExportFileName(objID, FileTypeXlsx))
 
 	c.File(filePath)
 
@@ -234,6 +243,20 @@ func (s *service) exportInstFunc(c *gin.Context, objID string) {
 	}
 }
 
+// getExportFileName get the name of the exported instance file
+func getExportFileName(objID string, fileType FileType) string {
+	switch objID {
+	case common.BKInnerObjIDHost:
+		return fmt.Sprintf("bk_cmdb_export_host.%s", fileType)
+	case common.BKInnerObjIDApp:
+		return fmt.Sprintf("bk_cmdb_export_biz.%s", fileType)
+	case common.BKInnerObjIDProject:
+		return fmt.Sprintf("bk_cmdb_export_project.%s", fileType)
+	default:
+		return fmt.Sprintf("bk_cmdb_export_inst_%s.%s", objID, fileType)
+	}
+}
+
 const param = "params"
 
 // AddInst add instance
@@ -252,16 +275,39 @@ func (s *service) UpdateHost(c *gin.Context) {
 	s.importInstFunc(c, common.BKInnerObjIDHost, core.UpdateHost)
 }
 
+// ImportBiz add or update businesses, the businesses with the same name are updated
+func (s *service) ImportBiz(c *gin.Context) {
+	s.importInstFunc(c, common.BKInnerObjIDApp, core.AddBiz)
+}
+
 // importInstFunc import instance function
 func (s *service) importInstFunc(c *gin.Context, objID string, handleType core.HandleType) {
 	kit := rest.NewKitFromHeader(c.Request.Header, s.engine.CCErr)
-	params := c.PostForm(param)
-	if params == "" {
-		blog.Errorf("not found params value, rid: %s", kit.Rid)
-		c.JSON(http.StatusOK, getErrResp(kit, common.CCErrCommParamsNeedSet, param))
+	op := s.newInstImporter(c, kit, objID, handleType)
+	if op == nil {
 		return
 	}
 
+	result, err := op.Handle()
+	if err != nil {
+		blog.Errorf("handle excel import request failed, err: %v, rid: %s", err, kit.Rid)
+		c.String(http.StatusInternalServerError, fmt.Errorf("handle import request failed, err: %+v", err).Error())
+		return
+	}
+	if err := op.Clean(); err != nil {
+		blog.Errorf("clean importer resource failed, err: %v, rid: %s", err, kit.Rid)
+		c.String(http.StatusInternalServerError, fmt.Errorf("clean importer resource failed, err: %+v", err).Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, metadata.NewSuccessResp(result))
+}
+
+// newInstImporter save the uploaded excel, csv or json lines file and create the instance importer of it, returns
+// nil if failed, in which case the error response has been written
+func (s *service) newInstImporter(c *gin.Context, kit *rest.Kit, objID string,
+	handleType core.HandleType) *importer.Importer {
+
 	var input importer.ImportParamI
 	switch handleType {
 	case core.AddHost:
@@ -270,24 +316,41 @@ func (s *service) importInstFunc(c *gin.Context, objID string, handleType core.H
 		input = &importer.UpdateHostParam{}
 	case core.AddInst:
 		input = &importer.InstParam{}
+	case core.AddBiz:
+		input = &importer.BizParam{}
+	}
+
+	return s.newImporter(c, kit, objID, ImportTypeInst, input)
+}
+
+// newImporter save the uploaded file of the import type and create the importer of it, returns nil if failed, in
+// which case the error response has been written
+func (s *service) newImporter(c *gin.Context, kit *rest.Kit, objID string, importType ImportType,
+	input importer.ImportParamI) *importer.Importer {
+
+	params := c.PostForm(param)
+	if params == "" {
+		blog.Errorf("not found params value, rid: %s", kit.Rid)
+		c.JSON(http.StatusOK, getErrResp(kit, common.CCErrCommParamsNeedSet, param))
+		return nil
 	}
 
 	if err := json.Unmarshal([]byte(params), input); err != nil {
 		blog.Errorf("params unmarshal error, err: %v, rid: %s", err, kit.Rid)
 		c.JSON(http.StatusOK, getErrResp(kit, common.CCErrCommParamsValueInvalidError, params, err.Error()))
-		return
+		return nil
 	}
 
 	file, err := c.FormFile("file")
 	if err != nil {
 		blog.Errorf("get file failed, err: %v, rid: %s", err, kit.Rid)
 		c.JSON(http.StatusOK, getErrResp(kit, common.CCErrWebFileNoFound))
-		return
+		return nil
 	}
-	if err := VerifyFileType(ImportTypeInst, file.Filename, kit.Rid); err != nil {
+	if err := VerifyFileType(importType, file.Filename, kit.Rid); err != nil {
 		blog.Errorf("file type verify failed, err: %v, fileName: %s, rid: %s", err, file.Filename, kit.Rid)
 		c.JSON(http.StatusOK, getErrResp(kit, common.CCErrInvalidFileTypeFail, err.Error()))
-		return
+		return nil
 	}
 
 	dir := webCommon.ResourcePath + "/import/"
@@ -298,42 +361,47 @@ func (s *service) importInstFunc(c *gin.Context, objID string, handleType core.H
 		}
 	}
 
-	filePath := fmt.Sprintf("%s/importinsts-%d-%d.xlsx", dir, time.Now().UnixNano(), rand.Uint32())
+	fileType := FileType(strings.TrimPrefix(filepath.Ext(file.Filename), "."))
+	_, isRecord := recordFileTypes[fileType]
+	if !isRecord {
+		fileType = FileTypeXlsx
+	}
+
+	filePath := fmt.Sprintf("%s/importinsts-%d-%d.%s", dir, time.Now().UnixNano(), rand.Uint32(), fileType)
 	err = c.SaveUploadedFile(file, filePath)
 	if err != nil {
 		c.JSON(http.StatusOK, getErrResp(kit, common.CCErrWebFileSaveFail))
-		return
+		return nil
 	}
 
 	client := &core.Client{ApiClient: s.apiCli}
-	baseOp, err := operator.NewBaseOp(operator.FilePath(filePath), operator.Client(client), operator.ObjID(objID),
-		operator.Kit(kit), operator.Language(s.engine.Language))
-	if err != nil {
-		blog.Errorf("create importer failed, err: %v, rid: %s", err, kit.Rid)
-		c.String(http.StatusInternalServerError, fmt.Errorf("create importer failed, err: %+v", err).Error())
-		return
+	baseOpts := []operator.BuildOpFunc{operator.Client(client), operator.ObjID(objID), operator.Kit(kit),
+		operator.Language(s.engine.Language)}
+	importerOpts := []importer.BuildImporterFunc{importer.Param(input)}
+	switch {
+	case importType == ImportTypeInstAsst:
+		importerOpts = append(importerOpts, importer.AsstRecordFile(filePath))
+	case isRecord:
+		importerOpts = append(importerOpts, importer.RecordFile(filePath))
+	default:
+		baseOpts = append(baseOpts, operator.FilePath(filePath))
 	}
 
-	op, err := importer.NewImporter(importer.BaseOperator(baseOp), importer.Param(input))
+	baseOp, err := operator.NewBaseOp(baseOpts...)
 	if err != nil {
 		blog.Errorf("create importer failed, err: %v, rid: %s", err, kit.Rid)
 		c.String(http.StatusInternalServerError, fmt.Errorf("create importer failed, err: %+v", err).Error())
-		return
+		return nil
 	}
 
-	result, err := op.Handle()
+	op, err := importer.NewImporter(append(importerOpts, importer.BaseOperator(baseOp))...)
 	if err != nil {
-		blog.Errorf("handle excel import request failed, err: %v, rid: %s", err, kit.Rid)
-		c.String(http.StatusInternalServerError, fmt.Errorf("handle import request failed, err: %+v", err).Error())
-		return
-	}
-	if err := op.Clean(); err != nil {
-		blog.Errorf("clean importer resource failed, err: %v, rid: %s", err, kit.Rid)
-		c.String(http.StatusInternalServerError, fmt.Errorf("clean importer resource failed, err: %+v", err).Error())
-		return
+		blog.Errorf("create importer failed, err: %v, rid: %s", err, kit.Rid)
+		c.String(http.StatusInternalServerError, fmt.Errorf("create importer failed, err: %+v", err).Error())
+		return nil
 	}
 
-	c.JSON(http.StatusOK, metadata.NewSuccessResp(result))
+	return op
 }
 
 // ExportObject export object
@@ -389,7 +457,9 @@ func (s *service) ExportObject(c *gin.Context) {
 	}
 }
 
-// ImportObject import object attribute
+// ImportObject import object attribute. unlike the instance and host imports, it is still executed synchronously
+// without the validation and error report apis, because the workbook has one row per attribute of the object, which
+// is small enough to be imported within the request
 func (s *service) ImportObject(c *gin.Context) {
 	kit := rest.NewKitFromHeader(c.Request.Header, s.engine.CCErr)
 	objID := c.Param(common.BKObjIDField)
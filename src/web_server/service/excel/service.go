@@ -49,11 +49,37 @@ func Init(c *capability.Capability) {
 
 	c.Ws.POST("/hosts/update", s.UpdateHost)
 
+	c.Ws.POST("/insts/object/:bk_obj_id/import/validate", s.ValidateInst)
+
+	c.Ws.POST("/hosts/import/validate", s.ValidateAddHost)
+
+	c.Ws.POST("/hosts/update/validate", s.ValidateUpdateHost)
+
+	c.Ws.POST("/insts/object/:bk_obj_id/import/task", s.CreateInstImportTask)
+
+	c.Ws.POST("/hosts/import/task", s.CreateAddHostImportTask)
+
+	c.Ws.POST("/hosts/update/task", s.CreateUpdateHostImportTask)
+
+	c.Ws.GET("/import/task/:task_id", s.FindImportTask)
+
+	c.Ws.POST("/insts/object/:bk_obj_id/import/error_report", s.InstImportErrorReport)
+
+	c.Ws.POST("/hosts/import/error_report", s.AddHostImportErrorReport)
+
+	c.Ws.POST("/hosts/update/error_report", s.UpdateHostImportErrorReport)
+
+	c.Ws.POST("/insts/object/:bk_obj_id/association/export", s.ExportInstAsst)
+
+	c.Ws.POST("/insts/object/:bk_obj_id/association/import", s.ImportInstAsst)
+
 	c.Ws.POST("/object/object/:bk_obj_id/export", s.ExportObject)
 
 	c.Ws.POST("/object/object/:bk_obj_id/import", s.ImportObject)
 
 	c.Ws.POST("/biz/export", s.ExportBiz)
 
+	c.Ws.POST("/biz/import", s.ImportBiz)
+
 	c.Ws.POST("/project/export", s.ExportProject)
 }
@@ -83,7 +83,24 @@ func (s *Service) LoginUser(c *gin.Context) {
 		c.HTML(200, "login.html", gin.H{
 			"error": defErr.CCError(common.CCErrWebNeedFillinUsernamePasswd).Error(),
 		})
+		return
 	}
+
+	// the login plugins like ldap verify the user name and password by themselves
+	userManger := user.NewUser(*s.Config, s.Engine, s.CacheCli, s.ApiCli)
+	supported, rawErr := userManger.VerifyPassword(c, userName, password)
+	if supported {
+		if rawErr != nil {
+			c.HTML(200, "login.html", gin.H{
+				"error": rawErr.ToCCError(defErr).Error(),
+			})
+			return
+		}
+		userManger.LoginUser(c)
+		c.Redirect(302, s.parseRedirectURL(c.Query("c_url"), rid))
+		return
+	}
+
 	userInfo, err := cc.String("webServer.session.userInfo")
 	if err != nil {
 		c.HTML(200, "login.html", gin.H{
@@ -108,7 +125,6 @@ func (s *Service) LoginUser(c *gin.Context) {
 			if err := session.Save(); err != nil {
 				blog.Warnf("save session failed, err: %s, rid: %s", err.Error(), rid)
 			}
-			userManger := user.NewUser(*s.Config, s.Engine, s.CacheCli, s.ApiCli)
 			userManger.LoginUser(c)
 			redirectURL := s.parseRedirectURL(c.Query("c_url"), rid)
 			c.Redirect(302, redirectURL)
@@ -121,6 +137,30 @@ func (s *Service) LoginUser(c *gin.Context) {
 	return
 }
 
+// LoginCallback handle the redirection back from the single sign-on system after the user logs in
+func (s *Service) LoginCallback(c *gin.Context) {
+	rid := httpheader.GetRid(c.Request.Header)
+	defErr := s.CCErr.CreateDefaultCCErrorIf(httpheader.GetLanguage(c.Request.Header))
+
+	userManger := user.NewUser(*s.Config, s.Engine, s.CacheCli, s.ApiCli)
+	redirectURL, rawErr := userManger.HandleCallback(c)
+	if rawErr != nil {
+		c.HTML(200, "login.html", gin.H{
+			"error": rawErr.ToCCError(defErr).Error(),
+		})
+		return
+	}
+
+	if !userManger.LoginUser(c) {
+		blog.Errorf("login user after single sign-on callback failed, rid: %s", rid)
+		c.HTML(200, "login.html", gin.H{
+			"error": defErr.CCErrorf(common.CCErrWebSSOLoginFailed, "login user failed").Error(),
+		})
+		return
+	}
+	c.Redirect(302, s.parseRedirectURL(redirectURL, rid))
+}
+
 func (s *Service) parseRedirectURL(redirectURL, rid string) string {
 	if redirectURL == "" {
 		return s.Config.Site.DomainUrl
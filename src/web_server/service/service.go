@@ -139,6 +139,7 @@ func (s *Service) initService(ws *gin.Engine) {
 	ws.GET("/login", s.Login)
 	ws.GET("/is_login", s.IsLogin)
 	ws.POST("/login", s.LoginUser)
+	ws.GET("/login/callback", s.LoginCallback)
 	ws.POST("/object/exportmany", s.BatchExportObject)
 	ws.POST("/object/importmany/analysis", s.BatchImportObjectAnalysis)
 	ws.POST("/object/importmany", s.BatchImportObject)
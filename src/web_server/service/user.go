@@ -127,6 +127,10 @@ func (s *Service) UserInfo(c *gin.Context) {
 	if ok {
 		resultData.Data.AvatarUrl = avatarUrl
 	}
+	defaultBizID, ok := session.Get(common.WEBSessionDefaultBizKey).(int64)
+	if ok {
+		resultData.Data.DefaultBizID = defaultBizID
+	}
 	iultiSupplier, ok := session.Get(common.WEBSessionMultiSupplierKey).(string)
 	if ok && common.LoginSystemMultiSupplierTrue == iultiSupplier {
 		resultData.Data.MultiSupplier = true // true